@@ -0,0 +1,192 @@
+/*
+Package api containing API initialization and API route handler
+*/
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/reyhanfikridz/ecom-account-service/internal/event"
+	"github.com/reyhanfikridz/ecom-account-service/internal/form"
+)
+
+// DeleteUserMeHandler handling route delete account of the logged in user,
+// confirmed by password (method: DELETE)
+//
+// Password sent as form-data, urlencoded, or JSON body.
+// The account erased after grace period, login before that cancel the deletion
+func (a *API) DeleteUserMeHandler(w http.ResponseWriter, r *http.Request) {
+	var responseContent map[string]any
+	var responseStatus int
+
+	// allow host
+	w.Header().Set("Access-Control-Allow-Origin", a.Config.FrontendURL)
+
+	// get password from request body
+	bodyErr := parseDeleteRequestBody(r)
+	password := r.FormValue("password")
+
+	// check token in request
+	tokenString := getRequestToken(r)
+	if bodyErr != nil { // if request body not valid
+		log.Println(strconv.Quote("DELETE /api/user/me/"), "400 BAD REQUEST")
+		responseContent = map[string]any{
+			"message": "Request body not valid",
+		}
+		responseStatus = 400
+	} else if strings.TrimSpace(tokenString) != "" { // if token exist
+		userSession, status, err := a.authenticateToken(tokenString)
+		if status == 200 && err == nil { // if token valid
+
+			// validate password
+			v := form.Validator{}
+			v.Required("password", password)
+			if validationErrs := v.Errors(); len(validationErrs) > 0 { // if password not exist
+				log.Println(strconv.Quote("DELETE /api/user/me/"), "422 UNPROCESSABLE ENTITY")
+				responseContent = getValidationErrorResponse(validationErrs)
+				responseStatus = 422
+			} else { // if password exist, schedule user deletion
+				user, status, err := a.Store.ScheduleUserDeletion(userSession.User.ID,
+					password, getRequestIP(r))
+				if status == 200 && err == nil { // if schedule user deletion success
+					// notify user, only logged if failed
+					err = a.Mailer.Send(user.Email, "Your account will be deleted",
+						"Your account is scheduled to be deleted at "+
+							user.DeletionScheduledAt.Format(time.RFC1123)+".\n\n"+
+							"Log in before that time to cancel the deletion.")
+					if err != nil {
+						log.Println(err.Error())
+					}
+
+					log.Println(strconv.Quote("DELETE /api/user/me/"), "200 SUCCESS")
+					responseContent = map[string]any{
+						"message":               "Account scheduled for deletion!",
+						"deletion_scheduled_at": user.DeletionScheduledAt,
+					}
+					responseStatus = 200
+				} else if status == 400 { // if password wrong
+					log.Println(strconv.Quote("DELETE /api/user/me/"), "400 BAD REQUEST")
+					responseContent = map[string]any{
+						"message": "Password invalid",
+					}
+					responseStatus = 400
+				} else if status == 423 { // if account or IP address locked
+					log.Println(strconv.Quote("DELETE /api/user/me/"), "423 LOCKED")
+					w.Header().Set("Retry-After",
+						a.getLoginRetryAfter(userSession.User.Email, getRequestIP(r)))
+					responseContent = map[string]any{
+						"message": "Account temporarily locked because of too many failed login attempts",
+						"code":    "account_locked",
+					}
+					responseStatus = 423
+				} else if status == 429 { // if still delayed after the last wrong password
+					log.Println(strconv.Quote("DELETE /api/user/me/"), "429 TOO MANY REQUESTS")
+					w.Header().Set("Retry-After",
+						a.getLoginRetryAfter(userSession.User.Email, getRequestIP(r)))
+					responseContent = map[string]any{
+						"message": "Too many failed login attempts, please try again later",
+						"code":    "login_delayed",
+					}
+					responseStatus = 429
+				} else { // if there's an error when schedule user deletion
+					log.Println(strconv.Quote("DELETE /api/user/me/"), "500 INTERNAL SERVER ERROR")
+					log.Println(err.Error())
+					responseContent = map[string]any{
+						"message": err.Error(),
+					}
+					responseStatus = 500
+				}
+			}
+
+		} else if status == 400 { // if token not valid
+			log.Println(strconv.Quote("DELETE /api/user/me/"), "400 BAD REQUEST")
+			responseContent = map[string]any{
+				"message": "Token not valid",
+			}
+			responseStatus = 400
+		} else { // if there's internal server error
+			log.Println(strconv.Quote("DELETE /api/user/me/"), "500 INTERNAL SERVER ERROR")
+			log.Println(err.Error())
+			responseContent = map[string]any{
+				"message": err.Error(),
+			}
+			responseStatus = 500
+		}
+	} else { // if token not exist
+		log.Println(strconv.Quote("DELETE /api/user/me/"), "400 BAD REQUEST")
+		responseContent = map[string]any{
+			"message": "Token empty/not found",
+		}
+		responseStatus = 400
+	}
+
+	response, marshalErr := json.Marshal(responseContent)
+	if marshalErr != nil {
+		log.Fatal("Error When Creating Response -> ", marshalErr)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(responseStatus)
+	w.Write(response)
+}
+
+// RunAccountErasure run ProcessAccountErasure every interval until
+// the program stopped, error only logged
+func (a *API) RunAccountErasure(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		err := a.ProcessAccountErasure()
+		if err != nil {
+			log.Println("Account erasure failed => " + err.Error())
+		}
+	}
+}
+
+// ProcessAccountErasure erase user accounts that already passed
+// its deletion time, then publish events not published yet
+//
+// Events kept until published, so event not lost when publisher not set
+// or failed, and published again by the next process
+func (a *API) ProcessAccountErasure() error {
+	count, err := a.Store.EraseScheduledUsers()
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		log.Println("Account erasure =>", count, "accounts erased")
+	}
+
+	if a.Events == nil {
+		return nil
+	}
+
+	events, err := a.Store.GetUnpublishedEvents(100)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range events {
+		err = a.Events.Publish(event.Event{
+			ID:         e.ID,
+			Type:       e.Type,
+			OccurredAt: e.CreatedAt,
+			Data:       json.RawMessage(e.Payload),
+		})
+		if err != nil {
+			return err
+		}
+
+		err = a.Store.MarkEventPublished(e.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
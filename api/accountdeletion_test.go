@@ -0,0 +1,246 @@
+/*
+Package api containing API initialization and API route handler
+*/
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/reyhanfikridz/ecom-account-service/internal/event"
+	"github.com/reyhanfikridz/ecom-account-service/internal/utils"
+)
+
+// TestDeleteUserMeHandlerAndProcessAccountErasure integration test
+// DeleteUserMeHandler and ProcessAccountErasure
+func TestDeleteUserMeHandlerAndProcessAccountErasure(t *testing.T) {
+	// initialize testing API
+	a, err := GetTestingAPI()
+	if err != nil {
+		t.Errorf("There's an error when getting testing API => " + err.Error())
+	}
+
+	// create user with its session
+	_, err = a.DB.Exec(`DELETE FROM account_user WHERE email = $1`, "testdeleteuserme@gmail.com")
+	if err != nil {
+		t.Errorf("There's an error when deleting delete user testing data " + err.Error())
+	}
+
+	hashedPassword, err := utils.HashPassword("test")
+	if err != nil {
+		t.Errorf("There's an error when hashing password => " + err.Error())
+	}
+
+	var userID int
+	err = a.DB.QueryRow(`
+		INSERT INTO account_user(email, password, full_name, address, phone_number, role)
+			VALUES($1, $2, $3, $4, $5, $6)
+			RETURNING id`,
+		"testdeleteuserme@gmail.com", hashedPassword, "test", "test", "test", "buyer").Scan(&userID)
+	if err != nil {
+		t.Errorf("There's an error when creating testing user data => " + err.Error())
+	}
+
+	token, err := utils.GenerateJWT(a.Config.JWTKeys, userID, "buyer",
+		a.Config.AccessTokenDuration)
+	if err != nil {
+		t.Errorf("There's an error when creating token " +
+			"for creating user session data => " +
+			err.Error())
+	}
+
+	_, err = a.DB.Exec(`
+		INSERT INTO account_usersession(token, account_user_id)
+			VALUES($1, $2)`, token, userID)
+	if err != nil {
+		t.Errorf("There's an error when creating testing user session data => " +
+			err.Error())
+	}
+
+	// initialize testing table, form data sent as multipart body
+	// if body type empty
+	testTable := []struct {
+		BodyType        string
+		FormData        map[string]string
+		ExpectedStatus  int
+		ExpectedBodyKey []string
+	}{
+		{
+			FormData: map[string]string{
+				"token":    "",
+				"password": "test",
+			},
+			ExpectedStatus:  400,
+			ExpectedBodyKey: []string{"message"},
+		},
+		{
+			FormData: map[string]string{
+				"token":    token,
+				"password": "",
+			},
+			ExpectedStatus:  422,
+			ExpectedBodyKey: []string{"message", "code", "errors"},
+		},
+		{
+			BodyType: "json",
+			FormData: map[string]string{
+				"token": token,
+			},
+			ExpectedStatus:  422,
+			ExpectedBodyKey: []string{"message", "code", "errors"},
+		},
+		{
+			BodyType: "invalid json",
+			FormData: map[string]string{
+				"token":    token,
+				"password": "test",
+			},
+			ExpectedStatus:  400,
+			ExpectedBodyKey: []string{"message"},
+		},
+		{
+			BodyType: "urlencoded",
+			FormData: map[string]string{
+				"token":    token,
+				"password": "wrongpassword",
+			},
+			ExpectedStatus:  400,
+			ExpectedBodyKey: []string{"message"},
+		},
+		{
+			BodyType: "json",
+			FormData: map[string]string{
+				"token":    token,
+				"password": "test",
+			},
+			ExpectedStatus:  200,
+			ExpectedBodyKey: []string{"message", "deletion_scheduled_at"},
+		},
+		{ // session revoked after deletion scheduled
+			BodyType: "urlencoded",
+			FormData: map[string]string{
+				"token":    token,
+				"password": "test",
+			},
+			ExpectedStatus:  400,
+			ExpectedBodyKey: []string{"message"},
+		},
+	}
+
+	// loop test in test table
+	for _, test := range testTable {
+		// transform form data to request body of the body type
+		var body bytes.Buffer
+		var contentType string
+		switch test.BodyType {
+		case "json", "invalid json":
+			err = json.NewEncoder(&body).Encode(test.FormData)
+			if err != nil {
+				t.Errorf("There's an error when creating json body => " + err.Error())
+			}
+			if test.BodyType == "invalid json" {
+				body.Truncate(body.Len() - 2)
+			}
+			contentType = "application/json"
+		case "urlencoded":
+			values := url.Values{}
+			for key, value := range test.FormData {
+				values.Set(key, value)
+			}
+			body.WriteString(values.Encode())
+			contentType = "application/x-www-form-urlencoded"
+		default:
+			w := multipart.NewWriter(&body)
+			for key, value := range test.FormData {
+				fw, err := w.CreateFormField(key)
+				if err != nil {
+					t.Errorf("There's an error when creating bytes buffer form data => " +
+						err.Error())
+				}
+
+				_, err = io.Copy(fw, strings.NewReader(value))
+				if err != nil {
+					t.Errorf("There's an error when creating bytes buffer form data => " +
+						err.Error())
+				}
+			}
+			w.Close()
+			contentType = w.FormDataContentType()
+		}
+
+		// create new request
+		req, err := http.NewRequest("DELETE", "/api/user/me/", &body)
+		if err != nil {
+			t.Errorf("There's an error when creating request API delete user me => " +
+				err.Error())
+		}
+		req.Header.Set("Content-Type", contentType)
+
+		// run request
+		response := httptest.NewRecorder()
+		a.Router.ServeHTTP(response, req)
+
+		// check response
+		if response.Code != test.ExpectedStatus {
+			t.Errorf("Expected status %d got %d (%s body)", test.ExpectedStatus, response.Code,
+				test.BodyType)
+		}
+
+		var responseData map[string]any
+		err = json.Unmarshal(response.Body.Bytes(), &responseData)
+		if err != nil {
+			t.Errorf("There's an error when unmarshal body response => " + err.Error())
+		}
+		for _, expectedKey := range test.ExpectedBodyKey {
+			if responseData[expectedKey] == nil {
+				t.Errorf("Expected key " + expectedKey + " empty/not found")
+			}
+		}
+	}
+
+	// make the deletion time passed, then erase the account
+	_, err = a.DB.Exec(`UPDATE account_user SET deletion_scheduled_at = $1 WHERE id = $2`,
+		time.Now().UTC().Add(-time.Minute), userID)
+	if err != nil {
+		t.Errorf("There's an error when updating deletion time => " + err.Error())
+	}
+
+	err = a.ProcessAccountErasure()
+	if err != nil {
+		t.Errorf("There's an error when processing account erasure => " + err.Error())
+	}
+
+	// check account deleted event published
+	e, ok := a.Events.(*event.MemoryPublisher).LastEvent(event.TypeAccountDeleted)
+	if !ok {
+		t.Errorf("Expected account deleted event published, but not published")
+	}
+
+	var data map[string]any
+	err = json.Unmarshal(e.Data, &data)
+	if err != nil {
+		t.Errorf("There's an error when unmarshal event data => " + err.Error())
+	}
+	if data["user_id"] != float64(userID) {
+		t.Errorf("Expected event user_id %d, but got %v", userID, data["user_id"])
+	}
+
+	// check personal data erased
+	var email, fullName string
+	err = a.DB.QueryRow(`SELECT email, full_name FROM account_user WHERE id = $1`,
+		userID).Scan(&email, &fullName)
+	if err != nil {
+		t.Errorf("There's an error when getting erased user => " + err.Error())
+	}
+	if email == "testdeleteuserme@gmail.com" || fullName != "" {
+		t.Errorf("Expected user personal data erased, but got email '" + email + "'")
+	}
+}
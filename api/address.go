@@ -0,0 +1,436 @@
+/*
+Package api containing API initialization and API route handler
+*/
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/reyhanfikridz/ecom-account-service/internal/form"
+	"github.com/reyhanfikridz/ecom-account-service/internal/model"
+)
+
+// GetAddressesHandler handling route get all addresses
+// of the logged in user (method: GET)
+func (a *API) GetAddressesHandler(w http.ResponseWriter, r *http.Request) {
+	var responseContent map[string]any
+	var responseStatus int
+
+	// allow host
+	w.Header().Set("Access-Control-Allow-Origin", a.Config.FrontendURL)
+
+	// check token in request
+	tokenString := getRequestToken(r)
+	if strings.TrimSpace(tokenString) != "" { // if token exist
+		userSession, status, err := a.authenticateToken(tokenString)
+		if status == 200 && err == nil { // if token valid
+			addresses, err := a.Store.GetAddresses(userSession.User.ID)
+			if err == nil { // if get addresses success
+				log.Println(strconv.Quote("GET /api/user/me/addresses/"), "200 SUCCESS")
+				responseContent = map[string]any{
+					"addresses": addresses,
+				}
+				responseStatus = 200
+			} else { // if there's an error when get addresses
+				log.Println(strconv.Quote("GET /api/user/me/addresses/"), "500 INTERNAL SERVER ERROR")
+				log.Println(err.Error())
+				responseContent = map[string]any{
+					"message": err.Error(),
+				}
+				responseStatus = 500
+			}
+
+		} else if status == 400 { // if token not valid
+			log.Println(strconv.Quote("GET /api/user/me/addresses/"), "400 BAD REQUEST")
+			responseContent = map[string]any{
+				"message": "Token not valid",
+			}
+			responseStatus = 400
+		} else { // if there's internal server error
+			log.Println(strconv.Quote("GET /api/user/me/addresses/"), "500 INTERNAL SERVER ERROR")
+			log.Println(err.Error())
+			responseContent = map[string]any{
+				"message": err.Error(),
+			}
+			responseStatus = 500
+		}
+	} else { // if token not exist
+		log.Println(strconv.Quote("GET /api/user/me/addresses/"), "400 BAD REQUEST")
+		responseContent = map[string]any{
+			"message": "Token empty/not found",
+		}
+		responseStatus = 400
+	}
+
+	response, marshalErr := json.Marshal(responseContent)
+	if marshalErr != nil {
+		log.Fatal("Error When Creating Response -> ", marshalErr)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(responseStatus)
+	w.Write(response)
+}
+
+// CreateAddressHandler handling route create address
+// of the logged in user (method: POST)
+//
+// The first address of the user become default shipping and billing address
+func (a *API) CreateAddressHandler(w http.ResponseWriter, r *http.Request) {
+	var responseContent map[string]any
+	var responseStatus int
+
+	// allow host
+	w.Header().Set("Access-Control-Allow-Origin", a.Config.FrontendURL)
+
+	// check token in request
+	tokenString := getRequestToken(r)
+	if strings.TrimSpace(tokenString) != "" { // if token exist
+		userSession, status, err := a.authenticateToken(tokenString)
+		if status == 200 && err == nil { // if token valid
+
+			// get and validate address from form-data
+			address, validationErrs := getAddressForm(r, model.Address{})
+			if len(validationErrs) == 0 { // if address form valid, create address
+				address.UserID = userSession.User.ID
+				address, status, err := a.Store.CreateAddress(address)
+				if status == 200 && err == nil { // if create address success
+					log.Println(strconv.Quote("POST /api/user/me/addresses/"), "201 CREATED")
+					responseContent = map[string]any{
+						"address": address,
+					}
+					responseStatus = 201
+				} else if status == 400 { // if maximum number of addresses reached
+					log.Println(strconv.Quote("POST /api/user/me/addresses/"), "400 BAD REQUEST")
+					responseContent = map[string]any{
+						"message": "Maximum " + strconv.Itoa(model.MaxAddressesPerUser) +
+							" addresses reached, please delete an address first",
+					}
+					responseStatus = 400
+				} else { // if there's an error when create address
+					log.Println(strconv.Quote("POST /api/user/me/addresses/"), "500 INTERNAL SERVER ERROR")
+					log.Println(err.Error())
+					responseContent = map[string]any{
+						"message": err.Error(),
+					}
+					responseStatus = 500
+				}
+			} else { // if address form not valid
+				log.Println(strconv.Quote("POST /api/user/me/addresses/"), "422 UNPROCESSABLE ENTITY")
+				responseContent = getValidationErrorResponse(validationErrs)
+				responseStatus = 422
+			}
+
+		} else if status == 400 { // if token not valid
+			log.Println(strconv.Quote("POST /api/user/me/addresses/"), "400 BAD REQUEST")
+			responseContent = map[string]any{
+				"message": "Token not valid",
+			}
+			responseStatus = 400
+		} else { // if there's internal server error
+			log.Println(strconv.Quote("POST /api/user/me/addresses/"), "500 INTERNAL SERVER ERROR")
+			log.Println(err.Error())
+			responseContent = map[string]any{
+				"message": err.Error(),
+			}
+			responseStatus = 500
+		}
+	} else { // if token not exist
+		log.Println(strconv.Quote("POST /api/user/me/addresses/"), "400 BAD REQUEST")
+		responseContent = map[string]any{
+			"message": "Token empty/not found",
+		}
+		responseStatus = 400
+	}
+
+	response, marshalErr := json.Marshal(responseContent)
+	if marshalErr != nil {
+		log.Fatal("Error When Creating Response -> ", marshalErr)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(responseStatus)
+	w.Write(response)
+}
+
+// GetAddressHandler handling route get an address
+// of the logged in user (method: GET)
+func (a *API) GetAddressHandler(w http.ResponseWriter, r *http.Request) {
+	var responseContent map[string]any
+	var responseStatus int
+
+	// allow host
+	w.Header().Set("Access-Control-Allow-Origin", a.Config.FrontendURL)
+
+	// check token in request
+	tokenString := getRequestToken(r)
+	if strings.TrimSpace(tokenString) != "" { // if token exist
+		userSession, status, err := a.authenticateToken(tokenString)
+		if status == 200 && err == nil { // if token valid
+			ID, _ := strconv.Atoi(mux.Vars(r)["id"])
+			address, err := a.Store.GetAddress(ID, userSession.User.ID)
+			if err == nil { // if get address success
+				log.Println(strconv.Quote("GET /api/user/me/addresses/{id}/"), "200 SUCCESS")
+				responseContent = map[string]any{
+					"address": address,
+				}
+				responseStatus = 200
+			} else if err == sql.ErrNoRows { // if address not exist
+				log.Println(strconv.Quote("GET /api/user/me/addresses/{id}/"), "404 NOT FOUND")
+				responseContent = map[string]any{
+					"message": "Address not found",
+				}
+				responseStatus = 404
+			} else { // if there's an error when get address
+				log.Println(strconv.Quote("GET /api/user/me/addresses/{id}/"),
+					"500 INTERNAL SERVER ERROR")
+				log.Println(err.Error())
+				responseContent = map[string]any{
+					"message": err.Error(),
+				}
+				responseStatus = 500
+			}
+
+		} else if status == 400 { // if token not valid
+			log.Println(strconv.Quote("GET /api/user/me/addresses/{id}/"), "400 BAD REQUEST")
+			responseContent = map[string]any{
+				"message": "Token not valid",
+			}
+			responseStatus = 400
+		} else { // if there's internal server error
+			log.Println(strconv.Quote("GET /api/user/me/addresses/{id}/"), "500 INTERNAL SERVER ERROR")
+			log.Println(err.Error())
+			responseContent = map[string]any{
+				"message": err.Error(),
+			}
+			responseStatus = 500
+		}
+	} else { // if token not exist
+		log.Println(strconv.Quote("GET /api/user/me/addresses/{id}/"), "400 BAD REQUEST")
+		responseContent = map[string]any{
+			"message": "Token empty/not found",
+		}
+		responseStatus = 400
+	}
+
+	response, marshalErr := json.Marshal(responseContent)
+	if marshalErr != nil {
+		log.Fatal("Error When Creating Response -> ", marshalErr)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(responseStatus)
+	w.Write(response)
+}
+
+// UpdateAddressHandler handling route partial update an address
+// of the logged in user (method: PATCH)
+//
+// Only sent field updated, the updated address validated as a whole
+func (a *API) UpdateAddressHandler(w http.ResponseWriter, r *http.Request) {
+	var responseContent map[string]any
+	var responseStatus int
+
+	// allow host
+	w.Header().Set("Access-Control-Allow-Origin", a.Config.FrontendURL)
+
+	// check token in request
+	tokenString := getRequestToken(r)
+	if strings.TrimSpace(tokenString) != "" { // if token exist
+		userSession, status, err := a.authenticateToken(tokenString)
+		if status == 200 && err == nil { // if token valid
+			ID, _ := strconv.Atoi(mux.Vars(r)["id"])
+			address, err := a.Store.GetAddress(ID, userSession.User.ID)
+
+			// get updated address from form-data and validate it
+			var validationErrs form.FieldErrors
+			if err == nil {
+				address, validationErrs = getAddressForm(r, address)
+			}
+
+			if err == nil && len(validationErrs) == 0 { // if address form valid, update address
+				address, err = a.Store.UpdateAddress(address)
+			}
+
+			if err == nil && len(validationErrs) == 0 { // if update address success
+				log.Println(strconv.Quote("PATCH /api/user/me/addresses/{id}/"), "200 SUCCESS")
+				responseContent = map[string]any{
+					"address": address,
+				}
+				responseStatus = 200
+			} else if err == sql.ErrNoRows { // if address not exist
+				log.Println(strconv.Quote("PATCH /api/user/me/addresses/{id}/"), "404 NOT FOUND")
+				responseContent = map[string]any{
+					"message": "Address not found",
+				}
+				responseStatus = 404
+			} else if err == nil { // if address form not valid
+				log.Println(strconv.Quote("PATCH /api/user/me/addresses/{id}/"),
+					"422 UNPROCESSABLE ENTITY")
+				responseContent = getValidationErrorResponse(validationErrs)
+				responseStatus = 422
+			} else { // if there's an error when update address
+				log.Println(strconv.Quote("PATCH /api/user/me/addresses/{id}/"),
+					"500 INTERNAL SERVER ERROR")
+				log.Println(err.Error())
+				responseContent = map[string]any{
+					"message": err.Error(),
+				}
+				responseStatus = 500
+			}
+
+		} else if status == 400 { // if token not valid
+			log.Println(strconv.Quote("PATCH /api/user/me/addresses/{id}/"), "400 BAD REQUEST")
+			responseContent = map[string]any{
+				"message": "Token not valid",
+			}
+			responseStatus = 400
+		} else { // if there's internal server error
+			log.Println(strconv.Quote("PATCH /api/user/me/addresses/{id}/"),
+				"500 INTERNAL SERVER ERROR")
+			log.Println(err.Error())
+			responseContent = map[string]any{
+				"message": err.Error(),
+			}
+			responseStatus = 500
+		}
+	} else { // if token not exist
+		log.Println(strconv.Quote("PATCH /api/user/me/addresses/{id}/"), "400 BAD REQUEST")
+		responseContent = map[string]any{
+			"message": "Token empty/not found",
+		}
+		responseStatus = 400
+	}
+
+	response, marshalErr := json.Marshal(responseContent)
+	if marshalErr != nil {
+		log.Fatal("Error When Creating Response -> ", marshalErr)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(responseStatus)
+	w.Write(response)
+}
+
+// DeleteAddressHandler handling route delete an address
+// of the logged in user (method: DELETE)
+func (a *API) DeleteAddressHandler(w http.ResponseWriter, r *http.Request) {
+	var responseContent map[string]any
+	var responseStatus int
+
+	// allow host
+	w.Header().Set("Access-Control-Allow-Origin", a.Config.FrontendURL)
+
+	// check token in request
+	tokenString := getRequestToken(r)
+	if strings.TrimSpace(tokenString) != "" { // if token exist
+		userSession, status, err := a.authenticateToken(tokenString)
+		if status == 200 && err == nil { // if token valid
+			ID, _ := strconv.Atoi(mux.Vars(r)["id"])
+			err := a.Store.DeleteAddress(ID, userSession.User.ID)
+			if err == nil { // if delete address success
+				log.Println(strconv.Quote("DELETE /api/user/me/addresses/{id}/"), "200 SUCCESS")
+				responseContent = map[string]any{
+					"message": "Address deleted!",
+				}
+				responseStatus = 200
+			} else if err == sql.ErrNoRows { // if address not exist
+				log.Println(strconv.Quote("DELETE /api/user/me/addresses/{id}/"), "404 NOT FOUND")
+				responseContent = map[string]any{
+					"message": "Address not found",
+				}
+				responseStatus = 404
+			} else { // if there's an error when delete address
+				log.Println(strconv.Quote("DELETE /api/user/me/addresses/{id}/"),
+					"500 INTERNAL SERVER ERROR")
+				log.Println(err.Error())
+				responseContent = map[string]any{
+					"message": err.Error(),
+				}
+				responseStatus = 500
+			}
+
+		} else if status == 400 { // if token not valid
+			log.Println(strconv.Quote("DELETE /api/user/me/addresses/{id}/"), "400 BAD REQUEST")
+			responseContent = map[string]any{
+				"message": "Token not valid",
+			}
+			responseStatus = 400
+		} else { // if there's internal server error
+			log.Println(strconv.Quote("DELETE /api/user/me/addresses/{id}/"),
+				"500 INTERNAL SERVER ERROR")
+			log.Println(err.Error())
+			responseContent = map[string]any{
+				"message": err.Error(),
+			}
+			responseStatus = 500
+		}
+	} else { // if token not exist
+		log.Println(strconv.Quote("DELETE /api/user/me/addresses/{id}/"), "400 BAD REQUEST")
+		responseContent = map[string]any{
+			"message": "Token empty/not found",
+		}
+		responseStatus = 400
+	}
+
+	response, marshalErr := json.Marshal(responseContent)
+	if marshalErr != nil {
+		log.Fatal("Error When Creating Response -> ", marshalErr)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(responseStatus)
+	w.Write(response)
+}
+
+// getAddressForm apply address fields sent in form-data to the address,
+// field not sent keep its value, then normalize and validate the address.
+// Return validation errors of default flags and the address
+func getAddressForm(r *http.Request, address model.Address) (model.Address, form.FieldErrors) {
+	fields := []struct {
+		Key   string
+		Value *string
+	}{
+		{Key: "label", Value: &address.Label},
+		{Key: "recipient_name", Value: &address.RecipientName},
+		{Key: "line1", Value: &address.Line1},
+		{Key: "line2", Value: &address.Line2},
+		{Key: "city", Value: &address.City},
+		{Key: "region", Value: &address.Region},
+		{Key: "postal_code", Value: &address.PostalCode},
+		{Key: "country_code", Value: &address.CountryCode},
+		{Key: "phone_number", Value: &address.PhoneNumber},
+	}
+	for _, field := range fields {
+		value := getFormValuePtr(r, field.Key)
+		if value != nil {
+			*field.Value = *value
+		}
+	}
+
+	v := form.Validator{}
+	flags := []struct {
+		Key   string
+		Value *bool
+	}{
+		{Key: "is_default_shipping", Value: &address.IsDefaultShipping},
+		{Key: "is_default_billing", Value: &address.IsDefaultBilling},
+	}
+	for _, flag := range flags {
+		value := getFormValuePtr(r, flag.Key)
+		if value == nil {
+			continue
+		}
+
+		isDefault, err := strconv.ParseBool(*value)
+		if err != nil {
+			v.Add(flag.Key, form.CodeInvalid, flag.Key+" not valid", nil)
+			continue
+		}
+		*flag.Value = isDefault
+	}
+
+	address = form.NormalizeAddress(address)
+	return address, append(v.Errors(), form.ValidateAddressForm(address)...)
+}
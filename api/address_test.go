@@ -0,0 +1,253 @@
+/*
+Package api containing API initialization and API route handler
+*/
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/reyhanfikridz/ecom-account-service/internal/utils"
+)
+
+// TestAddressHandlers integration test GetAddressesHandler,
+// CreateAddressHandler, GetAddressHandler, UpdateAddressHandler,
+// and DeleteAddressHandler
+func TestAddressHandlers(t *testing.T) {
+	// initialize testing API
+	a, err := GetTestingAPI()
+	if err != nil {
+		t.Errorf("There's an error when getting testing API => " + err.Error())
+	}
+
+	// create user with its session
+	_, err = a.DB.Exec(`DELETE FROM account_user WHERE email = $1`, "testaddresses@gmail.com")
+	if err != nil {
+		t.Errorf("There's an error when deleting address testing data " + err.Error())
+	}
+
+	hashedPassword, err := utils.HashPassword("test")
+	if err != nil {
+		t.Errorf("There's an error when hashing password => " + err.Error())
+	}
+
+	var userID int
+	err = a.DB.QueryRow(`
+		INSERT INTO account_user(email, password, full_name, address, phone_number, role)
+			VALUES($1, $2, $3, $4, $5, $6)
+			RETURNING id`,
+		"testaddresses@gmail.com", hashedPassword, "test", "", "test", "buyer").Scan(&userID)
+	if err != nil {
+		t.Errorf("There's an error when creating testing user data => " + err.Error())
+	}
+
+	token, err := utils.GenerateJWT(a.Config.JWTKeys, userID, "buyer",
+		a.Config.AccessTokenDuration)
+	if err != nil {
+		t.Errorf("There's an error when creating token " +
+			"for creating user session data => " +
+			err.Error())
+	}
+
+	_, err = a.DB.Exec(`
+		INSERT INTO account_usersession(token, account_user_id)
+			VALUES($1, $2)`, token, userID)
+	if err != nil {
+		t.Errorf("There's an error when creating testing user session data => " +
+			err.Error())
+	}
+
+	// initialize testing table, run in order,
+	// {id} in URL replaced by ID of the created address
+	testTable := []struct {
+		Method          string
+		URL             string
+		Token           string
+		FormData        map[string]string
+		ExpectedStatus  int
+		ExpectedBodyKey []string
+	}{
+		{
+			Method:          "GET",
+			URL:             "/api/user/me/addresses/",
+			Token:           "",
+			ExpectedStatus:  400,
+			ExpectedBodyKey: []string{"message"},
+		},
+		{
+			Method: "POST",
+			URL:    "/api/user/me/addresses/",
+			Token:  token,
+			FormData: map[string]string{
+				"recipient_name": "test",
+				"line1":          "1 Market St",
+				"city":           "San Francisco",
+				"postal_code":    "941",
+				"country_code":   "us",
+				"region":         "CA",
+			},
+			ExpectedStatus:  422,
+			ExpectedBodyKey: []string{"message", "code", "errors"},
+		},
+		{
+			Method: "POST",
+			URL:    "/api/user/me/addresses/",
+			Token:  token,
+			FormData: map[string]string{
+				"recipient_name":      "test",
+				"line1":               "1 Market St",
+				"city":                "San Francisco",
+				"postal_code":         "94105",
+				"country_code":        "us",
+				"region":              "CA",
+				"is_default_shipping": "maybe",
+			},
+			ExpectedStatus:  422,
+			ExpectedBodyKey: []string{"message", "code", "errors"},
+		},
+		{
+			Method: "POST",
+			URL:    "/api/user/me/addresses/",
+			Token:  token,
+			FormData: map[string]string{
+				"label":          "home",
+				"recipient_name": "test",
+				"line1":          "1 Market St",
+				"city":           "San Francisco",
+				"postal_code":    "94105",
+				"country_code":   "us",
+				"region":         "CA",
+			},
+			ExpectedStatus:  201,
+			ExpectedBodyKey: []string{"address"},
+		},
+		{
+			Method:          "GET",
+			URL:             "/api/user/me/addresses/",
+			Token:           token,
+			ExpectedStatus:  200,
+			ExpectedBodyKey: []string{"addresses"},
+		},
+		{
+			Method:          "GET",
+			URL:             "/api/user/me/addresses/{id}/",
+			Token:           token,
+			ExpectedStatus:  200,
+			ExpectedBodyKey: []string{"address"},
+		},
+		{
+			Method: "PATCH",
+			URL:    "/api/user/me/addresses/{id}/",
+			Token:  token,
+			FormData: map[string]string{
+				"postal_code": "ABC",
+			},
+			ExpectedStatus:  422,
+			ExpectedBodyKey: []string{"message", "code", "errors"},
+		},
+		{
+			Method: "PATCH",
+			URL:    "/api/user/me/addresses/{id}/",
+			Token:  token,
+			FormData: map[string]string{
+				"line2": "Suite 100",
+			},
+			ExpectedStatus:  200,
+			ExpectedBodyKey: []string{"address"},
+		},
+		{
+			Method:          "PATCH",
+			URL:             "/api/user/me/addresses/0/",
+			Token:           token,
+			FormData:        map[string]string{"line2": "Suite 100"},
+			ExpectedStatus:  404,
+			ExpectedBodyKey: []string{"message"},
+		},
+		{
+			Method:          "DELETE",
+			URL:             "/api/user/me/addresses/{id}/",
+			Token:           token,
+			ExpectedStatus:  200,
+			ExpectedBodyKey: []string{"message"},
+		},
+		{
+			Method:          "GET",
+			URL:             "/api/user/me/addresses/{id}/",
+			Token:           token,
+			ExpectedStatus:  404,
+			ExpectedBodyKey: []string{"message"},
+		},
+	}
+
+	// loop test in test table
+	addressID := 0
+	for _, test := range testTable {
+		// transform form data to bytes buffer
+		var bFormData bytes.Buffer
+		w := multipart.NewWriter(&bFormData)
+		for key, value := range test.FormData {
+			fw, err := w.CreateFormField(key)
+			if err != nil {
+				t.Errorf("There's an error when creating bytes buffer form data => " +
+					err.Error())
+			}
+
+			_, err = io.Copy(fw, strings.NewReader(value))
+			if err != nil {
+				t.Errorf("There's an error when creating bytes buffer form data => " +
+					err.Error())
+			}
+		}
+		w.Close()
+
+		// create new request
+		URL := strings.Replace(test.URL, "{id}", strconv.Itoa(addressID), 1)
+		req, err := http.NewRequest(test.Method, URL, &bFormData)
+		if err != nil {
+			t.Errorf("There's an error when creating request " + URL + " => " + err.Error())
+		}
+		req.Header.Set("Content-Type", w.FormDataContentType())
+		if test.Token != "" {
+			req.Header.Set("Authorization", "Bearer "+test.Token)
+		}
+
+		// run request
+		response := httptest.NewRecorder()
+		a.Router.ServeHTTP(response, req)
+
+		// check response
+		if response.Code != test.ExpectedStatus {
+			t.Errorf("Expected status %d got %d (%s %s)", test.ExpectedStatus, response.Code,
+				test.Method, URL)
+		}
+
+		var responseData map[string]any
+		err = json.Unmarshal(response.Body.Bytes(), &responseData)
+		if err != nil {
+			t.Errorf("There's an error when unmarshal body response => " + err.Error())
+		}
+		for _, expectedKey := range test.ExpectedBodyKey {
+			if responseData[expectedKey] == nil {
+				t.Errorf("Expected key " + expectedKey + " empty/not found")
+			}
+		}
+
+		// save created address ID, the first address is default address
+		if test.Method == "POST" && response.Code == 201 {
+			address, _ := responseData["address"].(map[string]any)
+			ID, _ := address["id"].(float64)
+			addressID = int(ID)
+
+			if address["country_code"] != "US" || address["is_default_shipping"] != true {
+				t.Errorf("Expected normalized default address, but got %v", address)
+			}
+		}
+	}
+}
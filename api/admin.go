@@ -0,0 +1,185 @@
+/*
+Package api containing API initialization and API route handler
+*/
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/reyhanfikridz/ecom-account-service/internal/form"
+	"github.com/reyhanfikridz/ecom-account-service/internal/model"
+)
+
+// PromoteJWTKeyHandler handling route promote jwt signing key
+// from key directory to be the active key (method: POST)
+//
+// The previous active key is retired but still valid for verification
+// until all tokens signed by it expired. The key is recorded as promoted
+// in key directory, so another replica can verify tokens signed by it.
+func (a *API) PromoteJWTKeyHandler(w http.ResponseWriter, r *http.Request) {
+	var responseContent map[string]any
+	var responseStatus int
+
+	// allow host
+	w.Header().Set("Access-Control-Allow-Origin", a.Config.FrontendURL)
+
+	// check token in request
+	tokenString := getRequestToken(r)
+	if strings.TrimSpace(tokenString) != "" { // if token exist
+		userSession, status, err := a.authenticateToken(tokenString)
+		if status == 200 && err == nil {
+			status, err = a.authorizePermission(userSession.User, model.PermissionPromoteJWTKey)
+		}
+		if status == 200 && err == nil { // if user has permission
+
+			// promote key by key ID
+			keyID := r.FormValue("kid")
+			if strings.TrimSpace(keyID) != "" { // if key ID exist
+				key, err := a.Config.JWTKeys.PromoteFromDir(keyID)
+				if err == nil { // if promote success
+					log.Println(strconv.Quote("POST /api/admin/jwt-keys/promote/"), "200 SUCCESS")
+					responseContent = map[string]any{
+						"message": "JWT key promoted!",
+						"kid":     key.ID,
+					}
+					responseStatus = 200
+				} else { // if key not valid or not found
+					log.Println(strconv.Quote("POST /api/admin/jwt-keys/promote/"), "400 BAD REQUEST")
+					log.Println(err.Error())
+					responseContent = map[string]any{
+						"message": "There's an error when promote JWT key => " + err.Error(),
+					}
+					responseStatus = 400
+				}
+			} else { // if key ID not exist
+				log.Println(strconv.Quote("POST /api/admin/jwt-keys/promote/"), "422 UNPROCESSABLE ENTITY")
+				v := form.Validator{}
+				v.Required("kid", keyID)
+				responseContent = getValidationErrorResponse(v.Errors())
+				responseStatus = 422
+			}
+
+		} else if status == 403 { // if user doesn't have permission
+			log.Println(strconv.Quote("POST /api/admin/jwt-keys/promote/"), "403 FORBIDDEN")
+			responseContent = map[string]any{
+				"message": "Forbidden",
+			}
+			responseStatus = 403
+		} else if status == 400 { // if token not valid
+			log.Println(strconv.Quote("POST /api/admin/jwt-keys/promote/"), "400 BAD REQUEST")
+			responseContent = map[string]any{
+				"message": "Token not valid",
+			}
+			responseStatus = 400
+		} else { // if there's internal server error
+			log.Println(strconv.Quote("POST /api/admin/jwt-keys/promote/"), "500 INTERNAL SERVER ERROR")
+			log.Println(err.Error())
+			responseContent = map[string]any{
+				"message": err.Error(),
+			}
+			responseStatus = 500
+		}
+	} else { // if token not exist
+		log.Println(strconv.Quote("POST /api/admin/jwt-keys/promote/"), "400 BAD REQUEST")
+		responseContent = map[string]any{
+			"message": "Token empty/not found",
+		}
+		responseStatus = 400
+	}
+
+	response, marshalErr := json.Marshal(responseContent)
+	if marshalErr != nil {
+		log.Fatal("Error When Creating Response -> ", marshalErr)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(responseStatus)
+	w.Write(response)
+}
+
+// ClearLoginLockoutHandler handling route clear failed login attempts
+// (including lockout) of an account email and/or IP address (method: POST)
+func (a *API) ClearLoginLockoutHandler(w http.ResponseWriter, r *http.Request) {
+	var responseContent map[string]any
+	var responseStatus int
+
+	// allow host
+	w.Header().Set("Access-Control-Allow-Origin", a.Config.FrontendURL)
+
+	// check token in request
+	tokenString := getRequestToken(r)
+	if strings.TrimSpace(tokenString) != "" { // if token exist
+		userSession, status, err := a.authenticateToken(tokenString)
+		if status == 200 && err == nil {
+			status, err = a.authorizePermission(userSession.User, model.PermissionClearLoginLockout)
+		}
+		if status == 200 && err == nil { // if user has permission
+
+			// clear login failures by email and/or IP address
+			email := r.FormValue("email")
+			IPAddress := r.FormValue("ip_address")
+			if strings.TrimSpace(email) != "" || strings.TrimSpace(IPAddress) != "" { // if email or IP address exist
+				isCleared, err := a.Store.ClearLoginFailures(email, IPAddress)
+				if err == nil { // if clear login failures success
+					log.Println(strconv.Quote("POST /api/admin/login-lockouts/clear/"), "200 SUCCESS")
+					responseContent = map[string]any{
+						"message":    "Login lockout cleared!",
+						"is_cleared": isCleared,
+					}
+					responseStatus = 200
+				} else { // if there's an error when clear login failures
+					log.Println(strconv.Quote("POST /api/admin/login-lockouts/clear/"), "500 INTERNAL SERVER ERROR")
+					log.Println(err.Error())
+					responseContent = map[string]any{
+						"message": err.Error(),
+					}
+					responseStatus = 500
+				}
+			} else { // if email and IP address not exist
+				log.Println(strconv.Quote("POST /api/admin/login-lockouts/clear/"), "422 UNPROCESSABLE ENTITY")
+				v := form.Validator{}
+				v.Add("", form.CodeRequired, "email and ip_address empty/not found",
+					map[string]any{"fields": []string{"email", "ip_address"}})
+				responseContent = getValidationErrorResponse(v.Errors())
+				responseStatus = 422
+			}
+
+		} else if status == 403 { // if user doesn't have permission
+			log.Println(strconv.Quote("POST /api/admin/login-lockouts/clear/"), "403 FORBIDDEN")
+			responseContent = map[string]any{
+				"message": "Forbidden",
+			}
+			responseStatus = 403
+		} else if status == 400 { // if token not valid
+			log.Println(strconv.Quote("POST /api/admin/login-lockouts/clear/"), "400 BAD REQUEST")
+			responseContent = map[string]any{
+				"message": "Token not valid",
+			}
+			responseStatus = 400
+		} else { // if there's internal server error
+			log.Println(strconv.Quote("POST /api/admin/login-lockouts/clear/"), "500 INTERNAL SERVER ERROR")
+			log.Println(err.Error())
+			responseContent = map[string]any{
+				"message": err.Error(),
+			}
+			responseStatus = 500
+		}
+	} else { // if token not exist
+		log.Println(strconv.Quote("POST /api/admin/login-lockouts/clear/"), "400 BAD REQUEST")
+		responseContent = map[string]any{
+			"message": "Token empty/not found",
+		}
+		responseStatus = 400
+	}
+
+	response, marshalErr := json.Marshal(responseContent)
+	if marshalErr != nil {
+		log.Fatal("Error When Creating Response -> ", marshalErr)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(responseStatus)
+	w.Write(response)
+}
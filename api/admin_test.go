@@ -0,0 +1,372 @@
+/*
+Package api containing API initialization and API route handler
+*/
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/reyhanfikridz/ecom-account-service/internal/config"
+	"github.com/reyhanfikridz/ecom-account-service/internal/utils"
+)
+
+// TestPromoteJWTKeyHandler test PromoteJWTKeyHandler
+func TestPromoteJWTKeyHandler(t *testing.T) {
+	// use jwt keys from testing key directory
+	dir := t.TempDir()
+	for _, ID := range []string{"2022-09", "2022-10"} {
+		err := os.WriteFile(filepath.Join(dir, ID+".key"), []byte(ID), 0600)
+		if err != nil {
+			t.Errorf("There's an error when writing secret key file => " + err.Error())
+		}
+	}
+
+	c := testConfig
+	keys, err := config.NewJWTKeyRingFromDir(dir, "2022-09", "HS256", time.Hour)
+	if err != nil {
+		t.Errorf("There's an error when creating jwt key ring => " + err.Error())
+	}
+	c.JWTKeys = keys
+
+	// initialize testing API
+	a, err := GetTestingAPIWithConfig(c)
+	if err != nil {
+		t.Errorf("There's an error when getting testing API => " + err.Error())
+	}
+
+	// create admin and non admin user with its session
+	tokens := map[string]string{}
+	for _, role := range []string{"admin", "buyer"} {
+		email := "testpromote" + role + "@gmail.com"
+		_, err = a.DB.Exec(`DELETE FROM account_user WHERE email = $1`, email)
+		if err != nil {
+			t.Errorf("There's an error when deleting promote testing data " + err.Error())
+		}
+
+		hashedPassword, err := utils.HashPassword("test")
+		if err != nil {
+			t.Errorf("There's an error when hashing password => " + err.Error())
+		}
+
+		var userID int
+		err = a.DB.QueryRow(`
+			INSERT INTO account_user(email, password, full_name, address, phone_number, role)
+				VALUES($1, $2, $3, $4, $5, $6)
+				RETURNING id`,
+			email, hashedPassword, "test", "test", "test", role).Scan(&userID)
+		if err != nil {
+			t.Errorf("There's an error when creating testing user data => " +
+				err.Error())
+		}
+
+		token, err := utils.GenerateJWT(a.Config.JWTKeys, userID, role,
+			a.Config.AccessTokenDuration)
+		if err != nil {
+			t.Errorf("There's an error when creating token " +
+				"for creating user session data => " +
+				err.Error())
+		}
+
+		_, err = a.DB.Exec(`
+			INSERT INTO account_usersession(token, account_user_id)
+				VALUES($1, $2)`, token, userID)
+		if err != nil {
+			t.Errorf("There's an error when creating testing user session data => " +
+				err.Error())
+		}
+
+		tokens[role] = token
+	}
+
+	// initialize testing table
+	testTable := []struct {
+		FormData        map[string]io.Reader
+		ExpectedStatus  int
+		ExpectedBodyKey []string
+	}{
+		{
+			FormData: map[string]io.Reader{
+				"token": strings.NewReader(tokens["buyer"]),
+				"kid":   strings.NewReader("2022-10"),
+			},
+			ExpectedStatus:  403,
+			ExpectedBodyKey: []string{"message"},
+		},
+		{
+			FormData: map[string]io.Reader{
+				"token": strings.NewReader(tokens["admin"]),
+				"kid":   strings.NewReader(""),
+			},
+			ExpectedStatus:  422,
+			ExpectedBodyKey: []string{"message", "code", "errors"},
+		},
+		{
+			FormData: map[string]io.Reader{
+				"token": strings.NewReader(tokens["admin"]),
+				"kid":   strings.NewReader("2022-11"),
+			},
+			ExpectedStatus:  400,
+			ExpectedBodyKey: []string{"message"},
+		},
+		{
+			FormData: map[string]io.Reader{
+				"token": strings.NewReader(tokens["admin"]),
+				"kid":   strings.NewReader("2022-10"),
+			},
+			ExpectedStatus:  200,
+			ExpectedBodyKey: []string{"message", "kid"},
+		},
+		{
+			FormData: map[string]io.Reader{
+				"token": strings.NewReader(""),
+				"kid":   strings.NewReader("2022-10"),
+			},
+			ExpectedStatus:  400,
+			ExpectedBodyKey: []string{"message"},
+		},
+	}
+
+	// loop test in test table
+	for _, test := range testTable {
+		// transform form data to bytes buffer
+		var bFormData bytes.Buffer
+		w := multipart.NewWriter(&bFormData)
+		for key, r := range test.FormData {
+			fw, err := w.CreateFormField(key)
+			if err != nil {
+				t.Errorf("There's an error when creating bytes buffer form data => " +
+					err.Error())
+			}
+
+			_, err = io.Copy(fw, r)
+			if err != nil {
+				t.Errorf("There's an error when creating bytes buffer form data => " +
+					err.Error())
+			}
+		}
+		w.Close()
+
+		// create new request
+		req, err := http.NewRequest("POST", "/api/admin/jwt-keys/promote/", &bFormData)
+		if err != nil {
+			t.Errorf("There's an error when creating request API promote jwt key => " +
+				err.Error())
+		}
+		req.Header.Set("Content-Type", w.FormDataContentType())
+
+		// run request
+		response := httptest.NewRecorder()
+		a.Router.ServeHTTP(response, req)
+
+		// check response
+		if response.Code != test.ExpectedStatus {
+			t.Errorf("Expected status %d got %d", test.ExpectedStatus, response.Code)
+		}
+
+		var responseData map[string]any
+		err = json.Unmarshal(response.Body.Bytes(), &responseData)
+		if err != nil {
+			t.Errorf("There's an error when unmarshal body response => " + err.Error())
+		}
+		for _, expectedKey := range test.ExpectedBodyKey {
+			if responseData[expectedKey] == nil {
+				t.Errorf("Expected key " + expectedKey + " empty/not found")
+			}
+		}
+	}
+
+	// check result
+	if a.Config.JWTKeys.ActiveKey().ID != "2022-10" {
+		t.Errorf("Expected active key '2022-10', but got '%s'",
+			a.Config.JWTKeys.ActiveKey().ID)
+	}
+
+	if utils.ValidateJWT(a.Config.JWTKeys, tokens["admin"]) == nil {
+		t.Errorf("Expected token signed by retired key valid, but got invalid")
+	}
+}
+
+// TestClearLoginLockoutHandler integration test LoginHandler lockout
+// and ClearLoginLockoutHandler
+func TestClearLoginLockoutHandler(t *testing.T) {
+	// initialize testing API, lock account after 2 failed login
+	c := testConfig
+	c.LoginFailureThreshold = 2
+
+	a, err := GetTestingAPIWithConfig(c)
+	if err != nil {
+		t.Errorf("There's an error when getting testing API => " + err.Error())
+	}
+
+	// create admin and locked user
+	for _, role := range []string{"admin", "buyer"} {
+		email := "testlockout" + role + "@gmail.com"
+		_, err = a.DB.Exec(`DELETE FROM account_user WHERE email = $1`, email)
+		if err != nil {
+			t.Errorf("There's an error when deleting lockout testing data " + err.Error())
+		}
+
+		hashedPassword, err := utils.HashPassword("test")
+		if err != nil {
+			t.Errorf("There's an error when hashing password => " + err.Error())
+		}
+
+		_, err = a.DB.Exec(`
+			INSERT INTO account_user(email, password, full_name, address, phone_number, role)
+				VALUES($1, $2, $3, $4, $5, $6)`,
+			email, hashedPassword, "test", "test", "test", role)
+		if err != nil {
+			t.Errorf("There's an error when creating testing user data => " +
+				err.Error())
+		}
+	}
+
+	// initialize testing table,
+	// "{token}" replaced by token of the last logged in user
+	testTable := []struct {
+		URL             string
+		FormData        map[string]string
+		ExpectedStatus  int
+		ExpectedBodyKey []string
+	}{
+		{
+			URL: "/api/login/",
+			FormData: map[string]string{
+				"email":    "testlockoutadmin@gmail.com",
+				"password": "test",
+			},
+			ExpectedStatus:  200,
+			ExpectedBodyKey: []string{"message", "token"},
+		},
+		{
+			URL: "/api/login/",
+			FormData: map[string]string{
+				"email":    "testlockoutbuyer@gmail.com",
+				"password": "wrong",
+			},
+			ExpectedStatus:  400,
+			ExpectedBodyKey: []string{"message"},
+		},
+		{
+			URL: "/api/login/",
+			FormData: map[string]string{
+				"email":    "testlockoutbuyer@gmail.com",
+				"password": "wrong",
+			},
+			ExpectedStatus:  400,
+			ExpectedBodyKey: []string{"message"},
+		},
+		{
+			URL: "/api/login/",
+			FormData: map[string]string{
+				"email":    "testlockoutbuyer@gmail.com",
+				"password": "test",
+			},
+			ExpectedStatus:  423,
+			ExpectedBodyKey: []string{"message", "code"},
+		},
+		{
+			URL: "/api/admin/login-lockouts/clear/",
+			FormData: map[string]string{
+				"token": "{token}",
+			},
+			ExpectedStatus:  422,
+			ExpectedBodyKey: []string{"message", "code", "errors"},
+		},
+		{
+			URL: "/api/admin/login-lockouts/clear/",
+			FormData: map[string]string{
+				"token": "{token}",
+				"email": "testlockoutbuyer@gmail.com",
+			},
+			ExpectedStatus:  200,
+			ExpectedBodyKey: []string{"message", "is_cleared"},
+		},
+		{
+			URL: "/api/login/",
+			FormData: map[string]string{
+				"email":    "testlockoutbuyer@gmail.com",
+				"password": "test",
+			},
+			ExpectedStatus:  200,
+			ExpectedBodyKey: []string{"message", "token"},
+		},
+		{
+			URL: "/api/admin/login-lockouts/clear/",
+			FormData: map[string]string{
+				"token": "{token}",
+				"email": "testlockoutbuyer@gmail.com",
+			},
+			ExpectedStatus:  403,
+			ExpectedBodyKey: []string{"message"},
+		},
+	}
+
+	// loop test in test table
+	token := ""
+	for _, test := range testTable {
+		// transform form data to bytes buffer
+		var bFormData bytes.Buffer
+		w := multipart.NewWriter(&bFormData)
+		for key, value := range test.FormData {
+			fw, err := w.CreateFormField(key)
+			if err != nil {
+				t.Errorf("There's an error when creating bytes buffer form data => " +
+					err.Error())
+			}
+
+			_, err = io.Copy(fw, strings.NewReader(strings.ReplaceAll(value, "{token}", token)))
+			if err != nil {
+				t.Errorf("There's an error when creating bytes buffer form data => " +
+					err.Error())
+			}
+		}
+		w.Close()
+
+		// create new request
+		req, err := http.NewRequest("POST", test.URL, &bFormData)
+		if err != nil {
+			t.Errorf("There's an error when creating request API " + test.URL + " => " +
+				err.Error())
+		}
+		req.Header.Set("Content-Type", w.FormDataContentType())
+
+		// run request
+		response := httptest.NewRecorder()
+		a.Router.ServeHTTP(response, req)
+
+		// check response
+		if response.Code != test.ExpectedStatus {
+			t.Errorf("Expected status %d got %d (%s)", test.ExpectedStatus, response.Code, test.URL)
+		}
+
+		if response.Code == 423 && response.Header().Get("Retry-After") == "" {
+			t.Errorf("Expected Retry-After header, but got empty")
+		}
+
+		var responseData map[string]any
+		err = json.Unmarshal(response.Body.Bytes(), &responseData)
+		if err != nil {
+			t.Errorf("There's an error when unmarshal body response => " + err.Error())
+		}
+		for _, expectedKey := range test.ExpectedBodyKey {
+			if responseData[expectedKey] == nil {
+				t.Errorf("Expected key " + expectedKey + " empty/not found")
+			}
+		}
+
+		if value, ok := responseData["token"].(string); ok {
+			token = value
+		}
+	}
+}
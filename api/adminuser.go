@@ -0,0 +1,505 @@
+/*
+Package api containing API initialization and API route handler
+*/
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/reyhanfikridz/ecom-account-service/internal/form"
+	"github.com/reyhanfikridz/ecom-account-service/internal/model"
+)
+
+// GetAdminUsersHandler handling route get users for admin
+// with search, filter, sort, and cursor pagination (method: GET)
+//
+// Query params: q (prefix of email or full name), role, status,
+// created_from and created_to (RFC 3339 or YYYY-MM-DD),
+// sort (created_at, email, full_name, prefix "-" for descending),
+// cursor (next_cursor of the previous page), and limit
+func (a *API) GetAdminUsersHandler(w http.ResponseWriter, r *http.Request) {
+	var responseContent map[string]any
+	var responseStatus int
+
+	// allow host
+	w.Header().Set("Access-Control-Allow-Origin", a.Config.FrontendURL)
+
+	// check token in request
+	tokenString := getRequestToken(r)
+	if strings.TrimSpace(tokenString) != "" { // if token exist
+		userSession, status, err := a.authenticateToken(tokenString)
+		if status == 200 && err == nil {
+			status, err = a.authorizePermission(userSession.User, model.PermissionReadUser)
+		}
+		if status == 200 && err == nil { // if user has permission
+
+			// get user list filter from query params
+			f, validationErrs := getUserListFilter(r)
+			if len(validationErrs) == 0 { // if filter valid, get users
+				users, nextCursor, status, err := a.Store.GetUsers(f)
+				if status == 200 && err == nil { // if get users success
+					log.Println(strconv.Quote("GET /api/admin/users/"), "200 SUCCESS")
+					for i := range users {
+						users[i].Password = "" // makes password empty for security purpose
+					}
+					responseContent = map[string]any{
+						"users":       users,
+						"next_cursor": nextCursor,
+					}
+					responseStatus = 200
+				} else if status == 400 { // if sort or cursor not valid
+					log.Println(strconv.Quote("GET /api/admin/users/"), "422 UNPROCESSABLE ENTITY")
+					v := form.Validator{}
+					v.Add("", form.CodeInvalid, "sort or cursor not valid",
+						map[string]any{"fields": []string{"sort", "cursor"}})
+					responseContent = getValidationErrorResponse(v.Errors())
+					responseStatus = 422
+				} else { // if there's an error when get users
+					log.Println(strconv.Quote("GET /api/admin/users/"), "500 INTERNAL SERVER ERROR")
+					log.Println(err.Error())
+					responseContent = map[string]any{
+						"message": err.Error(),
+					}
+					responseStatus = 500
+				}
+			} else { // if filter not valid
+				log.Println(strconv.Quote("GET /api/admin/users/"), "422 UNPROCESSABLE ENTITY")
+				responseContent = getValidationErrorResponse(validationErrs)
+				responseStatus = 422
+			}
+
+		} else if status == 403 { // if user doesn't have permission
+			log.Println(strconv.Quote("GET /api/admin/users/"), "403 FORBIDDEN")
+			responseContent = map[string]any{
+				"message": "Forbidden",
+			}
+			responseStatus = 403
+		} else if status == 400 { // if token not valid
+			log.Println(strconv.Quote("GET /api/admin/users/"), "400 BAD REQUEST")
+			responseContent = map[string]any{
+				"message": "Token not valid",
+			}
+			responseStatus = 400
+		} else { // if there's internal server error
+			log.Println(strconv.Quote("GET /api/admin/users/"), "500 INTERNAL SERVER ERROR")
+			log.Println(err.Error())
+			responseContent = map[string]any{
+				"message": err.Error(),
+			}
+			responseStatus = 500
+		}
+	} else { // if token not exist
+		log.Println(strconv.Quote("GET /api/admin/users/"), "400 BAD REQUEST")
+		responseContent = map[string]any{
+			"message": "Token empty/not found",
+		}
+		responseStatus = 400
+	}
+
+	response, marshalErr := json.Marshal(responseContent)
+	if marshalErr != nil {
+		log.Fatal("Error When Creating Response -> ", marshalErr)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(responseStatus)
+	w.Write(response)
+}
+
+// SuspendUserHandler handling route suspend a user with its reason
+// and optional expiry (until), all sessions of the user
+// are logged out (method: POST)
+func (a *API) SuspendUserHandler(w http.ResponseWriter, r *http.Request) {
+	a.handleSetUserStatus(w, r, "POST /api/admin/users/{id}/suspend/",
+		model.UserStatusSuspended, "User suspended!")
+}
+
+// ReactivateUserHandler handling route reactivate
+// a suspended user, only suspended user can be reactivated (method: POST)
+func (a *API) ReactivateUserHandler(w http.ResponseWriter, r *http.Request) {
+	a.handleSetUserStatus(w, r, "POST /api/admin/users/{id}/reactivate/",
+		model.UserStatusActive, "User reactivated!")
+}
+
+// DeleteUserHandler handling route soft delete a user, the email of the user
+// still registered until deleted user retention passed (method: DELETE)
+func (a *API) DeleteUserHandler(w http.ResponseWriter, r *http.Request) {
+	a.handleSetUserStatus(w, r, "DELETE /api/admin/users/{id}/",
+		model.UserStatusDeleted, "User deleted!")
+}
+
+// LogoutUserHandler handling route force logout a user
+// from all sessions (method: POST)
+func (a *API) LogoutUserHandler(w http.ResponseWriter, r *http.Request) {
+	var responseContent map[string]any
+	var responseStatus int
+
+	// allow host
+	w.Header().Set("Access-Control-Allow-Origin", a.Config.FrontendURL)
+
+	// check token in request
+	tokenString := getRequestToken(r)
+	if strings.TrimSpace(tokenString) != "" { // if token exist
+		userSession, status, err := a.authenticateToken(tokenString)
+		if status == 200 && err == nil {
+			status, err = a.authorizePermission(userSession.User, model.PermissionWriteUser)
+		}
+		if status == 200 && err == nil { // if user has permission
+
+			// get user by ID, route only match numeric ID
+			ID, _ := strconv.Atoi(mux.Vars(r)["id"])
+			_, err := a.Store.GetUser("", ID)
+			if err == nil {
+				err = a.Store.DeleteUserSessions(ID)
+			}
+
+			if err == nil { // if delete user sessions success
+				log.Println(strconv.Quote("POST /api/admin/users/{id}/logout/"), "200 SUCCESS")
+				responseContent = map[string]any{
+					"message": "User logged out from all sessions!",
+				}
+				responseStatus = 200
+			} else if err == sql.ErrNoRows { // if user not found
+				log.Println(strconv.Quote("POST /api/admin/users/{id}/logout/"), "404 NOT FOUND")
+				responseContent = map[string]any{
+					"message": "User not found",
+				}
+				responseStatus = 404
+			} else { // if there's an error when delete user sessions
+				log.Println(strconv.Quote("POST /api/admin/users/{id}/logout/"), "500 INTERNAL SERVER ERROR")
+				log.Println(err.Error())
+				responseContent = map[string]any{
+					"message": err.Error(),
+				}
+				responseStatus = 500
+			}
+
+		} else if status == 403 { // if user doesn't have permission
+			log.Println(strconv.Quote("POST /api/admin/users/{id}/logout/"), "403 FORBIDDEN")
+			responseContent = map[string]any{
+				"message": "Forbidden",
+			}
+			responseStatus = 403
+		} else if status == 400 { // if token not valid
+			log.Println(strconv.Quote("POST /api/admin/users/{id}/logout/"), "400 BAD REQUEST")
+			responseContent = map[string]any{
+				"message": "Token not valid",
+			}
+			responseStatus = 400
+		} else { // if there's internal server error
+			log.Println(strconv.Quote("POST /api/admin/users/{id}/logout/"), "500 INTERNAL SERVER ERROR")
+			log.Println(err.Error())
+			responseContent = map[string]any{
+				"message": err.Error(),
+			}
+			responseStatus = 500
+		}
+	} else { // if token not exist
+		log.Println(strconv.Quote("POST /api/admin/users/{id}/logout/"), "400 BAD REQUEST")
+		responseContent = map[string]any{
+			"message": "Token empty/not found",
+		}
+		responseStatus = 400
+	}
+
+	response, marshalErr := json.Marshal(responseContent)
+	if marshalErr != nil {
+		log.Fatal("Error When Creating Response -> ", marshalErr)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(responseStatus)
+	w.Write(response)
+}
+
+// SetUserRoleHandler handling route change role of a user,
+// the role must be in role catalog (method: POST)
+func (a *API) SetUserRoleHandler(w http.ResponseWriter, r *http.Request) {
+	var responseContent map[string]any
+	var responseStatus int
+
+	// allow host
+	w.Header().Set("Access-Control-Allow-Origin", a.Config.FrontendURL)
+
+	// check token in request
+	tokenString := getRequestToken(r)
+	if strings.TrimSpace(tokenString) != "" { // if token exist
+		userSession, status, err := a.authenticateToken(tokenString)
+		if status == 200 && err == nil {
+			status, err = a.authorizePermission(userSession.User, model.PermissionWriteUser)
+		}
+		if status == 200 && err == nil { // if user has permission
+
+			// get user ID and role, route only match numeric ID
+			ID, _ := strconv.Atoi(mux.Vars(r)["id"])
+			role := strings.TrimSpace(r.FormValue("role"))
+			v := form.Validator{}
+			v.Required("role", role)
+			if len(v.Errors()) > 0 { // if role not exist
+				log.Println(strconv.Quote("POST /api/admin/users/{id}/role/"), "422 UNPROCESSABLE ENTITY")
+				responseContent = getValidationErrorResponse(v.Errors())
+				responseStatus = 422
+			} else if ID == userSession.User.ID { // if change role of itself
+				log.Println(strconv.Quote("POST /api/admin/users/{id}/role/"), "400 BAD REQUEST")
+				responseContent = map[string]any{
+					"message": "Can't change your own role",
+				}
+				responseStatus = 400
+			} else { // if form valid, set user role
+				status, err := a.Store.SetUserRole(ID, role)
+				if status == 200 && err == nil { // if set user role success
+					log.Println(strconv.Quote("POST /api/admin/users/{id}/role/"), "200 SUCCESS")
+					responseContent = map[string]any{
+						"message": "User role changed!",
+					}
+					responseStatus = 200
+				} else if status == 400 { // if user not found
+					log.Println(strconv.Quote("POST /api/admin/users/{id}/role/"), "404 NOT FOUND")
+					responseContent = map[string]any{
+						"message": "User not found",
+					}
+					responseStatus = 404
+				} else if status == 422 { // if role not exist
+					log.Println(strconv.Quote("POST /api/admin/users/{id}/role/"), "422 UNPROCESSABLE ENTITY")
+					v.Add("role", form.CodeInvalid, "role not valid", nil)
+					responseContent = getValidationErrorResponse(v.Errors())
+					responseStatus = 422
+				} else { // if there's an error when set user role
+					log.Println(strconv.Quote("POST /api/admin/users/{id}/role/"), "500 INTERNAL SERVER ERROR")
+					log.Println(err.Error())
+					responseContent = map[string]any{
+						"message": err.Error(),
+					}
+					responseStatus = 500
+				}
+			}
+
+		} else if status == 403 { // if user doesn't have permission
+			log.Println(strconv.Quote("POST /api/admin/users/{id}/role/"), "403 FORBIDDEN")
+			responseContent = map[string]any{
+				"message": "Forbidden",
+			}
+			responseStatus = 403
+		} else if status == 400 { // if token not valid
+			log.Println(strconv.Quote("POST /api/admin/users/{id}/role/"), "400 BAD REQUEST")
+			responseContent = map[string]any{
+				"message": "Token not valid",
+			}
+			responseStatus = 400
+		} else { // if there's internal server error
+			log.Println(strconv.Quote("POST /api/admin/users/{id}/role/"), "500 INTERNAL SERVER ERROR")
+			log.Println(err.Error())
+			responseContent = map[string]any{
+				"message": err.Error(),
+			}
+			responseStatus = 500
+		}
+	} else { // if token not exist
+		log.Println(strconv.Quote("POST /api/admin/users/{id}/role/"), "400 BAD REQUEST")
+		responseContent = map[string]any{
+			"message": "Token empty/not found",
+		}
+		responseStatus = 400
+	}
+
+	response, marshalErr := json.Marshal(responseContent)
+	if marshalErr != nil {
+		log.Fatal("Error When Creating Response -> ", marshalErr)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(responseStatus)
+	w.Write(response)
+}
+
+// handleSetUserStatus handling route set status of a user by admin,
+// admin can't set status of itself
+func (a *API) handleSetUserStatus(w http.ResponseWriter, r *http.Request,
+	route string, userStatus string, successMessage string) {
+	var responseContent map[string]any
+	var responseStatus int
+
+	// allow host
+	w.Header().Set("Access-Control-Allow-Origin", a.Config.FrontendURL)
+
+	// check token in request
+	tokenString := getRequestToken(r)
+	if strings.TrimSpace(tokenString) != "" { // if token exist
+		userSession, status, err := a.authenticateToken(tokenString)
+		if status == 200 && err == nil {
+			status, err = a.authorizePermission(userSession.User, model.PermissionWriteUser)
+		}
+		if status == 200 && err == nil { // if user has permission
+
+			// get user ID, route only match numeric ID
+			ID, _ := strconv.Atoi(mux.Vars(r)["id"])
+
+			// get suspension reason and expiry if suspend user
+			var reason string
+			var until *time.Time
+			var validationErrs form.FieldErrors
+			if userStatus == model.UserStatusSuspended {
+				reason, until, validationErrs = getUserSuspension(r)
+			}
+
+			if len(validationErrs) > 0 { // if suspension not valid
+				log.Println(strconv.Quote(route), "422 UNPROCESSABLE ENTITY")
+				responseContent = getValidationErrorResponse(validationErrs)
+				responseStatus = 422
+			} else if ID == userSession.User.ID { // if set status of itself
+				log.Println(strconv.Quote(route), "400 BAD REQUEST")
+				responseContent = map[string]any{
+					"message": "Can't change your own status",
+				}
+				responseStatus = 400
+			} else { // if not itself, set user status
+				var status int
+				var err error
+				if userStatus == model.UserStatusSuspended {
+					status, err = a.Store.SuspendUser(ID, reason, until)
+				} else {
+					status, err = a.Store.SetUserStatus(ID, userStatus)
+				}
+
+				if status == 200 && err == nil { // if set user status success
+					log.Println(strconv.Quote(route), "200 SUCCESS")
+					responseContent = map[string]any{
+						"message": successMessage,
+					}
+					responseStatus = 200
+				} else if status == 400 { // if user not found
+					log.Println(strconv.Quote(route), "404 NOT FOUND")
+					responseContent = map[string]any{
+						"message": "User not found",
+					}
+					responseStatus = 404
+				} else if status == 409 { // if user status can't be changed
+					log.Println(strconv.Quote(route), "409 CONFLICT")
+					responseContent = map[string]any{
+						"message": "User status can't be changed to " + userStatus,
+					}
+					responseStatus = 409
+				} else { // if there's an error when set user status
+					log.Println(strconv.Quote(route), "500 INTERNAL SERVER ERROR")
+					log.Println(err.Error())
+					responseContent = map[string]any{
+						"message": err.Error(),
+					}
+					responseStatus = 500
+				}
+			}
+
+		} else if status == 403 { // if user doesn't have permission
+			log.Println(strconv.Quote(route), "403 FORBIDDEN")
+			responseContent = map[string]any{
+				"message": "Forbidden",
+			}
+			responseStatus = 403
+		} else if status == 400 { // if token not valid
+			log.Println(strconv.Quote(route), "400 BAD REQUEST")
+			responseContent = map[string]any{
+				"message": "Token not valid",
+			}
+			responseStatus = 400
+		} else { // if there's internal server error
+			log.Println(strconv.Quote(route), "500 INTERNAL SERVER ERROR")
+			log.Println(err.Error())
+			responseContent = map[string]any{
+				"message": err.Error(),
+			}
+			responseStatus = 500
+		}
+	} else { // if token not exist
+		log.Println(strconv.Quote(route), "400 BAD REQUEST")
+		responseContent = map[string]any{
+			"message": "Token empty/not found",
+		}
+		responseStatus = 400
+	}
+
+	response, marshalErr := json.Marshal(responseContent)
+	if marshalErr != nil {
+		log.Fatal("Error When Creating Response -> ", marshalErr)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(responseStatus)
+	w.Write(response)
+}
+
+// getUserListFilter get user list filter from query params,
+// return validation errors if a param not valid
+func getUserListFilter(r *http.Request) (model.UserListFilter, form.FieldErrors) {
+	f := model.UserListFilter{
+		Query:  strings.TrimSpace(r.FormValue("q")),
+		Role:   strings.TrimSpace(r.FormValue("role")),
+		Status: strings.TrimSpace(r.FormValue("status")),
+		Sort:   strings.TrimSpace(r.FormValue("sort")),
+		Cursor: strings.TrimSpace(r.FormValue("cursor")),
+	}
+
+	v := form.Validator{}
+	if stringLimit := strings.TrimSpace(r.FormValue("limit")); stringLimit != "" {
+		limit, err := strconv.Atoi(stringLimit)
+		if err != nil || limit <= 0 {
+			v.Add("limit", form.CodeInvalid, "limit not valid", nil)
+		}
+		f.Limit = limit
+	}
+
+	for _, param := range []struct {
+		Name string
+		Dest **time.Time
+	}{
+		{Name: "created_from", Dest: &f.CreatedFrom},
+		{Name: "created_to", Dest: &f.CreatedTo},
+	} {
+		value := strings.TrimSpace(r.FormValue(param.Name))
+		if value == "" {
+			continue
+		}
+
+		t, err := parseTimeParam(value)
+		if err != nil {
+			v.Add(param.Name, form.CodeInvalid, param.Name+" not valid", nil)
+			continue
+		}
+		*param.Dest = &t
+	}
+
+	return f, v.Errors()
+}
+
+// getUserSuspension get suspension reason and expiry from form,
+// return validation errors if reason empty or expiry not in the future
+func getUserSuspension(r *http.Request) (string, *time.Time, form.FieldErrors) {
+	v := form.Validator{}
+
+	reason := strings.TrimSpace(r.FormValue("reason"))
+	v.Required("reason", reason)
+	v.MaxLength("reason", reason, 500)
+
+	var until *time.Time
+	if value := strings.TrimSpace(r.FormValue("until")); value != "" {
+		t, err := parseTimeParam(value)
+		if err != nil || !t.After(time.Now().UTC()) {
+			v.Add("until", form.CodeInvalid, "until not valid", nil)
+		}
+		until = &t
+	}
+
+	return reason, until, v.Errors()
+}
+
+// parseTimeParam parse time from RFC 3339 time or YYYY-MM-DD date
+func parseTimeParam(value string) (time.Time, error) {
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		t, err = time.Parse("2006-01-02", value)
+	}
+
+	return t.UTC(), err
+}
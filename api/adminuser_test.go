@@ -0,0 +1,338 @@
+/*
+Package api containing API initialization and API route handler
+*/
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/reyhanfikridz/ecom-account-service/internal/utils"
+)
+
+// TestAdminUserHandlers integration test GetAdminUsersHandler,
+// SuspendUserHandler, ReactivateUserHandler, LogoutUserHandler,
+// SetUserRoleHandler, and DeleteUserHandler
+func TestAdminUserHandlers(t *testing.T) {
+	// initialize testing API
+	a, err := GetTestingAPI()
+	if err != nil {
+		t.Errorf("There's an error when getting testing API => " + err.Error())
+	}
+
+	// create admin, non admin, and target user with its session
+	userIDs := map[string]int{}
+	tokens := map[string]string{}
+	for _, name := range []string{"admin", "buyer", "target"} {
+		role := name
+		if name == "target" {
+			role = "buyer"
+		}
+
+		email := "testadminuser" + name + "@gmail.com"
+		_, err = a.DB.Exec(`DELETE FROM account_user WHERE email = $1`, email)
+		if err != nil {
+			t.Errorf("There's an error when deleting admin user testing data " + err.Error())
+		}
+
+		hashedPassword, err := utils.HashPassword("test")
+		if err != nil {
+			t.Errorf("There's an error when hashing password => " + err.Error())
+		}
+
+		var userID int
+		err = a.DB.QueryRow(`
+			INSERT INTO account_user(email, password, full_name, address, phone_number, role)
+				VALUES($1, $2, $3, $4, $5, $6)
+				RETURNING id`,
+			email, hashedPassword, "test", "test", "test", role).Scan(&userID)
+		if err != nil {
+			t.Errorf("There's an error when creating testing user data => " +
+				err.Error())
+		}
+
+		token, err := utils.GenerateJWT(a.Config.JWTKeys, userID, role,
+			a.Config.AccessTokenDuration)
+		if err != nil {
+			t.Errorf("There's an error when creating token " +
+				"for creating user session data => " +
+				err.Error())
+		}
+
+		_, err = a.DB.Exec(`
+			INSERT INTO account_usersession(token, account_user_id)
+				VALUES($1, $2)`, token, userID)
+		if err != nil {
+			t.Errorf("There's an error when creating testing user session data => " +
+				err.Error())
+		}
+
+		userIDs[name] = userID
+		tokens[name] = token
+	}
+
+	adminURL := "/api/admin/users/" + strconv.Itoa(userIDs["admin"]) + "/"
+	targetURL := "/api/admin/users/" + strconv.Itoa(userIDs["target"]) + "/"
+
+	// initialize testing table
+	testTable := []struct {
+		Method          string
+		URL             string
+		Token           string
+		ExpectedStatus  int
+		ExpectedBodyKey []string
+	}{
+		{
+			Method:          "GET",
+			URL:             "/api/admin/users/?q=testadminuser&limit=2",
+			Token:           tokens["admin"],
+			ExpectedStatus:  200,
+			ExpectedBodyKey: []string{"users", "next_cursor"},
+		},
+		{
+			Method:          "GET",
+			URL:             "/api/admin/users/?role=buyer&status=active&created_from=2022-01-01",
+			Token:           tokens["admin"],
+			ExpectedStatus:  200,
+			ExpectedBodyKey: []string{"users", "next_cursor"},
+		},
+		{
+			Method:          "GET",
+			URL:             "/api/admin/users/?sort=password",
+			Token:           tokens["admin"],
+			ExpectedStatus:  422,
+			ExpectedBodyKey: []string{"message", "code", "errors"},
+		},
+		{
+			Method:          "GET",
+			URL:             "/api/admin/users/?cursor=notvalid",
+			Token:           tokens["admin"],
+			ExpectedStatus:  422,
+			ExpectedBodyKey: []string{"message", "code", "errors"},
+		},
+		{
+			Method:          "GET",
+			URL:             "/api/admin/users/?limit=abc",
+			Token:           tokens["admin"],
+			ExpectedStatus:  422,
+			ExpectedBodyKey: []string{"message", "code", "errors"},
+		},
+		{
+			Method:          "GET",
+			URL:             "/api/admin/users/?created_to=yesterday",
+			Token:           tokens["admin"],
+			ExpectedStatus:  422,
+			ExpectedBodyKey: []string{"message", "code", "errors"},
+		},
+		{
+			Method:          "GET",
+			URL:             "/api/admin/users/",
+			Token:           tokens["buyer"],
+			ExpectedStatus:  403,
+			ExpectedBodyKey: []string{"message"},
+		},
+		{
+			Method:          "GET",
+			URL:             "/api/admin/users/",
+			Token:           "",
+			ExpectedStatus:  400,
+			ExpectedBodyKey: []string{"message"},
+		},
+		{
+			Method:          "POST",
+			URL:             targetURL + "suspend/?reason=fraud",
+			Token:           tokens["buyer"],
+			ExpectedStatus:  403,
+			ExpectedBodyKey: []string{"message"},
+		},
+		{
+			Method:          "POST",
+			URL:             adminURL + "suspend/?reason=fraud",
+			Token:           tokens["admin"],
+			ExpectedStatus:  400,
+			ExpectedBodyKey: []string{"message"},
+		},
+		{
+			Method:          "POST",
+			URL:             "/api/admin/users/0/suspend/?reason=fraud",
+			Token:           tokens["admin"],
+			ExpectedStatus:  404,
+			ExpectedBodyKey: []string{"message"},
+		},
+		{
+			Method:          "POST",
+			URL:             targetURL + "suspend/",
+			Token:           tokens["admin"],
+			ExpectedStatus:  422,
+			ExpectedBodyKey: []string{"message", "code", "errors"},
+		},
+		{
+			Method:          "POST",
+			URL:             targetURL + "suspend/?reason=fraud&until=2022-01-01",
+			Token:           tokens["admin"],
+			ExpectedStatus:  422,
+			ExpectedBodyKey: []string{"message", "code", "errors"},
+		},
+		{
+			Method:          "POST",
+			URL:             targetURL + "suspend/?reason=fraud&until=2099-01-01",
+			Token:           tokens["admin"],
+			ExpectedStatus:  200,
+			ExpectedBodyKey: []string{"message"},
+		},
+		{ // suspended user sessions revoked
+			Method:          "GET",
+			URL:             "/api/sessions/",
+			Token:           tokens["target"],
+			ExpectedStatus:  400,
+			ExpectedBodyKey: []string{"message"},
+		},
+		{
+			Method:          "POST",
+			URL:             targetURL + "reactivate/",
+			Token:           tokens["admin"],
+			ExpectedStatus:  200,
+			ExpectedBodyKey: []string{"message"},
+		},
+		{ // active user can't be reactivated
+			Method:          "POST",
+			URL:             targetURL + "reactivate/",
+			Token:           tokens["admin"],
+			ExpectedStatus:  409,
+			ExpectedBodyKey: []string{"message"},
+		},
+		{
+			Method:          "POST",
+			URL:             targetURL + "role/?role=notexist",
+			Token:           tokens["admin"],
+			ExpectedStatus:  422,
+			ExpectedBodyKey: []string{"message", "code", "errors"},
+		},
+		{
+			Method:          "POST",
+			URL:             targetURL + "role/",
+			Token:           tokens["admin"],
+			ExpectedStatus:  422,
+			ExpectedBodyKey: []string{"message", "code", "errors"},
+		},
+		{
+			Method:          "POST",
+			URL:             adminURL + "role/?role=buyer",
+			Token:           tokens["admin"],
+			ExpectedStatus:  400,
+			ExpectedBodyKey: []string{"message"},
+		},
+		{
+			Method:          "POST",
+			URL:             "/api/admin/users/0/role/?role=seller",
+			Token:           tokens["admin"],
+			ExpectedStatus:  404,
+			ExpectedBodyKey: []string{"message"},
+		},
+		{
+			Method:          "POST",
+			URL:             targetURL + "role/?role=seller",
+			Token:           tokens["admin"],
+			ExpectedStatus:  200,
+			ExpectedBodyKey: []string{"message"},
+		},
+		{
+			Method:          "POST",
+			URL:             "/api/admin/users/0/logout/",
+			Token:           tokens["admin"],
+			ExpectedStatus:  404,
+			ExpectedBodyKey: []string{"message"},
+		},
+		{
+			Method:          "POST",
+			URL:             "/api/admin/users/" + strconv.Itoa(userIDs["buyer"]) + "/logout/",
+			Token:           tokens["admin"],
+			ExpectedStatus:  200,
+			ExpectedBodyKey: []string{"message"},
+		},
+		{ // logged out user sessions revoked
+			Method:          "GET",
+			URL:             "/api/sessions/",
+			Token:           tokens["buyer"],
+			ExpectedStatus:  400,
+			ExpectedBodyKey: []string{"message"},
+		},
+		{
+			Method:          "DELETE",
+			URL:             adminURL,
+			Token:           tokens["admin"],
+			ExpectedStatus:  400,
+			ExpectedBodyKey: []string{"message"},
+		},
+		{
+			Method:          "DELETE",
+			URL:             targetURL,
+			Token:           tokens["admin"],
+			ExpectedStatus:  200,
+			ExpectedBodyKey: []string{"message"},
+		},
+		{ // deleted user can't be reactivated
+			Method:          "POST",
+			URL:             targetURL + "reactivate/",
+			Token:           tokens["admin"],
+			ExpectedStatus:  409,
+			ExpectedBodyKey: []string{"message"},
+		},
+		{ // deleted user can't be suspended
+			Method:          "POST",
+			URL:             targetURL + "suspend/?reason=fraud",
+			Token:           tokens["admin"],
+			ExpectedStatus:  409,
+			ExpectedBodyKey: []string{"message"},
+		},
+	}
+
+	// loop test in test table
+	for _, test := range testTable {
+		// create new request
+		req, err := http.NewRequest(test.Method, test.URL, nil)
+		if err != nil {
+			t.Errorf("There's an error when creating request API admin users => " +
+				err.Error())
+		}
+		if test.Token != "" {
+			req.Header.Set("Authorization", "Bearer "+test.Token)
+		}
+
+		// run request
+		response := httptest.NewRecorder()
+		a.Router.ServeHTTP(response, req)
+
+		// check response
+		if response.Code != test.ExpectedStatus {
+			t.Errorf("Expected status %d got %d on "+test.Method+" "+test.URL,
+				test.ExpectedStatus, response.Code)
+		}
+
+		var responseData map[string]any
+		err = json.Unmarshal(response.Body.Bytes(), &responseData)
+		if err != nil {
+			t.Errorf("There's an error when unmarshal body response => " + err.Error())
+		}
+		for _, expectedKey := range test.ExpectedBodyKey {
+			if responseData[expectedKey] == nil {
+				t.Errorf("Expected key " + expectedKey + " empty/not found")
+			}
+		}
+	}
+
+	// check result
+	var role, status string
+	err = a.DB.QueryRow(`SELECT role, status FROM account_user WHERE id = $1`,
+		userIDs["target"]).Scan(&role, &status)
+	if err != nil {
+		t.Errorf("There's an error when getting target user => " + err.Error())
+	}
+	if role != "seller" || status != "deleted" {
+		t.Errorf("Expected target user role 'seller' and status 'deleted', " +
+			"but got role '" + role + "' and status '" + status + "'")
+	}
+}
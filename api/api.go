@@ -6,67 +6,79 @@ package api
 import (
 	"database/sql"
 	"encoding/json"
-	"fmt"
+	"io"
 	"log"
+	"math"
+	"mime"
+	"net"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
 	_ "github.com/lib/pq"
 	"github.com/reyhanfikridz/ecom-account-service/internal/config"
+	"github.com/reyhanfikridz/ecom-account-service/internal/event"
 	"github.com/reyhanfikridz/ecom-account-service/internal/form"
+	"github.com/reyhanfikridz/ecom-account-service/internal/mailer"
+	"github.com/reyhanfikridz/ecom-account-service/internal/migration"
 	"github.com/reyhanfikridz/ecom-account-service/internal/model"
+	"github.com/reyhanfikridz/ecom-account-service/internal/phone"
+	"github.com/reyhanfikridz/ecom-account-service/internal/ratelimit"
+	"github.com/reyhanfikridz/ecom-account-service/internal/sms"
+	"github.com/reyhanfikridz/ecom-account-service/internal/store"
 	"github.com/reyhanfikridz/ecom-account-service/internal/utils"
 )
 
-// API contain database connection and router for account service API
+// API contain config, database connection, store, router, email sender,
+// SMS sender, event publisher, and rate limit storage for account service API
+//
+// Config must be set before InitDB and InitRouter.
+// Store set to SQL store of the configured database by InitDB,
+// or can be set to memory store so the handlers run without database.
+// RateLimitStore can be set before InitRouter to use shared storage,
+// otherwise in-process storage used. Events not published if Events not set,
+// and phone verification not available if SMS not set.
 type API struct {
-	DB     *sql.DB
-	Router *mux.Router
+	Config         config.Config
+	DB             *sql.DB
+	Store          store.Store
+	Router         *mux.Router
+	Mailer         mailer.Mailer
+	SMS            sms.Sender
+	Events         event.Publisher
+	RateLimitStore ratelimit.Store
 }
 
-// InitDB initialize API database connection
+// InitDB initialize API database connection and migrate database schema
+// to the latest version, SQLite database file used if driver is "sqlite",
+// otherwise Postgres
+//
+// Return migration.ErrSchemaTooNew if database schema migrated
+// by newer version of the service
 func (a *API) InitDB(DBConfig map[string]string) error {
-	// connect to db
-	connString := fmt.Sprintf("user=%s password=%s dbname=%s sslmode=disable",
-		DBConfig["user"], DBConfig["password"], DBConfig["dbname"])
+	err := a.ConnectDB(DBConfig)
+	if err != nil {
+		return err
+	}
 
-	var err error
-	a.DB, err = sql.Open("postgres", connString)
+	// create or update db tables
+	migrator, err := migration.New(a.DB, getDBDriver(DBConfig), a.Config)
 	if err != nil {
 		return err
 	}
 
-	// create db tables if not exist
-	tableCreationQuery := `
-		CREATE TABLE IF NOT EXISTS account_user
-		(
-			id SERIAL PRIMARY KEY NOT NULL,
-			email VARCHAR(50) UNIQUE NOT NULL,
-			password VARCHAR(100) NOT NULL,
-			full_name VARCHAR(50) NOT NULL,
-			address VARCHAR(100) NOT NULL,
-			phone_number VARCHAR(20) NOT NULL,
-			role VARCHAR(20) NOT NULL
-		);
-		
-		CREATE TABLE IF NOT EXISTS account_usersession
-		(
-			id SERIAL PRIMARY KEY NOT NULL,
-			token VARCHAR(200) UNIQUE NOT NULL,
-			account_user_id INT UNIQUE NOT NULL,
-			CONSTRAINT fk_account_user
-				FOREIGN KEY(account_user_id) 
-					REFERENCES account_user(id)
-					ON DELETE CASCADE
-		);
-	`
-
-	_, err = a.DB.Exec(tableCreationQuery)
+	migrations, err := migrator.Up()
 	if err != nil {
 		return err
 	}
+	for _, m := range migrations {
+		log.Printf("Database migration => %04d_%s applied", m.Version, m.Name)
+	}
+
+	a.Store = store.NewSQLStore(a.DB, a.Config)
 
 	return nil
 }
@@ -78,7 +90,8 @@ func (a *API) InitRouter() error {
 	// route register user
 	registerRoute := a.Router.
 		HandleFunc("/api/register/", a.RegisterHandler).
-		Methods("POST")
+		Methods("POST").
+		Name("register")
 	if registerRoute.GetError() != nil {
 		return registerRoute.GetError()
 	}
@@ -86,15 +99,89 @@ func (a *API) InitRouter() error {
 	// route login user
 	loginRoute := a.Router.
 		HandleFunc("/api/login/", a.LoginHandler).
-		Methods("POST")
+		Methods("POST").
+		Name("login")
 	if loginRoute.GetError() != nil {
 		return loginRoute.GetError()
 	}
 
+	// route login user with two-factor authentication
+	loginMFARoute := a.Router.
+		HandleFunc("/api/login/mfa/", a.LoginMFAHandler).
+		Methods("POST").
+		Name("login_mfa")
+	if loginMFARoute.GetError() != nil {
+		return loginMFARoute.GetError()
+	}
+
+	// route enroll totp
+	enrollTOTPRoute := a.Router.
+		HandleFunc("/api/mfa/totp/enroll/", a.EnrollTOTPHandler).
+		Methods("POST").
+		Name("enroll_totp")
+	if enrollTOTPRoute.GetError() != nil {
+		return enrollTOTPRoute.GetError()
+	}
+
+	// route confirm totp
+	confirmTOTPRoute := a.Router.
+		HandleFunc("/api/mfa/totp/confirm/", a.ConfirmTOTPHandler).
+		Methods("POST").
+		Name("confirm_totp")
+	if confirmTOTPRoute.GetError() != nil {
+		return confirmTOTPRoute.GetError()
+	}
+
+	// route refresh access token
+	refreshTokenRoute := a.Router.
+		HandleFunc("/api/token/refresh/", a.RefreshTokenHandler).
+		Methods("POST").
+		Name("refresh_token")
+	if refreshTokenRoute.GetError() != nil {
+		return refreshTokenRoute.GetError()
+	}
+
+	// route forgot password (send password reset link)
+	forgotPasswordRoute := a.Router.
+		HandleFunc("/api/password/forgot/", a.ForgotPasswordHandler).
+		Methods("POST").
+		Name("forgot_password")
+	if forgotPasswordRoute.GetError() != nil {
+		return forgotPasswordRoute.GetError()
+	}
+
+	// route reset password
+	resetPasswordRoute := a.Router.
+		HandleFunc("/api/password/reset/", a.ResetPasswordHandler).
+		Methods("POST").
+		Name("reset_password")
+	if resetPasswordRoute.GetError() != nil {
+		return resetPasswordRoute.GetError()
+	}
+
+	// route verify email
+	verifyEmailRoute := a.Router.
+		HandleFunc("/api/email/verify/", a.VerifyEmailHandler).
+		Methods("POST").
+		Name("verify_email")
+	if verifyEmailRoute.GetError() != nil {
+		return verifyEmailRoute.GetError()
+	}
+
+	// route resend email verification
+	resendEmailVerificationRoute := a.Router.
+		HandleFunc("/api/email/verify/resend/", a.ResendEmailVerificationHandler).
+		Methods("POST").
+		Name("resend_email_verification")
+	if resendEmailVerificationRoute.GetError() != nil {
+		return resendEmailVerificationRoute.GetError()
+	}
+
 	// route authorize user
 	authorizeRoute := a.Router.
 		HandleFunc("/api/authorize/", a.AuthorizeHandler).
-		Methods("POST")
+		Methods("POST").
+		Name("authorize")
 	if authorizeRoute.GetError() != nil {
 		return authorizeRoute.GetError()
 	}
@@ -102,19 +189,281 @@ func (a *API) InitRouter() error {
 	// route logout user
 	logoutRoute := a.Router.
 		HandleFunc("/api/logout/", a.LogoutHandler).
-		Methods("POST")
+		Methods("POST").
+		Name("logout")
 	if logoutRoute.GetError() != nil {
 		return logoutRoute.GetError()
 	}
 
+	// route get user sessions
+	getSessionsRoute := a.Router.
+		HandleFunc("/api/sessions/", a.GetSessionsHandler).
+		Methods("GET").
+		Name("get_sessions")
+	if getSessionsRoute.GetError() != nil {
+		return getSessionsRoute.GetError()
+	}
+
+	// route delete all user sessions (log out everywhere)
+	deleteSessionsRoute := a.Router.
+		HandleFunc("/api/sessions/", a.DeleteSessionsHandler).
+		Methods("DELETE").
+		Name("delete_sessions")
+	if deleteSessionsRoute.GetError() != nil {
+		return deleteSessionsRoute.GetError()
+	}
+
+	// route delete a user session
+	deleteSessionRoute := a.Router.
+		HandleFunc("/api/sessions/{id:[0-9]+}/", a.DeleteSessionHandler).
+		Methods("DELETE").
+		Name("delete_session")
+	if deleteSessionRoute.GetError() != nil {
+		return deleteSessionRoute.GetError()
+	}
+
+	// route get JSON Web Key Set for verifying jwt token
+	jwksRoute := a.Router.
+		HandleFunc("/.well-known/jwks.json", a.JWKSHandler).
+		Methods("GET").
+		Name("jwks")
+	if jwksRoute.GetError() != nil {
+		return jwksRoute.GetError()
+	}
+
+	// route promote jwt signing key (admin only)
+	promoteJWTKeyRoute := a.Router.
+		HandleFunc("/api/admin/jwt-keys/promote/", a.PromoteJWTKeyHandler).
+		Methods("POST").
+		Name("promote_jwt_key")
+	if promoteJWTKeyRoute.GetError() != nil {
+		return promoteJWTKeyRoute.GetError()
+	}
+
+	// route clear login lockout (admin only)
+	clearLoginLockoutRoute := a.Router.
+		HandleFunc("/api/admin/login-lockouts/clear/", a.ClearLoginLockoutHandler).
+		Methods("POST").
+		Name("clear_login_lockout")
+	if clearLoginLockoutRoute.GetError() != nil {
+		return clearLoginLockoutRoute.GetError()
+	}
+
+	// route get users with search, filter, and pagination (admin only)
+	getAdminUsersRoute := a.Router.
+		HandleFunc("/api/admin/users/", a.GetAdminUsersHandler).
+		Methods("GET").
+		Name("get_admin_users")
+	if getAdminUsersRoute.GetError() != nil {
+		return getAdminUsersRoute.GetError()
+	}
+
+	// route suspend a user (admin only)
+	suspendUserRoute := a.Router.
+		HandleFunc("/api/admin/users/{id:[0-9]+}/suspend/", a.SuspendUserHandler).
+		Methods("POST").
+		Name("suspend_user")
+	if suspendUserRoute.GetError() != nil {
+		return suspendUserRoute.GetError()
+	}
+
+	// route reactivate a suspended user (admin only)
+	reactivateUserRoute := a.Router.
+		HandleFunc("/api/admin/users/{id:[0-9]+}/reactivate/", a.ReactivateUserHandler).
+		Methods("POST").
+		Name("reactivate_user")
+	if reactivateUserRoute.GetError() != nil {
+		return reactivateUserRoute.GetError()
+	}
+
+	// route force logout a user from all sessions (admin only)
+	logoutUserRoute := a.Router.
+		HandleFunc("/api/admin/users/{id:[0-9]+}/logout/", a.LogoutUserHandler).
+		Methods("POST").
+		Name("logout_user")
+	if logoutUserRoute.GetError() != nil {
+		return logoutUserRoute.GetError()
+	}
+
+	// route soft delete a user (admin only)
+	deleteUserRoute := a.Router.
+		HandleFunc("/api/admin/users/{id:[0-9]+}/", a.DeleteUserHandler).
+		Methods("DELETE").
+		Name("delete_user")
+	if deleteUserRoute.GetError() != nil {
+		return deleteUserRoute.GetError()
+	}
+
+	// route change role of a user (admin only)
+	setUserRoleRoute := a.Router.
+		HandleFunc("/api/admin/users/{id:[0-9]+}/role/", a.SetUserRoleHandler).
+		Methods("POST").
+		Name("set_user_role")
+	if setUserRoleRoute.GetError() != nil {
+		return setUserRoleRoute.GetError()
+	}
+
 	// route get user
 	getUserRoute := a.Router.
 		HandleFunc("/api/user/", a.GetUserHandler).
-		Methods("GET")
+		Methods("GET").
+		Name("get_user")
 	if getUserRoute.GetError() != nil {
 		return getUserRoute.GetError()
 	}
 
+	// route update profile of the logged in user
+	updateUserMeRoute := a.Router.
+		HandleFunc("/api/user/me/", a.UpdateUserMeHandler).
+		Methods("PATCH").
+		Name("update_user_me")
+	if updateUserMeRoute.GetError() != nil {
+		return updateUserMeRoute.GetError()
+	}
+
+	// route delete account of the logged in user
+	deleteUserMeRoute := a.Router.
+		HandleFunc("/api/user/me/", a.DeleteUserMeHandler).
+		Methods("DELETE").
+		Name("delete_user_me")
+	if deleteUserMeRoute.GetError() != nil {
+		return deleteUserMeRoute.GetError()
+	}
+
+	// route send verification code to phone number of the logged in user
+	sendPhoneVerificationRoute := a.Router.
+		HandleFunc("/api/user/me/phone/verification/", a.SendPhoneVerificationHandler).
+		Methods("POST").
+		Name("send_phone_verification")
+	if sendPhoneVerificationRoute.GetError() != nil {
+		return sendPhoneVerificationRoute.GetError()
+	}
+
+	// route verify phone number of the logged in user
+	verifyPhoneRoute := a.Router.
+		HandleFunc("/api/user/me/phone/verify/", a.VerifyPhoneHandler).
+		Methods("POST").
+		Name("verify_phone")
+	if verifyPhoneRoute.GetError() != nil {
+		return verifyPhoneRoute.GetError()
+	}
+
+	// route get all addresses of the logged in user
+	getAddressesRoute := a.Router.
+		HandleFunc("/api/user/me/addresses/", a.GetAddressesHandler).
+		Methods("GET").
+		Name("get_addresses")
+	if getAddressesRoute.GetError() != nil {
+		return getAddressesRoute.GetError()
+	}
+
+	// route create address of the logged in user
+	createAddressRoute := a.Router.
+		HandleFunc("/api/user/me/addresses/", a.CreateAddressHandler).
+		Methods("POST").
+		Name("create_address")
+	if createAddressRoute.GetError() != nil {
+		return createAddressRoute.GetError()
+	}
+
+	// route get an address of the logged in user
+	getAddressRoute := a.Router.
+		HandleFunc("/api/user/me/addresses/{id:[0-9]+}/", a.GetAddressHandler).
+		Methods("GET").
+		Name("get_address")
+	if getAddressRoute.GetError() != nil {
+		return getAddressRoute.GetError()
+	}
+
+	// route update an address of the logged in user
+	updateAddressRoute := a.Router.
+		HandleFunc("/api/user/me/addresses/{id:[0-9]+}/", a.UpdateAddressHandler).
+		Methods("PATCH").
+		Name("update_address")
+	if updateAddressRoute.GetError() != nil {
+		return updateAddressRoute.GetError()
+	}
+
+	// route delete an address of the logged in user
+	deleteAddressRoute := a.Router.
+		HandleFunc("/api/user/me/addresses/{id:[0-9]+}/", a.DeleteAddressHandler).
+		Methods("DELETE").
+		Name("delete_address")
+	if deleteAddressRoute.GetError() != nil {
+		return deleteAddressRoute.GetError()
+	}
+
+	// route request personal data export of the logged in user
+	exportUserMeRoute := a.Router.
+		HandleFunc("/api/user/me/export/", a.ExportUserMeHandler).
+		Methods("POST").
+		Name("export_user_me")
+	if exportUserMeRoute.GetError() != nil {
+		return exportUserMeRoute.GetError()
+	}
+
+	// route get personal data export status of the logged in user
+	getDataExportRoute := a.Router.
+		HandleFunc("/api/user/me/export/{id:[0-9]+}/", a.GetDataExportHandler).
+		Methods("GET").
+		Name("get_data_export")
+	if getDataExportRoute.GetError() != nil {
+		return getDataExportRoute.GetError()
+	}
+
+	// route download personal data export archive of the logged in user
+	downloadDataExportRoute := a.Router.
+		HandleFunc("/api/user/me/export/{id:[0-9]+}/download/", a.DownloadDataExportHandler).
+		Methods("GET").
+		Name("download_data_export")
+	if downloadDataExportRoute.GetError() != nil {
+		return downloadDataExportRoute.GetError()
+	}
+
+	// route change password of the logged in user
+	changePasswordRoute := a.Router.
+		HandleFunc("/api/user/me/password/", a.ChangePasswordHandler).
+		Methods("POST").
+		Name("change_password")
+	if changePasswordRoute.GetError() != nil {
+		return changePasswordRoute.GetError()
+	}
+
+	// route request email change of the logged in user
+	requestEmailChangeRoute := a.Router.
+		HandleFunc("/api/user/me/email/", a.RequestEmailChangeHandler).
+		Methods("POST").
+		Name("request_email_change")
+	if requestEmailChangeRoute.GetError() != nil {
+		return requestEmailChangeRoute.GetError()
+	}
+
+	// route confirm email change (from the new email)
+	confirmEmailChangeRoute := a.Router.
+		HandleFunc("/api/email/change/confirm/", a.ConfirmEmailChangeHandler).
+		Methods("POST").
+		Name("confirm_email_change")
+	if confirmEmailChangeRoute.GetError() != nil {
+		return confirmEmailChangeRoute.GetError()
+	}
+
+	// route revert email change (from the old email)
+	revertEmailChangeRoute := a.Router.
+		HandleFunc("/api/email/change/revert/", a.RevertEmailChangeHandler).
+		Methods("POST").
+		Name("revert_email_change")
+	if revertEmailChangeRoute.GetError() != nil {
+		return revertEmailChangeRoute.GetError()
+	}
+
+	// rate limit all routes
+	if a.Config.RateLimitEnabled {
+		if a.RateLimitStore == nil {
+			a.RateLimitStore = ratelimit.NewMemoryStore()
+		}
+		a.Router.Use(a.getRateLimiter().Middleware)
+	}
+
 	return nil
 }
 
@@ -124,7 +473,7 @@ func (a *API) RegisterHandler(w http.ResponseWriter, r *http.Request) {
 	var responseStatus int
 
 	// allow host
-	w.Header().Set("Access-Control-Allow-Origin", config.FrontendURL)
+	w.Header().Set("Access-Control-Allow-Origin", a.Config.FrontendURL)
 
 	// get user data from form-data
 	u := model.User{
@@ -136,11 +485,41 @@ func (a *API) RegisterHandler(w http.ResponseWriter, r *http.Request) {
 		Role:        r.FormValue("role"),
 	}
 
-	// validate register user form
-	isValid, errString := form.IsUserFormValid(u, "register")
-	if isValid { // if register form valid, create user
-		u, err := model.CreateUser(a.DB, u)
+	// validate register user form, only self registrable role can be chosen
+	validationErrs := form.ValidateUserForm(u, "register", a.Config.PhoneDefaultRegion,
+		a.Config.PasswordMinLength)
+	isValid := len(validationErrs) == 0
+	isRoleValid, roleErr := false, error(nil)
+	if isValid {
+		isRoleValid, roleErr = a.Store.IsRoleSelfRegistrable(u.Role)
+	}
+
+	if isValid && roleErr != nil { // if there's an error when check role
+		log.Println(strconv.Quote("POST /api/register/"), "500 INTERNAL SERVER ERROR")
+		log.Println(roleErr.Error())
+		responseContent = map[string]any{
+			"message": "There's an error when register user => " + roleErr.Error(),
+		}
+		responseStatus = 500
+	} else if isValid && !isRoleValid { // if role not exist or not self registrable
+		log.Println(strconv.Quote("POST /api/register/"), "422 UNPROCESSABLE ENTITY")
+		v := form.Validator{}
+		v.Add("role", form.CodeInvalid, "role not valid", nil)
+		responseContent = getValidationErrorResponse(v.Errors())
+		responseStatus = 422
+	} else if isValid { // if register form valid, create user
+		// phone number already validated, save it in E.164 format
+		u.PhoneNumber, _ = phone.Normalize(u.PhoneNumber, a.Config.PhoneDefaultRegion)
+
+		u, err := a.Store.CreateUser(u)
 		if err == nil { // if there's no error when create user
+			// send email verification link, failure only logged
+			// because user can resend it later
+			_, err = a.sendEmailVerification(u)
+			if err != nil {
+				log.Println(err.Error())
+			}
+
 			log.Println(strconv.Quote("POST /api/register/"), "200 SUCCESS")
 			responseContent = map[string]any{
 				"message": "User registered!",
@@ -149,8 +528,7 @@ func (a *API) RegisterHandler(w http.ResponseWriter, r *http.Request) {
 			responseStatus = 200
 
 		} else { // if there's an error when create user
-			if strings.Contains(err.Error(), "duplicate") &&
-				strings.Contains(err.Error(), "email") { // if email already used
+			if err == store.ErrDuplicateEmail { // if email already used
 				log.Println(strconv.Quote("POST /api/register/"), "400 BAD REQUEST")
 				responseContent = map[string]any{
 					"message": "Email already registered, please use another email",
@@ -165,12 +543,10 @@ func (a *API) RegisterHandler(w http.ResponseWriter, r *http.Request) {
 				responseStatus = 500
 			}
 		}
-	} else { // if register form not valid, return bad request
-		log.Println(strconv.Quote("POST /api/register/"), "400 BAD REQUEST")
-		responseContent = map[string]any{
-			"message": errString,
-		}
-		responseStatus = 400
+	} else { // if register form not valid, return validation errors
+		log.Println(strconv.Quote("POST /api/register/"), "422 UNPROCESSABLE ENTITY")
+		responseContent = getValidationErrorResponse(validationErrs)
+		responseStatus = 422
 	}
 
 	response, marshalErr := json.Marshal(responseContent)
@@ -188,7 +564,7 @@ func (a *API) LoginHandler(w http.ResponseWriter, r *http.Request) {
 	var responseStatus int
 
 	// allow host
-	w.Header().Set("Access-Control-Allow-Origin", config.FrontendURL)
+	w.Header().Set("Access-Control-Allow-Origin", a.Config.FrontendURL)
 
 	// get user data from form-data
 	u := model.User{
@@ -197,17 +573,58 @@ func (a *API) LoginHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// validate user data from login form
-	isValid, errString := form.IsUserFormValid(u, "login")
-	if isValid { // if user data valid, login user
-		token, status, u, err := model.AuthenticateUser(a.DB, u)
+	validationErrs := form.ValidateUserForm(u, "login", a.Config.PhoneDefaultRegion,
+		a.Config.PasswordMinLength)
+	if len(validationErrs) == 0 { // if user data valid, login user
+		token, refreshToken, status, u, err := a.Store.AuthenticateUser(
+			u, r.UserAgent(), getRequestIP(r))
 		if status == 200 && err == nil { // If user authenticated
 			log.Println(strconv.Quote("POST /api/login/"), "200 SUCCESS")
 			responseContent = map[string]any{
-				"message": "User logged in!",
-				"token":   token,
-				"role":    u.Role,
+				"message":       "User logged in!",
+				"token":         token,
+				"refresh_token": refreshToken,
+				"role":          model.EffectiveRole(a.Config, u),
 			}
 			responseStatus = 200
+		} else if status == 202 { // if user need two-factor authentication
+			log.Println(strconv.Quote("POST /api/login/"), "202 ACCEPTED")
+			responseContent = map[string]any{
+				"message":      "Two-factor authentication required",
+				"mfa_required": true,
+				"mfa_token":    token,
+			}
+			responseStatus = 202
+		} else if status == 423 { // if account or IP address locked
+			log.Println(strconv.Quote("POST /api/login/"), "423 LOCKED")
+			w.Header().Set("Retry-After", a.getLoginRetryAfter(r.FormValue("email"), getRequestIP(r)))
+			responseContent = map[string]any{
+				"message": "Account temporarily locked because of too many failed login attempts",
+				"code":    "account_locked",
+			}
+			responseStatus = 423
+		} else if status == 429 { // if still delayed after the last failed login
+			log.Println(strconv.Quote("POST /api/login/"), "429 TOO MANY REQUESTS")
+			w.Header().Set("Retry-After", a.getLoginRetryAfter(r.FormValue("email"), getRequestIP(r)))
+			responseContent = map[string]any{
+				"message": "Too many failed login attempts, please try again later",
+				"code":    "login_delayed",
+			}
+			responseStatus = 429
+		} else if status == 403 && u.Status == model.UserStatusSuspended { // if user suspended
+			log.Println(strconv.Quote("POST /api/login/"), "403 FORBIDDEN")
+			responseContent = map[string]any{
+				"message":         "Account suspended",
+				"code":            "account_suspended",
+				"suspended_until": u.SuspendedUntil,
+			}
+			responseStatus = 403
+		} else if status == 403 { // if user email not verified
+			log.Println(strconv.Quote("POST /api/login/"), "403 FORBIDDEN")
+			responseContent = map[string]any{
+				"message": "Email not verified",
+			}
+			responseStatus = 403
 		} else if status == 400 { // if user not authenticated
 			log.Println(strconv.Quote("POST /api/login/"), "400 BAD REQUEST")
 			responseContent = map[string]any{
@@ -223,12 +640,62 @@ func (a *API) LoginHandler(w http.ResponseWriter, r *http.Request) {
 			responseStatus = 500
 		}
 
-	} else { // if user data from login form not valid, return bad request
-		log.Println(strconv.Quote("POST /api/login/"), "400 BAD REQUEST")
-		responseContent = map[string]any{
-			"message": errString,
+	} else { // if user data from login form not valid, return validation errors
+		log.Println(strconv.Quote("POST /api/login/"), "422 UNPROCESSABLE ENTITY")
+		responseContent = getValidationErrorResponse(validationErrs)
+		responseStatus = 422
+	}
+
+	response, marshalErr := json.Marshal(responseContent)
+	if marshalErr != nil {
+		log.Fatal("Error When Creating Response -> ", marshalErr)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(responseStatus)
+	w.Write(response)
+}
+
+// RefreshTokenHandler handling route refresh access token (method: POST)
+func (a *API) RefreshTokenHandler(w http.ResponseWriter, r *http.Request) {
+	var responseContent map[string]any
+	var responseStatus int
+
+	// allow host
+	w.Header().Set("Access-Control-Allow-Origin", a.Config.FrontendURL)
+
+	// check refresh token in form
+	refreshTokenString := r.FormValue("refresh_token")
+	if strings.TrimSpace(refreshTokenString) != "" { // if refresh token exist
+		// rotate refresh token and get new access token
+		token, refreshToken, status, err := a.Store.RefreshUserSession(refreshTokenString)
+		if status == 200 && err == nil { // if refresh success
+			log.Println(strconv.Quote("POST /api/token/refresh/"), "200 SUCCESS")
+			responseContent = map[string]any{
+				"message":       "Token refreshed!",
+				"token":         token,
+				"refresh_token": refreshToken,
+			}
+			responseStatus = 200
+		} else if status == 400 { // if refresh token not valid, expired, or reused
+			log.Println(strconv.Quote("POST /api/token/refresh/"), "400 BAD REQUEST")
+			responseContent = map[string]any{
+				"message": "Refresh token not valid",
+			}
+			responseStatus = 400
+		} else { // if there's internal server error
+			log.Println(strconv.Quote("POST /api/token/refresh/"), "500 INTERNAL SERVER ERROR")
+			log.Println(err.Error())
+			responseContent = map[string]any{
+				"message": err.Error(),
+			}
+			responseStatus = 500
 		}
-		responseStatus = 400
+	} else { // if refresh token not exist
+		log.Println(strconv.Quote("POST /api/token/refresh/"), "422 UNPROCESSABLE ENTITY")
+		v := form.Validator{}
+		v.Required("refresh_token", refreshTokenString)
+		responseContent = getValidationErrorResponse(v.Errors())
+		responseStatus = 422
 	}
 
 	response, marshalErr := json.Marshal(responseContent)
@@ -248,46 +715,29 @@ func (a *API) AuthorizeHandler(w http.ResponseWriter, r *http.Request) {
 	responseData := model.User{}
 
 	// allow host
-	w.Header().Set("Access-Control-Allow-Origin", config.FrontendURL)
+	w.Header().Set("Access-Control-Allow-Origin", a.Config.FrontendURL)
 
-	// check token in form or not
-	tokenString := r.FormValue("token")
-	if strings.TrimSpace(tokenString) != "" { // if token is in form
-
-		// validate token
-		tokenClaimsMap := utils.ValidateJWT(tokenString)
-		if tokenClaimsMap != nil { // if token valid
-
-			// check if user is in DB
-			user, err := model.GetUser(a.DB, tokenClaimsMap["email"], 0)
-			if err == nil { // if user exist
-
-				// check if user session is in DB
-				userSession, err := model.GetUserSession(a.DB, tokenString, user.ID)
-				if err == nil && userSession.ID != 0 { // if user session exist
-					log.Println(strconv.Quote("POST /api/authorize/"), "200 SUCCESS")
-
-					user.Password = "" // makes password empty for security purpose
-
-					isResponseData = true
-					responseData = user
-					responseStatus = 200
-				} else if err == nil && userSession.ID == 0 { // if user session not exist
-					log.Println(strconv.Quote("POST /api/authorize/"), "400 BAD REQUEST")
-
-					isResponseData = false
-					responseMessage["message"] = "Token not valid"
-					responseStatus = 400
-				} else { // if error encountered
-					log.Println(strconv.Quote("POST /api/authorize/"), "500 INTERNAL SERVER ERROR")
-					log.Println(err.Error())
-
-					isResponseData = false
-					responseMessage["message"] = err.Error()
-					responseStatus = 500
-				}
+	// check token in request or not
+	tokenString := getRequestToken(r)
+	if strings.TrimSpace(tokenString) != "" { // if token is in request
 
-			} else { // if user not exist
+		// validate token and check if user session is in DB
+		userSession, status, err := a.authenticateToken(tokenString)
+		if status == 200 && err == nil { // if user session exist
+			// get user with permissions of its role
+			user := userSession.User
+			user.Password = "" // makes password empty for security purpose
+			user.Role = model.EffectiveRole(a.Config, user)
+			user.Status = model.EffectiveStatus(user)
+			user.Permissions, err = a.Store.GetRolePermissions(user.Role)
+
+			if err == nil { // if get permissions success
+				log.Println(strconv.Quote("POST /api/authorize/"), "200 SUCCESS")
+
+				isResponseData = true
+				responseData = user
+				responseStatus = 200
+			} else { // if there's an error when get permissions
 				log.Println(strconv.Quote("POST /api/authorize/"), "500 INTERNAL SERVER ERROR")
 				log.Println(err.Error())
 
@@ -295,16 +745,22 @@ func (a *API) AuthorizeHandler(w http.ResponseWriter, r *http.Request) {
 				responseMessage["message"] = err.Error()
 				responseStatus = 500
 			}
-
-		} else { // if token not valid
+		} else if status == 400 { // if token not valid or user session not exist
 			log.Println(strconv.Quote("POST /api/authorize/"), "400 BAD REQUEST")
 
 			isResponseData = false
 			responseMessage["message"] = "Token not valid"
 			responseStatus = 400
+		} else { // if error encountered
+			log.Println(strconv.Quote("POST /api/authorize/"), "500 INTERNAL SERVER ERROR")
+			log.Println(err.Error())
+
+			isResponseData = false
+			responseMessage["message"] = err.Error()
+			responseStatus = 500
 		}
 
-	} else { // if token not in form
+	} else { // if token not in request
 		log.Println(strconv.Quote("POST /api/authorize/"), "400 BAD REQUEST")
 
 		isResponseData = false
@@ -335,13 +791,13 @@ func (a *API) LogoutHandler(w http.ResponseWriter, r *http.Request) {
 	var responseStatus int
 
 	// allow host
-	w.Header().Set("Access-Control-Allow-Origin", config.FrontendURL)
+	w.Header().Set("Access-Control-Allow-Origin", a.Config.FrontendURL)
 
 	// check token in form
 	tokenString := r.FormValue("token")
 	if strings.TrimSpace(r.FormValue("token")) != "" { // if token exist
 		// delete user session
-		err := model.DeleteUserSession(a.DB, tokenString)
+		err := a.Store.DeleteUserSession(tokenString)
 		if err == nil { // if delete success
 			log.Println(strconv.Quote("POST /api/logout/"), "200 SUCCESS")
 			responseContent = map[string]any{
@@ -381,7 +837,7 @@ func (a *API) GetUserHandler(w http.ResponseWriter, r *http.Request) {
 	responseData := model.User{}
 
 	// allow host
-	w.Header().Set("Access-Control-Allow-Origin", config.ProductServiceURL)
+	w.Header().Set("Access-Control-Allow-Origin", a.Config.ProductServiceURL)
 
 	// check id in params or not
 	stringID := r.FormValue("id")
@@ -392,7 +848,7 @@ func (a *API) GetUserHandler(w http.ResponseWriter, r *http.Request) {
 		if err == nil { // if convert success
 
 			// get user
-			user, err := model.GetUser(a.DB, "", ID)
+			user, err := a.Store.GetUser("", ID)
 			if err == nil { // if get user success
 				log.Println(strconv.Quote("GET /api/user/"), "200 SUCCESS")
 
@@ -427,7 +883,7 @@ func (a *API) GetUserHandler(w http.ResponseWriter, r *http.Request) {
 		if strings.TrimSpace(email) != "" { // if email valid
 
 			// get user
-			user, err := model.GetUser(a.DB, email, 0)
+			user, err := a.Store.GetUser(email, 0)
 			if err == nil { // if get user success
 				log.Println(strconv.Quote("GET /api/user/"), "200 SUCCESS")
 
@@ -472,3 +928,198 @@ func (a *API) GetUserHandler(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(responseStatus)
 	w.Write(response)
 }
+
+// JWKSHandler handling route get JSON Web Key Set (method: GET)
+//
+// The key set is public, so another service can fetch it
+// and verify jwt token offline
+func (a *API) JWKSHandler(w http.ResponseWriter, r *http.Request) {
+	// allow all host
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	log.Println(strconv.Quote("GET /.well-known/jwks.json"), "200 SUCCESS")
+
+	response, marshalErr := json.Marshal(utils.GetJWKS(a.Config.JWTKeys))
+	if marshalErr != nil {
+		log.Fatal("Error When Creating Response -> ", marshalErr)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	w.WriteHeader(200)
+	w.Write(response)
+}
+
+// getRequestToken get access token from request,
+// from "Authorization: Bearer <token>" header or from "token" form value
+func getRequestToken(r *http.Request) string {
+	authorization := r.Header.Get("Authorization")
+	if strings.HasPrefix(authorization, "Bearer ") {
+		return strings.TrimSpace(strings.TrimPrefix(authorization, "Bearer "))
+	}
+
+	return r.FormValue("token")
+}
+
+// maximum size of urlencoded or JSON request body parsed by parseDeleteRequestBody
+// (and memory used by multipart body), the same as Go's limit of urlencoded form body
+const maxDeleteRequestBodySize = 10 << 20
+
+// parseDeleteRequestBody parse multipart, urlencoded, or JSON object body
+// of DELETE request into request form, so the body values can be read by FormValue
+//
+// Go only parse urlencoded body of POST, PUT, and PATCH request.
+// Only string values of JSON object used.
+func parseDeleteRequestBody(r *http.Request) error {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "multipart/form-data" {
+		return r.ParseMultipartForm(maxDeleteRequestBodySize)
+	}
+
+	// parse query values, body of DELETE request not parsed
+	err := r.ParseForm()
+	if err != nil || r.Body == nil {
+		return err
+	}
+
+	var values url.Values
+	switch mediaType {
+	case "application/x-www-form-urlencoded":
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxDeleteRequestBodySize))
+		if err != nil {
+			return err
+		}
+
+		values, err = url.ParseQuery(string(body))
+		if err != nil {
+			return err
+		}
+	case "application/json":
+		fields := map[string]any{}
+		err = json.NewDecoder(io.LimitReader(r.Body, maxDeleteRequestBodySize)).
+			Decode(&fields)
+		if err != nil && err != io.EOF { // empty body has no values
+			return err
+		}
+
+		values = url.Values{}
+		for key, value := range fields {
+			if stringValue, ok := value.(string); ok {
+				values.Set(key, stringValue)
+			}
+		}
+	default:
+		return nil
+	}
+
+	// body values before query values, the same as ParseForm
+	for key, bodyValues := range values {
+		r.PostForm[key] = append(r.PostForm[key], bodyValues...)
+		r.Form[key] = append(bodyValues, r.Form[key]...)
+	}
+
+	return nil
+}
+
+// getValidationErrorResponse get response content of form validation errors,
+// every invalid field has its own error
+func getValidationErrorResponse(errs form.FieldErrors) map[string]any {
+	return map[string]any{
+		"message": "Validation failed",
+		"code":    "validation_failed",
+		"errors":  errs,
+	}
+}
+
+// getLoginRetryAfter get seconds until login of an account email
+// and source IP address allowed again, used as Retry-After header
+func (a *API) getLoginRetryAfter(email string, IPAddress string) string {
+	_, lockedUntil, err := a.Store.CheckLoginLockout(email, IPAddress)
+	if err != nil || !lockedUntil.After(time.Now().UTC()) {
+		return "1"
+	}
+
+	return strconv.Itoa(int(math.Ceil(time.Until(lockedUntil).Seconds())))
+}
+
+// getRequestIP get IP address of request client
+func getRequestIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
+
+// authenticateToken validate access token and get its user session
+//
+// Return status 200 if token valid, 400 if token not valid or
+// user session not exist, and 500 if there's an error
+func (a *API) authenticateToken(tokenString string) (model.UserSession, int, error) {
+	// validate token
+	tokenClaimsMap := utils.ValidateJWT(a.Config.JWTKeys, tokenString)
+	if tokenClaimsMap == nil {
+		return model.UserSession{}, 400, nil
+	}
+
+	// check if user is in DB, get user by ID or by email
+	// for token generated before identified by user ID
+	var user model.User
+	var err error
+	if tokenClaimsMap["sub"] != "" {
+		userID, atoiErr := strconv.Atoi(tokenClaimsMap["sub"])
+		if atoiErr != nil {
+			return model.UserSession{}, 400, nil
+		}
+		user, err = a.Store.GetUser("", userID)
+	} else {
+		user, err = a.Store.GetUser(tokenClaimsMap["email"], 0)
+	}
+	if err == sql.ErrNoRows {
+		return model.UserSession{}, 400, nil
+	} else if err != nil {
+		return model.UserSession{}, 500, err
+	}
+
+	// check user still can use its account (e.g. not suspended or deleted)
+	if !model.IsUserStatusUsable(model.EffectiveStatus(user)) {
+		return model.UserSession{}, 400, nil
+	}
+
+	// check if user session is in DB
+	userSession, err := a.Store.GetUserSession(tokenString, user.ID)
+	if err == sql.ErrNoRows {
+		return userSession, 400, nil
+	} else if err != nil {
+		return userSession, 500, err
+	}
+
+	// update user session last seen,
+	// only once a minute so not every request write into DB
+	if time.Since(userSession.LastSeenAt) > time.Minute {
+		err = a.Store.UpdateUserSessionLastSeen(userSession.ID)
+		if err != nil {
+			return userSession, 500, err
+		}
+	}
+
+	return userSession, 200, nil
+}
+
+// authorizePermission check user role (effective by email verification policy)
+// has a permission or not
+//
+// Return status 200 if user has the permission, 403 if not,
+// and 500 if there's an error
+func (a *API) authorizePermission(user model.User, permission string) (int, error) {
+	hasPermission, err := a.Store.HasPermission(model.EffectiveRole(a.Config, user),
+		permission)
+	if err != nil {
+		return 500, err
+	}
+	if !hasPermission {
+		return 403, nil
+	}
+
+	return 200, nil
+}
@@ -17,29 +17,58 @@ import (
 	"testing"
 
 	"github.com/reyhanfikridz/ecom-account-service/internal/config"
+	"github.com/reyhanfikridz/ecom-account-service/internal/event"
+	"github.com/reyhanfikridz/ecom-account-service/internal/mailer"
+	"github.com/reyhanfikridz/ecom-account-service/internal/sms"
+	"github.com/reyhanfikridz/ecom-account-service/internal/store"
 	"github.com/reyhanfikridz/ecom-account-service/internal/utils"
 )
 
+// config of all testing in the package
+var testConfig config.Config
+
 // TestMain do some test before and after all testing in the package
 func TestMain(m *testing.M) {
-	// init all config before can be used
-	err := config.InitConfig()
+	// load config before can be used
+	var err error
+	testConfig, _, err = config.Load(nil)
 	if err != nil {
 		log.Fatalf("There's an error when initialize config => %s", err)
 	}
 
+	// no delay after failed login, so testing can login right after
+	// testing wrong password (lockout still applied after the threshold)
+	testConfig.LoginBackoffBase = 0
+
+	// no rate limit, so testing can send many requests
+	// (rate limit enabled in its own testing)
+	testConfig.RateLimitEnabled = false
+
+	// no retry when ping database, so testing fail fast
+	// if testing database not running
+	testConfig.DBPingAttempts = 1
+
 	// run all testing
 	m.Run()
 }
 
 // TestInitDB test InitDB
 func TestInitDB(t *testing.T) {
-	a := API{}
+	a := API{Config: testConfig}
 
 	DBConfig := map[string]string{
-		"user":     config.DBUsername,
-		"password": config.DBPassword,
-		"dbname":   config.DBName,
+		"driver":          testConfig.DBDriver,
+		"user":            testConfig.DBUsername,
+		"password":        testConfig.DBPassword,
+		"dbname":          testConfig.DBName,
+		"host":            testConfig.DBHost,
+		"port":            testConfig.DBPort,
+		"sslmode":         testConfig.DBSSLMode,
+		"sslrootcert":     testConfig.DBSSLRootCert,
+		"sslcert":         testConfig.DBSSLCert,
+		"sslkey":          testConfig.DBSSLKey,
+		"connect_timeout": testConfig.DBConnectTimeout.String(),
+		"path":            testConfig.DBPath,
 	}
 	err := a.InitDB(DBConfig)
 	if err != nil {
@@ -50,7 +79,7 @@ func TestInitDB(t *testing.T) {
 
 // TestInitRouter test InitRouter
 func TestInitRouter(t *testing.T) {
-	a := API{}
+	a := API{Config: testConfig}
 	err := a.InitRouter()
 	if err != nil {
 		t.Errorf("Expected router initialization success,"+
@@ -76,11 +105,11 @@ func TestRegisterHandler(t *testing.T) {
 		{
 			FormData: map[string]io.Reader{
 				"email":        strings.NewReader("testregister@gmail.com"),
-				"password":     strings.NewReader("test"),
+				"password":     strings.NewReader("testpassword1"),
 				"full_name":    strings.NewReader("test"),
 				"address":      strings.NewReader("test"),
-				"phone_number": strings.NewReader("test"),
-				"role":         strings.NewReader("test"),
+				"phone_number": strings.NewReader("08111111111"),
+				"role":         strings.NewReader("buyer"),
 			},
 			ExpectedStatus:  200,
 			ExpectedBodyKey: []string{"message", "id"},
@@ -89,11 +118,11 @@ func TestRegisterHandler(t *testing.T) {
 		{
 			FormData: map[string]io.Reader{
 				"email":        strings.NewReader("testregister@gmail.com"),
-				"password":     strings.NewReader("test"),
+				"password":     strings.NewReader("testpassword1"),
 				"full_name":    strings.NewReader("test"),
 				"address":      strings.NewReader("test"),
-				"phone_number": strings.NewReader("test"),
-				"role":         strings.NewReader("test"),
+				"phone_number": strings.NewReader("08111111111"),
+				"role":         strings.NewReader("buyer"),
 			},
 			ExpectedStatus:  400,
 			ExpectedBodyKey: []string{"message"},
@@ -102,14 +131,14 @@ func TestRegisterHandler(t *testing.T) {
 		{
 			FormData: map[string]io.Reader{
 				"email":        strings.NewReader(""),
-				"password":     strings.NewReader("test"),
+				"password":     strings.NewReader("testpassword1"),
 				"full_name":    strings.NewReader("test"),
 				"address":      strings.NewReader("test"),
-				"phone_number": strings.NewReader("test"),
-				"role":         strings.NewReader("test"),
+				"phone_number": strings.NewReader("08111111111"),
+				"role":         strings.NewReader("buyer"),
 			},
-			ExpectedStatus:  400,
-			ExpectedBodyKey: []string{"message"},
+			ExpectedStatus:  422,
+			ExpectedBodyKey: []string{"message", "code", "errors"},
 			DeleteDataFirst: true,
 		},
 		{
@@ -118,62 +147,88 @@ func TestRegisterHandler(t *testing.T) {
 				"password":     strings.NewReader(""),
 				"full_name":    strings.NewReader("test"),
 				"address":      strings.NewReader("test"),
-				"phone_number": strings.NewReader("test"),
-				"role":         strings.NewReader("test"),
+				"phone_number": strings.NewReader("08111111111"),
+				"role":         strings.NewReader("buyer"),
 			},
-			ExpectedStatus:  400,
+			ExpectedStatus:  422,
 			ExpectedBodyKey: []string{"message"},
 			DeleteDataFirst: true,
 		},
 		{
 			FormData: map[string]io.Reader{
 				"email":        strings.NewReader("testregister@gmail.com"),
-				"password":     strings.NewReader("test"),
+				"password":     strings.NewReader("testpassword1"),
 				"full_name":    strings.NewReader(""),
 				"address":      strings.NewReader("test"),
-				"phone_number": strings.NewReader("test"),
-				"role":         strings.NewReader("test"),
+				"phone_number": strings.NewReader("08111111111"),
+				"role":         strings.NewReader("buyer"),
 			},
-			ExpectedStatus:  400,
+			ExpectedStatus:  422,
 			ExpectedBodyKey: []string{"message"},
 			DeleteDataFirst: true,
 		},
 		{
 			FormData: map[string]io.Reader{
 				"email":        strings.NewReader("testregister@gmail.com"),
-				"password":     strings.NewReader("test"),
+				"password":     strings.NewReader("testpassword1"),
 				"full_name":    strings.NewReader("test"),
 				"address":      strings.NewReader(""),
-				"phone_number": strings.NewReader("test"),
-				"role":         strings.NewReader("test"),
+				"phone_number": strings.NewReader("08111111111"),
+				"role":         strings.NewReader("buyer"),
 			},
-			ExpectedStatus:  400,
+			ExpectedStatus:  422,
 			ExpectedBodyKey: []string{"message"},
 			DeleteDataFirst: true,
 		},
 		{
 			FormData: map[string]io.Reader{
 				"email":        strings.NewReader("testregister@gmail.com"),
-				"password":     strings.NewReader("test"),
+				"password":     strings.NewReader("testpassword1"),
 				"full_name":    strings.NewReader("test"),
 				"address":      strings.NewReader("test"),
 				"phone_number": strings.NewReader(""),
-				"role":         strings.NewReader("test"),
+				"role":         strings.NewReader("buyer"),
 			},
-			ExpectedStatus:  400,
+			ExpectedStatus:  422,
 			ExpectedBodyKey: []string{"message"},
 			DeleteDataFirst: true,
 		},
 		{
 			FormData: map[string]io.Reader{
 				"email":        strings.NewReader("testregister@gmail.com"),
-				"password":     strings.NewReader("test"),
+				"password":     strings.NewReader("testpassword1"),
 				"full_name":    strings.NewReader("test"),
 				"address":      strings.NewReader("test"),
-				"phone_number": strings.NewReader("test"),
+				"phone_number": strings.NewReader("08111111111"),
 				"role":         strings.NewReader(""),
 			},
-			ExpectedStatus:  400,
+			ExpectedStatus:  422,
+			ExpectedBodyKey: []string{"message"},
+			DeleteDataFirst: true,
+		},
+		{
+			FormData: map[string]io.Reader{
+				"email":        strings.NewReader("testregister@gmail.com"),
+				"password":     strings.NewReader("testpassword1"),
+				"full_name":    strings.NewReader("test"),
+				"address":      strings.NewReader("test"),
+				"phone_number": strings.NewReader("08111111111"),
+				"role":         strings.NewReader("admin"),
+			},
+			ExpectedStatus:  422,
+			ExpectedBodyKey: []string{"message"},
+			DeleteDataFirst: true,
+		},
+		{
+			FormData: map[string]io.Reader{
+				"email":        strings.NewReader("testregister@gmail.com"),
+				"password":     strings.NewReader("testpassword1"),
+				"full_name":    strings.NewReader("test"),
+				"address":      strings.NewReader("test"),
+				"phone_number": strings.NewReader("08111111111"),
+				"role":         strings.NewReader("notexist"),
+			},
+			ExpectedStatus:  422,
 			ExpectedBodyKey: []string{"message"},
 			DeleteDataFirst: true,
 		},
@@ -278,7 +333,7 @@ func TestLoginHandler(t *testing.T) {
 				"password": strings.NewReader("test"),
 			},
 			ExpectedStatus:  200,
-			ExpectedBodyKey: []string{"message", "token", "role"},
+			ExpectedBodyKey: []string{"message", "token", "refresh_token", "role"},
 		},
 		{
 			FormData: map[string]io.Reader{
@@ -296,6 +351,14 @@ func TestLoginHandler(t *testing.T) {
 			ExpectedStatus:  400,
 			ExpectedBodyKey: []string{"message"},
 		},
+		{
+			FormData: map[string]io.Reader{
+				"email":    strings.NewReader(""),
+				"password": strings.NewReader(""),
+			},
+			ExpectedStatus:  422,
+			ExpectedBodyKey: []string{"message", "code", "errors"},
+		},
 	}
 
 	// loop test in test table
@@ -348,6 +411,145 @@ func TestLoginHandler(t *testing.T) {
 	}
 }
 
+// TestRefreshTokenHandler test RefreshTokenHandler
+func TestRefreshTokenHandler(t *testing.T) {
+	// initialize testing API
+	a, err := GetTestingAPI()
+	if err != nil {
+		t.Errorf("There's an error when getting testing API => " + err.Error())
+	}
+
+	// create user first
+	_, err = a.DB.Exec(`DELETE FROM account_user WHERE email = $1`, "testrefresh@gmail.com")
+	if err != nil {
+		t.Errorf("There's an error when deleting refresh testing data " + err.Error())
+	}
+
+	hashedPassword, err := utils.HashPassword("test")
+	if err != nil {
+		t.Errorf("There's an error when hashing password => " + err.Error())
+	}
+
+	createdRow := a.DB.QueryRow(`
+		INSERT INTO account_user(email, password, full_name, address, phone_number, role)
+			VALUES($1, $2, $3, $4, $5, $6)
+			RETURNING id`,
+		"testrefresh@gmail.com", hashedPassword, "test", "test", "test", "test")
+	if createdRow.Err() != nil {
+		t.Errorf(("There's an error when creating testing user data => " +
+			createdRow.Err().Error()))
+	}
+
+	// login user to get refresh token
+	var bLoginFormData bytes.Buffer
+	lw := multipart.NewWriter(&bLoginFormData)
+	lw.WriteField("email", "testrefresh@gmail.com")
+	lw.WriteField("password", "test")
+	lw.Close()
+
+	loginReq, err := http.NewRequest("POST", "/api/login/", &bLoginFormData)
+	if err != nil {
+		t.Errorf("There's an error when creating request API login => " +
+			err.Error())
+	}
+	loginReq.Header.Set("Content-Type", lw.FormDataContentType())
+
+	loginResponse := httptest.NewRecorder()
+	a.Router.ServeHTTP(loginResponse, loginReq)
+
+	var loginResponseData map[string]any
+	err = json.Unmarshal(loginResponse.Body.Bytes(), &loginResponseData)
+	if err != nil {
+		t.Errorf("There's an error when unmarshal login body response => " + err.Error())
+	}
+	refreshToken, _ := loginResponseData["refresh_token"].(string)
+
+	// initialize testing table
+	testTable := []struct {
+		FormData        map[string]io.Reader
+		ExpectedStatus  int
+		ExpectedBodyKey []string
+	}{
+		{
+			FormData: map[string]io.Reader{
+				"refresh_token": strings.NewReader(refreshToken),
+			},
+			ExpectedStatus:  200,
+			ExpectedBodyKey: []string{"message", "token", "refresh_token"},
+		},
+		{
+			FormData: map[string]io.Reader{
+				"refresh_token": strings.NewReader(refreshToken),
+			},
+			ExpectedStatus:  400,
+			ExpectedBodyKey: []string{"message"},
+		},
+		{
+			FormData: map[string]io.Reader{
+				"refresh_token": strings.NewReader("Invalid Refresh Token"),
+			},
+			ExpectedStatus:  400,
+			ExpectedBodyKey: []string{"message"},
+		},
+		{
+			FormData: map[string]io.Reader{
+				"refresh_token": strings.NewReader(""),
+			},
+			ExpectedStatus:  422,
+			ExpectedBodyKey: []string{"message", "code", "errors"},
+		},
+	}
+
+	// loop test in test table
+	for _, test := range testTable {
+		// transform form data to bytes buffer
+		var bFormData bytes.Buffer
+		w := multipart.NewWriter(&bFormData)
+		for key, r := range test.FormData {
+			fw, err := w.CreateFormField(key)
+			if err != nil {
+				t.Errorf("There's an error when creating bytes buffer form data => " +
+					err.Error())
+			}
+
+			_, err = io.Copy(fw, r)
+			if err != nil {
+				t.Errorf("There's an error when creating bytes buffer form data => " +
+					err.Error())
+			}
+		}
+		w.Close()
+
+		// create new request
+		req, err := http.NewRequest("POST", "/api/token/refresh/", &bFormData)
+		if err != nil {
+			t.Errorf("There's an error when creating request API refresh token => " +
+				err.Error())
+		}
+		req.Header.Set("Content-Type", w.FormDataContentType())
+
+		// run request
+		response := httptest.NewRecorder()
+		a.Router.ServeHTTP(response, req)
+
+		// check response
+		if response.Code != test.ExpectedStatus {
+			t.Errorf("Expected status %d got %d", test.ExpectedStatus, response.Code)
+		}
+
+		var responseData map[string]any
+		err = json.Unmarshal(response.Body.Bytes(), &responseData)
+		if err != nil {
+			t.Errorf("There's an error when unmarshal body response => " + err.Error())
+		}
+		for _, expectedKey := range test.ExpectedBodyKey {
+			if responseData[expectedKey] == nil {
+				t.Errorf("Expected key " + expectedKey + " empty/not found")
+			}
+		}
+	}
+}
+
 // TestAuthorizeHandler test AuthorizeHandler
 func TestAuthorizeHandler(t *testing.T) {
 	// initialize testing API
@@ -371,7 +573,7 @@ func TestAuthorizeHandler(t *testing.T) {
 		INSERT INTO account_user(email, password, full_name, address, phone_number, role)
 			VALUES($1, $2, $3, $4, $5, $6)
 			RETURNING id`,
-		"testauthorize@gmail.com", hashedPassword, "test", "test", "test", "test")
+		"testauthorize@gmail.com", hashedPassword, "test", "test", "test", "buyer")
 	if createdRow.Err() != nil {
 		t.Errorf("There's an error when creating testing user data => " +
 			createdRow.Err().Error())
@@ -385,7 +587,8 @@ func TestAuthorizeHandler(t *testing.T) {
 	}
 
 	// create user session
-	validToken, err := utils.GenerateJWT("testauthorize@gmail.com", "test")
+	validToken, err := utils.GenerateJWT(a.Config.JWTKeys, userID, "buyer",
+		a.Config.AccessTokenDuration)
 	if err != nil {
 		t.Errorf("There's an error when creating token " +
 			"for creating user session data => " +
@@ -412,7 +615,7 @@ func TestAuthorizeHandler(t *testing.T) {
 				"token": strings.NewReader(validToken),
 			},
 			ExpectedStatus:  200,
-			ExpectedBodyKey: []string{"id", "email", "password", "address", "phone_number", "role"},
+			ExpectedBodyKey: []string{"id", "email", "address", "phone_number", "role", "permissions"},
 		},
 		{
 			FormData: map[string]io.Reader{
@@ -517,7 +720,8 @@ func TestLogoutHandler(t *testing.T) {
 	}
 
 	// create user session
-	validToken, err := utils.GenerateJWT("testlogout@gmail.com", "test")
+	validToken, err := utils.GenerateJWT(a.Config.JWTKeys, userID, "test",
+		a.Config.AccessTokenDuration)
 	if err != nil {
 		t.Errorf("There's an error when creating token " +
 			"for creating user session data => " +
@@ -702,26 +906,262 @@ func TestGetUserHandler(t *testing.T) {
 
 }
 
+// TestJWKSHandler test JWKSHandler
+func TestJWKSHandler(t *testing.T) {
+	// initialize testing API
+	a, err := GetTestingAPI()
+	if err != nil {
+		t.Errorf("There's an error when getting testing API => " + err.Error())
+	}
+
+	// create new request
+	req, err := http.NewRequest("GET", "/.well-known/jwks.json", nil)
+	if err != nil {
+		t.Errorf("There's an error when creating request API JWKS => " +
+			err.Error())
+	}
+
+	// run request
+	response := httptest.NewRecorder()
+	a.Router.ServeHTTP(response, req)
+
+	// check response
+	if response.Code != 200 {
+		t.Errorf("Expected status %d got %d", 200, response.Code)
+	}
+
+	var responseData map[string]any
+	err = json.Unmarshal(response.Body.Bytes(), &responseData)
+	if err != nil {
+		t.Errorf("There's an error when unmarshal body response => " + err.Error())
+	}
+	if responseData["keys"] == nil {
+		t.Errorf("Expected key keys empty/not found")
+	}
+}
+
+// TestAuthHandlersMemoryStore test register, login, authorize,
+// and refresh token handlers with memory store
+func TestAuthHandlersMemoryStore(t *testing.T) {
+	// initialize testing API
+	a, err := GetTestingMemoryAPI()
+	if err != nil {
+		t.Errorf("There's an error when getting testing API => " + err.Error())
+	}
+
+	// initialize testing table, run in order,
+	// {token} and {refresh_token} in form data replaced
+	// by the last token and refresh token responded
+	testTable := []struct {
+		URL             string
+		FormData        map[string]string
+		ExpectedStatus  int
+		ExpectedBodyKey []string
+	}{
+		{
+			URL: "/api/register/",
+			FormData: map[string]string{
+				"email":        "testmemoryauth@gmail.com",
+				"password":     "testpassword1",
+				"full_name":    "test",
+				"address":      "test",
+				"phone_number": "08111111111",
+				"role":         "admin",
+			},
+			ExpectedStatus:  422,
+			ExpectedBodyKey: []string{"message", "code", "errors"},
+		},
+		{
+			URL: "/api/register/",
+			FormData: map[string]string{
+				"email":        "testmemoryauth@gmail.com",
+				"password":     "testpassword1",
+				"full_name":    "test",
+				"address":      "test",
+				"phone_number": "08111111111",
+				"role":         "buyer",
+			},
+			ExpectedStatus:  200,
+			ExpectedBodyKey: []string{"message", "id"},
+		},
+		{
+			URL: "/api/login/",
+			FormData: map[string]string{
+				"email":    "testmemoryauth@gmail.com",
+				"password": "wrongpassword1",
+			},
+			ExpectedStatus:  400,
+			ExpectedBodyKey: []string{"message"},
+		},
+		{
+			URL: "/api/login/",
+			FormData: map[string]string{
+				"email":    "testmemoryauth@gmail.com",
+				"password": "testpassword1",
+			},
+			ExpectedStatus:  200,
+			ExpectedBodyKey: []string{"message", "token", "refresh_token", "role"},
+		},
+		{
+			URL:             "/api/authorize/",
+			FormData:        map[string]string{"token": "{token}"},
+			ExpectedStatus:  200,
+			ExpectedBodyKey: []string{"id", "email", "role", "permissions"},
+		},
+		{
+			URL:             "/api/token/refresh/",
+			FormData:        map[string]string{"refresh_token": "{refresh_token}"},
+			ExpectedStatus:  200,
+			ExpectedBodyKey: []string{"message", "token", "refresh_token"},
+		},
+		{
+			URL:             "/api/authorize/",
+			FormData:        map[string]string{"token": "{token}"},
+			ExpectedStatus:  200,
+			ExpectedBodyKey: []string{"id", "email", "role", "permissions"},
+		},
+		{
+			URL:             "/api/token/refresh/",
+			FormData:        map[string]string{"refresh_token": "notvalid"},
+			ExpectedStatus:  400,
+			ExpectedBodyKey: []string{"message"},
+		},
+	}
+
+	// loop test in test table
+	token, refreshToken := "", ""
+	for _, test := range testTable {
+		// transform form data to bytes buffer
+		var bFormData bytes.Buffer
+		w := multipart.NewWriter(&bFormData)
+		for key, value := range test.FormData {
+			value = strings.Replace(value, "{token}", token, 1)
+			value = strings.Replace(value, "{refresh_token}", refreshToken, 1)
+			err = w.WriteField(key, value)
+			if err != nil {
+				t.Errorf("There's an error when creating bytes buffer form data => " +
+					err.Error())
+			}
+		}
+		w.Close()
+
+		// create new request
+		req, err := http.NewRequest("POST", test.URL, &bFormData)
+		if err != nil {
+			t.Errorf("There's an error when creating request " + test.URL + " => " +
+				err.Error())
+		}
+		req.Header.Set("Content-Type", w.FormDataContentType())
+
+		// run request
+		response := httptest.NewRecorder()
+		a.Router.ServeHTTP(response, req)
+
+		// check response
+		if response.Code != test.ExpectedStatus {
+			t.Errorf("Expected status %d got %d (%s)", test.ExpectedStatus, response.Code,
+				test.URL)
+		}
+
+		var responseData map[string]any
+		err = json.Unmarshal(response.Body.Bytes(), &responseData)
+		if err != nil {
+			t.Errorf("There's an error when unmarshal body response => " + err.Error())
+		}
+		for _, expectedKey := range test.ExpectedBodyKey {
+			if responseData[expectedKey] == nil {
+				t.Errorf("Expected key " + expectedKey + " empty/not found (" + test.URL + ")")
+			}
+		}
+
+		// keep the last tokens responded
+		if responseToken, ok := responseData["token"].(string); ok {
+			token = responseToken
+		}
+		if responseRefreshToken, ok := responseData["refresh_token"].(string); ok {
+			refreshToken = responseRefreshToken
+		}
+	}
+
+	// address given when register become the first address book entry
+	u, err := a.Store.GetUser("testmemoryauth@gmail.com", 0)
+	if err != nil {
+		t.Fatalf("There's an error when getting registered user => " + err.Error())
+	}
+	addresses, err := a.Store.GetAddresses(u.ID)
+	if err != nil || len(addresses) != 1 || addresses[0].Line1 != "test" {
+		t.Errorf("Expected 1 address from register, but got %+v error %v", addresses, err)
+	}
+}
+
 // GetTestingAPI get API for testing
 func GetTestingAPI() (API, error) {
-	a := API{}
+	return GetTestingAPIWithConfig(testConfig)
+}
+
+// GetTestingAPIWithConfig get API for testing with a config,
+// so a testing can change the config used by the handlers
+func GetTestingAPIWithConfig(c config.Config) (API, error) {
+	a := API{Config: c}
 
 	// init database
 	DBConfig := map[string]string{
-		"user":     config.DBUsername,
-		"password": config.DBPassword,
-		"dbname":   config.DBTestName,
+		"driver":          c.DBDriver,
+		"user":            c.DBUsername,
+		"password":        c.DBPassword,
+		"dbname":          c.DBTestName,
+		"host":            c.DBHost,
+		"port":            c.DBPort,
+		"sslmode":         c.DBSSLMode,
+		"sslrootcert":     c.DBSSLRootCert,
+		"sslcert":         c.DBSSLCert,
+		"sslkey":          c.DBSSLKey,
+		"connect_timeout": c.DBConnectTimeout.String(),
+		"path":            c.DBTestPath,
 	}
 	err := a.InitDB(DBConfig)
 	if err != nil {
 		return a, err
 	}
 
+	// clear failed login attempts of previous testing
+	_, err = a.DB.Exec(`DELETE FROM account_loginfailure`)
+	if err != nil {
+		return a, err
+	}
+
 	// init router
 	err = a.InitRouter()
 	if err != nil {
 		return a, err
 	}
 
+	// keep all sent emails and published events in memory
+	a.Mailer = &mailer.MemoryMailer{}
+	a.SMS = &sms.MemorySender{}
+	a.Events = &event.MemoryPublisher{}
+
+	return a, nil
+}
+
+// GetTestingMemoryAPI get API for testing with memory store,
+// without database
+func GetTestingMemoryAPI() (API, error) {
+	a := API{
+		Config: testConfig,
+		Store:  store.NewMemoryStore(testConfig),
+	}
+
+	// init router
+	err := a.InitRouter()
+	if err != nil {
+		return a, err
+	}
+
+	// keep all sent emails and published events in memory
+	a.Mailer = &mailer.MemoryMailer{}
+	a.SMS = &sms.MemorySender{}
+	a.Events = &event.MemoryPublisher{}
+
 	return a, nil
 }
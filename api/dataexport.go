@@ -0,0 +1,310 @@
+/*
+Package api containing API initialization and API route handler
+*/
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/reyhanfikridz/ecom-account-service/internal/dataexport"
+	"github.com/reyhanfikridz/ecom-account-service/internal/model"
+)
+
+// ExportUserMeHandler handling route request personal data export
+// of the logged in user (method: POST)
+//
+// The export built in background, the user can poll the export status
+// and also notified by email with download link when the export ready
+func (a *API) ExportUserMeHandler(w http.ResponseWriter, r *http.Request) {
+	var responseContent map[string]any
+	var responseStatus int
+
+	// allow host
+	w.Header().Set("Access-Control-Allow-Origin", a.Config.FrontendURL)
+
+	// check token in request
+	tokenString := getRequestToken(r)
+	if strings.TrimSpace(tokenString) != "" { // if token exist
+		userSession, status, err := a.authenticateToken(tokenString)
+		if status == 200 && err == nil { // if token valid
+			dataExport, _, err := a.Store.CreateDataExport(userSession.User.ID)
+			if err == nil { // if create data export success
+				log.Println(strconv.Quote("POST /api/user/me/export/"), "202 ACCEPTED")
+				responseContent = map[string]any{
+					"message": "Data export requested!",
+					"id":      dataExport.ID,
+					"status":  dataExport.Status,
+				}
+				responseStatus = 202
+			} else { // if there's an error when create data export
+				log.Println(strconv.Quote("POST /api/user/me/export/"), "500 INTERNAL SERVER ERROR")
+				log.Println(err.Error())
+				responseContent = map[string]any{
+					"message": err.Error(),
+				}
+				responseStatus = 500
+			}
+
+		} else if status == 400 { // if token not valid
+			log.Println(strconv.Quote("POST /api/user/me/export/"), "400 BAD REQUEST")
+			responseContent = map[string]any{
+				"message": "Token not valid",
+			}
+			responseStatus = 400
+		} else { // if there's internal server error
+			log.Println(strconv.Quote("POST /api/user/me/export/"), "500 INTERNAL SERVER ERROR")
+			log.Println(err.Error())
+			responseContent = map[string]any{
+				"message": err.Error(),
+			}
+			responseStatus = 500
+		}
+	} else { // if token not exist
+		log.Println(strconv.Quote("POST /api/user/me/export/"), "400 BAD REQUEST")
+		responseContent = map[string]any{
+			"message": "Token empty/not found",
+		}
+		responseStatus = 400
+	}
+
+	response, marshalErr := json.Marshal(responseContent)
+	if marshalErr != nil {
+		log.Fatal("Error When Creating Response -> ", marshalErr)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(responseStatus)
+	w.Write(response)
+}
+
+// GetDataExportHandler handling route get personal data export status
+// of the logged in user (method: GET)
+func (a *API) GetDataExportHandler(w http.ResponseWriter, r *http.Request) {
+	var responseContent map[string]any
+	var responseStatus int
+
+	// allow host
+	w.Header().Set("Access-Control-Allow-Origin", a.Config.FrontendURL)
+
+	// check token in request
+	tokenString := getRequestToken(r)
+	if strings.TrimSpace(tokenString) != "" { // if token exist
+		userSession, status, err := a.authenticateToken(tokenString)
+		if status == 200 && err == nil { // if token valid
+			ID, _ := strconv.Atoi(mux.Vars(r)["id"])
+			dataExport, err := a.Store.GetDataExport(ID, userSession.User.ID)
+			if err == nil { // if get data export success
+				log.Println(strconv.Quote("GET /api/user/me/export/{id}/"), "200 SUCCESS")
+				responseContent = map[string]any{
+					"data_export": dataExport,
+				}
+				if dataExport.Status == model.DataExportStatusReady {
+					responseContent["download_url"] = getDataExportDownloadPath(dataExport.ID)
+				}
+				responseStatus = 200
+			} else if err == sql.ErrNoRows { // if data export not exist
+				log.Println(strconv.Quote("GET /api/user/me/export/{id}/"), "404 NOT FOUND")
+				responseContent = map[string]any{
+					"message": "Data export not found",
+				}
+				responseStatus = 404
+			} else { // if there's an error when get data export
+				log.Println(strconv.Quote("GET /api/user/me/export/{id}/"), "500 INTERNAL SERVER ERROR")
+				log.Println(err.Error())
+				responseContent = map[string]any{
+					"message": err.Error(),
+				}
+				responseStatus = 500
+			}
+
+		} else if status == 400 { // if token not valid
+			log.Println(strconv.Quote("GET /api/user/me/export/{id}/"), "400 BAD REQUEST")
+			responseContent = map[string]any{
+				"message": "Token not valid",
+			}
+			responseStatus = 400
+		} else { // if there's internal server error
+			log.Println(strconv.Quote("GET /api/user/me/export/{id}/"), "500 INTERNAL SERVER ERROR")
+			log.Println(err.Error())
+			responseContent = map[string]any{
+				"message": err.Error(),
+			}
+			responseStatus = 500
+		}
+	} else { // if token not exist
+		log.Println(strconv.Quote("GET /api/user/me/export/{id}/"), "400 BAD REQUEST")
+		responseContent = map[string]any{
+			"message": "Token empty/not found",
+		}
+		responseStatus = 400
+	}
+
+	response, marshalErr := json.Marshal(responseContent)
+	if marshalErr != nil {
+		log.Fatal("Error When Creating Response -> ", marshalErr)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(responseStatus)
+	w.Write(response)
+}
+
+// DownloadDataExportHandler handling route download personal data export
+// archive of the logged in user (method: GET)
+//
+// Response is zip archive if success, otherwise JSON like other routes
+func (a *API) DownloadDataExportHandler(w http.ResponseWriter, r *http.Request) {
+	var responseContent map[string]any
+	var responseStatus int
+
+	// allow host
+	w.Header().Set("Access-Control-Allow-Origin", a.Config.FrontendURL)
+
+	// check token in request
+	tokenString := getRequestToken(r)
+	if strings.TrimSpace(tokenString) != "" { // if token exist
+		userSession, status, err := a.authenticateToken(tokenString)
+		if status == 200 && err == nil { // if token valid
+			ID, _ := strconv.Atoi(mux.Vars(r)["id"])
+			dataExport, err := a.Store.GetDataExport(ID, userSession.User.ID)
+
+			// only ready and not expired data export can be downloaded
+			var archive []byte
+			if err == nil {
+				if dataExport.Status != model.DataExportStatusReady ||
+					!time.Now().UTC().Before(*dataExport.ExpiredAt) {
+					err = sql.ErrNoRows
+				} else {
+					archive, err = a.Store.GetDataExportArchive(dataExport.ID)
+				}
+			}
+
+			if err == nil { // if get data export archive success
+				log.Println(strconv.Quote("GET /api/user/me/export/{id}/download/"), "200 SUCCESS")
+				w.Header().Set("Content-Type", "application/zip")
+				w.Header().Set("Content-Disposition",
+					`attachment; filename="data-export-`+strconv.Itoa(dataExport.ID)+`.zip"`)
+				w.WriteHeader(200)
+				w.Write(archive)
+				return
+			} else if err == sql.ErrNoRows { // if data export not exist, not ready, or expired
+				log.Println(strconv.Quote("GET /api/user/me/export/{id}/download/"), "404 NOT FOUND")
+				responseContent = map[string]any{
+					"message": "Data export not found",
+				}
+				responseStatus = 404
+			} else { // if there's an error when get data export archive
+				log.Println(strconv.Quote("GET /api/user/me/export/{id}/download/"),
+					"500 INTERNAL SERVER ERROR")
+				log.Println(err.Error())
+				responseContent = map[string]any{
+					"message": err.Error(),
+				}
+				responseStatus = 500
+			}
+
+		} else if status == 400 { // if token not valid
+			log.Println(strconv.Quote("GET /api/user/me/export/{id}/download/"), "400 BAD REQUEST")
+			responseContent = map[string]any{
+				"message": "Token not valid",
+			}
+			responseStatus = 400
+		} else { // if there's internal server error
+			log.Println(strconv.Quote("GET /api/user/me/export/{id}/download/"),
+				"500 INTERNAL SERVER ERROR")
+			log.Println(err.Error())
+			responseContent = map[string]any{
+				"message": err.Error(),
+			}
+			responseStatus = 500
+		}
+	} else { // if token not exist
+		log.Println(strconv.Quote("GET /api/user/me/export/{id}/download/"), "400 BAD REQUEST")
+		responseContent = map[string]any{
+			"message": "Token empty/not found",
+		}
+		responseStatus = 400
+	}
+
+	response, marshalErr := json.Marshal(responseContent)
+	if marshalErr != nil {
+		log.Fatal("Error When Creating Response -> ", marshalErr)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(responseStatus)
+	w.Write(response)
+}
+
+// getDataExportDownloadPath get download route path of a data export
+func getDataExportDownloadPath(ID int) string {
+	return "/api/user/me/export/" + strconv.Itoa(ID) + "/download/"
+}
+
+// RunDataExport run ProcessDataExports every interval until
+// the program stopped, error only logged
+func (a *API) RunDataExport(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		err := a.ProcessDataExports()
+		if err != nil {
+			log.Println("Data export failed => " + err.Error())
+		}
+	}
+}
+
+// ProcessDataExports build archive of all requested data exports,
+// notify the users by email, then delete expired data exports
+//
+// Data export that failed to be built marked as failed,
+// so the user can request a new one
+func (a *API) ProcessDataExports() error {
+	for {
+		dataExport, err := a.Store.ClaimDataExport()
+		if err == sql.ErrNoRows { // no more data export to process
+			break
+		} else if err != nil {
+			return err
+		}
+
+		// build archive
+		files, err := a.Store.GetDataExportFiles(dataExport.UserID)
+		var archive []byte
+		if err == nil {
+			archive, err = dataexport.Build(dataExport.UserID, time.Now(), files)
+		}
+		if err != nil {
+			log.Println("Data export", dataExport.ID, "failed => "+err.Error())
+			err = a.Store.FailDataExport(dataExport.ID)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		err = a.Store.CompleteDataExport(dataExport.ID, archive)
+		if err != nil {
+			return err
+		}
+
+		// notify user, only logged if failed
+		user, err := a.Store.GetUser("", dataExport.UserID)
+		if err == nil {
+			err = a.Mailer.Send(user.Email, "Your data export is ready",
+				"Your personal data export is ready to download at "+
+					a.Config.DataExportURL+"?id="+strconv.Itoa(dataExport.ID)+"\n\n"+
+					"The download link will expire in "+a.Config.DataExportDuration.String()+".")
+		}
+		if err != nil {
+			log.Println(err.Error())
+		}
+	}
+
+	return a.Store.DeleteExpiredDataExports()
+}
@@ -0,0 +1,221 @@
+/*
+Package api containing API initialization and API route handler
+*/
+package api
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/reyhanfikridz/ecom-account-service/internal/dataexport"
+	"github.com/reyhanfikridz/ecom-account-service/internal/mailer"
+	"github.com/reyhanfikridz/ecom-account-service/internal/utils"
+)
+
+// TestDataExportHandlers integration test ExportUserMeHandler,
+// ProcessDataExports, GetDataExportHandler, and DownloadDataExportHandler
+func TestDataExportHandlers(t *testing.T) {
+	// initialize testing API
+	a, err := GetTestingAPI()
+	if err != nil {
+		t.Errorf("There's an error when getting testing API => " + err.Error())
+	}
+
+	// create user with its session
+	_, err = a.DB.Exec(`DELETE FROM account_user WHERE email = $1`, "testexportuserme@gmail.com")
+	if err != nil {
+		t.Errorf("There's an error when deleting export user testing data " + err.Error())
+	}
+
+	hashedPassword, err := utils.HashPassword("test")
+	if err != nil {
+		t.Errorf("There's an error when hashing password => " + err.Error())
+	}
+
+	var userID int
+	err = a.DB.QueryRow(`
+		INSERT INTO account_user(email, password, full_name, address, phone_number, role)
+			VALUES($1, $2, $3, $4, $5, $6)
+			RETURNING id`,
+		"testexportuserme@gmail.com", hashedPassword, "test", "test", "test", "buyer").Scan(&userID)
+	if err != nil {
+		t.Errorf("There's an error when creating testing user data => " + err.Error())
+	}
+
+	token, err := utils.GenerateJWT(a.Config.JWTKeys, userID, "buyer",
+		a.Config.AccessTokenDuration)
+	if err != nil {
+		t.Errorf("There's an error when creating token " +
+			"for creating user session data => " +
+			err.Error())
+	}
+
+	_, err = a.DB.Exec(`
+		INSERT INTO account_usersession(token, account_user_id)
+			VALUES($1, $2)`, token, userID)
+	if err != nil {
+		t.Errorf("There's an error when creating testing user session data => " +
+			err.Error())
+	}
+
+	// serveRequest run request to the router with token
+	serveRequest := func(method string, URL string, token string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(method, URL, nil)
+		if err != nil {
+			t.Errorf("There's an error when creating request " + URL + " => " + err.Error())
+		}
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		response := httptest.NewRecorder()
+		a.Router.ServeHTTP(response, req)
+		return response
+	}
+
+	//////////////////// EXPORT USER ME ////////////////////
+	// initialize testing table
+	exportTestTable := []struct {
+		Token          string
+		ExpectedStatus int
+	}{
+		{
+			Token:          "",
+			ExpectedStatus: 400,
+		},
+		{
+			Token:          "notvalidtoken",
+			ExpectedStatus: 400,
+		},
+		{
+			Token:          token,
+			ExpectedStatus: 202,
+		},
+	}
+
+	// loop test in test table
+	var dataExportID int
+	for _, test := range exportTestTable {
+		response := serveRequest("POST", "/api/user/me/export/", test.Token)
+		if response.Code != test.ExpectedStatus {
+			t.Errorf("Expected status %d got %d", test.ExpectedStatus, response.Code)
+		}
+
+		var responseData map[string]any
+		err = json.Unmarshal(response.Body.Bytes(), &responseData)
+		if err != nil {
+			t.Errorf("There's an error when unmarshal body response => " + err.Error())
+		}
+		if test.ExpectedStatus == 202 {
+			ID, _ := responseData["id"].(float64)
+			dataExportID = int(ID)
+		}
+	}
+
+	// data export pending before processed
+	statusURL := "/api/user/me/export/" + strconv.Itoa(dataExportID) + "/"
+	response := serveRequest("GET", statusURL, token)
+	if response.Code != 200 {
+		t.Errorf("Expected status 200 got %d", response.Code)
+	}
+	response = serveRequest("GET", statusURL+"download/", token)
+	if response.Code != 404 {
+		t.Errorf("Expected status 404 when download pending data export, but got %d",
+			response.Code)
+	}
+
+	// process data export
+	err = a.ProcessDataExports()
+	if err != nil {
+		t.Errorf("There's an error when processing data exports => " + err.Error())
+	}
+
+	_, ok := a.Mailer.(*mailer.MemoryMailer).LastMessage("testexportuserme@gmail.com")
+	if !ok {
+		t.Errorf("Expected data export email sent, but not sent")
+	}
+
+	//////////////////// GET DATA EXPORT ////////////////////
+	// initialize testing table
+	getTestTable := []struct {
+		URL             string
+		Token           string
+		ExpectedStatus  int
+		ExpectedBodyKey []string
+	}{
+		{
+			URL:             statusURL,
+			Token:           "",
+			ExpectedStatus:  400,
+			ExpectedBodyKey: []string{"message"},
+		},
+		{
+			URL:             "/api/user/me/export/0/",
+			Token:           token,
+			ExpectedStatus:  404,
+			ExpectedBodyKey: []string{"message"},
+		},
+		{
+			URL:             statusURL,
+			Token:           token,
+			ExpectedStatus:  200,
+			ExpectedBodyKey: []string{"data_export", "download_url"},
+		},
+	}
+
+	// loop test in test table
+	for _, test := range getTestTable {
+		response := serveRequest("GET", test.URL, test.Token)
+		if response.Code != test.ExpectedStatus {
+			t.Errorf("Expected status %d got %d", test.ExpectedStatus, response.Code)
+		}
+
+		var responseData map[string]any
+		err = json.Unmarshal(response.Body.Bytes(), &responseData)
+		if err != nil {
+			t.Errorf("There's an error when unmarshal body response => " + err.Error())
+		}
+		for _, expectedKey := range test.ExpectedBodyKey {
+			if responseData[expectedKey] == nil {
+				t.Errorf("Expected key " + expectedKey + " empty/not found")
+			}
+		}
+	}
+
+	//////////////////// DOWNLOAD DATA EXPORT ////////////////////
+	response = serveRequest("GET", statusURL+"download/", token)
+	if response.Code != 200 {
+		t.Fatalf("Expected status 200 got %d", response.Code)
+	}
+	if response.Header().Get("Content-Type") != "application/zip" {
+		t.Errorf("Expected content type application/zip, but got " +
+			response.Header().Get("Content-Type"))
+	}
+
+	archive := response.Body.Bytes()
+	r, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		t.Fatalf("There's an error when reading archive => " + err.Error())
+	}
+	if len(r.File) == 0 || r.File[0].Name != dataexport.FileManifest {
+		t.Errorf("Expected manifest as first file in archive")
+	}
+
+	// data export can't be downloaded after expired
+	_, err = a.DB.Exec(`UPDATE account_dataexport SET expired_at = $1 WHERE id = $2`,
+		time.Now().UTC().Add(-time.Minute), dataExportID)
+	if err != nil {
+		t.Errorf("There's an error when expiring data export => " + err.Error())
+	}
+	response = serveRequest("GET", statusURL+"download/", token)
+	if response.Code != 404 {
+		t.Errorf("Expected status 404 when download expired data export, but got %d",
+			response.Code)
+	}
+}
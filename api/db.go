@@ -0,0 +1,150 @@
+/*
+Package api containing API initialization and API route handler
+*/
+package api
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/reyhanfikridz/ecom-account-service/internal/config"
+	"github.com/reyhanfikridz/ecom-account-service/internal/sqlite"
+)
+
+// maximum wait between database ping attempts
+const maxDBPingBackoff = 30 * time.Second
+
+// Postgres connection parameters of database config,
+// other database config keys (driver, path) not passed to Postgres
+var postgresConnParams = map[string]bool{
+	"host":            true,
+	"port":            true,
+	"user":            true,
+	"password":        true,
+	"dbname":          true,
+	"sslmode":         true,
+	"sslrootcert":     true,
+	"sslcert":         true,
+	"sslkey":          true,
+	"connect_timeout": true,
+}
+
+// ConnectDB connect API to database without migrating database schema,
+// connection pool configured and database pinged until reachable
+//
+// DB left nil if database not reachable
+func (a *API) ConnectDB(DBConfig map[string]string) error {
+	var err error
+	if getDBDriver(DBConfig) == config.DBDriverSQLite {
+		// open db file, created if not exist
+		a.DB, err = sqlite.Open(DBConfig["path"])
+	} else {
+		var connString string
+		connString, err = getPostgresConnString(DBConfig)
+		if err != nil {
+			return err
+		}
+
+		a.DB, err = sql.Open("postgres", connString)
+	}
+	if err != nil {
+		return err
+	}
+
+	a.DB.SetMaxOpenConns(a.Config.DBMaxOpenConns)
+	a.DB.SetMaxIdleConns(a.Config.DBMaxIdleConns)
+	a.DB.SetConnMaxLifetime(a.Config.DBConnMaxLifetime)
+
+	// sql.Open doesn't connect, so check the database reachable,
+	// close the connection pool if not reachable
+	err = pingDB(a.DB, a.Config.DBPingAttempts, a.Config.DBPingBackoff)
+	if err != nil {
+		a.DB.Close()
+		a.DB = nil
+		return err
+	}
+
+	return nil
+}
+
+// getDBDriver get database driver of database config, Postgres if not set
+func getDBDriver(DBConfig map[string]string) string {
+	if DBConfig["driver"] == "" {
+		return config.DBDriverPostgres
+	}
+
+	return DBConfig["driver"]
+}
+
+// getPostgresConnString get Postgres connection string of database config,
+// empty parameter not set and SSL disabled if SSL mode not set.
+// Connect timeout is a duration (e.g. "10s") rounded up to seconds.
+func getPostgresConnString(DBConfig map[string]string) (string, error) {
+	params := map[string]string{"sslmode": "disable"}
+	for key, value := range DBConfig {
+		if postgresConnParams[key] && strings.TrimSpace(value) != "" {
+			params[key] = value
+		}
+	}
+
+	if timeout, ok := params["connect_timeout"]; ok {
+		d, err := time.ParseDuration(timeout)
+		if err != nil || d < 0 {
+			return "", fmt.Errorf("database connect timeout %q not valid", timeout)
+		}
+		params["connect_timeout"] = strconv.Itoa(int(math.Ceil(d.Seconds())))
+	}
+
+	// sorted so the connection string always the same
+	keys := []string{}
+	for key := range params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	// value quoted so it can contain space or quote (e.g. password)
+	connParams := []string{}
+	for _, key := range keys {
+		value := strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(params[key])
+		connParams = append(connParams, key+"='"+value+"'")
+	}
+
+	return strings.Join(connParams, " "), nil
+}
+
+// pinger database connection that can be pinged
+type pinger interface {
+	Ping() error
+}
+
+// pingDB ping database until success as many as attempts,
+// wait backoff after the first failed ping and doubled after every failed ping
+func pingDB(DB pinger, attempts int, backoff time.Duration) error {
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		err = DB.Ping()
+		if err == nil {
+			return nil
+		}
+		if attempt == attempts {
+			break
+		}
+
+		log.Println("Database ping", attempt, "of", attempts, "failed, retry in",
+			backoff.String(), "=> "+err.Error())
+		time.Sleep(backoff)
+
+		backoff *= 2
+		if backoff > maxDBPingBackoff {
+			backoff = maxDBPingBackoff
+		}
+	}
+
+	return err
+}
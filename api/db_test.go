@@ -0,0 +1,137 @@
+/*
+Package api containing API initialization and API route handler
+*/
+package api
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/reyhanfikridz/ecom-account-service/internal/config"
+)
+
+// TestGetPostgresConnString test getPostgresConnString
+func TestGetPostgresConnString(t *testing.T) {
+	// initialize testing table
+	testTable := []struct {
+		DBConfig           map[string]string
+		ExpectedConnString string
+		IsErrorExpected    bool
+	}{
+		{
+			DBConfig: map[string]string{
+				"driver":   "postgres",
+				"user":     "user",
+				"password": "password",
+				"dbname":   "db",
+				"path":     "test.db",
+			},
+			ExpectedConnString: "dbname='db' password='password' sslmode='disable' user='user'",
+		},
+		{
+			DBConfig: map[string]string{
+				"host":            "db.example.com",
+				"port":            "6543",
+				"user":            "user",
+				"password":        `pass 'word\`,
+				"dbname":          "db",
+				"sslmode":         "verify-full",
+				"sslrootcert":     "/etc/ssl/root.crt",
+				"sslcert":         "",
+				"sslkey":          "",
+				"connect_timeout": "1500ms",
+			},
+			ExpectedConnString: "connect_timeout='2' dbname='db' host='db.example.com' " +
+				`password='pass \'word\\' port='6543' sslmode='verify-full' ` +
+				"sslrootcert='/etc/ssl/root.crt' user='user'",
+		},
+		{
+			DBConfig:        map[string]string{"connect_timeout": "10"},
+			IsErrorExpected: true,
+		},
+	}
+
+	// test
+	for _, test := range testTable {
+		connString, err := getPostgresConnString(test.DBConfig)
+		if test.IsErrorExpected {
+			if err == nil {
+				t.Errorf("Expected error, but got nil")
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("Expected error nil, but got not nil => " + err.Error())
+		} else if connString != test.ExpectedConnString {
+			t.Errorf("Expected connection string %q, but got %q",
+				test.ExpectedConnString, connString)
+		}
+	}
+}
+
+// testPinger pinger failing until the number of failures reached
+type testPinger struct {
+	failures int
+	pings    int
+}
+
+func (p *testPinger) Ping() error {
+	p.pings++
+	if p.pings <= p.failures {
+		return errors.New("connection refused")
+	}
+
+	return nil
+}
+
+// TestPingDB test pingDB
+func TestPingDB(t *testing.T) {
+	// initialize testing table
+	testTable := []struct {
+		Failures        int
+		Attempts        int
+		ExpectedPings   int
+		IsErrorExpected bool
+	}{
+		{Failures: 0, Attempts: 3, ExpectedPings: 1},
+		{Failures: 2, Attempts: 3, ExpectedPings: 3},
+		{Failures: 3, Attempts: 3, ExpectedPings: 3, IsErrorExpected: true},
+		{Failures: 1, Attempts: 1, ExpectedPings: 1, IsErrorExpected: true},
+	}
+
+	// test
+	for _, test := range testTable {
+		p := &testPinger{failures: test.Failures}
+		err := pingDB(p, test.Attempts, time.Millisecond)
+		if test.IsErrorExpected && err == nil {
+			t.Errorf("Expected error, but got nil")
+		} else if !test.IsErrorExpected && err != nil {
+			t.Errorf("Expected error nil, but got not nil => " + err.Error())
+		}
+
+		if p.pings != test.ExpectedPings {
+			t.Errorf("Expected %d pings, but got %d", test.ExpectedPings, p.pings)
+		}
+	}
+}
+
+// TestConnectDBNotReachable test ConnectDB leave DB nil
+// if database not reachable
+func TestConnectDBNotReachable(t *testing.T) {
+	a := API{Config: config.Config{DBPingAttempts: 1}}
+	err := a.ConnectDB(map[string]string{
+		"driver":          "postgres",
+		"host":            "127.0.0.1",
+		"port":            "1",
+		"dbname":          "db",
+		"connect_timeout": "1s",
+	})
+	if err == nil {
+		t.Errorf("Expected error, but got nil")
+	}
+	if a.DB != nil {
+		t.Errorf("Expected DB nil, but got not nil")
+	}
+}
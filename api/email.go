@@ -0,0 +1,428 @@
+/*
+Package api containing API initialization and API route handler
+*/
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/reyhanfikridz/ecom-account-service/internal/form"
+	"github.com/reyhanfikridz/ecom-account-service/internal/model"
+	"github.com/reyhanfikridz/ecom-account-service/internal/utils"
+)
+
+// VerifyEmailHandler handling route verify user email
+// with email verification token (method: POST)
+func (a *API) VerifyEmailHandler(w http.ResponseWriter, r *http.Request) {
+	var responseContent map[string]any
+	var responseStatus int
+
+	// allow host
+	w.Header().Set("Access-Control-Allow-Origin", a.Config.FrontendURL)
+
+	// validate email verification token
+	var claimsMap map[string]string
+	tokenString := r.FormValue("token")
+	if strings.TrimSpace(tokenString) != "" {
+		claimsMap = utils.ValidateActionJWT(a.Config.JWTKeys, tokenString, "email_verification")
+	}
+
+	userID, err := strconv.Atoi(claimsMap["sub"])
+	if strings.TrimSpace(tokenString) == "" { // if token not exist
+		log.Println(strconv.Quote("POST /api/email/verify/"), "422 UNPROCESSABLE ENTITY")
+		v := form.Validator{}
+		v.Required("token", tokenString)
+		responseContent = getValidationErrorResponse(v.Errors())
+		responseStatus = 422
+	} else if claimsMap != nil && err == nil { // if token valid, verify user email
+		status, err := a.Store.VerifyUserEmail(userID, claimsMap["email"])
+		if status == 200 && err == nil { // if verify user email success
+			log.Println(strconv.Quote("POST /api/email/verify/"), "200 SUCCESS")
+			responseContent = map[string]any{
+				"message": "Email verified!",
+			}
+			responseStatus = 200
+		} else if status == 400 { // if user not exist or email already changed
+			log.Println(strconv.Quote("POST /api/email/verify/"), "400 BAD REQUEST")
+			responseContent = map[string]any{
+				"message": "Token not valid or expired",
+			}
+			responseStatus = 400
+		} else { // if there's internal server error
+			log.Println(strconv.Quote("POST /api/email/verify/"), "500 INTERNAL SERVER ERROR")
+			log.Println(err.Error())
+			responseContent = map[string]any{
+				"message": err.Error(),
+			}
+			responseStatus = 500
+		}
+	} else { // if token not valid or expired
+		log.Println(strconv.Quote("POST /api/email/verify/"), "400 BAD REQUEST")
+		responseContent = map[string]any{
+			"message": "Token not valid or expired",
+		}
+		responseStatus = 400
+	}
+
+	response, marshalErr := json.Marshal(responseContent)
+	if marshalErr != nil {
+		log.Fatal("Error When Creating Response -> ", marshalErr)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(responseStatus)
+	w.Write(response)
+}
+
+// ResendEmailVerificationHandler handling route resend
+// email verification link to user email (method: POST)
+//
+// Response is the same whether the email registered or not,
+// and when the link already sent within resend interval (not sent again),
+// so the response can't be used to find registered emails
+func (a *API) ResendEmailVerificationHandler(w http.ResponseWriter, r *http.Request) {
+	var responseContent map[string]any
+	var responseStatus int
+
+	// allow host
+	w.Header().Set("Access-Control-Allow-Origin", a.Config.FrontendURL)
+
+	// check email in form
+	email := r.FormValue("email")
+	if strings.TrimSpace(email) != "" { // if email exist
+
+		// get user by email
+		user, err := a.Store.GetUser(email, 0)
+		if err == nil && user.EmailVerifiedAt == nil &&
+			user.Status != model.UserStatusDeleted { // if user exist and not verified
+
+			// send email verification link to user email,
+			// not sent again if already sent within resend interval
+			_, err := a.sendEmailVerification(user)
+			if err == nil { // if send email verification success or already sent recently
+				log.Println(strconv.Quote("POST /api/email/verify/resend/"), "200 SUCCESS")
+				responseContent = map[string]any{
+					"message": "If the email is registered and not verified, " +
+						"a verification link has been sent",
+				}
+				responseStatus = 200
+			} else { // if there's an error when send email verification
+				log.Println(strconv.Quote("POST /api/email/verify/resend/"), "500 INTERNAL SERVER ERROR")
+				log.Println(err.Error())
+				responseContent = map[string]any{
+					"message": err.Error(),
+				}
+				responseStatus = 500
+			}
+
+		} else if err == nil || err == sql.ErrNoRows { // if user verified, deleted, or not exist
+			log.Println(strconv.Quote("POST /api/email/verify/resend/"), "200 SUCCESS")
+			responseContent = map[string]any{
+				"message": "If the email is registered and not verified, " +
+					"a verification link has been sent",
+			}
+			responseStatus = 200
+		} else { // if there's an error when get user
+			log.Println(strconv.Quote("POST /api/email/verify/resend/"), "500 INTERNAL SERVER ERROR")
+			log.Println(err.Error())
+			responseContent = map[string]any{
+				"message": err.Error(),
+			}
+			responseStatus = 500
+		}
+
+	} else { // if email not exist
+		log.Println(strconv.Quote("POST /api/email/verify/resend/"), "422 UNPROCESSABLE ENTITY")
+		v := form.Validator{}
+		v.Required("email", email)
+		responseContent = getValidationErrorResponse(v.Errors())
+		responseStatus = 422
+	}
+
+	response, marshalErr := json.Marshal(responseContent)
+	if marshalErr != nil {
+		log.Fatal("Error When Creating Response -> ", marshalErr)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(responseStatus)
+	w.Write(response)
+}
+
+// sendEmailVerification send signed email verification link to user email,
+// return false if the link already sent within resend interval
+func (a *API) sendEmailVerification(user model.User) (bool, error) {
+	isMarked, err := a.Store.MarkEmailVerificationSent(user.ID)
+	if err != nil || !isMarked {
+		return false, err
+	}
+
+	tokenString, err := utils.GenerateActionJWT(a.Config.JWTKeys, "email_verification",
+		map[string]string{
+			"sub":   strconv.Itoa(user.ID),
+			"email": user.Email,
+		}, a.Config.EmailVerificationTokenDuration)
+	if err != nil {
+		return false, err
+	}
+
+	err = a.Mailer.Send(user.Email, "Verify your email",
+		"Thank you for registering.\n\n"+
+			"Open this link to verify your email:\n"+
+			a.Config.EmailVerificationURL+"?token="+url.QueryEscape(tokenString)+"\n\n"+
+			"The link will expire in "+a.Config.EmailVerificationTokenDuration.String()+".")
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// RequestEmailChangeHandler handling route request email change
+// of the logged in user, send confirmation link to the new email (method: POST)
+func (a *API) RequestEmailChangeHandler(w http.ResponseWriter, r *http.Request) {
+	var responseContent map[string]any
+	var responseStatus int
+
+	// allow host
+	w.Header().Set("Access-Control-Allow-Origin", a.Config.FrontendURL)
+
+	// get email change data from form-data
+	newEmail := strings.TrimSpace(r.FormValue("new_email"))
+	currentPassword := r.FormValue("current_password")
+
+	// check token in request
+	tokenString := getRequestToken(r)
+	if strings.TrimSpace(tokenString) != "" { // if token exist
+		userSession, status, err := a.authenticateToken(tokenString)
+		if status == 200 && err == nil { // if token valid
+
+			// validate email change form
+			v := form.Validator{}
+			v.Required("current_password", currentPassword)
+			validationErrs := append(form.ValidateNewEmail(newEmail), v.Errors()...)
+			if len(validationErrs) > 0 { // if email change form not valid
+				log.Println(strconv.Quote("POST /api/user/me/email/"), "422 UNPROCESSABLE ENTITY")
+				responseContent = getValidationErrorResponse(validationErrs)
+				responseStatus = 422
+			} else if newEmail == userSession.User.Email { // if new email not changed
+				log.Println(strconv.Quote("POST /api/user/me/email/"), "400 BAD REQUEST")
+				responseContent = map[string]any{
+					"message": "new_email same as the current email",
+				}
+				responseStatus = 400
+			} else { // if form valid, create email change
+				ec, status, err := a.Store.CreateEmailChange(userSession.User.ID,
+					currentPassword, newEmail)
+				if status == 200 && err == nil {
+					err = a.sendEmailChangeConfirmation(ec)
+				}
+
+				if status == 200 && err == nil { // if create and send email change success
+					log.Println(strconv.Quote("POST /api/user/me/email/"), "200 SUCCESS")
+					responseContent = map[string]any{
+						"message": "Confirmation link has been sent to the new email",
+					}
+					responseStatus = 200
+				} else if status == 400 { // if current password wrong
+					log.Println(strconv.Quote("POST /api/user/me/email/"), "400 BAD REQUEST")
+					responseContent = map[string]any{
+						"message": "Current password invalid",
+					}
+					responseStatus = 400
+				} else if status == 409 { // if new email already registered
+					log.Println(strconv.Quote("POST /api/user/me/email/"), "409 CONFLICT")
+					responseContent = map[string]any{
+						"message": "Email already registered",
+					}
+					responseStatus = 409
+				} else { // if there's an error when create or send email change
+					log.Println(strconv.Quote("POST /api/user/me/email/"), "500 INTERNAL SERVER ERROR")
+					log.Println(err.Error())
+					responseContent = map[string]any{
+						"message": err.Error(),
+					}
+					responseStatus = 500
+				}
+			}
+
+		} else if status == 400 { // if token not valid
+			log.Println(strconv.Quote("POST /api/user/me/email/"), "400 BAD REQUEST")
+			responseContent = map[string]any{
+				"message": "Token not valid",
+			}
+			responseStatus = 400
+		} else { // if there's internal server error
+			log.Println(strconv.Quote("POST /api/user/me/email/"), "500 INTERNAL SERVER ERROR")
+			log.Println(err.Error())
+			responseContent = map[string]any{
+				"message": err.Error(),
+			}
+			responseStatus = 500
+		}
+	} else { // if token not exist
+		log.Println(strconv.Quote("POST /api/user/me/email/"), "400 BAD REQUEST")
+		responseContent = map[string]any{
+			"message": "Token empty/not found",
+		}
+		responseStatus = 400
+	}
+
+	response, marshalErr := json.Marshal(responseContent)
+	if marshalErr != nil {
+		log.Fatal("Error When Creating Response -> ", marshalErr)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(responseStatus)
+	w.Write(response)
+}
+
+// ConfirmEmailChangeHandler handling route confirm email change
+// with token sent to the new email, send notice with revert link
+// to the old email (method: POST)
+func (a *API) ConfirmEmailChangeHandler(w http.ResponseWriter, r *http.Request) {
+	var responseContent map[string]any
+	var responseStatus int
+
+	// allow host
+	w.Header().Set("Access-Control-Allow-Origin", a.Config.FrontendURL)
+
+	// check token in form
+	tokenString := r.FormValue("token")
+	if strings.TrimSpace(tokenString) != "" { // if token exist
+		ec, status, err := a.Store.ConfirmEmailChange(tokenString)
+		if status == 200 && err == nil { // if confirm email change success
+			log.Println(strconv.Quote("POST /api/email/change/confirm/"), "200 SUCCESS")
+
+			// email already changed, so failed notice only logged
+			err = a.sendEmailChangeNotice(ec)
+			if err != nil {
+				log.Println("Error When Sending Email Change Notice -> ", err)
+			}
+
+			responseContent = map[string]any{
+				"message": "Email changed!",
+			}
+			responseStatus = 200
+		} else if status == 400 { // if token not valid, used, or expired
+			log.Println(strconv.Quote("POST /api/email/change/confirm/"), "400 BAD REQUEST")
+			responseContent = map[string]any{
+				"message": "Token not valid or expired",
+			}
+			responseStatus = 400
+		} else if status == 409 { // if new email already registered
+			log.Println(strconv.Quote("POST /api/email/change/confirm/"), "409 CONFLICT")
+			responseContent = map[string]any{
+				"message": "Email already registered",
+			}
+			responseStatus = 409
+		} else { // if there's internal server error
+			log.Println(strconv.Quote("POST /api/email/change/confirm/"), "500 INTERNAL SERVER ERROR")
+			log.Println(err.Error())
+			responseContent = map[string]any{
+				"message": err.Error(),
+			}
+			responseStatus = 500
+		}
+	} else { // if token not exist
+		log.Println(strconv.Quote("POST /api/email/change/confirm/"), "422 UNPROCESSABLE ENTITY")
+		v := form.Validator{}
+		v.Required("token", tokenString)
+		responseContent = getValidationErrorResponse(v.Errors())
+		responseStatus = 422
+	}
+
+	response, marshalErr := json.Marshal(responseContent)
+	if marshalErr != nil {
+		log.Fatal("Error When Creating Response -> ", marshalErr)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(responseStatus)
+	w.Write(response)
+}
+
+// RevertEmailChangeHandler handling route revert email change
+// with token sent to the old email (method: POST)
+//
+// All sessions of the user are logged out after email reverted
+func (a *API) RevertEmailChangeHandler(w http.ResponseWriter, r *http.Request) {
+	var responseContent map[string]any
+	var responseStatus int
+
+	// allow host
+	w.Header().Set("Access-Control-Allow-Origin", a.Config.FrontendURL)
+
+	// check token in form
+	tokenString := r.FormValue("token")
+	if strings.TrimSpace(tokenString) != "" { // if token exist
+		_, status, err := a.Store.RevertEmailChange(tokenString)
+		if status == 200 && err == nil { // if revert email change success
+			log.Println(strconv.Quote("POST /api/email/change/revert/"), "200 SUCCESS")
+			responseContent = map[string]any{
+				"message": "Email change reverted, please login again " +
+					"and reset your password if the change wasn't made by you",
+			}
+			responseStatus = 200
+		} else if status == 400 { // if token not valid, used, or expired
+			log.Println(strconv.Quote("POST /api/email/change/revert/"), "400 BAD REQUEST")
+			responseContent = map[string]any{
+				"message": "Token not valid or expired",
+			}
+			responseStatus = 400
+		} else if status == 409 { // if old email already registered by another user
+			log.Println(strconv.Quote("POST /api/email/change/revert/"), "409 CONFLICT")
+			responseContent = map[string]any{
+				"message": "Email already registered",
+			}
+			responseStatus = 409
+		} else { // if there's internal server error
+			log.Println(strconv.Quote("POST /api/email/change/revert/"), "500 INTERNAL SERVER ERROR")
+			log.Println(err.Error())
+			responseContent = map[string]any{
+				"message": err.Error(),
+			}
+			responseStatus = 500
+		}
+	} else { // if token not exist
+		log.Println(strconv.Quote("POST /api/email/change/revert/"), "422 UNPROCESSABLE ENTITY")
+		v := form.Validator{}
+		v.Required("token", tokenString)
+		responseContent = getValidationErrorResponse(v.Errors())
+		responseStatus = 422
+	}
+
+	response, marshalErr := json.Marshal(responseContent)
+	if marshalErr != nil {
+		log.Fatal("Error When Creating Response -> ", marshalErr)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(responseStatus)
+	w.Write(response)
+}
+
+// sendEmailChangeConfirmation send email change confirmation link
+// to the new email
+func (a *API) sendEmailChangeConfirmation(ec model.EmailChange) error {
+	return a.Mailer.Send(ec.NewEmail, "Confirm your new email",
+		"We received a request to change your account email "+
+			"from "+ec.OldEmail+" to this email.\n\n"+
+			"Open this link to confirm the change:\n"+
+			a.Config.EmailChangeConfirmURL+"?token="+url.QueryEscape(ec.Token)+"\n\n"+
+			"The link will expire in "+a.Config.EmailChangeTokenDuration.String()+". "+
+			"If you didn't request this, you can ignore this email.")
+}
+
+// sendEmailChangeNotice send email changed notice with revert link
+// to the old email
+func (a *API) sendEmailChangeNotice(ec model.EmailChange) error {
+	return a.Mailer.Send(ec.OldEmail, "Your email has been changed",
+		"Your account email has been changed to "+ec.NewEmail+".\n\n"+
+			"If this wasn't you, open this link to change it back "+
+			"and log out all devices:\n"+
+			a.Config.EmailChangeRevertURL+"?token="+url.QueryEscape(ec.RevertToken)+"\n\n"+
+			"The link will expire in "+a.Config.EmailChangeRevertDuration.String()+".")
+}
@@ -0,0 +1,441 @@
+/*
+Package api containing API initialization and API route handler
+*/
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/reyhanfikridz/ecom-account-service/internal/config"
+	"github.com/reyhanfikridz/ecom-account-service/internal/mailer"
+	"github.com/reyhanfikridz/ecom-account-service/internal/utils"
+)
+
+// TestVerifyEmailHandlerAndResendEmailVerificationHandler integration test
+// VerifyEmailHandler and ResendEmailVerificationHandler
+func TestVerifyEmailHandlerAndResendEmailVerificationHandler(t *testing.T) {
+	// initialize testing API,
+	// block login for unverified email while testing
+	c := testConfig
+	c.EmailVerificationPolicy = config.EmailVerificationPolicyBlockLogin
+
+	a, err := GetTestingAPIWithConfig(c)
+	if err != nil {
+		t.Errorf("There's an error when getting testing API => " + err.Error())
+	}
+
+	// delete user first
+	_, err = a.DB.Exec(`DELETE FROM account_user WHERE email = $1`, "testverify@gmail.com")
+	if err != nil {
+		t.Errorf("There's an error when deleting verify testing data " + err.Error())
+	}
+
+	// initialize testing table,
+	// "{token}" replaced by token from the last sent email,
+	// resend response the same for email already sent recently and not registered
+	resendResponse := `{"message":"If the email is registered and not verified, ` +
+		`a verification link has been sent"}`
+	testTable := []struct {
+		URL             string
+		FormData        map[string]string
+		ExpectedStatus  int
+		ExpectedBodyKey []string
+		ExpectedBody    string
+	}{
+		{
+			URL: "/api/register/",
+			FormData: map[string]string{
+				"email":        "testverify@gmail.com",
+				"password":     "testpassword1",
+				"full_name":    "test",
+				"address":      "test",
+				"phone_number": "08111111111",
+				"role":         "buyer",
+			},
+			ExpectedStatus:  200,
+			ExpectedBodyKey: []string{"message", "id"},
+		},
+		{
+			URL: "/api/login/",
+			FormData: map[string]string{
+				"email":    "testverify@gmail.com",
+				"password": "testpassword1",
+			},
+			ExpectedStatus:  403,
+			ExpectedBodyKey: []string{"message"},
+		},
+		{
+			URL: "/api/email/verify/resend/",
+			FormData: map[string]string{
+				"email": "testverify@gmail.com",
+			},
+			ExpectedStatus:  200,
+			ExpectedBodyKey: []string{"message"},
+			ExpectedBody:    resendResponse,
+		},
+		{
+			URL: "/api/email/verify/resend/",
+			FormData: map[string]string{
+				"email": "testverifynotexist@gmail.com",
+			},
+			ExpectedStatus:  200,
+			ExpectedBodyKey: []string{"message"},
+			ExpectedBody:    resendResponse,
+		},
+		{
+			URL: "/api/email/verify/resend/",
+			FormData: map[string]string{
+				"email": "",
+			},
+			ExpectedStatus:  422,
+			ExpectedBodyKey: []string{"message", "code", "errors"},
+		},
+		{
+			URL: "/api/email/verify/",
+			FormData: map[string]string{
+				"token": "",
+			},
+			ExpectedStatus:  422,
+			ExpectedBodyKey: []string{"message", "code", "errors"},
+		},
+		{
+			URL: "/api/email/verify/",
+			FormData: map[string]string{
+				"token": "Invalid Token",
+			},
+			ExpectedStatus:  400,
+			ExpectedBodyKey: []string{"message"},
+		},
+		{
+			URL: "/api/email/verify/",
+			FormData: map[string]string{
+				"token": "{token}",
+			},
+			ExpectedStatus:  200,
+			ExpectedBodyKey: []string{"message"},
+		},
+		{
+			URL: "/api/email/verify/resend/",
+			FormData: map[string]string{
+				"email": "testverify@gmail.com",
+			},
+			ExpectedStatus:  200,
+			ExpectedBodyKey: []string{"message"},
+		},
+		{
+			URL: "/api/login/",
+			FormData: map[string]string{
+				"email":    "testverify@gmail.com",
+				"password": "testpassword1",
+			},
+			ExpectedStatus:  200,
+			ExpectedBodyKey: []string{"message", "token"},
+		},
+	}
+
+	// loop test in test table
+	tokenRegexp := regexp.MustCompile(`token=([A-Za-z0-9_.-]+)`)
+	for _, test := range testTable {
+		// get token from the last sent email
+		token := ""
+		message, ok := a.Mailer.(*mailer.MemoryMailer).LastMessage("testverify@gmail.com")
+		if ok && tokenRegexp.MatchString(message.Body) {
+			token = tokenRegexp.FindStringSubmatch(message.Body)[1]
+		}
+
+		// transform form data to bytes buffer
+		var bFormData bytes.Buffer
+		w := multipart.NewWriter(&bFormData)
+		for key, value := range test.FormData {
+			fw, err := w.CreateFormField(key)
+			if err != nil {
+				t.Errorf("There's an error when creating bytes buffer form data => " +
+					err.Error())
+			}
+
+			_, err = io.Copy(fw, strings.NewReader(strings.ReplaceAll(value, "{token}", token)))
+			if err != nil {
+				t.Errorf("There's an error when creating bytes buffer form data => " +
+					err.Error())
+			}
+		}
+		w.Close()
+
+		// create new request
+		req, err := http.NewRequest("POST", test.URL, &bFormData)
+		if err != nil {
+			t.Errorf("There's an error when creating request API " + test.URL + " => " +
+				err.Error())
+		}
+		req.Header.Set("Content-Type", w.FormDataContentType())
+
+		// run request
+		response := httptest.NewRecorder()
+		a.Router.ServeHTTP(response, req)
+
+		// check response
+		if response.Code != test.ExpectedStatus {
+			t.Errorf("Expected status %d got %d (%s)", test.ExpectedStatus, response.Code, test.URL)
+		}
+
+		if test.ExpectedBody != "" && response.Body.String() != test.ExpectedBody {
+			t.Errorf("Expected body %s got %s (%s)", test.ExpectedBody, response.Body.String(),
+				test.URL)
+		}
+
+		var responseData map[string]any
+		err = json.Unmarshal(response.Body.Bytes(), &responseData)
+		if err != nil {
+			t.Errorf("There's an error when unmarshal body response => " + err.Error())
+		}
+		for _, expectedKey := range test.ExpectedBodyKey {
+			if responseData[expectedKey] == nil {
+				t.Errorf("Expected key " + expectedKey + " empty/not found")
+			}
+		}
+	}
+}
+
+// TestRequestEmailChangeHandlerAndConfirmEmailChangeHandlerAndRevertEmailChangeHandler
+// integration test RequestEmailChangeHandler, ConfirmEmailChangeHandler,
+// and RevertEmailChangeHandler
+func TestRequestEmailChangeHandlerAndConfirmEmailChangeHandlerAndRevertEmailChangeHandler(
+	t *testing.T) {
+	// initialize testing API
+	a, err := GetTestingAPI()
+	if err != nil {
+		t.Errorf("There's an error when getting testing API => " + err.Error())
+	}
+
+	// create user with its session and another user
+	for _, email := range []string{
+		"testemailchange@gmail.com",
+		"testemailchangenew@gmail.com",
+		"testemailchangeother@gmail.com",
+	} {
+		_, err = a.DB.Exec(`DELETE FROM account_user WHERE email = $1`, email)
+		if err != nil {
+			t.Errorf("There's an error when deleting email change testing data " + err.Error())
+		}
+	}
+
+	hashedPassword, err := utils.HashPassword("test")
+	if err != nil {
+		t.Errorf("There's an error when hashing password => " + err.Error())
+	}
+
+	var userID int
+	for _, email := range []string{"testemailchangeother@gmail.com", "testemailchange@gmail.com"} {
+		err = a.DB.QueryRow(`
+			INSERT INTO account_user(email, password, full_name, address, phone_number, role)
+				VALUES($1, $2, $3, $4, $5, $6)
+				RETURNING id`,
+			email, hashedPassword, "test", "test", "test", "test").Scan(&userID)
+		if err != nil {
+			t.Errorf("There's an error when creating testing user data => " + err.Error())
+		}
+	}
+
+	accessToken, err := utils.GenerateJWT(a.Config.JWTKeys, userID, "test",
+		a.Config.AccessTokenDuration)
+	if err != nil {
+		t.Errorf("There's an error when creating token " +
+			"for creating user session data => " +
+			err.Error())
+	}
+
+	_, err = a.DB.Exec(`
+		INSERT INTO account_usersession(token, account_user_id)
+			VALUES($1, $2)`, accessToken, userID)
+	if err != nil {
+		t.Errorf("There's an error when creating testing user session data => " +
+			err.Error())
+	}
+
+	// initialize testing table,
+	// "{access_token}" replaced by the user access token and
+	// "{token}" replaced by token from the last email sent to MailTo
+	testTable := []struct {
+		URL             string
+		MailTo          string
+		FormData        map[string]string
+		ExpectedStatus  int
+		ExpectedBodyKey []string
+	}{
+		{
+			URL: "/api/user/me/email/",
+			FormData: map[string]string{
+				"token":            "{access_token}",
+				"new_email":        "testemailchangenew",
+				"current_password": "test",
+			},
+			ExpectedStatus:  422,
+			ExpectedBodyKey: []string{"message", "code", "errors"},
+		},
+		{
+			URL: "/api/user/me/email/",
+			FormData: map[string]string{
+				"token":            "{access_token}",
+				"new_email":        "testemailchangenew@gmail.com",
+				"current_password": "wrongpassword",
+			},
+			ExpectedStatus:  400,
+			ExpectedBodyKey: []string{"message"},
+		},
+		{
+			URL: "/api/user/me/email/",
+			FormData: map[string]string{
+				"token":            "{access_token}",
+				"new_email":        "testemailchangeother@gmail.com",
+				"current_password": "test",
+			},
+			ExpectedStatus:  409,
+			ExpectedBodyKey: []string{"message"},
+		},
+		{
+			URL: "/api/user/me/email/",
+			FormData: map[string]string{
+				"token":            "{access_token}",
+				"new_email":        "testemailchangenew@gmail.com",
+				"current_password": "test",
+			},
+			ExpectedStatus:  200,
+			ExpectedBodyKey: []string{"message"},
+		},
+		{
+			URL: "/api/email/change/confirm/",
+			FormData: map[string]string{
+				"token": "",
+			},
+			ExpectedStatus:  422,
+			ExpectedBodyKey: []string{"message", "code", "errors"},
+		},
+		{
+			URL:    "/api/email/change/confirm/",
+			MailTo: "testemailchangenew@gmail.com",
+			FormData: map[string]string{
+				"token": "{token}",
+			},
+			ExpectedStatus:  200,
+			ExpectedBodyKey: []string{"message"},
+		},
+		{
+			URL:    "/api/email/change/confirm/",
+			MailTo: "testemailchangenew@gmail.com",
+			FormData: map[string]string{
+				"token": "{token}",
+			},
+			ExpectedStatus:  400,
+			ExpectedBodyKey: []string{"message"},
+		},
+		{
+			URL: "/api/authorize/",
+			FormData: map[string]string{
+				"token": "{access_token}",
+			},
+			ExpectedStatus:  200,
+			ExpectedBodyKey: []string{"id", "email"},
+		},
+		{
+			URL: "/api/login/",
+			FormData: map[string]string{
+				"email":    "testemailchangenew@gmail.com",
+				"password": "test",
+			},
+			ExpectedStatus:  200,
+			ExpectedBodyKey: []string{"message", "token"},
+		},
+		{
+			URL:    "/api/email/change/revert/",
+			MailTo: "testemailchange@gmail.com",
+			FormData: map[string]string{
+				"token": "{token}",
+			},
+			ExpectedStatus:  200,
+			ExpectedBodyKey: []string{"message"},
+		},
+		{
+			URL: "/api/authorize/",
+			FormData: map[string]string{
+				"token": "{access_token}",
+			},
+			ExpectedStatus:  400,
+			ExpectedBodyKey: []string{"message"},
+		},
+		{
+			URL: "/api/login/",
+			FormData: map[string]string{
+				"email":    "testemailchange@gmail.com",
+				"password": "test",
+			},
+			ExpectedStatus:  200,
+			ExpectedBodyKey: []string{"message", "token"},
+		},
+	}
+
+	// loop test in test table
+	tokenRegexp := regexp.MustCompile(`token=([A-Za-z0-9_.-]+)`)
+	for _, test := range testTable {
+		// get token from the last email sent to MailTo
+		token := ""
+		message, ok := a.Mailer.(*mailer.MemoryMailer).LastMessage(test.MailTo)
+		if ok && tokenRegexp.MatchString(message.Body) {
+			token = tokenRegexp.FindStringSubmatch(message.Body)[1]
+		}
+		replacer := strings.NewReplacer("{access_token}", accessToken, "{token}", token)
+
+		// transform form data to bytes buffer
+		var bFormData bytes.Buffer
+		w := multipart.NewWriter(&bFormData)
+		for key, value := range test.FormData {
+			fw, err := w.CreateFormField(key)
+			if err != nil {
+				t.Errorf("There's an error when creating bytes buffer form data => " +
+					err.Error())
+			}
+
+			_, err = io.Copy(fw, strings.NewReader(replacer.Replace(value)))
+			if err != nil {
+				t.Errorf("There's an error when creating bytes buffer form data => " +
+					err.Error())
+			}
+		}
+		w.Close()
+
+		// create new request
+		req, err := http.NewRequest("POST", test.URL, &bFormData)
+		if err != nil {
+			t.Errorf("There's an error when creating request API " + test.URL + " => " +
+				err.Error())
+		}
+		req.Header.Set("Content-Type", w.FormDataContentType())
+
+		// run request
+		response := httptest.NewRecorder()
+		a.Router.ServeHTTP(response, req)
+
+		// check response
+		if response.Code != test.ExpectedStatus {
+			t.Errorf("Expected status %d got %d (%s)", test.ExpectedStatus, response.Code, test.URL)
+		}
+
+		var responseData map[string]any
+		err = json.Unmarshal(response.Body.Bytes(), &responseData)
+		if err != nil {
+			t.Errorf("There's an error when unmarshal body response => " + err.Error())
+		}
+		for _, expectedKey := range test.ExpectedBodyKey {
+			if responseData[expectedKey] == nil {
+				t.Errorf("Expected key " + expectedKey + " empty/not found")
+			}
+		}
+	}
+}
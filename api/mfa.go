@@ -0,0 +1,242 @@
+/*
+Package api containing API initialization and API route handler
+*/
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/reyhanfikridz/ecom-account-service/internal/form"
+	"github.com/reyhanfikridz/ecom-account-service/internal/model"
+	"github.com/reyhanfikridz/ecom-account-service/internal/utils"
+)
+
+// EnrollTOTPHandler handling route enroll totp secret
+// of the logged in user (method: POST)
+func (a *API) EnrollTOTPHandler(w http.ResponseWriter, r *http.Request) {
+	var responseContent map[string]any
+	var responseStatus int
+
+	// allow host
+	w.Header().Set("Access-Control-Allow-Origin", a.Config.FrontendURL)
+
+	// check token in request
+	tokenString := getRequestToken(r)
+	if strings.TrimSpace(tokenString) != "" { // if token exist
+		userSession, status, err := a.authenticateToken(tokenString)
+		if status == 200 && err == nil { // if token valid
+
+			// enroll totp secret
+			secret, status, err := a.Store.EnrollTOTP(userSession.User.ID)
+			if status == 200 && err == nil { // if enroll totp success
+				log.Println(strconv.Quote("POST /api/mfa/totp/enroll/"), "200 SUCCESS")
+				responseContent = map[string]any{
+					"message": "TOTP enrolled, please confirm with the first code",
+					"secret":  secret,
+					"uri": utils.GetTOTPURI(a.Config.TOTPIssuer,
+						userSession.User.Email, secret),
+				}
+				responseStatus = 200
+			} else if status == 400 { // if two-factor authentication already enabled
+				log.Println(strconv.Quote("POST /api/mfa/totp/enroll/"), "400 BAD REQUEST")
+				responseContent = map[string]any{
+					"message": "Two-factor authentication already enabled",
+				}
+				responseStatus = 400
+			} else { // if there's an error when enroll totp
+				log.Println(strconv.Quote("POST /api/mfa/totp/enroll/"), "500 INTERNAL SERVER ERROR")
+				log.Println(err.Error())
+				responseContent = map[string]any{
+					"message": err.Error(),
+				}
+				responseStatus = 500
+			}
+
+		} else if status == 400 { // if token not valid
+			log.Println(strconv.Quote("POST /api/mfa/totp/enroll/"), "400 BAD REQUEST")
+			responseContent = map[string]any{
+				"message": "Token not valid",
+			}
+			responseStatus = 400
+		} else { // if there's internal server error
+			log.Println(strconv.Quote("POST /api/mfa/totp/enroll/"), "500 INTERNAL SERVER ERROR")
+			log.Println(err.Error())
+			responseContent = map[string]any{
+				"message": err.Error(),
+			}
+			responseStatus = 500
+		}
+	} else { // if token not exist
+		log.Println(strconv.Quote("POST /api/mfa/totp/enroll/"), "400 BAD REQUEST")
+		responseContent = map[string]any{
+			"message": "Token empty/not found",
+		}
+		responseStatus = 400
+	}
+
+	response, marshalErr := json.Marshal(responseContent)
+	if marshalErr != nil {
+		log.Fatal("Error When Creating Response -> ", marshalErr)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(responseStatus)
+	w.Write(response)
+}
+
+// ConfirmTOTPHandler handling route confirm enrolled totp secret
+// of the logged in user, return recovery codes (method: POST)
+func (a *API) ConfirmTOTPHandler(w http.ResponseWriter, r *http.Request) {
+	var responseContent map[string]any
+	var responseStatus int
+
+	// allow host
+	w.Header().Set("Access-Control-Allow-Origin", a.Config.FrontendURL)
+
+	// check token in request
+	tokenString := getRequestToken(r)
+	if strings.TrimSpace(tokenString) != "" { // if token exist
+		userSession, status, err := a.authenticateToken(tokenString)
+		if status == 200 && err == nil { // if token valid
+
+			// confirm totp with the first code
+			recoveryCodes, status, err := a.Store.ConfirmTOTP(
+				userSession.User.ID, r.FormValue("code"))
+			if status == 200 && err == nil { // if confirm totp success
+				log.Println(strconv.Quote("POST /api/mfa/totp/confirm/"), "200 SUCCESS")
+				responseContent = map[string]any{
+					"message":        "Two-factor authentication enabled!",
+					"recovery_codes": recoveryCodes,
+				}
+				responseStatus = 200
+			} else if status == 400 { // if totp not enrolled or code not valid
+				log.Println(strconv.Quote("POST /api/mfa/totp/confirm/"), "400 BAD REQUEST")
+				responseContent = map[string]any{
+					"message": "TOTP not enrolled or code not valid",
+				}
+				responseStatus = 400
+			} else { // if there's an error when confirm totp
+				log.Println(strconv.Quote("POST /api/mfa/totp/confirm/"), "500 INTERNAL SERVER ERROR")
+				log.Println(err.Error())
+				responseContent = map[string]any{
+					"message": err.Error(),
+				}
+				responseStatus = 500
+			}
+
+		} else if status == 400 { // if token not valid
+			log.Println(strconv.Quote("POST /api/mfa/totp/confirm/"), "400 BAD REQUEST")
+			responseContent = map[string]any{
+				"message": "Token not valid",
+			}
+			responseStatus = 400
+		} else { // if there's internal server error
+			log.Println(strconv.Quote("POST /api/mfa/totp/confirm/"), "500 INTERNAL SERVER ERROR")
+			log.Println(err.Error())
+			responseContent = map[string]any{
+				"message": err.Error(),
+			}
+			responseStatus = 500
+		}
+	} else { // if token not exist
+		log.Println(strconv.Quote("POST /api/mfa/totp/confirm/"), "400 BAD REQUEST")
+		responseContent = map[string]any{
+			"message": "Token empty/not found",
+		}
+		responseStatus = 400
+	}
+
+	response, marshalErr := json.Marshal(responseContent)
+	if marshalErr != nil {
+		log.Fatal("Error When Creating Response -> ", marshalErr)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(responseStatus)
+	w.Write(response)
+}
+
+// LoginMFAHandler handling route complete login of user with
+// two-factor authentication by mfa challenge token and
+// totp code or recovery code (method: POST)
+func (a *API) LoginMFAHandler(w http.ResponseWriter, r *http.Request) {
+	var responseContent map[string]any
+	var responseStatus int
+
+	// allow host
+	w.Header().Set("Access-Control-Allow-Origin", a.Config.FrontendURL)
+
+	// get mfa data from form-data
+	mfaToken := r.FormValue("mfa_token")
+	code := r.FormValue("code")
+
+	// validate mfa form
+	v := form.Validator{}
+	v.Required("mfa_token", mfaToken)
+	v.Required("code", code)
+
+	if len(v.Errors()) > 0 { // if mfa token or code not exist
+		log.Println(strconv.Quote("POST /api/login/mfa/"), "422 UNPROCESSABLE ENTITY")
+		responseContent = getValidationErrorResponse(v.Errors())
+		responseStatus = 422
+	} else { // if form valid, login user
+		token, refreshToken, status, u, err := a.Store.AuthenticateUserMFA(
+			mfaToken, code, r.UserAgent(), getRequestIP(r))
+		if status == 200 && err == nil { // if user authenticated
+			log.Println(strconv.Quote("POST /api/login/mfa/"), "200 SUCCESS")
+			responseContent = map[string]any{
+				"message":       "User logged in!",
+				"token":         token,
+				"refresh_token": refreshToken,
+				"role":          model.EffectiveRole(a.Config, u),
+			}
+			responseStatus = 200
+		} else if status == 423 { // if account or IP address locked
+			log.Println(strconv.Quote("POST /api/login/mfa/"), "423 LOCKED")
+			w.Header().Set("Retry-After", a.getLoginRetryAfter(u.Email, getRequestIP(r)))
+			responseContent = map[string]any{
+				"message": "Account temporarily locked because of too many failed login attempts",
+				"code":    "account_locked",
+			}
+			responseStatus = 423
+		} else if status == 429 { // if still delayed after the last failed login
+			log.Println(strconv.Quote("POST /api/login/mfa/"), "429 TOO MANY REQUESTS")
+			w.Header().Set("Retry-After", a.getLoginRetryAfter(u.Email, getRequestIP(r)))
+			responseContent = map[string]any{
+				"message": "Too many failed login attempts, please try again later",
+				"code":    "login_delayed",
+			}
+			responseStatus = 429
+		} else if status == 403 { // if user suspended
+			log.Println(strconv.Quote("POST /api/login/mfa/"), "403 FORBIDDEN")
+			responseContent = map[string]any{
+				"message": "Account suspended",
+				"code":    "account_suspended",
+			}
+			responseStatus = 403
+		} else if status == 400 { // if mfa token or code not valid
+			log.Println(strconv.Quote("POST /api/login/mfa/"), "400 BAD REQUEST")
+			responseContent = map[string]any{
+				"message": "MFA token or code invalid",
+			}
+			responseStatus = 400
+		} else { // if there's internal server error
+			log.Println(strconv.Quote("POST /api/login/mfa/"), "500 INTERNAL SERVER ERROR")
+			log.Println(err.Error())
+			responseContent = map[string]any{
+				"message": err.Error(),
+			}
+			responseStatus = 500
+		}
+	}
+
+	response, marshalErr := json.Marshal(responseContent)
+	if marshalErr != nil {
+		log.Fatal("Error When Creating Response -> ", marshalErr)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(responseStatus)
+	w.Write(response)
+}
@@ -0,0 +1,211 @@
+/*
+Package api containing API initialization and API route handler
+*/
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/reyhanfikridz/ecom-account-service/internal/utils"
+)
+
+// TestEnrollTOTPHandlerAndConfirmTOTPHandlerAndLoginMFAHandler integration test
+// EnrollTOTPHandler, ConfirmTOTPHandler, and LoginMFAHandler
+func TestEnrollTOTPHandlerAndConfirmTOTPHandlerAndLoginMFAHandler(t *testing.T) {
+	// initialize testing API
+	a, err := GetTestingAPI()
+	if err != nil {
+		t.Errorf("There's an error when getting testing API => " + err.Error())
+	}
+
+	// create user first
+	_, err = a.DB.Exec(`DELETE FROM account_user WHERE email = $1`, "testmfa@gmail.com")
+	if err != nil {
+		t.Errorf("There's an error when deleting mfa testing data " + err.Error())
+	}
+
+	hashedPassword, err := utils.HashPassword("test")
+	if err != nil {
+		t.Errorf("There's an error when hashing password => " + err.Error())
+	}
+
+	_, err = a.DB.Exec(`
+		INSERT INTO account_user(email, password, full_name, address, phone_number, role)
+			VALUES($1, $2, $3, $4, $5, $6)`,
+		"testmfa@gmail.com", hashedPassword, "test", "test", "test", "test")
+	if err != nil {
+		t.Errorf("There's an error when creating testing user data => " + err.Error())
+	}
+
+	// initialize testing table,
+	// "{key}" replaced by value of the key from previous response
+	// and "{code}" replaced by totp code of the next time step
+	testTable := []struct {
+		URL             string
+		FormData        map[string]string
+		ExpectedStatus  int
+		ExpectedBodyKey []string
+	}{
+		{
+			URL: "/api/login/",
+			FormData: map[string]string{
+				"email":    "testmfa@gmail.com",
+				"password": "test",
+			},
+			ExpectedStatus:  200,
+			ExpectedBodyKey: []string{"message", "token"},
+		},
+		{
+			URL: "/api/mfa/totp/enroll/",
+			FormData: map[string]string{
+				"token": "",
+			},
+			ExpectedStatus:  400,
+			ExpectedBodyKey: []string{"message"},
+		},
+		{
+			URL: "/api/mfa/totp/enroll/",
+			FormData: map[string]string{
+				"token": "{token}",
+			},
+			ExpectedStatus:  200,
+			ExpectedBodyKey: []string{"message", "secret", "uri"},
+		},
+		{
+			URL: "/api/mfa/totp/confirm/",
+			FormData: map[string]string{
+				"token": "{token}",
+				"code":  "000000x",
+			},
+			ExpectedStatus:  400,
+			ExpectedBodyKey: []string{"message"},
+		},
+		{
+			URL: "/api/mfa/totp/confirm/",
+			FormData: map[string]string{
+				"token": "{token}",
+				"code":  "{code}",
+			},
+			ExpectedStatus:  200,
+			ExpectedBodyKey: []string{"message", "recovery_codes"},
+		},
+		{
+			URL: "/api/login/",
+			FormData: map[string]string{
+				"email":    "testmfa@gmail.com",
+				"password": "test",
+			},
+			ExpectedStatus:  202,
+			ExpectedBodyKey: []string{"message", "mfa_required", "mfa_token"},
+		},
+		{
+			URL: "/api/login/mfa/",
+			FormData: map[string]string{
+				"mfa_token": "{mfa_token}",
+				"code":      "",
+			},
+			ExpectedStatus:  422,
+			ExpectedBodyKey: []string{"message", "code", "errors"},
+		},
+		{
+			URL: "/api/login/mfa/",
+			FormData: map[string]string{
+				"mfa_token": "{mfa_token}",
+				"code":      "{recovery_code}",
+			},
+			ExpectedStatus:  200,
+			ExpectedBodyKey: []string{"message", "token", "refresh_token"},
+		},
+		{
+			URL: "/api/login/mfa/",
+			FormData: map[string]string{
+				"mfa_token": "{mfa_token}",
+				"code":      "{recovery_code}",
+			},
+			ExpectedStatus:  400,
+			ExpectedBodyKey: []string{"message"},
+		},
+	}
+
+	// loop test in test table
+	values := map[string]string{}
+	for _, test := range testTable {
+		// totp code of the next time step, so it's not used yet
+		if values["secret"] != "" {
+			values["code"], err = utils.GenerateTOTPCode(values["secret"],
+				utils.GetTOTPStep(time.Now().UTC())+1)
+			if err != nil {
+				t.Errorf("There's an error when generate totp code => " + err.Error())
+			}
+		}
+
+		// transform form data to bytes buffer
+		var bFormData bytes.Buffer
+		w := multipart.NewWriter(&bFormData)
+		for key, value := range test.FormData {
+			fw, err := w.CreateFormField(key)
+			if err != nil {
+				t.Errorf("There's an error when creating bytes buffer form data => " +
+					err.Error())
+			}
+
+			for valueKey, v := range values {
+				value = strings.ReplaceAll(value, "{"+valueKey+"}", v)
+			}
+
+			_, err = io.Copy(fw, strings.NewReader(value))
+			if err != nil {
+				t.Errorf("There's an error when creating bytes buffer form data => " +
+					err.Error())
+			}
+		}
+		w.Close()
+
+		// create new request
+		req, err := http.NewRequest("POST", test.URL, &bFormData)
+		if err != nil {
+			t.Errorf("There's an error when creating request API " + test.URL + " => " +
+				err.Error())
+		}
+		req.Header.Set("Content-Type", w.FormDataContentType())
+
+		// run request
+		response := httptest.NewRecorder()
+		a.Router.ServeHTTP(response, req)
+
+		// check response
+		if response.Code != test.ExpectedStatus {
+			t.Errorf("Expected status %d got %d (%s)", test.ExpectedStatus, response.Code, test.URL)
+		}
+
+		var responseData map[string]any
+		err = json.Unmarshal(response.Body.Bytes(), &responseData)
+		if err != nil {
+			t.Errorf("There's an error when unmarshal body response => " + err.Error())
+		}
+		for _, expectedKey := range test.ExpectedBodyKey {
+			if responseData[expectedKey] == nil {
+				t.Errorf("Expected key " + expectedKey + " empty/not found")
+			}
+		}
+
+		// save response values for next request
+		for _, key := range []string{"token", "secret", "mfa_token"} {
+			if value, ok := responseData[key].(string); ok {
+				values[key] = value
+			}
+		}
+		if recoveryCodes, ok := responseData["recovery_codes"].([]any); ok &&
+			len(recoveryCodes) > 0 {
+			values["recovery_code"], _ = recoveryCodes[0].(string)
+		}
+	}
+}
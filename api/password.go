@@ -0,0 +1,256 @@
+/*
+Package api containing API initialization and API route handler
+*/
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/reyhanfikridz/ecom-account-service/internal/form"
+	"github.com/reyhanfikridz/ecom-account-service/internal/model"
+)
+
+// ForgotPasswordHandler handling route forgot password,
+// send password reset link to user email (method: POST)
+//
+// Response is the same whether the email registered or not,
+// so the route can't be used to find out registered email
+func (a *API) ForgotPasswordHandler(w http.ResponseWriter, r *http.Request) {
+	var responseContent map[string]any
+	var responseStatus int
+
+	// allow host
+	w.Header().Set("Access-Control-Allow-Origin", a.Config.FrontendURL)
+
+	// check email in form
+	email := r.FormValue("email")
+	if strings.TrimSpace(email) != "" { // if email exist
+
+		// get user by email
+		user, err := a.Store.GetUser(email, 0)
+		if err == nil && user.Status != model.UserStatusDeleted { // if user exist
+
+			// create password reset token and send it to user email
+			prt, err := a.Store.CreatePasswordResetToken(model.PasswordResetToken{
+				User: user,
+			})
+			if err == nil { // if create password reset token success
+				err = a.Mailer.Send(user.Email, "Reset your password",
+					"Someone requested a password reset for your account.\n\n"+
+						"Open this link to set a new password:\n"+
+						a.Config.PasswordResetURL+"?token="+url.QueryEscape(prt.Token)+"\n\n"+
+						"The link will expire in "+a.Config.PasswordResetTokenDuration.String()+". "+
+						"If you did not request it, ignore this email.")
+				if err != nil { // if send email failed, only logged
+					log.Println(err.Error())
+				}
+
+				log.Println(strconv.Quote("POST /api/password/forgot/"), "200 SUCCESS")
+				responseContent = map[string]any{
+					"message": "If the email is registered, a password reset link has been sent",
+				}
+				responseStatus = 200
+			} else { // if there's an error when create password reset token
+				log.Println(strconv.Quote("POST /api/password/forgot/"), "500 INTERNAL SERVER ERROR")
+				log.Println(err.Error())
+				responseContent = map[string]any{
+					"message": err.Error(),
+				}
+				responseStatus = 500
+			}
+
+		} else if err == nil || err == sql.ErrNoRows { // if user deleted or not exist
+			log.Println(strconv.Quote("POST /api/password/forgot/"), "200 SUCCESS")
+			responseContent = map[string]any{
+				"message": "If the email is registered, a password reset link has been sent",
+			}
+			responseStatus = 200
+		} else { // if there's an error when get user
+			log.Println(strconv.Quote("POST /api/password/forgot/"), "500 INTERNAL SERVER ERROR")
+			log.Println(err.Error())
+			responseContent = map[string]any{
+				"message": err.Error(),
+			}
+			responseStatus = 500
+		}
+
+	} else { // if email not exist
+		log.Println(strconv.Quote("POST /api/password/forgot/"), "422 UNPROCESSABLE ENTITY")
+		v := form.Validator{}
+		v.Required("email", email)
+		responseContent = getValidationErrorResponse(v.Errors())
+		responseStatus = 422
+	}
+
+	response, marshalErr := json.Marshal(responseContent)
+	if marshalErr != nil {
+		log.Fatal("Error When Creating Response -> ", marshalErr)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(responseStatus)
+	w.Write(response)
+}
+
+// ResetPasswordHandler handling route reset password
+// with password reset token (method: POST)
+func (a *API) ResetPasswordHandler(w http.ResponseWriter, r *http.Request) {
+	var responseContent map[string]any
+	var responseStatus int
+
+	// allow host
+	w.Header().Set("Access-Control-Allow-Origin", a.Config.FrontendURL)
+
+	// get password reset data from form-data
+	tokenString := r.FormValue("token")
+	password := r.FormValue("password")
+
+	// validate token exist and new password follows the password policy
+	v := form.Validator{}
+	v.Required("token", tokenString)
+	validationErrs := append(v.Errors(), form.ValidateNewPassword("password", password,
+		a.Config.PasswordMinLength)...)
+
+	if len(validationErrs) > 0 { // if token not exist or password not valid
+		log.Println(strconv.Quote("POST /api/password/reset/"), "422 UNPROCESSABLE ENTITY")
+		responseContent = getValidationErrorResponse(validationErrs)
+		responseStatus = 422
+	} else { // if form valid, reset password
+		status, err := a.Store.ResetPassword(tokenString, password)
+		if status == 200 && err == nil { // if reset password success
+			log.Println(strconv.Quote("POST /api/password/reset/"), "200 SUCCESS")
+			responseContent = map[string]any{
+				"message": "Password has been reset, please login again",
+			}
+			responseStatus = 200
+		} else if status == 400 { // if token not valid, used, or expired
+			log.Println(strconv.Quote("POST /api/password/reset/"), "400 BAD REQUEST")
+			responseContent = map[string]any{
+				"message": "Token not valid or expired",
+			}
+			responseStatus = 400
+		} else if status == 422 { // if new password used recently
+			log.Println(strconv.Quote("POST /api/password/reset/"), "422 UNPROCESSABLE ENTITY")
+			v := form.Validator{}
+			v.Add("password", form.CodeReused, "password can't be one of the last "+
+				strconv.Itoa(a.Config.PasswordHistoryCount)+" passwords",
+				map[string]any{"count": a.Config.PasswordHistoryCount})
+			responseContent = getValidationErrorResponse(v.Errors())
+			responseStatus = 422
+		} else { // if there's internal server error
+			log.Println(strconv.Quote("POST /api/password/reset/"), "500 INTERNAL SERVER ERROR")
+			log.Println(err.Error())
+			responseContent = map[string]any{
+				"message": err.Error(),
+			}
+			responseStatus = 500
+		}
+	}
+
+	response, marshalErr := json.Marshal(responseContent)
+	if marshalErr != nil {
+		log.Fatal("Error When Creating Response -> ", marshalErr)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(responseStatus)
+	w.Write(response)
+}
+
+// ChangePasswordHandler handling route change password
+// of the logged in user (method: POST)
+//
+// All other sessions of the user are logged out after password changed
+func (a *API) ChangePasswordHandler(w http.ResponseWriter, r *http.Request) {
+	var responseContent map[string]any
+	var responseStatus int
+
+	// allow host
+	w.Header().Set("Access-Control-Allow-Origin", a.Config.FrontendURL)
+
+	// get password data from form-data
+	currentPassword := r.FormValue("current_password")
+	newPassword := r.FormValue("new_password")
+
+	// check token in request
+	tokenString := getRequestToken(r)
+	if strings.TrimSpace(tokenString) != "" { // if token exist
+		userSession, status, err := a.authenticateToken(tokenString)
+		if status == 200 && err == nil { // if token valid
+
+			// validate password form
+			v := form.Validator{}
+			v.Required("current_password", currentPassword)
+			validationErrs := append(v.Errors(),
+				form.ValidateNewPassword("new_password", newPassword,
+					a.Config.PasswordMinLength)...)
+			if len(validationErrs) > 0 { // if password form not valid
+				log.Println(strconv.Quote("POST /api/user/me/password/"), "422 UNPROCESSABLE ENTITY")
+				responseContent = getValidationErrorResponse(validationErrs)
+				responseStatus = 422
+			} else { // if form valid, change password
+				status, err := a.Store.ChangePassword(userSession.User.ID,
+					currentPassword, newPassword, userSession.ID)
+				if status == 200 && err == nil { // if change password success
+					log.Println(strconv.Quote("POST /api/user/me/password/"), "200 SUCCESS")
+					responseContent = map[string]any{
+						"message": "Password changed!",
+					}
+					responseStatus = 200
+				} else if status == 400 { // if current password wrong
+					log.Println(strconv.Quote("POST /api/user/me/password/"), "400 BAD REQUEST")
+					responseContent = map[string]any{
+						"message": "Current password invalid",
+					}
+					responseStatus = 400
+				} else if status == 422 { // if new password used recently
+					log.Println(strconv.Quote("POST /api/user/me/password/"), "422 UNPROCESSABLE ENTITY")
+					responseContent = map[string]any{
+						"message": "New password can't be one of the last " +
+							strconv.Itoa(a.Config.PasswordHistoryCount) + " passwords",
+					}
+					responseStatus = 422
+				} else { // if there's an error when change password
+					log.Println(strconv.Quote("POST /api/user/me/password/"), "500 INTERNAL SERVER ERROR")
+					log.Println(err.Error())
+					responseContent = map[string]any{
+						"message": err.Error(),
+					}
+					responseStatus = 500
+				}
+			}
+
+		} else if status == 400 { // if token not valid
+			log.Println(strconv.Quote("POST /api/user/me/password/"), "400 BAD REQUEST")
+			responseContent = map[string]any{
+				"message": "Token not valid",
+			}
+			responseStatus = 400
+		} else { // if there's internal server error
+			log.Println(strconv.Quote("POST /api/user/me/password/"), "500 INTERNAL SERVER ERROR")
+			log.Println(err.Error())
+			responseContent = map[string]any{
+				"message": err.Error(),
+			}
+			responseStatus = 500
+		}
+	} else { // if token not exist
+		log.Println(strconv.Quote("POST /api/user/me/password/"), "400 BAD REQUEST")
+		responseContent = map[string]any{
+			"message": "Token empty/not found",
+		}
+		responseStatus = 400
+	}
+
+	response, marshalErr := json.Marshal(responseContent)
+	if marshalErr != nil {
+		log.Fatal("Error When Creating Response -> ", marshalErr)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(responseStatus)
+	w.Write(response)
+}
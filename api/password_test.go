@@ -0,0 +1,389 @@
+/*
+Package api containing API initialization and API route handler
+*/
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/reyhanfikridz/ecom-account-service/internal/mailer"
+	"github.com/reyhanfikridz/ecom-account-service/internal/utils"
+)
+
+// TestForgotPasswordHandlerAndResetPasswordHandler integration test
+// ForgotPasswordHandler and ResetPasswordHandler
+func TestForgotPasswordHandlerAndResetPasswordHandler(t *testing.T) {
+	// initialize testing API
+	a, err := GetTestingAPI()
+	if err != nil {
+		t.Errorf("There's an error when getting testing API => " + err.Error())
+	}
+
+	// create user first
+	_, err = a.DB.Exec(`DELETE FROM account_user WHERE email = $1`, "testreset@gmail.com")
+	if err != nil {
+		t.Errorf("There's an error when deleting reset testing data " + err.Error())
+	}
+
+	hashedPassword, err := utils.HashPassword("test")
+	if err != nil {
+		t.Errorf("There's an error when hashing password => " + err.Error())
+	}
+
+	_, err = a.DB.Exec(`
+		INSERT INTO account_user(email, password, full_name, address, phone_number, role)
+			VALUES($1, $2, $3, $4, $5, $6)`,
+		"testreset@gmail.com", hashedPassword, "test", "test", "test", "test")
+	if err != nil {
+		t.Errorf("There's an error when creating testing user data => " + err.Error())
+	}
+
+	// initialize testing table,
+	// "{token}" replaced by token from the last sent email
+	testTable := []struct {
+		URL             string
+		FormData        map[string]string
+		ExpectedStatus  int
+		ExpectedBodyKey []string
+	}{
+		{
+			URL: "/api/password/forgot/",
+			FormData: map[string]string{
+				"email": "testreset@gmail.com",
+			},
+			ExpectedStatus:  200,
+			ExpectedBodyKey: []string{"message"},
+		},
+		{
+			URL: "/api/password/forgot/",
+			FormData: map[string]string{
+				"email": "testresetnotexist@gmail.com",
+			},
+			ExpectedStatus:  200,
+			ExpectedBodyKey: []string{"message"},
+		},
+		{
+			URL: "/api/password/forgot/",
+			FormData: map[string]string{
+				"email": "",
+			},
+			ExpectedStatus:  422,
+			ExpectedBodyKey: []string{"message", "code", "errors"},
+		},
+		{
+			URL: "/api/password/reset/",
+			FormData: map[string]string{
+				"token":    "",
+				"password": "newtest1",
+			},
+			ExpectedStatus:  422,
+			ExpectedBodyKey: []string{"message", "code", "errors"},
+		},
+		{
+			URL: "/api/password/reset/",
+			FormData: map[string]string{
+				"token":    "{token}",
+				"password": "",
+			},
+			ExpectedStatus:  422,
+			ExpectedBodyKey: []string{"message", "code", "errors"},
+		},
+		{
+			URL: "/api/password/reset/",
+			FormData: map[string]string{
+				"token":    "{token}",
+				"password": "newtest",
+			},
+			ExpectedStatus:  422,
+			ExpectedBodyKey: []string{"message", "code", "errors"},
+		},
+		{
+			URL: "/api/password/reset/",
+			FormData: map[string]string{
+				"token":    "Invalid Token",
+				"password": "newtest1",
+			},
+			ExpectedStatus:  400,
+			ExpectedBodyKey: []string{"message"},
+		},
+		{
+			URL: "/api/password/reset/",
+			FormData: map[string]string{
+				"token":    "{token}",
+				"password": "newtest1",
+			},
+			ExpectedStatus:  200,
+			ExpectedBodyKey: []string{"message"},
+		},
+		{
+			URL: "/api/password/reset/",
+			FormData: map[string]string{
+				"token":    "{token}",
+				"password": "newtest1",
+			},
+			ExpectedStatus:  400,
+			ExpectedBodyKey: []string{"message"},
+		},
+		{
+			URL: "/api/login/",
+			FormData: map[string]string{
+				"email":    "testreset@gmail.com",
+				"password": "newtest1",
+			},
+			ExpectedStatus:  200,
+			ExpectedBodyKey: []string{"message", "token"},
+		},
+		{
+			URL: "/api/password/forgot/",
+			FormData: map[string]string{
+				"email": "testreset@gmail.com",
+			},
+			ExpectedStatus:  200,
+			ExpectedBodyKey: []string{"message"},
+		},
+		{
+			URL: "/api/password/reset/",
+			FormData: map[string]string{
+				"token":    "{token}",
+				"password": "newtest1",
+			},
+			ExpectedStatus:  422,
+			ExpectedBodyKey: []string{"message", "code", "errors"},
+		},
+		{
+			URL: "/api/password/reset/",
+			FormData: map[string]string{
+				"token":    "{token}",
+				"password": "newtest2",
+			},
+			ExpectedStatus:  200,
+			ExpectedBodyKey: []string{"message"},
+		},
+	}
+
+	// loop test in test table
+	tokenRegexp := regexp.MustCompile(`token=([A-Za-z0-9_-]+)`)
+	for _, test := range testTable {
+		// get token from the last sent email
+		token := ""
+		message, ok := a.Mailer.(*mailer.MemoryMailer).LastMessage("testreset@gmail.com")
+		if ok && tokenRegexp.MatchString(message.Body) {
+			token = tokenRegexp.FindStringSubmatch(message.Body)[1]
+		}
+
+		// transform form data to bytes buffer
+		var bFormData bytes.Buffer
+		w := multipart.NewWriter(&bFormData)
+		for key, value := range test.FormData {
+			fw, err := w.CreateFormField(key)
+			if err != nil {
+				t.Errorf("There's an error when creating bytes buffer form data => " +
+					err.Error())
+			}
+
+			_, err = io.Copy(fw, strings.NewReader(strings.ReplaceAll(value, "{token}", token)))
+			if err != nil {
+				t.Errorf("There's an error when creating bytes buffer form data => " +
+					err.Error())
+			}
+		}
+		w.Close()
+
+		// create new request
+		req, err := http.NewRequest("POST", test.URL, &bFormData)
+		if err != nil {
+			t.Errorf("There's an error when creating request API " + test.URL + " => " +
+				err.Error())
+		}
+		req.Header.Set("Content-Type", w.FormDataContentType())
+
+		// run request
+		response := httptest.NewRecorder()
+		a.Router.ServeHTTP(response, req)
+
+		// check response
+		if response.Code != test.ExpectedStatus {
+			t.Errorf("Expected status %d got %d (%s)", test.ExpectedStatus, response.Code, test.URL)
+		}
+
+		var responseData map[string]any
+		err = json.Unmarshal(response.Body.Bytes(), &responseData)
+		if err != nil {
+			t.Errorf("There's an error when unmarshal body response => " + err.Error())
+		}
+		for _, expectedKey := range test.ExpectedBodyKey {
+			if responseData[expectedKey] == nil {
+				t.Errorf("Expected key " + expectedKey + " empty/not found")
+			}
+		}
+	}
+}
+
+// TestChangePasswordHandler test ChangePasswordHandler
+func TestChangePasswordHandler(t *testing.T) {
+	// initialize testing API
+	a, err := GetTestingAPI()
+	if err != nil {
+		t.Errorf("There's an error when getting testing API => " + err.Error())
+	}
+
+	// create user with two sessions
+	_, err = a.DB.Exec(`DELETE FROM account_user WHERE email = $1`, "testchangepassword@gmail.com")
+	if err != nil {
+		t.Errorf("There's an error when deleting change password testing data " + err.Error())
+	}
+
+	hashedPassword, err := utils.HashPassword("test")
+	if err != nil {
+		t.Errorf("There's an error when hashing password => " + err.Error())
+	}
+
+	var userID int
+	err = a.DB.QueryRow(`
+		INSERT INTO account_user(email, password, full_name, address, phone_number, role)
+			VALUES($1, $2, $3, $4, $5, $6)
+			RETURNING id`,
+		"testchangepassword@gmail.com", hashedPassword, "test", "test", "test", "test").Scan(&userID)
+	if err != nil {
+		t.Errorf("There's an error when creating testing user data => " + err.Error())
+	}
+
+	tokens := []string{}
+	for i := 0; i < 2; i++ {
+		token, err := utils.GenerateJWT(a.Config.JWTKeys, userID, "test",
+			a.Config.AccessTokenDuration)
+		if err != nil {
+			t.Errorf("There's an error when creating token " +
+				"for creating user session data => " +
+				err.Error())
+		}
+
+		_, err = a.DB.Exec(`
+			INSERT INTO account_usersession(token, account_user_id)
+				VALUES($1, $2)`, token, userID)
+		if err != nil {
+			t.Errorf("There's an error when creating testing user session data => " +
+				err.Error())
+		}
+		tokens = append(tokens, token)
+	}
+
+	// initialize testing table
+	testTable := []struct {
+		FormData        map[string]string
+		ExpectedStatus  int
+		ExpectedBodyKey []string
+	}{
+		{
+			FormData: map[string]string{
+				"token":            "",
+				"current_password": "test",
+				"new_password":     "newpassword1",
+			},
+			ExpectedStatus:  400,
+			ExpectedBodyKey: []string{"message"},
+		},
+		{
+			FormData: map[string]string{
+				"token":            tokens[0],
+				"current_password": "test",
+				"new_password":     "short1",
+			},
+			ExpectedStatus:  422,
+			ExpectedBodyKey: []string{"message", "code", "errors"},
+		},
+		{
+			FormData: map[string]string{
+				"token":            tokens[0],
+				"current_password": "wrongpassword",
+				"new_password":     "newpassword1",
+			},
+			ExpectedStatus:  400,
+			ExpectedBodyKey: []string{"message"},
+		},
+		{
+			FormData: map[string]string{
+				"token":            tokens[0],
+				"current_password": "test",
+				"new_password":     "newpassword1",
+			},
+			ExpectedStatus:  200,
+			ExpectedBodyKey: []string{"message"},
+		},
+		{
+			FormData: map[string]string{
+				"token":            tokens[0],
+				"current_password": "newpassword1",
+				"new_password":     "newpassword1",
+			},
+			ExpectedStatus:  422,
+			ExpectedBodyKey: []string{"message"},
+		},
+		{
+			FormData: map[string]string{
+				"token":            tokens[1],
+				"current_password": "newpassword1",
+				"new_password":     "newpassword2",
+			},
+			ExpectedStatus:  400,
+			ExpectedBodyKey: []string{"message"},
+		},
+	}
+
+	// loop test in test table
+	for _, test := range testTable {
+		// transform form data to bytes buffer
+		var bFormData bytes.Buffer
+		w := multipart.NewWriter(&bFormData)
+		for key, value := range test.FormData {
+			fw, err := w.CreateFormField(key)
+			if err != nil {
+				t.Errorf("There's an error when creating bytes buffer form data => " +
+					err.Error())
+			}
+
+			_, err = io.Copy(fw, strings.NewReader(value))
+			if err != nil {
+				t.Errorf("There's an error when creating bytes buffer form data => " +
+					err.Error())
+			}
+		}
+		w.Close()
+
+		// create new request
+		req, err := http.NewRequest("POST", "/api/user/me/password/", &bFormData)
+		if err != nil {
+			t.Errorf("There's an error when creating request API change password => " +
+				err.Error())
+		}
+		req.Header.Set("Content-Type", w.FormDataContentType())
+
+		// run request
+		response := httptest.NewRecorder()
+		a.Router.ServeHTTP(response, req)
+
+		// check response
+		if response.Code != test.ExpectedStatus {
+			t.Errorf("Expected status %d got %d", test.ExpectedStatus, response.Code)
+		}
+
+		var responseData map[string]any
+		err = json.Unmarshal(response.Body.Bytes(), &responseData)
+		if err != nil {
+			t.Errorf("There's an error when unmarshal body response => " + err.Error())
+		}
+		for _, expectedKey := range test.ExpectedBodyKey {
+			if responseData[expectedKey] == nil {
+				t.Errorf("Expected key " + expectedKey + " empty/not found")
+			}
+		}
+	}
+}
@@ -0,0 +1,211 @@
+/*
+Package api containing API initialization and API route handler
+*/
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/reyhanfikridz/ecom-account-service/internal/form"
+)
+
+// SendPhoneVerificationHandler handling route send verification code by SMS
+// to phone number of the logged in user, not available if SMS sender
+// not configured (method: POST)
+func (a *API) SendPhoneVerificationHandler(w http.ResponseWriter, r *http.Request) {
+	var responseContent map[string]any
+	var responseStatus int
+
+	// allow host
+	w.Header().Set("Access-Control-Allow-Origin", a.Config.FrontendURL)
+
+	// check token in request
+	tokenString := getRequestToken(r)
+	if strings.TrimSpace(tokenString) != "" { // if token exist
+		userSession, status, err := a.authenticateToken(tokenString)
+		if status == 200 && err == nil && a.SMS == nil { // if SMS sender not configured
+			log.Println(strconv.Quote("POST /api/user/me/phone/verification/"),
+				"503 SERVICE UNAVAILABLE")
+			responseContent = map[string]any{
+				"message": "Phone verification not available",
+			}
+			responseStatus = 503
+		} else if status == 200 && err == nil { // if token valid
+			code, phoneNumber, status, err := a.Store.CreatePhoneVerification(
+				userSession.User.ID)
+			if status == 200 && err == nil { // if create code success, send it
+				err = a.SMS.Send(phoneNumber, "Your verification code is "+code+
+					". The code will expire in "+
+					a.Config.PhoneVerificationCodeDuration.String()+".")
+			}
+
+			if status == 200 && err == nil { // if send code success
+				log.Println(strconv.Quote("POST /api/user/me/phone/verification/"), "200 SUCCESS")
+				responseContent = map[string]any{
+					"message":      "Verification code sent!",
+					"phone_number": phoneNumber,
+				}
+				responseStatus = 200
+			} else if status == 400 { // if user not exist
+				log.Println(strconv.Quote("POST /api/user/me/phone/verification/"),
+					"400 BAD REQUEST")
+				responseContent = map[string]any{
+					"message": "User not found",
+				}
+				responseStatus = 400
+			} else if status == 409 { // if phone number already verified
+				log.Println(strconv.Quote("POST /api/user/me/phone/verification/"), "409 CONFLICT")
+				responseContent = map[string]any{
+					"message": "Phone number already verified",
+				}
+				responseStatus = 409
+			} else if status == 422 { // if phone number not valid
+				log.Println(strconv.Quote("POST /api/user/me/phone/verification/"),
+					"422 UNPROCESSABLE ENTITY")
+				responseContent = map[string]any{
+					"message": "phone_number not valid, please update your phone number",
+				}
+				responseStatus = 422
+			} else if status == 429 { // if code already sent recently
+				log.Println(strconv.Quote("POST /api/user/me/phone/verification/"),
+					"429 TOO MANY REQUESTS")
+				w.Header().Set("Retry-After",
+					strconv.Itoa(int(a.Config.PhoneVerificationResendInterval.Seconds())))
+				responseContent = map[string]any{
+					"message": "Verification code already sent, please try again later",
+				}
+				responseStatus = 429
+			} else { // if there's an error when send code
+				log.Println(strconv.Quote("POST /api/user/me/phone/verification/"),
+					"500 INTERNAL SERVER ERROR")
+				log.Println(err.Error())
+				responseContent = map[string]any{
+					"message": err.Error(),
+				}
+				responseStatus = 500
+			}
+
+		} else if status == 400 { // if token not valid
+			log.Println(strconv.Quote("POST /api/user/me/phone/verification/"), "400 BAD REQUEST")
+			responseContent = map[string]any{
+				"message": "Token not valid",
+			}
+			responseStatus = 400
+		} else { // if there's internal server error
+			log.Println(strconv.Quote("POST /api/user/me/phone/verification/"),
+				"500 INTERNAL SERVER ERROR")
+			log.Println(err.Error())
+			responseContent = map[string]any{
+				"message": err.Error(),
+			}
+			responseStatus = 500
+		}
+	} else { // if token not exist
+		log.Println(strconv.Quote("POST /api/user/me/phone/verification/"), "400 BAD REQUEST")
+		responseContent = map[string]any{
+			"message": "Token empty/not found",
+		}
+		responseStatus = 400
+	}
+
+	response, marshalErr := json.Marshal(responseContent)
+	if marshalErr != nil {
+		log.Fatal("Error When Creating Response -> ", marshalErr)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(responseStatus)
+	w.Write(response)
+}
+
+// VerifyPhoneHandler handling route verify phone number of the logged in user
+// by code sent by SMS (method: POST)
+func (a *API) VerifyPhoneHandler(w http.ResponseWriter, r *http.Request) {
+	var responseContent map[string]any
+	var responseStatus int
+
+	// allow host
+	w.Header().Set("Access-Control-Allow-Origin", a.Config.FrontendURL)
+
+	// get code from form-data
+	code := strings.TrimSpace(r.FormValue("code"))
+
+	// check token in request
+	tokenString := getRequestToken(r)
+	if strings.TrimSpace(tokenString) != "" { // if token exist
+		userSession, status, err := a.authenticateToken(tokenString)
+		if status == 200 && err == nil { // if token valid
+
+			// validate code exist
+			v := form.Validator{}
+			v.Required("code", code)
+
+			if len(v.Errors()) > 0 { // if code not exist
+				log.Println(strconv.Quote("POST /api/user/me/phone/verify/"), "422 UNPROCESSABLE ENTITY")
+				responseContent = getValidationErrorResponse(v.Errors())
+				responseStatus = 422
+			} else { // if code exist, verify phone number
+				status, err := a.Store.VerifyPhone(userSession.User.ID, code)
+				if status == 200 && err == nil { // if verify phone number success
+					log.Println(strconv.Quote("POST /api/user/me/phone/verify/"), "200 SUCCESS")
+					responseContent = map[string]any{
+						"message": "Phone number verified!",
+					}
+					responseStatus = 200
+				} else if status == 400 { // if code not valid or expired
+					log.Println(strconv.Quote("POST /api/user/me/phone/verify/"), "400 BAD REQUEST")
+					responseContent = map[string]any{
+						"message": "Code not valid or expired",
+					}
+					responseStatus = 400
+				} else if status == 429 { // if wrong code entered too many times
+					log.Println(strconv.Quote("POST /api/user/me/phone/verify/"),
+						"429 TOO MANY REQUESTS")
+					responseContent = map[string]any{
+						"message": "Too many wrong code, please request a new code",
+					}
+					responseStatus = 429
+				} else { // if there's an error when verify phone number
+					log.Println(strconv.Quote("POST /api/user/me/phone/verify/"),
+						"500 INTERNAL SERVER ERROR")
+					log.Println(err.Error())
+					responseContent = map[string]any{
+						"message": err.Error(),
+					}
+					responseStatus = 500
+				}
+			}
+
+		} else if status == 400 { // if token not valid
+			log.Println(strconv.Quote("POST /api/user/me/phone/verify/"), "400 BAD REQUEST")
+			responseContent = map[string]any{
+				"message": "Token not valid",
+			}
+			responseStatus = 400
+		} else { // if there's internal server error
+			log.Println(strconv.Quote("POST /api/user/me/phone/verify/"), "500 INTERNAL SERVER ERROR")
+			log.Println(err.Error())
+			responseContent = map[string]any{
+				"message": err.Error(),
+			}
+			responseStatus = 500
+		}
+	} else { // if token not exist
+		log.Println(strconv.Quote("POST /api/user/me/phone/verify/"), "400 BAD REQUEST")
+		responseContent = map[string]any{
+			"message": "Token empty/not found",
+		}
+		responseStatus = 400
+	}
+
+	response, marshalErr := json.Marshal(responseContent)
+	if marshalErr != nil {
+		log.Fatal("Error When Creating Response -> ", marshalErr)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(responseStatus)
+	w.Write(response)
+}
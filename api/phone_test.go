@@ -0,0 +1,197 @@
+/*
+Package api containing API initialization and API route handler
+*/
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/reyhanfikridz/ecom-account-service/internal/sms"
+	"github.com/reyhanfikridz/ecom-account-service/internal/utils"
+)
+
+// TestPhoneVerificationHandlers integration test
+// SendPhoneVerificationHandler and VerifyPhoneHandler
+func TestPhoneVerificationHandlers(t *testing.T) {
+	// initialize testing API
+	a, err := GetTestingAPI()
+	if err != nil {
+		t.Errorf("There's an error when getting testing API => " + err.Error())
+	}
+
+	// create user with phone number saved before normalization and its session
+	_, err = a.DB.Exec(`DELETE FROM account_user WHERE email = $1`, "testverifyphone@gmail.com")
+	if err != nil {
+		t.Errorf("There's an error when deleting verify phone testing data " + err.Error())
+	}
+
+	hashedPassword, err := utils.HashPassword("test")
+	if err != nil {
+		t.Errorf("There's an error when hashing password => " + err.Error())
+	}
+
+	var userID int
+	err = a.DB.QueryRow(`
+		INSERT INTO account_user(email, password, full_name, address, phone_number, role)
+			VALUES($1, $2, $3, $4, $5, $6)
+			RETURNING id`,
+		"testverifyphone@gmail.com", hashedPassword, "test", "", "0811-3333-3333",
+		"buyer").Scan(&userID)
+	if err != nil {
+		t.Errorf("There's an error when creating testing user data => " + err.Error())
+	}
+
+	token, err := utils.GenerateJWT(a.Config.JWTKeys, userID, "buyer",
+		a.Config.AccessTokenDuration)
+	if err != nil {
+		t.Errorf("There's an error when creating token " +
+			"for creating user session data => " +
+			err.Error())
+	}
+
+	_, err = a.DB.Exec(`
+		INSERT INTO account_usersession(token, account_user_id)
+			VALUES($1, $2)`, token, userID)
+	if err != nil {
+		t.Errorf("There's an error when creating testing user session data => " +
+			err.Error())
+	}
+
+	// initialize testing table, run in order,
+	// {code} in form data replaced by the last code sent by SMS
+	testTable := []struct {
+		URL             string
+		FormData        map[string]string
+		ExpectedStatus  int
+		ExpectedBodyKey []string
+	}{
+		{
+			URL:             "/api/user/me/phone/verification/",
+			FormData:        map[string]string{"token": ""},
+			ExpectedStatus:  400,
+			ExpectedBodyKey: []string{"message"},
+		},
+		{
+			URL:             "/api/user/me/phone/verification/",
+			FormData:        map[string]string{"token": token},
+			ExpectedStatus:  200,
+			ExpectedBodyKey: []string{"message", "phone_number"},
+		},
+		{
+			URL:             "/api/user/me/phone/verification/",
+			FormData:        map[string]string{"token": token},
+			ExpectedStatus:  429,
+			ExpectedBodyKey: []string{"message"},
+		},
+		{
+			URL:             "/api/user/me/phone/verify/",
+			FormData:        map[string]string{"token": token, "code": ""},
+			ExpectedStatus:  422,
+			ExpectedBodyKey: []string{"message", "code", "errors"},
+		},
+		{
+			URL:             "/api/user/me/phone/verify/",
+			FormData:        map[string]string{"token": token, "code": "{code}"},
+			ExpectedStatus:  200,
+			ExpectedBodyKey: []string{"message"},
+		},
+		{
+			URL:             "/api/user/me/phone/verify/",
+			FormData:        map[string]string{"token": token, "code": "{code}"},
+			ExpectedStatus:  400,
+			ExpectedBodyKey: []string{"message"},
+		},
+		{
+			URL:             "/api/user/me/phone/verification/",
+			FormData:        map[string]string{"token": token},
+			ExpectedStatus:  409,
+			ExpectedBodyKey: []string{"message"},
+		},
+	}
+
+	// loop test in test table
+	codePattern := regexp.MustCompile(`\d{6}`)
+	for _, test := range testTable {
+		// get the last code sent by SMS
+		code := ""
+		message, ok := a.SMS.(*sms.MemorySender).LastMessage("+6281133333333")
+		if ok {
+			code = codePattern.FindString(message.Message)
+		}
+
+		// transform form data to bytes buffer
+		var bFormData bytes.Buffer
+		w := multipart.NewWriter(&bFormData)
+		for key, value := range test.FormData {
+			fw, err := w.CreateFormField(key)
+			if err != nil {
+				t.Errorf("There's an error when creating bytes buffer form data => " +
+					err.Error())
+			}
+
+			_, err = io.Copy(fw, strings.NewReader(strings.Replace(value, "{code}", code, 1)))
+			if err != nil {
+				t.Errorf("There's an error when creating bytes buffer form data => " +
+					err.Error())
+			}
+		}
+		w.Close()
+
+		// create new request
+		req, err := http.NewRequest("POST", test.URL, &bFormData)
+		if err != nil {
+			t.Errorf("There's an error when creating request " + test.URL + " => " +
+				err.Error())
+		}
+		req.Header.Set("Content-Type", w.FormDataContentType())
+
+		// run request
+		response := httptest.NewRecorder()
+		a.Router.ServeHTTP(response, req)
+
+		// check response
+		if response.Code != test.ExpectedStatus {
+			t.Errorf("Expected status %d got %d (%s)", test.ExpectedStatus, response.Code,
+				test.URL)
+		}
+
+		var responseData map[string]any
+		err = json.Unmarshal(response.Body.Bytes(), &responseData)
+		if err != nil {
+			t.Errorf("There's an error when unmarshal body response => " + err.Error())
+		}
+		for _, expectedKey := range test.ExpectedBodyKey {
+			if responseData[expectedKey] == nil {
+				t.Errorf("Expected key " + expectedKey + " empty/not found")
+			}
+		}
+	}
+
+	// phone verification not available if SMS sender not configured
+	noSMSAPI := a
+	noSMSAPI.SMS = nil
+	err = noSMSAPI.InitRouter()
+	if err != nil {
+		t.Errorf("There's an error when initializing router => " + err.Error())
+	}
+
+	req, err := http.NewRequest("POST", "/api/user/me/phone/verification/", nil)
+	if err != nil {
+		t.Errorf("There's an error when creating request => " + err.Error())
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	response := httptest.NewRecorder()
+	noSMSAPI.Router.ServeHTTP(response, req)
+	if response.Code != 503 {
+		t.Errorf("Expected status 503 got %d", response.Code)
+	}
+}
@@ -0,0 +1,151 @@
+/*
+Package api containing API initialization and API route handler
+*/
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/reyhanfikridz/ecom-account-service/internal/form"
+	"github.com/reyhanfikridz/ecom-account-service/internal/model"
+	"github.com/reyhanfikridz/ecom-account-service/internal/phone"
+)
+
+// UpdateUserMeHandler handling route partial update profile
+// of the logged in user (method: PATCH)
+//
+// User version read by client must be sent in If-Match header
+// or version form-data, so the update can't overwrite another update
+func (a *API) UpdateUserMeHandler(w http.ResponseWriter, r *http.Request) {
+	var responseStatus int
+	var isResponseData bool
+	responseMessage := make(map[string]any)
+	responseData := model.User{}
+
+	// allow host
+	w.Header().Set("Access-Control-Allow-Origin", a.Config.FrontendURL)
+
+	// get profile data from form-data, only sent field updated
+	p := model.UserProfileUpdate{
+		FullName:    getFormValuePtr(r, "full_name"),
+		PhoneNumber: getFormValuePtr(r, "phone_number"),
+	}
+	stringVersion := getRequestVersion(r)
+
+	// check token in request
+	tokenString := getRequestToken(r)
+	if strings.TrimSpace(tokenString) != "" { // if token exist
+		userSession, status, err := a.authenticateToken(tokenString)
+		if status == 200 && err == nil { // if token valid
+
+			// validate version and profile form
+			version, versionErr := strconv.Atoi(stringVersion)
+			validationErrs := form.ValidateUserProfileForm(p, a.Config.PhoneDefaultRegion)
+			if strings.TrimSpace(stringVersion) == "" { // if version not exist
+				log.Println(strconv.Quote("PATCH /api/user/me/"), "428 PRECONDITION REQUIRED")
+				responseMessage["message"] = "version (or If-Match header) empty/not found"
+				responseStatus = 428
+			} else if versionErr != nil { // if version not valid
+				log.Println(strconv.Quote("PATCH /api/user/me/"), "422 UNPROCESSABLE ENTITY")
+				v := form.Validator{}
+				v.Add("version", form.CodeInvalid, "version not valid", nil)
+				responseMessage = getValidationErrorResponse(v.Errors())
+				responseStatus = 422
+			} else if len(validationErrs) > 0 { // if profile form not valid
+				log.Println(strconv.Quote("PATCH /api/user/me/"), "422 UNPROCESSABLE ENTITY")
+				responseMessage = getValidationErrorResponse(validationErrs)
+				responseStatus = 422
+			} else { // if form valid, update user profile
+				// phone number already validated, save it in E.164 format
+				if p.PhoneNumber != nil {
+					phoneNumber, _ := phone.Normalize(*p.PhoneNumber, a.Config.PhoneDefaultRegion)
+					p.PhoneNumber = &phoneNumber
+				}
+
+				user, status, err := a.Store.UpdateUserProfile(userSession.User.ID, version, p)
+				user.Password = "" // makes password empty for security purpose
+				user.Role = model.EffectiveRole(a.Config, user)
+
+				if status == 200 && err == nil { // if update user profile success
+					log.Println(strconv.Quote("PATCH /api/user/me/"), "200 SUCCESS")
+					w.Header().Set("ETag", strconv.Quote(strconv.Itoa(user.Version)))
+					isResponseData = true
+					responseData = user
+					responseStatus = 200
+				} else if status == 409 { // if user already updated by another request
+					log.Println(strconv.Quote("PATCH /api/user/me/"), "409 CONFLICT")
+					w.Header().Set("ETag", strconv.Quote(strconv.Itoa(user.Version)))
+					responseMessage["message"] = "User already updated by another request, " +
+						"please reload and try again"
+					responseMessage["user"] = user
+					responseStatus = 409
+				} else if status == 400 { // if user not exist
+					log.Println(strconv.Quote("PATCH /api/user/me/"), "400 BAD REQUEST")
+					responseMessage["message"] = "User not found"
+					responseStatus = 400
+				} else { // if there's an error when update user profile
+					log.Println(strconv.Quote("PATCH /api/user/me/"), "500 INTERNAL SERVER ERROR")
+					log.Println(err.Error())
+					responseMessage["message"] = err.Error()
+					responseStatus = 500
+				}
+			}
+
+		} else if status == 400 { // if token not valid
+			log.Println(strconv.Quote("PATCH /api/user/me/"), "400 BAD REQUEST")
+			responseMessage["message"] = "Token not valid"
+			responseStatus = 400
+		} else { // if there's internal server error
+			log.Println(strconv.Quote("PATCH /api/user/me/"), "500 INTERNAL SERVER ERROR")
+			log.Println(err.Error())
+			responseMessage["message"] = err.Error()
+			responseStatus = 500
+		}
+	} else { // if token not exist
+		log.Println(strconv.Quote("PATCH /api/user/me/"), "400 BAD REQUEST")
+		responseMessage["message"] = "Token empty/not found"
+		responseStatus = 400
+	}
+
+	// write response
+	var response []byte
+	var marshalErr error
+	if isResponseData {
+		response, marshalErr = json.Marshal(responseData)
+	} else {
+		response, marshalErr = json.Marshal(responseMessage)
+	}
+
+	if marshalErr != nil {
+		log.Fatal("Error When Creating Response -> ", marshalErr)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(responseStatus)
+	w.Write(response)
+}
+
+// getFormValuePtr get trimmed form value,
+// return nil if the field not sent at all
+func getFormValuePtr(r *http.Request, key string) *string {
+	value := strings.TrimSpace(r.FormValue(key)) // also parse form
+	if _, ok := r.Form[key]; !ok {
+		return nil
+	}
+
+	return &value
+}
+
+// getRequestVersion get resource version from If-Match header
+// (e.g. `"3"` or `W/"3"`), or from version form value
+func getRequestVersion(r *http.Request) string {
+	ifMatch := strings.TrimSpace(r.Header.Get("If-Match"))
+	if ifMatch != "" {
+		return strings.Trim(strings.TrimPrefix(ifMatch, "W/"), `"`)
+	}
+
+	return strings.TrimSpace(r.FormValue("version"))
+}
@@ -0,0 +1,234 @@
+/*
+Package api containing API initialization and API route handler
+*/
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/reyhanfikridz/ecom-account-service/internal/model"
+	"github.com/reyhanfikridz/ecom-account-service/internal/utils"
+)
+
+// TestUpdateUserMeHandler test UpdateUserMeHandler
+func TestUpdateUserMeHandler(t *testing.T) {
+	// initialize testing API
+	a, err := GetTestingAPI()
+	if err != nil {
+		t.Errorf("There's an error when getting testing API => " + err.Error())
+	}
+
+	// create user with its session
+	_, err = a.DB.Exec(`DELETE FROM account_user WHERE email = $1`, "testprofile@gmail.com")
+	if err != nil {
+		t.Errorf("There's an error when deleting profile testing data " + err.Error())
+	}
+
+	hashedPassword, err := utils.HashPassword("test")
+	if err != nil {
+		t.Errorf("There's an error when hashing password => " + err.Error())
+	}
+
+	var userID int
+	err = a.DB.QueryRow(`
+		INSERT INTO account_user(email, password, full_name, address, phone_number, role)
+			VALUES($1, $2, $3, $4, $5, $6)
+			RETURNING id`,
+		"testprofile@gmail.com", hashedPassword, "test", "test", "test", "test").Scan(&userID)
+	if err != nil {
+		t.Errorf("There's an error when creating testing user data => " + err.Error())
+	}
+
+	token, err := utils.GenerateJWT(a.Config.JWTKeys, userID, "test",
+		a.Config.AccessTokenDuration)
+	if err != nil {
+		t.Errorf("There's an error when creating token " +
+			"for creating user session data => " +
+			err.Error())
+	}
+
+	_, err = a.DB.Exec(`
+		INSERT INTO account_usersession(token, account_user_id)
+			VALUES($1, $2)`, token, userID)
+	if err != nil {
+		t.Errorf("There's an error when creating testing user session data => " +
+			err.Error())
+	}
+
+	runUpdateUserMeHandlerTest(t, a, token)
+}
+
+// TestUpdateUserMeHandlerMemoryStore test UpdateUserMeHandler
+// with memory store, without database
+func TestUpdateUserMeHandlerMemoryStore(t *testing.T) {
+	// initialize testing API
+	a, err := GetTestingMemoryAPI()
+	if err != nil {
+		t.Errorf("There's an error when getting testing API => " + err.Error())
+	}
+
+	// create user with its session
+	u, err := a.Store.CreateUser(model.User{
+		Email:       "testprofile@gmail.com",
+		Password:    "test",
+		FullName:    "test",
+		PhoneNumber: "test",
+		Role:        "test",
+	})
+	if err != nil {
+		t.Errorf("There's an error when creating testing user data => " + err.Error())
+	}
+
+	token, err := utils.GenerateJWT(a.Config.JWTKeys, u.ID, "test",
+		a.Config.AccessTokenDuration)
+	if err != nil {
+		t.Errorf("There's an error when creating token " +
+			"for creating user session data => " +
+			err.Error())
+	}
+
+	_, err = a.Store.CreateUserSession(model.UserSession{Token: token, User: u})
+	if err != nil {
+		t.Errorf("There's an error when creating testing user session data => " +
+			err.Error())
+	}
+
+	runUpdateUserMeHandlerTest(t, a, token)
+}
+
+// runUpdateUserMeHandlerTest run update user me handler testing table
+// with token of user version 1
+func runUpdateUserMeHandlerTest(t *testing.T, a API, token string) {
+	// initialize testing table
+	testTable := []struct {
+		IfMatch         string
+		FormData        map[string]string
+		ExpectedStatus  int
+		ExpectedBodyKey []string
+	}{
+		{
+			FormData: map[string]string{
+				"token":     token,
+				"full_name": "new test",
+			},
+			ExpectedStatus:  428,
+			ExpectedBodyKey: []string{"message"},
+		},
+		{
+			FormData: map[string]string{
+				"token":     token,
+				"version":   "v1",
+				"full_name": "new test",
+			},
+			ExpectedStatus:  422,
+			ExpectedBodyKey: []string{"message", "code", "errors"},
+		},
+		{
+			FormData: map[string]string{
+				"token":   token,
+				"version": "1",
+			},
+			ExpectedStatus:  422,
+			ExpectedBodyKey: []string{"message", "code", "errors"},
+		},
+		{
+			FormData: map[string]string{
+				"token":     "",
+				"version":   "1",
+				"full_name": "new test",
+			},
+			ExpectedStatus:  400,
+			ExpectedBodyKey: []string{"message"},
+		},
+		{
+			FormData: map[string]string{
+				"token":     token,
+				"version":   "1",
+				"full_name": "new test",
+			},
+			ExpectedStatus:  200,
+			ExpectedBodyKey: []string{"id", "email", "full_name", "version", "updated_at"},
+		},
+		{
+			IfMatch: `"1"`,
+			FormData: map[string]string{
+				"token":        token,
+				"phone_number": "08122222222",
+			},
+			ExpectedStatus:  409,
+			ExpectedBodyKey: []string{"message", "user"},
+		},
+		{
+			IfMatch: `"2"`,
+			FormData: map[string]string{
+				"token":        token,
+				"phone_number": "08122222222",
+			},
+			ExpectedStatus:  200,
+			ExpectedBodyKey: []string{"id", "email", "phone_number", "version", "updated_at"},
+		},
+	}
+
+	// loop test in test table
+	for _, test := range testTable {
+		// transform form data to bytes buffer
+		var bFormData bytes.Buffer
+		w := multipart.NewWriter(&bFormData)
+		for key, value := range test.FormData {
+			fw, err := w.CreateFormField(key)
+			if err != nil {
+				t.Errorf("There's an error when creating bytes buffer form data => " +
+					err.Error())
+			}
+
+			_, err = io.Copy(fw, strings.NewReader(value))
+			if err != nil {
+				t.Errorf("There's an error when creating bytes buffer form data => " +
+					err.Error())
+			}
+		}
+		w.Close()
+
+		// create new request
+		req, err := http.NewRequest("PATCH", "/api/user/me/", &bFormData)
+		if err != nil {
+			t.Errorf("There's an error when creating request API update user me => " +
+				err.Error())
+		}
+		req.Header.Set("Content-Type", w.FormDataContentType())
+		if test.IfMatch != "" {
+			req.Header.Set("If-Match", test.IfMatch)
+		}
+
+		// run request
+		response := httptest.NewRecorder()
+		a.Router.ServeHTTP(response, req)
+
+		// check response
+		if response.Code != test.ExpectedStatus {
+			t.Errorf("Expected status %d got %d", test.ExpectedStatus, response.Code)
+		}
+
+		var responseData map[string]any
+		err = json.Unmarshal(response.Body.Bytes(), &responseData)
+		if err != nil {
+			t.Errorf("There's an error when unmarshal body response => " + err.Error())
+		}
+		for _, expectedKey := range test.ExpectedBodyKey {
+			if responseData[expectedKey] == nil {
+				t.Errorf("Expected key " + expectedKey + " empty/not found")
+			}
+		}
+
+		if response.Code == 200 && responseData["password"] != "" {
+			t.Errorf("Expected password empty, but got not empty")
+		}
+	}
+}
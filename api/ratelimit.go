@@ -0,0 +1,119 @@
+/*
+Package api containing API initialization and API route handler
+*/
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/reyhanfikridz/ecom-account-service/internal/ratelimit"
+	"github.com/reyhanfikridz/ecom-account-service/internal/utils"
+)
+
+// RateLimitedHandler handling request over the rate limit
+func (a *API) RateLimitedHandler(w http.ResponseWriter, r *http.Request) {
+	// allow host
+	w.Header().Set("Access-Control-Allow-Origin", a.Config.FrontendURL)
+
+	log.Println(strconv.Quote(r.Method+" "+r.URL.Path), "429 TOO MANY REQUESTS")
+	responseContent := map[string]any{
+		"message": "Too many requests, please try again later",
+	}
+
+	response, marshalErr := json.Marshal(responseContent)
+	if marshalErr != nil {
+		log.Fatal("Error When Creating Response -> ", marshalErr)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(429)
+	w.Write(response)
+}
+
+// getRateLimiter get rate limiter of all routes, rules set per route name
+func (a *API) getRateLimiter() *ratelimit.Limiter {
+	perIP := func(requests int, period time.Duration) ratelimit.Rule {
+		return ratelimit.Rule{
+			Name:  "ip",
+			Key:   getRequestIP,
+			Limit: ratelimit.Limit{Requests: requests, Period: period},
+		}
+	}
+	perEmail := func(requests int, period time.Duration) ratelimit.Rule {
+		return ratelimit.Rule{
+			Name:  "email",
+			Key:   getRateLimitEmailKey,
+			Limit: ratelimit.Limit{Requests: requests, Period: period},
+		}
+	}
+	perSubject := func(requests int, period time.Duration) ratelimit.Rule {
+		return ratelimit.Rule{
+			Name:  "sub",
+			Key:   a.getRateLimitSubjectKey,
+			Limit: ratelimit.Limit{Requests: requests, Period: period},
+		}
+	}
+
+	return &ratelimit.Limiter{
+		Store: a.RateLimitStore,
+		Rules: map[string][]ratelimit.Rule{
+			"register": {perIP(10, time.Hour)},
+			"login": {
+				perIP(30, time.Minute),
+				perEmail(10, time.Minute),
+			},
+			"login_mfa":     {perIP(30, time.Minute)},
+			"refresh_token": {perIP(60, time.Minute)},
+			"forgot_password": {
+				perIP(20, time.Hour),
+				perEmail(5, time.Hour),
+			},
+			"reset_password": {perIP(20, time.Hour)},
+			"verify_email":   {perIP(20, time.Hour)},
+			"resend_email_verification": {
+				perIP(20, time.Hour),
+				perEmail(5, time.Hour),
+			},
+
+			// called by other services on every request,
+			// so limited per token subject instead of per service IP
+			"authorize": {perSubject(600, time.Minute)},
+
+			"delete_user_me":          {perSubject(5, time.Hour)},
+			"export_user_me":          {perSubject(5, time.Hour)},
+			"send_phone_verification": {perSubject(5, time.Hour)},
+			"verify_phone":            {perSubject(10, time.Hour)},
+			"change_password":         {perSubject(10, time.Hour)},
+			"request_email_change":    {perSubject(5, time.Hour)},
+			"confirm_email_change":    {perIP(20, time.Hour)},
+			"revert_email_change":     {perIP(20, time.Hour)},
+
+			"jwks": {perIP(120, time.Minute)},
+		},
+		DefaultRules:   []ratelimit.Rule{perSubject(120, time.Minute)},
+		LimitedHandler: a.RateLimitedHandler,
+	}
+}
+
+// getRateLimitEmailKey get rate limit key by email in form
+func getRateLimitEmailKey(r *http.Request) string {
+	return strings.ToLower(strings.TrimSpace(r.FormValue("email")))
+}
+
+// getRateLimitSubjectKey get rate limit key by token subject (user ID),
+// or user email for token generated before identified by user ID,
+// use IP address if token not exist or not valid
+func (a *API) getRateLimitSubjectKey(r *http.Request) string {
+	claimsMap := utils.ValidateJWT(a.Config.JWTKeys, getRequestToken(r))
+	if claimsMap != nil && claimsMap["sub"] != "" {
+		return "sub:" + claimsMap["sub"]
+	} else if claimsMap != nil && claimsMap["email"] != "" {
+		return claimsMap["email"]
+	}
+
+	return "ip:" + getRequestIP(r)
+}
@@ -0,0 +1,96 @@
+/*
+Package api containing API initialization and API route handler
+*/
+package api
+
+import (
+	"bytes"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestRateLimitedHandler test rate limit of login route
+// handled by RateLimitedHandler
+func TestRateLimitedHandler(t *testing.T) {
+	// initialize testing API, enable rate limit while testing
+	c := testConfig
+	c.RateLimitEnabled = true
+
+	a, err := GetTestingAPIWithConfig(c)
+	if err != nil {
+		t.Errorf("There's an error when getting testing API => " + err.Error())
+	}
+
+	// initialize testing table,
+	// login limited to 10 requests per minute per email
+	type testCase struct {
+		Email          string
+		ExpectedStatus int
+	}
+	testTable := []testCase{}
+	for i := 0; i < 10; i++ {
+		testTable = append(testTable, testCase{
+			Email:          "testratelimit@gmail.com",
+			ExpectedStatus: 422,
+		})
+	}
+	testTable = append(testTable, testCase{
+		Email:          "testratelimit@gmail.com",
+		ExpectedStatus: 429,
+	}, testCase{
+		Email:          "testratelimitanother@gmail.com",
+		ExpectedStatus: 422,
+	})
+
+	// loop test in test table
+	for _, test := range testTable {
+		// transform form data to bytes buffer,
+		// password empty so the form not valid
+		var bFormData bytes.Buffer
+		w := multipart.NewWriter(&bFormData)
+		fw, err := w.CreateFormField("email")
+		if err != nil {
+			t.Errorf("There's an error when creating bytes buffer form data => " +
+				err.Error())
+		}
+
+		_, err = io.Copy(fw, strings.NewReader(test.Email))
+		if err != nil {
+			t.Errorf("There's an error when creating bytes buffer form data => " +
+				err.Error())
+		}
+		w.Close()
+
+		// create new request
+		req, err := http.NewRequest("POST", "/api/login/", &bFormData)
+		if err != nil {
+			t.Errorf("There's an error when creating request API login => " +
+				err.Error())
+		}
+		req.Header.Set("Content-Type", w.FormDataContentType())
+
+		// run request
+		response := httptest.NewRecorder()
+		a.Router.ServeHTTP(response, req)
+
+		// check response
+		if response.Code != test.ExpectedStatus {
+			t.Errorf("Expected status %d got %d", test.ExpectedStatus, response.Code)
+		}
+
+		for _, header := range []string{"RateLimit-Limit", "RateLimit-Remaining",
+			"RateLimit-Reset"} {
+			if response.Header().Get(header) == "" {
+				t.Errorf("Expected header " + header + " exist, but got empty")
+			}
+		}
+
+		if response.Code == 429 && response.Header().Get("Retry-After") == "" {
+			t.Errorf("Expected header Retry-After exist, but got empty")
+		}
+	}
+}
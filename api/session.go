@@ -0,0 +1,223 @@
+/*
+Package api containing API initialization and API route handler
+*/
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// GetSessionsHandler handling route get all active sessions
+// of the logged in user (method: GET)
+func (a *API) GetSessionsHandler(w http.ResponseWriter, r *http.Request) {
+	var responseContent map[string]any
+	var responseStatus int
+
+	// allow host
+	w.Header().Set("Access-Control-Allow-Origin", a.Config.FrontendURL)
+
+	// check token in request
+	tokenString := getRequestToken(r)
+	if strings.TrimSpace(tokenString) != "" { // if token exist
+		userSession, status, err := a.authenticateToken(tokenString)
+		if status == 200 && err == nil { // if token valid
+
+			// get all user sessions
+			userSessions, err := a.Store.GetUserSessions(userSession.User.ID)
+			if err == nil { // if get user sessions success
+				log.Println(strconv.Quote("GET /api/sessions/"), "200 SUCCESS")
+
+				sessions := []map[string]any{}
+				for _, us := range userSessions {
+					sessions = append(sessions, map[string]any{
+						"id":           us.ID,
+						"user_agent":   us.UserAgent,
+						"ip_address":   us.IPAddress,
+						"created_at":   us.CreatedAt,
+						"last_seen_at": us.LastSeenAt,
+						"is_current":   us.ID == userSession.ID,
+					})
+				}
+
+				responseContent = map[string]any{
+					"sessions": sessions,
+				}
+				responseStatus = 200
+			} else { // if there's an error when get user sessions
+				log.Println(strconv.Quote("GET /api/sessions/"), "500 INTERNAL SERVER ERROR")
+				log.Println(err.Error())
+				responseContent = map[string]any{
+					"message": err.Error(),
+				}
+				responseStatus = 500
+			}
+
+		} else if status == 400 { // if token not valid
+			log.Println(strconv.Quote("GET /api/sessions/"), "400 BAD REQUEST")
+			responseContent = map[string]any{
+				"message": "Token not valid",
+			}
+			responseStatus = 400
+		} else { // if there's internal server error
+			log.Println(strconv.Quote("GET /api/sessions/"), "500 INTERNAL SERVER ERROR")
+			log.Println(err.Error())
+			responseContent = map[string]any{
+				"message": err.Error(),
+			}
+			responseStatus = 500
+		}
+	} else { // if token not exist
+		log.Println(strconv.Quote("GET /api/sessions/"), "400 BAD REQUEST")
+		responseContent = map[string]any{
+			"message": "Token empty/not found",
+		}
+		responseStatus = 400
+	}
+
+	response, marshalErr := json.Marshal(responseContent)
+	if marshalErr != nil {
+		log.Fatal("Error When Creating Response -> ", marshalErr)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(responseStatus)
+	w.Write(response)
+}
+
+// DeleteSessionHandler handling route revoke one session
+// of the logged in user (method: DELETE)
+func (a *API) DeleteSessionHandler(w http.ResponseWriter, r *http.Request) {
+	var responseContent map[string]any
+	var responseStatus int
+
+	// allow host
+	w.Header().Set("Access-Control-Allow-Origin", a.Config.FrontendURL)
+
+	// check token in request
+	tokenString := getRequestToken(r)
+	if strings.TrimSpace(tokenString) != "" { // if token exist
+		userSession, status, err := a.authenticateToken(tokenString)
+		if status == 200 && err == nil { // if token valid
+
+			// delete user session by ID, route only match numeric ID
+			ID, _ := strconv.Atoi(mux.Vars(r)["id"])
+			isDeleted, err := a.Store.DeleteUserSessionByID(ID, userSession.User.ID)
+			if err == nil && isDeleted { // if delete success
+				log.Println(strconv.Quote("DELETE /api/sessions/{id}/"), "200 SUCCESS")
+				responseContent = map[string]any{
+					"message": "Session revoked",
+				}
+				responseStatus = 200
+			} else if err == nil && !isDeleted { // if session not found
+				log.Println(strconv.Quote("DELETE /api/sessions/{id}/"), "404 NOT FOUND")
+				responseContent = map[string]any{
+					"message": "Session not found",
+				}
+				responseStatus = 404
+			} else { // if there's an error when delete user session
+				log.Println(strconv.Quote("DELETE /api/sessions/{id}/"), "500 INTERNAL SERVER ERROR")
+				log.Println(err.Error())
+				responseContent = map[string]any{
+					"message": err.Error(),
+				}
+				responseStatus = 500
+			}
+
+		} else if status == 400 { // if token not valid
+			log.Println(strconv.Quote("DELETE /api/sessions/{id}/"), "400 BAD REQUEST")
+			responseContent = map[string]any{
+				"message": "Token not valid",
+			}
+			responseStatus = 400
+		} else { // if there's internal server error
+			log.Println(strconv.Quote("DELETE /api/sessions/{id}/"), "500 INTERNAL SERVER ERROR")
+			log.Println(err.Error())
+			responseContent = map[string]any{
+				"message": err.Error(),
+			}
+			responseStatus = 500
+		}
+	} else { // if token not exist
+		log.Println(strconv.Quote("DELETE /api/sessions/{id}/"), "400 BAD REQUEST")
+		responseContent = map[string]any{
+			"message": "Token empty/not found",
+		}
+		responseStatus = 400
+	}
+
+	response, marshalErr := json.Marshal(responseContent)
+	if marshalErr != nil {
+		log.Fatal("Error When Creating Response -> ", marshalErr)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(responseStatus)
+	w.Write(response)
+}
+
+// DeleteSessionsHandler handling route revoke all sessions
+// of the logged in user / log out everywhere (method: DELETE)
+func (a *API) DeleteSessionsHandler(w http.ResponseWriter, r *http.Request) {
+	var responseContent map[string]any
+	var responseStatus int
+
+	// allow host
+	w.Header().Set("Access-Control-Allow-Origin", a.Config.FrontendURL)
+
+	// check token in request
+	tokenString := getRequestToken(r)
+	if strings.TrimSpace(tokenString) != "" { // if token exist
+		userSession, status, err := a.authenticateToken(tokenString)
+		if status == 200 && err == nil { // if token valid
+
+			// delete all user sessions
+			err := a.Store.DeleteUserSessions(userSession.User.ID)
+			if err == nil { // if delete success
+				log.Println(strconv.Quote("DELETE /api/sessions/"), "200 SUCCESS")
+				responseContent = map[string]any{
+					"message": "All sessions revoked",
+				}
+				responseStatus = 200
+			} else { // if there's an error when delete user sessions
+				log.Println(strconv.Quote("DELETE /api/sessions/"), "500 INTERNAL SERVER ERROR")
+				log.Println(err.Error())
+				responseContent = map[string]any{
+					"message": err.Error(),
+				}
+				responseStatus = 500
+			}
+
+		} else if status == 400 { // if token not valid
+			log.Println(strconv.Quote("DELETE /api/sessions/"), "400 BAD REQUEST")
+			responseContent = map[string]any{
+				"message": "Token not valid",
+			}
+			responseStatus = 400
+		} else { // if there's internal server error
+			log.Println(strconv.Quote("DELETE /api/sessions/"), "500 INTERNAL SERVER ERROR")
+			log.Println(err.Error())
+			responseContent = map[string]any{
+				"message": err.Error(),
+			}
+			responseStatus = 500
+		}
+	} else { // if token not exist
+		log.Println(strconv.Quote("DELETE /api/sessions/"), "400 BAD REQUEST")
+		responseContent = map[string]any{
+			"message": "Token empty/not found",
+		}
+		responseStatus = 400
+	}
+
+	response, marshalErr := json.Marshal(responseContent)
+	if marshalErr != nil {
+		log.Fatal("Error When Creating Response -> ", marshalErr)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(responseStatus)
+	w.Write(response)
+}
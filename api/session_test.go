@@ -0,0 +1,234 @@
+/*
+Package api containing API initialization and API route handler
+*/
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/reyhanfikridz/ecom-account-service/internal/model"
+	"github.com/reyhanfikridz/ecom-account-service/internal/utils"
+)
+
+// TestSessionsHandler integration test
+// GetSessionsHandler, DeleteSessionHandler and DeleteSessionsHandler
+func TestSessionsHandler(t *testing.T) {
+	// initialize testing API
+	a, err := GetTestingAPI()
+	if err != nil {
+		t.Errorf("There's an error when getting testing API => " + err.Error())
+	}
+
+	// create user
+	_, err = a.DB.Exec(`DELETE FROM account_user WHERE email = $1`, "testsessions@gmail.com")
+	if err != nil {
+		t.Errorf("There's an error when deleting sessions testing data " + err.Error())
+	}
+
+	hashedPassword, err := utils.HashPassword("test")
+	if err != nil {
+		t.Errorf("There's an error when hashing password => " + err.Error())
+	}
+
+	createdRow := a.DB.QueryRow(`
+		INSERT INTO account_user(email, password, full_name, address, phone_number, role)
+			VALUES($1, $2, $3, $4, $5, $6)
+			RETURNING id`,
+		"testsessions@gmail.com", hashedPassword, "test", "test", "test", "test")
+	if createdRow.Err() != nil {
+		t.Errorf("There's an error when creating testing user data => " +
+			createdRow.Err().Error())
+	}
+
+	var userID int
+	err = createdRow.Scan(&userID)
+	if err != nil {
+		t.Errorf("There's an error when creating testing user data => " +
+			err.Error())
+	}
+
+	// create user sessions on two devices
+	tokens := []string{}
+	sessionIDs := []int{}
+	for _, userAgent := range []string{"laptop-agent", "phone-agent"} {
+		token, err := utils.GenerateJWT(a.Config.JWTKeys, userID, "test",
+			a.Config.AccessTokenDuration)
+		if err != nil {
+			t.Errorf("There's an error when creating token " +
+				"for creating user session data => " +
+				err.Error())
+		}
+
+		var sessionID int
+		err = a.DB.QueryRow(`
+			INSERT INTO account_usersession(token, account_user_id, user_agent, ip_address)
+				VALUES($1, $2, $3, $4) RETURNING id`,
+			token, userID, userAgent, "127.0.0.1").Scan(&sessionID)
+		if err != nil {
+			t.Errorf("There's an error when creating testing user session data => " +
+				err.Error())
+		}
+
+		tokens = append(tokens, token)
+		sessionIDs = append(sessionIDs, sessionID)
+	}
+
+	runSessionsHandlerTest(t, a, tokens, sessionIDs)
+}
+
+// TestSessionsHandlerMemoryStore integration test
+// GetSessionsHandler, DeleteSessionHandler and DeleteSessionsHandler
+// with memory store, without database
+func TestSessionsHandlerMemoryStore(t *testing.T) {
+	// initialize testing API
+	a, err := GetTestingMemoryAPI()
+	if err != nil {
+		t.Errorf("There's an error when getting testing API => " + err.Error())
+	}
+
+	// create user
+	u, err := a.Store.CreateUser(model.User{
+		Email:    "testsessions@gmail.com",
+		Password: "test",
+		Role:     "test",
+	})
+	if err != nil {
+		t.Errorf("There's an error when creating testing user data => " + err.Error())
+	}
+
+	// create user sessions on two devices
+	tokens := []string{}
+	sessionIDs := []int{}
+	for _, userAgent := range []string{"laptop-agent", "phone-agent"} {
+		token, err := utils.GenerateJWT(a.Config.JWTKeys, u.ID, "test",
+			a.Config.AccessTokenDuration)
+		if err != nil {
+			t.Errorf("There's an error when creating token " +
+				"for creating user session data => " +
+				err.Error())
+		}
+
+		userSession, err := a.Store.CreateUserSession(model.UserSession{
+			Token:     token,
+			User:      u,
+			UserAgent: userAgent,
+			IPAddress: "127.0.0.1",
+		})
+		if err != nil {
+			t.Errorf("There's an error when creating testing user session data => " +
+				err.Error())
+		}
+
+		tokens = append(tokens, token)
+		sessionIDs = append(sessionIDs, userSession.ID)
+	}
+
+	runSessionsHandlerTest(t, a, tokens, sessionIDs)
+}
+
+// runSessionsHandlerTest run sessions handler testing table
+// with user sessions of the same user
+func runSessionsHandlerTest(t *testing.T, a API, tokens []string, sessionIDs []int) {
+	// initialize testing table
+	testTable := []struct {
+		Method          string
+		URL             string
+		Token           string
+		ExpectedStatus  int
+		ExpectedBodyKey []string
+	}{
+		{
+			Method:          "GET",
+			URL:             "/api/sessions/",
+			Token:           tokens[0],
+			ExpectedStatus:  200,
+			ExpectedBodyKey: []string{"sessions"},
+		},
+		{
+			Method:          "GET",
+			URL:             "/api/sessions/",
+			Token:           "Invalid Token",
+			ExpectedStatus:  400,
+			ExpectedBodyKey: []string{"message"},
+		},
+		{
+			Method:          "GET",
+			URL:             "/api/sessions/",
+			Token:           "",
+			ExpectedStatus:  400,
+			ExpectedBodyKey: []string{"message"},
+		},
+		{
+			Method:          "DELETE",
+			URL:             "/api/sessions/" + strconv.Itoa(sessionIDs[1]) + "/",
+			Token:           tokens[0],
+			ExpectedStatus:  200,
+			ExpectedBodyKey: []string{"message"},
+		},
+		{
+			Method:          "DELETE",
+			URL:             "/api/sessions/" + strconv.Itoa(sessionIDs[1]) + "/",
+			Token:           tokens[0],
+			ExpectedStatus:  404,
+			ExpectedBodyKey: []string{"message"},
+		},
+		{
+			Method:          "GET",
+			URL:             "/api/sessions/",
+			Token:           tokens[1],
+			ExpectedStatus:  400,
+			ExpectedBodyKey: []string{"message"},
+		},
+		{
+			Method:          "DELETE",
+			URL:             "/api/sessions/",
+			Token:           tokens[0],
+			ExpectedStatus:  200,
+			ExpectedBodyKey: []string{"message"},
+		},
+		{
+			Method:          "GET",
+			URL:             "/api/sessions/",
+			Token:           tokens[0],
+			ExpectedStatus:  400,
+			ExpectedBodyKey: []string{"message"},
+		},
+	}
+
+	// loop test in test table
+	for _, test := range testTable {
+		// create new request
+		req, err := http.NewRequest(test.Method, test.URL, nil)
+		if err != nil {
+			t.Errorf("There's an error when creating request API sessions => " +
+				err.Error())
+		}
+		if test.Token != "" {
+			req.Header.Set("Authorization", "Bearer "+test.Token)
+		}
+
+		// run request
+		response := httptest.NewRecorder()
+		a.Router.ServeHTTP(response, req)
+
+		// check response
+		if response.Code != test.ExpectedStatus {
+			t.Errorf("Expected status %d got %d", test.ExpectedStatus, response.Code)
+		}
+
+		var responseData map[string]any
+		err = json.Unmarshal(response.Body.Bytes(), &responseData)
+		if err != nil {
+			t.Errorf("There's an error when unmarshal body response => " + err.Error())
+		}
+		for _, expectedKey := range test.ExpectedBodyKey {
+			if responseData[expectedKey] == nil {
+				t.Errorf("Expected key " + expectedKey + " empty/not found")
+			}
+		}
+	}
+}
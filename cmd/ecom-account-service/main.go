@@ -6,40 +6,59 @@ package main
 import (
 	"log"
 	"net/http"
+	"os"
 
 	"github.com/reyhanfikridz/ecom-account-service/api"
 	"github.com/reyhanfikridz/ecom-account-service/internal/config"
+	"github.com/reyhanfikridz/ecom-account-service/internal/event"
+	"github.com/reyhanfikridz/ecom-account-service/internal/mailer"
+	"github.com/reyhanfikridz/ecom-account-service/internal/sms"
 )
 
 // main
 func main() {
+	// init all config before can be used, flags written before subcommand
+	// (e.g. "ecom-account-service -config config.yaml migrate up")
+	c, args, err := config.Load(os.Args[1:])
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// run migrate subcommand instead of serving server
+	if len(args) > 0 {
+		if args[0] != "migrate" {
+			log.Fatalf("Command %q not exist", args[0])
+		}
+
+		err = runMigrate(c, args[1:], os.Stdout)
+		if err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	// init API
-	a, err := InitAPI()
+	a, err := InitAPI(c)
 	if err != nil {
 		log.Fatal(err)
 	}
 
+	// erase deleted accounts periodically
+	go a.RunAccountErasure(c.AccountErasureInterval)
+
+	// build requested personal data exports periodically
+	go a.RunDataExport(c.DataExportInterval)
+
 	// serve server
 	log.Fatal(http.ListenAndServe(":8010", a.Router))
 }
 
-// InitAPI initialize API
-func InitAPI() (api.API, error) {
-	a := api.API{}
-
-	// init all config before can be used
-	err := config.InitConfig()
-	if err != nil {
-		return a, err
-	}
+// InitAPI initialize API of the config
+func InitAPI(c config.Config) (api.API, error) {
+	a := api.API{Config: c}
 
 	// init database
-	DBConfig := map[string]string{
-		"user":     config.DBUsername,
-		"password": config.DBPassword,
-		"dbname":   config.DBName,
-	}
-	err = a.InitDB(DBConfig)
+	err := a.InitDB(getDBConfig(c))
 	if err != nil {
 		return a, err
 	}
@@ -50,5 +69,61 @@ func InitAPI() (api.API, error) {
 		return a, err
 	}
 
+	// init email sender
+	a.Mailer = mailer.SMTPMailer{
+		Host:     c.SMTPHost,
+		Port:     c.SMTPPort,
+		Username: c.SMTPUsername,
+		Password: c.SMTPPassword,
+		From:     c.MailFrom,
+	}
+
+	// init SMS sender if SMS provider configured
+	a.SMS = getSMSSender(c)
+
+	// init event publisher if webhook configured
+	if len(c.EventWebhookURLs) > 0 {
+		a.Events = event.WebhookPublisher{
+			URLs:   c.EventWebhookURLs,
+			Secret: c.EventWebhookSecret,
+		}
+	}
+
 	return a, nil
 }
+
+// getDBConfig get database config of the config
+func getDBConfig(c config.Config) map[string]string {
+	return map[string]string{
+		"driver":          c.DBDriver,
+		"user":            c.DBUsername,
+		"password":        c.DBPassword,
+		"dbname":          c.DBName,
+		"host":            c.DBHost,
+		"port":            c.DBPort,
+		"sslmode":         c.DBSSLMode,
+		"sslrootcert":     c.DBSSLRootCert,
+		"sslcert":         c.DBSSLCert,
+		"sslkey":          c.DBSSLKey,
+		"connect_timeout": c.DBConnectTimeout.String(),
+		"path":            c.DBPath,
+	}
+}
+
+// getSMSSender get SMS sender of the configured SMS provider,
+// nil if SMS provider not configured
+func getSMSSender(c config.Config) sms.Sender {
+	switch c.SMSProvider {
+	case config.SMSProviderTwilio:
+		return sms.TwilioSender{
+			AccountSID: c.TwilioAccountSID,
+			AuthToken:  c.TwilioAuthToken,
+			From:       c.SMSFrom,
+			BaseURL:    c.TwilioAPIURL,
+		}
+	case config.SMSProviderLog:
+		return sms.LogSender{}
+	}
+
+	return nil
+}
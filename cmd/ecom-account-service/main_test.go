@@ -3,13 +3,47 @@ Package main the executeable file
 */
 package main
 
-import "testing"
+import (
+	"reflect"
+	"testing"
+
+	"github.com/reyhanfikridz/ecom-account-service/internal/config"
+	"github.com/reyhanfikridz/ecom-account-service/internal/sms"
+)
 
 // TestInitAPI test InitAPI
 func TestInitAPI(t *testing.T) {
+	// init all config before can be used
+	c, _, err := config.Load(nil)
+	if err != nil {
+		t.Fatalf("Initialization of config failed => " + err.Error())
+	}
+
 	// init API
-	_, err := InitAPI()
+	_, err = InitAPI(c)
 	if err != nil {
 		t.Errorf("Initialization of API failed => " + err.Error())
 	}
 }
+
+// TestGetSMSSender test getSMSSender of every SMS provider
+func TestGetSMSSender(t *testing.T) {
+	// create testing table
+	testTable := []struct {
+		Provider       string
+		ExpectedSender sms.Sender
+	}{
+		{Provider: "", ExpectedSender: nil},
+		{Provider: config.SMSProviderLog, ExpectedSender: sms.LogSender{}},
+		{Provider: config.SMSProviderTwilio, ExpectedSender: sms.TwilioSender{}},
+	}
+
+	// loop test in test table
+	for _, test := range testTable {
+		sender := getSMSSender(config.Config{SMSProvider: test.Provider})
+		if reflect.TypeOf(sender) != reflect.TypeOf(test.ExpectedSender) {
+			t.Errorf("Expected sender %T of provider %q, but got %T",
+				test.ExpectedSender, test.Provider, sender)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+/*
+Package main the executeable file
+*/
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/reyhanfikridz/ecom-account-service/api"
+	"github.com/reyhanfikridz/ecom-account-service/internal/config"
+	"github.com/reyhanfikridz/ecom-account-service/internal/migration"
+)
+
+// errMigrateUsage migrate subcommand arguments not valid
+var errMigrateUsage = errors.New(
+	"usage: ecom-account-service [flags] migrate up|down [steps]|status")
+
+// runMigrate run migrate subcommand on database of the config,
+// write the result to out
+//
+//	migrate up            apply all pending migrations
+//	migrate down [steps]  roll back the latest applied migrations (default 1)
+//	migrate status        print status of every migration
+func runMigrate(c config.Config, args []string, out io.Writer) error {
+	// check arguments before connecting to database
+	if len(args) == 0 {
+		return errMigrateUsage
+	}
+	steps := 1
+	switch args[0] {
+	case "up", "status":
+		if len(args) > 1 {
+			return errMigrateUsage
+		}
+	case "down":
+		if len(args) > 2 {
+			return errMigrateUsage
+		}
+		if len(args) == 2 {
+			var err error
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps <= 0 {
+				return errMigrateUsage
+			}
+		}
+	default:
+		return errMigrateUsage
+	}
+
+	// connect to database without migrating
+	a := api.API{Config: c}
+	err := a.ConnectDB(getDBConfig(c))
+	if err != nil {
+		return err
+	}
+	defer a.DB.Close()
+
+	migrator, err := migration.New(a.DB, c.DBDriver, c)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "up":
+		migrations, err := migrator.Up()
+		if err != nil {
+			return err
+		}
+		if len(migrations) == 0 {
+			fmt.Fprintln(out, "No pending migration")
+		}
+		for _, m := range migrations {
+			fmt.Fprintf(out, "Applied %04d_%s\n", m.Version, m.Name)
+		}
+
+	case "down":
+		migrations, err := migrator.Down(steps)
+		if err != nil {
+			return err
+		}
+		if len(migrations) == 0 {
+			fmt.Fprintln(out, "No applied migration")
+		}
+		for _, m := range migrations {
+			fmt.Fprintf(out, "Rolled back %04d_%s\n", m.Version, m.Name)
+		}
+
+	case "status":
+		statuses, err := migrator.Status()
+		if err != nil {
+			return err
+		}
+		writeMigrationStatus(out, statuses)
+	}
+
+	return nil
+}
+
+// writeMigrationStatus write migration statuses as table
+func writeMigrationStatus(out io.Writer, statuses []migration.Status) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tSTATUS")
+	for _, s := range statuses {
+		name := s.Name
+		status := "pending"
+		if !s.IsKnown {
+			name = "(unknown, newer than the service)"
+		}
+		if s.AppliedAt != nil {
+			status = "applied at " + s.AppliedAt.UTC().Format("2006-01-02 15:04:05")
+		}
+		fmt.Fprintf(w, "%04d\t%s\t%s\n", s.Version, name, status)
+	}
+	w.Flush()
+}
@@ -0,0 +1,68 @@
+/*
+Package main the executeable file
+*/
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/reyhanfikridz/ecom-account-service/internal/config"
+	"github.com/reyhanfikridz/ecom-account-service/internal/migration"
+)
+
+// TestRunMigrateUsage test runMigrate with arguments not valid
+func TestRunMigrateUsage(t *testing.T) {
+	testTable := [][]string{
+		{},
+		{"sideways"},
+		{"up", "1"},
+		{"status", "all"},
+		{"down", "0"},
+		{"down", "-1"},
+		{"down", "one"},
+		{"down", "1", "2"},
+	}
+
+	for _, args := range testTable {
+		var out bytes.Buffer
+		err := runMigrate(config.Config{}, args, &out)
+		if err != errMigrateUsage {
+			t.Errorf("Expected usage error for arguments %q, but got %v", args, err)
+		}
+	}
+}
+
+// TestWriteMigrationStatus test writeMigrationStatus
+func TestWriteMigrationStatus(t *testing.T) {
+	appliedAt := time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC)
+	statuses := []migration.Status{
+		{Version: 1, Name: "initial", AppliedAt: &appliedAt, IsKnown: true},
+		{Version: 2, Name: "second", IsKnown: true},
+		{Version: 3, AppliedAt: &appliedAt},
+	}
+
+	var out bytes.Buffer
+	writeMigrationStatus(&out, statuses)
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("Expected 4 lines, but got %q", out.String())
+	}
+
+	expectedContents := [][]string{
+		{"VERSION", "NAME", "STATUS"},
+		{"0001", "initial", "applied at 2022-01-02 03:04:05"},
+		{"0002", "second", "pending"},
+		{"0003", "unknown", "applied at 2022-01-02 03:04:05"},
+	}
+	for i, contents := range expectedContents {
+		for _, content := range contents {
+			if !strings.Contains(lines[i], content) {
+				t.Errorf("Expected line %d contain %q, but got %q", i+1, content, lines[i])
+			}
+		}
+	}
+}
@@ -4,46 +4,204 @@ Package config collection of configuration
 package config
 
 import (
-	"os"
+	"errors"
+	"fmt"
+	"time"
 
 	"github.com/golang-jwt/jwt/v4"
-	"github.com/joho/godotenv"
 )
 
-var (
+// database driver
+const (
+	DBDriverPostgres = "postgres"
+
+	// single file database, for single node and development deployment
+	DBDriverSQLite = "sqlite"
+)
+
+// email verification policy for user with unverified email
+const (
+	// user with unverified email can still login
+	EmailVerificationPolicyNone = "none"
+
+	// user with unverified email can't login
+	EmailVerificationPolicyBlockLogin = "block_login"
+
+	// user with unverified email can login, but with unverified role
+	EmailVerificationPolicyRestrictRole = "restrict_role"
+)
+
+// SMS provider, SMS can't be sent if no provider configured
+const (
+	SMSProviderTwilio = "twilio"
+
+	// SMS only written to log, only for development
+	SMSProviderLog = "log"
+)
+
+// Config typed config of the service, loaded by Load
+type Config struct {
+	DBDriver   string
 	DBName     string
 	DBTestName string
 	DBUsername string
 	DBPassword string
+	DBPath     string
+	DBTestPath string
+
+	DBHost           string
+	DBPort           string
+	DBSSLMode        string
+	DBSSLRootCert    string
+	DBSSLCert        string
+	DBSSLKey         string
+	DBConnectTimeout time.Duration
+
+	DBMaxOpenConns    int
+	DBMaxIdleConns    int
+	DBConnMaxLifetime time.Duration
+
+	DBPingAttempts int
+	DBPingBackoff  time.Duration
+
+	JWTSecretKey          string
+	JWTPrivateKeyFilePath string
+	JWTKeyDir             string
+	JWTSigningMethod      string
+	JWTActiveKeyID        string
+	JWTKeys               *JWTKeyRing
+
+	AccessTokenDuration  time.Duration
+	RefreshTokenDuration time.Duration
+
+	PasswordResetTokenDuration time.Duration
+	PasswordResetURL           string
+	PasswordMinLength          int
+	PasswordHistoryCount       int
+
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUsername string
+	SMTPPassword string
+	MailFrom     string
+
+	EmailVerificationPolicy         string
+	EmailUnverifiedRole             string
+	EmailVerificationTokenDuration  time.Duration
+	EmailVerificationResendInterval time.Duration
+	EmailVerificationURL            string
+
+	EmailChangeTokenDuration  time.Duration
+	EmailChangeRevertDuration time.Duration
+	EmailChangeConfirmURL     string
+	EmailChangeRevertURL      string
+
+	SMSProvider      string
+	SMSFrom          string
+	TwilioAccountSID string
+	TwilioAuthToken  string
+	TwilioAPIURL     string
+
+	PhoneDefaultRegion              string
+	PhoneVerificationCodeDuration   time.Duration
+	PhoneVerificationResendInterval time.Duration
+	PhoneVerificationMaxAttempts    int
 
-	JWTSecretKey     string
-	JWTSigningMethod *jwt.SigningMethodHMAC
+	DeletedUserRetention       time.Duration
+	AccountDeletionGracePeriod time.Duration
+	AccountErasureInterval     time.Duration
+
+	DataExportDuration time.Duration
+	DataExportInterval time.Duration
+	DataExportURL      string
+
+	EventWebhookURLs   []string
+	EventWebhookSecret string
+
+	TOTPIssuer       string
+	MFATokenDuration time.Duration
+
+	LoginFailureThreshold   int
+	LoginIPFailureThreshold int
+	LoginBackoffBase        time.Duration
+	LoginLockoutDuration    time.Duration
+
+	RateLimitEnabled bool
 
 	FrontendURL       string
 	ProductServiceURL string
-)
+}
+
+// validate check config values, including values required
+// by other values (e.g. jwt secret key required by HMAC signing method)
+func (c Config) validate() error {
+	if c.DBDriver != DBDriverPostgres && c.DBDriver != DBDriverSQLite {
+		return fmt.Errorf("database driver %q not supported", c.DBDriver)
+	}
+	if c.DBDriver == DBDriverPostgres && c.DBName == "" {
+		return errors.New("database name (DB_NAME) required")
+	}
+	if c.DBDriver == DBDriverSQLite && c.DBPath == "" {
+		return errors.New("database path (DB_PATH) required")
+	}
 
-// InitConfig initialize all config variable from environment variable
-func InitConfig() error {
-	// load all values from .env file into the system
-	// .env file must be at root directory (same level as go.mod file)
-	err := godotenv.Load(os.ExpandEnv(
-		"$GOPATH/src/github.com/reyhanfikridz/ecom-account-service/.env"))
-	if err != nil {
-		return err
+	// SSL modes supported by the Postgres driver
+	if c.DBSSLMode != "disable" && c.DBSSLMode != "require" &&
+		c.DBSSLMode != "verify-ca" && c.DBSSLMode != "verify-full" {
+		return fmt.Errorf("database SSL mode %q not supported", c.DBSSLMode)
+	}
+	if c.DBPingAttempts < 1 {
+		return fmt.Errorf("database ping attempts must be at least 1")
 	}
 
-	// set all config variable after all environment variable loaded
-	DBName = os.Getenv("ECOM_ACCOUNT_SERVICE_DB_NAME")
-	DBTestName = os.Getenv("ECOM_ACCOUNT_SERVICE_DB_TEST_NAME")
-	DBUsername = os.Getenv("ECOM_ACCOUNT_SERVICE_DB_USERNAME")
-	DBPassword = os.Getenv("ECOM_ACCOUNT_SERVICE_DB_PASSWORD")
+	if c.EmailVerificationPolicy != EmailVerificationPolicyNone &&
+		c.EmailVerificationPolicy != EmailVerificationPolicyBlockLogin &&
+		c.EmailVerificationPolicy != EmailVerificationPolicyRestrictRole {
+		return fmt.Errorf("email verification policy %q not supported",
+			c.EmailVerificationPolicy)
+	}
 
-	JWTSecretKey = os.Getenv("ECOM_ACCOUNT_SERVICE_JWT_SECRET_KEY")
-	JWTSigningMethod = jwt.SigningMethodHS256
+	if c.SMSProvider != "" && c.SMSProvider != SMSProviderTwilio &&
+		c.SMSProvider != SMSProviderLog {
+		return fmt.Errorf("SMS provider %q not supported", c.SMSProvider)
+	}
+	if c.SMSProvider == SMSProviderTwilio && (c.TwilioAccountSID == "" ||
+		c.TwilioAuthToken == "" || c.SMSFrom == "") {
+		return errors.New("twilio account SID (TWILIO_ACCOUNT_SID), auth token " +
+			"(TWILIO_AUTH_TOKEN), and SMS sender (SMS_FROM) required")
+	}
 
-	FrontendURL = os.Getenv("ECOM_ACCOUNT_SERVICE_FRONTEND_URL")
-	ProductServiceURL = os.Getenv("ECOM_ACCOUNT_SERVICE_PRODUCT_SERVICE_URL")
+	// jwt key from key directory, or from secret key or private key file
+	if c.JWTKeyDir == "" {
+		switch jwt.GetSigningMethod(c.JWTSigningMethod).(type) {
+		case *jwt.SigningMethodHMAC:
+			if c.JWTSecretKey == "" {
+				return errors.New("jwt secret key (JWT_SECRET_KEY) required")
+			}
+		case nil:
+			return fmt.Errorf("jwt signing method %q not supported", c.JWTSigningMethod)
+		default:
+			if c.JWTPrivateKeyFilePath == "" {
+				return errors.New("jwt private key file (JWT_PRIVATE_KEY_FILE) required")
+			}
+		}
+	}
 
 	return nil
 }
+
+// jwtKeyRetention get how long retired jwt key still valid
+// for verification, that is the longest lifetime of jwt token
+func (c Config) jwtKeyRetention() time.Duration {
+	retention := c.AccessTokenDuration
+	for _, duration := range []time.Duration{
+		c.EmailVerificationTokenDuration,
+		c.MFATokenDuration,
+	} {
+		if duration > retention {
+			retention = duration
+		}
+	}
+
+	return retention
+}
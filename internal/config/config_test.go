@@ -3,13 +3,80 @@ Package config collection of configuration
 */
 package config
 
-import "testing"
+import (
+	"testing"
+)
 
-// TestInitConfig test InitConfig
-func TestInitConfig(t *testing.T) {
-	err := InitConfig()
+// TestConfig test Load config of defaults and environment variables
+func TestConfig(t *testing.T) {
+	_, _, err := Load(nil)
 	if err != nil {
 		t.Errorf("Expected initialize config success, but failed => %s",
 			err.Error())
 	}
 }
+
+// TestConfigDBDriver test Load database driver
+func TestConfigDBDriver(t *testing.T) {
+	// initialize testing table
+	testTable := []struct {
+		Value            string
+		ExpectedDBDriver string
+		IsErrorExpected  bool
+	}{
+		{Value: "", ExpectedDBDriver: DBDriverPostgres},
+		{Value: "postgres", ExpectedDBDriver: DBDriverPostgres},
+		{Value: "sqlite", ExpectedDBDriver: DBDriverSQLite},
+		{Value: "mysql", IsErrorExpected: true},
+	}
+
+	// test
+	for _, test := range testTable {
+		t.Setenv("ECOM_ACCOUNT_SERVICE_DB_DRIVER", test.Value)
+
+		c, _, err := Load(nil)
+		if test.IsErrorExpected {
+			if err == nil {
+				t.Errorf("Expected error for driver %q, but got nil", test.Value)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("Expected error nil for driver %q, but got %s", test.Value, err.Error())
+		} else if c.DBDriver != test.ExpectedDBDriver {
+			t.Errorf("Expected driver %q, but got %q", test.ExpectedDBDriver, c.DBDriver)
+		}
+	}
+}
+
+// TestConfigDBConnection test Load database connection config
+func TestConfigDBConnection(t *testing.T) {
+	// initialize testing table
+	testTable := []struct {
+		Key             string
+		Value           string
+		IsErrorExpected bool
+	}{
+		{Key: "ECOM_ACCOUNT_SERVICE_DB_SSL_MODE", Value: "verify-full"},
+		{Key: "ECOM_ACCOUNT_SERVICE_DB_SSL_MODE", Value: "prefer", IsErrorExpected: true},
+		{Key: "ECOM_ACCOUNT_SERVICE_DB_MAX_OPEN_CONNS", Value: "many", IsErrorExpected: true},
+		{Key: "ECOM_ACCOUNT_SERVICE_DB_CONN_MAX_LIFETIME", Value: "5", IsErrorExpected: true},
+		{Key: "ECOM_ACCOUNT_SERVICE_DB_PING_ATTEMPTS", Value: "0", IsErrorExpected: true},
+		{Key: "ECOM_ACCOUNT_SERVICE_DB_PING_BACKOFF", Value: "2s"},
+	}
+
+	// test
+	for _, test := range testTable {
+		t.Run(test.Key+"="+test.Value, func(t *testing.T) {
+			t.Setenv(test.Key, test.Value)
+
+			_, _, err := Load(nil)
+			if test.IsErrorExpected && err == nil {
+				t.Errorf("Expected error, but got nil")
+			} else if !test.IsErrorExpected && err != nil {
+				t.Errorf("Expected error nil, but got %s", err.Error())
+			}
+		})
+	}
+}
@@ -0,0 +1,205 @@
+/*
+Package config collection of configuration
+*/
+package config
+
+import (
+	"crypto/ed25519"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// jwtKeyIDRegexp allowed jwt key ID, key ID used as file name in key directory
+var jwtKeyIDRegexp = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,64}$`)
+
+// file in key directory listing promoted key IDs and their promoted time
+const jwtPromotedKeysFile = "promoted"
+
+// JWTKey key for signing and verifying jwt token,
+// ID is written into "kid" header of the signed token
+type JWTKey struct {
+	ID              string
+	Method          jwt.SigningMethod
+	SigningKey      any
+	VerificationKey any
+	RetiredAt       time.Time
+}
+
+// LoadJWTKey load jwt key with signing method, signing key and verification key
+//
+// HMAC methods (HS256, HS384, HS512) use the secret key for both signing
+// and verification. RSA methods (RS256, RS384, RS512) and EdDSA (Ed25519)
+// use private key from PEM file for signing and its public key
+// for verification.
+func LoadJWTKey(ID string, methodName string, secretKey string,
+	privateKeyFilePath string) (JWTKey, error) {
+	key := JWTKey{ID: ID}
+	if !jwtKeyIDRegexp.MatchString(ID) {
+		return key, fmt.Errorf("jwt key ID %q not valid", ID)
+	}
+
+	key.Method = jwt.GetSigningMethod(methodName)
+	switch key.Method.(type) {
+	case *jwt.SigningMethodHMAC:
+		key.SigningKey = []byte(secretKey)
+		key.VerificationKey = []byte(secretKey)
+		return key, nil
+
+	case *jwt.SigningMethodRSA:
+		keyPEM, err := os.ReadFile(privateKeyFilePath)
+		if err != nil {
+			return key, err
+		}
+
+		privateKey, err := jwt.ParseRSAPrivateKeyFromPEM(keyPEM)
+		if err != nil {
+			return key, err
+		}
+
+		key.SigningKey = privateKey
+		key.VerificationKey = &privateKey.PublicKey
+		return key, nil
+
+	case *jwt.SigningMethodEd25519:
+		keyPEM, err := os.ReadFile(privateKeyFilePath)
+		if err != nil {
+			return key, err
+		}
+
+		privateKey, err := jwt.ParseEdPrivateKeyFromPEM(keyPEM)
+		if err != nil {
+			return key, err
+		}
+
+		edPrivateKey, ok := privateKey.(ed25519.PrivateKey)
+		if !ok {
+			return key, fmt.Errorf("private key is not ed25519 private key")
+		}
+
+		key.SigningKey = edPrivateKey
+		key.VerificationKey = edPrivateKey.Public()
+		return key, nil
+	}
+
+	return key, fmt.Errorf("jwt signing method %q not supported", methodName)
+}
+
+// LoadJWTKeyFromDir load jwt key by key ID from key directory
+//
+// HMAC secret key is read from "<key ID>.key" file and
+// private key is read from "<key ID>.pem" file
+func LoadJWTKeyFromDir(dir string, ID string, methodName string) (JWTKey, error) {
+	if strings.TrimSpace(dir) == "" {
+		return JWTKey{ID: ID}, fmt.Errorf("jwt key directory not configured")
+	}
+
+	if !jwtKeyIDRegexp.MatchString(ID) {
+		return JWTKey{ID: ID}, fmt.Errorf("jwt key ID %q not valid", ID)
+	}
+
+	if _, ok := jwt.GetSigningMethod(methodName).(*jwt.SigningMethodHMAC); ok {
+		secretKey, err := os.ReadFile(getJWTKeyFilePath(dir, ID, methodName))
+		if err != nil {
+			return JWTKey{ID: ID}, err
+		}
+
+		return LoadJWTKey(ID, methodName, strings.TrimSpace(string(secretKey)), "")
+	}
+
+	return LoadJWTKey(ID, methodName, "", getJWTKeyFilePath(dir, ID, methodName))
+}
+
+// getJWTKeyFilePath get key file path of key ID in key directory,
+// "<key ID>.key" for HMAC secret key, otherwise "<key ID>.pem"
+func getJWTKeyFilePath(dir string, ID string, methodName string) string {
+	if _, ok := jwt.GetSigningMethod(methodName).(*jwt.SigningMethodHMAC); ok {
+		return filepath.Join(dir, ID+".key")
+	}
+
+	return filepath.Join(dir, ID+".pem")
+}
+
+// getPromotedJWTKeys get retired time of key IDs listed in promoted keys file
+// of key directory, empty if the file not exist. Each line of the file is
+// "<key ID> <promoted time (RFC 3339)>", key retired when the next key promoted,
+// so the last promoted key (the active key) has zero retired time.
+func getPromotedJWTKeys(dir string) (map[string]time.Time, error) {
+	content, err := os.ReadFile(filepath.Join(dir, jwtPromotedKeysFile))
+	if os.IsNotExist(err) {
+		return map[string]time.Time{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	retiredAt := map[string]time.Time{}
+	previousID := ""
+	for i, line := range strings.Split(string(content), "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) != 2 || !jwtKeyIDRegexp.MatchString(fields[0]) {
+			return nil, fmt.Errorf("jwt promoted keys file line %d not valid", i+1)
+		}
+		promotedAt, err := time.Parse(time.RFC3339, fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("jwt promoted keys file line %d not valid => %w", i+1, err)
+		}
+
+		if previousID != "" && previousID != fields[0] {
+			retiredAt[previousID] = promotedAt
+		}
+		retiredAt[fields[0]] = time.Time{}
+		previousID = fields[0]
+	}
+
+	return retiredAt, nil
+}
+
+// addPromotedJWTKey append key ID and promoted time into promoted keys file
+// of key directory, the file created if not exist
+func addPromotedJWTKey(dir string, ID string, promotedAt time.Time) error {
+	f, err := os.OpenFile(filepath.Join(dir, jwtPromotedKeysFile),
+		os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+
+	_, err = f.WriteString(ID + " " + promotedAt.UTC().Format(time.RFC3339) + "\n")
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// getJWTKeyIDsInDir get all key ID of key files in key directory
+func getJWTKeyIDsInDir(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	IDs := []string{}
+	for _, entry := range entries {
+		ext := filepath.Ext(entry.Name())
+		if entry.IsDir() || (ext != ".key" && ext != ".pem") {
+			continue
+		}
+
+		ID := strings.TrimSuffix(entry.Name(), ext)
+		if jwtKeyIDRegexp.MatchString(ID) {
+			IDs = append(IDs, ID)
+		}
+	}
+
+	return IDs, nil
+}
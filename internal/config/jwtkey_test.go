@@ -0,0 +1,247 @@
+/*
+Package config collection of configuration
+*/
+package config
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// TestLoadJWTKey test LoadJWTKey
+func TestLoadJWTKey(t *testing.T) {
+	// create private key PEM files
+	dir := t.TempDir()
+
+	rsaPrivateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Errorf("There's an error when generating RSA key => " + err.Error())
+	}
+	rsaKeyFilePath := filepath.Join(dir, "rsa.pem")
+	err = os.WriteFile(rsaKeyFilePath, pem.EncodeToMemory(&pem.Block{
+		Type:  "RSA PRIVATE KEY",
+		Bytes: x509.MarshalPKCS1PrivateKey(rsaPrivateKey),
+	}), 0600)
+	if err != nil {
+		t.Errorf("There's an error when writing RSA key file => " + err.Error())
+	}
+
+	_, edPrivateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Errorf("There's an error when generating Ed25519 key => " + err.Error())
+	}
+	edPrivateKeyBytes, err := x509.MarshalPKCS8PrivateKey(edPrivateKey)
+	if err != nil {
+		t.Errorf("There's an error when marshal Ed25519 key => " + err.Error())
+	}
+	edKeyFilePath := filepath.Join(dir, "ed25519.pem")
+	err = os.WriteFile(edKeyFilePath, pem.EncodeToMemory(&pem.Block{
+		Type:  "PRIVATE KEY",
+		Bytes: edPrivateKeyBytes,
+	}), 0600)
+	if err != nil {
+		t.Errorf("There's an error when writing Ed25519 key file => " + err.Error())
+	}
+
+	// initialize testing table
+	testTable := []struct {
+		MethodName         string
+		PrivateKeyFilePath string
+		ExpectedIsError    bool
+	}{
+		{
+			MethodName:         "HS256",
+			PrivateKeyFilePath: "",
+			ExpectedIsError:    false,
+		},
+		{
+			MethodName:         "RS256",
+			PrivateKeyFilePath: rsaKeyFilePath,
+			ExpectedIsError:    false,
+		},
+		{
+			MethodName:         "EdDSA",
+			PrivateKeyFilePath: edKeyFilePath,
+			ExpectedIsError:    false,
+		},
+		{
+			MethodName:         "RS256",
+			PrivateKeyFilePath: edKeyFilePath,
+			ExpectedIsError:    true,
+		},
+		{
+			MethodName:         "EdDSA",
+			PrivateKeyFilePath: filepath.Join(dir, "not-exist.pem"),
+			ExpectedIsError:    true,
+		},
+		{
+			MethodName:         "none",
+			PrivateKeyFilePath: "",
+			ExpectedIsError:    true,
+		},
+	}
+
+	// loop test in test table
+	for _, test := range testTable {
+		key, err := LoadJWTKey(
+			"test", test.MethodName, "secret", test.PrivateKeyFilePath)
+		if test.ExpectedIsError {
+			if err == nil {
+				t.Errorf("Expected error not nil for method %s, but got nil",
+					test.MethodName)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("Expected error nil for method %s, but got not nil => %s",
+				test.MethodName, err.Error())
+			continue
+		}
+
+		if key.Method.Alg() != test.MethodName {
+			t.Errorf("Expected method %s, but got %s", test.MethodName, key.Method.Alg())
+		}
+
+		if key.SigningKey == nil || key.VerificationKey == nil {
+			t.Errorf("Expected signing key and verification key not nil, but got nil")
+		}
+	}
+}
+
+// TestLoadJWTKeyFromDir test LoadJWTKeyFromDir
+func TestLoadJWTKeyFromDir(t *testing.T) {
+	// create secret key file
+	dir := t.TempDir()
+	err := os.WriteFile(filepath.Join(dir, "2022-09.key"), []byte("secret\n"), 0600)
+	if err != nil {
+		t.Errorf("There's an error when writing secret key file => " + err.Error())
+	}
+
+	// initialize testing table
+	testTable := []struct {
+		Dir             string
+		ID              string
+		ExpectedIsError bool
+	}{
+		{
+			Dir:             dir,
+			ID:              "2022-09",
+			ExpectedIsError: false,
+		},
+		{
+			Dir:             dir,
+			ID:              "2022-10",
+			ExpectedIsError: true,
+		},
+		{
+			Dir:             dir,
+			ID:              "../2022-09",
+			ExpectedIsError: true,
+		},
+		{
+			Dir:             "",
+			ID:              "2022-09",
+			ExpectedIsError: true,
+		},
+	}
+
+	// loop test in test table
+	for _, test := range testTable {
+		key, err := LoadJWTKeyFromDir(test.Dir, test.ID, "HS256")
+		if test.ExpectedIsError && err == nil {
+			t.Errorf("Expected error not nil for key ID %s, but got nil", test.ID)
+		} else if !test.ExpectedIsError && err != nil {
+			t.Errorf("Expected error nil for key ID %s, but got not nil => %s",
+				test.ID, err.Error())
+		}
+
+		if !test.ExpectedIsError && string(key.SigningKey.([]byte)) != "secret" {
+			t.Errorf("Expected secret key 'secret', but got '%s'", key.SigningKey)
+		}
+	}
+}
+
+// TestGetPromotedJWTKeys test getPromotedJWTKeys and addPromotedJWTKey
+func TestGetPromotedJWTKeys(t *testing.T) {
+	firstPromotedAt := time.Date(2022, 8, 1, 0, 0, 0, 0, time.UTC)
+	secondPromotedAt := time.Date(2022, 9, 1, 0, 0, 0, 0, time.UTC)
+
+	// initialize testing table
+	testTable := []struct {
+		Content           string
+		ExpectedRetiredAt map[string]time.Time
+		ExpectedIsError   bool
+	}{
+		{
+			Content:           "",
+			ExpectedRetiredAt: map[string]time.Time{},
+		},
+		{
+			Content: "2022-08 2022-08-01T00:00:00Z\n" +
+				"2022-09 2022-09-01T00:00:00Z\n",
+			ExpectedRetiredAt: map[string]time.Time{
+				"2022-08": secondPromotedAt,
+				"2022-09": {},
+			},
+		},
+		{
+			Content:         "2022-08\n",
+			ExpectedIsError: true,
+		},
+		{
+			Content:         "2022-08 yesterday\n",
+			ExpectedIsError: true,
+		},
+	}
+
+	// loop test in test table
+	for _, test := range testTable {
+		dir := t.TempDir()
+		err := os.WriteFile(filepath.Join(dir, jwtPromotedKeysFile), []byte(test.Content), 0600)
+		if err != nil {
+			t.Errorf("There's an error when writing promoted keys file => " + err.Error())
+		}
+
+		retiredAt, err := getPromotedJWTKeys(dir)
+		if test.ExpectedIsError {
+			if err == nil {
+				t.Errorf("Expected error not nil for %q, but got nil", test.Content)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("Expected error nil, but got not nil => " + err.Error())
+		} else if !reflect.DeepEqual(retiredAt, test.ExpectedRetiredAt) {
+			t.Errorf("Expected retired time %v, but got %v", test.ExpectedRetiredAt, retiredAt)
+		}
+	}
+
+	// promoted keys file created if not exist
+	dir := t.TempDir()
+	err := addPromotedJWTKey(dir, "2022-08", firstPromotedAt)
+	if err != nil {
+		t.Errorf("Expected error nil, but got not nil => " + err.Error())
+	}
+
+	err = addPromotedJWTKey(dir, "2022-09", secondPromotedAt)
+	if err != nil {
+		t.Errorf("Expected error nil, but got not nil => " + err.Error())
+	}
+
+	retiredAt, err := getPromotedJWTKeys(dir)
+	if err != nil {
+		t.Errorf("Expected error nil, but got not nil => " + err.Error())
+	} else if !retiredAt["2022-08"].Equal(secondPromotedAt) || !retiredAt["2022-09"].IsZero() {
+		t.Errorf("Expected '2022-08' retired when '2022-09' promoted, but got %v", retiredAt)
+	}
+}
@@ -0,0 +1,251 @@
+/*
+Package config collection of configuration
+*/
+package config
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// JWTKeyRing collection of jwt keys, one active key for signing new token
+// and retired keys only for verifying token signed before the rotation
+//
+// Retired key still valid for verification until retention duration
+// (the longest lifetime of jwt token) passed since the key retired.
+// Key not found in key ring is loaded from key directory (if configured)
+// only if listed in promoted keys file of the directory, so key promoted
+// on another replica sharing the same directory still can be verified,
+// but key only staged in the directory can't. Loaded key has the same
+// retention as retired key (since loaded if still active on another replica,
+// then reloaded), and is revoked if its key file deleted.
+type JWTKeyRing struct {
+	mu          sync.RWMutex
+	keys        map[string]JWTKey
+	loadedIDs   map[string]bool
+	activeKeyID string
+	retention   time.Duration
+	dir         string
+	methodName  string
+}
+
+// NewJWTKeyRing create new jwt key ring with an active key
+func NewJWTKeyRing(activeKey JWTKey, retention time.Duration) *JWTKeyRing {
+	return &JWTKeyRing{
+		keys:        map[string]JWTKey{activeKey.ID: activeKey},
+		loadedIDs:   map[string]bool{},
+		activeKeyID: activeKey.ID,
+		retention:   retention,
+		methodName:  activeKey.Method.Alg(),
+	}
+}
+
+// NewJWTKeyRingFromDir create new jwt key ring from key directory,
+// active key is the key with active key ID and other promoted keys
+// in the directory are added as retired key
+func NewJWTKeyRingFromDir(dir string, activeKeyID string, methodName string,
+	retention time.Duration) (*JWTKeyRing, error) {
+	activeKey, err := LoadJWTKeyFromDir(dir, activeKeyID, methodName)
+	if err != nil {
+		return nil, err
+	}
+
+	keyRing := NewJWTKeyRing(activeKey, retention)
+	keyRing.dir = dir
+
+	// add another promoted keys in directory as retired keys
+	IDs, err := getJWTKeyIDsInDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	promotedKeys, err := getPromotedJWTKeys(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	for _, ID := range IDs {
+		retiredAt, ok := promotedKeys[ID]
+		if ID == activeKeyID || !ok {
+			continue
+		}
+
+		key, err := LoadJWTKeyFromDir(dir, ID, methodName)
+		if err != nil {
+			return nil, err
+		}
+
+		key.RetiredAt = retiredAt
+		if key.RetiredAt.IsZero() {
+			key.RetiredAt = now
+		}
+		if !keyRing.isExpired(key) {
+			keyRing.keys[ID] = key
+		}
+	}
+
+	return keyRing, nil
+}
+
+// ActiveKey get active key for signing new token
+func (kr *JWTKeyRing) ActiveKey() JWTKey {
+	kr.mu.RLock()
+	defer kr.mu.RUnlock()
+
+	return kr.keys[kr.activeKeyID]
+}
+
+// GetKey get key for verifying token by key ID,
+// return false if key not exist or retired key already expired
+func (kr *JWTKeyRing) GetKey(ID string) (JWTKey, bool) {
+	kr.mu.RLock()
+	key, ok := kr.keys[ID]
+	isLoaded := kr.loadedIDs[ID]
+	kr.mu.RUnlock()
+
+	// loaded key removed if expired (so it reloaded if still promoted)
+	// or its key file deleted
+	if ok && isLoaded && (kr.isExpired(key) ||
+		!isFileExist(getJWTKeyFilePath(kr.dir, ID, kr.methodName))) {
+		kr.mu.Lock()
+		if kr.loadedIDs[ID] {
+			delete(kr.keys, ID)
+			delete(kr.loadedIDs, ID)
+		}
+		kr.mu.Unlock()
+
+		ok = false
+	}
+
+	if !ok {
+		return kr.loadKey(ID)
+	}
+
+	if kr.isExpired(key) {
+		return key, false
+	}
+
+	return key, true
+}
+
+// loadKey load key promoted by another replica from key directory,
+// return false if key not promoted or key file not exist
+func (kr *JWTKeyRing) loadKey(ID string) (JWTKey, bool) {
+	if kr.dir == "" {
+		return JWTKey{ID: ID}, false
+	}
+
+	promotedKeys, err := getPromotedJWTKeys(kr.dir)
+	if err != nil {
+		return JWTKey{ID: ID}, false
+	}
+
+	retiredAt, ok := promotedKeys[ID]
+	if !ok {
+		return JWTKey{ID: ID}, false
+	}
+
+	key, err := LoadJWTKeyFromDir(kr.dir, ID, kr.methodName)
+	if err != nil {
+		return key, false
+	}
+
+	// key still active on another replica retired since loaded,
+	// so it reloaded after its retention passed
+	key.RetiredAt = retiredAt
+	if key.RetiredAt.IsZero() {
+		key.RetiredAt = time.Now()
+	}
+	if kr.isExpired(key) {
+		return key, false
+	}
+
+	kr.mu.Lock()
+	defer kr.mu.Unlock()
+
+	// key promoted on this replica while loading is kept
+	if existingKey, ok := kr.keys[ID]; ok && !kr.loadedIDs[ID] {
+		return existingKey, !kr.isExpired(existingKey)
+	}
+
+	kr.keys[ID] = key
+	kr.loadedIDs[ID] = true
+	return key, true
+}
+
+// Keys get all keys valid for verification (active and retired keys),
+// sorted by key ID
+func (kr *JWTKeyRing) Keys() []JWTKey {
+	kr.mu.RLock()
+	defer kr.mu.RUnlock()
+
+	keys := []JWTKey{}
+	for _, key := range kr.keys {
+		if !kr.isExpired(key) {
+			keys = append(keys, key)
+		}
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].ID < keys[j].ID
+	})
+
+	return keys
+}
+
+// Promote make a key as the active key and retire the previous active key
+func (kr *JWTKeyRing) Promote(key JWTKey) {
+	kr.mu.Lock()
+	defer kr.mu.Unlock()
+
+	now := time.Now()
+	if key.ID != kr.activeKeyID {
+		previousKey := kr.keys[kr.activeKeyID]
+		previousKey.RetiredAt = now
+		kr.keys[previousKey.ID] = previousKey
+	}
+
+	key.RetiredAt = time.Time{}
+	kr.keys[key.ID] = key
+	kr.activeKeyID = key.ID
+	delete(kr.loadedIDs, key.ID)
+
+	// remove expired retired keys
+	for ID, k := range kr.keys {
+		if kr.isExpired(k) {
+			delete(kr.keys, ID)
+			delete(kr.loadedIDs, ID)
+		}
+	}
+}
+
+// PromoteFromDir load key by key ID from key directory
+// and make it as the active key, key ID added into promoted keys file
+// so the key can be verified by another replica
+func (kr *JWTKeyRing) PromoteFromDir(ID string) (JWTKey, error) {
+	if kr.dir == "" {
+		return JWTKey{ID: ID}, fmt.Errorf("jwt key directory not configured")
+	}
+
+	key, err := LoadJWTKeyFromDir(kr.dir, ID, kr.methodName)
+	if err != nil {
+		return key, err
+	}
+
+	err = addPromotedJWTKey(kr.dir, ID, time.Now())
+	if err != nil {
+		return key, err
+	}
+
+	kr.Promote(key)
+	return key, nil
+}
+
+// isExpired check retired key already passed its retention
+func (kr *JWTKeyRing) isExpired(key JWTKey) bool {
+	return !key.RetiredAt.IsZero() &&
+		time.Since(key.RetiredAt) > kr.retention
+}
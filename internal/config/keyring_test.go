@@ -0,0 +1,185 @@
+/*
+Package config collection of configuration
+*/
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// TestJWTKeyRingPromote integration test
+// NewJWTKeyRing, Promote, ActiveKey, GetKey and Keys
+func TestJWTKeyRingPromote(t *testing.T) {
+	oldKey := JWTKey{
+		ID:              "old",
+		Method:          jwt.SigningMethodHS256,
+		SigningKey:      []byte("old"),
+		VerificationKey: []byte("old"),
+	}
+	newKey := JWTKey{
+		ID:              "new",
+		Method:          jwt.SigningMethodHS256,
+		SigningKey:      []byte("new"),
+		VerificationKey: []byte("new"),
+	}
+
+	// create key ring and promote new key
+	keyRing := NewJWTKeyRing(oldKey, time.Hour)
+	keyRing.Promote(newKey)
+
+	// check result
+	if keyRing.ActiveKey().ID != "new" {
+		t.Errorf("Expected active key 'new', but got '%s'", keyRing.ActiveKey().ID)
+	}
+
+	retiredKey, ok := keyRing.GetKey("old")
+	if !ok {
+		t.Errorf("Expected retired key still valid, but got not valid")
+	}
+
+	if retiredKey.RetiredAt.IsZero() {
+		t.Errorf("Expected retired key has retired time, but got zero")
+	}
+
+	if len(keyRing.Keys()) != 2 {
+		t.Errorf("Expected 2 keys valid for verification, but got %d",
+			len(keyRing.Keys()))
+	}
+
+	_, ok = keyRing.GetKey("unknown")
+	if ok {
+		t.Errorf("Expected unknown key not valid, but got valid")
+	}
+
+	// retired key expired after retention passed
+	keyRing = NewJWTKeyRing(oldKey, 0)
+	keyRing.Promote(newKey)
+	time.Sleep(time.Millisecond)
+
+	_, ok = keyRing.GetKey("old")
+	if ok {
+		t.Errorf("Expected retired key expired, but got valid")
+	}
+
+	if len(keyRing.Keys()) != 1 {
+		t.Errorf("Expected 1 key valid for verification, but got %d",
+			len(keyRing.Keys()))
+	}
+}
+
+// TestJWTKeyRingFromDir integration test
+// NewJWTKeyRingFromDir, GetKey and PromoteFromDir
+func TestJWTKeyRingFromDir(t *testing.T) {
+	// create secret key files, only promoted key valid for verification
+	dir := t.TempDir()
+	for _, ID := range []string{"2022-07", "2022-08", "2022-09", "staged"} {
+		err := os.WriteFile(filepath.Join(dir, ID+".key"), []byte(ID), 0600)
+		if err != nil {
+			t.Errorf("There's an error when writing secret key file => " + err.Error())
+		}
+	}
+
+	now := time.Now()
+	promotedKeys := []struct {
+		ID         string
+		PromotedAt time.Time
+	}{
+		{ID: "2022-07", PromotedAt: now.Add(-3 * time.Hour)},
+		{ID: "2022-08", PromotedAt: now.Add(-2 * time.Hour)},
+		{ID: "2022-09", PromotedAt: now.Add(-time.Minute)},
+	}
+	for _, promotedKey := range promotedKeys {
+		err := addPromotedJWTKey(dir, promotedKey.ID, promotedKey.PromotedAt)
+		if err != nil {
+			t.Errorf("There's an error when writing promoted keys file => " + err.Error())
+		}
+	}
+
+	// create key ring from directory
+	keyRing, err := NewJWTKeyRingFromDir(dir, "2022-09", "HS256", time.Hour)
+	if err != nil {
+		t.Fatalf("Expected error nil, but got not nil => " + err.Error())
+	}
+
+	if keyRing.ActiveKey().ID != "2022-09" {
+		t.Errorf("Expected active key '2022-09', but got '%s'", keyRing.ActiveKey().ID)
+	}
+
+	// "2022-07" retired more than retention ago, "2022-08" retired recently
+	if len(keyRing.Keys()) != 2 {
+		t.Errorf("Expected 2 keys valid for verification, but got %d",
+			len(keyRing.Keys()))
+	}
+
+	// key added into directory after key ring created (e.g. by another replica)
+	err = os.WriteFile(filepath.Join(dir, "2022-10.key"), []byte("2022-10"), 0600)
+	if err != nil {
+		t.Errorf("There's an error when writing secret key file => " + err.Error())
+	}
+
+	// initialize testing table, run in order
+	testTable := []struct {
+		ID            string
+		Promote       bool
+		Delete        bool
+		ExpectedValid bool
+	}{
+		{ID: "staged", ExpectedValid: false},
+		{ID: "2022-07", ExpectedValid: false},
+		{ID: "2022-10", ExpectedValid: false},
+		{ID: "2022-10", Promote: true, ExpectedValid: true},
+		{ID: "2022-10", Delete: true, ExpectedValid: false},
+	}
+
+	// loop test in test table
+	for _, test := range testTable {
+		if test.Promote { // promoted by another replica
+			err = addPromotedJWTKey(dir, test.ID, time.Now())
+			if err != nil {
+				t.Errorf("There's an error when writing promoted keys file => " + err.Error())
+			}
+		}
+		if test.Delete {
+			err = os.Remove(filepath.Join(dir, test.ID+".key"))
+			if err != nil {
+				t.Errorf("There's an error when deleting secret key file => " + err.Error())
+			}
+		}
+
+		_, ok := keyRing.GetKey(test.ID)
+		if ok != test.ExpectedValid {
+			t.Errorf("Expected key %s valid %t, but got %t", test.ID, test.ExpectedValid, ok)
+		}
+	}
+
+	// promote key from directory
+	_, err = keyRing.PromoteFromDir("2022-08")
+	if err != nil {
+		t.Errorf("Expected error nil, but got not nil => " + err.Error())
+	}
+
+	if keyRing.ActiveKey().ID != "2022-08" {
+		t.Errorf("Expected active key '2022-08', but got '%s'", keyRing.ActiveKey().ID)
+	}
+
+	_, err = keyRing.PromoteFromDir("2022-11")
+	if err == nil {
+		t.Errorf("Expected error not nil, but got nil")
+	}
+
+	// key promoted on this replica valid on another replica
+	otherKeyRing, err := NewJWTKeyRingFromDir(dir, "2022-09", "HS256", time.Hour)
+	if err != nil {
+		t.Fatalf("Expected error nil, but got not nil => " + err.Error())
+	}
+
+	_, ok := otherKeyRing.GetKey("2022-08")
+	if !ok {
+		t.Errorf("Expected promoted key '2022-08' valid, but got not valid")
+	}
+}
@@ -0,0 +1,547 @@
+/*
+Package config collection of configuration
+*/
+package config
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/joho/godotenv"
+)
+
+// prefix of setting environment variable,
+// e.g. setting "DB_HOST" set by "ECOM_ACCOUNT_SERVICE_DB_HOST"
+const envPrefix = "ECOM_ACCOUNT_SERVICE_"
+
+// environment variable of config file path, overridden by -config flag
+const configFileEnv = envPrefix + "CONFIG_FILE"
+
+// setting a config value, set from string of every config source
+type setting struct {
+	Key     string
+	Default string
+	Set     func(c *Config, value string) error
+}
+
+// all settings of Config, value of setting with empty default
+// derived from other settings if still empty (e.g. URLs from frontend URL)
+var settings = []setting{
+	stringSetting("DB_DRIVER", DBDriverPostgres, func(c *Config) *string { return &c.DBDriver }),
+	stringSetting("DB_NAME", "", func(c *Config) *string { return &c.DBName }),
+	stringSetting("DB_TEST_NAME", "", func(c *Config) *string { return &c.DBTestName }),
+	stringSetting("DB_USERNAME", "", func(c *Config) *string { return &c.DBUsername }),
+	stringSetting("DB_PASSWORD", "", func(c *Config) *string { return &c.DBPassword }),
+	stringSetting("DB_PATH", "ecom-account-service.db",
+		func(c *Config) *string { return &c.DBPath }),
+	stringSetting("DB_TEST_PATH", "ecom-account-service-test.db",
+		func(c *Config) *string { return &c.DBTestPath }),
+
+	stringSetting("DB_HOST", "localhost", func(c *Config) *string { return &c.DBHost }),
+	stringSetting("DB_PORT", "5432", func(c *Config) *string { return &c.DBPort }),
+	stringSetting("DB_SSL_MODE", "disable", func(c *Config) *string { return &c.DBSSLMode }),
+	stringSetting("DB_SSL_ROOT_CERT", "", func(c *Config) *string { return &c.DBSSLRootCert }),
+	stringSetting("DB_SSL_CERT", "", func(c *Config) *string { return &c.DBSSLCert }),
+	stringSetting("DB_SSL_KEY", "", func(c *Config) *string { return &c.DBSSLKey }),
+	// 0 wait indefinitely
+	durationSetting("DB_CONNECT_TIMEOUT", 10*time.Second,
+		func(c *Config) *time.Duration { return &c.DBConnectTimeout }),
+
+	// default is the same as database/sql default,
+	// 0 unlimited open connections and connection lifetime
+	intSetting("DB_MAX_OPEN_CONNS", 0, func(c *Config) *int { return &c.DBMaxOpenConns }),
+	intSetting("DB_MAX_IDLE_CONNS", 2, func(c *Config) *int { return &c.DBMaxIdleConns }),
+	durationSetting("DB_CONN_MAX_LIFETIME", 0,
+		func(c *Config) *time.Duration { return &c.DBConnMaxLifetime }),
+
+	// backoff doubled after every failed ping
+	intSetting("DB_PING_ATTEMPTS", 5, func(c *Config) *int { return &c.DBPingAttempts }),
+	durationSetting("DB_PING_BACKOFF", time.Second,
+		func(c *Config) *time.Duration { return &c.DBPingBackoff }),
+
+	stringSetting("JWT_SECRET_KEY", "", func(c *Config) *string { return &c.JWTSecretKey }),
+	stringSetting("JWT_PRIVATE_KEY_FILE", "",
+		func(c *Config) *string { return &c.JWTPrivateKeyFilePath }),
+	stringSetting("JWT_KEY_DIR", "", func(c *Config) *string { return &c.JWTKeyDir }),
+	stringSetting("JWT_SIGNING_METHOD", jwt.SigningMethodHS256.Alg(),
+		func(c *Config) *string { return &c.JWTSigningMethod }),
+	stringSetting("JWT_ACTIVE_KEY_ID", "default",
+		func(c *Config) *string { return &c.JWTActiveKeyID }),
+
+	durationSetting("ACCESS_TOKEN_DURATION", 30*time.Minute,
+		func(c *Config) *time.Duration { return &c.AccessTokenDuration }),
+	durationSetting("REFRESH_TOKEN_DURATION", 30*24*time.Hour,
+		func(c *Config) *time.Duration { return &c.RefreshTokenDuration }),
+
+	durationSetting("PASSWORD_RESET_TOKEN_DURATION", time.Hour,
+		func(c *Config) *time.Duration { return &c.PasswordResetTokenDuration }),
+	stringSetting("PASSWORD_RESET_URL", "", func(c *Config) *string { return &c.PasswordResetURL }),
+	intSetting("PASSWORD_MIN_LENGTH", 8, func(c *Config) *int { return &c.PasswordMinLength }),
+	intSetting("PASSWORD_HISTORY_COUNT", 5,
+		func(c *Config) *int { return &c.PasswordHistoryCount }),
+
+	stringSetting("SMTP_HOST", "", func(c *Config) *string { return &c.SMTPHost }),
+	stringSetting("SMTP_PORT", "", func(c *Config) *string { return &c.SMTPPort }),
+	stringSetting("SMTP_USERNAME", "", func(c *Config) *string { return &c.SMTPUsername }),
+	stringSetting("SMTP_PASSWORD", "", func(c *Config) *string { return &c.SMTPPassword }),
+	stringSetting("MAIL_FROM", "", func(c *Config) *string { return &c.MailFrom }),
+
+	stringSetting("EMAIL_VERIFICATION_POLICY", EmailVerificationPolicyNone,
+		func(c *Config) *string { return &c.EmailVerificationPolicy }),
+	stringSetting("EMAIL_UNVERIFIED_ROLE", "unverified",
+		func(c *Config) *string { return &c.EmailUnverifiedRole }),
+	durationSetting("EMAIL_VERIFICATION_TOKEN_DURATION", 24*time.Hour,
+		func(c *Config) *time.Duration { return &c.EmailVerificationTokenDuration }),
+	durationSetting("EMAIL_VERIFICATION_RESEND_INTERVAL", time.Minute,
+		func(c *Config) *time.Duration { return &c.EmailVerificationResendInterval }),
+	stringSetting("EMAIL_VERIFICATION_URL", "",
+		func(c *Config) *string { return &c.EmailVerificationURL }),
+
+	durationSetting("EMAIL_CHANGE_TOKEN_DURATION", 24*time.Hour,
+		func(c *Config) *time.Duration { return &c.EmailChangeTokenDuration }),
+	durationSetting("EMAIL_CHANGE_REVERT_DURATION", 7*24*time.Hour,
+		func(c *Config) *time.Duration { return &c.EmailChangeRevertDuration }),
+	stringSetting("EMAIL_CHANGE_CONFIRM_URL", "",
+		func(c *Config) *string { return &c.EmailChangeConfirmURL }),
+	stringSetting("EMAIL_CHANGE_REVERT_URL", "",
+		func(c *Config) *string { return &c.EmailChangeRevertURL }),
+
+	stringSetting("SMS_PROVIDER", "", func(c *Config) *string { return &c.SMSProvider }),
+	stringSetting("SMS_FROM", "", func(c *Config) *string { return &c.SMSFrom }),
+	stringSetting("TWILIO_ACCOUNT_SID", "",
+		func(c *Config) *string { return &c.TwilioAccountSID }),
+	stringSetting("TWILIO_AUTH_TOKEN", "", func(c *Config) *string { return &c.TwilioAuthToken }),
+	stringSetting("TWILIO_API_URL", "https://api.twilio.com",
+		func(c *Config) *string { return &c.TwilioAPIURL }),
+
+	stringSetting("PHONE_DEFAULT_REGION", "ID",
+		func(c *Config) *string { return &c.PhoneDefaultRegion }),
+	durationSetting("PHONE_VERIFICATION_CODE_DURATION", 10*time.Minute,
+		func(c *Config) *time.Duration { return &c.PhoneVerificationCodeDuration }),
+	durationSetting("PHONE_VERIFICATION_RESEND_INTERVAL", time.Minute,
+		func(c *Config) *time.Duration { return &c.PhoneVerificationResendInterval }),
+	intSetting("PHONE_VERIFICATION_MAX_ATTEMPTS", 5,
+		func(c *Config) *int { return &c.PhoneVerificationMaxAttempts }),
+
+	durationSetting("DELETED_USER_RETENTION", 30*24*time.Hour,
+		func(c *Config) *time.Duration { return &c.DeletedUserRetention }),
+	durationSetting("ACCOUNT_DELETION_GRACE_PERIOD", 14*24*time.Hour,
+		func(c *Config) *time.Duration { return &c.AccountDeletionGracePeriod }),
+	durationSetting("ACCOUNT_ERASURE_INTERVAL", time.Hour,
+		func(c *Config) *time.Duration { return &c.AccountErasureInterval }),
+
+	durationSetting("DATA_EXPORT_DURATION", 7*24*time.Hour,
+		func(c *Config) *time.Duration { return &c.DataExportDuration }),
+	durationSetting("DATA_EXPORT_INTERVAL", time.Minute,
+		func(c *Config) *time.Duration { return &c.DataExportInterval }),
+	stringSetting("DATA_EXPORT_URL", "", func(c *Config) *string { return &c.DataExportURL }),
+
+	listSetting("EVENT_WEBHOOK_URLS", func(c *Config) *[]string { return &c.EventWebhookURLs }),
+	stringSetting("EVENT_WEBHOOK_SECRET", "",
+		func(c *Config) *string { return &c.EventWebhookSecret }),
+
+	stringSetting("TOTP_ISSUER", "ecom-account-service",
+		func(c *Config) *string { return &c.TOTPIssuer }),
+	durationSetting("MFA_TOKEN_DURATION", 5*time.Minute,
+		func(c *Config) *time.Duration { return &c.MFATokenDuration }),
+
+	intSetting("LOGIN_FAILURE_THRESHOLD", 5,
+		func(c *Config) *int { return &c.LoginFailureThreshold }),
+	intSetting("LOGIN_IP_FAILURE_THRESHOLD", 20,
+		func(c *Config) *int { return &c.LoginIPFailureThreshold }),
+	durationSetting("LOGIN_BACKOFF_BASE", time.Second,
+		func(c *Config) *time.Duration { return &c.LoginBackoffBase }),
+	durationSetting("LOGIN_LOCKOUT_DURATION", 15*time.Minute,
+		func(c *Config) *time.Duration { return &c.LoginLockoutDuration }),
+
+	boolSetting("RATE_LIMIT_ENABLED", true, func(c *Config) *bool { return &c.RateLimitEnabled }),
+
+	stringSetting("FRONTEND_URL", "", func(c *Config) *string { return &c.FrontendURL }),
+	stringSetting("PRODUCT_SERVICE_URL", "",
+		func(c *Config) *string { return &c.ProductServiceURL }),
+}
+
+// Load load config from layered sources, each source override the previous:
+// defaults, optional config file, environment variables, then command line flags.
+// Return the config and command line arguments left after the flags.
+//
+// Config file path set by -config flag or ECOM_ACCOUNT_SERVICE_CONFIG_FILE,
+// otherwise ".env" file in working directory or module root used if exist.
+// Empty value of any source is ignored, so the previous source value used.
+func Load(args []string) (Config, []string, error) {
+	c := Config{}
+
+	// parse flags first to get config file path
+	fileFlag, flagValues, args, err := parseFlags(args)
+	if err != nil {
+		return c, args, err
+	}
+
+	// defaults
+	values := map[string]string{}
+	for _, s := range settings {
+		values[s.Key] = s.Default
+	}
+
+	// config file
+	path, isRequired := getConfigFilePath(fileFlag)
+	if path != "" {
+		fileValues, err := readConfigFile(path, isRequired)
+		if err != nil {
+			return c, args, err
+		}
+		mergeValues(values, fileValues)
+	}
+
+	// environment variables
+	envValues := map[string]string{}
+	for _, s := range settings {
+		envValues[s.Key] = os.Getenv(envPrefix + s.Key)
+	}
+	mergeValues(values, envValues)
+
+	// command line flags
+	mergeValues(values, flagValues)
+
+	for _, s := range settings {
+		err = s.Set(&c, values[s.Key])
+		if err != nil {
+			return c, args, fmt.Errorf("config %s value %q not valid => %w",
+				s.Key, values[s.Key], err)
+		}
+	}
+
+	setDerivedValues(&c)
+
+	err = c.validate()
+	if err != nil {
+		return c, args, err
+	}
+
+	// load jwt keys from key directory if configured,
+	// otherwise only use one key from secret key or private key file
+	if c.JWTKeyDir != "" {
+		c.JWTKeys, err = NewJWTKeyRingFromDir(c.JWTKeyDir, c.JWTActiveKeyID,
+			c.JWTSigningMethod, c.jwtKeyRetention())
+		if err != nil {
+			return c, args, err
+		}
+	} else {
+		activeKey, err := LoadJWTKey(c.JWTActiveKeyID, c.JWTSigningMethod,
+			c.JWTSecretKey, c.JWTPrivateKeyFilePath)
+		if err != nil {
+			return c, args, err
+		}
+
+		c.JWTKeys = NewJWTKeyRing(activeKey, c.jwtKeyRetention())
+	}
+
+	return c, args, nil
+}
+
+// setDerivedValues set values derived from other values if still empty
+func setDerivedValues(c *Config) {
+	derivedURLs := []struct {
+		URL  *string
+		Path string
+	}{
+		{URL: &c.PasswordResetURL, Path: "/password/reset/"},
+		{URL: &c.EmailVerificationURL, Path: "/email/verify/"},
+		{URL: &c.EmailChangeConfirmURL, Path: "/email/change/confirm/"},
+		{URL: &c.EmailChangeRevertURL, Path: "/email/change/revert/"},
+		{URL: &c.DataExportURL, Path: "/account/export/"},
+	}
+	for _, derivedURL := range derivedURLs {
+		if *derivedURL.URL == "" {
+			*derivedURL.URL = c.FrontendURL + derivedURL.Path
+		}
+	}
+
+	c.PhoneDefaultRegion = strings.ToUpper(c.PhoneDefaultRegion)
+}
+
+// mergeValues override values by not empty source values
+func mergeValues(values map[string]string, sourceValues map[string]string) {
+	for key, value := range sourceValues {
+		if strings.TrimSpace(value) != "" {
+			values[key] = value
+		}
+	}
+}
+
+// parseFlags parse command line flags, every setting has a flag
+// (e.g. setting "DB_HOST" set by -db-host), return config file flag,
+// setting values of flags, and arguments left after the flags
+func parseFlags(args []string) (string, map[string]string, []string, error) {
+	flagSet := flag.NewFlagSet("ecom-account-service", flag.ContinueOnError)
+
+	var fileFlag string
+	flagSet.StringVar(&fileFlag, "config", "",
+		"config file path (.env, .yaml, or .toml), or "+configFileEnv)
+
+	values := map[string]string{}
+	for _, s := range settings {
+		key := s.Key
+		flagSet.Func(getFlagName(key), "or "+envPrefix+key+" (default "+
+			strconv.Quote(s.Default)+")", func(value string) error {
+			values[key] = value
+			return nil
+		})
+	}
+
+	err := flagSet.Parse(args)
+	if err != nil {
+		return "", values, args, err
+	}
+
+	return fileFlag, values, flagSet.Args(), nil
+}
+
+// getFlagName get command line flag name of setting, e.g. "DB_HOST" to "db-host"
+func getFlagName(key string) string {
+	return strings.ToLower(strings.ReplaceAll(key, "_", "-"))
+}
+
+// getConfigFilePath get config file path and whether the file must exist,
+// ".env" file searched in working directory then module root
+// (nearest parent directory containing go.mod) if path not set
+func getConfigFilePath(fileFlag string) (string, bool) {
+	if strings.TrimSpace(fileFlag) != "" {
+		return fileFlag, true
+	}
+	if strings.TrimSpace(os.Getenv(configFileEnv)) != "" {
+		return os.Getenv(configFileEnv), true
+	}
+
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", false
+	}
+	if isFileExist(filepath.Join(dir, ".env")) {
+		return filepath.Join(dir, ".env"), false
+	}
+	for {
+		if isFileExist(filepath.Join(dir, "go.mod")) {
+			if isFileExist(filepath.Join(dir, ".env")) {
+				return filepath.Join(dir, ".env"), false
+			}
+			break
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+
+	// legacy location, so existing development setup still works
+	if os.Getenv("GOPATH") != "" {
+		path := os.ExpandEnv("$GOPATH/src/github.com/reyhanfikridz/ecom-account-service/.env")
+		if isFileExist(path) {
+			return path, false
+		}
+	}
+
+	return "", false
+}
+
+// isFileExist check if path is an existing file
+func isFileExist(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
+
+// readConfigFile read setting values of config file, format by extension:
+// ".yaml"/".yml" and ".toml" flat key value file, otherwise ".env" file.
+//
+// Key of .env file is the environment variable name, other variables ignored.
+// Key of YAML and TOML file is the setting name (e.g. "db_host"),
+// unknown key not allowed.
+func readConfigFile(path string, isRequired bool) (map[string]string, error) {
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) && !isRequired {
+		return map[string]string{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	values := map[string]string{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		values, err = parseFlatFile(string(content), ":")
+	case ".toml":
+		values, err = parseFlatFile(string(content), "=")
+	default:
+		var envValues map[string]string
+		envValues, err = godotenv.Unmarshal(string(content))
+		for key, value := range envValues {
+			if strings.HasPrefix(key, envPrefix) {
+				values[strings.TrimPrefix(key, envPrefix)] = value
+			}
+		}
+		return values, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("config file %s not valid => %w", path, err)
+	}
+
+	settingValues := map[string]string{}
+	for key, value := range values {
+		key = strings.ToUpper(strings.ReplaceAll(key, "-", "_"))
+		if !isSettingExist(key) {
+			return nil, fmt.Errorf("config file %s setting %q not exist", path, key)
+		}
+		settingValues[key] = value
+	}
+
+	return settingValues, nil
+}
+
+// isSettingExist check if setting key exist
+func isSettingExist(key string) bool {
+	for _, s := range settings {
+		if s.Key == key {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseFlatFile parse flat key value file, one "key<separator>value" per line
+// (e.g. YAML "key: value", TOML "key = value"), line starting with "#" is comment.
+// Nested value, list, and multi line value not supported,
+// list setting written as comma separated string.
+func parseFlatFile(content string, separator string) (map[string]string, error) {
+	values := map[string]string{}
+	for i, line := range strings.Split(content, "\n") {
+		if strings.TrimSpace(line) != "" &&
+			(strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			return nil, fmt.Errorf("line %d nested value not supported", i+1)
+		}
+
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") || line == "---" {
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, separator)
+		key = strings.TrimSpace(key)
+		if !ok || key == "" || strings.ContainsAny(key, " []") {
+			return nil, fmt.Errorf("line %d not valid", i+1)
+		}
+
+		value, err := parseFlatValue(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("line %d value not valid => %w", i+1, err)
+		}
+		values[key] = value
+	}
+
+	return values, nil
+}
+
+// parseFlatValue parse value of flat key value file,
+// double quoted value can contain escape, comment after the value removed
+func parseFlatValue(value string) (string, error) {
+	var rest string
+	if strings.HasPrefix(value, `"`) {
+		quoted, err := strconv.QuotedPrefix(value)
+		if err != nil {
+			return "", err
+		}
+		rest = value[len(quoted):]
+		value, err = strconv.Unquote(quoted)
+		if err != nil {
+			return "", err
+		}
+	} else if strings.HasPrefix(value, "'") {
+		end := strings.Index(value[1:], "'")
+		if end < 0 {
+			return "", fmt.Errorf("closing quote not found")
+		}
+		rest = value[end+2:]
+		value = value[1 : end+1]
+	} else {
+		if strings.HasPrefix(value, "#") {
+			return "", nil
+		}
+		if i := strings.Index(value, " #"); i >= 0 {
+			value = value[:i]
+		}
+		return strings.TrimSpace(value), nil
+	}
+
+	rest = strings.TrimSpace(rest)
+	if rest != "" && !strings.HasPrefix(rest, "#") {
+		return "", fmt.Errorf("unexpected %q after quoted value", rest)
+	}
+
+	return value, nil
+}
+
+// stringSetting string setting
+func stringSetting(key string, defaultValue string, field func(c *Config) *string) setting {
+	return setting{Key: key, Default: defaultValue, Set: func(c *Config, value string) error {
+		*field(c) = value
+		return nil
+	}}
+}
+
+// durationSetting duration setting (e.g. "30m", "720h")
+func durationSetting(key string, defaultValue time.Duration,
+	field func(c *Config) *time.Duration) setting {
+	return setting{Key: key, Default: defaultValue.String(),
+		Set: func(c *Config, value string) error {
+			duration, err := time.ParseDuration(strings.TrimSpace(value))
+			*field(c) = duration
+			return err
+		}}
+}
+
+// intSetting integer setting
+func intSetting(key string, defaultValue int, field func(c *Config) *int) setting {
+	return setting{Key: key, Default: strconv.Itoa(defaultValue),
+		Set: func(c *Config, value string) error {
+			number, err := strconv.Atoi(strings.TrimSpace(value))
+			*field(c) = number
+			return err
+		}}
+}
+
+// boolSetting boolean setting (e.g. "true", "false", "1", "0")
+func boolSetting(key string, defaultValue bool, field func(c *Config) *bool) setting {
+	return setting{Key: key, Default: strconv.FormatBool(defaultValue),
+		Set: func(c *Config, value string) error {
+			b, err := strconv.ParseBool(strings.TrimSpace(value))
+			*field(c) = b
+			return err
+		}}
+}
+
+// listSetting comma separated list setting, empty by default
+func listSetting(key string, field func(c *Config) *[]string) setting {
+	return setting{Key: key, Set: func(c *Config, value string) error {
+		*field(c) = parseList(value)
+		return nil
+	}}
+}
+
+// parseList parse comma separated list, empty item skipped
+func parseList(value string) []string {
+	list := []string{}
+	for _, item := range strings.Split(value, ",") {
+		if strings.TrimSpace(item) != "" {
+			list = append(list, strings.TrimSpace(item))
+		}
+	}
+
+	return list
+}
@@ -0,0 +1,301 @@
+/*
+Package config collection of configuration
+*/
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeTestConfigFile write config file in testing temporary directory
+func writeTestConfigFile(t *testing.T, name string, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	err := os.WriteFile(path, []byte(content), 0o600)
+	if err != nil {
+		t.Fatalf("There's an error when writing config file => " + err.Error())
+	}
+
+	return path
+}
+
+// TestLoadLayers test Load, config file override defaults,
+// environment variables override config file, flags override all
+func TestLoadLayers(t *testing.T) {
+	path := writeTestConfigFile(t, "config.yaml", `
+# testing config
+db_name: "file_db"
+db_host: file-host
+db_port: '6543'
+jwt_secret_key: "file secret" # comment
+frontend_url: http://file.example.com
+event_webhook_urls: http://a.example.com/, http://b.example.com/
+login_failure_threshold: 7
+`)
+	t.Setenv(configFileEnv, path)
+	t.Setenv(envPrefix+"DB_HOST", "env-host")
+	t.Setenv(envPrefix+"DB_PORT", "7654")
+	t.Setenv(envPrefix+"DB_NAME", "")
+
+	c, args, err := Load([]string{"-db-port", "8765", "-rate-limit-enabled=false",
+		"migrate", "up"})
+	if err != nil {
+		t.Fatalf("Expected error nil, but got not nil => " + err.Error())
+	}
+
+	testTable := []struct {
+		Name          string
+		Value         any
+		ExpectedValue any
+	}{
+		{Name: "default", Value: c.DBSSLMode, ExpectedValue: "disable"},
+		{Name: "file", Value: c.DBName, ExpectedValue: "file_db"},
+		{Name: "file quoted", Value: c.JWTSecretKey, ExpectedValue: "file secret"},
+		{Name: "file int", Value: c.LoginFailureThreshold, ExpectedValue: 7},
+		{
+			Name:          "file list",
+			Value:         strings.Join(c.EventWebhookURLs, "|"),
+			ExpectedValue: "http://a.example.com/|http://b.example.com/",
+		},
+		{Name: "env", Value: c.DBHost, ExpectedValue: "env-host"},
+		{Name: "flag", Value: c.DBPort, ExpectedValue: "8765"},
+		{Name: "flag bool", Value: c.RateLimitEnabled, ExpectedValue: false},
+		{
+			Name:          "derived",
+			Value:         c.PasswordResetURL,
+			ExpectedValue: "http://file.example.com/password/reset/",
+		},
+		{Name: "args", Value: strings.Join(args, " "), ExpectedValue: "migrate up"},
+	}
+	for _, test := range testTable {
+		if test.Value != test.ExpectedValue {
+			t.Errorf("Expected %s value %v, but got %v", test.Name, test.ExpectedValue,
+				test.Value)
+		}
+	}
+
+	if c.JWTKeys == nil || c.JWTKeys.ActiveKey().ID != "default" {
+		t.Errorf("Expected jwt key ring with active key default, but got %v", c.JWTKeys)
+	}
+}
+
+// TestLoadConfigFile test Load config file formats
+func TestLoadConfigFile(t *testing.T) {
+	// initialize testing table
+	testTable := []struct {
+		Name            string
+		Content         string
+		ExpectedDBName  string
+		IsErrorExpected bool
+	}{
+		{
+			Name: "config.env",
+			Content: "ECOM_ACCOUNT_SERVICE_DB_NAME=env_db\n" +
+				"ECOM_ACCOUNT_SERVICE_JWT_SECRET_KEY=secret\nPOSTGRES_PASSWORD=other\n",
+			ExpectedDBName: "env_db",
+		},
+		{
+			Name:           "config.toml",
+			Content:        "db_name = \"toml_db\"\njwt_secret_key = 'secret'\n",
+			ExpectedDBName: "toml_db",
+		},
+		{
+			Name:           "config.yml",
+			Content:        "---\nDB_NAME: yml_db\njwt-secret-key: secret\n",
+			ExpectedDBName: "yml_db",
+		},
+		{
+			Name:            "unknown.yaml",
+			Content:         "db_nmae: typo_db\njwt_secret_key: secret\n",
+			IsErrorExpected: true,
+		},
+		{
+			Name:            "nested.yaml",
+			Content:         "db:\n  name: nested_db\n",
+			IsErrorExpected: true,
+		},
+		{
+			Name:            "section.toml",
+			Content:         "[db]\nname = \"section_db\"\n",
+			IsErrorExpected: true,
+		},
+	}
+
+	// test
+	for _, test := range testTable {
+		t.Run(test.Name, func(t *testing.T) {
+			path := writeTestConfigFile(t, test.Name, test.Content)
+			t.Setenv(envPrefix+"DB_NAME", "")
+			t.Setenv(envPrefix+"JWT_SECRET_KEY", "")
+
+			c, _, err := Load([]string{"-config", path})
+			if test.IsErrorExpected {
+				if err == nil {
+					t.Errorf("Expected error, but got nil")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Errorf("Expected error nil, but got not nil => " + err.Error())
+			} else if c.DBName != test.ExpectedDBName {
+				t.Errorf("Expected DB name %q, but got %q", test.ExpectedDBName, c.DBName)
+			}
+		})
+	}
+
+	// config file set explicitly must exist
+	_, _, err := Load([]string{"-config", filepath.Join(t.TempDir(), "missing.env")})
+	if err == nil {
+		t.Errorf("Expected error for missing config file, but got nil")
+	}
+}
+
+// TestLoadValidation test Load refuse value not valid or required value empty
+func TestLoadValidation(t *testing.T) {
+	path := writeTestConfigFile(t, "config.env",
+		"ECOM_ACCOUNT_SERVICE_DB_NAME=db\nECOM_ACCOUNT_SERVICE_JWT_SECRET_KEY=secret\n")
+
+	// initialize testing table
+	testTable := []struct {
+		Args            []string
+		IsErrorExpected bool
+	}{
+		{Args: []string{}},
+		{Args: []string{"-access-token-duration", "45m"}},
+		{Args: []string{"-access-token-duration", "45"}, IsErrorExpected: true},
+		{Args: []string{"-password-min-length", "ten"}, IsErrorExpected: true},
+		{Args: []string{"-rate-limit-enabled=nope"}, IsErrorExpected: true},
+		{Args: []string{"-db-driver", "mysql"}, IsErrorExpected: true},
+		{Args: []string{"-jwt-signing-method", "none"}, IsErrorExpected: true},
+		{Args: []string{"-unknown-flag", "value"}, IsErrorExpected: true},
+		{Args: []string{"-sms-provider", "log"}},
+		{Args: []string{"-sms-provider", "unknown"}, IsErrorExpected: true},
+		{Args: []string{"-sms-provider", "twilio"}, IsErrorExpected: true},
+		{Args: []string{"-sms-provider", "twilio", "-twilio-account-sid", "AC123",
+			"-twilio-auth-token", "token", "-sms-from", "+15005550006"}},
+	}
+
+	// test
+	for _, test := range testTable {
+		t.Run(strings.Join(test.Args, " "), func(t *testing.T) {
+			_, _, err := Load(append([]string{"-config", path}, test.Args...))
+			if test.IsErrorExpected && err == nil {
+				t.Errorf("Expected error, but got nil")
+			} else if !test.IsErrorExpected && err != nil {
+				t.Errorf("Expected error nil, but got not nil => " + err.Error())
+			}
+		})
+	}
+}
+
+// TestLoadRequired test Load refuse empty required value
+func TestLoadRequired(t *testing.T) {
+	// initialize testing table
+	testTable := []struct {
+		Name    string
+		Content string
+	}{
+		{
+			Name:    "jwt secret key",
+			Content: "ECOM_ACCOUNT_SERVICE_DB_NAME=db\n",
+		},
+		{
+			Name:    "database name",
+			Content: "ECOM_ACCOUNT_SERVICE_JWT_SECRET_KEY=secret\n",
+		},
+		{
+			Name: "jwt private key file",
+			Content: "ECOM_ACCOUNT_SERVICE_DB_NAME=db\n" +
+				"ECOM_ACCOUNT_SERVICE_JWT_SIGNING_METHOD=RS256\n",
+		},
+	}
+
+	// test
+	for _, test := range testTable {
+		t.Run(test.Name, func(t *testing.T) {
+			path := writeTestConfigFile(t, "config.env", test.Content)
+			for _, key := range []string{"DB_NAME", "JWT_SECRET_KEY", "JWT_KEY_DIR",
+				"JWT_PRIVATE_KEY_FILE", "JWT_SIGNING_METHOD"} {
+				t.Setenv(envPrefix+key, "")
+			}
+
+			_, _, err := Load([]string{"-config", path})
+			if err == nil {
+				t.Errorf("Expected error for empty %s, but got nil", test.Name)
+			}
+		})
+	}
+}
+
+// TestParseFlatValue test parseFlatValue
+func TestParseFlatValue(t *testing.T) {
+	// initialize testing table
+	testTable := []struct {
+		Value           string
+		ExpectedValue   string
+		IsErrorExpected bool
+	}{
+		{Value: `plain value`, ExpectedValue: "plain value"},
+		{Value: `value # comment`, ExpectedValue: "value"},
+		{Value: `http://localhost:3000/#/home`, ExpectedValue: "http://localhost:3000/#/home"},
+		{Value: `# comment`, ExpectedValue: ""},
+		{Value: `"quoted \"value\" # not comment"`, ExpectedValue: `quoted "value" # not comment`},
+		{Value: `'single # quoted' # comment`, ExpectedValue: "single # quoted"},
+		{Value: `"not closed`, IsErrorExpected: true},
+		{Value: `'not closed`, IsErrorExpected: true},
+		{Value: `"quoted" rest`, IsErrorExpected: true},
+	}
+
+	// test
+	for _, test := range testTable {
+		value, err := parseFlatValue(test.Value)
+		if test.IsErrorExpected {
+			if err == nil {
+				t.Errorf("Expected error for %q, but got nil", test.Value)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("Expected error nil for %q, but got not nil => %s", test.Value, err)
+		} else if value != test.ExpectedValue {
+			t.Errorf("Expected value %q, but got %q", test.ExpectedValue, value)
+		}
+	}
+}
+
+// TestParseList test parseList
+func TestParseList(t *testing.T) {
+	// initialize testing table
+	testTable := []struct {
+		Value        string
+		ExpectedList []string
+	}{
+		{
+			Value:        "",
+			ExpectedList: []string{},
+		},
+		{
+			Value:        "http://localhost:8020/events/",
+			ExpectedList: []string{"http://localhost:8020/events/"},
+		},
+		{
+			Value: " http://localhost:8020/events/, ,http://localhost:8030/events/",
+			ExpectedList: []string{
+				"http://localhost:8020/events/",
+				"http://localhost:8030/events/",
+			},
+		},
+	}
+
+	// test
+	for _, test := range testTable {
+		list := parseList(test.Value)
+		if strings.Join(list, "|") != strings.Join(test.ExpectedList, "|") {
+			t.Errorf("Expected list %v, but got %v", test.ExpectedList, list)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+/*
+Package dataexport build personal data export archive
+*/
+package dataexport
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"time"
+)
+
+// FormatVersion version of the archive layout, increased when
+// a file removed or a field changed in a way that break consumers.
+// New file or new field can be added without increasing the version
+// (e.g. consents, once recorded by the account service).
+//
+// Version 1 layout:
+//
+//	manifest.json       format version, user ID, generated time, and file list
+//	profile.json        profile of the user
+//	addresses.json      addresses of the user
+//	sessions.json       logged in sessions (without token)
+//	login_history.json  login attempts of the user
+const FormatVersion = 1
+
+// archive file names of format version 1
+const (
+	FileManifest     = "manifest.json"
+	FileProfile      = "profile.json"
+	FileAddresses    = "addresses.json"
+	FileSessions     = "sessions.json"
+	FileLoginHistory = "login_history.json"
+)
+
+// File a JSON file in the archive
+type File struct {
+	Name    string
+	Content any
+}
+
+// Manifest describe the archive, always the first file in the archive
+type Manifest struct {
+	FormatVersion int       `json:"format_version"`
+	UserID        int       `json:"user_id"`
+	GeneratedAt   time.Time `json:"generated_at"`
+	Files         []string  `json:"files"`
+}
+
+// Build build zip archive of JSON files with its manifest
+func Build(userID int, generatedAt time.Time, files []File) ([]byte, error) {
+	manifest := Manifest{
+		FormatVersion: FormatVersion,
+		UserID:        userID,
+		GeneratedAt:   generatedAt.UTC(),
+		Files:         []string{},
+	}
+	for _, file := range files {
+		manifest.Files = append(manifest.Files, file.Name)
+	}
+
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for _, file := range append([]File{{Name: FileManifest, Content: manifest}}, files...) {
+		content, err := json.MarshalIndent(file.Content, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+
+		fw, err := w.CreateHeader(&zip.FileHeader{
+			Name:     file.Name,
+			Method:   zip.Deflate,
+			Modified: manifest.GeneratedAt,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = fw.Write(content)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err := w.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
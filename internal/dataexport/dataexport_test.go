@@ -0,0 +1,72 @@
+/*
+Package dataexport build personal data export archive
+*/
+package dataexport
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"testing"
+	"time"
+)
+
+// TestBuild test Build
+func TestBuild(t *testing.T) {
+	archive, err := Build(1, time.Now(), []File{
+		{Name: FileProfile, Content: map[string]any{"email": "test@gmail.com"}},
+		{Name: FileAddresses, Content: []any{}},
+	})
+	if err != nil {
+		t.Errorf("Expected error nil, but got not nil => " + err.Error())
+	}
+
+	// read archive
+	r, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		t.Errorf("There's an error when reading archive => " + err.Error())
+	}
+
+	contents := map[string][]byte{}
+	names := []string{}
+	for _, f := range r.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Errorf("There's an error when opening archive file => " + err.Error())
+		}
+		content, err := io.ReadAll(rc)
+		if err != nil {
+			t.Errorf("There's an error when reading archive file => " + err.Error())
+		}
+		rc.Close()
+
+		contents[f.Name] = content
+		names = append(names, f.Name)
+	}
+
+	// check result
+	if len(names) != 3 || names[0] != FileManifest {
+		t.Errorf("Expected 3 files with manifest first, but got %v", names)
+	}
+
+	manifest := Manifest{}
+	err = json.Unmarshal(contents[FileManifest], &manifest)
+	if err != nil {
+		t.Errorf("There's an error when unmarshal manifest => " + err.Error())
+	}
+	if manifest.FormatVersion != FormatVersion || manifest.UserID != 1 ||
+		len(manifest.Files) != 2 {
+		t.Errorf("Expected manifest version %d, user ID 1, and 2 files, but got %+v",
+			FormatVersion, manifest)
+	}
+
+	profile := map[string]any{}
+	err = json.Unmarshal(contents[FileProfile], &profile)
+	if err != nil {
+		t.Errorf("There's an error when unmarshal profile => " + err.Error())
+	}
+	if profile["email"] != "test@gmail.com" {
+		t.Errorf("Expected profile email 'test@gmail.com', but got %v", profile["email"])
+	}
+}
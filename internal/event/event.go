@@ -0,0 +1,92 @@
+/*
+Package event collection of event publisher
+*/
+package event
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// event types published by account service
+const (
+	// user account erased, data contain user_id that still
+	// used as pseudonymous ID (e.g. in order history)
+	TypeAccountDeleted = "account.deleted"
+)
+
+// Event something happened in account service that other services can react to
+//
+// ID is unique for every event, so subscriber can ignore event
+// that already received when the event published again
+type Event struct {
+	ID         int             `json:"id"`
+	Type       string          `json:"type"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Data       json.RawMessage `json:"data"`
+}
+
+// Publisher event publisher
+type Publisher interface {
+	Publish(e Event) error
+}
+
+// WebhookPublisher event publisher that post event as JSON to webhook URLs
+//
+// Request body signed with secret (HMAC-SHA256) in X-Event-Signature header,
+// so subscriber can check the event really sent by account service
+type WebhookPublisher struct {
+	URLs   []string
+	Secret string
+	Client *http.Client
+}
+
+// Publish post event to all webhook URLs, return error
+// if one of webhook not respond with success status
+func (p WebhookPublisher) Publish(e Event) error {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	client := p.Client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	for _, URL := range p.URLs {
+		req, err := http.NewRequest("POST", URL, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Event-Type", e.Type)
+		req.Header.Set("X-Event-Signature", Sign(p.Secret, body))
+
+		res, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		res.Body.Close()
+
+		if res.StatusCode < 200 || res.StatusCode >= 300 {
+			return fmt.Errorf("webhook %s respond with status %d", URL, res.StatusCode)
+		}
+	}
+
+	return nil
+}
+
+// Sign sign event body with secret, return "sha256=<hex HMAC-SHA256>"
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,90 @@
+/*
+Package event collection of event publisher
+*/
+package event
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestWebhookPublisherPublish test WebhookPublisher Publish
+// with webhook that respond success and failed
+func TestWebhookPublisherPublish(t *testing.T) {
+	// create webhook servers
+	received := []Event{}
+	successServer := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				t.Errorf("There's an error when reading request body => " + err.Error())
+			}
+
+			if r.Header.Get("X-Event-Signature") != Sign("secret", body) {
+				t.Errorf("Expected signature '%s', but got '%s'",
+					Sign("secret", body), r.Header.Get("X-Event-Signature"))
+			}
+
+			e := Event{}
+			err = json.Unmarshal(body, &e)
+			if err != nil {
+				t.Errorf("There's an error when unmarshal event => " + err.Error())
+			}
+			received = append(received, e)
+		}))
+	defer successServer.Close()
+
+	failedServer := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(500)
+		}))
+	defer failedServer.Close()
+
+	e := Event{
+		ID:         1,
+		Type:       TypeAccountDeleted,
+		OccurredAt: time.Now().UTC(),
+		Data:       json.RawMessage(`{"user_id":1}`),
+	}
+
+	// create testing table
+	testTable := []struct {
+		URLs          []string
+		ExpectedError bool
+	}{
+		{
+			URLs:          []string{successServer.URL},
+			ExpectedError: false,
+		},
+		{
+			URLs:          []string{successServer.URL, failedServer.URL},
+			ExpectedError: true,
+		},
+	}
+
+	// loop test in test table
+	for _, test := range testTable {
+		p := WebhookPublisher{URLs: test.URLs, Secret: "secret"}
+		err := p.Publish(e)
+		if test.ExpectedError && err == nil {
+			t.Errorf("Expected error not nil, but got nil")
+		} else if !test.ExpectedError && err != nil {
+			t.Errorf("Expected error nil, but got not nil => " + err.Error())
+		}
+	}
+
+	// check result
+	if len(received) != 2 {
+		t.Errorf("Expected 2 events received, but got %d", len(received))
+	}
+	for _, receivedEvent := range received {
+		if receivedEvent.ID != e.ID || receivedEvent.Type != e.Type ||
+			string(receivedEvent.Data) != string(e.Data) {
+			t.Errorf("Expected received event same as published event, but not same")
+		}
+	}
+}
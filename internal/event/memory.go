@@ -0,0 +1,38 @@
+/*
+Package event collection of event publisher
+*/
+package event
+
+import "sync"
+
+// MemoryPublisher event publisher that keep all events in memory
+// instead of publishing it, used for testing
+type MemoryPublisher struct {
+	mu     sync.Mutex
+	Events []Event
+}
+
+// Publish save event in memory
+func (p *MemoryPublisher) Publish(e Event) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.Events = append(p.Events, e)
+
+	return nil
+}
+
+// LastEvent get last published event of an event type,
+// return false if there's no event of the type published
+func (p *MemoryPublisher) LastEvent(eventType string) (Event, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for i := len(p.Events) - 1; i >= 0; i-- {
+		if p.Events[i].Type == eventType {
+			return p.Events[i], true
+		}
+	}
+
+	return Event{}, false
+}
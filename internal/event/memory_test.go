@@ -0,0 +1,37 @@
+/*
+Package event collection of event publisher
+*/
+package event
+
+import (
+	"testing"
+)
+
+// TestMemoryPublisherPublishAndLastEvent integration test
+// MemoryPublisher Publish and LastEvent
+func TestMemoryPublisherPublishAndLastEvent(t *testing.T) {
+	p := &MemoryPublisher{}
+
+	// publish events
+	for _, ID := range []int{1, 2} {
+		err := p.Publish(Event{ID: ID, Type: TypeAccountDeleted})
+		if err != nil {
+			t.Errorf("Expected error nil, but got not nil => " + err.Error())
+		}
+	}
+
+	// check result
+	e, ok := p.LastEvent(TypeAccountDeleted)
+	if !ok {
+		t.Errorf("Expected event exist, but not exist")
+	}
+
+	if e.ID != 2 {
+		t.Errorf("Expected last event ID 2, but got %d", e.ID)
+	}
+
+	_, ok = p.LastEvent("another.type")
+	if ok {
+		t.Errorf("Expected event not exist, but exist")
+	}
+}
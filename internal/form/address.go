@@ -0,0 +1,147 @@
+/*
+Package form collection of form validation
+*/
+package form
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/reyhanfikridz/ecom-account-service/internal/model"
+	"github.com/reyhanfikridz/ecom-account-service/internal/phone"
+)
+
+// ISO 3166-1 alpha-2 country codes
+var countryCodes = strings.Fields(`
+	AD AE AF AG AI AL AM AO AQ AR AS AT AU AW AX AZ BA BB BD BE BF BG BH BI BJ BL
+	BM BN BO BQ BR BS BT BV BW BY BZ CA CC CD CF CG CH CI CK CL CM CN CO CR CU CV
+	CW CX CY CZ DE DJ DK DM DO DZ EC EE EG EH ER ES ET FI FJ FK FM FO FR GA GB GD
+	GE GF GG GH GI GL GM GN GP GQ GR GS GT GU GW GY HK HM HN HR HT HU ID IE IL IM
+	IN IO IQ IR IS IT JE JM JO JP KE KG KH KI KM KN KP KR KW KY KZ LA LB LC LI LK
+	LR LS LT LU LV LY MA MC MD ME MF MG MH MK ML MM MN MO MP MQ MR MS MT MU MV MW
+	MX MY MZ NA NC NE NF NG NI NL NO NP NR NU NZ OM PA PE PF PG PH PK PL PM PN PR
+	PS PT PW PY QA RE RO RS RU RW SA SB SC SD SE SG SH SI SJ SK SL SM SN SO SR SS
+	ST SV SX SY SZ TC TD TF TG TH TJ TK TL TM TN TO TR TT TV TW TZ UA UG UM US UY
+	UZ VA VC VE VG VI VN VU WF WS YE YT ZA ZM ZW
+`)
+
+// countryAddressRule address rule of a country
+type countryAddressRule struct {
+	// postal code required and must match the pattern if pattern not nil
+	PostalCodePattern *regexp.Regexp
+
+	// region (e.g. state, province) required
+	IsRegionRequired bool
+}
+
+// address rules of countries, country not listed here
+// has optional region and free format postal code
+var countryAddressRules = map[string]countryAddressRule{
+	"AU": {PostalCodePattern: regexp.MustCompile(`^\d{4}$`), IsRegionRequired: true},
+	"CA": {
+		PostalCodePattern: regexp.MustCompile(`^[A-Z]\d[A-Z] ?\d[A-Z]\d$`),
+		IsRegionRequired:  true,
+	},
+	"DE": {PostalCodePattern: regexp.MustCompile(`^\d{5}$`)},
+	"FR": {PostalCodePattern: regexp.MustCompile(`^\d{5}$`)},
+	"GB": {PostalCodePattern: regexp.MustCompile(`^[A-Z]{1,2}\d[A-Z\d]? ?\d[A-Z]{2}$`)},
+	"ID": {PostalCodePattern: regexp.MustCompile(`^\d{5}$`), IsRegionRequired: true},
+	"IN": {PostalCodePattern: regexp.MustCompile(`^\d{6}$`), IsRegionRequired: true},
+	"JP": {PostalCodePattern: regexp.MustCompile(`^\d{3}-?\d{4}$`), IsRegionRequired: true},
+	"MY": {PostalCodePattern: regexp.MustCompile(`^\d{5}$`), IsRegionRequired: true},
+	"NL": {PostalCodePattern: regexp.MustCompile(`^\d{4} ?[A-Z]{2}$`)},
+	"SG": {PostalCodePattern: regexp.MustCompile(`^\d{6}$`)},
+	"US": {
+		PostalCodePattern: regexp.MustCompile(`^\d{5}(-\d{4})?$`),
+		IsRegionRequired:  true,
+	},
+}
+
+// NormalizeAddress trim address fields, uppercase country code
+// and postal code, and normalize valid phone number to E.164,
+// so the address can be validated and saved
+func NormalizeAddress(a model.Address) model.Address {
+	a.Label = strings.TrimSpace(a.Label)
+	a.RecipientName = strings.TrimSpace(a.RecipientName)
+	a.Line1 = strings.TrimSpace(a.Line1)
+	a.Line2 = strings.TrimSpace(a.Line2)
+	a.City = strings.TrimSpace(a.City)
+	a.Region = strings.TrimSpace(a.Region)
+	a.PostalCode = strings.ToUpper(strings.TrimSpace(a.PostalCode))
+	a.CountryCode = strings.ToUpper(strings.TrimSpace(a.CountryCode))
+	a.PhoneNumber = strings.TrimSpace(a.PhoneNumber)
+
+	// national phone number parsed by the address country
+	phoneNumber, err := phone.Normalize(a.PhoneNumber, a.CountryCode)
+	if err == nil {
+		a.PhoneNumber = phoneNumber
+	}
+
+	return a
+}
+
+// ValidateAddressForm validate normalized address form,
+// region, postal code, and phone number validated by the country
+func ValidateAddressForm(a model.Address) FieldErrors {
+	v := Validator{}
+
+	// maximum length is the same as the column length
+	fields := []struct {
+		Name       string
+		Value      string
+		MaxLength  int
+		IsRequired bool
+	}{
+		{Name: "label", Value: a.Label, MaxLength: 30},
+		{Name: "recipient_name", Value: a.RecipientName, MaxLength: 50, IsRequired: true},
+		{Name: "line1", Value: a.Line1, MaxLength: 100, IsRequired: true},
+		{Name: "line2", Value: a.Line2, MaxLength: 100},
+		{Name: "city", Value: a.City, MaxLength: 50, IsRequired: true},
+		{Name: "region", Value: a.Region, MaxLength: 50},
+		{Name: "postal_code", Value: a.PostalCode, MaxLength: 20},
+		{Name: "country_code", Value: a.CountryCode, MaxLength: 2, IsRequired: true},
+		{Name: "phone_number", Value: a.PhoneNumber, MaxLength: 20},
+	}
+	for _, field := range fields {
+		if field.IsRequired {
+			v.Required(field.Name, field.Value)
+		}
+		v.MaxLength(field.Name, field.Value, field.MaxLength)
+	}
+
+	if v.IsValid("country_code") && !isCountryCodeValid(a.CountryCode) {
+		v.Add("country_code", CodeInvalid, "country_code not valid", nil)
+	}
+	if !v.IsValid("country_code") {
+		return v.Errors()
+	}
+
+	rule := countryAddressRules[a.CountryCode]
+	if rule.IsRegionRequired {
+		v.Required("region", a.Region)
+	}
+
+	if rule.PostalCodePattern != nil {
+		v.Required("postal_code", a.PostalCode)
+		if v.IsValid("postal_code") && !rule.PostalCodePattern.MatchString(a.PostalCode) {
+			v.Add("postal_code", CodeInvalid,
+				"postal_code not valid for country "+a.CountryCode,
+				map[string]any{"country_code": a.CountryCode})
+		}
+	}
+
+	v.PhoneNumber("phone_number", a.PhoneNumber, a.CountryCode)
+
+	return v.Errors()
+}
+
+// isCountryCodeValid check if country code is ISO 3166-1 alpha-2 code
+func isCountryCodeValid(countryCode string) bool {
+	for _, code := range countryCodes {
+		if code == countryCode {
+			return true
+		}
+	}
+
+	return false
+}
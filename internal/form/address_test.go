@@ -0,0 +1,153 @@
+/*
+Package form collection of form validation
+*/
+package form
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/reyhanfikridz/ecom-account-service/internal/model"
+)
+
+// TestNormalizeAddress test NormalizeAddress
+func TestNormalizeAddress(t *testing.T) {
+	a := NormalizeAddress(model.Address{
+		RecipientName: " test ",
+		PostalCode:    " sw1a 1aa ",
+		CountryCode:   " gb",
+	})
+	if a.RecipientName != "test" || a.PostalCode != "SW1A 1AA" || a.CountryCode != "GB" {
+		t.Errorf("Expected address normalized, but got %+v", a)
+	}
+
+	a = NormalizeAddress(model.Address{
+		PhoneNumber: "0811-1111-1111",
+		CountryCode: "id",
+	})
+	if a.PhoneNumber != "+6281111111111" {
+		t.Errorf("Expected phone number '+6281111111111', but got '" + a.PhoneNumber + "'")
+	}
+}
+
+// TestValidateAddressForm test ValidateAddressForm
+func TestValidateAddressForm(t *testing.T) {
+	// validAddress get valid address in a country, then modified by modify
+	validAddress := func(countryCode string, modify func(a *model.Address)) model.Address {
+		a := model.Address{
+			RecipientName: "test",
+			Line1:         "Jl. Test No. 1",
+			City:          "Jakarta",
+			Region:        "DKI Jakarta",
+			PostalCode:    "10110",
+			CountryCode:   countryCode,
+			PhoneNumber:   "+62 811-1111-1111",
+		}
+		if modify != nil {
+			modify(&a)
+		}
+		return a
+	}
+
+	// initialize testing table
+	testTable := []struct {
+		Name          string
+		Address       model.Address
+		ExpectedCodes string
+	}{
+		{
+			Name:          "test-address-success-1",
+			Address:       validAddress("ID", nil),
+			ExpectedCodes: "",
+		},
+		{
+			Name: "test-address-success-2",
+			Address: validAddress("US", func(a *model.Address) {
+				a.PostalCode = "94105-1234"
+			}),
+			ExpectedCodes: "",
+		},
+		{
+			Name: "test-address-success-3",
+			Address: validAddress("GB", func(a *model.Address) {
+				a.Region = ""
+				a.PostalCode = "SW1A 1AA"
+			}),
+			ExpectedCodes: "",
+		},
+		{
+			Name: "test-address-success-4", // country without rule
+			Address: validAddress("TH", func(a *model.Address) {
+				a.Region = ""
+				a.PostalCode = ""
+				a.PhoneNumber = ""
+			}),
+			ExpectedCodes: "",
+		},
+		{
+			Name: "test-address-failed-1",
+			Address: validAddress("ID", func(a *model.Address) {
+				a.RecipientName = ""
+			}),
+			ExpectedCodes: "recipient_name:required",
+		},
+		{
+			Name: "test-address-failed-2",
+			Address: validAddress("ID", func(a *model.Address) {
+				a.Line1 = strings.Repeat("a", 101)
+			}),
+			ExpectedCodes: "line1:too_long",
+		},
+		{
+			Name:          "test-address-failed-3",
+			Address:       validAddress("XX", nil),
+			ExpectedCodes: "country_code:invalid",
+		},
+		{
+			Name: "test-address-failed-4",
+			Address: validAddress("US", func(a *model.Address) {
+				a.Region = ""
+			}),
+			ExpectedCodes: "region:required",
+		},
+		{
+			Name: "test-address-failed-5",
+			Address: validAddress("SG", func(a *model.Address) {
+				a.PostalCode = ""
+			}),
+			ExpectedCodes: "postal_code:required",
+		},
+		{
+			Name: "test-address-failed-6",
+			Address: validAddress("US", func(a *model.Address) {
+				a.PostalCode = "1234"
+			}),
+			ExpectedCodes: "postal_code:invalid",
+		},
+		{
+			Name: "test-address-failed-7",
+			Address: validAddress("ID", func(a *model.Address) {
+				a.PhoneNumber = "0811-abc"
+			}),
+			ExpectedCodes: "phone_number:invalid",
+		},
+		{
+			Name: "test-address-failed-8",
+			Address: validAddress("US", func(a *model.Address) {
+				a.RecipientName = ""
+				a.Region = ""
+				a.PostalCode = "1234"
+			}),
+			ExpectedCodes: "recipient_name:required,region:required,postal_code:invalid",
+		},
+	}
+
+	// loop test in test table
+	for _, test := range testTable {
+		codes := fieldErrorCodes(ValidateAddressForm(test.Address))
+		if test.ExpectedCodes != codes {
+			t.Errorf("Expected errors '" + test.ExpectedCodes + "' got '" + codes +
+				"' (" + test.Name + ")")
+		}
+	}
+}
@@ -4,36 +4,84 @@ Package form collection of form validation
 package form
 
 import (
-	"strings"
-
 	"github.com/reyhanfikridz/ecom-account-service/internal/model"
 )
 
-// IsUserFormValid check if user form is valid (for login/register)
-func IsUserFormValid(u model.User, formType string) (bool, string) {
-	if strings.TrimSpace(u.Email) == "" {
-		return false, "email empty/not found"
-	}
+// ValidateUserForm validate user form (for login/register),
+// register form also validated by column length, phone number
+// of default region, and password policy
+func ValidateUserForm(u model.User, formType string, region string,
+	passwordMinLength int) FieldErrors {
+	v := Validator{}
+	isRegister := formType == "register"
 
-	if strings.TrimSpace(u.Password) == "" {
-		return false, "password empty/not found"
+	v.Required("email", u.Email)
+	if isRegister {
+		v.MaxLength("email", u.Email, 50)
+		v.Email("email", u.Email)
 	}
 
-	if formType == "register" && strings.TrimSpace(u.FullName) == "" {
-		return false, "full_name empty/not found"
+	v.Required("password", u.Password)
+	if isRegister {
+		v.Password("password", u.Password, passwordMinLength)
+
+		v.Required("full_name", u.FullName)
+		v.MaxLength("full_name", u.FullName, 50)
+
+		// register address become the first address book entry line 1
+		v.Required("address", u.Address)
+		v.MaxLength("address", u.Address, 100)
+
+		v.Required("phone_number", u.PhoneNumber)
+		v.PhoneNumber("phone_number", u.PhoneNumber, region)
+
+		v.Required("role", u.Role)
+		v.MaxLength("role", u.Role, 20)
 	}
 
-	if formType == "register" && strings.TrimSpace(u.Address) == "" {
-		return false, "address empty/not found"
+	return v.Errors()
+}
+
+// ValidateUserProfileForm validate user profile update form,
+// at least one field must be updated and updated field can't be empty,
+// phone number without country code is a number of default region
+func ValidateUserProfileForm(p model.UserProfileUpdate, region string) FieldErrors {
+	v := Validator{}
+	if p.FullName == nil && p.PhoneNumber == nil {
+		v.Add("", CodeRequired, "full_name and phone_number empty/not found",
+			map[string]any{"fields": []string{"full_name", "phone_number"}})
+		return v.Errors()
 	}
 
-	if formType == "register" && strings.TrimSpace(u.PhoneNumber) == "" {
-		return false, "phone_number empty/not found"
+	// maximum length is the same as the column length
+	if p.FullName != nil {
+		v.Required("full_name", *p.FullName)
+		v.MaxLength("full_name", *p.FullName, 50)
 	}
 
-	if formType == "register" && strings.TrimSpace(u.Role) == "" {
-		return false, "role empty/not found"
+	if p.PhoneNumber != nil {
+		v.Required("phone_number", *p.PhoneNumber)
+		v.PhoneNumber("phone_number", *p.PhoneNumber, region)
 	}
 
-	return true, ""
+	return v.Errors()
+}
+
+// ValidateNewPassword validate new password of a field
+// follows the password policy
+func ValidateNewPassword(field string, password string, minLength int) FieldErrors {
+	v := Validator{}
+	v.Required(field, password)
+	v.Password(field, password, minLength)
+	return v.Errors()
+}
+
+// ValidateNewEmail validate new email is a valid email address
+// and fit the email column
+func ValidateNewEmail(email string) FieldErrors {
+	v := Validator{}
+	v.Required("new_email", email)
+	v.MaxLength("new_email", email, 50)
+	v.Email("new_email", email)
+	return v.Errors()
 }
@@ -4,172 +4,247 @@ Package form collection of form validation
 package form
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/reyhanfikridz/ecom-account-service/internal/model"
 )
 
-// TestIsUserFormValid test IsUserFormValid
-func TestIsUserFormValid(t *testing.T) {
+// TestValidateUserForm test ValidateUserForm
+func TestValidateUserForm(t *testing.T) {
+	// validUser get valid register user, then modified by modify
+	validUser := func(modify func(u *model.User)) model.User {
+		u := model.User{
+			Email:       "test@gmail.com",
+			Password:    "testpassword1",
+			FullName:    "test",
+			Address:     "test",
+			PhoneNumber: "08111111111",
+			Role:        "test",
+		}
+		if modify != nil {
+			modify(&u)
+		}
+		return u
+	}
+
 	// initialize testing table
 	testTable := []struct {
-		Name              string
-		User              model.User
-		FormType          string
-		ExpectedIsValid   bool
-		ExpectedErrString string
+		Name          string
+		User          model.User
+		FormType      string
+		ExpectedCodes string
 	}{
 		{
-			Name: "test-register-success",
-			User: model.User{
-				Email:       "test@gmail.com",
-				Password:    "test",
-				FullName:    "test",
-				Address:     "test",
-				PhoneNumber: "test",
-				Role:        "test",
-			},
-			FormType:          "register",
-			ExpectedIsValid:   true,
-			ExpectedErrString: "",
+			Name:          "test-register-success",
+			User:          validUser(nil),
+			FormType:      "register",
+			ExpectedCodes: "",
 		},
 		{
-			Name: "test-register-failed-1",
-			User: model.User{
-				Email:       "",
-				Password:    "test",
-				FullName:    "test",
-				Address:     "test",
-				PhoneNumber: "test",
-				Role:        "test",
-			},
-			FormType:          "register",
-			ExpectedIsValid:   false,
-			ExpectedErrString: "email empty/not found",
+			Name:     "test-register-failed-all-empty",
+			User:     model.User{},
+			FormType: "register",
+			ExpectedCodes: "email:required,password:required,full_name:required," +
+				"address:required,phone_number:required,role:required",
 		},
 		{
-			Name: "test-register-failed-2",
-			User: model.User{
-				Email:       "test@gmail.com",
-				Password:    "",
-				FullName:    "test",
-				Address:     "test",
-				PhoneNumber: "test",
-				Role:        "test",
-			},
-			FormType:          "register",
-			ExpectedIsValid:   false,
-			ExpectedErrString: "password empty/not found",
+			Name: "test-register-failed-email",
+			User: validUser(func(u *model.User) {
+				u.Email = "test.gmail.com"
+			}),
+			FormType:      "register",
+			ExpectedCodes: "email:invalid",
+		},
+		{
+			Name: "test-register-failed-email-too-long",
+			User: validUser(func(u *model.User) {
+				u.Email = strings.Repeat("a", 41) + "@gmail.com"
+			}),
+			FormType:      "register",
+			ExpectedCodes: "email:too_long",
 		},
 		{
-			Name: "test-register-failed-3",
+			Name: "test-register-failed-password-weak",
+			User: validUser(func(u *model.User) {
+				u.Password = "testpassword"
+			}),
+			FormType:      "register",
+			ExpectedCodes: "password:weak",
+		},
+		{
+			Name: "test-register-failed-multiple",
+			User: validUser(func(u *model.User) {
+				u.Password = "test"
+				u.FullName = strings.Repeat("a", 51)
+				u.Address = strings.Repeat("a", 101)
+				u.PhoneNumber = "0811"
+				u.Role = strings.Repeat("a", 21)
+			}),
+			FormType: "register",
+			ExpectedCodes: "password:too_short,full_name:too_long,address:too_long," +
+				"phone_number:invalid,role:too_long",
+		},
+		{
+			Name: "test-login-success",
 			User: model.User{
-				Email:       "test@gmail.com",
-				Password:    "test",
-				FullName:    "",
-				Address:     "test",
-				PhoneNumber: "test",
-				Role:        "test",
+				Email:    "test@gmail.com",
+				Password: "test",
 			},
-			FormType:          "register",
-			ExpectedIsValid:   false,
-			ExpectedErrString: "full_name empty/not found",
+			FormType:      "login",
+			ExpectedCodes: "",
 		},
 		{
-			Name: "test-register-failed-4",
-			User: model.User{
-				Email:       "test@gmail.com",
-				Password:    "test",
-				FullName:    "test",
-				Address:     "",
-				PhoneNumber: "test",
-				Role:        "test",
+			Name:          "test-login-failed",
+			User:          model.User{},
+			FormType:      "login",
+			ExpectedCodes: "email:required,password:required",
+		},
+	}
+
+	// loop test in test table
+	for _, test := range testTable {
+		codes := fieldErrorCodes(ValidateUserForm(test.User, test.FormType, "ID", 8))
+		if test.ExpectedCodes != codes {
+			t.Errorf("Expected errors '" + test.ExpectedCodes + "' got '" + codes +
+				"' (" + test.Name + ")")
+		}
+	}
+}
+
+// TestValidateUserProfileForm test ValidateUserProfileForm
+func TestValidateUserProfileForm(t *testing.T) {
+	value := func(s string) *string {
+		return &s
+	}
+
+	// initialize testing table
+	testTable := []struct {
+		Name          string
+		Profile       model.UserProfileUpdate
+		ExpectedCodes string
+	}{
+		{
+			Name: "test-profile-success-1",
+			Profile: model.UserProfileUpdate{
+				FullName: value("test"),
 			},
-			FormType:          "register",
-			ExpectedIsValid:   false,
-			ExpectedErrString: "address empty/not found",
+			ExpectedCodes: "",
 		},
 		{
-			Name: "test-register-failed-5",
-			User: model.User{
-				Email:       "test@gmail.com",
-				Password:    "test",
-				FullName:    "test",
-				Address:     "test",
-				PhoneNumber: "",
-				Role:        "test",
+			Name: "test-profile-success-2",
+			Profile: model.UserProfileUpdate{
+				FullName:    value("test"),
+				PhoneNumber: value("+62 811-1111-1111"),
 			},
-			FormType:          "register",
-			ExpectedIsValid:   false,
-			ExpectedErrString: "phone_number empty/not found",
+			ExpectedCodes: "",
 		},
 		{
-			Name: "test-register-failed-6",
-			User: model.User{
-				Email:       "test@gmail.com",
-				Password:    "test",
-				FullName:    "test",
-				Address:     "test",
-				PhoneNumber: "test",
-				Role:        "",
+			Name:          "test-profile-failed-1",
+			Profile:       model.UserProfileUpdate{},
+			ExpectedCodes: ":required",
+		},
+		{
+			Name: "test-profile-failed-2",
+			Profile: model.UserProfileUpdate{
+				PhoneNumber: value(" "),
 			},
-			FormType:          "register",
-			ExpectedIsValid:   false,
-			ExpectedErrString: "role empty/not found",
+			ExpectedCodes: "phone_number:required",
 		},
 		{
-			Name: "test-login-success",
-			User: model.User{
-				Email:       "test@gmail.com",
-				Password:    "test",
-				FullName:    "",
-				Address:     "",
-				PhoneNumber: "",
-				Role:        "",
+			Name: "test-profile-failed-3",
+			Profile: model.UserProfileUpdate{
+				FullName:    value(strings.Repeat("a", 51)),
+				PhoneNumber: value("0811-abc"),
 			},
-			FormType:          "login",
-			ExpectedIsValid:   true,
+			ExpectedCodes: "full_name:too_long,phone_number:invalid",
+		},
+	}
+
+	// loop test in test table
+	for _, test := range testTable {
+		codes := fieldErrorCodes(ValidateUserProfileForm(test.Profile, "ID"))
+		if test.ExpectedCodes != codes {
+			t.Errorf("Expected errors '" + test.ExpectedCodes + "' got '" + codes +
+				"' (" + test.Name + ")")
+		}
+	}
+}
+
+// TestValidateNewPassword test ValidateNewPassword
+func TestValidateNewPassword(t *testing.T) {
+	// initialize testing table
+	testTable := []struct {
+		Password          string
+		ExpectedErrString string
+	}{
+		{
+			Password:          "newpassword1",
 			ExpectedErrString: "",
 		},
 		{
-			Name: "test-login-failed-1",
-			User: model.User{
-				Email:       "",
-				Password:    "test",
-				FullName:    "",
-				Address:     "",
-				PhoneNumber: "",
-				Role:        "",
-			},
-			FormType:          "login",
-			ExpectedIsValid:   false,
-			ExpectedErrString: "email empty/not found",
+			Password:          "",
+			ExpectedErrString: "new_password empty/not found",
 		},
 		{
-			Name: "test-login-failed-2",
-			User: model.User{
-				Email:       "test@gmail.com",
-				Password:    "",
-				FullName:    "",
-				Address:     "",
-				PhoneNumber: "",
-				Role:        "",
-			},
-			FormType:          "login",
-			ExpectedIsValid:   false,
-			ExpectedErrString: "password empty/not found",
+			Password:          "short1",
+			ExpectedErrString: "new_password too short (minimum 8 characters)",
+		},
+		{
+			Password:          strings.Repeat("a1", 37),
+			ExpectedErrString: "new_password too long (maximum 72 bytes)",
+		},
+		{
+			Password:          "newpassword",
+			ExpectedErrString: "new_password must contain letter and digit",
+		},
+		{
+			Password:          "12345678",
+			ExpectedErrString: "new_password must contain letter and digit",
 		},
 	}
 
 	// loop test in test table
 	for _, test := range testTable {
-		isValid, errString := IsUserFormValid(test.User, test.FormType)
-		if test.ExpectedIsValid && !isValid {
-			t.Errorf("Expected form Valid got Invalid")
-		} else if !test.ExpectedIsValid && isValid {
-			t.Errorf("Expected form Invalid got Valid")
+		errString := ValidateNewPassword("new_password", test.Password, 8).Error()
+		if test.ExpectedErrString != errString {
+			t.Errorf("Expected error '" + test.ExpectedErrString + "' got '" + errString + "'")
 		}
+	}
+}
+
+// TestValidateNewEmail test ValidateNewEmail
+func TestValidateNewEmail(t *testing.T) {
+	// initialize testing table
+	testTable := []struct {
+		Email             string
+		ExpectedErrString string
+	}{
+		{
+			Email:             "new@gmail.com",
+			ExpectedErrString: "",
+		},
+		{
+			Email:             "",
+			ExpectedErrString: "new_email empty/not found",
+		},
+		{
+			Email:             strings.Repeat("a", 41) + "@gmail.com",
+			ExpectedErrString: "new_email too long (maximum 50 characters)",
+		},
+		{
+			Email:             "new.gmail.com",
+			ExpectedErrString: "new_email not valid",
+		},
+		{
+			Email:             "New <new@gmail.com>",
+			ExpectedErrString: "new_email not valid",
+		},
+	}
 
+	// loop test in test table
+	for _, test := range testTable {
+		errString := ValidateNewEmail(test.Email).Error()
 		if test.ExpectedErrString != errString {
 			t.Errorf("Expected error '" + test.ExpectedErrString + "' got '" + errString + "'")
 		}
@@ -0,0 +1,154 @@
+/*
+Package form collection of form validation
+*/
+package form
+
+import (
+	"net/mail"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/reyhanfikridz/ecom-account-service/internal/phone"
+)
+
+// validation error codes, stable so client can handle the error
+// without matching the message
+const (
+	CodeRequired = "required"
+	CodeTooShort = "too_short"
+	CodeTooLong  = "too_long"
+	CodeInvalid  = "invalid"
+	CodeWeak     = "weak"
+	CodeReused   = "reused"
+)
+
+// FieldError validation error of a form field, error of the whole form
+// (not a single field) has empty field
+type FieldError struct {
+	Field   string         `json:"field"`
+	Code    string         `json:"code"`
+	Message string         `json:"message"`
+	Params  map[string]any `json:"params"`
+}
+
+// FieldErrors all validation errors of a form, empty if the form valid
+type FieldErrors []FieldError
+
+// Error join all validation error messages
+func (e FieldErrors) Error() string {
+	messages := []string{}
+	for _, fieldError := range e {
+		messages = append(messages, fieldError.Message)
+	}
+
+	return strings.Join(messages, ", ")
+}
+
+// Validator collect validation errors of a form, only the first error
+// of each field collected so a rule skipped if its field already invalid
+type Validator struct {
+	errs FieldErrors
+}
+
+// Errors get collected validation errors
+func (v *Validator) Errors() FieldErrors {
+	return v.errs
+}
+
+// Add add validation error of a field
+func (v *Validator) Add(field string, code string, message string, params map[string]any) {
+	if params == nil {
+		params = map[string]any{}
+	}
+
+	v.errs = append(v.errs, FieldError{
+		Field:   field,
+		Code:    code,
+		Message: message,
+		Params:  params,
+	})
+}
+
+// IsValid check if field has no validation error yet
+func (v *Validator) IsValid(field string) bool {
+	for _, fieldError := range v.errs {
+		if fieldError.Field == field {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Required check if field value not empty
+func (v *Validator) Required(field string, value string) {
+	if v.IsValid(field) && strings.TrimSpace(value) == "" {
+		v.Add(field, CodeRequired, field+" empty/not found", nil)
+	}
+}
+
+// MaxLength check if field value not longer than maximum characters
+// (e.g. the column length)
+func (v *Validator) MaxLength(field string, value string, max int) {
+	if v.IsValid(field) && utf8.RuneCountInString(value) > max {
+		v.Add(field, CodeTooLong,
+			field+" too long (maximum "+strconv.Itoa(max)+" characters)",
+			map[string]any{"max": max})
+	}
+}
+
+// Email check if field value is a bare email address
+// (e.g. not "Name <user@domain>")
+func (v *Validator) Email(field string, value string) {
+	if !v.IsValid(field) || value == "" {
+		return
+	}
+
+	address, err := mail.ParseAddress(value)
+	if err != nil || address.Address != value {
+		v.Add(field, CodeInvalid, field+" not valid", nil)
+	}
+}
+
+// PhoneNumber check if field value can be normalized to E.164,
+// national number parsed by the region
+func (v *Validator) PhoneNumber(field string, value string, region string) {
+	if !v.IsValid(field) || strings.TrimSpace(value) == "" {
+		return
+	}
+
+	_, err := phone.Normalize(value, region)
+	if err != nil {
+		v.Add(field, CodeInvalid, field+" not valid", map[string]any{"region": region})
+	}
+}
+
+// Password check if field value follows the password policy
+// (minimum length, maximum 72 bytes, contains letter and digit)
+func (v *Validator) Password(field string, value string, minLength int) {
+	if !v.IsValid(field) || value == "" {
+		return
+	}
+
+	if utf8.RuneCountInString(value) < minLength {
+		v.Add(field, CodeTooShort,
+			field+" too short (minimum "+strconv.Itoa(minLength)+" characters)",
+			map[string]any{"min": minLength})
+		return
+	}
+
+	// bcrypt only use the first 72 bytes of password
+	if len(value) > 72 {
+		v.Add(field, CodeTooLong, field+" too long (maximum 72 bytes)",
+			map[string]any{"max_bytes": 72})
+		return
+	}
+
+	if strings.IndexFunc(value, unicode.IsLetter) < 0 ||
+		strings.IndexFunc(value, unicode.IsDigit) < 0 {
+		v.Add(field, CodeWeak, field+" must contain letter and digit",
+			map[string]any{"requires": []string{"letter", "digit"}})
+	}
+}
@@ -0,0 +1,73 @@
+/*
+Package form collection of form validation
+*/
+package form
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// fieldErrorCodes get "field:code" of validation errors joined by comma,
+// so expected validation errors can be written in test table
+func fieldErrorCodes(errs FieldErrors) string {
+	codes := []string{}
+	for _, fieldError := range errs {
+		codes = append(codes, fieldError.Field+":"+fieldError.Code)
+	}
+
+	return strings.Join(codes, ",")
+}
+
+// TestValidator test Validator collect the first error of each field
+func TestValidator(t *testing.T) {
+	v := Validator{}
+	v.Required("email", "")
+	v.MaxLength("email", "", 50)
+	v.Email("email", "")
+	v.Required("full_name", "test")
+	v.MaxLength("full_name", "test", 2)
+	v.Required("password", "short1")
+	v.Password("password", "short1", 8)
+
+	expectedCodes := "email:required,full_name:too_long,password:too_short"
+	if codes := fieldErrorCodes(v.Errors()); codes != expectedCodes {
+		t.Errorf("Expected errors '" + expectedCodes + "' got '" + codes + "'")
+	}
+
+	if v.IsValid("email") {
+		t.Errorf("Expected email Invalid got Valid")
+	}
+	if !v.IsValid("role") {
+		t.Errorf("Expected role Valid got Invalid")
+	}
+
+	expectedErrString := "email empty/not found, " +
+		"full_name too long (maximum 2 characters), " +
+		"password too short (minimum 8 characters)"
+	if v.Errors().Error() != expectedErrString {
+		t.Errorf("Expected error '" + expectedErrString + "' got '" + v.Errors().Error() + "'")
+	}
+}
+
+// TestFieldErrorJSON test FieldError JSON schema
+func TestFieldErrorJSON(t *testing.T) {
+	v := Validator{}
+	v.MaxLength("full_name", "test", 2)
+	v.Required("role", "")
+
+	b, err := json.Marshal(v.Errors())
+	if err != nil {
+		t.Fatalf("Expected error nil, but got error " + err.Error())
+	}
+
+	expectedJSON := `[` +
+		`{"field":"full_name","code":"too_long",` +
+		`"message":"full_name too long (maximum 2 characters)","params":{"max":2}},` +
+		`{"field":"role","code":"required","message":"role empty/not found","params":{}}` +
+		`]`
+	if string(b) != expectedJSON {
+		t.Errorf("Expected JSON '" + expectedJSON + "' got '" + string(b) + "'")
+	}
+}
@@ -0,0 +1,54 @@
+/*
+Package mailer collection of email sender
+*/
+package mailer
+
+import (
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+)
+
+// Mailer email sender
+type Mailer interface {
+	Send(to string, subject string, body string) error
+}
+
+// SMTPMailer email sender through SMTP server
+type SMTPMailer struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+// Send send plain text email through SMTP server
+func (m SMTPMailer) Send(to string, subject string, body string) error {
+	// only authenticate if username configured
+	var auth smtp.Auth
+	if m.Username != "" {
+		auth = smtp.PlainAuth("", m.Username, m.Password, m.Host)
+	}
+
+	return smtp.SendMail(net.JoinHostPort(m.Host, m.Port), auth,
+		m.From, []string{to}, BuildMessage(m.From, to, subject, body))
+}
+
+// BuildMessage build plain text email message with its headers
+func BuildMessage(from string, to string, subject string, body string) []byte {
+	// header value must not contain new line (header injection)
+	replacer := strings.NewReplacer("\r", "", "\n", "")
+
+	message := fmt.Sprintf("From: %s\r\n"+
+		"To: %s\r\n"+
+		"Subject: %s\r\n"+
+		"MIME-Version: 1.0\r\n"+
+		"Content-Type: text/plain; charset=\"utf-8\"\r\n"+
+		"\r\n"+
+		"%s\r\n",
+		replacer.Replace(from), replacer.Replace(to), replacer.Replace(subject), body)
+
+	return []byte(message)
+}
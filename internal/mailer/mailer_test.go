@@ -0,0 +1,28 @@
+/*
+Package mailer collection of email sender
+*/
+package mailer
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestBuildMessage test BuildMessage
+func TestBuildMessage(t *testing.T) {
+	message := string(BuildMessage("noreply@gmail.com", "test@gmail.com",
+		"Reset password\r\nBcc: attacker@gmail.com", "This is body"))
+
+	// check result
+	if !strings.HasPrefix(message, "From: noreply@gmail.com\r\nTo: test@gmail.com\r\n") {
+		t.Errorf("Expected message started with From and To header, but got '%s'", message)
+	}
+
+	if strings.Contains(message, "\r\nBcc:") {
+		t.Errorf("Expected no injected header, but got '%s'", message)
+	}
+
+	if !strings.HasSuffix(message, "\r\n\r\nThis is body\r\n") {
+		t.Errorf("Expected message ended with body, but got '%s'", message)
+	}
+}
@@ -0,0 +1,49 @@
+/*
+Package mailer collection of email sender
+*/
+package mailer
+
+import "sync"
+
+// Message email message sent by memory mailer
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// MemoryMailer email sender that keep all messages in memory
+// instead of sending it, used for testing
+type MemoryMailer struct {
+	mu       sync.Mutex
+	Messages []Message
+}
+
+// Send save email message in memory
+func (m *MemoryMailer) Send(to string, subject string, body string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.Messages = append(m.Messages, Message{
+		To:      to,
+		Subject: subject,
+		Body:    body,
+	})
+
+	return nil
+}
+
+// LastMessage get last email message sent to an email address,
+// return false if there's no message sent to the address
+func (m *MemoryMailer) LastMessage(to string) (Message, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i := len(m.Messages) - 1; i >= 0; i-- {
+		if m.Messages[i].To == to {
+			return m.Messages[i], true
+		}
+	}
+
+	return Message{}, false
+}
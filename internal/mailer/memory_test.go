@@ -0,0 +1,37 @@
+/*
+Package mailer collection of email sender
+*/
+package mailer
+
+import (
+	"testing"
+)
+
+// TestMemoryMailerSendAndLastMessage integration test
+// MemoryMailer Send and LastMessage
+func TestMemoryMailerSendAndLastMessage(t *testing.T) {
+	m := &MemoryMailer{}
+
+	// send messages
+	for _, body := range []string{"first", "second"} {
+		err := m.Send("test@gmail.com", "subject", body)
+		if err != nil {
+			t.Errorf("Expected error nil, but got not nil => " + err.Error())
+		}
+	}
+
+	// check result
+	message, ok := m.LastMessage("test@gmail.com")
+	if !ok {
+		t.Errorf("Expected message exist, but not exist")
+	}
+
+	if message.Body != "second" {
+		t.Errorf("Expected last message body 'second', but got '%s'", message.Body)
+	}
+
+	_, ok = m.LastMessage("another@gmail.com")
+	if ok {
+		t.Errorf("Expected message not exist, but exist")
+	}
+}
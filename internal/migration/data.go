@@ -0,0 +1,75 @@
+/*
+Package migration versioned database schema migration,
+migrations of each database driver embedded in the binary
+*/
+package migration
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/reyhanfikridz/ecom-account-service/internal/config"
+	"github.com/reyhanfikridz/ecom-account-service/internal/phone"
+)
+
+// data migrations by version, run after the migration query of every driver
+var dataMigrations = map[int]DataMigration{
+	18: normalizePhoneNumbers,
+}
+
+// normalizePhoneNumbers normalize phone number of users saved before
+// normalization to E.164 format, national number parsed by default phone region
+//
+// Changed phone number need to be verified again. Phone number that can't be
+// normalized kept as is and flagged, until the user update the phone number.
+func normalizePhoneNumbers(ctx context.Context, tx *sql.Tx, c config.Config) error {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT id, phone_number FROM account_user
+			WHERE phone_number <> ''
+		`)
+	if err != nil {
+		return err
+	}
+
+	phoneNumbers := map[int]string{}
+	for rows.Next() {
+		var userID int
+		var phoneNumber string
+		err = rows.Scan(&userID, &phoneNumber)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+
+		phoneNumbers[userID] = phoneNumber
+	}
+	rows.Close()
+	if rows.Err() != nil {
+		return rows.Err()
+	}
+
+	now := time.Now().UTC()
+	for userID, phoneNumber := range phoneNumbers {
+		normalizedPhoneNumber, err := phone.Normalize(phoneNumber, c.PhoneDefaultRegion)
+		if err != nil { // if phone number can't be normalized
+			_, err = tx.ExecContext(ctx, `
+				UPDATE account_user
+					SET is_phone_number_invalid = TRUE
+					WHERE id = $1
+				`, userID)
+		} else if normalizedPhoneNumber != phoneNumber { // if not normalized yet
+			_, err = tx.ExecContext(ctx, `
+				UPDATE account_user
+					SET phone_number = $1, phone_verified_at = NULL,
+						version = version + 1, updated_at = $2
+					WHERE id = $3
+				`, normalizedPhoneNumber, now, userID)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,149 @@
+/*
+Package migration versioned database schema migration,
+migrations of each database driver embedded in the binary
+*/
+package migration
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/reyhanfikridz/ecom-account-service/internal/config"
+)
+
+// TestWithDataMigrations test set data migration of migrations by version
+func TestWithDataMigrations(t *testing.T) {
+	dataMigration := func(ctx context.Context, tx *sql.Tx, c config.Config) error {
+		return nil
+	}
+
+	testTable := []struct {
+		DataMigrations map[int]DataMigration
+		IsErrExpected  bool
+	}{
+		{
+			DataMigrations: map[int]DataMigration{2: dataMigration},
+			IsErrExpected:  false,
+		},
+		{
+			DataMigrations: map[int]DataMigration{3: dataMigration},
+			IsErrExpected:  true,
+		},
+	}
+
+	for _, test := range testTable {
+		migrations, err := withDataMigrations([]Migration{
+			{Version: 1, Name: "first"},
+			{Version: 2, Name: "second"},
+		}, test.DataMigrations)
+		if test.IsErrExpected {
+			if err == nil {
+				t.Errorf("Expected error not nil, but got nil")
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("Expected error nil, but got not nil => " + err.Error())
+		} else if migrations[0].UpData != nil || migrations[1].UpData == nil {
+			t.Errorf("Expected only migration 2 has data migration")
+		}
+	}
+}
+
+// TestNormalizePhoneNumbers test normalize phone number of users
+// on migrated testing database, rolled back after testing
+func TestNormalizePhoneNumbers(t *testing.T) {
+	DB, err := getTestDBConnection()
+	if err != nil {
+		t.Fatalf("There's an error when connect to testing database => " + err.Error())
+	}
+	defer DB.Close()
+
+	m, err := New(DB, testConfig.DBDriver, testConfig)
+	if err != nil {
+		t.Fatalf("There's an error when creating migrator => " + err.Error())
+	}
+	_, err = m.Up()
+	if err != nil {
+		t.Fatalf("There's an error when migrating testing database => " + err.Error())
+	}
+
+	tx, err := DB.Begin()
+	if err != nil {
+		t.Fatalf("There's an error when begin transaction => " + err.Error())
+	}
+	defer tx.Rollback() // testing users not saved
+
+	c := testConfig
+	c.PhoneDefaultRegion = "ID"
+
+	testTable := []struct {
+		Email               string
+		PhoneNumber         string
+		ExpectedPhoneNumber string
+		ExpectedVersion     int
+		IsInvalidExpected   bool
+	}{
+		{
+			Email:               "testmigrationphone1@gmail.com",
+			PhoneNumber:         "0811-1111-1111",
+			ExpectedPhoneNumber: "+6281111111111",
+			ExpectedVersion:     2,
+		},
+		{
+			Email:               "testmigrationphone2@gmail.com",
+			PhoneNumber:         "+6281222222222",
+			ExpectedPhoneNumber: "+6281222222222",
+			ExpectedVersion:     1,
+		},
+		{
+			Email:               "testmigrationphone3@gmail.com",
+			PhoneNumber:         "not a number",
+			ExpectedPhoneNumber: "not a number",
+			ExpectedVersion:     1,
+			IsInvalidExpected:   true,
+		},
+	}
+
+	for _, test := range testTable {
+		_, err = tx.Exec(`
+			INSERT INTO account_user(email, password, full_name, address, phone_number, role)
+				VALUES($1, 'test', 'test', '', $2, 'buyer')
+			`, test.Email, test.PhoneNumber)
+		if err != nil {
+			t.Fatalf("There's an error when creating testing user => " + err.Error())
+		}
+	}
+
+	err = normalizePhoneNumbers(context.Background(), tx, c)
+	if err != nil {
+		t.Fatalf("Expected error nil, but got not nil => " + err.Error())
+	}
+
+	for _, test := range testTable {
+		var phoneNumber string
+		var version int
+		var isInvalid bool
+		err = tx.QueryRow(`
+			SELECT phone_number, version, is_phone_number_invalid
+				FROM account_user WHERE email = $1
+			`, test.Email).Scan(&phoneNumber, &version, &isInvalid)
+		if err != nil {
+			t.Fatalf("There's an error when getting testing user => " + err.Error())
+		}
+
+		if phoneNumber != test.ExpectedPhoneNumber {
+			t.Errorf("Expected phone number %q, but got %q", test.ExpectedPhoneNumber,
+				phoneNumber)
+		}
+		if version != test.ExpectedVersion {
+			t.Errorf("Expected version %d, but got %d", test.ExpectedVersion, version)
+		}
+		if isInvalid != test.IsInvalidExpected {
+			t.Errorf("Expected phone number invalid %t, but got %t", test.IsInvalidExpected,
+				isInvalid)
+		}
+	}
+}
@@ -0,0 +1,451 @@
+/*
+Package migration versioned database schema migration,
+migrations of each database driver embedded in the binary
+*/
+package migration
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/reyhanfikridz/ecom-account-service/internal/config"
+)
+
+// migration files of each database driver in directory named as the driver,
+// named "<version>_<name>.up.sql" and "<version>_<name>.down.sql"
+//
+//go:embed postgres/*.sql sqlite/*.sql
+var migrationFiles embed.FS
+
+// ErrSchemaTooNew database schema migrated by newer version of the service
+var ErrSchemaTooNew = errors.New("database schema newer than the service, " +
+	"please upgrade the service")
+
+// Postgres advisory lock key of migration,
+// so only one service replica migrate at a time
+const lockKey = 8010
+
+// migration file name, e.g. "0001_initial.up.sql"
+var fileNameRegexp = regexp.MustCompile(`^([0-9]+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// Migration a version of database schema
+type Migration struct {
+	Version int
+	Name    string
+
+	// query migrate schema to this version
+	Up string
+
+	// query roll back schema to the previous version
+	Down string
+
+	// data migration run after Up query in the same transaction,
+	// for change that can't be written in SQL (e.g. parsed by Go package)
+	UpData DataMigration
+}
+
+// DataMigration migrate data of database in migration transaction
+type DataMigration func(ctx context.Context, tx *sql.Tx, c config.Config) error
+
+// Status status of a migration, AppliedAt nil if not applied yet.
+// Migration applied by newer version of the service isn't known
+// (empty name)
+type Status struct {
+	Version   int
+	Name      string
+	AppliedAt *time.Time
+	IsKnown   bool
+}
+
+// Migrator apply and roll back migrations of a database,
+// config used by data migrations (e.g. default phone region)
+type Migrator struct {
+	DB     *sql.DB
+	Driver string
+	Config config.Config
+
+	// migrations sorted by version
+	Migrations []Migration
+
+	// table of applied migrations
+	table string
+}
+
+// New create migrator of database with embedded migrations of the driver
+func New(DB *sql.DB, driver string, c config.Config) (*Migrator, error) {
+	migrations, err := Load(driver)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{
+		DB:         DB,
+		Driver:     driver,
+		Config:     c,
+		Migrations: migrations,
+		table:      "schema_migrations",
+	}, nil
+}
+
+// Load load embedded migrations of database driver sorted by version
+func Load(driver string) ([]Migration, error) {
+	if driver != config.DBDriverPostgres && driver != config.DBDriverSQLite {
+		return nil, fmt.Errorf("database driver %q not supported", driver)
+	}
+
+	driverFiles, err := fs.Sub(migrationFiles, driver)
+	if err != nil {
+		return nil, err
+	}
+
+	migrations, err := parse(driverFiles)
+	if err != nil {
+		return nil, err
+	}
+
+	return withDataMigrations(migrations, dataMigrations)
+}
+
+// withDataMigrations set data migration of migrations by version,
+// every data migration must have its migration files
+func withDataMigrations(migrations []Migration, dataMigrations map[int]DataMigration) (
+	[]Migration, error) {
+	for version, dataMigration := range dataMigrations {
+		isFound := false
+		for i := range migrations {
+			if migrations[i].Version == version {
+				migrations[i].UpData = dataMigration
+				isFound = true
+			}
+		}
+		if !isFound {
+			return nil, fmt.Errorf("data migration version %d has no migration file",
+				version)
+		}
+	}
+
+	return migrations, nil
+}
+
+// parse parse migration files, every migration must have up and down file
+func parse(files fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(files, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	migrationsByVersion := map[int]*Migration{}
+	for _, entry := range entries {
+		match := fileNameRegexp.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			return nil, fmt.Errorf("migration file name %q not valid", entry.Name())
+		}
+
+		version, err := strconv.Atoi(match[1])
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("migration file name %q not valid", entry.Name())
+		}
+
+		query, err := fs.ReadFile(files, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := migrationsByVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			migrationsByVersion[version] = m
+		} else if m.Name != match[2] {
+			return nil, fmt.Errorf("migration version %d has more than one name", version)
+		}
+
+		if match[3] == "up" {
+			m.Up = string(query)
+		} else {
+			m.Down = string(query)
+		}
+	}
+
+	migrations := []Migration{}
+	for _, m := range migrationsByVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration version %d must have up and down file",
+				m.Version)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// LatestVersion get version of the latest known migration, 0 if no migration
+func (m *Migrator) LatestVersion() int {
+	if len(m.Migrations) == 0 {
+		return 0
+	}
+
+	return m.Migrations[len(m.Migrations)-1].Version
+}
+
+// Up apply all pending migrations, return applied migrations
+//
+// Return ErrSchemaTooNew if database has migration newer than
+// the latest known migration, nothing applied
+func (m *Migrator) Up() ([]Migration, error) {
+	applied := []Migration{}
+	err := m.withLock(func(ctx context.Context, conn *sql.Conn) error {
+		appliedAt, err := m.getAppliedAt(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for version := range appliedAt {
+			if version > m.LatestVersion() {
+				return ErrSchemaTooNew
+			}
+		}
+
+		for _, migration := range m.Migrations {
+			if _, ok := appliedAt[migration.Version]; ok {
+				continue
+			}
+
+			isApplied, err := m.apply(ctx, conn, migration, true)
+			if err != nil {
+				return fmt.Errorf("migration %d_%s up failed => %w",
+					migration.Version, migration.Name, err)
+			}
+			if isApplied {
+				applied = append(applied, migration)
+			}
+		}
+
+		return nil
+	})
+
+	return applied, err
+}
+
+// Down roll back the latest applied migrations as many as steps,
+// return rolled back migrations
+//
+// Return ErrSchemaTooNew if a migration need to be rolled back
+// is not known, nothing rolled back
+func (m *Migrator) Down(steps int) ([]Migration, error) {
+	rolledBack := []Migration{}
+	err := m.withLock(func(ctx context.Context, conn *sql.Conn) error {
+		appliedAt, err := m.getAppliedAt(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		// get the latest applied versions
+		versions := []int{}
+		for version := range appliedAt {
+			versions = append(versions, version)
+		}
+		sort.Sort(sort.Reverse(sort.IntSlice(versions)))
+		if steps < len(versions) {
+			versions = versions[:steps]
+		}
+
+		migrations := []Migration{}
+		for _, version := range versions {
+			migration, ok := m.getMigration(version)
+			if !ok {
+				return ErrSchemaTooNew
+			}
+			migrations = append(migrations, migration)
+		}
+
+		for _, migration := range migrations {
+			isRolledBack, err := m.apply(ctx, conn, migration, false)
+			if err != nil {
+				return fmt.Errorf("migration %d_%s down failed => %w",
+					migration.Version, migration.Name, err)
+			}
+			if isRolledBack {
+				rolledBack = append(rolledBack, migration)
+			}
+		}
+
+		return nil
+	})
+
+	return rolledBack, err
+}
+
+// Status get status of known and applied migrations sorted by version
+func (m *Migrator) Status() ([]Status, error) {
+	statuses := []Status{}
+	err := m.withLock(func(ctx context.Context, conn *sql.Conn) error {
+		appliedAt, err := m.getAppliedAt(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range m.Migrations {
+			s := Status{Version: migration.Version, Name: migration.Name, IsKnown: true}
+			if t, ok := appliedAt[migration.Version]; ok {
+				s.AppliedAt = &t
+				delete(appliedAt, migration.Version)
+			}
+			statuses = append(statuses, s)
+		}
+
+		// migrations applied by newer version of the service
+		for version, t := range appliedAt {
+			t := t
+			statuses = append(statuses, Status{Version: version, AppliedAt: &t})
+		}
+
+		return nil
+	})
+
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Version < statuses[j].Version
+	})
+
+	return statuses, err
+}
+
+// withLock run function with database connection holding migration lock,
+// table of applied migrations created if not exist
+//
+// SQLite has no advisory lock, but every transaction lock the database file
+// and migration skipped if already applied by the other process
+func (m *Migrator) withLock(f func(ctx context.Context, conn *sql.Conn) error) error {
+	ctx := context.Background()
+	conn, err := m.DB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	// lock held by the connection session, released when the session ends
+	if m.Driver == config.DBDriverPostgres {
+		_, err = conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, lockKey)
+		if err != nil {
+			return err
+		}
+		defer conn.ExecContext(ctx, `SELECT pg_advisory_unlock($1)`, lockKey)
+	}
+
+	_, err = conn.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS `+m.table+`
+		(
+			version BIGINT PRIMARY KEY NOT NULL,
+			name VARCHAR(100) NOT NULL,
+			applied_at TIMESTAMP NOT NULL
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	return f(ctx, conn)
+}
+
+// getAppliedAt get applied time of applied migrations by version
+func (m *Migrator) getAppliedAt(ctx context.Context, conn *sql.Conn) (
+	map[int]time.Time, error) {
+	appliedAt := map[int]time.Time{}
+	rows, err := conn.QueryContext(ctx, `SELECT version, applied_at FROM `+m.table)
+	if err != nil {
+		return appliedAt, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var version int
+		var t time.Time
+		err = rows.Scan(&version, &t)
+		if err != nil {
+			return appliedAt, err
+		}
+		appliedAt[version] = t
+	}
+
+	return appliedAt, rows.Err()
+}
+
+// getMigration get known migration by version
+func (m *Migrator) getMigration(version int) (Migration, bool) {
+	for _, migration := range m.Migrations {
+		if migration.Version == version {
+			return migration, true
+		}
+	}
+
+	return Migration{}, false
+}
+
+// apply apply (up) or roll back (down) a migration in a transaction,
+// return false if already applied or rolled back by the other process
+func (m *Migrator) apply(ctx context.Context, conn *sql.Conn, migration Migration,
+	isUp bool) (bool, error) {
+	//////////////////// begin transaction /////////////////////
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback() // rollback transaction if fail (return before commit)
+
+	var count int
+	err = tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM `+m.table+` WHERE version = $1`,
+		migration.Version).Scan(&count)
+	if err != nil {
+		return false, err
+	}
+	if (isUp && count > 0) || (!isUp && count == 0) {
+		return false, nil
+	}
+
+	if isUp {
+		_, err = tx.ExecContext(ctx, migration.Up)
+		if err != nil {
+			return false, err
+		}
+
+		if migration.UpData != nil {
+			err = migration.UpData(ctx, tx, m.Config)
+			if err != nil {
+				return false, err
+			}
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO `+m.table+`(version, name, applied_at) VALUES($1, $2, $3)
+			`, migration.Version, migration.Name, time.Now().UTC())
+	} else {
+		_, err = tx.ExecContext(ctx, migration.Down)
+		if err != nil {
+			return false, err
+		}
+
+		_, err = tx.ExecContext(ctx, `DELETE FROM `+m.table+` WHERE version = $1`,
+			migration.Version)
+	}
+	if err != nil {
+		return false, err
+	}
+
+	// commit transaction
+	err = tx.Commit()
+	if err != nil {
+		return false, err
+	}
+	////////////////////////////////////////////////////////////
+
+	return true, nil
+}
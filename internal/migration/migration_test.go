@@ -0,0 +1,273 @@
+/*
+Package migration versioned database schema migration,
+migrations of each database driver embedded in the binary
+*/
+package migration
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	_ "github.com/lib/pq"
+	"github.com/reyhanfikridz/ecom-account-service/internal/config"
+	"github.com/reyhanfikridz/ecom-account-service/internal/sqlite"
+)
+
+// config of all testing in the package
+var testConfig config.Config
+
+// TestMain do some test before and after all testing in the package
+func TestMain(m *testing.M) {
+	// load config before can be used
+	var err error
+	testConfig, _, err = config.Load(nil)
+	if err != nil {
+		log.Fatalf("There's an error when initialize config => %s", err)
+	}
+
+	m.Run()
+}
+
+// TestParse test parse
+func TestParse(t *testing.T) {
+	// initialize testing table
+	testTable := []struct {
+		Files            fstest.MapFS
+		ExpectedVersions []int
+		IsErrorExpected  bool
+	}{
+		{
+			Files: fstest.MapFS{
+				"0002_second.up.sql":   {Data: []byte("up 2")},
+				"0002_second.down.sql": {Data: []byte("down 2")},
+				"0001_first.up.sql":    {Data: []byte("up 1")},
+				"0001_first.down.sql":  {Data: []byte("down 1")},
+				"0010_tenth.up.sql":    {Data: []byte("up 10")},
+				"0010_tenth.down.sql":  {Data: []byte("down 10")},
+			},
+			ExpectedVersions: []int{1, 2, 10},
+		},
+		{
+			Files:            fstest.MapFS{},
+			ExpectedVersions: []int{},
+		},
+		{
+			Files: fstest.MapFS{
+				"0001_first.up.sql": {Data: []byte("up 1")},
+			},
+			IsErrorExpected: true,
+		},
+		{
+			Files: fstest.MapFS{
+				"0001_first.up.sql":    {Data: []byte("up 1")},
+				"0001_second.down.sql": {Data: []byte("down 1")},
+			},
+			IsErrorExpected: true,
+		},
+		{
+			Files: fstest.MapFS{
+				"0000_zero.up.sql":   {Data: []byte("up 0")},
+				"0000_zero.down.sql": {Data: []byte("down 0")},
+			},
+			IsErrorExpected: true,
+		},
+		{
+			Files: fstest.MapFS{
+				"first.up.sql": {Data: []byte("up 1")},
+			},
+			IsErrorExpected: true,
+		},
+	}
+
+	// test
+	for _, test := range testTable {
+		migrations, err := parse(test.Files)
+		if test.IsErrorExpected {
+			if err == nil {
+				t.Errorf("Expected error, but got nil")
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Expected error nil, but got not nil => " + err.Error())
+			continue
+		}
+
+		versions := []int{}
+		for _, m := range migrations {
+			versions = append(versions, m.Version)
+			if m.Up != fmt.Sprintf("up %d", m.Version) ||
+				m.Down != fmt.Sprintf("down %d", m.Version) {
+				t.Errorf("Expected up and down of version %d, but got %q and %q",
+					m.Version, m.Up, m.Down)
+			}
+		}
+		if fmt.Sprint(versions) != fmt.Sprint(test.ExpectedVersions) {
+			t.Errorf("Expected versions %v, but got %v", test.ExpectedVersions, versions)
+		}
+	}
+}
+
+// TestLoad test Load embedded migrations,
+// every driver must have the same migration versions
+func TestLoad(t *testing.T) {
+	postgresMigrations, err := Load(config.DBDriverPostgres)
+	if err != nil {
+		t.Fatalf("Expected error nil, but got not nil => " + err.Error())
+	}
+	sqliteMigrations, err := Load(config.DBDriverSQLite)
+	if err != nil {
+		t.Fatalf("Expected error nil, but got not nil => " + err.Error())
+	}
+
+	if len(postgresMigrations) == 0 {
+		t.Errorf("Expected migrations not empty, but got empty")
+	}
+	if len(postgresMigrations) != len(sqliteMigrations) {
+		t.Fatalf("Expected %d SQLite migrations, but got %d",
+			len(postgresMigrations), len(sqliteMigrations))
+	}
+	for i, m := range postgresMigrations {
+		if sqliteMigrations[i].Version != m.Version || sqliteMigrations[i].Name != m.Name {
+			t.Errorf("Expected SQLite migration %04d_%s, but got %04d_%s",
+				m.Version, m.Name, sqliteMigrations[i].Version, sqliteMigrations[i].Name)
+		}
+	}
+
+	// initial migration adopt existing database, rolling it back
+	// must not drop the tables
+	for _, migrations := range [][]Migration{postgresMigrations, sqliteMigrations} {
+		if strings.Contains(strings.ToUpper(migrations[0].Down), "DROP TABLE") {
+			t.Errorf("Expected initial migration down not drop tables, but got %q",
+				migrations[0].Down)
+		}
+	}
+
+	_, err = Load("mysql")
+	if err == nil {
+		t.Errorf("Expected error for driver not supported, but got nil")
+	}
+}
+
+// TestMigrator test Migrator Up, Down, and Status
+// with testing migrations and testing migration table
+func TestMigrator(t *testing.T) {
+	DB, err := getTestDBConnection()
+	if err != nil {
+		t.Fatalf("There's an error when connect to testing database => " + err.Error())
+	}
+	defer DB.Close()
+
+	// clear testing tables of previous testing
+	for _, table := range []string{"test_schema_migrations", "test_migration_second",
+		"test_migration_first"} {
+		_, err = DB.Exec(`DROP TABLE IF EXISTS ` + table)
+		if err != nil {
+			t.Fatalf("There's an error when clear testing table => " + err.Error())
+		}
+	}
+
+	m := &Migrator{
+		DB:     DB,
+		Driver: testConfig.DBDriver,
+		Migrations: []Migration{
+			{
+				Version: 1,
+				Name:    "first",
+				Up:      `CREATE TABLE test_migration_first (id INT NOT NULL)`,
+				Down:    `DROP TABLE test_migration_first`,
+			},
+			{
+				Version: 2,
+				Name:    "second",
+				Up:      `CREATE TABLE test_migration_second (id INT NOT NULL)`,
+				Down:    `DROP TABLE test_migration_second`,
+			},
+		},
+		table: "test_schema_migrations",
+	}
+
+	// apply all migrations, then nothing to apply
+	migrations, err := m.Up()
+	if err != nil {
+		t.Fatalf("Expected up error nil, but got not nil => " + err.Error())
+	}
+	if len(migrations) != 2 {
+		t.Errorf("Expected 2 migrations applied, but got %d", len(migrations))
+	}
+
+	migrations, err = m.Up()
+	if err != nil {
+		t.Fatalf("Expected up error nil, but got not nil => " + err.Error())
+	}
+	if len(migrations) != 0 {
+		t.Errorf("Expected 0 migration applied, but got %d", len(migrations))
+	}
+
+	// roll back the latest migration
+	migrations, err = m.Down(1)
+	if err != nil {
+		t.Fatalf("Expected down error nil, but got not nil => " + err.Error())
+	}
+	if len(migrations) != 1 || migrations[0].Version != 2 {
+		t.Errorf("Expected migration 2 rolled back, but got %v", migrations)
+	}
+
+	statuses, err := m.Status()
+	if err != nil {
+		t.Fatalf("Expected status error nil, but got not nil => " + err.Error())
+	}
+	if len(statuses) != 2 || statuses[0].AppliedAt == nil || statuses[1].AppliedAt != nil {
+		t.Errorf("Expected migration 1 applied and 2 pending, but got %v", statuses)
+	}
+
+	// service that only know the first migration refuse newer schema
+	_, err = m.Up()
+	if err != nil {
+		t.Fatalf("Expected up error nil, but got not nil => " + err.Error())
+	}
+	oldM := &Migrator{DB: DB, Driver: m.Driver, Migrations: m.Migrations[:1], table: m.table}
+	_, err = oldM.Up()
+	if err != ErrSchemaTooNew {
+		t.Errorf("Expected up error ErrSchemaTooNew, but got %v", err)
+	}
+	_, err = oldM.Down(1)
+	if err != ErrSchemaTooNew {
+		t.Errorf("Expected down error ErrSchemaTooNew, but got %v", err)
+	}
+
+	statuses, err = oldM.Status()
+	if err != nil {
+		t.Fatalf("Expected status error nil, but got not nil => " + err.Error())
+	}
+	if len(statuses) != 2 || statuses[1].IsKnown {
+		t.Errorf("Expected migration 2 unknown, but got %v", statuses)
+	}
+
+	// roll back all migrations
+	migrations, err = m.Down(10)
+	if err != nil {
+		t.Fatalf("Expected down error nil, but got not nil => " + err.Error())
+	}
+	if len(migrations) != 2 || migrations[0].Version != 2 || migrations[1].Version != 1 {
+		t.Errorf("Expected migration 2 and 1 rolled back, but got %v", migrations)
+	}
+}
+
+// get connection to testing DB
+func getTestDBConnection() (*sql.DB, error) {
+	if testConfig.DBDriver == config.DBDriverSQLite {
+		return sqlite.Open(testConfig.DBTestPath)
+	}
+
+	connString := fmt.Sprintf("host='%s' port='%s' user='%s' password='%s' dbname='%s' "+
+		"sslmode='%s' sslrootcert='%s' sslcert='%s' sslkey='%s'",
+		testConfig.DBHost, testConfig.DBPort, testConfig.DBUsername,
+		testConfig.DBPassword, testConfig.DBTestName, testConfig.DBSSLMode,
+		testConfig.DBSSLRootCert, testConfig.DBSSLCert, testConfig.DBSSLKey)
+	return sql.Open("postgres", connString)
+}
@@ -0,0 +1,3 @@
+-- initial schema isn't rolled back, database created before versioned
+-- migration adopted by this migration, so dropping the tables
+-- would delete all of its users
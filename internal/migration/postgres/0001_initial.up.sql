@@ -0,0 +1,24 @@
+-- initial schema, tables created only if not exist,
+-- so database created before versioned migration adopted as is
+
+CREATE TABLE IF NOT EXISTS account_user
+(
+	id SERIAL PRIMARY KEY NOT NULL,
+	email VARCHAR(50) UNIQUE NOT NULL,
+	password VARCHAR(100) NOT NULL,
+	full_name VARCHAR(50) NOT NULL,
+	address VARCHAR(100) NOT NULL,
+	phone_number VARCHAR(20) NOT NULL,
+	role VARCHAR(20) NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS account_usersession
+(
+	id SERIAL PRIMARY KEY NOT NULL,
+	token VARCHAR(200) UNIQUE NOT NULL,
+	account_user_id INT UNIQUE NOT NULL,
+	CONSTRAINT fk_account_user
+		FOREIGN KEY(account_user_id)
+			REFERENCES account_user(id)
+			ON DELETE CASCADE
+);
@@ -0,0 +1,4 @@
+-- session token column kept as TEXT, existing token may be
+-- longer than the old column
+
+DROP TABLE IF EXISTS account_refreshtoken;
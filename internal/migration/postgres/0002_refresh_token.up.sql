@@ -0,0 +1,17 @@
+-- rotating refresh token of user session,
+-- session token become longer so it can't fit the old column
+
+CREATE TABLE IF NOT EXISTS account_refreshtoken
+(
+	id SERIAL PRIMARY KEY NOT NULL,
+	token_hash VARCHAR(64) UNIQUE NOT NULL,
+	account_usersession_id INT NOT NULL,
+	is_used BOOLEAN NOT NULL DEFAULT FALSE,
+	expired_at TIMESTAMP NOT NULL,
+	CONSTRAINT fk_account_usersession
+		FOREIGN KEY(account_usersession_id)
+			REFERENCES account_usersession(id)
+			ON DELETE CASCADE
+);
+
+ALTER TABLE account_usersession ALTER COLUMN token TYPE TEXT;
@@ -0,0 +1,7 @@
+-- one session per user not restored, user may have many sessions already
+
+ALTER TABLE account_usersession
+	DROP COLUMN IF EXISTS user_agent,
+	DROP COLUMN IF EXISTS ip_address,
+	DROP COLUMN IF EXISTS created_at,
+	DROP COLUMN IF EXISTS last_seen_at;
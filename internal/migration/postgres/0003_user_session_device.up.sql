@@ -0,0 +1,9 @@
+-- multiple sessions of a user with device metadata
+
+ALTER TABLE account_usersession
+	DROP CONSTRAINT IF EXISTS account_usersession_account_user_id_key;
+ALTER TABLE account_usersession
+	ADD COLUMN IF NOT EXISTS user_agent VARCHAR(255) NOT NULL DEFAULT '',
+	ADD COLUMN IF NOT EXISTS ip_address VARCHAR(45) NOT NULL DEFAULT '',
+	ADD COLUMN IF NOT EXISTS created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	ADD COLUMN IF NOT EXISTS last_seen_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP;
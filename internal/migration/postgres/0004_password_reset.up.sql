@@ -0,0 +1,14 @@
+-- one-time password reset token
+
+CREATE TABLE IF NOT EXISTS account_passwordreset
+(
+	id SERIAL PRIMARY KEY NOT NULL,
+	token_hash VARCHAR(64) UNIQUE NOT NULL,
+	account_user_id INT NOT NULL,
+	expired_at TIMESTAMP NOT NULL,
+	used_at TIMESTAMP NULL,
+	CONSTRAINT fk_account_user
+		FOREIGN KEY(account_user_id)
+			REFERENCES account_user(id)
+			ON DELETE CASCADE
+);
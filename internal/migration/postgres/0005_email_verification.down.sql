@@ -0,0 +1,3 @@
+ALTER TABLE account_user
+	DROP COLUMN IF EXISTS email_verified_at,
+	DROP COLUMN IF EXISTS email_verification_sent_at;
@@ -0,0 +1,21 @@
+-- email verification of user
+--
+-- Users registered before email verification treated as verified,
+-- so login policy that requires verified email doesn't lock them out.
+-- Only backfilled when the column added by this migration, not when
+-- the column already added before versioned migration adopted.
+
+DO $$
+BEGIN
+	IF NOT EXISTS (
+		SELECT 1 FROM information_schema.columns
+			WHERE table_schema = current_schema() AND table_name = 'account_user'
+				AND column_name = 'email_verified_at'
+	) THEN
+		ALTER TABLE account_user ADD COLUMN email_verified_at TIMESTAMP NULL;
+		UPDATE account_user SET email_verified_at = NOW() AT TIME ZONE 'UTC';
+	END IF;
+END $$;
+
+ALTER TABLE account_user
+	ADD COLUMN IF NOT EXISTS email_verification_sent_at TIMESTAMP NULL;
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS account_recoverycode;
+ALTER TABLE account_user
+	DROP COLUMN IF EXISTS totp_secret,
+	DROP COLUMN IF EXISTS totp_enabled_at,
+	DROP COLUMN IF EXISTS totp_last_step;
@@ -0,0 +1,18 @@
+-- TOTP two-factor authentication with recovery codes
+
+CREATE TABLE IF NOT EXISTS account_recoverycode
+(
+	id SERIAL PRIMARY KEY NOT NULL,
+	code_hash VARCHAR(64) NOT NULL,
+	account_user_id INT NOT NULL,
+	used_at TIMESTAMP NULL,
+	CONSTRAINT fk_account_user
+		FOREIGN KEY(account_user_id)
+			REFERENCES account_user(id)
+			ON DELETE CASCADE
+);
+
+ALTER TABLE account_user
+	ADD COLUMN IF NOT EXISTS totp_secret VARCHAR(64) NULL,
+	ADD COLUMN IF NOT EXISTS totp_enabled_at TIMESTAMP NULL,
+	ADD COLUMN IF NOT EXISTS totp_last_step BIGINT NOT NULL DEFAULT 0;
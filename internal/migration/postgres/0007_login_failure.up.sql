@@ -0,0 +1,10 @@
+-- failed login of account and IP address for login lockout
+
+CREATE TABLE IF NOT EXISTS account_loginfailure
+(
+	id SERIAL PRIMARY KEY NOT NULL,
+	failure_key VARCHAR(100) UNIQUE NOT NULL,
+	failure_count INT NOT NULL DEFAULT 0,
+	last_failed_at TIMESTAMP NOT NULL,
+	locked_until TIMESTAMP NULL
+);
@@ -0,0 +1,5 @@
+-- user version for optimistic concurrency of profile update
+
+ALTER TABLE account_user
+	ADD COLUMN IF NOT EXISTS version INT NOT NULL DEFAULT 1,
+	ADD COLUMN IF NOT EXISTS updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP;
@@ -0,0 +1,13 @@
+-- previous passwords of user so recent password can't be reused
+
+CREATE TABLE IF NOT EXISTS account_passwordhistory
+(
+	id SERIAL PRIMARY KEY NOT NULL,
+	account_user_id INT NOT NULL,
+	password VARCHAR(100) NOT NULL,
+	created_at TIMESTAMP NOT NULL,
+	CONSTRAINT fk_account_user
+		FOREIGN KEY(account_user_id)
+			REFERENCES account_user(id)
+			ON DELETE CASCADE
+);
@@ -0,0 +1,19 @@
+-- email change with confirm and revert token
+
+CREATE TABLE IF NOT EXISTS account_emailchange
+(
+	id SERIAL PRIMARY KEY NOT NULL,
+	account_user_id INT NOT NULL,
+	old_email VARCHAR(50) NOT NULL,
+	new_email VARCHAR(50) NOT NULL,
+	token_hash VARCHAR(64) UNIQUE NOT NULL,
+	expired_at TIMESTAMP NOT NULL,
+	confirmed_at TIMESTAMP NULL,
+	revert_token_hash VARCHAR(64) UNIQUE NULL,
+	revert_expired_at TIMESTAMP NULL,
+	reverted_at TIMESTAMP NULL,
+	CONSTRAINT fk_account_user
+		FOREIGN KEY(account_user_id)
+			REFERENCES account_user(id)
+			ON DELETE CASCADE
+);
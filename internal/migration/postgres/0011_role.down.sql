@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS account_rolepermission;
+DROP TABLE IF EXISTS account_role;
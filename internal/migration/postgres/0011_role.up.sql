@@ -0,0 +1,43 @@
+-- role catalog with permissions of each role
+
+CREATE TABLE IF NOT EXISTS account_role
+(
+	id SERIAL PRIMARY KEY NOT NULL,
+	name VARCHAR(20) UNIQUE NOT NULL,
+	is_self_registrable BOOLEAN NOT NULL DEFAULT FALSE
+);
+
+CREATE TABLE IF NOT EXISTS account_rolepermission
+(
+	id SERIAL PRIMARY KEY NOT NULL,
+	account_role_id INT NOT NULL,
+	permission VARCHAR(50) NOT NULL,
+	CONSTRAINT uq_account_rolepermission
+		UNIQUE(account_role_id, permission),
+	CONSTRAINT fk_account_role
+		FOREIGN KEY(account_role_id)
+			REFERENCES account_role(id)
+			ON DELETE CASCADE
+);
+
+INSERT INTO account_role(name, is_self_registrable)
+	VALUES ('buyer', TRUE), ('seller', TRUE), ('admin', FALSE)
+	ON CONFLICT (name) DO NOTHING;
+INSERT INTO account_rolepermission(account_role_id, permission)
+	SELECT account_role.id, seed.permission
+		FROM account_role JOIN (VALUES
+			('buyer', 'product:read'),
+			('buyer', 'order:create'),
+			('buyer', 'order:read'),
+			('seller', 'product:read'),
+			('seller', 'product:write'),
+			('seller', 'order:read'),
+			('admin', 'product:read'),
+			('admin', 'product:write'),
+			('admin', 'order:read'),
+			('admin', 'user:read'),
+			('admin', 'user:write'),
+			('admin', 'jwt_key:promote'),
+			('admin', 'login_lockout:clear')
+		) AS seed(role_name, permission) ON account_role.name = seed.role_name
+	ON CONFLICT (account_role_id, permission) DO NOTHING;
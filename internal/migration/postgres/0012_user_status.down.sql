@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_account_user_created_at;
+ALTER TABLE account_user
+	DROP COLUMN IF EXISTS status,
+	DROP COLUMN IF EXISTS created_at;
@@ -0,0 +1,7 @@
+-- user status and creation time for admin user management
+
+ALTER TABLE account_user
+	ADD COLUMN IF NOT EXISTS status VARCHAR(20) NOT NULL DEFAULT 'active',
+	ADD COLUMN IF NOT EXISTS created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP;
+CREATE INDEX IF NOT EXISTS idx_account_user_created_at
+	ON account_user(created_at, id);
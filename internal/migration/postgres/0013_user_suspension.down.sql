@@ -0,0 +1,4 @@
+ALTER TABLE account_user
+	DROP COLUMN IF EXISTS suspended_reason,
+	DROP COLUMN IF EXISTS suspended_until,
+	DROP COLUMN IF EXISTS deleted_at;
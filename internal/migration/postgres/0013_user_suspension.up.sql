@@ -0,0 +1,6 @@
+-- suspension reason and expiry, deletion time of user
+
+ALTER TABLE account_user
+	ADD COLUMN IF NOT EXISTS suspended_reason TEXT NULL,
+	ADD COLUMN IF NOT EXISTS suspended_until TIMESTAMP NULL,
+	ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP NULL;
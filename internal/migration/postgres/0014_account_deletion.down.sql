@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS account_event;
+ALTER TABLE account_user
+	DROP COLUMN IF EXISTS deletion_scheduled_at;
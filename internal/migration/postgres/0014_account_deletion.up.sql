@@ -0,0 +1,13 @@
+-- scheduled account deletion and outbox of account events
+
+CREATE TABLE IF NOT EXISTS account_event
+(
+	id SERIAL PRIMARY KEY NOT NULL,
+	event_type VARCHAR(50) NOT NULL,
+	payload TEXT NOT NULL,
+	created_at TIMESTAMP NOT NULL,
+	published_at TIMESTAMP NULL
+);
+
+ALTER TABLE account_user
+	ADD COLUMN IF NOT EXISTS deletion_scheduled_at TIMESTAMP NULL;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS account_dataexport;
+DROP TABLE IF EXISTS account_loginhistory;
@@ -0,0 +1,32 @@
+-- login history and personal data export job
+
+CREATE TABLE IF NOT EXISTS account_loginhistory
+(
+	id SERIAL PRIMARY KEY NOT NULL,
+	account_user_id INT NOT NULL,
+	user_agent VARCHAR(255) NOT NULL DEFAULT '',
+	ip_address VARCHAR(45) NOT NULL DEFAULT '',
+	is_succeeded BOOLEAN NOT NULL,
+	created_at TIMESTAMP NOT NULL,
+	CONSTRAINT fk_account_user
+		FOREIGN KEY(account_user_id)
+			REFERENCES account_user(id)
+			ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS account_dataexport
+(
+	id SERIAL PRIMARY KEY NOT NULL,
+	account_user_id INT NOT NULL,
+	format_version INT NOT NULL,
+	status VARCHAR(20) NOT NULL,
+	archive BYTEA NULL,
+	created_at TIMESTAMP NOT NULL,
+	started_at TIMESTAMP NULL,
+	completed_at TIMESTAMP NULL,
+	expired_at TIMESTAMP NULL,
+	CONSTRAINT fk_account_user
+		FOREIGN KEY(account_user_id)
+			REFERENCES account_user(id)
+			ON DELETE CASCADE
+);
@@ -0,0 +1,10 @@
+-- first line of default shipping address moved back to
+-- legacy free text user address before the address book dropped
+
+UPDATE account_user
+	SET address = account_address.line1
+	FROM account_address
+	WHERE account_address.account_user_id = account_user.id
+		AND account_address.is_default_shipping;
+
+DROP TABLE IF EXISTS account_address;
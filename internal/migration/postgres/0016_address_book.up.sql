@@ -0,0 +1,51 @@
+-- address book of user, legacy free text user address moved
+-- to the address book as default shipping and billing address
+
+CREATE TABLE IF NOT EXISTS account_address
+(
+	id SERIAL PRIMARY KEY NOT NULL,
+	account_user_id INT NOT NULL,
+	label VARCHAR(30) NOT NULL DEFAULT '',
+	recipient_name VARCHAR(50) NOT NULL,
+	line1 VARCHAR(100) NOT NULL,
+	line2 VARCHAR(100) NOT NULL DEFAULT '',
+	city VARCHAR(50) NOT NULL DEFAULT '',
+	region VARCHAR(50) NOT NULL DEFAULT '',
+	postal_code VARCHAR(20) NOT NULL DEFAULT '',
+	country_code VARCHAR(2) NOT NULL DEFAULT '',
+	phone_number VARCHAR(20) NOT NULL DEFAULT '',
+	is_default_shipping BOOLEAN NOT NULL DEFAULT FALSE,
+	is_default_billing BOOLEAN NOT NULL DEFAULT FALSE,
+	created_at TIMESTAMP NOT NULL,
+	updated_at TIMESTAMP NOT NULL,
+	CONSTRAINT fk_account_user
+		FOREIGN KEY(account_user_id)
+			REFERENCES account_user(id)
+			ON DELETE CASCADE
+);
+CREATE UNIQUE INDEX IF NOT EXISTS uq_account_address_default_shipping
+	ON account_address(account_user_id) WHERE is_default_shipping;
+CREATE UNIQUE INDEX IF NOT EXISTS uq_account_address_default_billing
+	ON account_address(account_user_id) WHERE is_default_billing;
+
+WITH legacy_address AS (
+	UPDATE account_user
+		SET address = ''
+		FROM (
+			SELECT id, address FROM account_user
+				WHERE address <> ''
+				FOR UPDATE
+		) AS old_user
+		WHERE account_user.id = old_user.id
+		RETURNING account_user.id, account_user.full_name,
+			old_user.address, account_user.phone_number
+)
+INSERT INTO account_address(account_user_id, recipient_name, line1, phone_number,
+	is_default_shipping, is_default_billing, created_at, updated_at)
+	SELECT id, full_name, address, phone_number,
+		NOT EXISTS (SELECT 1 FROM account_address
+			WHERE account_address.account_user_id = legacy_address.id),
+		NOT EXISTS (SELECT 1 FROM account_address
+			WHERE account_address.account_user_id = legacy_address.id),
+		NOW() AT TIME ZONE 'UTC', NOW() AT TIME ZONE 'UTC'
+		FROM legacy_address;
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS account_phoneverification;
+ALTER TABLE account_user
+	DROP COLUMN IF EXISTS phone_verified_at;
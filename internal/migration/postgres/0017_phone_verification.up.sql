@@ -0,0 +1,19 @@
+-- SMS phone number verification
+
+CREATE TABLE IF NOT EXISTS account_phoneverification
+(
+	id SERIAL PRIMARY KEY NOT NULL,
+	account_user_id INT NOT NULL,
+	phone_number VARCHAR(20) NOT NULL,
+	code_hash VARCHAR(64) NOT NULL,
+	attempts INT NOT NULL DEFAULT 0,
+	created_at TIMESTAMP NOT NULL,
+	expired_at TIMESTAMP NOT NULL,
+	CONSTRAINT fk_account_user
+		FOREIGN KEY(account_user_id)
+			REFERENCES account_user(id)
+			ON DELETE CASCADE
+);
+
+ALTER TABLE account_user
+	ADD COLUMN IF NOT EXISTS phone_verified_at TIMESTAMP NULL;
@@ -0,0 +1,244 @@
+-- initial schema, SQLite backend added after the schema changes
+-- of the later migrations (version 2 to 17), so already created here
+
+CREATE TABLE IF NOT EXISTS account_user
+(
+	id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+	email VARCHAR(50) UNIQUE NOT NULL,
+	password VARCHAR(100) NOT NULL,
+	full_name VARCHAR(50) NOT NULL,
+	address VARCHAR(100) NOT NULL,
+	phone_number VARCHAR(20) NOT NULL,
+	role VARCHAR(20) NOT NULL,
+	email_verified_at TIMESTAMP NULL,
+	email_verification_sent_at TIMESTAMP NULL,
+	totp_secret VARCHAR(64) NULL,
+	totp_enabled_at TIMESTAMP NULL,
+	totp_last_step BIGINT NOT NULL DEFAULT 0,
+	version INT NOT NULL DEFAULT 1,
+	updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	status VARCHAR(20) NOT NULL DEFAULT 'active',
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	suspended_reason TEXT NULL,
+	suspended_until TIMESTAMP NULL,
+	deleted_at TIMESTAMP NULL,
+	deletion_scheduled_at TIMESTAMP NULL,
+	phone_verified_at TIMESTAMP NULL
+);
+CREATE INDEX IF NOT EXISTS idx_account_user_created_at
+	ON account_user(created_at, id);
+
+CREATE TABLE IF NOT EXISTS account_usersession
+(
+	id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+	token TEXT UNIQUE NOT NULL,
+	account_user_id INT NOT NULL,
+	user_agent VARCHAR(255) NOT NULL DEFAULT '',
+	ip_address VARCHAR(45) NOT NULL DEFAULT '',
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	last_seen_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	CONSTRAINT fk_account_user
+		FOREIGN KEY(account_user_id)
+			REFERENCES account_user(id)
+			ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS account_refreshtoken
+(
+	id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+	token_hash VARCHAR(64) UNIQUE NOT NULL,
+	account_usersession_id INT NOT NULL,
+	is_used BOOLEAN NOT NULL DEFAULT FALSE,
+	expired_at TIMESTAMP NOT NULL,
+	CONSTRAINT fk_account_usersession
+		FOREIGN KEY(account_usersession_id)
+			REFERENCES account_usersession(id)
+			ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS account_passwordreset
+(
+	id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+	token_hash VARCHAR(64) UNIQUE NOT NULL,
+	account_user_id INT NOT NULL,
+	expired_at TIMESTAMP NOT NULL,
+	used_at TIMESTAMP NULL,
+	CONSTRAINT fk_account_user
+		FOREIGN KEY(account_user_id)
+			REFERENCES account_user(id)
+			ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS account_recoverycode
+(
+	id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+	code_hash VARCHAR(64) NOT NULL,
+	account_user_id INT NOT NULL,
+	used_at TIMESTAMP NULL,
+	CONSTRAINT fk_account_user
+		FOREIGN KEY(account_user_id)
+			REFERENCES account_user(id)
+			ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS account_loginfailure
+(
+	id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+	failure_key VARCHAR(100) UNIQUE NOT NULL,
+	failure_count INT NOT NULL DEFAULT 0,
+	last_failed_at TIMESTAMP NOT NULL,
+	locked_until TIMESTAMP NULL
+);
+
+CREATE TABLE IF NOT EXISTS account_passwordhistory
+(
+	id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+	account_user_id INT NOT NULL,
+	password VARCHAR(100) NOT NULL,
+	created_at TIMESTAMP NOT NULL,
+	CONSTRAINT fk_account_user
+		FOREIGN KEY(account_user_id)
+			REFERENCES account_user(id)
+			ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS account_emailchange
+(
+	id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+	account_user_id INT NOT NULL,
+	old_email VARCHAR(50) NOT NULL,
+	new_email VARCHAR(50) NOT NULL,
+	token_hash VARCHAR(64) UNIQUE NOT NULL,
+	expired_at TIMESTAMP NOT NULL,
+	confirmed_at TIMESTAMP NULL,
+	revert_token_hash VARCHAR(64) UNIQUE NULL,
+	revert_expired_at TIMESTAMP NULL,
+	reverted_at TIMESTAMP NULL,
+	CONSTRAINT fk_account_user
+		FOREIGN KEY(account_user_id)
+			REFERENCES account_user(id)
+			ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS account_role
+(
+	id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+	name VARCHAR(20) UNIQUE NOT NULL,
+	is_self_registrable BOOLEAN NOT NULL DEFAULT FALSE
+);
+
+CREATE TABLE IF NOT EXISTS account_rolepermission
+(
+	id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+	account_role_id INT NOT NULL,
+	permission VARCHAR(50) NOT NULL,
+	CONSTRAINT uq_account_rolepermission
+		UNIQUE(account_role_id, permission),
+	CONSTRAINT fk_account_role
+		FOREIGN KEY(account_role_id)
+			REFERENCES account_role(id)
+			ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS account_event
+(
+	id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+	event_type VARCHAR(50) NOT NULL,
+	payload TEXT NOT NULL,
+	created_at TIMESTAMP NOT NULL,
+	published_at TIMESTAMP NULL
+);
+
+CREATE TABLE IF NOT EXISTS account_loginhistory
+(
+	id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+	account_user_id INT NOT NULL,
+	user_agent VARCHAR(255) NOT NULL DEFAULT '',
+	ip_address VARCHAR(45) NOT NULL DEFAULT '',
+	is_succeeded BOOLEAN NOT NULL,
+	created_at TIMESTAMP NOT NULL,
+	CONSTRAINT fk_account_user
+		FOREIGN KEY(account_user_id)
+			REFERENCES account_user(id)
+			ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS account_dataexport
+(
+	id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+	account_user_id INT NOT NULL,
+	format_version INT NOT NULL,
+	status VARCHAR(20) NOT NULL,
+	archive BLOB NULL,
+	created_at TIMESTAMP NOT NULL,
+	started_at TIMESTAMP NULL,
+	completed_at TIMESTAMP NULL,
+	expired_at TIMESTAMP NULL,
+	CONSTRAINT fk_account_user
+		FOREIGN KEY(account_user_id)
+			REFERENCES account_user(id)
+			ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS account_address
+(
+	id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+	account_user_id INT NOT NULL,
+	label VARCHAR(30) NOT NULL DEFAULT '',
+	recipient_name VARCHAR(50) NOT NULL,
+	line1 VARCHAR(100) NOT NULL,
+	line2 VARCHAR(100) NOT NULL DEFAULT '',
+	city VARCHAR(50) NOT NULL DEFAULT '',
+	region VARCHAR(50) NOT NULL DEFAULT '',
+	postal_code VARCHAR(20) NOT NULL DEFAULT '',
+	country_code VARCHAR(2) NOT NULL DEFAULT '',
+	phone_number VARCHAR(20) NOT NULL DEFAULT '',
+	is_default_shipping BOOLEAN NOT NULL DEFAULT FALSE,
+	is_default_billing BOOLEAN NOT NULL DEFAULT FALSE,
+	created_at TIMESTAMP NOT NULL,
+	updated_at TIMESTAMP NOT NULL,
+	CONSTRAINT fk_account_user
+		FOREIGN KEY(account_user_id)
+			REFERENCES account_user(id)
+			ON DELETE CASCADE
+);
+CREATE UNIQUE INDEX IF NOT EXISTS uq_account_address_default_shipping
+	ON account_address(account_user_id) WHERE is_default_shipping;
+CREATE UNIQUE INDEX IF NOT EXISTS uq_account_address_default_billing
+	ON account_address(account_user_id) WHERE is_default_billing;
+
+CREATE TABLE IF NOT EXISTS account_phoneverification
+(
+	id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+	account_user_id INT NOT NULL,
+	phone_number VARCHAR(20) NOT NULL,
+	code_hash VARCHAR(64) NOT NULL,
+	attempts INT NOT NULL DEFAULT 0,
+	created_at TIMESTAMP NOT NULL,
+	expired_at TIMESTAMP NOT NULL,
+	CONSTRAINT fk_account_user
+		FOREIGN KEY(account_user_id)
+			REFERENCES account_user(id)
+			ON DELETE CASCADE
+);
+
+INSERT OR IGNORE INTO account_role(name, is_self_registrable)
+	VALUES ('buyer', TRUE), ('seller', TRUE), ('admin', FALSE);
+INSERT OR IGNORE INTO account_rolepermission(account_role_id, permission)
+	WITH seed(role_name, permission) AS (VALUES
+		('buyer', 'product:read'),
+		('buyer', 'order:create'),
+		('buyer', 'order:read'),
+		('seller', 'product:read'),
+		('seller', 'product:write'),
+		('seller', 'order:read'),
+		('admin', 'product:read'),
+		('admin', 'product:write'),
+		('admin', 'order:read'),
+		('admin', 'user:read'),
+		('admin', 'user:write'),
+		('admin', 'jwt_key:promote'),
+		('admin', 'login_lockout:clear')
+	)
+	SELECT account_role.id, seed.permission
+		FROM account_role JOIN seed ON account_role.name = seed.role_name;
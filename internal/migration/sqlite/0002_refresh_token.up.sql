@@ -0,0 +1,2 @@
+-- nothing to migrate, SQLite backend added after this schema change
+-- so it's already in the initial schema
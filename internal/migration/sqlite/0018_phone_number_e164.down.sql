@@ -0,0 +1,5 @@
+-- normalized phone numbers not reverted, phone number in E.164 format
+-- also accepted before the normalization
+
+ALTER TABLE account_user
+	DROP COLUMN is_phone_number_invalid;
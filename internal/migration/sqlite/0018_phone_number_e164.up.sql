@@ -0,0 +1,5 @@
+-- flag of user phone number that can't be normalized to E.164 format,
+-- phone numbers normalized by data migration after this query
+
+ALTER TABLE account_user
+	ADD COLUMN is_phone_number_invalid BOOLEAN NOT NULL DEFAULT FALSE;
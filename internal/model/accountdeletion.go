@@ -0,0 +1,251 @@
+/*
+Package model containing structs and functions for
+database transaction
+*/
+package model
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/reyhanfikridz/ecom-account-service/internal/config"
+	"github.com/reyhanfikridz/ecom-account-service/internal/event"
+	"github.com/reyhanfikridz/ecom-account-service/internal/utils"
+)
+
+// func for schedule deletion of a user account after grace period,
+// the password must be right and all sessions of the user are revoked
+//
+// Login before the deletion time cancel the deletion.
+// Wrong password recorded as failed login of the account and source IP,
+// so the password can't be guessed through this instead of login.
+// Return status 400 if user not exist or password wrong,
+// status 423 if account or IP address locked after too many failed login,
+// or status 429 if still delayed after the last failed login
+func ScheduleUserDeletion(DB *sql.DB, c config.Config, userID int, password string,
+	IPAddress string) (
+	User, int, error) {
+	// get existed user data
+	existedUser, err := GetUser(DB, "", userID)
+	if err == sql.ErrNoRows {
+		return existedUser, 400, nil
+	} else if err != nil {
+		return existedUser, 500, err
+	}
+
+	// check account or IP address locked or not
+	status, _, err := CheckLoginLockout(DB, c, existedUser.Email, IPAddress)
+	if err != nil {
+		return existedUser, 500, err
+	}
+	if status != 200 {
+		return existedUser, status, nil
+	}
+
+	// check password right or wrong
+	err = utils.ComparePassword(existedUser.Password, password)
+	if err != nil {
+		err = RecordLoginFailure(DB, c, existedUser.Email, IPAddress)
+		if err != nil {
+			return existedUser, 500, err
+		}
+
+		return existedUser, 400, nil
+	}
+
+	now := time.Now().UTC()
+	deletionScheduledAt := now.Add(c.AccountDeletionGracePeriod)
+
+	//////////////////// begin transaction /////////////////////
+	tx, err := DB.Begin()
+	if err != nil {
+		return existedUser, 500, err
+	}
+	defer tx.Rollback() // rollback transaction if fail (return before commit)
+
+	// set deletion time
+	_, err = tx.Exec(`
+		UPDATE account_user
+			SET deletion_scheduled_at = $1, version = version + 1, updated_at = $2
+			WHERE id = $3
+		`, deletionScheduledAt, now, userID)
+	if err != nil {
+		return existedUser, 500, err
+	}
+
+	// revoke all user sessions
+	_, err = tx.Exec(`
+		DELETE FROM account_usersession
+			WHERE account_user_id = $1
+		`, userID)
+	if err != nil {
+		return existedUser, 500, err
+	}
+
+	// commit transaction
+	err = tx.Commit()
+	if err != nil {
+		return existedUser, 500, err
+	}
+	////////////////////////////////////////////////////////////
+
+	existedUser.DeletionScheduledAt = &deletionScheduledAt
+	return existedUser, 200, nil
+}
+
+// func for cancel scheduled deletion of a user account,
+// return false if there's no scheduled deletion
+func CancelUserDeletion(DB *sql.DB, userID int) (bool, error) {
+	res, err := DB.Exec(`
+		UPDATE account_user
+			SET deletion_scheduled_at = NULL, version = version + 1, updated_at = $1
+			WHERE id = $2 AND deletion_scheduled_at IS NOT NULL
+		`, time.Now().UTC(), userID)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected > 0, nil
+}
+
+// func for erase all user accounts that already passed its deletion time,
+// return number of erased user accounts
+func EraseScheduledUsers(DB *sql.DB) (int, error) {
+	// get user accounts to erase
+	rows, err := DB.Query(`
+		SELECT id FROM account_user
+			WHERE deletion_scheduled_at <= $1
+		`, time.Now().UTC())
+	if err != nil {
+		return 0, err
+	}
+
+	userIDs := []int{}
+	for rows.Next() {
+		var userID int
+		err = rows.Scan(&userID)
+		if err != nil {
+			rows.Close()
+			return 0, err
+		}
+
+		userIDs = append(userIDs, userID)
+	}
+	rows.Close()
+	if rows.Err() != nil {
+		return 0, rows.Err()
+	}
+
+	// erase user accounts one by one
+	count := 0
+	for _, userID := range userIDs {
+		isErased, err := eraseUser(DB, userID)
+		if err != nil {
+			return count, err
+		}
+		if isErased {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+// func for erase a user account that already passed its deletion time,
+// return false if the deletion cancelled in the meantime
+//
+// Personal data of the user deleted, but the user row kept with anonymized
+// fields, so the user ID still can be used as pseudonymous ID
+// (e.g. order history in other services). Account deleted event
+// created in the same transaction.
+func eraseUser(DB *sql.DB, userID int) (bool, error) {
+	now := time.Now().UTC()
+
+	//////////////////// begin transaction /////////////////////
+	tx, err := DB.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback() // rollback transaction if fail (return before commit)
+
+	// lock the user row, check the deletion not cancelled
+	var email string
+	err = tx.QueryRow(`
+		SELECT email FROM account_user
+			WHERE id = $1 AND deletion_scheduled_at <= $2
+			FOR UPDATE
+		`, userID, now).Scan(&email)
+	if err == sql.ErrNoRows {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	// anonymize personal fields
+	_, err = tx.Exec(`
+		UPDATE account_user
+			SET email = 'deleted-' || id || '@deleted.invalid', password = '',
+				full_name = '', address = '', phone_number = '',
+				email_verified_at = NULL, email_verification_sent_at = NULL,
+				phone_verified_at = NULL, is_phone_number_invalid = FALSE,
+				totp_secret = NULL, totp_enabled_at = NULL, totp_last_step = 0,
+				status = $1, suspended_reason = NULL, suspended_until = NULL,
+				deleted_at = $2, deletion_scheduled_at = NULL,
+				version = version + 1, updated_at = $2
+			WHERE id = $3
+		`, UserStatusDeleted, now, userID)
+	if err != nil {
+		return false, err
+	}
+
+	// delete personal data in other tables
+	for _, table := range []string{
+		"account_usersession",
+		"account_passwordreset",
+		"account_passwordhistory",
+		"account_recoverycode",
+		"account_emailchange",
+		"account_address",
+		"account_phoneverification",
+		"account_loginhistory",
+		"account_dataexport",
+	} {
+		_, err = tx.Exec(`DELETE FROM `+table+` WHERE account_user_id = $1`, userID)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	for _, key := range LoginFailureKeys(email, "") {
+		_, err = tx.Exec(`
+			DELETE FROM account_loginfailure
+				WHERE failure_key = $1
+			`, key)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	// create account deleted event
+	err = createEvent(tx, event.TypeAccountDeleted, map[string]any{
+		"user_id":    userID,
+		"deleted_at": now,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	// commit transaction
+	err = tx.Commit()
+	if err != nil {
+		return false, err
+	}
+	////////////////////////////////////////////////////////////
+
+	return true, nil
+}
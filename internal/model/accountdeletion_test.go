@@ -0,0 +1,212 @@
+/*
+Package model containing structs and functions for
+database transaction
+*/
+package model
+
+import (
+	"encoding/json"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/reyhanfikridz/ecom-account-service/internal/event"
+	"github.com/reyhanfikridz/ecom-account-service/internal/utils"
+)
+
+// TestScheduleUserDeletionAndEraseScheduledUsers integration test
+// ScheduleUserDeletion, CancelUserDeletion, and EraseScheduledUsers
+func TestScheduleUserDeletionAndEraseScheduledUsers(t *testing.T) {
+	// get connection to testing DB
+	DB, err := GetTestDBConnection()
+	if err != nil {
+		t.Fatalf("Connection to testing DB failed => " + err.Error())
+	}
+
+	// delete prev data first
+	_, err = DB.Exec(`DELETE FROM account_user WHERE email = $1`,
+		"testaccountdeletion@gmail.com")
+	if err != nil {
+		t.Errorf("There's an error when deleting previous user testing data => " +
+			err.Error())
+	}
+
+	// create user data with its session
+	user, err := CreateUser(DB, testConfig, User{
+		Email:       "testaccountdeletion@gmail.com",
+		Password:    "testaccountdeletion",
+		FullName:    "testaccountdeletion",
+		Address:     "address",
+		PhoneNumber: "08111111111",
+		Role:        "buyer",
+	})
+	if err != nil {
+		t.Errorf("There's an error when creating user => " + err.Error())
+	}
+	_, err = DB.Exec(`UPDATE account_user SET email_verified_at = $1 WHERE id = $2`,
+		time.Now().UTC(), user.ID)
+	if err != nil {
+		t.Errorf("There's an error when verifying user email => " + err.Error())
+	}
+
+	token, err := utils.GenerateJWT(testConfig.JWTKeys, user.ID, user.Role,
+		testConfig.AccessTokenDuration)
+	if err != nil {
+		t.Errorf("There's an error when generating jwt token => " + err.Error())
+	}
+
+	_, err = CreateUserSession(DB, UserSession{Token: token, User: user})
+	if err != nil {
+		t.Errorf("There's an error when creating user session => " + err.Error())
+	}
+
+	//////////////////// SCHEDULE USER DELETION ////////////////////
+	// lock account after 2 wrong password, the same as failed login
+	c := testConfig
+	c.LoginFailureThreshold = 2
+
+	_, err = ClearLoginFailures(DB, "testaccountdeletion@gmail.com", "127.0.0.1")
+	if err != nil {
+		t.Errorf("There's an error when clearing previous login failures => " + err.Error())
+	}
+
+	// create testing table
+	scheduleTestTable := []struct {
+		UserID                 int
+		Password               string
+		IsLoginFailuresCleared bool
+		ExpectedStatus         int
+	}{
+		{
+			UserID:         0,
+			Password:       "testaccountdeletion",
+			ExpectedStatus: 400,
+		},
+		{
+			UserID:         user.ID,
+			Password:       "wrongpassword",
+			ExpectedStatus: 400,
+		},
+		{
+			UserID:         user.ID,
+			Password:       "wrongpassword",
+			ExpectedStatus: 400,
+		},
+		{
+			UserID:         user.ID,
+			Password:       "testaccountdeletion",
+			ExpectedStatus: 423,
+		},
+		{
+			UserID:                 user.ID,
+			Password:               "testaccountdeletion",
+			IsLoginFailuresCleared: true,
+			ExpectedStatus:         200,
+		},
+	}
+
+	for _, test := range scheduleTestTable {
+		if test.IsLoginFailuresCleared {
+			_, err = ClearLoginFailures(DB, "testaccountdeletion@gmail.com", "127.0.0.1")
+			if err != nil {
+				t.Errorf("There's an error when clearing login failures => " + err.Error())
+			}
+		}
+
+		scheduledUser, status, err := ScheduleUserDeletion(DB, c, test.UserID, test.Password,
+			"127.0.0.1")
+		if err != nil {
+			t.Errorf("There's an error when scheduling user deletion => " + err.Error())
+		}
+		if status != test.ExpectedStatus {
+			t.Errorf("Expected status %d, but got %d", test.ExpectedStatus, status)
+		}
+		if status == 200 && scheduledUser.DeletionScheduledAt == nil {
+			t.Errorf("Expected user deletion time filled, but got empty")
+		}
+	}
+
+	// all user sessions revoked after deletion scheduled
+	userSessions, err := GetUserSessions(DB, user.ID)
+	if err != nil {
+		t.Errorf("There's an error when getting user sessions => " + err.Error())
+	}
+	if len(userSessions) != 0 {
+		t.Errorf("Expected all user sessions revoked, but got %d sessions",
+			len(userSessions))
+	}
+
+	// login cancel the deletion
+	_, _, status, _, err := AuthenticateUser(DB, testConfig, User{
+		Email:    "testaccountdeletion@gmail.com",
+		Password: "testaccountdeletion",
+	}, "test-agent", "127.0.0.1")
+	if status != 200 || err != nil {
+		t.Errorf("Expected authenticate user status 200, but got %d", status)
+	}
+
+	isCancelled, err := CancelUserDeletion(DB, user.ID)
+	if err != nil {
+		t.Errorf("There's an error when cancelling user deletion => " + err.Error())
+	}
+	if isCancelled {
+		t.Errorf("Expected user deletion already cancelled by login, but still scheduled")
+	}
+
+	//////////////////// ERASE SCHEDULED USERS ////////////////////
+	// schedule deletion again, then make the deletion time passed
+	_, status, err = ScheduleUserDeletion(DB, testConfig, user.ID, "testaccountdeletion",
+		"127.0.0.1")
+	if status != 200 || err != nil {
+		t.Errorf("Expected schedule user deletion status 200, but got %d", status)
+	}
+
+	_, err = DB.Exec(`UPDATE account_user SET deletion_scheduled_at = $1 WHERE id = $2`,
+		time.Now().UTC().Add(-time.Minute), user.ID)
+	if err != nil {
+		t.Errorf("There's an error when updating deletion time => " + err.Error())
+	}
+
+	count, err := EraseScheduledUsers(DB)
+	if err != nil {
+		t.Errorf("There's an error when erasing scheduled users => " + err.Error())
+	}
+	if count < 1 {
+		t.Errorf("Expected at least 1 user erased, but got %d", count)
+	}
+
+	// check user anonymized, but the user ID kept
+	erasedUser, err := GetUser(DB, "", user.ID)
+	if err != nil {
+		t.Errorf("There's an error when getting erased user => " + err.Error())
+	}
+	expectedEmail := "deleted-" + strconv.Itoa(user.ID) + "@deleted.invalid"
+	if erasedUser.Email != expectedEmail || erasedUser.FullName != "" ||
+		erasedUser.Address != "" || erasedUser.PhoneNumber != "" ||
+		erasedUser.Status != UserStatusDeleted {
+		t.Errorf("Expected user anonymized and deleted, but got email '" +
+			erasedUser.Email + "' and status '" + erasedUser.Status + "'")
+	}
+
+	// check account deleted event created
+	events, err := GetUnpublishedEvents(DB, 1000)
+	if err != nil {
+		t.Errorf("There's an error when getting unpublished events => " + err.Error())
+	}
+
+	isEventCreated := false
+	for _, e := range events {
+		var data map[string]any
+		err = json.Unmarshal([]byte(e.Payload), &data)
+		if err != nil {
+			t.Errorf("There's an error when unmarshal event payload => " + err.Error())
+		}
+
+		if e.Type == event.TypeAccountDeleted && data["user_id"] == float64(user.ID) {
+			isEventCreated = true
+		}
+	}
+	if !isEventCreated {
+		t.Errorf("Expected account deleted event created, but not created")
+	}
+}
@@ -0,0 +1,282 @@
+/*
+Package model containing structs and functions for
+database transaction
+*/
+package model
+
+import (
+	"database/sql"
+	"time"
+)
+
+// maximum number of addresses of a user
+const MaxAddressesPerUser = 20
+
+// address model, an entry of user address book used
+// for shipping and billing
+//
+// Address migrated from legacy user address only has line 1 filled
+// (without country), it must be completed before can be updated
+type Address struct {
+	ID                int       `json:"id"`
+	UserID            int       `json:"user_id"`
+	Label             string    `json:"label"`
+	RecipientName     string    `json:"recipient_name"`
+	Line1             string    `json:"line1"`
+	Line2             string    `json:"line2"`
+	City              string    `json:"city"`
+	Region            string    `json:"region"`
+	PostalCode        string    `json:"postal_code"`
+	CountryCode       string    `json:"country_code"`
+	PhoneNumber       string    `json:"phone_number"`
+	IsDefaultShipping bool      `json:"is_default_shipping"`
+	IsDefaultBilling  bool      `json:"is_default_billing"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+// address table columns used in select query,
+// the order need to be same as order in addressScanDest
+const addressColumns = `id, account_user_id, label, recipient_name, line1, line2,
+	city, region, postal_code, country_code, phone_number,
+	is_default_shipping, is_default_billing, created_at, updated_at`
+
+// addressScanDest get scan destinations of address table columns
+func addressScanDest(a *Address) []any {
+	return []any{
+		&a.ID,
+		&a.UserID,
+		&a.Label,
+		&a.RecipientName,
+		&a.Line1,
+		&a.Line2,
+		&a.City,
+		&a.Region,
+		&a.PostalCode,
+		&a.CountryCode,
+		&a.PhoneNumber,
+		&a.IsDefaultShipping,
+		&a.IsDefaultBilling,
+		&a.CreatedAt,
+		&a.UpdatedAt,
+	}
+}
+
+// func for get all addresses of a user, default addresses first
+// then newest first
+func GetAddresses(DB *sql.DB, userID int) ([]Address, error) {
+	addresses := []Address{}
+	rows, err := DB.Query(`
+		SELECT `+addressColumns+`
+			FROM account_address
+			WHERE account_user_id = $1
+			ORDER BY is_default_shipping DESC, is_default_billing DESC, id DESC
+		`, userID)
+	if err != nil {
+		return addresses, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		a := Address{}
+		err = rows.Scan(addressScanDest(&a)...)
+		if err != nil {
+			return addresses, err
+		}
+
+		addresses = append(addresses, a)
+	}
+
+	return addresses, rows.Err()
+}
+
+// func for get an address of a user by ID
+func GetAddress(DB *sql.DB, ID int, userID int) (Address, error) {
+	a := Address{}
+	err := DB.QueryRow(`
+		SELECT `+addressColumns+`
+			FROM account_address
+			WHERE id = $1 AND account_user_id = $2
+		`, ID, userID).Scan(addressScanDest(&a)...)
+
+	return a, err
+}
+
+// func for create address of a user, the first address of the user
+// become default shipping and billing address
+//
+// Return status 400 if the user already has maximum number of addresses
+func CreateAddress(DB *sql.DB, a Address) (Address, int, error) {
+	//////////////////// begin transaction /////////////////////
+	tx, err := DB.Begin()
+	if err != nil {
+		return a, 500, err
+	}
+	defer tx.Rollback() // rollback transaction if fail (return before commit)
+
+	// lock the user so addresses counted correctly
+	// when created by concurrent requests
+	_, err = tx.Exec(`SELECT id FROM account_user WHERE id = $1 FOR UPDATE`, a.UserID)
+	if err != nil {
+		return a, 500, err
+	}
+
+	var count int
+	err = tx.QueryRow(`SELECT COUNT(*) FROM account_address WHERE account_user_id = $1`,
+		a.UserID).Scan(&count)
+	if err != nil {
+		return a, 500, err
+	}
+	if count >= MaxAddressesPerUser {
+		return a, 400, nil
+	}
+	if count == 0 {
+		a.IsDefaultShipping = true
+		a.IsDefaultBilling = true
+	}
+
+	// only one default address of each type
+	err = unsetDefaultAddresses(tx, a.UserID, 0, a.IsDefaultShipping, a.IsDefaultBilling)
+	if err != nil {
+		return a, 500, err
+	}
+
+	a.CreatedAt = time.Now().UTC()
+	a.UpdatedAt = a.CreatedAt
+	err = tx.QueryRow(`
+		INSERT INTO account_address(account_user_id, label, recipient_name, line1, line2,
+			city, region, postal_code, country_code, phone_number,
+			is_default_shipping, is_default_billing, created_at, updated_at)
+			VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+			RETURNING id`,
+		a.UserID, a.Label, a.RecipientName, a.Line1, a.Line2,
+		a.City, a.Region, a.PostalCode, a.CountryCode, a.PhoneNumber,
+		a.IsDefaultShipping, a.IsDefaultBilling, a.CreatedAt, a.UpdatedAt,
+	).Scan(&a.ID)
+	if err != nil {
+		return a, 500, err
+	}
+
+	// commit transaction
+	err = tx.Commit()
+	if err != nil {
+		return a, 500, err
+	}
+	////////////////////////////////////////////////////////////
+
+	return a, 200, nil
+}
+
+// func for update address of a user, all fields replaced
+//
+// Return sql.ErrNoRows if the address not exist
+func UpdateAddress(DB *sql.DB, a Address) (Address, error) {
+	//////////////////// begin transaction /////////////////////
+	tx, err := DB.Begin()
+	if err != nil {
+		return a, err
+	}
+	defer tx.Rollback() // rollback transaction if fail (return before commit)
+
+	// only one default address of each type
+	err = unsetDefaultAddresses(tx, a.UserID, a.ID, a.IsDefaultShipping, a.IsDefaultBilling)
+	if err != nil {
+		return a, err
+	}
+
+	a.UpdatedAt = time.Now().UTC()
+	err = tx.QueryRow(`
+		UPDATE account_address
+			SET label = $1, recipient_name = $2, line1 = $3, line2 = $4,
+				city = $5, region = $6, postal_code = $7, country_code = $8,
+				phone_number = $9, is_default_shipping = $10, is_default_billing = $11,
+				updated_at = $12
+			WHERE id = $13 AND account_user_id = $14
+			RETURNING created_at`,
+		a.Label, a.RecipientName, a.Line1, a.Line2,
+		a.City, a.Region, a.PostalCode, a.CountryCode,
+		a.PhoneNumber, a.IsDefaultShipping, a.IsDefaultBilling,
+		a.UpdatedAt, a.ID, a.UserID,
+	).Scan(&a.CreatedAt)
+	if err != nil {
+		return a, err
+	}
+
+	// commit transaction
+	err = tx.Commit()
+	if err != nil {
+		return a, err
+	}
+	////////////////////////////////////////////////////////////
+
+	return a, nil
+}
+
+// func for delete address of a user, the newest remaining address
+// become default address if the deleted address was default
+//
+// Return sql.ErrNoRows if the address not exist
+func DeleteAddress(DB *sql.DB, ID int, userID int) error {
+	//////////////////// begin transaction /////////////////////
+	tx, err := DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() // rollback transaction if fail (return before commit)
+
+	var isDefaultShipping, isDefaultBilling bool
+	err = tx.QueryRow(`
+		DELETE FROM account_address
+			WHERE id = $1 AND account_user_id = $2
+			RETURNING is_default_shipping, is_default_billing
+		`, ID, userID).Scan(&isDefaultShipping, &isDefaultBilling)
+	if err != nil {
+		return err
+	}
+
+	// replace deleted default address
+	if isDefaultShipping || isDefaultBilling {
+		_, err = tx.Exec(`
+			UPDATE account_address
+				SET is_default_shipping = is_default_shipping OR $1,
+					is_default_billing = is_default_billing OR $2
+				WHERE id = (
+					SELECT id FROM account_address
+						WHERE account_user_id = $3
+						ORDER BY id DESC
+						LIMIT 1
+				)
+			`, isDefaultShipping, isDefaultBilling, userID)
+		if err != nil {
+			return err
+		}
+	}
+
+	// commit transaction
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+	////////////////////////////////////////////////////////////
+
+	return nil
+}
+
+// func for unset default shipping and/or billing address of a user,
+// except address with exceptID
+func unsetDefaultAddresses(tx *sql.Tx, userID int, exceptID int,
+	isShipping bool, isBilling bool) error {
+	if !isShipping && !isBilling {
+		return nil
+	}
+
+	_, err := tx.Exec(`
+		UPDATE account_address
+			SET is_default_shipping = is_default_shipping AND NOT $1,
+				is_default_billing = is_default_billing AND NOT $2
+			WHERE account_user_id = $3 AND id <> $4
+				AND ((is_default_shipping AND $1) OR (is_default_billing AND $2))
+		`, isShipping, isBilling, userID, exceptID)
+
+	return err
+}
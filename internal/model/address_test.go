@@ -0,0 +1,154 @@
+/*
+Package model containing structs and functions for
+database transaction
+*/
+package model
+
+import (
+	"database/sql"
+	"testing"
+)
+
+// TestAddressBook integration test CreateAddress, GetAddresses,
+// GetAddress, UpdateAddress, and DeleteAddress
+func TestAddressBook(t *testing.T) {
+	// get connection to testing DB
+	DB, err := GetTestDBConnection()
+	if err != nil {
+		t.Fatalf("Connection to testing DB failed => " + err.Error())
+	}
+
+	// delete prev data first
+	_, err = DB.Exec(`DELETE FROM account_user WHERE email = $1`,
+		"testaddressbook@gmail.com")
+	if err != nil {
+		t.Errorf("There's an error when deleting previous user testing data => " +
+			err.Error())
+	}
+
+	// create user data, register address become the first address
+	user, err := CreateUser(DB, testConfig, User{
+		Email:       "testaddressbook@gmail.com",
+		Password:    "testaddressbook",
+		FullName:    "testaddressbook",
+		Address:     "address",
+		PhoneNumber: "08111111111",
+		Role:        "buyer",
+	})
+	if err != nil {
+		t.Errorf("There's an error when creating user => " + err.Error())
+	}
+
+	addresses, err := GetAddresses(DB, user.ID)
+	if err != nil {
+		t.Errorf("There's an error when getting addresses => " + err.Error())
+	}
+	if len(addresses) != 1 || addresses[0].Line1 != "address" ||
+		!addresses[0].IsDefaultShipping || !addresses[0].IsDefaultBilling {
+		t.Fatalf("Expected register address as default address, but got %+v", addresses)
+	}
+	firstAddress := addresses[0]
+
+	// create default shipping address
+	address, status, err := CreateAddress(DB, Address{
+		UserID:            user.ID,
+		Label:             "office",
+		RecipientName:     "test",
+		Line1:             "Jl. Test No. 1",
+		City:              "Jakarta",
+		Region:            "DKI Jakarta",
+		PostalCode:        "10110",
+		CountryCode:       "ID",
+		IsDefaultShipping: true,
+	})
+	if status != 200 || err != nil {
+		t.Fatalf("Expected create address status 200, but got %d (%v)", status, err)
+	}
+
+	// check default address of each type
+	checkDefaultAddresses := func(expectedShippingID int, expectedBillingID int) {
+		addresses, err := GetAddresses(DB, user.ID)
+		if err != nil {
+			t.Errorf("There's an error when getting addresses => " + err.Error())
+		}
+
+		shippingID, billingID := 0, 0
+		for _, a := range addresses {
+			if a.IsDefaultShipping {
+				shippingID = a.ID
+			}
+			if a.IsDefaultBilling {
+				billingID = a.ID
+			}
+		}
+		if shippingID != expectedShippingID || billingID != expectedBillingID {
+			t.Errorf("Expected default shipping %d and billing %d, but got %d and %d",
+				expectedShippingID, expectedBillingID, shippingID, billingID)
+		}
+	}
+	checkDefaultAddresses(address.ID, firstAddress.ID)
+
+	// update address to default billing too
+	address.City = "Bandung"
+	address.IsDefaultBilling = true
+	address, err = UpdateAddress(DB, address)
+	if err != nil {
+		t.Errorf("There's an error when updating address => " + err.Error())
+	}
+	checkDefaultAddresses(address.ID, address.ID)
+
+	address, err = GetAddress(DB, address.ID, user.ID)
+	if err != nil {
+		t.Errorf("There's an error when getting address => " + err.Error())
+	}
+	if address.City != "Bandung" {
+		t.Errorf("Expected address city 'Bandung', but got '" + address.City + "'")
+	}
+
+	// address of other user not found
+	_, err = GetAddress(DB, address.ID, user.ID+1)
+	if err != sql.ErrNoRows {
+		t.Errorf("Expected address of other user not found, but got %v", err)
+	}
+	address.UserID = user.ID + 1
+	_, err = UpdateAddress(DB, address)
+	if err != sql.ErrNoRows {
+		t.Errorf("Expected update address of other user not found, but got %v", err)
+	}
+	err = DeleteAddress(DB, address.ID, user.ID+1)
+	if err != sql.ErrNoRows {
+		t.Errorf("Expected delete address of other user not found, but got %v", err)
+	}
+
+	// delete default address, the remaining address become default
+	err = DeleteAddress(DB, address.ID, user.ID)
+	if err != nil {
+		t.Errorf("There's an error when deleting address => " + err.Error())
+	}
+	checkDefaultAddresses(firstAddress.ID, firstAddress.ID)
+
+	// can't create more than maximum number of addresses
+	for i := 1; i < MaxAddressesPerUser; i++ {
+		_, status, err = CreateAddress(DB, Address{
+			UserID:        user.ID,
+			RecipientName: "test",
+			Line1:         "Jl. Test No. 1",
+			City:          "Jakarta",
+			CountryCode:   "ID",
+		})
+		if status != 200 || err != nil {
+			t.Fatalf("Expected create address status 200, but got %d (%v)", status, err)
+		}
+	}
+
+	_, status, err = CreateAddress(DB, Address{
+		UserID:        user.ID,
+		RecipientName: "test",
+		Line1:         "Jl. Test No. 1",
+		City:          "Jakarta",
+		CountryCode:   "ID",
+	})
+	if status != 400 || err != nil {
+		t.Errorf("Expected create address status 400, but got %d (%v)", status, err)
+	}
+}
@@ -0,0 +1,245 @@
+/*
+Package model containing structs and functions for
+database transaction
+*/
+package model
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/reyhanfikridz/ecom-account-service/internal/config"
+	"github.com/reyhanfikridz/ecom-account-service/internal/dataexport"
+)
+
+// data export status
+const (
+	DataExportStatusPending    = "pending"
+	DataExportStatusProcessing = "processing"
+	DataExportStatusReady      = "ready"
+	DataExportStatusFailed     = "failed"
+)
+
+// data export processing considered stuck (e.g. service restarted)
+// and processed again after this duration
+const DataExportProcessingTimeout = time.Hour
+
+// data export model, personal data archive of a user
+// built in background, the archive downloadable until expired
+type DataExport struct {
+	ID            int        `json:"id"`
+	UserID        int        `json:"user_id"`
+	FormatVersion int        `json:"format_version"`
+	Status        string     `json:"status"`
+	CreatedAt     time.Time  `json:"created_at"`
+	StartedAt     *time.Time `json:"started_at"`
+	CompletedAt   *time.Time `json:"completed_at"`
+	ExpiredAt     *time.Time `json:"expired_at"`
+}
+
+// data export table columns used in select query (without archive),
+// the order need to be same as order in dataExportScanDest
+const dataExportColumns = `id, account_user_id, format_version, status,
+	created_at, started_at, completed_at, expired_at`
+
+// dataExportScanDest get scan destinations of data export table columns
+func dataExportScanDest(de *DataExport) []any {
+	return []any{
+		&de.ID,
+		&de.UserID,
+		&de.FormatVersion,
+		&de.Status,
+		&de.CreatedAt,
+		&de.StartedAt,
+		&de.CompletedAt,
+		&de.ExpiredAt,
+	}
+}
+
+// func for create data export of a user, return data export
+// of the user that still pending or processing if exist
+// (return false), so one user only has one export in progress
+func CreateDataExport(DB *sql.DB, userID int) (DataExport, bool, error) {
+	de := DataExport{}
+
+	// get data export in progress
+	err := DB.QueryRow(`
+		SELECT `+dataExportColumns+`
+			FROM account_dataexport
+			WHERE account_user_id = $1 AND status IN ($2, $3)
+			ORDER BY id DESC
+			LIMIT 1
+		`, userID, DataExportStatusPending, DataExportStatusProcessing,
+	).Scan(dataExportScanDest(&de)...)
+	if err == nil {
+		return de, false, nil
+	} else if err != sql.ErrNoRows {
+		return de, false, err
+	}
+
+	// create new data export
+	de = DataExport{
+		UserID:        userID,
+		FormatVersion: dataexport.FormatVersion,
+		Status:        DataExportStatusPending,
+		CreatedAt:     time.Now().UTC(),
+	}
+	err = DB.QueryRow(`
+		INSERT INTO account_dataexport(account_user_id, format_version, status, created_at)
+			VALUES($1, $2, $3, $4) RETURNING id
+		`, de.UserID, de.FormatVersion, de.Status, de.CreatedAt).Scan(&de.ID)
+	if err != nil {
+		return de, false, err
+	}
+
+	return de, true, nil
+}
+
+// func for get data export of a user by ID
+func GetDataExport(DB *sql.DB, ID int, userID int) (DataExport, error) {
+	de := DataExport{}
+	err := DB.QueryRow(`
+		SELECT `+dataExportColumns+`
+			FROM account_dataexport
+			WHERE id = $1 AND account_user_id = $2
+		`, ID, userID).Scan(dataExportScanDest(&de)...)
+
+	return de, err
+}
+
+// func for get archive of a ready data export
+func GetDataExportArchive(DB *sql.DB, ID int) ([]byte, error) {
+	var archive []byte
+	err := DB.QueryRow(`
+		SELECT archive
+			FROM account_dataexport
+			WHERE id = $1 AND status = $2
+		`, ID, DataExportStatusReady).Scan(&archive)
+
+	return archive, err
+}
+
+// func for claim the oldest pending data export to be processed,
+// data export that processing too long claimed again
+//
+// Return sql.ErrNoRows if there's no data export to process
+func ClaimDataExport(DB *sql.DB) (DataExport, error) {
+	de := DataExport{}
+	now := time.Now().UTC()
+	err := DB.QueryRow(`
+		UPDATE account_dataexport
+			SET status = $1, started_at = $2
+			WHERE id = (
+				SELECT id FROM account_dataexport
+					WHERE status = $3 OR (status = $1 AND started_at <= $4)
+					ORDER BY id
+					LIMIT 1
+					FOR UPDATE SKIP LOCKED
+			)
+			RETURNING `+dataExportColumns,
+		DataExportStatusProcessing, now, DataExportStatusPending,
+		now.Add(-DataExportProcessingTimeout),
+	).Scan(dataExportScanDest(&de)...)
+
+	return de, err
+}
+
+// func for complete data export with its archive,
+// the archive expired after data export duration
+func CompleteDataExport(DB *sql.DB, c config.Config, ID int, archive []byte) error {
+	now := time.Now().UTC()
+	_, err := DB.Exec(`
+		UPDATE account_dataexport
+			SET status = $1, archive = $2, completed_at = $3, expired_at = $4
+			WHERE id = $5
+		`, DataExportStatusReady, archive, now, now.Add(c.DataExportDuration), ID)
+
+	return err
+}
+
+// func for mark data export as failed
+func FailDataExport(DB *sql.DB, ID int) error {
+	_, err := DB.Exec(`
+		UPDATE account_dataexport
+			SET status = $1, completed_at = $2
+			WHERE id = $3
+		`, DataExportStatusFailed, time.Now().UTC(), ID)
+
+	return err
+}
+
+// func for delete expired data exports with its archive
+func DeleteExpiredDataExports(DB *sql.DB) error {
+	_, err := DB.Exec(`
+		DELETE FROM account_dataexport
+			WHERE expired_at <= $1
+		`, time.Now().UTC())
+
+	return err
+}
+
+// func for get all personal data of a user as data export archive files
+func GetDataExportFiles(DB *sql.DB, userID int) ([]dataexport.File, error) {
+	// get profile
+	user, err := GetUser(DB, "", userID)
+	if err != nil {
+		return nil, err
+	}
+
+	addresses, err := GetAddresses(DB, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// get sessions without its token
+	userSessions, err := GetUserSessions(DB, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// get login history
+	loginHistory, err := GetLoginHistory(DB, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return DataExportFiles(user, addresses, userSessions, loginHistory), nil
+}
+
+// func for get data export archive files of a user personal data,
+// sessions without its token
+func DataExportFiles(user User, addresses []Address, userSessions []UserSession,
+	loginHistory []LoginHistory) []dataexport.File {
+	profile := map[string]any{
+		"id":                    user.ID,
+		"email":                 user.Email,
+		"full_name":             user.FullName,
+		"phone_number":          user.PhoneNumber,
+		"role":                  user.Role,
+		"status":                user.Status,
+		"email_verified_at":     user.EmailVerifiedAt,
+		"phone_verified_at":     user.PhoneVerifiedAt,
+		"totp_enabled_at":       user.TOTPEnabledAt,
+		"created_at":            user.CreatedAt,
+		"updated_at":            user.UpdatedAt,
+		"deletion_scheduled_at": user.DeletionScheduledAt,
+	}
+
+	sessions := []map[string]any{}
+	for _, us := range userSessions {
+		sessions = append(sessions, map[string]any{
+			"id":           us.ID,
+			"user_agent":   us.UserAgent,
+			"ip_address":   us.IPAddress,
+			"created_at":   us.CreatedAt,
+			"last_seen_at": us.LastSeenAt,
+		})
+	}
+
+	return []dataexport.File{
+		{Name: dataexport.FileProfile, Content: profile},
+		{Name: dataexport.FileAddresses, Content: addresses},
+		{Name: dataexport.FileSessions, Content: sessions},
+		{Name: dataexport.FileLoginHistory, Content: loginHistory},
+	}
+}
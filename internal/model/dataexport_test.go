@@ -0,0 +1,136 @@
+/*
+Package model containing structs and functions for
+database transaction
+*/
+package model
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/reyhanfikridz/ecom-account-service/internal/dataexport"
+)
+
+// TestCreateDataExportAndCompleteDataExport integration test
+// CreateDataExport, ClaimDataExport, CompleteDataExport,
+// GetDataExport, and GetDataExportArchive
+func TestCreateDataExportAndCompleteDataExport(t *testing.T) {
+	// get connection to testing DB
+	DB, err := GetTestDBConnection()
+	if err != nil {
+		t.Fatalf("Connection to testing DB failed => " + err.Error())
+	}
+
+	// delete prev data first
+	_, err = DB.Exec(`DELETE FROM account_user WHERE email = $1`,
+		"testdataexport@gmail.com")
+	if err != nil {
+		t.Errorf("There's an error when deleting previous user testing data => " +
+			err.Error())
+	}
+
+	// create user data
+	user, err := CreateUser(DB, testConfig, User{
+		Email:       "testdataexport@gmail.com",
+		Password:    "testdataexport",
+		FullName:    "testdataexport",
+		Address:     "address",
+		PhoneNumber: "08111111111",
+		Role:        "buyer",
+	})
+	if err != nil {
+		t.Errorf("There's an error when creating user => " + err.Error())
+	}
+
+	// create data export twice, the second return the first
+	dataExport, isCreated, err := CreateDataExport(DB, user.ID)
+	if err != nil {
+		t.Errorf("There's an error when creating data export => " + err.Error())
+	}
+	if !isCreated || dataExport.Status != DataExportStatusPending ||
+		dataExport.FormatVersion != dataexport.FormatVersion {
+		t.Errorf("Expected pending data export created, but got %+v", dataExport)
+	}
+
+	secondDataExport, isCreated, err := CreateDataExport(DB, user.ID)
+	if err != nil {
+		t.Errorf("There's an error when creating data export => " + err.Error())
+	}
+	if isCreated || secondDataExport.ID != dataExport.ID {
+		t.Errorf("Expected data export %d returned, but got %d created %t",
+			dataExport.ID, secondDataExport.ID, isCreated)
+	}
+
+	// claim data exports until the created one claimed
+	isClaimed := false
+	for !isClaimed {
+		claimedDataExport, err := ClaimDataExport(DB)
+		if err == sql.ErrNoRows {
+			break
+		} else if err != nil {
+			t.Fatalf("There's an error when claiming data export => " + err.Error())
+		}
+
+		isClaimed = claimedDataExport.ID == dataExport.ID
+	}
+	if !isClaimed {
+		t.Fatalf("Expected data export %d claimed, but not claimed", dataExport.ID)
+	}
+
+	// get data export files
+	files, err := GetDataExportFiles(DB, user.ID)
+	if err != nil {
+		t.Errorf("There's an error when getting data export files => " + err.Error())
+	}
+	fileNames := map[string]bool{}
+	for _, file := range files {
+		fileNames[file.Name] = true
+	}
+	for _, name := range []string{
+		dataexport.FileProfile,
+		dataexport.FileAddresses,
+		dataexport.FileSessions,
+		dataexport.FileLoginHistory,
+	} {
+		if !fileNames[name] {
+			t.Errorf("Expected file " + name + " in data export files, but not found")
+		}
+	}
+
+	// complete data export
+	err = CompleteDataExport(DB, testConfig, dataExport.ID, []byte("archive"))
+	if err != nil {
+		t.Errorf("There's an error when completing data export => " + err.Error())
+	}
+
+	dataExport, err = GetDataExport(DB, dataExport.ID, user.ID)
+	if err != nil {
+		t.Errorf("There's an error when getting data export => " + err.Error())
+	}
+	if dataExport.Status != DataExportStatusReady || dataExport.ExpiredAt == nil {
+		t.Errorf("Expected ready data export with expiry, but got %+v", dataExport)
+	}
+
+	// data export of other user not found
+	_, err = GetDataExport(DB, dataExport.ID, user.ID+1)
+	if err != sql.ErrNoRows {
+		t.Errorf("Expected data export of other user not found, but got %v", err)
+	}
+
+	archive, err := GetDataExportArchive(DB, dataExport.ID)
+	if err != nil {
+		t.Errorf("There's an error when getting data export archive => " + err.Error())
+	}
+	if string(archive) != "archive" {
+		t.Errorf("Expected archive 'archive', but got '" + string(archive) + "'")
+	}
+
+	// new data export can be created after the previous one ready
+	_, isCreated, err = CreateDataExport(DB, user.ID)
+	if err != nil {
+		t.Errorf("There's an error when creating data export => " + err.Error())
+	}
+	if !isCreated {
+		t.Errorf("Expected new data export created, but not created")
+	}
+}
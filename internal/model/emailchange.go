@@ -0,0 +1,359 @@
+/*
+Package model containing structs and functions for
+database transaction
+*/
+package model
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/reyhanfikridz/ecom-account-service/internal/config"
+	"github.com/reyhanfikridz/ecom-account-service/internal/utils"
+)
+
+// email change model
+//
+// User email only changed after confirmed from the new email, then
+// the old email get a notice with link to revert the change.
+// Tokens are single use and only saved as hash in database,
+// token only filled right after created (revert token after confirmed).
+type EmailChange struct {
+	ID              int        `json:"id"`
+	User            User       `json:"user"`
+	OldEmail        string     `json:"old_email"`
+	NewEmail        string     `json:"new_email"`
+	Token           string     `json:"token"`
+	ExpiredAt       time.Time  `json:"expired_at"`
+	ConfirmedAt     *time.Time `json:"confirmed_at"`
+	RevertToken     string     `json:"revert_token"`
+	RevertExpiredAt *time.Time `json:"revert_expired_at"`
+}
+
+// func for create email change of a user, the current password must be right,
+// all previous unconfirmed email change of the user is deleted
+//
+// Return status 400 if user not exist or current password wrong,
+// or status 409 if new email already registered
+func CreateEmailChange(DB *sql.DB, c config.Config, userID int, currentPassword string,
+	newEmail string) (EmailChange, int, error) {
+	ec := EmailChange{NewEmail: newEmail}
+
+	// get existed user data
+	existedUser, err := GetUser(DB, "", userID)
+	if err == sql.ErrNoRows {
+		return ec, 400, nil
+	} else if err != nil {
+		return ec, 500, err
+	}
+	ec.User = existedUser
+	ec.OldEmail = existedUser.Email
+
+	// check current password right or wrong
+	err = utils.ComparePassword(existedUser.Password, currentPassword)
+	if err != nil {
+		return ec, 400, nil
+	}
+
+	// check new email not registered yet,
+	// the email can be used if used by deleted user after retention passed
+	err = releaseDeletedUserEmail(DB, c, newEmail)
+	if err != nil {
+		return ec, 500, err
+	}
+
+	var count int
+	err = DB.QueryRow(`
+		SELECT COUNT(*) FROM account_user
+			WHERE email = $1
+		`, newEmail).Scan(&count)
+	if err != nil {
+		return ec, 500, err
+	}
+	if count > 0 {
+		return ec, 409, nil
+	}
+
+	// generate opaque token
+	tokenString, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return ec, 500, err
+	}
+	ec.Token = tokenString
+	ec.ExpiredAt = time.Now().UTC().Add(c.EmailChangeTokenDuration)
+
+	//////////////////// begin transaction /////////////////////
+	tx, err := DB.Begin()
+	if err != nil {
+		return ec, 500, err
+	}
+	defer tx.Rollback() // rollback transaction if fail (return before commit)
+
+	// delete previous unconfirmed email change
+	_, err = tx.Exec(`
+		DELETE FROM account_emailchange
+			WHERE account_user_id = $1 AND confirmed_at IS NULL
+		`, userID)
+	if err != nil {
+		return ec, 500, err
+	}
+
+	// insert email change
+	err = tx.QueryRow(`
+		INSERT INTO account_emailchange(account_user_id, old_email, new_email,
+			token_hash, expired_at)
+			VALUES($1, $2, $3, $4, $5) RETURNING id`,
+		userID, ec.OldEmail, ec.NewEmail, utils.HashToken(ec.Token), ec.ExpiredAt,
+	).Scan(&ec.ID)
+	if err != nil {
+		return ec, 500, err
+	}
+
+	// commit transaction
+	err = tx.Commit()
+	if err != nil {
+		return ec, 500, err
+	}
+	////////////////////////////////////////////////////////////
+
+	return ec, 200, nil
+}
+
+// func for confirm email change by token sent to the new email,
+// change user email and return email change with its revert token
+//
+// Return status 400 if token not valid, already used, expired, or
+// user email already changed, or status 409 if new email already registered
+func ConfirmEmailChange(DB *sql.DB, c config.Config, tokenString string) (
+	EmailChange, int, error) {
+	ec := EmailChange{}
+
+	//////////////////// begin transaction /////////////////////
+	tx, err := DB.Begin()
+	if err != nil {
+		return ec, 500, err
+	}
+	defer tx.Rollback() // rollback transaction if fail (return before commit)
+
+	// get email change
+	err = tx.QueryRow(`
+		SELECT id, account_user_id, old_email, new_email, expired_at, confirmed_at
+			FROM account_emailchange
+			WHERE token_hash = $1
+		`, utils.HashToken(tokenString)).Scan(
+		&ec.ID,
+		&ec.User.ID,
+		&ec.OldEmail,
+		&ec.NewEmail,
+		&ec.ExpiredAt,
+		&ec.ConfirmedAt,
+	)
+	if err == sql.ErrNoRows { // if token not exist
+		return ec, 400, nil
+	} else if err != nil {
+		return ec, 500, err
+	}
+
+	// check token already used or expired
+	now := time.Now().UTC()
+	if ec.ConfirmedAt != nil || now.After(ec.ExpiredAt) {
+		return ec, 400, nil
+	}
+
+	// check new email not registered by another user in the meantime
+	var count int
+	err = tx.QueryRow(`
+		SELECT COUNT(*) FROM account_user
+			WHERE email = $1
+		`, ec.NewEmail).Scan(&count)
+	if err != nil {
+		return ec, 500, err
+	}
+	if count > 0 {
+		return ec, 409, nil
+	}
+
+	// change user email, the new email verified by the confirmation,
+	// if no row affected then the email changed after email change created
+	res, err := tx.Exec(`
+		UPDATE account_user
+			SET email = $1, email_verified_at = $2,
+				status = CASE WHEN status = $5 THEN $6 ELSE status END,
+				version = version + 1, updated_at = $2
+			WHERE id = $3 AND email = $4
+		`, ec.NewEmail, now, ec.User.ID, ec.OldEmail,
+		UserStatusPendingVerification, UserStatusActive)
+	if err != nil {
+		return ec, 500, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return ec, 500, err
+	}
+	if affected == 0 {
+		return ec, 400, nil
+	}
+
+	// mark email change as confirmed with its revert token
+	revertTokenString, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return ec, 500, err
+	}
+	revertExpiredAt := now.Add(c.EmailChangeRevertDuration)
+
+	res, err = tx.Exec(`
+		UPDATE account_emailchange
+			SET confirmed_at = $1, revert_token_hash = $2, revert_expired_at = $3
+			WHERE id = $4 AND confirmed_at IS NULL
+		`, now, utils.HashToken(revertTokenString), revertExpiredAt, ec.ID)
+	if err != nil {
+		return ec, 500, err
+	}
+
+	affected, err = res.RowsAffected()
+	if err != nil {
+		return ec, 500, err
+	}
+	if affected == 0 {
+		return ec, 400, nil
+	}
+
+	// commit transaction
+	err = tx.Commit()
+	if err != nil {
+		return ec, 500, err
+	}
+	////////////////////////////////////////////////////////////
+
+	ec.ConfirmedAt = &now
+	ec.RevertToken = revertTokenString
+	ec.RevertExpiredAt = &revertExpiredAt
+
+	return ec, 200, nil
+}
+
+// func for revert confirmed email change by token sent to the old email,
+// user email changed back to the old email and all sessions of the user
+// are revoked, because the change might not be done by the user
+//
+// Return status 400 if token not valid, already used, expired, or user
+// not exist, or status 409 if old email already registered by another user
+func RevertEmailChange(DB *sql.DB, tokenString string) (EmailChange, int, error) {
+	ec := EmailChange{}
+
+	//////////////////// begin transaction /////////////////////
+	tx, err := DB.Begin()
+	if err != nil {
+		return ec, 500, err
+	}
+	defer tx.Rollback() // rollback transaction if fail (return before commit)
+
+	// get confirmed email change
+	var revertedAt sql.NullTime
+	err = tx.QueryRow(`
+		SELECT id, account_user_id, old_email, new_email, expired_at,
+			confirmed_at, revert_expired_at, reverted_at
+			FROM account_emailchange
+			WHERE revert_token_hash = $1
+		`, utils.HashToken(tokenString)).Scan(
+		&ec.ID,
+		&ec.User.ID,
+		&ec.OldEmail,
+		&ec.NewEmail,
+		&ec.ExpiredAt,
+		&ec.ConfirmedAt,
+		&ec.RevertExpiredAt,
+		&revertedAt,
+	)
+	if err == sql.ErrNoRows { // if token not exist
+		return ec, 400, nil
+	} else if err != nil {
+		return ec, 500, err
+	}
+
+	// check token already used or expired
+	now := time.Now().UTC()
+	if revertedAt.Valid || ec.RevertExpiredAt == nil || now.After(*ec.RevertExpiredAt) {
+		return ec, 400, nil
+	}
+
+	// check old email not registered by another user in the meantime
+	var count int
+	err = tx.QueryRow(`
+		SELECT COUNT(*) FROM account_user
+			WHERE email = $1 AND id <> $2
+		`, ec.OldEmail, ec.User.ID).Scan(&count)
+	if err != nil {
+		return ec, 500, err
+	}
+	if count > 0 {
+		return ec, 409, nil
+	}
+
+	// change user email back to the old email, even if the email
+	// changed again after this change, the old email verified by the revert
+	res, err := tx.Exec(`
+		UPDATE account_user
+			SET email = $1, email_verified_at = $2,
+				version = version + 1, updated_at = $2
+			WHERE id = $3
+		`, ec.OldEmail, now, ec.User.ID)
+	if err != nil {
+		return ec, 500, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return ec, 500, err
+	}
+	if affected == 0 {
+		return ec, 400, nil
+	}
+
+	// mark email change as reverted, if no row affected
+	// then the token used by another request at the same time
+	res, err = tx.Exec(`
+		UPDATE account_emailchange SET reverted_at = $1
+			WHERE id = $2 AND reverted_at IS NULL
+		`, now, ec.ID)
+	if err != nil {
+		return ec, 500, err
+	}
+
+	affected, err = res.RowsAffected()
+	if err != nil {
+		return ec, 500, err
+	}
+	if affected == 0 {
+		return ec, 400, nil
+	}
+
+	// delete all other email changes of the user,
+	// so later changes can't be confirmed or reverted
+	_, err = tx.Exec(`
+		DELETE FROM account_emailchange
+			WHERE account_user_id = $1 AND id <> $2
+		`, ec.User.ID, ec.ID)
+	if err != nil {
+		return ec, 500, err
+	}
+
+	// revoke all user sessions
+	_, err = tx.Exec(`
+		DELETE FROM account_usersession
+			WHERE account_user_id = $1
+		`, ec.User.ID)
+	if err != nil {
+		return ec, 500, err
+	}
+
+	// commit transaction
+	err = tx.Commit()
+	if err != nil {
+		return ec, 500, err
+	}
+	////////////////////////////////////////////////////////////
+
+	return ec, 200, nil
+}
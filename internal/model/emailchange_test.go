@@ -0,0 +1,204 @@
+/*
+Package model containing structs and functions for
+database transaction
+*/
+package model
+
+import (
+	"testing"
+
+	"github.com/reyhanfikridz/ecom-account-service/internal/utils"
+)
+
+// TestCreateEmailChangeAndConfirmEmailChangeAndRevertEmailChange integration test
+// CreateEmailChange, ConfirmEmailChange, and RevertEmailChange
+func TestCreateEmailChangeAndConfirmEmailChangeAndRevertEmailChange(t *testing.T) {
+	//////////////////// CREATE USERS ////////////////////
+	// get connection to testing DB
+	DB, err := GetTestDBConnection()
+	if err != nil {
+		t.Fatalf("Connection to testing DB failed => " + err.Error())
+	}
+
+	// delete prev data first
+	for _, email := range []string{
+		"emailchange@gmail.com",
+		"emailchangenew@gmail.com",
+		"emailchangeother@gmail.com",
+	} {
+		_, err = DB.Exec(`DELETE FROM account_user WHERE email = $1`, email)
+		if err != nil {
+			t.Errorf("There's an error when deleting previous user testing data => " +
+				err.Error())
+		}
+	}
+
+	// create user data on DB
+	user, _ := CreateUser(DB, testConfig, User{
+		Email:       "emailchange@gmail.com",
+		Password:    "emailchange",
+		FullName:    "emailchange",
+		Address:     "address",
+		PhoneNumber: "08111111111",
+		Role:        "buyer",
+	})
+	CreateUser(DB, testConfig, User{
+		Email:       "emailchangeother@gmail.com",
+		Password:    "emailchange",
+		FullName:    "emailchange",
+		Address:     "address",
+		PhoneNumber: "08111111111",
+		Role:        "buyer",
+	})
+
+	token, err := utils.GenerateJWT(testConfig.JWTKeys, user.ID, user.Role,
+		testConfig.AccessTokenDuration)
+	if err != nil {
+		t.Errorf("There's an error when generating jwt token => " + err.Error())
+	}
+
+	_, err = CreateUserSession(DB, UserSession{Token: token, User: user})
+	if err != nil {
+		t.Errorf("There's an error when creating user session => " + err.Error())
+	}
+
+	//////////////////// CREATE EMAIL CHANGE ////////////////////
+	// create testing table
+	createTestTable := []struct {
+		CurrentPassword string
+		NewEmail        string
+		ExpectedStatus  int
+	}{
+		{
+			CurrentPassword: "wrongpassword",
+			NewEmail:        "emailchangenew@gmail.com",
+			ExpectedStatus:  400,
+		},
+		{
+			CurrentPassword: "emailchange",
+			NewEmail:        "emailchangeother@gmail.com",
+			ExpectedStatus:  409,
+		},
+		{
+			CurrentPassword: "emailchange",
+			NewEmail:        "emailchangenew@gmail.com",
+			ExpectedStatus:  200,
+		},
+	}
+
+	var ec EmailChange
+	for _, test := range createTestTable {
+		var status int
+		ec, status, err = CreateEmailChange(DB, testConfig, user.ID, test.CurrentPassword, test.NewEmail)
+		if err != nil {
+			t.Errorf("There's an error when creating email change => " + err.Error())
+		}
+		if status != test.ExpectedStatus {
+			t.Errorf("Expected status %d, but got %d", test.ExpectedStatus, status)
+		}
+	}
+
+	if ec.Token == "" || ec.OldEmail != "emailchange@gmail.com" {
+		t.Errorf("Expected email change token and old email filled, but got empty")
+	}
+
+	// email not changed before confirmed
+	_, err = GetUser(DB, "emailchange@gmail.com", 0)
+	if err != nil {
+		t.Errorf("Expected user email not changed before confirmed, " +
+			"but got error => " + err.Error())
+	}
+
+	//////////////////// CONFIRM EMAIL CHANGE ////////////////////
+	// create testing table
+	confirmTestTable := []struct {
+		Token          string
+		ExpectedStatus int
+	}{
+		{
+			Token:          "wrongtoken",
+			ExpectedStatus: 400,
+		},
+		{
+			Token:          ec.Token,
+			ExpectedStatus: 200,
+		},
+		{
+			Token:          ec.Token,
+			ExpectedStatus: 400,
+		},
+	}
+
+	var revertToken string
+	for _, test := range confirmTestTable {
+		confirmedEC, status, err := ConfirmEmailChange(DB, testConfig, test.Token)
+		if err != nil {
+			t.Errorf("There's an error when confirming email change => " + err.Error())
+		}
+		if status != test.ExpectedStatus {
+			t.Errorf("Expected status %d, but got %d", test.ExpectedStatus, status)
+		}
+
+		if status == 200 {
+			revertToken = confirmedEC.RevertToken
+		}
+	}
+
+	changedUser, err := GetUser(DB, "", user.ID)
+	if err != nil {
+		t.Errorf("There's an error when getting user => " + err.Error())
+	}
+	if changedUser.Email != "emailchangenew@gmail.com" || changedUser.EmailVerifiedAt == nil {
+		t.Errorf("Expected user email changed and verified, but got email '" +
+			changedUser.Email + "'")
+	}
+
+	//////////////////// REVERT EMAIL CHANGE ////////////////////
+	// create testing table
+	revertTestTable := []struct {
+		Token          string
+		ExpectedStatus int
+	}{
+		{
+			Token:          "wrongtoken",
+			ExpectedStatus: 400,
+		},
+		{
+			Token:          revertToken,
+			ExpectedStatus: 200,
+		},
+		{
+			Token:          revertToken,
+			ExpectedStatus: 400,
+		},
+	}
+
+	for _, test := range revertTestTable {
+		_, status, err := RevertEmailChange(DB, test.Token)
+		if err != nil {
+			t.Errorf("There's an error when reverting email change => " + err.Error())
+		}
+		if status != test.ExpectedStatus {
+			t.Errorf("Expected status %d, but got %d", test.ExpectedStatus, status)
+		}
+	}
+
+	revertedUser, err := GetUser(DB, "", user.ID)
+	if err != nil {
+		t.Errorf("There's an error when getting user => " + err.Error())
+	}
+	if revertedUser.Email != "emailchange@gmail.com" {
+		t.Errorf("Expected user email reverted, but got email '" +
+			revertedUser.Email + "'")
+	}
+
+	// all user sessions revoked after reverted
+	userSessions, err := GetUserSessions(DB, user.ID)
+	if err != nil {
+		t.Errorf("There's an error when getting user sessions => " + err.Error())
+	}
+	if len(userSessions) != 0 {
+		t.Errorf("Expected all user sessions revoked, but got %d sessions",
+			len(userSessions))
+	}
+}
@@ -0,0 +1,77 @@
+/*
+Package model containing structs and functions for
+database transaction
+*/
+package model
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/reyhanfikridz/ecom-account-service/internal/config"
+)
+
+// func for get user role that effective by email verification policy,
+// user with unverified email get unverified role
+// if the policy is restrict role
+func EffectiveRole(c config.Config, u User) string {
+	if u.EmailVerifiedAt == nil &&
+		c.EmailVerificationPolicy == config.EmailVerificationPolicyRestrictRole {
+		return c.EmailUnverifiedRole
+	}
+
+	return u.Role
+}
+
+// func for verify user email, the email must be still the same
+// as the email when verification link sent, user pending verification
+// become active
+//
+// Return status 400 if user not exist or email already changed
+func VerifyUserEmail(DB *sql.DB, userID int, email string) (int, error) {
+	// set verified time, keep the first verified time if already verified
+	res, err := DB.Exec(`
+		UPDATE account_user
+			SET email_verified_at = COALESCE(email_verified_at, $1),
+				status = CASE WHEN status = $4 THEN $5 ELSE status END
+			WHERE id = $2 AND email = $3
+		`, time.Now().UTC(), userID, email,
+		UserStatusPendingVerification, UserStatusActive)
+	if err != nil {
+		return 500, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return 500, err
+	}
+	if affected == 0 {
+		return 400, nil
+	}
+
+	return 200, nil
+}
+
+// func for mark email verification sent to user,
+// only once in resend interval
+//
+// Return false if email verification already sent within resend interval
+func MarkEmailVerificationSent(DB *sql.DB, c config.Config, userID int) (bool, error) {
+	now := time.Now().UTC()
+	res, err := DB.Exec(`
+		UPDATE account_user
+			SET email_verification_sent_at = $1
+			WHERE id = $2 AND (email_verification_sent_at IS NULL
+				OR email_verification_sent_at < $3)
+		`, now, userID, now.Add(-c.EmailVerificationResendInterval))
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected > 0, nil
+}
@@ -0,0 +1,145 @@
+/*
+Package model containing structs and functions for
+database transaction
+*/
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/reyhanfikridz/ecom-account-service/internal/config"
+)
+
+// TestEffectiveRole test EffectiveRole
+func TestEffectiveRole(t *testing.T) {
+	c := testConfig
+	verifiedAt := time.Now().UTC()
+
+	// create testing table
+	testTable := []struct {
+		Policy       string
+		User         User
+		ExpectedRole string
+	}{
+		{
+			Policy:       config.EmailVerificationPolicyNone,
+			User:         User{Role: "buyer"},
+			ExpectedRole: "buyer",
+		},
+		{
+			Policy:       config.EmailVerificationPolicyRestrictRole,
+			User:         User{Role: "buyer"},
+			ExpectedRole: testConfig.EmailUnverifiedRole,
+		},
+		{
+			Policy:       config.EmailVerificationPolicyRestrictRole,
+			User:         User{Role: "buyer", EmailVerifiedAt: &verifiedAt},
+			ExpectedRole: "buyer",
+		},
+	}
+
+	for _, test := range testTable {
+		c.EmailVerificationPolicy = test.Policy
+		role := EffectiveRole(c, test.User)
+		if role != test.ExpectedRole {
+			t.Errorf("Expected role '%s', but got '%s'", test.ExpectedRole, role)
+		}
+	}
+}
+
+// TestMarkEmailVerificationSentAndVerifyUserEmail integration test
+// MarkEmailVerificationSent and VerifyUserEmail
+func TestMarkEmailVerificationSentAndVerifyUserEmail(t *testing.T) {
+	c := testConfig
+	c.EmailVerificationPolicy = config.EmailVerificationPolicyBlockLogin
+
+	//////////////////// CREATE USER ////////////////////
+	// create user
+	user := User{
+		Email:       "verify@gmail.com",
+		Password:    "verify",
+		FullName:    "verify",
+		Address:     "address",
+		PhoneNumber: "08111111111",
+		Role:        "buyer",
+	}
+
+	// get connection to testing DB
+	DB, err := GetTestDBConnection()
+	if err != nil {
+		t.Fatalf("Connection to testing DB failed => " + err.Error())
+	}
+
+	// delete prev data first
+	_, err = DB.Exec(`DELETE FROM account_user WHERE email = $1`, user.Email)
+	if err != nil {
+		t.Errorf("There's an error when deleting previous user testing data => " +
+			err.Error())
+	}
+
+	// create user data on DB
+	user, _ = CreateUser(DB, c, user)
+
+	// login blocked before email verified
+	_, _, status, _, err := AuthenticateUser(DB, c, User{
+		Email:    "verify@gmail.com",
+		Password: "verify",
+	}, "test-agent", "127.0.0.1")
+	if err != nil || status != 403 {
+		t.Errorf("Expected login blocked with status 403, but got status %d", status)
+	}
+
+	//////////////////// MARK EMAIL VERIFICATION SENT ////////////////////
+	// only the first mark success within resend interval
+	for _, expected := range []bool{true, false} {
+		isMarked, err := MarkEmailVerificationSent(DB, c, user.ID)
+		if err != nil {
+			t.Errorf("Expected error nil, but got not nil => " + err.Error())
+		}
+
+		if isMarked != expected {
+			t.Errorf("Expected marked %t, but got %t", expected, isMarked)
+		}
+	}
+
+	//////////////////// VERIFY USER EMAIL ////////////////////
+	// create testing table
+	testTable := []struct {
+		UserID         int
+		Email          string
+		ExpectedStatus int
+	}{
+		{
+			UserID:         user.ID,
+			Email:          "verifychanged@gmail.com",
+			ExpectedStatus: 400,
+		},
+		{
+			UserID:         user.ID,
+			Email:          user.Email,
+			ExpectedStatus: 200,
+		},
+	}
+
+	for _, test := range testTable {
+		status, err := VerifyUserEmail(DB, test.UserID, test.Email)
+		if err != nil {
+			t.Errorf("There's an error when verify user email => " + err.Error())
+		}
+
+		if test.ExpectedStatus != status {
+			t.Errorf("Expected status %d, but got status %d",
+				test.ExpectedStatus, status)
+		}
+	}
+
+	// check user can login after email verified
+	_, _, status, _, err = AuthenticateUser(DB, c, User{
+		Email:    "verify@gmail.com",
+		Password: "verify",
+	}, "test-agent", "127.0.0.1")
+	if err != nil || status != 200 {
+		t.Errorf("Expected login success after email verified, but got status %d", status)
+	}
+}
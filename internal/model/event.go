@@ -0,0 +1,81 @@
+/*
+Package model containing structs and functions for
+database transaction
+*/
+package model
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+// event model, event saved in the same transaction as the change
+// that caused it, then published later (outbox)
+type Event struct {
+	ID          int        `json:"id"`
+	Type        string     `json:"type"`
+	Payload     string     `json:"payload"`
+	CreatedAt   time.Time  `json:"created_at"`
+	PublishedAt *time.Time `json:"published_at"`
+}
+
+// func for create event with its data as JSON payload in a transaction
+func createEvent(tx *sql.Tx, eventType string, data any) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO account_event(event_type, payload, created_at)
+			VALUES($1, $2, $3)
+		`, eventType, string(payload), time.Now().UTC())
+
+	return err
+}
+
+// func for get events not published yet, the oldest event first
+func GetUnpublishedEvents(DB *sql.DB, limit int) ([]Event, error) {
+	events := []Event{}
+	rows, err := DB.Query(`
+		SELECT id, event_type, payload, created_at, published_at
+			FROM account_event
+			WHERE published_at IS NULL
+			ORDER BY id
+			LIMIT $1
+		`, limit)
+	if err != nil {
+		return events, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		e := Event{}
+		err = rows.Scan(
+			&e.ID,
+			&e.Type,
+			&e.Payload,
+			&e.CreatedAt,
+			&e.PublishedAt,
+		)
+		if err != nil {
+			return events, err
+		}
+
+		events = append(events, e)
+	}
+
+	return events, rows.Err()
+}
+
+// func for mark event as published
+func MarkEventPublished(DB *sql.DB, ID int) error {
+	_, err := DB.Exec(`
+		UPDATE account_event
+			SET published_at = $1
+			WHERE id = $2
+		`, time.Now().UTC(), ID)
+
+	return err
+}
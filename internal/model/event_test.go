@@ -0,0 +1,75 @@
+/*
+Package model containing structs and functions for
+database transaction
+*/
+package model
+
+import (
+	"testing"
+)
+
+// TestGetUnpublishedEventsAndMarkEventPublished integration test
+// createEvent, GetUnpublishedEvents, and MarkEventPublished
+func TestGetUnpublishedEventsAndMarkEventPublished(t *testing.T) {
+	// get connection to testing DB
+	DB, err := GetTestDBConnection()
+	if err != nil {
+		t.Fatalf("Connection to testing DB failed => " + err.Error())
+	}
+
+	// delete prev data first
+	_, err = DB.Exec(`DELETE FROM account_event WHERE event_type = $1`, "test.event")
+	if err != nil {
+		t.Errorf("There's an error when deleting previous event testing data => " +
+			err.Error())
+	}
+
+	// create event
+	tx, err := DB.Begin()
+	if err != nil {
+		t.Errorf("There's an error when beginning transaction => " + err.Error())
+	}
+	err = createEvent(tx, "test.event", map[string]any{"test": true})
+	if err != nil {
+		t.Errorf("There's an error when creating event => " + err.Error())
+	}
+	err = tx.Commit()
+	if err != nil {
+		t.Errorf("There's an error when committing transaction => " + err.Error())
+	}
+
+	// get created event
+	getTestEvent := func() (Event, bool) {
+		events, err := GetUnpublishedEvents(DB, 1000)
+		if err != nil {
+			t.Errorf("There's an error when getting unpublished events => " + err.Error())
+		}
+
+		for _, e := range events {
+			if e.Type == "test.event" {
+				return e, true
+			}
+		}
+
+		return Event{}, false
+	}
+
+	e, ok := getTestEvent()
+	if !ok {
+		t.Errorf("Expected event unpublished, but not found")
+	}
+	if e.Payload != `{"test":true}` {
+		t.Errorf("Expected event payload '{\"test\":true}', but got '" + e.Payload + "'")
+	}
+
+	// mark event as published
+	err = MarkEventPublished(DB, e.ID)
+	if err != nil {
+		t.Errorf("There's an error when marking event published => " + err.Error())
+	}
+
+	_, ok = getTestEvent()
+	if ok {
+		t.Errorf("Expected event already published, but still unpublished")
+	}
+}
@@ -0,0 +1,240 @@
+/*
+Package model containing structs and functions for
+database transaction
+*/
+package model
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/reyhanfikridz/ecom-account-service/internal/config"
+)
+
+// login failure model
+//
+// Failed login attempts tracked per account (key "email:<email>")
+// and per source IP (key "ip:<ip address>"). Every failure delay the next
+// attempt exponentially, and the key locked for a while after
+// failure count reach the threshold.
+type LoginFailure struct {
+	ID           int        `json:"id"`
+	Key          string     `json:"key"`
+	FailureCount int        `json:"failure_count"`
+	LastFailedAt time.Time  `json:"last_failed_at"`
+	LockedUntil  *time.Time `json:"locked_until"`
+}
+
+// func for get login failure keys of an account email and source IP
+func LoginFailureKeys(email string, IPAddress string) []string {
+	keys := []string{}
+	if strings.TrimSpace(email) != "" {
+		keys = append(keys, "email:"+strings.ToLower(strings.TrimSpace(email)))
+	}
+	if strings.TrimSpace(IPAddress) != "" {
+		keys = append(keys, "ip:"+strings.TrimSpace(IPAddress))
+	}
+
+	// key can't be longer than the column
+	for i := range keys {
+		if len(keys[i]) > 100 {
+			keys[i] = keys[i][:100]
+		}
+	}
+
+	return keys
+}
+
+// func for get failure threshold of login failure key
+func getLoginFailureThreshold(c config.Config, key string) int {
+	if strings.HasPrefix(key, "ip:") {
+		return c.LoginIPFailureThreshold
+	}
+
+	return c.LoginFailureThreshold
+}
+
+// func for get login failures of an account email and source IP
+func GetLoginFailures(DB *sql.DB, email string, IPAddress string) ([]LoginFailure, error) {
+	loginFailures := []LoginFailure{}
+	for _, key := range LoginFailureKeys(email, IPAddress) {
+		lf := LoginFailure{}
+		err := DB.QueryRow(`
+			SELECT id, failure_key, failure_count, last_failed_at, locked_until
+				FROM account_loginfailure
+				WHERE failure_key = $1
+			`, key).Scan(
+			&lf.ID,
+			&lf.Key,
+			&lf.FailureCount,
+			&lf.LastFailedAt,
+			&lf.LockedUntil,
+		)
+		if err == sql.ErrNoRows {
+			continue
+		} else if err != nil {
+			return loginFailures, err
+		}
+
+		loginFailures = append(loginFailures, lf)
+	}
+
+	return loginFailures, nil
+}
+
+// func for check login of an account email and source IP
+// allowed or not, also return time until login allowed again
+//
+// Return status 423 if locked (failure count reach the threshold),
+// status 429 if still delayed after the last failure, or status 200 if allowed
+func CheckLoginLockout(DB *sql.DB, c config.Config, email string, IPAddress string) (
+	int, time.Time, error) {
+	loginFailures, err := GetLoginFailures(DB, email, IPAddress)
+	if err != nil {
+		return 500, time.Time{}, err
+	}
+
+	status, lockedUntil := LoginLockout(c, loginFailures, time.Now().UTC())
+	return status, lockedUntil, nil
+}
+
+// func for get login lockout status of login failures at a time,
+// also return time until login allowed again, lockout take precedence over delay
+//
+// Return status 423 if locked (failure count reach the threshold),
+// status 429 if still delayed after the last failure, or status 200 if allowed
+func LoginLockout(c config.Config, loginFailures []LoginFailure, now time.Time) (
+	int, time.Time) {
+	status := 200
+	lockedUntil := time.Time{}
+	for _, lf := range loginFailures {
+		if lf.LockedUntil == nil || !lf.LockedUntil.After(now) {
+			continue
+		}
+
+		lfStatus := 429
+		if lf.FailureCount >= getLoginFailureThreshold(c, lf.Key) {
+			lfStatus = 423
+		}
+
+		// 423 (lockout) wins over 429 (delay), the later time wins on the same status
+		if status == 200 || (lfStatus == 423 && status == 429) ||
+			(lfStatus == status && lf.LockedUntil.After(lockedUntil)) {
+			status = lfStatus
+			lockedUntil = *lf.LockedUntil
+		}
+	}
+
+	return status, lockedUntil
+}
+
+// func for record failed login of an account email and source IP
+//
+// Failure count restarted if the last failure older than lockout duration
+func RecordLoginFailure(DB *sql.DB, c config.Config, email string,
+	IPAddress string) error {
+	now := time.Now().UTC()
+	for _, key := range LoginFailureKeys(email, IPAddress) {
+		err := recordLoginFailure(DB, c, key, now)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// func for record failed login of a login failure key
+func recordLoginFailure(DB *sql.DB, c config.Config, key string, now time.Time) error {
+	//////////////////// begin transaction /////////////////////
+	tx, err := DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() // rollback transaction if fail (return before commit)
+
+	// create login failure if not exist
+	_, err = tx.Exec(`
+		INSERT INTO account_loginfailure(failure_key, failure_count, last_failed_at)
+			VALUES($1, 0, $2)
+			ON CONFLICT (failure_key) DO NOTHING
+		`, key, now)
+	if err != nil {
+		return err
+	}
+
+	// increase failure count
+	var failureCount int
+	err = tx.QueryRow(`
+		UPDATE account_loginfailure
+			SET failure_count = CASE WHEN last_failed_at < $1
+				THEN 1 ELSE failure_count + 1 END,
+				last_failed_at = $2
+			WHERE failure_key = $3
+			RETURNING failure_count
+		`, now.Add(-c.LoginLockoutDuration), now, key).Scan(&failureCount)
+	if err != nil {
+		return err
+	}
+
+	lockedUntil := LoginFailureLockedUntil(c, key, failureCount, now)
+	_, err = tx.Exec(`
+		UPDATE account_loginfailure SET locked_until = $1
+			WHERE failure_key = $2
+		`, lockedUntil, key)
+	if err != nil {
+		return err
+	}
+
+	// commit transaction
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+	////////////////////////////////////////////////////////////
+
+	return nil
+}
+
+// func for get time until login of a login failure key allowed again
+// after its failure count, the next attempt delayed exponentially
+// until reach the threshold, then locked for lockout duration
+func LoginFailureLockedUntil(c config.Config, key string, failureCount int,
+	now time.Time) time.Time {
+	if failureCount < getLoginFailureThreshold(c, key) {
+		delay := c.LoginBackoffBase
+		for i := 1; i < failureCount && delay < c.LoginLockoutDuration; i++ {
+			delay *= 2
+		}
+		if delay < c.LoginLockoutDuration {
+			return now.Add(delay)
+		}
+	}
+
+	return now.Add(c.LoginLockoutDuration)
+}
+
+// func for clear login failures of an account email and/or source IP
+// (e.g. after successful login or unlocked by admin),
+// return false if there's no login failure cleared
+func ClearLoginFailures(DB *sql.DB, email string, IPAddress string) (bool, error) {
+	isCleared := false
+	for _, key := range LoginFailureKeys(email, IPAddress) {
+		res, err := DB.Exec(`
+			DELETE FROM account_loginfailure
+				WHERE failure_key = $1
+			`, key)
+		if err != nil {
+			return false, err
+		}
+
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return false, err
+		}
+		isCleared = isCleared || affected > 0
+	}
+
+	return isCleared, nil
+}
@@ -0,0 +1,244 @@
+/*
+Package model containing structs and functions for
+database transaction
+*/
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+// TestAuthenticateUserLockout integration test AuthenticateUser
+// with RecordLoginFailure, CheckLoginLockout, and ClearLoginFailures
+func TestAuthenticateUserLockout(t *testing.T) {
+	c := testConfig
+	c.LoginFailureThreshold = 3
+	c.LoginBackoffBase = 0
+
+	//////////////////// CREATE USER ////////////////////
+	// create user
+	user := User{
+		Email:       "lockout@gmail.com",
+		Password:    "lockout",
+		FullName:    "lockout",
+		Address:     "address",
+		PhoneNumber: "08111111111",
+		Role:        "buyer",
+	}
+
+	// get connection to testing DB
+	DB, err := GetTestDBConnection()
+	if err != nil {
+		t.Fatalf("Connection to testing DB failed => " + err.Error())
+	}
+
+	// delete prev data first
+	_, err = DB.Exec(`DELETE FROM account_user WHERE email = $1`, user.Email)
+	if err != nil {
+		t.Errorf("There's an error when deleting previous user testing data => " +
+			err.Error())
+	}
+
+	// create user data on DB
+	_, err = CreateUser(DB, c, user)
+	if err != nil {
+		t.Errorf("There's an error when creating user => " + err.Error())
+	}
+
+	//////////////////// AUTHENTICATE USER ////////////////////
+	// create testing table
+	testTable := []struct {
+		Password       string
+		IPAddress      string
+		ExpectedStatus int
+	}{
+		{
+			Password:       "wrong",
+			IPAddress:      "10.0.0.1",
+			ExpectedStatus: 400,
+		},
+		{
+			Password:       "lockout",
+			IPAddress:      "10.0.0.1",
+			ExpectedStatus: 200,
+		},
+		{
+			Password:       "wrong",
+			IPAddress:      "10.0.0.1",
+			ExpectedStatus: 400,
+		},
+		{
+			Password:       "wrong",
+			IPAddress:      "10.0.0.2",
+			ExpectedStatus: 400,
+		},
+		{
+			Password:       "wrong",
+			IPAddress:      "10.0.0.3",
+			ExpectedStatus: 400,
+		},
+		{
+			Password:       "lockout",
+			IPAddress:      "10.0.0.4",
+			ExpectedStatus: 423,
+		},
+	}
+
+	for _, test := range testTable {
+		_, _, status, _, err := AuthenticateUser(DB, c, User{
+			Email:    user.Email,
+			Password: test.Password,
+		}, "test-agent", test.IPAddress)
+		if err != nil {
+			t.Errorf("There's an error when authenticate user => " + err.Error())
+		}
+
+		if test.ExpectedStatus != status {
+			t.Errorf("Expected status %d, but got status %d",
+				test.ExpectedStatus, status)
+		}
+	}
+
+	// check lockout time
+	status, lockedUntil, err := CheckLoginLockout(DB, c, user.Email, "")
+	if err != nil {
+		t.Errorf("Expected error nil, but got not nil => " + err.Error())
+	}
+
+	if status != 423 || !lockedUntil.After(time.Now().UTC()) {
+		t.Errorf("Expected status 423 with locked until in the future, but got status %d",
+			status)
+	}
+
+	//////////////////// CLEAR LOGIN FAILURES ////////////////////
+	isCleared, err := ClearLoginFailures(DB, user.Email, "")
+	if err != nil || !isCleared {
+		t.Errorf("Expected login failures cleared, but not cleared")
+	}
+
+	_, _, status, _, err = AuthenticateUser(DB, c, User{
+		Email:    user.Email,
+		Password: "lockout",
+	}, "test-agent", "10.0.0.4")
+	if err != nil || status != 200 {
+		t.Errorf("Expected login success after cleared, but got status %d", status)
+	}
+
+	//////////////////// BACKOFF ////////////////////
+	// next attempt delayed after failed login
+	c.LoginBackoffBase = time.Hour
+
+	for _, expectedStatus := range []int{400, 429} {
+		_, _, status, _, err = AuthenticateUser(DB, c, User{
+			Email:    user.Email,
+			Password: "wrong",
+		}, "test-agent", "10.0.0.5")
+		if err != nil {
+			t.Errorf("There's an error when authenticate user => " + err.Error())
+		}
+
+		if status != expectedStatus {
+			t.Errorf("Expected status %d, but got status %d", expectedStatus, status)
+		}
+	}
+}
+
+// TestLoginLockout test LoginLockout pick the strongest lock of login failures
+func TestLoginLockout(t *testing.T) {
+	now := time.Now().UTC()
+	past := now.Add(-time.Minute)
+	soon := now.Add(time.Second)
+	later := now.Add(time.Hour)
+
+	// create testing table
+	testTable := []struct {
+		LoginFailures       []LoginFailure
+		ExpectedStatus      int
+		ExpectedLockedUntil time.Time
+	}{
+		{
+			LoginFailures:  []LoginFailure{},
+			ExpectedStatus: 200,
+		},
+		{
+			LoginFailures: []LoginFailure{
+				{Key: "email:test@gmail.com", FailureCount: 10, LockedUntil: &past},
+				{Key: "ip:192.0.2.1", FailureCount: 1},
+			},
+			ExpectedStatus: 200,
+		},
+		{
+			LoginFailures: []LoginFailure{
+				{Key: "email:test@gmail.com", FailureCount: 1, LockedUntil: &soon},
+			},
+			ExpectedStatus:      429,
+			ExpectedLockedUntil: soon,
+		},
+		{
+			LoginFailures: []LoginFailure{
+				{Key: "email:test@gmail.com", FailureCount: 5, LockedUntil: &later},
+				{Key: "ip:192.0.2.1", FailureCount: 20, LockedUntil: &soon},
+			},
+			ExpectedStatus:      423,
+			ExpectedLockedUntil: later,
+		},
+		{
+			LoginFailures: []LoginFailure{
+				{Key: "email:test@gmail.com", FailureCount: 1, LockedUntil: &later},
+				{Key: "ip:192.0.2.1", FailureCount: 20, LockedUntil: &soon},
+			},
+			ExpectedStatus:      423,
+			ExpectedLockedUntil: soon,
+		},
+		{
+			LoginFailures: []LoginFailure{
+				{Key: "email:test@gmail.com", FailureCount: 5, LockedUntil: &later},
+				{Key: "ip:192.0.2.1", FailureCount: 5, LockedUntil: &soon},
+			},
+			ExpectedStatus:      423,
+			ExpectedLockedUntil: later,
+		},
+	}
+
+	// loop test in test table
+	for i, test := range testTable {
+		status, lockedUntil := LoginLockout(testConfig, test.LoginFailures, now)
+		if status != test.ExpectedStatus || !lockedUntil.Equal(test.ExpectedLockedUntil) {
+			t.Errorf("Expected status %d locked until %s of test %d, but got status %d until %s",
+				test.ExpectedStatus, test.ExpectedLockedUntil, i, status, lockedUntil)
+		}
+	}
+}
+
+// TestLoginFailureLockedUntil test LoginFailureLockedUntil
+// with exponential backoff before locked
+func TestLoginFailureLockedUntil(t *testing.T) {
+	c := testConfig
+	c.LoginFailureThreshold = 5
+	c.LoginBackoffBase = time.Second
+	c.LoginLockoutDuration = 15 * time.Minute
+	now := time.Now().UTC()
+
+	// create testing table
+	testTable := []struct {
+		Key           string
+		FailureCount  int
+		ExpectedDelay time.Duration
+	}{
+		{Key: "email:test@gmail.com", FailureCount: 1, ExpectedDelay: time.Second},
+		{Key: "email:test@gmail.com", FailureCount: 3, ExpectedDelay: 4 * time.Second},
+		{Key: "email:test@gmail.com", FailureCount: 5, ExpectedDelay: 15 * time.Minute},
+		{Key: "ip:192.0.2.1", FailureCount: 5, ExpectedDelay: 16 * time.Second},
+		{Key: "ip:192.0.2.1", FailureCount: 15, ExpectedDelay: 15 * time.Minute},
+	}
+
+	// loop test in test table
+	for _, test := range testTable {
+		lockedUntil := LoginFailureLockedUntil(c, test.Key, test.FailureCount, now)
+		if !lockedUntil.Equal(now.Add(test.ExpectedDelay)) {
+			t.Errorf("Expected locked %s after %d failures of %q, but got %s",
+				test.ExpectedDelay, test.FailureCount, test.Key, lockedUntil.Sub(now))
+		}
+	}
+}
@@ -0,0 +1,70 @@
+/*
+Package model containing structs and functions for
+database transaction
+*/
+package model
+
+import (
+	"database/sql"
+	"time"
+)
+
+// login history model, a login attempt of a user
+// with right or wrong credentials
+type LoginHistory struct {
+	ID          int       `json:"id"`
+	UserAgent   string    `json:"user_agent"`
+	IPAddress   string    `json:"ip_address"`
+	IsSucceeded bool      `json:"is_succeeded"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// func for record a login attempt of a user
+func RecordLoginHistory(DB *sql.DB, userID int, userAgent string, IPAddress string,
+	isSucceeded bool) error {
+	// user agent can't be longer than the column
+	if len(userAgent) > 255 {
+		userAgent = userAgent[:255]
+	}
+
+	_, err := DB.Exec(`
+		INSERT INTO account_loginhistory(account_user_id, user_agent, ip_address,
+			is_succeeded, created_at)
+			VALUES($1, $2, $3, $4, $5)
+		`, userID, userAgent, IPAddress, isSucceeded, time.Now().UTC())
+
+	return err
+}
+
+// func for get login history of a user, newest first
+func GetLoginHistory(DB *sql.DB, userID int) ([]LoginHistory, error) {
+	loginHistory := []LoginHistory{}
+	rows, err := DB.Query(`
+		SELECT id, user_agent, ip_address, is_succeeded, created_at
+			FROM account_loginhistory
+			WHERE account_user_id = $1
+			ORDER BY created_at DESC, id DESC
+		`, userID)
+	if err != nil {
+		return loginHistory, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		lh := LoginHistory{}
+		err = rows.Scan(
+			&lh.ID,
+			&lh.UserAgent,
+			&lh.IPAddress,
+			&lh.IsSucceeded,
+			&lh.CreatedAt,
+		)
+		if err != nil {
+			return loginHistory, err
+		}
+
+		loginHistory = append(loginHistory, lh)
+	}
+
+	return loginHistory, rows.Err()
+}
@@ -0,0 +1,93 @@
+/*
+Package model containing structs and functions for
+database transaction
+*/
+package model
+
+import (
+	"testing"
+)
+
+// TestRecordLoginHistoryAndGetLoginHistory integration test
+// RecordLoginHistory and GetLoginHistory
+func TestRecordLoginHistoryAndGetLoginHistory(t *testing.T) {
+	// get connection to testing DB
+	DB, err := GetTestDBConnection()
+	if err != nil {
+		t.Fatalf("Connection to testing DB failed => " + err.Error())
+	}
+
+	// delete prev data first
+	_, err = DB.Exec(`DELETE FROM account_user WHERE email = $1`,
+		"testloginhistory@gmail.com")
+	if err != nil {
+		t.Errorf("There's an error when deleting previous user testing data => " +
+			err.Error())
+	}
+
+	// create user data
+	user, err := CreateUser(DB, testConfig, User{
+		Email:       "testloginhistory@gmail.com",
+		Password:    "testloginhistory",
+		FullName:    "testloginhistory",
+		Address:     "address",
+		PhoneNumber: "08111111111",
+		Role:        "buyer",
+	})
+	if err != nil {
+		t.Errorf("There's an error when creating user => " + err.Error())
+	}
+
+	// login with wrong password, then with right password
+	_, _, status, _, err := AuthenticateUser(DB, testConfig, User{
+		Email:    "testloginhistory@gmail.com",
+		Password: "wrongpassword",
+	}, "test-agent", "127.0.0.1")
+	if status != 400 || err != nil {
+		t.Errorf("Expected status 400 when login with wrong password, but got %d", status)
+	}
+
+	_, _, _, _, err = AuthenticateUser(DB, testConfig, User{
+		Email:    "testloginhistory@gmail.com",
+		Password: "testloginhistory",
+	}, "test-agent", "127.0.0.1")
+	if err != nil {
+		t.Errorf("There's an error when authenticating user => " + err.Error())
+	}
+
+	// record login history with too long user agent
+	longUserAgent := ""
+	for len(longUserAgent) < 300 {
+		longUserAgent += "test-agent "
+	}
+	err = RecordLoginHistory(DB, user.ID, longUserAgent, "127.0.0.1", false)
+	if err != nil {
+		t.Errorf("There's an error when recording login history => " + err.Error())
+	}
+
+	// check login history, newest first
+	loginHistory, err := GetLoginHistory(DB, user.ID)
+	if err != nil {
+		t.Errorf("There's an error when getting login history => " + err.Error())
+	}
+
+	expectedIsSucceeded := []bool{false, true, false}
+	if len(loginHistory) != len(expectedIsSucceeded) {
+		t.Fatalf("Expected %d login history, but got %d",
+			len(expectedIsSucceeded), len(loginHistory))
+	}
+	for i, lh := range loginHistory {
+		if lh.IsSucceeded != expectedIsSucceeded[i] {
+			t.Errorf("Expected login history %d is succeeded %t, but got %t",
+				i, expectedIsSucceeded[i], lh.IsSucceeded)
+		}
+	}
+	if len(loginHistory[0].UserAgent) != 255 {
+		t.Errorf("Expected user agent truncated to 255, but got %d",
+			len(loginHistory[0].UserAgent))
+	}
+	if loginHistory[1].UserAgent != "test-agent" || loginHistory[1].IPAddress != "127.0.0.1" {
+		t.Errorf("Expected user agent 'test-agent' and IP address '127.0.0.1', but got '" +
+			loginHistory[1].UserAgent + "' and '" + loginHistory[1].IPAddress + "'")
+	}
+}
@@ -0,0 +1,271 @@
+/*
+Package model containing structs and functions for
+database transaction
+*/
+package model
+
+import (
+	"database/sql"
+	"strconv"
+	"time"
+
+	"github.com/reyhanfikridz/ecom-account-service/internal/config"
+	"github.com/reyhanfikridz/ecom-account-service/internal/utils"
+)
+
+// number of recovery codes generated when totp confirmed
+const RecoveryCodeCount = 10
+
+// func for enroll totp secret of a user, return the new secret
+//
+// The secret not active until confirmed by ConfirmTOTP,
+// enroll again before confirmed replace the secret.
+// Return status 400 if two-factor authentication already enabled
+func EnrollTOTP(DB *sql.DB, userID int) (string, int, error) {
+	// generate totp secret
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		return "", 500, err
+	}
+
+	// save secret if two-factor authentication not enabled yet
+	res, err := DB.Exec(`
+		UPDATE account_user SET totp_secret = $1, totp_last_step = 0
+			WHERE id = $2 AND totp_enabled_at IS NULL
+		`, secret, userID)
+	if err != nil {
+		return "", 500, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return "", 500, err
+	}
+	if affected == 0 {
+		return "", 400, nil
+	}
+
+	return secret, 200, nil
+}
+
+// func for confirm enrolled totp secret with the first totp code,
+// enable two-factor authentication and return new recovery codes
+//
+// Recovery codes only returned once, saved as hash in database.
+// Return status 400 if secret not enrolled, already enabled, or code not valid
+func ConfirmTOTP(DB *sql.DB, userID int, code string) ([]string, int, error) {
+	//////////////////// begin transaction /////////////////////
+	tx, err := DB.Begin()
+	if err != nil {
+		return nil, 500, err
+	}
+	defer tx.Rollback() // rollback transaction if fail (return before commit)
+
+	// get enrolled totp secret
+	var secret sql.NullString
+	var enabledAt sql.NullTime
+	err = tx.QueryRow(`
+		SELECT totp_secret, totp_enabled_at
+			FROM account_user
+			WHERE id = $1
+		`, userID).Scan(&secret, &enabledAt)
+	if err == sql.ErrNoRows { // if user not exist
+		return nil, 400, nil
+	} else if err != nil {
+		return nil, 500, err
+	}
+
+	if !secret.Valid || enabledAt.Valid {
+		return nil, 400, nil
+	}
+
+	// validate first totp code
+	now := time.Now().UTC()
+	step := utils.ValidateTOTPCode(secret.String, code, now, 0)
+	if step == 0 {
+		return nil, 400, nil
+	}
+
+	// enable two-factor authentication, if no row affected
+	// then enabled by another request at the same time
+	res, err := tx.Exec(`
+		UPDATE account_user SET totp_enabled_at = $1, totp_last_step = $2
+			WHERE id = $3 AND totp_enabled_at IS NULL
+		`, now, step, userID)
+	if err != nil {
+		return nil, 500, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return nil, 500, err
+	}
+	if affected == 0 {
+		return nil, 400, nil
+	}
+
+	// replace recovery codes
+	_, err = tx.Exec(`
+		DELETE FROM account_recoverycode
+			WHERE account_user_id = $1
+		`, userID)
+	if err != nil {
+		return nil, 500, err
+	}
+
+	recoveryCodes := []string{}
+	for i := 0; i < RecoveryCodeCount; i++ {
+		recoveryCode, err := utils.GenerateRecoveryCode()
+		if err != nil {
+			return nil, 500, err
+		}
+
+		_, err = tx.Exec(`
+			INSERT INTO account_recoverycode(code_hash, account_user_id)
+				VALUES($1, $2)
+			`, utils.HashToken(recoveryCode), userID)
+		if err != nil {
+			return nil, 500, err
+		}
+
+		recoveryCodes = append(recoveryCodes, recoveryCode)
+	}
+
+	// commit transaction
+	err = tx.Commit()
+	if err != nil {
+		return nil, 500, err
+	}
+	////////////////////////////////////////////////////////////
+
+	return recoveryCodes, 200, nil
+}
+
+// func for complete login of user with two-factor authentication
+// by mfa challenge token and totp code or recovery code,
+// return access token and refresh token
+//
+// Return status 400 if mfa challenge token or code not valid,
+// failed code counted as failed login like in AuthenticateUser.
+// Return status 403 if user suspended
+func AuthenticateUserMFA(DB *sql.DB, c config.Config, mfaToken string, code string,
+	userAgent string, IPAddress string) (string, string, int, User, error) {
+	// validate mfa challenge token
+	claimsMap := utils.ValidateActionJWT(c.JWTKeys, mfaToken, "mfa")
+	if claimsMap == nil {
+		return "", "", 400, User{}, nil
+	}
+
+	userID, err := strconv.Atoi(claimsMap["sub"])
+	if err != nil {
+		return "", "", 400, User{}, nil
+	}
+
+	// get existed user data
+	existedUser, err := GetUser(DB, "", userID)
+	if err == sql.ErrNoRows {
+		return "", "", 400, existedUser, nil
+	} else if err != nil {
+		return "", "", 500, existedUser, err
+	}
+
+	if existedUser.TOTPEnabledAt == nil {
+		return "", "", 400, existedUser, nil
+	}
+
+	// check user not deleted or suspended after mfa challenge token created
+	if existedUser.Status == UserStatusDeleted {
+		return "", "", 400, existedUser, nil
+	} else if EffectiveStatus(existedUser) == UserStatusSuspended {
+		return "", "", 403, existedUser, nil
+	}
+
+	// check account or IP address locked or not
+	status, _, err := CheckLoginLockout(DB, c, existedUser.Email, IPAddress)
+	if err != nil {
+		return "", "", 500, existedUser, err
+	}
+	if status != 200 {
+		return "", "", status, existedUser, nil
+	}
+
+	// check totp code or recovery code
+	isValid, err := verifyMFACode(DB, existedUser.ID, code)
+	if err != nil {
+		return "", "", 500, existedUser, err
+	}
+	if !isValid {
+		err = RecordLoginFailure(DB, c, existedUser.Email, IPAddress)
+		if err != nil {
+			return "", "", 500, existedUser, err
+		}
+
+		err = RecordLoginHistory(DB, existedUser.ID, userAgent, IPAddress, false)
+		if err != nil {
+			return "", "", 500, existedUser, err
+		}
+
+		return "", "", 400, existedUser, nil
+	}
+
+	// create user session with its tokens
+	tokenString, refreshTokenString, err := createUserSessionTokens(
+		DB, c, existedUser, userAgent, IPAddress)
+	if err != nil {
+		return "", "", 500, existedUser, err
+	}
+
+	return tokenString, refreshTokenString, 200, existedUser, nil
+}
+
+// func for verify totp code or single use recovery code of a user,
+// each totp code also can only be used once
+func verifyMFACode(DB *sql.DB, userID int, code string) (bool, error) {
+	// get totp secret and last used time step
+	var secret string
+	var lastStep int64
+	err := DB.QueryRow(`
+		SELECT COALESCE(totp_secret, ''), totp_last_step
+			FROM account_user
+			WHERE id = $1
+		`, userID).Scan(&secret, &lastStep)
+	if err != nil {
+		return false, err
+	}
+
+	// check totp code, mark its time step as used
+	now := time.Now().UTC()
+	step := utils.ValidateTOTPCode(secret, code, now, lastStep)
+	if step != 0 {
+		res, err := DB.Exec(`
+			UPDATE account_user SET totp_last_step = $1
+				WHERE id = $2 AND totp_last_step < $1
+			`, step, userID)
+		if err != nil {
+			return false, err
+		}
+
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return false, err
+		}
+
+		return affected > 0, nil
+	}
+
+	// check recovery code, mark it as used
+	res, err := DB.Exec(`
+		UPDATE account_recoverycode SET used_at = $1
+			WHERE account_user_id = $2 AND code_hash = $3 AND used_at IS NULL
+		`, now, userID, utils.HashToken(utils.NormalizeRecoveryCode(code)))
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected > 0, nil
+}
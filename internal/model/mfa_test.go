@@ -0,0 +1,172 @@
+/*
+Package model containing structs and functions for
+database transaction
+*/
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/reyhanfikridz/ecom-account-service/internal/utils"
+)
+
+// TestEnrollTOTPAndConfirmTOTPAndAuthenticateUserMFA integration test
+// EnrollTOTP, ConfirmTOTP, and AuthenticateUserMFA
+func TestEnrollTOTPAndConfirmTOTPAndAuthenticateUserMFA(t *testing.T) {
+	//////////////////// CREATE USER ////////////////////
+	// create user
+	user := User{
+		Email:       "mfa@gmail.com",
+		Password:    "mfa",
+		FullName:    "mfa",
+		Address:     "address",
+		PhoneNumber: "08111111111",
+		Role:        "buyer",
+	}
+
+	// get connection to testing DB
+	DB, err := GetTestDBConnection()
+	if err != nil {
+		t.Fatalf("Connection to testing DB failed => " + err.Error())
+	}
+
+	// delete prev data first
+	_, err = DB.Exec(`DELETE FROM account_user WHERE email = $1`, user.Email)
+	if err != nil {
+		t.Errorf("There's an error when deleting previous user testing data => " +
+			err.Error())
+	}
+
+	// create user data on DB
+	user, _ = CreateUser(DB, testConfig, user)
+
+	//////////////////// ENROLL AND CONFIRM TOTP ////////////////////
+	secret, status, err := EnrollTOTP(DB, user.ID)
+	if err != nil || status != 200 || secret == "" {
+		t.Errorf("Expected enroll totp success, but got status %d", status)
+	}
+
+	currentStep := utils.GetTOTPStep(time.Now().UTC())
+	code, err := utils.GenerateTOTPCode(secret, currentStep)
+	if err != nil {
+		t.Errorf("There's an error when generate totp code => " + err.Error())
+	}
+
+	// create testing table
+	confirmTestTable := []struct {
+		Code           string
+		ExpectedStatus int
+	}{
+		{
+			Code:           "000000x",
+			ExpectedStatus: 400,
+		},
+		{
+			Code:           code,
+			ExpectedStatus: 200,
+		},
+		{
+			Code:           code,
+			ExpectedStatus: 400,
+		},
+	}
+
+	var recoveryCodes []string
+	for _, test := range confirmTestTable {
+		codes, status, err := ConfirmTOTP(DB, user.ID, test.Code)
+		if err != nil {
+			t.Errorf("There's an error when confirm totp => " + err.Error())
+		}
+
+		if test.ExpectedStatus != status {
+			t.Errorf("Expected status %d, but got status %d",
+				test.ExpectedStatus, status)
+		}
+
+		if status == 200 {
+			recoveryCodes = codes
+		}
+	}
+
+	if len(recoveryCodes) != RecoveryCodeCount {
+		t.Fatalf("Expected %d recovery codes, but got %d",
+			RecoveryCodeCount, len(recoveryCodes))
+	}
+
+	// enroll again after enabled not allowed
+	_, status, err = EnrollTOTP(DB, user.ID)
+	if err != nil || status != 400 {
+		t.Errorf("Expected enroll totp status 400, but got status %d", status)
+	}
+
+	//////////////////// AUTHENTICATE USER MFA ////////////////////
+	// login return mfa challenge token instead of session
+	mfaToken, refreshToken, status, _, err := AuthenticateUser(DB, testConfig, User{
+		Email:    "mfa@gmail.com",
+		Password: "mfa",
+	}, "test-agent", "127.0.0.1")
+	if err != nil || status != 202 || mfaToken == "" || refreshToken != "" {
+		t.Errorf("Expected mfa challenge with status 202, but got status %d", status)
+	}
+
+	nextCode, err := utils.GenerateTOTPCode(secret, currentStep+1)
+	if err != nil {
+		t.Errorf("There's an error when generate totp code => " + err.Error())
+	}
+
+	// create testing table
+	loginTestTable := []struct {
+		MFAToken       string
+		Code           string
+		ExpectedStatus int
+	}{
+		{
+			MFAToken:       "invalid token",
+			Code:           nextCode,
+			ExpectedStatus: 400,
+		},
+		{
+			MFAToken:       mfaToken,
+			Code:           code,
+			ExpectedStatus: 400,
+		},
+		{
+			MFAToken:       mfaToken,
+			Code:           nextCode,
+			ExpectedStatus: 200,
+		},
+		{
+			MFAToken:       mfaToken,
+			Code:           nextCode,
+			ExpectedStatus: 400,
+		},
+		{
+			MFAToken:       mfaToken,
+			Code:           recoveryCodes[0],
+			ExpectedStatus: 200,
+		},
+		{
+			MFAToken:       mfaToken,
+			Code:           recoveryCodes[0],
+			ExpectedStatus: 400,
+		},
+	}
+
+	for _, test := range loginTestTable {
+		token, _, status, _, err := AuthenticateUserMFA(DB, testConfig, test.MFAToken, test.Code,
+			"test-agent", "127.0.0.1")
+		if err != nil {
+			t.Errorf("There's an error when authenticate user mfa => " + err.Error())
+		}
+
+		if test.ExpectedStatus != status {
+			t.Errorf("Expected status %d, but got status %d",
+				test.ExpectedStatus, status)
+		}
+
+		if status == 200 && utils.ValidateJWT(testConfig.JWTKeys, token) == nil {
+			t.Errorf("Expected access token valid, but got invalid")
+		}
+	}
+}
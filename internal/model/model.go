@@ -6,37 +6,129 @@ package model
 
 import (
 	"database/sql"
+	"strconv"
+	"strings"
+	"time"
 
 	_ "github.com/lib/pq"
+	"github.com/reyhanfikridz/ecom-account-service/internal/config"
 	"github.com/reyhanfikridz/ecom-account-service/internal/utils"
 )
 
+// user status
+//
+// New user is pending verification until its email verified,
+// deleted user kept until deleted user retention passed
+const (
+	UserStatusActive              = "active"
+	UserStatusPendingVerification = "pending_verification"
+	UserStatusSuspended           = "suspended"
+	UserStatusDeleted             = "deleted"
+)
+
 // user model
 type User struct {
 	ID          int    `json:"id"`
 	Email       string `json:"email"`
 	Password    string `json:"password"`
 	FullName    string `json:"full_name"`
-	Address     string `json:"address"`
 	PhoneNumber string `json:"phone_number"`
 	Role        string `json:"role"`
+
+	// legacy free text address, only used when register to create
+	// the first address book entry (see Address), always empty when read
+	Address string `json:"address"`
+
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
+	PhoneVerifiedAt *time.Time `json:"phone_verified_at"`
+	TOTPEnabledAt   *time.Time `json:"totp_enabled_at"`
+
+	Status    string    `json:"status"`
+	CreatedAt time.Time `json:"created_at"`
+
+	// suspension reason and expiry, suspension is permanent if no expiry
+	SuspendedReason *string    `json:"suspended_reason"`
+	SuspendedUntil  *time.Time `json:"suspended_until"`
+	DeletedAt       *time.Time `json:"deleted_at"`
+
+	// account erased after this time, unless cancelled by login
+	DeletionScheduledAt *time.Time `json:"deletion_scheduled_at"`
+
+	// version increased on every update, used for optimistic concurrency
+	Version   int       `json:"version"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// permissions of the user role, not a column,
+	// only filled when needed (e.g. authorize)
+	Permissions []string `json:"permissions,omitempty"`
+}
+
+// user table columns used in select query,
+// the order need to be same as order in userScanDest
+const userColumns = `account_user.id, account_user.email, account_user.password,
+	account_user.full_name, account_user.address, account_user.phone_number,
+	account_user.role, account_user.email_verified_at, account_user.phone_verified_at,
+	account_user.totp_enabled_at,
+	account_user.status, account_user.created_at,
+	account_user.suspended_reason, account_user.suspended_until, account_user.deleted_at,
+	account_user.deletion_scheduled_at,
+	account_user.version, account_user.updated_at`
+
+// userScanDest get scan destinations of user table columns
+func userScanDest(u *User) []any {
+	return []any{
+		&u.ID,
+		&u.Email,
+		&u.Password,
+		&u.FullName,
+		&u.Address,
+		&u.PhoneNumber,
+		&u.Role,
+		&u.EmailVerifiedAt,
+		&u.PhoneVerifiedAt,
+		&u.TOTPEnabledAt,
+		&u.Status,
+		&u.CreatedAt,
+		&u.SuspendedReason,
+		&u.SuspendedUntil,
+		&u.DeletedAt,
+		&u.DeletionScheduledAt,
+		&u.Version,
+		&u.UpdatedAt,
+	}
+}
+
+// user profile update, only not nil field updated
+type UserProfileUpdate struct {
+	FullName    *string `json:"full_name"`
+	PhoneNumber *string `json:"phone_number"`
 }
 
 // user session model
 type UserSession struct {
-	ID    int    `json:"id"`
-	Token string `json:"token"`
-	User  User   `json:"user"`
+	ID         int       `json:"id"`
+	Token      string    `json:"token"`
+	User       User      `json:"user"`
+	UserAgent  string    `json:"user_agent"`
+	IPAddress  string    `json:"ip_address"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
 }
 
 // func for creating exactly one new user
-func CreateUser(DB *sql.DB, u User) (User, error) {
+func CreateUser(DB *sql.DB, c config.Config, u User) (User, error) {
 	// hashing password
 	hashedPassword, err := utils.HashPassword(u.Password)
 	if err != nil {
 		return u, err
 	}
 
+	// release the email if used by deleted user after retention passed
+	err = releaseDeletedUserEmail(DB, c, u.Email)
+	if err != nil {
+		return u, err
+	}
+
 	//////////////////// begin transaction /////////////////////
 	tx, err := DB.Begin()
 	if err != nil {
@@ -45,11 +137,17 @@ func CreateUser(DB *sql.DB, u User) (User, error) {
 	defer tx.Rollback() // rollback transaction if fail (return before commit)
 
 	// create row data
+	u.Status = UserStatusPendingVerification
+	u.CreatedAt = time.Now().UTC()
+	u.Version = 1
+	u.UpdatedAt = u.CreatedAt
 	createdRow := tx.QueryRow(`
-		INSERT INTO account_user(email, password, full_name, address, phone_number, role) 
-			VALUES($1, $2, $3, $4, $5, $6)
+		INSERT INTO account_user(email, password, full_name, address, phone_number, role,
+			status, created_at, version, updated_at)
+			VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
 			RETURNING id`,
-		u.Email, hashedPassword, u.FullName, u.Address, u.PhoneNumber, u.Role)
+		u.Email, hashedPassword, u.FullName, "", u.PhoneNumber, u.Role,
+		u.Status, u.CreatedAt, u.Version, u.UpdatedAt)
 
 	if createdRow.Err() != nil {
 		return u, createdRow.Err()
@@ -61,6 +159,18 @@ func CreateUser(DB *sql.DB, u User) (User, error) {
 		return u, err
 	}
 
+	// address given when register become the first address book entry
+	if strings.TrimSpace(u.Address) != "" {
+		_, err = tx.Exec(`
+			INSERT INTO account_address(account_user_id, recipient_name, line1, phone_number,
+				is_default_shipping, is_default_billing, created_at, updated_at)
+				VALUES($1, $2, $3, $4, TRUE, TRUE, $5, $5)`,
+			u.ID, u.FullName, u.Address, u.PhoneNumber, u.CreatedAt)
+		if err != nil {
+			return u, err
+		}
+	}
+
 	// commit transaction
 	err = tx.Commit()
 	if err != nil {
@@ -71,38 +181,155 @@ func CreateUser(DB *sql.DB, u User) (User, error) {
 	return u, nil
 }
 
-// func for authenticate user
-func AuthenticateUser(DB *sql.DB, u User) (
-	string, int, User, error) {
-	// get existed user data
+// func for authenticate user, return access token and refresh token
+//
+// User agent and IP address saved as device metadata of the new user session.
+// Deleted user treated as not exist.
+// Return status 403 if user suspended, or email not verified
+// and login blocked for unverified email.
+// Return status 202 and mfa challenge token as access token (without session)
+// if user enabled two-factor authentication, login then completed
+// by AuthenticateUserMFA.
+// Return status 423 if account or IP address locked after too many failed login,
+// or status 429 if still delayed after the last failed login
+func AuthenticateUser(DB *sql.DB, c config.Config, u User, userAgent string,
+	IPAddress string) (
+	string, string, int, User, error) {
+	// check account or IP address locked or not
+	status, _, err := CheckLoginLockout(DB, c, u.Email, IPAddress)
+	if err != nil {
+		return "", "", 500, User{}, err
+	}
+	if status != 200 {
+		return "", "", status, User{}, nil
+	}
+
+	// get existed user data, deleted user treated as not exist
 	existedUser, err := GetUser(DB, u.Email, 0)
+	if err == nil && existedUser.Status == UserStatusDeleted {
+		err = sql.ErrNoRows
+	}
+	if err == nil {
+		// check password right or wrong
+		err = utils.ComparePassword(existedUser.Password, u.Password)
+	}
+	if err != nil {
+		err = RecordLoginFailure(DB, c, u.Email, IPAddress)
+		if err != nil {
+			return "", "", 500, existedUser, err
+		}
+
+		// record failed login of existed user
+		if existedUser.ID != 0 && existedUser.Status != UserStatusDeleted {
+			err = RecordLoginHistory(DB, existedUser.ID, userAgent, IPAddress, false)
+			if err != nil {
+				return "", "", 500, existedUser, err
+			}
+		}
+
+		return "", "", 400, existedUser, nil
+	}
+
+	// check user not suspended, lift the suspension if already expired
+	if EffectiveStatus(existedUser) == UserStatusSuspended {
+		return "", "", 403, existedUser, nil
+	} else if existedUser.Status == UserStatusSuspended {
+		status, err = SetUserStatus(DB, existedUser.ID, UserStatusActive)
+		if err != nil {
+			return "", "", 500, existedUser, err
+		}
+		if status != 200 { // if user status changed (e.g. deleted) after read
+			return "", "", 403, existedUser, nil
+		}
+		existedUser.Status = UserStatusActive
+		existedUser.SuspendedReason = nil
+		existedUser.SuspendedUntil = nil
+	}
+
+	// check email verified, if login blocked for unverified email
+	if existedUser.EmailVerifiedAt == nil &&
+		c.EmailVerificationPolicy == config.EmailVerificationPolicyBlockLogin {
+		return "", "", 403, existedUser, nil
+	}
+
+	// return mfa challenge token if two-factor authentication enabled
+	if existedUser.TOTPEnabledAt != nil {
+		mfaToken, err := utils.GenerateActionJWT(c.JWTKeys, "mfa", map[string]string{
+			"sub": strconv.Itoa(existedUser.ID),
+		}, c.MFATokenDuration)
+		if err != nil {
+			return "", "", 500, existedUser, err
+		}
+
+		return mfaToken, "", 202, existedUser, nil
+	}
+
+	// create user session with its tokens
+	tokenString, refreshTokenString, err := createUserSessionTokens(
+		DB, c, existedUser, userAgent, IPAddress)
 	if err != nil {
-		return "", 400, existedUser, nil
+		return "", "", 500, existedUser, err
 	}
 
-	// check password right or wrong
-	err = utils.ComparePassword(existedUser.Password, u.Password)
+	return tokenString, refreshTokenString, 200, existedUser, nil
+}
+
+// func for create user session of authenticated user,
+// return access token and refresh token of the session
+//
+// Failed login attempts of the account cleared, but not of the IP address,
+// so one valid account can't be used to reset lockout of the IP address.
+// Scheduled deletion of the account cancelled by the login,
+// and the login recorded in login history.
+func createUserSessionTokens(DB *sql.DB, c config.Config, u User,
+	userAgent string, IPAddress string) (
+	string, string, error) {
+	// clear failed login attempts of the account
+	_, err := ClearLoginFailures(DB, u.Email, "")
+	if err != nil {
+		return "", "", err
+	}
+
+	// cancel scheduled deletion of the account
+	_, err = CancelUserDeletion(DB, u.ID)
+	if err != nil {
+		return "", "", err
+	}
+
+	// record succeeded login
+	err = RecordLoginHistory(DB, u.ID, userAgent, IPAddress, true)
 	if err != nil {
-		return "", 400, existedUser, nil
+		return "", "", err
 	}
 
 	// generate jwt token string
-	tokenString, err := utils.GenerateJWT(existedUser.Email, existedUser.Role)
+	tokenString, err := utils.GenerateJWT(c.JWTKeys, u.ID, EffectiveRole(c, u),
+		c.AccessTokenDuration)
 	if err != nil {
-		return "", 500, existedUser, err
+		return "", "", err
 	}
 
 	// save user sessions
 	userSession := UserSession{
-		Token: tokenString,
-		User:  existedUser,
+		Token:     tokenString,
+		User:      u,
+		UserAgent: userAgent,
+		IPAddress: IPAddress,
 	}
 	userSession, err = CreateUserSession(DB, userSession)
 	if err != nil {
-		return "", 500, existedUser, err
+		return "", "", err
+	}
+
+	// create refresh token for user session
+	refreshToken, err := CreateRefreshToken(DB, c, RefreshToken{
+		UserSession: userSession,
+	})
+	if err != nil {
+		return "", "", err
 	}
 
-	return tokenString, 200, existedUser, nil
+	return tokenString, refreshToken.Token, nil
 }
 
 // func for get user data by email or by ID
@@ -113,12 +340,12 @@ func GetUser(DB *sql.DB, email string, ID int) (User, error) {
 	var queryRes *sql.Row
 	if ID == 0 {
 		queryRes = DB.QueryRow(`
-			SELECT id, email, password, full_name, address, phone_number, role 
+			SELECT `+userColumns+`
 				FROM account_user WHERE email = $1`,
 			email)
 	} else {
 		queryRes = DB.QueryRow(`
-			SELECT id, email, password, full_name, address, phone_number, role 
+			SELECT `+userColumns+`
 				FROM account_user WHERE id = $1`,
 			ID)
 	}
@@ -129,15 +356,7 @@ func GetUser(DB *sql.DB, email string, ID int) (User, error) {
 
 	// get data from query result
 	// Note: The order of Scan need to be same as order in QueryRow
-	err := queryRes.Scan(
-		&user.ID,
-		&user.Email,
-		&user.Password,
-		&user.FullName,
-		&user.Address,
-		&user.PhoneNumber,
-		&user.Role,
-	)
+	err := queryRes.Scan(userScanDest(&user)...)
 
 	if err != nil {
 		return user, err
@@ -146,8 +365,64 @@ func GetUser(DB *sql.DB, email string, ID int) (User, error) {
 	return user, nil
 }
 
+// func for partial update user profile with optimistic concurrency,
+// return the updated user
+//
+// The update only done if user version still the same as version read by client.
+// Return status 409 if user already updated by another request (version changed),
+// or status 400 if user not exist
+func UpdateUserProfile(DB *sql.DB, userID int, version int, p UserProfileUpdate) (
+	User, int, error) {
+	// update only not nil field and increase version,
+	// changed phone number need to be verified again,
+	// updated phone number already validated so it isn't invalid anymore
+	res, err := DB.Exec(`
+		UPDATE account_user
+			SET full_name = COALESCE($1, full_name),
+				phone_verified_at = CASE WHEN $2::VARCHAR IS NULL OR $2 = phone_number
+					THEN phone_verified_at ELSE NULL END,
+				is_phone_number_invalid = CASE WHEN $2::VARCHAR IS NULL
+					THEN is_phone_number_invalid ELSE FALSE END,
+				phone_number = COALESCE($2, phone_number),
+				version = version + 1,
+				updated_at = $3
+			WHERE id = $4 AND version = $5
+		`, p.FullName, p.PhoneNumber, time.Now().UTC(), userID, version)
+	if err != nil {
+		return User{}, 500, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return User{}, 500, err
+	}
+
+	// get updated user, or check why user not updated
+	user, err := GetUser(DB, "", userID)
+	if err == sql.ErrNoRows {
+		return user, 400, nil
+	} else if err != nil {
+		return user, 500, err
+	}
+
+	if affected == 0 {
+		return user, 409, nil
+	}
+
+	return user, 200, nil
+}
+
 // func for create user session
+//
+// One user can have many user sessions (e.g. logged in on many devices)
 func CreateUserSession(DB *sql.DB, us UserSession) (UserSession, error) {
+	// user agent can be very long, cut it to fit the column
+	if len(us.UserAgent) > 255 {
+		us.UserAgent = us.UserAgent[:255]
+	}
+	us.CreatedAt = time.Now().UTC()
+	us.LastSeenAt = us.CreatedAt
+
 	//////////////////// begin transaction /////////////////////
 	tx, err := DB.Begin()
 	if err != nil {
@@ -155,18 +430,12 @@ func CreateUserSession(DB *sql.DB, us UserSession) (UserSession, error) {
 	}
 	defer tx.Rollback() // rollback transaction if fail (return before commit)
 
-	// delete existed user session
-	_, err = tx.Exec(`
-		DELETE FROM account_usersession
-			WHERE account_user_id = $1`, us.User.ID)
-	if err != nil {
-		return us, err
-	}
-
 	// insert user session
 	createdRow := tx.QueryRow(`
-		INSERT INTO account_usersession(token, account_user_id) 
-			VALUES($1,$2) RETURNING id`, us.Token, us.User.ID)
+		INSERT INTO account_usersession(token, account_user_id, user_agent,
+			ip_address, created_at, last_seen_at)
+			VALUES($1, $2, $3, $4, $5, $6) RETURNING id`,
+		us.Token, us.User.ID, us.UserAgent, us.IPAddress, us.CreatedAt, us.LastSeenAt)
 	if createdRow.Err() != nil {
 		return us, createdRow.Err()
 	}
@@ -193,10 +462,14 @@ func GetUserSession(DB *sql.DB, tokenString string, userID int) (UserSession, er
 
 	// do query
 	row := DB.QueryRow(`
-		SELECT *
-			FROM account_usersession FULL OUTER JOIN account_user
+		SELECT account_usersession.id, account_usersession.token,
+			account_usersession.user_agent, account_usersession.ip_address,
+			account_usersession.created_at, account_usersession.last_seen_at,
+			`+userColumns+`
+			FROM account_usersession INNER JOIN account_user
 				ON account_usersession.account_user_id = account_user.id
-			WHERE token = $1 AND account_user_id = $2
+			WHERE account_usersession.token = $1
+				AND account_usersession.account_user_id = $2
 		`, tokenString, userID)
 
 	if row.Err() != nil {
@@ -204,24 +477,73 @@ func GetUserSession(DB *sql.DB, tokenString string, userID int) (UserSession, er
 	}
 
 	// scan query result
-	err := row.Scan(
+	err := row.Scan(append([]any{
 		&userSession.ID,
 		&userSession.Token,
-		&userSession.User.ID,
-		&userSession.User.ID,
-		&userSession.User.Email,
-		&userSession.User.Password,
-		&userSession.User.FullName,
-		&userSession.User.Address,
-		&userSession.User.PhoneNumber,
-		&userSession.User.Role,
-	)
+		&userSession.UserAgent,
+		&userSession.IPAddress,
+		&userSession.CreatedAt,
+		&userSession.LastSeenAt,
+	}, userScanDest(&userSession.User)...)...)
 	if err != nil {
 		return userSession, err
 	}
 	return userSession, nil
 }
 
+// func for get all sessions of a user, newest activity first
+//
+// Returned user sessions token and user data is not filled
+func GetUserSessions(DB *sql.DB, userID int) ([]UserSession, error) {
+	userSessions := []UserSession{}
+
+	// do query
+	rows, err := DB.Query(`
+		SELECT id, user_agent, ip_address, created_at, last_seen_at
+			FROM account_usersession
+			WHERE account_user_id = $1
+			ORDER BY last_seen_at DESC, id DESC
+		`, userID)
+	if err != nil {
+		return userSessions, err
+	}
+	defer rows.Close()
+
+	// scan query result
+	for rows.Next() {
+		userSession := UserSession{}
+		err = rows.Scan(
+			&userSession.ID,
+			&userSession.UserAgent,
+			&userSession.IPAddress,
+			&userSession.CreatedAt,
+			&userSession.LastSeenAt,
+		)
+		if err != nil {
+			return userSessions, err
+		}
+
+		userSession.User.ID = userID
+		userSessions = append(userSessions, userSession)
+	}
+
+	if rows.Err() != nil {
+		return userSessions, rows.Err()
+	}
+
+	return userSessions, nil
+}
+
+// func for update user session last seen time to now
+func UpdateUserSessionLastSeen(DB *sql.DB, ID int) error {
+	_, err := DB.Exec(`
+		UPDATE account_usersession SET last_seen_at = $1
+			WHERE id = $2
+		`, time.Now().UTC(), ID)
+
+	return err
+}
+
 // func for delete user session by token string
 func DeleteUserSession(DB *sql.DB, tokenString string) error {
 	//////////////////// begin transaction /////////////////////
@@ -250,3 +572,33 @@ func DeleteUserSession(DB *sql.DB, tokenString string) error {
 
 	return nil
 }
+
+// func for delete user session by ID, the session must be owned by the user
+//
+// Return false if there's no user session deleted
+func DeleteUserSessionByID(DB *sql.DB, ID int, userID int) (bool, error) {
+	res, err := DB.Exec(`
+		DELETE FROM account_usersession
+			WHERE id = $1 AND account_user_id = $2
+	`, ID, userID)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected > 0, nil
+}
+
+// func for delete all sessions of a user (log out everywhere)
+func DeleteUserSessions(DB *sql.DB, userID int) error {
+	_, err := DB.Exec(`
+		DELETE FROM account_usersession
+			WHERE account_user_id = $1
+	`, userID)
+
+	return err
+}
@@ -11,16 +11,26 @@ import (
 	"testing"
 
 	"github.com/reyhanfikridz/ecom-account-service/internal/config"
+	"github.com/reyhanfikridz/ecom-account-service/internal/migration"
+	"github.com/reyhanfikridz/ecom-account-service/internal/sqlite"
 )
 
+// config of all testing in the package
+var testConfig config.Config
+
 // TestMain do some test before and after all testing in the package
 func TestMain(m *testing.M) {
-	// init all config before can be used
-	err := config.InitConfig()
+	// load config before can be used
+	var err error
+	testConfig, _, err = config.Load(nil)
 	if err != nil {
 		log.Fatalf("There's an error when initialize config => %s", err)
 	}
 
+	// no delay after failed login, so testing can login right after
+	// testing wrong password (lockout still applied after the threshold)
+	testConfig.LoginBackoffBase = 0
+
 	// run all testing
 	m.Run()
 }
@@ -40,7 +50,7 @@ func TestCreateUser(t *testing.T) {
 	// get connection to testing DB
 	DB, err := GetTestDBConnection()
 	if err != nil {
-		t.Errorf("Connection to testing DB failed => " + err.Error())
+		t.Fatalf("Connection to testing DB failed => " + err.Error())
 	}
 
 	// delete prev data first
@@ -51,7 +61,7 @@ func TestCreateUser(t *testing.T) {
 	}
 
 	// create user data on DB
-	user, err = CreateUser(DB, user)
+	user, err = CreateUser(DB, testConfig, user)
 
 	// check result
 	if err != nil {
@@ -80,7 +90,7 @@ func TestCreateUserAndGetUser(t *testing.T) {
 	// get connection to testing DB
 	DB, err := GetTestDBConnection()
 	if err != nil {
-		t.Errorf("Connection to testing DB failed => " + err.Error())
+		t.Fatalf("Connection to testing DB failed => " + err.Error())
 	}
 
 	// delete prev data first
@@ -91,7 +101,7 @@ func TestCreateUserAndGetUser(t *testing.T) {
 	}
 
 	// create user data on DB
-	user, _ = CreateUser(DB, user)
+	user, _ = CreateUser(DB, testConfig, user)
 
 	//////////////////// GET BY EMAIL ////////////////////
 	userByEmail, err := GetUser(DB, "admin@gmail.com", 0)
@@ -129,7 +139,7 @@ func TestCreateUserAndCreateUserSession(t *testing.T) {
 	// get connection to testing DB
 	DB, err := GetTestDBConnection()
 	if err != nil {
-		t.Errorf("Connection to testing DB failed => " + err.Error())
+		t.Fatalf("Connection to testing DB failed => " + err.Error())
 	}
 
 	// delete prev data first
@@ -140,7 +150,7 @@ func TestCreateUserAndCreateUserSession(t *testing.T) {
 	}
 
 	// create user data on DB
-	user, _ = CreateUser(DB, user)
+	user, _ = CreateUser(DB, testConfig, user)
 
 	//////////////////// CREATE USER SESSION ////////////////////
 	// create user session
@@ -187,7 +197,7 @@ func TestCreateUserAndCreateUserSessionAndGetUserSession(t *testing.T) {
 	// get connection to testing DB
 	DB, err := GetTestDBConnection()
 	if err != nil {
-		t.Errorf("Connection to testing DB failed => " + err.Error())
+		t.Fatalf("Connection to testing DB failed => " + err.Error())
 	}
 
 	// delete prev data first
@@ -198,7 +208,7 @@ func TestCreateUserAndCreateUserSessionAndGetUserSession(t *testing.T) {
 	}
 
 	// create user data on DB
-	user, _ = CreateUser(DB, user)
+	user, _ = CreateUser(DB, testConfig, user)
 
 	//////////////////// CREATE USER SESSION ////////////////////
 	// create user session
@@ -250,7 +260,7 @@ func TestCreateUserAndCreateUserSessionAndDeleteUserSession(t *testing.T) {
 	// get connection to testing DB
 	DB, err := GetTestDBConnection()
 	if err != nil {
-		t.Errorf("Connection to testing DB failed => " + err.Error())
+		t.Fatalf("Connection to testing DB failed => " + err.Error())
 	}
 
 	// delete prev data first
@@ -261,7 +271,7 @@ func TestCreateUserAndCreateUserSessionAndDeleteUserSession(t *testing.T) {
 	}
 
 	// create user data on DB
-	user, _ = CreateUser(DB, user)
+	user, _ = CreateUser(DB, testConfig, user)
 
 	//////////////////// CREATE USER SESSION ////////////////////
 	// create user session
@@ -294,6 +304,118 @@ func TestCreateUserAndCreateUserSessionAndDeleteUserSession(t *testing.T) {
 	}
 }
 
+// TestCreateUserSessionAndGetUserSessionsAndDeleteUserSessions integration test
+// CreateUserSession (many devices), GetUserSessions, DeleteUserSessionByID
+// and DeleteUserSessions
+func TestCreateUserSessionAndGetUserSessionsAndDeleteUserSessions(t *testing.T) {
+	//////////////////// CREATE USER ////////////////////
+	// create user
+	user := User{
+		Email:       "sessions@gmail.com",
+		Password:    "sessions",
+		FullName:    "sessions",
+		Address:     "address",
+		PhoneNumber: "08111111111",
+		Role:        "admin",
+	}
+
+	// get connection to testing DB
+	DB, err := GetTestDBConnection()
+	if err != nil {
+		t.Fatalf("Connection to testing DB failed => " + err.Error())
+	}
+
+	// delete prev data first
+	_, err = DB.Exec(`DELETE FROM account_user WHERE email = $1`, user.Email)
+	if err != nil {
+		t.Errorf("There's an error when deleting previous user testing data => " +
+			err.Error())
+	}
+
+	// create user data on DB
+	user, _ = CreateUser(DB, testConfig, user)
+
+	//////////////////// CREATE USER SESSIONS ////////////////////
+	// create user sessions on many devices
+	laptopSession, err := CreateUserSession(DB, UserSession{
+		Token:     "This is laptop token",
+		User:      user,
+		UserAgent: "laptop-agent",
+		IPAddress: "127.0.0.1",
+	})
+	if err != nil {
+		t.Errorf("Expected err nil, but got err not nil => " + err.Error())
+	}
+
+	phoneSession, err := CreateUserSession(DB, UserSession{
+		Token:     "This is phone token",
+		User:      user,
+		UserAgent: "phone-agent",
+		IPAddress: "127.0.0.2",
+	})
+	if err != nil {
+		t.Errorf("Expected err nil, but got err not nil => " + err.Error())
+	}
+
+	//////////////////// GET USER SESSIONS ////////////////////
+	userSessions, err := GetUserSessions(DB, user.ID)
+	if err != nil {
+		t.Errorf("Expected err nil, but got err not nil => " + err.Error())
+	}
+
+	if len(userSessions) != 2 {
+		t.Errorf("Expected 2 user sessions, but got %d", len(userSessions))
+	}
+
+	for _, userSession := range userSessions {
+		if userSession.UserAgent == "" || userSession.IPAddress == "" ||
+			userSession.CreatedAt.IsZero() || userSession.LastSeenAt.IsZero() {
+			t.Errorf("Expected user session device metadata filled, but got empty")
+		}
+	}
+
+	//////////////////// DELETE USER SESSION BY ID ////////////////////
+	// delete with another user ID
+	isDeleted, err := DeleteUserSessionByID(DB, laptopSession.ID, user.ID+1)
+	if err != nil {
+		t.Errorf("Expected err nil, but got err not nil => " + err.Error())
+	}
+
+	if isDeleted {
+		t.Errorf("Expected user session of another user not deleted, but deleted")
+	}
+
+	// delete with right user ID
+	isDeleted, err = DeleteUserSessionByID(DB, laptopSession.ID, user.ID)
+	if err != nil {
+		t.Errorf("Expected err nil, but got err not nil => " + err.Error())
+	}
+
+	if !isDeleted {
+		t.Errorf("Expected user session deleted, but not deleted")
+	}
+
+	_, err = GetUserSession(DB, phoneSession.Token, user.ID)
+	if err != nil {
+		t.Errorf("Expected other user session still exist, but got err => " + err.Error())
+	}
+
+	//////////////////// DELETE USER SESSIONS ////////////////////
+	err = DeleteUserSessions(DB, user.ID)
+	if err != nil {
+		t.Errorf("Expected err nil, but got err not nil => " + err.Error())
+	}
+
+	userSessions, err = GetUserSessions(DB, user.ID)
+	if err != nil {
+		t.Errorf("Expected err nil, but got err not nil => " + err.Error())
+	}
+
+	if len(userSessions) != 0 {
+		t.Errorf("Expected 0 user sessions, but got %d", len(userSessions))
+	}
+}
+
 // TestCreateUserAndAuthenticateUser integration test
 // CreateUser and AuthenticateUser
 func TestCreateUserAndAuthenticateUser(t *testing.T) {
@@ -311,7 +433,7 @@ func TestCreateUserAndAuthenticateUser(t *testing.T) {
 	// get connection to testing DB
 	DB, err := GetTestDBConnection()
 	if err != nil {
-		t.Errorf("Connection to testing DB failed => " + err.Error())
+		t.Fatalf("Connection to testing DB failed => " + err.Error())
 	}
 
 	// delete prev data first
@@ -322,7 +444,7 @@ func TestCreateUserAndAuthenticateUser(t *testing.T) {
 	}
 
 	// create user data on DB
-	user, _ = CreateUser(DB, user)
+	user, _ = CreateUser(DB, testConfig, user)
 
 	//////////////////// AUTHENTICATE USER ////////////////////
 	// create testing table
@@ -354,7 +476,7 @@ func TestCreateUserAndAuthenticateUser(t *testing.T) {
 	}
 
 	for _, test := range testTable {
-		_, status, _, err := AuthenticateUser(DB, test.User)
+		_, _, status, _, err := AuthenticateUser(DB, testConfig, test.User, "test-agent", "127.0.0.1")
 		if err != nil {
 			t.Errorf("There's an error when authenticate user =>" + err.Error())
 		}
@@ -368,44 +490,140 @@ func TestCreateUserAndAuthenticateUser(t *testing.T) {
 
 // GetTestDBConnection get connection to testing DB
 func GetTestDBConnection() (*sql.DB, error) {
-	// Connect to db
-	connString := fmt.Sprintf("user=%s password=%s dbname=%s sslmode=disable",
-		config.DBUsername, config.DBPassword, config.DBTestName)
+	var DB *sql.DB
+	var err error
+	if testConfig.DBDriver == config.DBDriverSQLite {
+		// Open db file, created if not exist
+		DB, err = sqlite.Open(testConfig.DBTestPath)
+	} else {
+		// Connect to db
+		connString := fmt.Sprintf("host='%s' port='%s' user='%s' password='%s' dbname='%s' "+
+			"sslmode='%s' sslrootcert='%s' sslcert='%s' sslkey='%s'",
+			testConfig.DBHost, testConfig.DBPort, testConfig.DBUsername, testConfig.DBPassword,
+			testConfig.DBTestName, testConfig.DBSSLMode, testConfig.DBSSLRootCert, testConfig.DBSSLCert,
+			testConfig.DBSSLKey)
+
+		DB, err = sql.Open("postgres", connString)
+	}
+	if err != nil {
+		return nil, err
+	}
 
-	DB, err := sql.Open("postgres", connString)
+	// Create or update tables by migrations
+	migrator, err := migration.New(DB, testConfig.DBDriver, testConfig)
 	if err != nil {
 		return nil, err
 	}
 
-	// Create user tables if not exist
-	tableCreationQuery := `
-		CREATE TABLE IF NOT EXISTS account_user
-		(
-			id SERIAL PRIMARY KEY NOT NULL,
-			email VARCHAR(50) UNIQUE NOT NULL,
-			password VARCHAR(100) NOT NULL,
-			full_name VARCHAR(50) NOT NULL,
-			address VARCHAR(100) NOT NULL,
-			phone_number VARCHAR(20) NOT NULL,
-			role VARCHAR(20) NOT NULL
-		);
-
-		CREATE TABLE IF NOT EXISTS account_usersession
-		(
-			id SERIAL PRIMARY KEY NOT NULL,
-			token VARCHAR(200) UNIQUE NOT NULL,
-			account_user_id INT UNIQUE NOT NULL,
-			CONSTRAINT fk_account_user
-				FOREIGN KEY(account_user_id) 
-					REFERENCES account_user(id)
-					ON DELETE CASCADE
-		);
-	`
-
-	_, err = DB.Exec(tableCreationQuery)
+	_, err = migrator.Up()
+	if err != nil {
+		return nil, err
+	}
+
+	// clear failed login attempts of previous testing
+	_, err = DB.Exec(`DELETE FROM account_loginfailure`)
 	if err != nil {
 		return nil, err
 	}
 
 	return DB, nil
 }
+
+// TestUpdateUserProfile test UpdateUserProfile
+func TestUpdateUserProfile(t *testing.T) {
+	// create user
+	user := User{
+		Email:       "profile@gmail.com",
+		Password:    "profile",
+		FullName:    "profile",
+		Address:     "address",
+		PhoneNumber: "08111111111",
+		Role:        "buyer",
+	}
+
+	// get connection to testing DB
+	DB, err := GetTestDBConnection()
+	if err != nil {
+		t.Fatalf("Connection to testing DB failed => " + err.Error())
+	}
+
+	// delete prev data first
+	_, err = DB.Exec(`DELETE FROM account_user WHERE email = $1`, user.Email)
+	if err != nil {
+		t.Errorf("There's an error when deleting previous user testing data => " +
+			err.Error())
+	}
+
+	// create user data on DB
+	user, err = CreateUser(DB, testConfig, user)
+	if err != nil {
+		t.Errorf("There's an error when creating user => " + err.Error())
+	}
+
+	// create testing table
+	newFullName := "new profile"
+	newPhoneNumber := "08122222222"
+	testTable := []struct {
+		UserID              int
+		Version             int
+		Profile             UserProfileUpdate
+		ExpectedStatus      int
+		ExpectedVersion     int
+		ExpectedFullName    string
+		ExpectedPhoneNumber string
+	}{
+		{
+			UserID:              user.ID,
+			Version:             1,
+			Profile:             UserProfileUpdate{FullName: &newFullName},
+			ExpectedStatus:      200,
+			ExpectedVersion:     2,
+			ExpectedFullName:    "new profile",
+			ExpectedPhoneNumber: "08111111111",
+		},
+		{
+			UserID:              user.ID,
+			Version:             1,
+			Profile:             UserProfileUpdate{PhoneNumber: &newPhoneNumber},
+			ExpectedStatus:      409,
+			ExpectedVersion:     2,
+			ExpectedFullName:    "new profile",
+			ExpectedPhoneNumber: "08111111111",
+		},
+		{
+			UserID:              user.ID,
+			Version:             2,
+			Profile:             UserProfileUpdate{PhoneNumber: &newPhoneNumber},
+			ExpectedStatus:      200,
+			ExpectedVersion:     3,
+			ExpectedFullName:    "new profile",
+			ExpectedPhoneNumber: "08122222222",
+		},
+		{
+			UserID:         -1,
+			Version:        1,
+			Profile:        UserProfileUpdate{PhoneNumber: &newPhoneNumber},
+			ExpectedStatus: 400,
+		},
+	}
+
+	for _, test := range testTable {
+		updatedUser, status, err := UpdateUserProfile(DB, test.UserID, test.Version,
+			test.Profile)
+		if err != nil {
+			t.Errorf("There's an error when update user profile => " + err.Error())
+		}
+
+		if status != test.ExpectedStatus {
+			t.Errorf("Expected status %d, but got status %d", test.ExpectedStatus, status)
+		}
+
+		if updatedUser.Version != test.ExpectedVersion ||
+			updatedUser.FullName != test.ExpectedFullName ||
+			updatedUser.PhoneNumber != test.ExpectedPhoneNumber {
+			t.Errorf("Expected user version %d (%s, %s), but got version %d (%s, %s)",
+				test.ExpectedVersion, test.ExpectedFullName, test.ExpectedPhoneNumber,
+				updatedUser.Version, updatedUser.FullName, updatedUser.PhoneNumber)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+/*
+Package model containing structs and functions for
+database transaction
+*/
+package model
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/reyhanfikridz/ecom-account-service/internal/config"
+	"github.com/reyhanfikridz/ecom-account-service/internal/utils"
+)
+
+// func for change password of a user, the current password must be right
+// and the new password can't be one of the last passwords of the user,
+// all other sessions of the user are revoked after password changed
+//
+// Return status 400 if current password wrong,
+// or status 422 if new password already used recently
+func ChangePassword(DB *sql.DB, c config.Config, userID int, currentPassword string,
+	newPassword string, currentSessionID int) (int, error) {
+	// get existed user data
+	existedUser, err := GetUser(DB, "", userID)
+	if err == sql.ErrNoRows {
+		return 400, nil
+	} else if err != nil {
+		return 500, err
+	}
+
+	// check current password right or wrong
+	err = utils.ComparePassword(existedUser.Password, currentPassword)
+	if err != nil {
+		return 400, nil
+	}
+
+	// check new password not used recently
+	isUsed, err := isPasswordUsedRecently(DB, c, existedUser, newPassword)
+	if err != nil {
+		return 500, err
+	}
+	if isUsed {
+		return 422, nil
+	}
+
+	// hashing new password
+	hashedPassword, err := utils.HashPassword(newPassword)
+	if err != nil {
+		return 500, err
+	}
+
+	//////////////////// begin transaction /////////////////////
+	tx, err := DB.Begin()
+	if err != nil {
+		return 500, err
+	}
+	defer tx.Rollback() // rollback transaction if fail (return before commit)
+
+	// set new password, if no row affected then
+	// the password changed by another request at the same time
+	isChanged, err := setUserPassword(tx, c, existedUser, hashedPassword)
+	if err != nil {
+		return 500, err
+	}
+	if !isChanged {
+		return 400, nil
+	}
+
+	// revoke all user sessions except the current session
+	_, err = tx.Exec(`
+		DELETE FROM account_usersession
+			WHERE account_user_id = $1 AND id <> $2
+		`, userID, currentSessionID)
+	if err != nil {
+		return 500, err
+	}
+
+	// commit transaction
+	err = tx.Commit()
+	if err != nil {
+		return 500, err
+	}
+	////////////////////////////////////////////////////////////
+
+	return 200, nil
+}
+
+// queryer database connection or transaction to query,
+// so query can be done inside or outside transaction
+type queryer interface {
+	Query(query string, args ...any) (*sql.Rows, error)
+}
+
+// func for check password is the current password or
+// one of the previous passwords kept in password history
+func isPasswordUsedRecently(DB queryer, c config.Config, u User, password string) (
+	bool, error) {
+	if utils.ComparePassword(u.Password, password) == nil {
+		return true, nil
+	}
+
+	// the current password counted as one of the last passwords
+	if c.PasswordHistoryCount <= 1 {
+		return false, nil
+	}
+
+	rows, err := DB.Query(`
+		SELECT password
+			FROM account_passwordhistory
+			WHERE account_user_id = $1
+			ORDER BY created_at DESC, id DESC
+			LIMIT $2
+		`, u.ID, c.PasswordHistoryCount-1)
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var hashedPassword string
+		err = rows.Scan(&hashedPassword)
+		if err != nil {
+			return false, err
+		}
+
+		if utils.ComparePassword(hashedPassword, password) == nil {
+			return true, nil
+		}
+	}
+
+	return false, rows.Err()
+}
+
+// func for set new hashed password of a user inside a transaction,
+// the old password kept in password history
+//
+// Return false if password already changed (user password
+// not the same as the password in u anymore)
+func setUserPassword(tx *sql.Tx, c config.Config, u User, hashedPassword string) (
+	bool, error) {
+	now := time.Now().UTC()
+	res, err := tx.Exec(`
+		UPDATE account_user
+			SET password = $1, version = version + 1, updated_at = $2
+			WHERE id = $3 AND password = $4
+		`, hashedPassword, now, u.ID, u.Password)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if affected == 0 {
+		return false, nil
+	}
+
+	// keep old password in history
+	_, err = tx.Exec(`
+		INSERT INTO account_passwordhistory(account_user_id, password, created_at)
+			VALUES($1, $2, $3)
+		`, u.ID, u.Password, now)
+	if err != nil {
+		return false, err
+	}
+
+	// remove password history older than the last passwords
+	_, err = tx.Exec(`
+		DELETE FROM account_passwordhistory
+			WHERE account_user_id = $1 AND id NOT IN (
+				SELECT id FROM account_passwordhistory
+					WHERE account_user_id = $1
+					ORDER BY created_at DESC, id DESC
+					LIMIT $2
+			)
+		`, u.ID, c.PasswordHistoryCount)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
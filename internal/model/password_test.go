@@ -0,0 +1,140 @@
+/*
+Package model containing structs and functions for
+database transaction
+*/
+package model
+
+import (
+	"testing"
+
+	"github.com/reyhanfikridz/ecom-account-service/internal/utils"
+)
+
+// TestChangePassword test ChangePassword
+func TestChangePassword(t *testing.T) {
+	c := testConfig
+	c.PasswordHistoryCount = 2
+
+	// create user
+	user := User{
+		Email:       "changepassword@gmail.com",
+		Password:    "password0",
+		FullName:    "changepassword",
+		Address:     "address",
+		PhoneNumber: "08111111111",
+		Role:        "buyer",
+	}
+
+	// get connection to testing DB
+	DB, err := GetTestDBConnection()
+	if err != nil {
+		t.Fatalf("Connection to testing DB failed => " + err.Error())
+	}
+
+	// delete prev data first
+	_, err = DB.Exec(`DELETE FROM account_user WHERE email = $1`, user.Email)
+	if err != nil {
+		t.Errorf("There's an error when deleting previous user testing data => " +
+			err.Error())
+	}
+
+	// create user data on DB with two sessions
+	user, _ = CreateUser(DB, c, user)
+
+	userSessions := []UserSession{}
+	for i := 0; i < 2; i++ {
+		token, err := utils.GenerateJWT(c.JWTKeys, user.ID, user.Role,
+			c.AccessTokenDuration)
+		if err != nil {
+			t.Errorf("There's an error when generating jwt token => " + err.Error())
+		}
+
+		userSession, err := CreateUserSession(DB, UserSession{Token: token, User: user})
+		if err != nil {
+			t.Errorf("There's an error when creating user session => " + err.Error())
+		}
+		userSessions = append(userSessions, userSession)
+	}
+
+	// create testing table
+	testTable := []struct {
+		CurrentPassword string
+		NewPassword     string
+		ExpectedStatus  int
+	}{
+		{
+			CurrentPassword: "wrongpassword",
+			NewPassword:     "password1",
+			ExpectedStatus:  400,
+		},
+		{
+			CurrentPassword: "password0",
+			NewPassword:     "password0",
+			ExpectedStatus:  422,
+		},
+		{
+			CurrentPassword: "password0",
+			NewPassword:     "password1",
+			ExpectedStatus:  200,
+		},
+		{
+			CurrentPassword: "password1",
+			NewPassword:     "password0",
+			ExpectedStatus:  422,
+		},
+		{
+			CurrentPassword: "password1",
+			NewPassword:     "password2",
+			ExpectedStatus:  200,
+		},
+		{
+			CurrentPassword: "password2",
+			NewPassword:     "password0",
+			ExpectedStatus:  200,
+		},
+	}
+
+	// loop test in test table
+	for _, test := range testTable {
+		status, err := ChangePassword(DB, c, user.ID, test.CurrentPassword,
+			test.NewPassword, userSessions[0].ID)
+		if err != nil {
+			t.Errorf("There's an error when changing password => " + err.Error())
+		}
+		if status != test.ExpectedStatus {
+			t.Errorf("Expected status %d, but got %d", test.ExpectedStatus, status)
+		}
+	}
+
+	// check only current session remain
+	var sessionCount int
+	err = DB.QueryRow(`
+		SELECT COUNT(*) FROM account_usersession
+			WHERE account_user_id = $1
+		`, user.ID).Scan(&sessionCount)
+	if err != nil {
+		t.Errorf("There's an error when counting user sessions => " + err.Error())
+	}
+	if sessionCount != 1 {
+		t.Errorf("Expected 1 user session remain, but got %d", sessionCount)
+	}
+
+	_, err = GetUserSession(DB, userSessions[0].Token, user.ID)
+	if err != nil {
+		t.Errorf("Expected current user session remain, but got error => " + err.Error())
+	}
+
+	// check password history only keep the last passwords
+	var historyCount int
+	err = DB.QueryRow(`
+		SELECT COUNT(*) FROM account_passwordhistory
+			WHERE account_user_id = $1
+		`, user.ID).Scan(&historyCount)
+	if err != nil {
+		t.Errorf("There's an error when counting password history => " + err.Error())
+	}
+	if historyCount != c.PasswordHistoryCount {
+		t.Errorf("Expected %d password history, but got %d",
+			c.PasswordHistoryCount, historyCount)
+	}
+}
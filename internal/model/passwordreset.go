@@ -0,0 +1,181 @@
+/*
+Package model containing structs and functions for
+database transaction
+*/
+package model
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/reyhanfikridz/ecom-account-service/internal/config"
+	"github.com/reyhanfikridz/ecom-account-service/internal/utils"
+)
+
+// password reset token model
+//
+// Password reset token is single use and only saved as hash in database,
+// Token only filled right after the password reset token created.
+type PasswordResetToken struct {
+	ID        int       `json:"id"`
+	Token     string    `json:"token"`
+	User      User      `json:"user"`
+	ExpiredAt time.Time `json:"expired_at"`
+}
+
+// func for create password reset token of a user,
+// all previous password reset token of the user is deleted
+func CreatePasswordResetToken(DB *sql.DB, c config.Config, prt PasswordResetToken) (
+	PasswordResetToken, error) {
+	// generate opaque token
+	tokenString, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return prt, err
+	}
+	prt.Token = tokenString
+	prt.ExpiredAt = time.Now().UTC().Add(c.PasswordResetTokenDuration)
+
+	//////////////////// begin transaction /////////////////////
+	tx, err := DB.Begin()
+	if err != nil {
+		return prt, err
+	}
+	defer tx.Rollback() // rollback transaction if fail (return before commit)
+
+	// delete previous password reset token
+	_, err = tx.Exec(`
+		DELETE FROM account_passwordreset
+			WHERE account_user_id = $1`, prt.User.ID)
+	if err != nil {
+		return prt, err
+	}
+
+	// insert password reset token
+	createdRow := tx.QueryRow(`
+		INSERT INTO account_passwordreset(token_hash, account_user_id, expired_at)
+			VALUES($1, $2, $3) RETURNING id`,
+		utils.HashToken(prt.Token), prt.User.ID, prt.ExpiredAt)
+	if createdRow.Err() != nil {
+		return prt, createdRow.Err()
+	}
+
+	// scan row ID into password reset token ID
+	err = createdRow.Scan(&prt.ID)
+	if err != nil {
+		return prt, err
+	}
+
+	// commit transaction
+	err = tx.Commit()
+	if err != nil {
+		return prt, err
+	}
+	////////////////////////////////////////////////////////////
+
+	return prt, nil
+}
+
+// func for reset user password by password reset token, the new password
+// can't be one of the last passwords of the user, all sessions of the user
+// are revoked after password changed
+//
+// Return status 400 if token not valid, already used, or expired,
+// or status 422 if new password already used recently (token not used)
+func ResetPassword(DB *sql.DB, c config.Config, tokenString string,
+	newPassword string) (int, error) {
+	// hashing new password
+	hashedPassword, err := utils.HashPassword(newPassword)
+	if err != nil {
+		return 500, err
+	}
+
+	//////////////////// begin transaction /////////////////////
+	tx, err := DB.Begin()
+	if err != nil {
+		return 500, err
+	}
+	defer tx.Rollback() // rollback transaction if fail (return before commit)
+
+	// get password reset token
+	prt := PasswordResetToken{}
+	var usedAt sql.NullTime
+	err = tx.QueryRow(`
+		SELECT id, account_user_id, expired_at, used_at
+			FROM account_passwordreset
+			WHERE token_hash = $1
+		`, utils.HashToken(tokenString)).Scan(
+		&prt.ID,
+		&prt.User.ID,
+		&prt.ExpiredAt,
+		&usedAt,
+	)
+	if err == sql.ErrNoRows { // if token not exist
+		return 400, nil
+	} else if err != nil {
+		return 500, err
+	}
+
+	// check token already used or expired
+	if usedAt.Valid || time.Now().UTC().After(prt.ExpiredAt) {
+		return 400, nil
+	}
+
+	// check new password not used recently
+	err = tx.QueryRow(`
+		SELECT password FROM account_user
+			WHERE id = $1
+		`, prt.User.ID).Scan(&prt.User.Password)
+	if err != nil {
+		return 500, err
+	}
+
+	isUsed, err := isPasswordUsedRecently(tx, c, prt.User, newPassword)
+	if err != nil {
+		return 500, err
+	}
+	if isUsed {
+		return 422, nil
+	}
+
+	// mark token as used, if no row affected then
+	// the token used by another request at the same time
+	res, err := tx.Exec(`
+		UPDATE account_passwordreset SET used_at = $1
+			WHERE id = $2 AND used_at IS NULL
+		`, time.Now().UTC(), prt.ID)
+	if err != nil {
+		return 500, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return 500, err
+	}
+	if affected == 0 {
+		return 400, nil
+	}
+
+	// set new password, old password kept in password history
+	_, err = setUserPassword(tx, c, prt.User, hashedPassword)
+	if err != nil {
+		return 500, err
+	}
+
+	// revoke all user sessions
+	_, err = tx.Exec(`
+		DELETE FROM account_usersession
+			WHERE account_user_id = $1
+		`, prt.User.ID)
+	if err != nil {
+		return 500, err
+	}
+
+	// commit transaction
+	err = tx.Commit()
+	if err != nil {
+		return 500, err
+	}
+	////////////////////////////////////////////////////////////
+
+	return 200, nil
+}
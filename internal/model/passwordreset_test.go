@@ -0,0 +1,130 @@
+/*
+Package model containing structs and functions for
+database transaction
+*/
+package model
+
+import (
+	"testing"
+)
+
+// TestCreatePasswordResetTokenAndResetPassword integration test
+// CreatePasswordResetToken and ResetPassword
+func TestCreatePasswordResetTokenAndResetPassword(t *testing.T) {
+	//////////////////// CREATE USER ////////////////////
+	// create user
+	user := User{
+		Email:       "reset@gmail.com",
+		Password:    "reset",
+		FullName:    "reset",
+		Address:     "address",
+		PhoneNumber: "08111111111",
+		Role:        "admin",
+	}
+
+	// get connection to testing DB
+	DB, err := GetTestDBConnection()
+	if err != nil {
+		t.Fatalf("Connection to testing DB failed => " + err.Error())
+	}
+
+	// delete prev data first
+	_, err = DB.Exec(`DELETE FROM account_user WHERE email = $1`, user.Email)
+	if err != nil {
+		t.Errorf("There's an error when deleting previous user testing data => " +
+			err.Error())
+	}
+
+	// create user data on DB
+	user, _ = CreateUser(DB, testConfig, user)
+
+	// login user, so the user has a session
+	_, _, _, _, err = AuthenticateUser(DB, testConfig, User{
+		Email:    "reset@gmail.com",
+		Password: "reset",
+	}, "test-agent", "127.0.0.1")
+	if err != nil {
+		t.Errorf("There's an error when authenticate user => " + err.Error())
+	}
+
+	//////////////////// CREATE PASSWORD RESET TOKEN ////////////////////
+	// the first token replaced by the second token
+	firstToken, err := CreatePasswordResetToken(DB, testConfig, PasswordResetToken{User: user})
+	if err != nil {
+		t.Errorf("Expected error nil, but got not nil => " + err.Error())
+	}
+
+	secondToken, err := CreatePasswordResetToken(DB, testConfig, PasswordResetToken{User: user})
+	if err != nil {
+		t.Errorf("Expected error nil, but got not nil => " + err.Error())
+	}
+
+	if secondToken.Token == "" || secondToken.ID == 0 {
+		t.Errorf("Expected password reset token created, but got empty")
+	}
+
+	//////////////////// RESET PASSWORD ////////////////////
+	// create testing table
+	testTable := []struct {
+		Token          string
+		Password       string
+		ExpectedStatus int
+	}{
+		{
+			Token:          firstToken.Token,
+			Password:       "newreset",
+			ExpectedStatus: 400,
+		},
+		{
+			Token:          "invalid token",
+			Password:       "newreset",
+			ExpectedStatus: 400,
+		},
+		{
+			Token:          secondToken.Token,
+			Password:       "reset",
+			ExpectedStatus: 422,
+		},
+		{
+			Token:          secondToken.Token,
+			Password:       "newreset",
+			ExpectedStatus: 200,
+		},
+		{
+			Token:          secondToken.Token,
+			Password:       "newreset",
+			ExpectedStatus: 400,
+		},
+	}
+
+	for _, test := range testTable {
+		status, err := ResetPassword(DB, testConfig, test.Token, test.Password)
+		if err != nil {
+			t.Errorf("There's an error when reset password => " + err.Error())
+		}
+
+		if test.ExpectedStatus != status {
+			t.Errorf("Expected status %d, but got status %d",
+				test.ExpectedStatus, status)
+		}
+	}
+
+	// check all user sessions revoked
+	userSessions, err := GetUserSessions(DB, user.ID)
+	if err != nil {
+		t.Errorf("Expected error nil, but got not nil => " + err.Error())
+	}
+
+	if len(userSessions) != 0 {
+		t.Errorf("Expected 0 user sessions, but got %d", len(userSessions))
+	}
+
+	// check user can login with new password
+	_, _, status, _, err := AuthenticateUser(DB, testConfig, User{
+		Email:    "reset@gmail.com",
+		Password: "newreset",
+	}, "test-agent", "127.0.0.1")
+	if err != nil || status != 200 {
+		t.Errorf("Expected login with new password success, but got status %d", status)
+	}
+}
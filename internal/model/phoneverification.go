@@ -0,0 +1,204 @@
+/*
+Package model containing structs and functions for
+database transaction
+*/
+package model
+
+import (
+	"crypto/subtle"
+	"database/sql"
+	"time"
+
+	"github.com/reyhanfikridz/ecom-account-service/internal/config"
+	"github.com/reyhanfikridz/ecom-account-service/internal/phone"
+	"github.com/reyhanfikridz/ecom-account-service/internal/utils"
+)
+
+// number of digits of phone verification code
+const PhoneVerificationCodeDigits = 6
+
+// func for create phone verification code of user phone number,
+// replace previous code of the user. Phone number saved before normalization
+// normalized to E.164 first, return the normalized phone number with the code
+//
+// Return status 400 if user not exist, status 422 if phone number not valid,
+// status 409 if phone number already verified,
+// or status 429 if code already sent within resend interval
+func CreatePhoneVerification(DB *sql.DB, c config.Config, userID int) (
+	string, string, int, error) {
+	//////////////////// begin transaction /////////////////////
+	tx, err := DB.Begin()
+	if err != nil {
+		return "", "", 500, err
+	}
+	defer tx.Rollback() // rollback transaction if fail (return before commit)
+
+	// lock the user so only one code created by concurrent requests
+	var phoneNumber string
+	var phoneVerifiedAt *time.Time
+	err = tx.QueryRow(`
+		SELECT phone_number, phone_verified_at
+			FROM account_user
+			WHERE id = $1 AND status <> $2
+			FOR UPDATE
+		`, userID, UserStatusDeleted).Scan(&phoneNumber, &phoneVerifiedAt)
+	if err == sql.ErrNoRows {
+		return "", "", 400, nil
+	} else if err != nil {
+		return "", "", 500, err
+	}
+
+	normalizedPhoneNumber, err := phone.Normalize(phoneNumber, c.PhoneDefaultRegion)
+	if err != nil {
+		return "", "", 422, nil
+	}
+	if phoneVerifiedAt != nil && normalizedPhoneNumber == phoneNumber {
+		return "", "", 409, nil
+	}
+
+	// check resend interval
+	now := time.Now().UTC()
+	var count int
+	err = tx.QueryRow(`
+		SELECT COUNT(*)
+			FROM account_phoneverification
+			WHERE account_user_id = $1 AND created_at > $2
+		`, userID, now.Add(-c.PhoneVerificationResendInterval)).Scan(&count)
+	if err != nil {
+		return "", "", 500, err
+	}
+	if count > 0 {
+		return "", "", 429, nil
+	}
+
+	// normalize phone number saved before normalization
+	if normalizedPhoneNumber != phoneNumber {
+		_, err = tx.Exec(`
+			UPDATE account_user
+				SET phone_number = $1, phone_verified_at = NULL,
+					version = version + 1, updated_at = $2
+				WHERE id = $3
+			`, normalizedPhoneNumber, now, userID)
+		if err != nil {
+			return "", "", 500, err
+		}
+	}
+
+	// replace previous code
+	_, err = tx.Exec(`DELETE FROM account_phoneverification WHERE account_user_id = $1`,
+		userID)
+	if err != nil {
+		return "", "", 500, err
+	}
+
+	code, err := utils.GenerateNumericCode(PhoneVerificationCodeDigits)
+	if err != nil {
+		return "", "", 500, err
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO account_phoneverification(account_user_id, phone_number, code_hash,
+			created_at, expired_at)
+			VALUES($1, $2, $3, $4, $5)
+		`, userID, normalizedPhoneNumber, utils.HashToken(code),
+		now, now.Add(c.PhoneVerificationCodeDuration))
+	if err != nil {
+		return "", "", 500, err
+	}
+
+	// commit transaction
+	err = tx.Commit()
+	if err != nil {
+		return "", "", 500, err
+	}
+	////////////////////////////////////////////////////////////
+
+	return code, normalizedPhoneNumber, 200, nil
+}
+
+// func for verify user phone number by code sent to the phone number,
+// the phone number must be still the same as the phone number
+// when the code sent
+//
+// Return status 400 if code not valid, expired, or phone number already changed,
+// or status 429 if wrong code entered too many times (code deleted)
+func VerifyPhone(DB *sql.DB, c config.Config, userID int, code string) (int, error) {
+	//////////////////// begin transaction /////////////////////
+	tx, err := DB.Begin()
+	if err != nil {
+		return 500, err
+	}
+	defer tx.Rollback() // rollback transaction if fail (return before commit)
+
+	// get code of the user
+	var ID, attempts int
+	var phoneNumber, codeHash string
+	err = tx.QueryRow(`
+		SELECT id, phone_number, code_hash, attempts
+			FROM account_phoneverification
+			WHERE account_user_id = $1 AND expired_at > $2
+			FOR UPDATE
+		`, userID, time.Now().UTC()).Scan(&ID, &phoneNumber, &codeHash, &attempts)
+	if err == sql.ErrNoRows {
+		return 400, nil
+	} else if err != nil {
+		return 500, err
+	}
+
+	// check code, the code deleted after too many wrong attempts
+	if subtle.ConstantTimeCompare([]byte(utils.HashToken(code)), []byte(codeHash)) != 1 {
+		attempts++
+		if attempts >= c.PhoneVerificationMaxAttempts {
+			_, err = tx.Exec(`DELETE FROM account_phoneverification WHERE id = $1`, ID)
+		} else {
+			_, err = tx.Exec(`UPDATE account_phoneverification SET attempts = $1 WHERE id = $2`,
+				attempts, ID)
+		}
+		if err != nil {
+			return 500, err
+		}
+
+		err = tx.Commit()
+		if err != nil {
+			return 500, err
+		}
+
+		if attempts >= c.PhoneVerificationMaxAttempts {
+			return 429, nil
+		}
+		return 400, nil
+	}
+
+	// set verified time, keep the first verified time if already verified
+	res, err := tx.Exec(`
+		UPDATE account_user
+			SET phone_verified_at = COALESCE(phone_verified_at, $1)
+			WHERE id = $2 AND phone_number = $3
+		`, time.Now().UTC(), userID, phoneNumber)
+	if err != nil {
+		return 500, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return 500, err
+	}
+
+	_, err = tx.Exec(`DELETE FROM account_phoneverification WHERE id = $1`, ID)
+	if err != nil {
+		return 500, err
+	}
+
+	// commit transaction
+	err = tx.Commit()
+	if err != nil {
+		return 500, err
+	}
+	////////////////////////////////////////////////////////////
+
+	if affected == 0 {
+		return 400, nil
+	}
+
+	return 200, nil
+}
@@ -0,0 +1,129 @@
+/*
+Package model containing structs and functions for
+database transaction
+*/
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+// TestCreatePhoneVerificationAndVerifyPhone integration test
+// CreatePhoneVerification and VerifyPhone
+func TestCreatePhoneVerificationAndVerifyPhone(t *testing.T) {
+	c := testConfig
+	c.PhoneDefaultRegion = "ID"
+	c.PhoneVerificationCodeDuration = 10 * time.Minute
+	c.PhoneVerificationResendInterval = time.Minute
+	c.PhoneVerificationMaxAttempts = 2
+
+	// get connection to testing DB
+	DB, err := GetTestDBConnection()
+	if err != nil {
+		t.Fatalf("Connection to testing DB failed => " + err.Error())
+	}
+
+	// delete prev data first
+	_, err = DB.Exec(`DELETE FROM account_user WHERE email = $1`,
+		"testphoneverification@gmail.com")
+	if err != nil {
+		t.Errorf("There's an error when deleting previous user testing data => " +
+			err.Error())
+	}
+
+	// create user data with phone number saved before normalization
+	user, err := CreateUser(DB, c, User{
+		Email:       "testphoneverification@gmail.com",
+		Password:    "testphoneverification",
+		FullName:    "testphoneverification",
+		Address:     "address",
+		PhoneNumber: "0811-1111-1111",
+		Role:        "buyer",
+	})
+	if err != nil {
+		t.Errorf("There's an error when creating user => " + err.Error())
+	}
+
+	// create code, phone number normalized
+	code, phoneNumber, status, err := CreatePhoneVerification(DB, c, user.ID)
+	if status != 200 || err != nil {
+		t.Fatalf("Expected create phone verification status 200, but got %d (%v)",
+			status, err)
+	}
+	if phoneNumber != "+6281111111111" || len(code) != PhoneVerificationCodeDigits {
+		t.Errorf("Expected normalized phone number and code, but got '%s' and '%s'",
+			phoneNumber, code)
+	}
+
+	// can't resend within resend interval
+	_, _, status, err = CreatePhoneVerification(DB, c, user.ID)
+	if status != 429 || err != nil {
+		t.Errorf("Expected create phone verification status 429, but got %d (%v)",
+			status, err)
+	}
+
+	// wrong code, then too many wrong code
+	wrongCode := "000000"
+	if code == wrongCode {
+		wrongCode = "111111"
+	}
+	for _, expectedStatus := range []int{400, 429, 400} {
+		status, err = VerifyPhone(DB, c, user.ID, wrongCode)
+		if status != expectedStatus || err != nil {
+			t.Errorf("Expected verify phone status %d, but got %d (%v)",
+				expectedStatus, status, err)
+		}
+	}
+
+	// the code deleted after too many wrong code
+	status, err = VerifyPhone(DB, c, user.ID, code)
+	if status != 400 || err != nil {
+		t.Errorf("Expected verify phone status 400, but got %d (%v)", status, err)
+	}
+
+	// resend code then verify
+	_, err = DB.Exec(`UPDATE account_phoneverification SET created_at = $1
+		WHERE account_user_id = $2`, time.Now().UTC().Add(-time.Hour), user.ID)
+	if err != nil {
+		t.Errorf("There's an error when updating phone verification time => " + err.Error())
+	}
+
+	code, _, status, err = CreatePhoneVerification(DB, c, user.ID)
+	if status != 200 || err != nil {
+		t.Fatalf("Expected create phone verification status 200, but got %d (%v)",
+			status, err)
+	}
+
+	status, err = VerifyPhone(DB, c, user.ID, code)
+	if status != 200 || err != nil {
+		t.Errorf("Expected verify phone status 200, but got %d (%v)", status, err)
+	}
+
+	user, err = GetUser(DB, "", user.ID)
+	if err != nil {
+		t.Errorf("There's an error when getting user => " + err.Error())
+	}
+	if user.PhoneNumber != "+6281111111111" || user.PhoneVerifiedAt == nil {
+		t.Errorf("Expected phone number verified, but got '%s' (%v)",
+			user.PhoneNumber, user.PhoneVerifiedAt)
+	}
+
+	// already verified
+	_, _, status, err = CreatePhoneVerification(DB, c, user.ID)
+	if status != 409 || err != nil {
+		t.Errorf("Expected create phone verification status 409, but got %d (%v)",
+			status, err)
+	}
+
+	// changed phone number need to be verified again
+	newPhoneNumber := "+6282222222222"
+	user, status, err = UpdateUserProfile(DB, user.ID, user.Version,
+		UserProfileUpdate{PhoneNumber: &newPhoneNumber})
+	if status != 200 || err != nil {
+		t.Errorf("Expected update user profile status 200, but got %d (%v)", status, err)
+	}
+	if user.PhoneVerifiedAt != nil {
+		t.Errorf("Expected changed phone number not verified, but verified")
+	}
+}
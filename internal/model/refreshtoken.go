@@ -0,0 +1,210 @@
+/*
+Package model containing structs and functions for
+database transaction
+*/
+package model
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/reyhanfikridz/ecom-account-service/internal/config"
+	"github.com/reyhanfikridz/ecom-account-service/internal/utils"
+)
+
+// refresh token model
+//
+// Refresh token is opaque and only saved as hash in database,
+// Token only filled right after the refresh token created.
+// All refresh tokens of one user session is one token family,
+// so revoking the family is done by deleting the user session.
+type RefreshToken struct {
+	ID          int         `json:"id"`
+	Token       string      `json:"token"`
+	UserSession UserSession `json:"user_session"`
+	IsUsed      bool        `json:"is_used"`
+	ExpiredAt   time.Time   `json:"expired_at"`
+}
+
+// func for create refresh token of a user session
+func CreateRefreshToken(DB *sql.DB, c config.Config, rt RefreshToken) (
+	RefreshToken, error) {
+	//////////////////// begin transaction /////////////////////
+	tx, err := DB.Begin()
+	if err != nil {
+		return rt, err
+	}
+	defer tx.Rollback() // rollback transaction if fail (return before commit)
+
+	rt, err = createRefreshToken(tx, c, rt)
+	if err != nil {
+		return rt, err
+	}
+
+	// commit transaction
+	err = tx.Commit()
+	if err != nil {
+		return rt, err
+	}
+	////////////////////////////////////////////////////////////
+
+	return rt, nil
+}
+
+// func for refresh user session (rotating refresh token),
+// return new access token and new refresh token
+//
+// If refresh token already used before (token reuse), or the user can't
+// use its account anymore (suspended, deleted, or scheduled for deletion),
+// all token family will be revoked (user session deleted) and return status 400
+func RefreshUserSession(DB *sql.DB, c config.Config, refreshTokenString string) (
+	string, string, int, error) {
+	//////////////////// begin transaction /////////////////////
+	tx, err := DB.Begin()
+	if err != nil {
+		return "", "", 500, err
+	}
+	defer tx.Rollback() // rollback transaction if fail (return before commit)
+
+	// get refresh token and its user session
+	rt := RefreshToken{}
+	row := tx.QueryRow(`
+		SELECT account_refreshtoken.id, account_refreshtoken.is_used,
+			account_refreshtoken.expired_at, account_usersession.id,
+			account_user.id, account_user.email, account_user.role,
+			account_user.email_verified_at, account_user.status,
+			account_user.suspended_until, account_user.deletion_scheduled_at
+			FROM account_refreshtoken
+				INNER JOIN account_usersession
+					ON account_refreshtoken.account_usersession_id = account_usersession.id
+				INNER JOIN account_user
+					ON account_usersession.account_user_id = account_user.id
+			WHERE account_refreshtoken.token_hash = $1
+		`, utils.HashToken(refreshTokenString))
+
+	err = row.Scan(
+		&rt.ID,
+		&rt.IsUsed,
+		&rt.ExpiredAt,
+		&rt.UserSession.ID,
+		&rt.UserSession.User.ID,
+		&rt.UserSession.User.Email,
+		&rt.UserSession.User.Role,
+		&rt.UserSession.User.EmailVerifiedAt,
+		&rt.UserSession.User.Status,
+		&rt.UserSession.User.SuspendedUntil,
+		&rt.UserSession.User.DeletionScheduledAt,
+	)
+	if err == sql.ErrNoRows { // if refresh token not exist
+		return "", "", 400, nil
+	} else if err != nil {
+		return "", "", 500, err
+	}
+
+	// check user still can use its account
+	isUserUsable := IsUserStatusUsable(EffectiveStatus(rt.UserSession.User)) &&
+		rt.UserSession.User.DeletionScheduledAt == nil
+
+	// mark refresh token as used,
+	// if no row affected then the token already used (token reuse)
+	if !rt.IsUsed && isUserUsable {
+		res, err := tx.Exec(`
+			UPDATE account_refreshtoken SET is_used = TRUE
+				WHERE id = $1 AND is_used = FALSE
+			`, rt.ID)
+		if err != nil {
+			return "", "", 500, err
+		}
+
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return "", "", 500, err
+		}
+		rt.IsUsed = affected == 0
+	}
+
+	// revoke token family if refresh token reused or user can't use its account
+	if rt.IsUsed || !isUserUsable {
+		_, err = tx.Exec(`
+			DELETE FROM account_usersession
+				WHERE id = $1
+			`, rt.UserSession.ID)
+		if err != nil {
+			return "", "", 500, err
+		}
+
+		err = tx.Commit()
+		if err != nil {
+			return "", "", 500, err
+		}
+
+		return "", "", 400, nil
+	}
+
+	// check refresh token expired or not
+	if time.Now().UTC().After(rt.ExpiredAt) {
+		return "", "", 400, nil
+	}
+
+	// generate new access token and save it into user session
+	tokenString, err := utils.GenerateJWT(c.JWTKeys, rt.UserSession.User.ID,
+		EffectiveRole(c, rt.UserSession.User), c.AccessTokenDuration)
+	if err != nil {
+		return "", "", 500, err
+	}
+
+	_, err = tx.Exec(`
+		UPDATE account_usersession SET token = $1
+			WHERE id = $2
+		`, tokenString, rt.UserSession.ID)
+	if err != nil {
+		return "", "", 500, err
+	}
+
+	// create new refresh token in the same token family
+	newRefreshToken, err := createRefreshToken(tx, c, RefreshToken{
+		UserSession: rt.UserSession,
+	})
+	if err != nil {
+		return "", "", 500, err
+	}
+
+	// commit transaction
+	err = tx.Commit()
+	if err != nil {
+		return "", "", 500, err
+	}
+	////////////////////////////////////////////////////////////
+
+	return tokenString, newRefreshToken.Token, 200, nil
+}
+
+// func for create refresh token inside a transaction
+func createRefreshToken(tx *sql.Tx, c config.Config, rt RefreshToken) (
+	RefreshToken, error) {
+	// generate opaque token
+	tokenString, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return rt, err
+	}
+	rt.Token = tokenString
+	rt.IsUsed = false
+	rt.ExpiredAt = time.Now().UTC().Add(c.RefreshTokenDuration)
+
+	// insert refresh token
+	createdRow := tx.QueryRow(`
+		INSERT INTO account_refreshtoken(token_hash, account_usersession_id, expired_at)
+			VALUES($1, $2, $3) RETURNING id`,
+		utils.HashToken(rt.Token), rt.UserSession.ID, rt.ExpiredAt)
+	if createdRow.Err() != nil {
+		return rt, createdRow.Err()
+	}
+
+	// scan row ID into refresh token ID
+	err = createdRow.Scan(&rt.ID)
+	if err != nil {
+		return rt, err
+	}
+
+	return rt, nil
+}
@@ -0,0 +1,196 @@
+/*
+Package model containing structs and functions for
+database transaction
+*/
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+// TestAuthenticateUserAndRefreshUserSession integration test
+// AuthenticateUser and RefreshUserSession
+func TestAuthenticateUserAndRefreshUserSession(t *testing.T) {
+	//////////////////// CREATE USER ////////////////////
+	// create user
+	user := User{
+		Email:       "refresh@gmail.com",
+		Password:    "refresh",
+		FullName:    "refresh",
+		Address:     "address",
+		PhoneNumber: "08111111111",
+		Role:        "admin",
+	}
+
+	// get connection to testing DB
+	DB, err := GetTestDBConnection()
+	if err != nil {
+		t.Fatalf("Connection to testing DB failed => " + err.Error())
+	}
+
+	// delete prev data first
+	_, err = DB.Exec(`DELETE FROM account_user WHERE email = $1`, user.Email)
+	if err != nil {
+		t.Errorf("There's an error when deleting previous user testing data => " +
+			err.Error())
+	}
+
+	// create user data on DB
+	user, err = CreateUser(DB, testConfig, user)
+	if err != nil {
+		t.Errorf("There's an error when creating user => " + err.Error())
+	}
+
+	//////////////////// AUTHENTICATE USER ////////////////////
+	token, refreshToken, status, _, err := AuthenticateUser(DB, testConfig, User{
+		Email:    "refresh@gmail.com",
+		Password: "refresh",
+	}, "test-agent", "127.0.0.1")
+	if err != nil || status != 200 {
+		t.Fatalf("Expected authenticate user success, but got status %d", status)
+	}
+
+	//////////////////// REFRESH USER SESSION ////////////////////
+	// refresh with valid refresh token
+	newToken, newRefreshToken, status, err := RefreshUserSession(DB, testConfig, refreshToken)
+	if err != nil {
+		t.Errorf("Expected error nil, but got not nil => " + err.Error())
+	}
+
+	if status != 200 {
+		t.Errorf("Expected status 200, but got status %d", status)
+	}
+
+	if newToken == token || newRefreshToken == refreshToken {
+		t.Errorf("Expected new token and new refresh token, but got the old one")
+	}
+
+	// old access token replaced by new access token in user session
+	userSession, err := GetUserSession(DB, newToken, user.ID)
+	if err != nil || userSession.ID == 0 {
+		t.Errorf("Expected user session with new token exist, but not exist")
+	}
+
+	// refresh with invalid refresh token
+	_, _, status, err = RefreshUserSession(DB, testConfig, "invalid refresh token")
+	if err != nil {
+		t.Errorf("Expected error nil, but got not nil => " + err.Error())
+	}
+
+	if status != 400 {
+		t.Errorf("Expected status 400, but got status %d", status)
+	}
+
+	// reuse old (rotated) refresh token, token family must be revoked
+	_, _, status, err = RefreshUserSession(DB, testConfig, refreshToken)
+	if err != nil {
+		t.Errorf("Expected error nil, but got not nil => " + err.Error())
+	}
+
+	if status != 400 {
+		t.Errorf("Expected status 400, but got status %d", status)
+	}
+
+	_, err = GetUserSession(DB, newToken, user.ID)
+	if err == nil {
+		t.Errorf("Expected user session revoked, but still exist")
+	}
+
+	_, _, status, err = RefreshUserSession(DB, testConfig, newRefreshToken)
+	if err != nil {
+		t.Errorf("Expected error nil, but got not nil => " + err.Error())
+	}
+
+	if status != 400 {
+		t.Errorf("Expected status 400, but got status %d", status)
+	}
+}
+
+// TestRefreshUserSessionUserNotUsable test RefreshUserSession of user
+// that can't use its account anymore, without its sessions revoked before
+func TestRefreshUserSessionUserNotUsable(t *testing.T) {
+	// get connection to testing DB
+	DB, err := GetTestDBConnection()
+	if err != nil {
+		t.Fatalf("Connection to testing DB failed => " + err.Error())
+	}
+
+	// initialize testing table, user changed directly in DB
+	// so its sessions not revoked
+	testTable := []struct {
+		Name       string
+		Query      string
+		QueryValue any
+	}{
+		{
+			Name:       "permanently suspended",
+			Query:      `UPDATE account_user SET status = $1 WHERE email = $2`,
+			QueryValue: UserStatusSuspended,
+		},
+		{
+			Name: "suspended",
+			Query: `UPDATE account_user SET status = 'suspended', suspended_until = $1
+				WHERE email = $2`,
+			QueryValue: time.Now().UTC().Add(time.Hour),
+		},
+		{
+			Name:       "deleted",
+			Query:      `UPDATE account_user SET status = $1 WHERE email = $2`,
+			QueryValue: UserStatusDeleted,
+		},
+		{
+			Name:       "scheduled for deletion",
+			Query:      `UPDATE account_user SET deletion_scheduled_at = $1 WHERE email = $2`,
+			QueryValue: time.Now().UTC().Add(time.Hour),
+		},
+	}
+
+	// loop test in test table
+	for _, test := range testTable {
+		// create user data with its session
+		_, err = DB.Exec(`DELETE FROM account_user WHERE email = $1`,
+			"testrefreshnotusable@gmail.com")
+		if err != nil {
+			t.Errorf("There's an error when deleting previous user testing data => " +
+				err.Error())
+		}
+
+		user, err := CreateUser(DB, testConfig, User{
+			Email:       "testrefreshnotusable@gmail.com",
+			Password:    "testrefreshnotusable",
+			FullName:    "testrefreshnotusable",
+			Address:     "address",
+			PhoneNumber: "08111111111",
+			Role:        "buyer",
+		})
+		if err != nil {
+			t.Errorf("There's an error when creating user => " + err.Error())
+		}
+
+		token, refreshToken, status, _, err := AuthenticateUser(DB, testConfig, User{
+			Email:    "testrefreshnotusable@gmail.com",
+			Password: "testrefreshnotusable",
+		}, "test-agent", "127.0.0.1")
+		if err != nil || status != 200 {
+			t.Fatalf("Expected authenticate user success, but got status %d", status)
+		}
+
+		_, err = DB.Exec(test.Query, test.QueryValue, "testrefreshnotusable@gmail.com")
+		if err != nil {
+			t.Errorf("There's an error when updating user testing data => " + err.Error())
+		}
+
+		// refresh rejected and user session revoked
+		_, _, status, err = RefreshUserSession(DB, testConfig, refreshToken)
+		if status != 400 || err != nil {
+			t.Errorf("Expected status 400 and error nil of %s user, but got status %d error %v",
+				test.Name, status, err)
+		}
+
+		_, err = GetUserSession(DB, token, user.ID)
+		if err == nil {
+			t.Errorf("Expected user session of %s user revoked, but still exist", test.Name)
+		}
+	}
+}
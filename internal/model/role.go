@@ -0,0 +1,110 @@
+/*
+Package model containing structs and functions for
+database transaction
+*/
+package model
+
+import (
+	"database/sql"
+)
+
+// permissions checked by this service, the other permissions
+// (e.g. "product:write") checked by downstream services
+const (
+	PermissionReadUser          = "user:read"
+	PermissionWriteUser         = "user:write"
+	PermissionPromoteJWTKey     = "jwt_key:promote"
+	PermissionClearLoginLockout = "login_lockout:clear"
+)
+
+// role model
+//
+// Only self registrable role can be chosen when user register,
+// the other roles (e.g. admin) must be given by admin.
+type Role struct {
+	ID                int      `json:"id"`
+	Name              string   `json:"name"`
+	IsSelfRegistrable bool     `json:"is_self_registrable"`
+	Permissions       []string `json:"permissions"`
+}
+
+// func for get a role with its permissions by role name
+func GetRole(DB *sql.DB, name string) (Role, error) {
+	role := Role{}
+	err := DB.QueryRow(`
+		SELECT id, name, is_self_registrable
+			FROM account_role
+			WHERE name = $1
+		`, name).Scan(
+		&role.ID,
+		&role.Name,
+		&role.IsSelfRegistrable,
+	)
+	if err != nil {
+		return role, err
+	}
+
+	role.Permissions, err = GetRolePermissions(DB, role.Name)
+	if err != nil {
+		return role, err
+	}
+
+	return role, nil
+}
+
+// func for get permissions of a role by role name,
+// role that not exist has no permission
+func GetRolePermissions(DB *sql.DB, name string) ([]string, error) {
+	permissions := []string{}
+	rows, err := DB.Query(`
+		SELECT account_rolepermission.permission
+			FROM account_rolepermission
+			JOIN account_role ON account_role.id = account_rolepermission.account_role_id
+			WHERE account_role.name = $1
+			ORDER BY account_rolepermission.permission
+		`, name)
+	if err != nil {
+		return permissions, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var permission string
+		err = rows.Scan(&permission)
+		if err != nil {
+			return permissions, err
+		}
+
+		permissions = append(permissions, permission)
+	}
+
+	return permissions, rows.Err()
+}
+
+// func for check a role has a permission or not
+func HasPermission(DB *sql.DB, name string, permission string) (bool, error) {
+	var count int
+	err := DB.QueryRow(`
+		SELECT COUNT(*)
+			FROM account_rolepermission
+			JOIN account_role ON account_role.id = account_rolepermission.account_role_id
+			WHERE account_role.name = $1 AND account_rolepermission.permission = $2
+		`, name, permission).Scan(&count)
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+// func for check a role can be chosen by user when register or not
+func IsRoleSelfRegistrable(DB *sql.DB, name string) (bool, error) {
+	role, err := GetRole(DB, name)
+	if err == sql.ErrNoRows {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	return role.IsSelfRegistrable, nil
+}
@@ -0,0 +1,126 @@
+/*
+Package model containing structs and functions for
+database transaction
+*/
+package model
+
+import (
+	"database/sql"
+	"testing"
+)
+
+// TestGetRoleAndIsRoleSelfRegistrable integration test
+// GetRole and IsRoleSelfRegistrable with seeded roles
+func TestGetRoleAndIsRoleSelfRegistrable(t *testing.T) {
+	// get connection to testing DB
+	DB, err := GetTestDBConnection()
+	if err != nil {
+		t.Fatalf("Connection to testing DB failed => " + err.Error())
+	}
+
+	// create testing table
+	testTable := []struct {
+		Name                      string
+		ExpectedExist             bool
+		ExpectedIsSelfRegistrable bool
+	}{
+		{
+			Name:                      "buyer",
+			ExpectedExist:             true,
+			ExpectedIsSelfRegistrable: true,
+		},
+		{
+			Name:                      "seller",
+			ExpectedExist:             true,
+			ExpectedIsSelfRegistrable: true,
+		},
+		{
+			Name:                      "admin",
+			ExpectedExist:             true,
+			ExpectedIsSelfRegistrable: false,
+		},
+		{
+			Name:                      "notexist",
+			ExpectedExist:             false,
+			ExpectedIsSelfRegistrable: false,
+		},
+	}
+
+	// loop test in test table
+	for _, test := range testTable {
+		role, err := GetRole(DB, test.Name)
+		if test.ExpectedExist && err != nil {
+			t.Errorf("Expected role " + test.Name + " exist, but got error => " + err.Error())
+		} else if !test.ExpectedExist && err != sql.ErrNoRows {
+			t.Errorf("Expected role " + test.Name + " not exist, but got exist")
+		}
+
+		if test.ExpectedExist && len(role.Permissions) == 0 {
+			t.Errorf("Expected role " + test.Name + " has permissions, but got empty")
+		}
+
+		isSelfRegistrable, err := IsRoleSelfRegistrable(DB, test.Name)
+		if err != nil {
+			t.Errorf("There's an error when checking role self registrable => " +
+				err.Error())
+		}
+		if isSelfRegistrable != test.ExpectedIsSelfRegistrable {
+			t.Errorf("Expected role "+test.Name+" self registrable %t, but got %t",
+				test.ExpectedIsSelfRegistrable, isSelfRegistrable)
+		}
+	}
+}
+
+// TestHasPermission test HasPermission with seeded role permissions
+func TestHasPermission(t *testing.T) {
+	// get connection to testing DB
+	DB, err := GetTestDBConnection()
+	if err != nil {
+		t.Fatalf("Connection to testing DB failed => " + err.Error())
+	}
+
+	// create testing table
+	testTable := []struct {
+		Name               string
+		Permission         string
+		ExpectedPermission bool
+	}{
+		{
+			Name:               "admin",
+			Permission:         PermissionPromoteJWTKey,
+			ExpectedPermission: true,
+		},
+		{
+			Name:               "buyer",
+			Permission:         PermissionPromoteJWTKey,
+			ExpectedPermission: false,
+		},
+		{
+			Name:               "seller",
+			Permission:         "product:write",
+			ExpectedPermission: true,
+		},
+		{
+			Name:               "buyer",
+			Permission:         "product:write",
+			ExpectedPermission: false,
+		},
+		{
+			Name:               "notexist",
+			Permission:         "product:read",
+			ExpectedPermission: false,
+		},
+	}
+
+	// loop test in test table
+	for _, test := range testTable {
+		hasPermission, err := HasPermission(DB, test.Name, test.Permission)
+		if err != nil {
+			t.Errorf("There's an error when checking permission => " + err.Error())
+		}
+		if hasPermission != test.ExpectedPermission {
+			t.Errorf("Expected role "+test.Name+" has permission "+test.Permission+
+				" %t, but got %t", test.ExpectedPermission, hasPermission)
+		}
+	}
+}
@@ -0,0 +1,344 @@
+/*
+Package model containing structs and functions for
+database transaction
+*/
+package model
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// default and maximum number of users in a page of user list
+const (
+	DefaultUserListLimit = 20
+	MaxUserListLimit     = 100
+)
+
+// user list sort keys with its column, sort key can be
+// prefixed with "-" for descending order (e.g. "-created_at")
+var userListSortColumns = map[string]string{
+	"created_at": "account_user.created_at",
+	"email":      "account_user.email",
+	"full_name":  "account_user.full_name",
+}
+
+// user list filter, empty field not filtered
+type UserListFilter struct {
+	// prefix of email or full name, case insensitive
+	Query string
+
+	Role        string
+	Status      string
+	CreatedFrom *time.Time // inclusive
+	CreatedTo   *time.Time // exclusive
+
+	Sort   string
+	Cursor string
+	Limit  int
+}
+
+// user list cursor, position of the last user of the previous page
+type userListCursor struct {
+	Sort  string `json:"s"`
+	Value string `json:"v"`
+	ID    int    `json:"id"`
+}
+
+// func for get a page of users by filter with cursor pagination,
+// return users and cursor of the next page (empty if last page)
+//
+// Return status 400 if sort key or cursor not valid
+func GetUsers(DB *sql.DB, f UserListFilter) ([]User, string, int, error) {
+	users := []User{}
+
+	// get sort column and order
+	if strings.TrimSpace(f.Sort) == "" {
+		f.Sort = "-created_at"
+	}
+	isDesc := strings.HasPrefix(f.Sort, "-")
+	sortColumn, ok := userListSortColumns[strings.TrimPrefix(f.Sort, "-")]
+	if !ok {
+		return users, "", 400, nil
+	}
+
+	if f.Limit <= 0 {
+		f.Limit = DefaultUserListLimit
+	} else if f.Limit > MaxUserListLimit {
+		f.Limit = MaxUserListLimit
+	}
+
+	// build filter conditions
+	conditions := []string{}
+	args := []any{}
+	addCondition := func(condition string, values ...any) {
+		for _, value := range values {
+			args = append(args, value)
+			condition = strings.Replace(condition, "?", "$"+strconv.Itoa(len(args)), 1)
+		}
+		conditions = append(conditions, condition)
+	}
+
+	if strings.TrimSpace(f.Query) != "" {
+		pattern := escapeLikePattern(strings.ToLower(strings.TrimSpace(f.Query))) + "%"
+		addCondition(`(LOWER(account_user.email) LIKE ? ESCAPE '\'
+			OR LOWER(account_user.full_name) LIKE ? ESCAPE '\')`, pattern, pattern)
+	}
+	if strings.TrimSpace(f.Role) != "" {
+		addCondition(`account_user.role = ?`, f.Role)
+	}
+	if strings.TrimSpace(f.Status) != "" {
+		addCondition(`account_user.status = ?`, f.Status)
+	}
+	if f.CreatedFrom != nil {
+		addCondition(`account_user.created_at >= ?`, f.CreatedFrom.UTC())
+	}
+	if f.CreatedTo != nil {
+		addCondition(`account_user.created_at < ?`, f.CreatedTo.UTC())
+	}
+
+	// start after the cursor position
+	if strings.TrimSpace(f.Cursor) != "" {
+		cursor, err := decodeUserListCursor(f.Cursor)
+		if err != nil || cursor.Sort != f.Sort {
+			return users, "", 400, nil
+		}
+
+		var cursorValue any = cursor.Value
+		if sortColumn == "account_user.created_at" {
+			cursorValue, err = time.Parse(time.RFC3339Nano, cursor.Value)
+			if err != nil {
+				return users, "", 400, nil
+			}
+		}
+
+		operator := ">"
+		if isDesc {
+			operator = "<"
+		}
+		addCondition(`(`+sortColumn+`, account_user.id) `+operator+` (?, ?)`,
+			cursorValue, cursor.ID)
+	}
+
+	// do query, get one more user to know there's next page or not
+	query := `SELECT ` + userColumns + ` FROM account_user`
+	if len(conditions) > 0 {
+		query += ` WHERE ` + strings.Join(conditions, ` AND `)
+	}
+	order := "ASC"
+	if isDesc {
+		order = "DESC"
+	}
+	query += ` ORDER BY ` + sortColumn + ` ` + order + `, account_user.id ` + order +
+		` LIMIT ` + strconv.Itoa(f.Limit+1)
+
+	rows, err := DB.Query(query, args...)
+	if err != nil {
+		return users, "", 500, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		user := User{}
+		err = rows.Scan(userScanDest(&user)...)
+		if err != nil {
+			return users, "", 500, err
+		}
+
+		users = append(users, user)
+	}
+	if rows.Err() != nil {
+		return users, "", 500, rows.Err()
+	}
+
+	return getUserListPage(users, f, sortColumn)
+}
+
+// func for get a page of users by filter with cursor pagination
+// from all users (e.g. users kept in memory), the same as GetUsers
+//
+// Return status 400 if sort key or cursor not valid
+func FilterUsers(allUsers []User, f UserListFilter) ([]User, string, int, error) {
+	users := []User{}
+
+	// get sort column and order
+	if strings.TrimSpace(f.Sort) == "" {
+		f.Sort = "-created_at"
+	}
+	isDesc := strings.HasPrefix(f.Sort, "-")
+	sortColumn, ok := userListSortColumns[strings.TrimPrefix(f.Sort, "-")]
+	if !ok {
+		return users, "", 400, nil
+	}
+
+	if f.Limit <= 0 {
+		f.Limit = DefaultUserListLimit
+	} else if f.Limit > MaxUserListLimit {
+		f.Limit = MaxUserListLimit
+	}
+
+	// compare users by sort column then by ID in the sort order
+	compare := func(u1 User, u2 User) int {
+		result := 0
+		switch sortColumn {
+		case "account_user.created_at":
+			if u1.CreatedAt.Before(u2.CreatedAt) {
+				result = -1
+			} else if u1.CreatedAt.After(u2.CreatedAt) {
+				result = 1
+			}
+		case "account_user.email":
+			result = strings.Compare(u1.Email, u2.Email)
+		case "account_user.full_name":
+			result = strings.Compare(u1.FullName, u2.FullName)
+		}
+		if result == 0 && u1.ID != u2.ID {
+			result = 1
+			if u1.ID < u2.ID {
+				result = -1
+			}
+		}
+
+		if isDesc {
+			return -result
+		}
+		return result
+	}
+
+	// get position of the cursor as user
+	var cursorUser *User
+	if strings.TrimSpace(f.Cursor) != "" {
+		cursor, err := decodeUserListCursor(f.Cursor)
+		if err != nil || cursor.Sort != f.Sort {
+			return users, "", 400, nil
+		}
+
+		cursorUser = &User{ID: cursor.ID, Email: cursor.Value, FullName: cursor.Value}
+		if sortColumn == "account_user.created_at" {
+			cursorUser.CreatedAt, err = time.Parse(time.RFC3339Nano, cursor.Value)
+			if err != nil {
+				return users, "", 400, nil
+			}
+		}
+	}
+
+	// filter users, start after the cursor position
+	query := strings.ToLower(strings.TrimSpace(f.Query))
+	for _, user := range allUsers {
+		if (query != "" && !strings.HasPrefix(strings.ToLower(user.Email), query) &&
+			!strings.HasPrefix(strings.ToLower(user.FullName), query)) ||
+			(strings.TrimSpace(f.Role) != "" && user.Role != f.Role) ||
+			(strings.TrimSpace(f.Status) != "" && user.Status != f.Status) ||
+			(f.CreatedFrom != nil && user.CreatedAt.Before(f.CreatedFrom.UTC())) ||
+			(f.CreatedTo != nil && !user.CreatedAt.Before(f.CreatedTo.UTC())) ||
+			(cursorUser != nil && compare(user, *cursorUser) <= 0) {
+			continue
+		}
+
+		users = append(users, user)
+	}
+
+	sort.Slice(users, func(i, j int) bool {
+		return compare(users[i], users[j]) < 0
+	})
+	if len(users) > f.Limit+1 {
+		users = users[:f.Limit+1]
+	}
+
+	return getUserListPage(users, f, sortColumn)
+}
+
+// func for get a page of users with cursor of the next page
+// (empty if last page), users has one more user than the limit
+// if there's next page
+func getUserListPage(users []User, f UserListFilter, sortColumn string) (
+	[]User, string, int, error) {
+	// create cursor of the next page from the last user
+	if len(users) <= f.Limit {
+		return users, "", 200, nil
+	}
+	users = users[:f.Limit]
+
+	lastUser := users[len(users)-1]
+	cursor := userListCursor{Sort: f.Sort, ID: lastUser.ID}
+	switch sortColumn {
+	case "account_user.created_at":
+		cursor.Value = lastUser.CreatedAt.UTC().Format(time.RFC3339Nano)
+	case "account_user.email":
+		cursor.Value = lastUser.Email
+	case "account_user.full_name":
+		cursor.Value = lastUser.FullName
+	}
+
+	nextCursor, err := encodeUserListCursor(cursor)
+	if err != nil {
+		return users, "", 500, err
+	}
+
+	return users, nextCursor, 200, nil
+}
+
+// func for set role of a user, the role must be in role catalog
+//
+// Return status 400 if user not exist, or status 422 if role not exist
+func SetUserRole(DB *sql.DB, userID int, role string) (int, error) {
+	// check role exist
+	_, err := GetRole(DB, role)
+	if err == sql.ErrNoRows {
+		return 422, nil
+	} else if err != nil {
+		return 500, err
+	}
+
+	// update user role
+	res, err := DB.Exec(`
+		UPDATE account_user
+			SET role = $1, version = version + 1, updated_at = $2
+			WHERE id = $3
+		`, role, time.Now().UTC(), userID)
+	if err != nil {
+		return 500, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return 500, err
+	}
+	if affected == 0 {
+		return 400, nil
+	}
+
+	return 200, nil
+}
+
+// func for escape LIKE pattern special characters
+func escapeLikePattern(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// func for encode user list cursor to url safe string
+func encodeUserListCursor(cursor userListCursor) (string, error) {
+	b, err := json.Marshal(cursor)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// func for decode user list cursor from url safe string
+func decodeUserListCursor(s string) (userListCursor, error) {
+	cursor := userListCursor{}
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return cursor, err
+	}
+
+	err = json.Unmarshal(b, &cursor)
+	return cursor, err
+}
@@ -0,0 +1,433 @@
+/*
+Package model containing structs and functions for
+database transaction
+*/
+package model
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/reyhanfikridz/ecom-account-service/internal/utils"
+)
+
+// TestGetUsers integration test GetUsers with search,
+// filter, sort, and cursor pagination
+func TestGetUsers(t *testing.T) {
+	// get connection to testing DB
+	DB, err := GetTestDBConnection()
+	if err != nil {
+		t.Fatalf("Connection to testing DB failed => " + err.Error())
+	}
+
+	// delete prev data first
+	_, err = DB.Exec(`DELETE FROM account_user WHERE email LIKE 'testgetusers%'`)
+	if err != nil {
+		t.Errorf("There's an error when deleting previous user testing data => " +
+			err.Error())
+	}
+
+	// create 5 users, first 3 users are buyer, last user is suspended
+	for i := 1; i <= 5; i++ {
+		role := "buyer"
+		if i > 3 {
+			role = "seller"
+		}
+
+		user, err := CreateUser(DB, testConfig, User{
+			Email:       "testgetusers" + strconv.Itoa(i) + "@gmail.com",
+			Password:    "testgetusers",
+			FullName:    "Get Users " + strconv.Itoa(i),
+			Address:     "address",
+			PhoneNumber: "08111111111",
+			Role:        role,
+		})
+		if err != nil {
+			t.Errorf("There's an error when creating user => " + err.Error())
+		}
+
+		if i == 5 {
+			status, err := SetUserStatus(DB, user.ID, UserStatusSuspended)
+			if status != 200 || err != nil {
+				t.Errorf("Expected set user status 200, but got %d", status)
+			}
+		}
+	}
+
+	// create testing table
+	yesterday := time.Now().UTC().Add(-24 * time.Hour)
+	testTable := []struct {
+		Filter         UserListFilter
+		ExpectedStatus int
+		ExpectedEmails []string
+	}{
+		{
+			Filter: UserListFilter{Query: "TESTGETUSERS", Sort: "email"},
+			ExpectedEmails: []string{
+				"testgetusers1@gmail.com",
+				"testgetusers2@gmail.com",
+				"testgetusers3@gmail.com",
+				"testgetusers4@gmail.com",
+				"testgetusers5@gmail.com",
+			},
+			ExpectedStatus: 200,
+		},
+		{
+			Filter:         UserListFilter{Query: "get users 4"},
+			ExpectedEmails: []string{"testgetusers4@gmail.com"},
+			ExpectedStatus: 200,
+		},
+		{
+			Filter:         UserListFilter{Query: "testgetusers_"},
+			ExpectedEmails: []string{},
+			ExpectedStatus: 200,
+		},
+		{
+			Filter: UserListFilter{Query: "testgetusers", Role: "seller", Sort: "-email"},
+			ExpectedEmails: []string{
+				"testgetusers5@gmail.com",
+				"testgetusers4@gmail.com",
+			},
+			ExpectedStatus: 200,
+		},
+		{
+			Filter: UserListFilter{
+				Query:  "testgetusers",
+				Status: UserStatusSuspended,
+			},
+			ExpectedEmails: []string{"testgetusers5@gmail.com"},
+			ExpectedStatus: 200,
+		},
+		{
+			Filter: UserListFilter{
+				Query:     "testgetusers",
+				CreatedTo: &yesterday,
+			},
+			ExpectedEmails: []string{},
+			ExpectedStatus: 200,
+		},
+		{
+			Filter:         UserListFilter{Sort: "password"},
+			ExpectedEmails: []string{},
+			ExpectedStatus: 400,
+		},
+		{
+			Filter:         UserListFilter{Cursor: "notvalid"},
+			ExpectedEmails: []string{},
+			ExpectedStatus: 400,
+		},
+	}
+
+	// loop test in test table
+	for _, test := range testTable {
+		users, _, status, err := GetUsers(DB, test.Filter)
+		if err != nil {
+			t.Errorf("There's an error when getting users => " + err.Error())
+		}
+		if status != test.ExpectedStatus {
+			t.Errorf("Expected status %d, but got %d", test.ExpectedStatus, status)
+		}
+
+		if len(users) != len(test.ExpectedEmails) {
+			t.Errorf("Expected %d users, but got %d", len(test.ExpectedEmails), len(users))
+			continue
+		}
+		for i, user := range users {
+			if user.Email != test.ExpectedEmails[i] {
+				t.Errorf("Expected user email '" + test.ExpectedEmails[i] +
+					"', but got '" + user.Email + "'")
+			}
+		}
+	}
+
+	// get all pages with cursor pagination
+	emails := []string{}
+	filter := UserListFilter{Query: "testgetusers", Sort: "email", Limit: 2}
+	for page := 1; page <= 5; page++ {
+		users, nextCursor, status, err := GetUsers(DB, filter)
+		if status != 200 || err != nil {
+			t.Errorf("Expected get users page %d status 200, but got %d", page, status)
+			break
+		}
+
+		for _, user := range users {
+			emails = append(emails, user.Email)
+		}
+		if nextCursor == "" {
+			break
+		}
+		filter.Cursor = nextCursor
+	}
+
+	if len(emails) != 5 {
+		t.Errorf("Expected 5 users from all pages, but got %d", len(emails))
+	}
+	for i, email := range emails {
+		expectedEmail := "testgetusers" + strconv.Itoa(i+1) + "@gmail.com"
+		if email != expectedEmail {
+			t.Errorf("Expected user email '" + expectedEmail + "', but got '" + email + "'")
+		}
+	}
+
+	// cursor can't be used with other sort
+	filter.Sort = "-email"
+	_, _, status, _ := GetUsers(DB, filter)
+	if status != 400 {
+		t.Errorf("Expected get users with other sort cursor status 400, but got %d", status)
+	}
+}
+
+// TestSetUserStatusAndSetUserRole integration test
+// SetUserStatus and SetUserRole
+func TestSetUserStatusAndSetUserRole(t *testing.T) {
+	// get connection to testing DB
+	DB, err := GetTestDBConnection()
+	if err != nil {
+		t.Fatalf("Connection to testing DB failed => " + err.Error())
+	}
+
+	// delete prev data first
+	_, err = DB.Exec(`DELETE FROM account_user WHERE email = $1`, "testsetuser@gmail.com")
+	if err != nil {
+		t.Errorf("There's an error when deleting previous user testing data => " +
+			err.Error())
+	}
+
+	// create user data with its session
+	user, _ := CreateUser(DB, testConfig, User{
+		Email:       "testsetuser@gmail.com",
+		Password:    "testsetuser",
+		FullName:    "testsetuser",
+		Address:     "address",
+		PhoneNumber: "08111111111",
+		Role:        "buyer",
+	})
+	_, err = DB.Exec(`UPDATE account_user SET email_verified_at = $1 WHERE id = $2`,
+		time.Now().UTC(), user.ID)
+	if err != nil {
+		t.Errorf("There's an error when verifying user email => " + err.Error())
+	}
+
+	token, err := utils.GenerateJWT(testConfig.JWTKeys, user.ID, user.Role,
+		testConfig.AccessTokenDuration)
+	if err != nil {
+		t.Errorf("There's an error when generating jwt token => " + err.Error())
+	}
+
+	_, err = CreateUserSession(DB, UserSession{Token: token, User: user})
+	if err != nil {
+		t.Errorf("There's an error when creating user session => " + err.Error())
+	}
+
+	//////////////////// SET USER STATUS ////////////////////
+	// create testing table
+	statusTestTable := []struct {
+		UserID                     int
+		Status                     string
+		ExpectedStatus             int
+		ExpectedAuthenticateStatus int
+	}{
+		{
+			UserID:         0,
+			Status:         UserStatusSuspended,
+			ExpectedStatus: 400,
+		},
+		{
+			UserID:                     user.ID,
+			Status:                     UserStatusSuspended,
+			ExpectedStatus:             200,
+			ExpectedAuthenticateStatus: 403,
+		},
+		{
+			UserID:                     user.ID,
+			Status:                     UserStatusActive,
+			ExpectedStatus:             200,
+			ExpectedAuthenticateStatus: 200,
+		},
+		{
+			UserID:         user.ID,
+			Status:         UserStatusActive,
+			ExpectedStatus: 409,
+		},
+	}
+
+	for _, test := range statusTestTable {
+		status, err := SetUserStatus(DB, test.UserID, test.Status)
+		if err != nil {
+			t.Errorf("There's an error when setting user status => " + err.Error())
+		}
+		if status != test.ExpectedStatus {
+			t.Errorf("Expected status %d, but got %d", test.ExpectedStatus, status)
+		}
+
+		// all user sessions revoked after suspended
+		if test.ExpectedStatus == 200 && test.Status == UserStatusSuspended {
+			userSessions, err := GetUserSessions(DB, user.ID)
+			if err != nil {
+				t.Errorf("There's an error when getting user sessions => " + err.Error())
+			}
+			if len(userSessions) != 0 {
+				t.Errorf("Expected all user sessions revoked, but got %d sessions",
+					len(userSessions))
+			}
+		}
+
+		if test.ExpectedAuthenticateStatus != 0 {
+			_, _, status, _, _ = AuthenticateUser(DB, testConfig, User{
+				Email:    "testsetuser@gmail.com",
+				Password: "testsetuser",
+			}, "test-agent", "127.0.0.1")
+			if status != test.ExpectedAuthenticateStatus {
+				t.Errorf("Expected authenticate user status %d, but got %d",
+					test.ExpectedAuthenticateStatus, status)
+			}
+		}
+	}
+
+	//////////////////// SET USER ROLE ////////////////////
+	// create testing table
+	roleTestTable := []struct {
+		UserID         int
+		Role           string
+		ExpectedStatus int
+	}{
+		{
+			UserID:         user.ID,
+			Role:           "notexist",
+			ExpectedStatus: 422,
+		},
+		{
+			UserID:         0,
+			Role:           "seller",
+			ExpectedStatus: 400,
+		},
+		{
+			UserID:         user.ID,
+			Role:           "seller",
+			ExpectedStatus: 200,
+		},
+	}
+
+	for _, test := range roleTestTable {
+		status, err := SetUserRole(DB, test.UserID, test.Role)
+		if err != nil {
+			t.Errorf("There's an error when setting user role => " + err.Error())
+		}
+		if status != test.ExpectedStatus {
+			t.Errorf("Expected status %d, but got %d", test.ExpectedStatus, status)
+		}
+	}
+
+	changedUser, err := GetUser(DB, "", user.ID)
+	if err != nil {
+		t.Errorf("There's an error when getting user => " + err.Error())
+	}
+	if changedUser.Role != "seller" {
+		t.Errorf("Expected user role 'seller', but got '" + changedUser.Role + "'")
+	}
+}
+
+// TestFilterUsers test FilterUsers with search, filter, sort,
+// and cursor pagination of users in memory
+func TestFilterUsers(t *testing.T) {
+	now := time.Now().UTC()
+	allUsers := []User{
+		{ID: 1, Email: "b@gmail.com", FullName: "Budi", Role: "buyer",
+			Status: UserStatusActive, CreatedAt: now.Add(-3 * time.Hour)},
+		{ID: 2, Email: "a@gmail.com", FullName: "Citra", Role: "seller",
+			Status: UserStatusActive, CreatedAt: now.Add(-2 * time.Hour)},
+		{ID: 3, Email: "c@gmail.com", FullName: "Bambang", Role: "buyer",
+			Status: UserStatusSuspended, CreatedAt: now.Add(-time.Hour)},
+		{ID: 4, Email: "d@gmail.com", FullName: "Dewi", Role: "buyer",
+			Status: UserStatusActive, CreatedAt: now.Add(-time.Hour)},
+	}
+	createdFrom := now.Add(-2 * time.Hour)
+
+	// create testing table
+	testTable := []struct {
+		Filter          UserListFilter
+		ExpectedUserIDs []int
+		ExpectedStatus  int
+	}{
+		{
+			Filter:          UserListFilter{},
+			ExpectedUserIDs: []int{4, 3, 2, 1},
+			ExpectedStatus:  200,
+		},
+		{
+			Filter:          UserListFilter{Query: "B", Sort: "email"},
+			ExpectedUserIDs: []int{1, 3},
+			ExpectedStatus:  200,
+		},
+		{
+			Filter:          UserListFilter{Role: "buyer", Status: UserStatusActive, Sort: "-full_name"},
+			ExpectedUserIDs: []int{4, 1},
+			ExpectedStatus:  200,
+		},
+		{
+			Filter:          UserListFilter{CreatedFrom: &createdFrom, Sort: "created_at"},
+			ExpectedUserIDs: []int{2, 3, 4},
+			ExpectedStatus:  200,
+		},
+		{
+			Filter:         UserListFilter{Sort: "password"},
+			ExpectedStatus: 400,
+		},
+		{
+			Filter:         UserListFilter{Cursor: "notvalid"},
+			ExpectedStatus: 400,
+		},
+	}
+
+	// loop test in test table
+	for _, test := range testTable {
+		users, _, status, err := FilterUsers(allUsers, test.Filter)
+		if status != test.ExpectedStatus || err != nil {
+			t.Errorf("Expected status %d and error nil of filter %+v, but got status %d error %v",
+				test.ExpectedStatus, test.Filter, status, err)
+			continue
+		}
+
+		userIDs := []int{}
+		for _, user := range users {
+			userIDs = append(userIDs, user.ID)
+		}
+		if len(userIDs) != len(test.ExpectedUserIDs) {
+			t.Errorf("Expected users %v of filter %+v, but got %v",
+				test.ExpectedUserIDs, test.Filter, userIDs)
+			continue
+		}
+		for i := range userIDs {
+			if userIDs[i] != test.ExpectedUserIDs[i] {
+				t.Errorf("Expected users %v of filter %+v, but got %v",
+					test.ExpectedUserIDs, test.Filter, userIDs)
+				break
+			}
+		}
+	}
+
+	// cursor pagination through all users with the same created time
+	f := UserListFilter{Sort: "-created_at", Limit: 1}
+	userIDs := []int{}
+	for i := 0; i < len(allUsers); i++ {
+		users, nextCursor, status, err := FilterUsers(allUsers, f)
+		if status != 200 || err != nil || len(users) != 1 {
+			t.Fatalf("Expected status 200 with 1 user and error nil,"+
+				" but got %d users status %d error %v", len(users), status, err)
+		}
+		userIDs = append(userIDs, users[0].ID)
+
+		if (i == len(allUsers)-1) != (nextCursor == "") {
+			t.Errorf("Expected next cursor only before the last page, but got %q on page %d",
+				nextCursor, i+1)
+		}
+		f.Cursor = nextCursor
+	}
+	for i, expectedUserID := range []int{4, 3, 2, 1} {
+		if userIDs[i] != expectedUserID {
+			t.Errorf("Expected users [4 3 2 1], but got %v", userIDs)
+			break
+		}
+	}
+}
@@ -0,0 +1,176 @@
+/*
+Package model containing structs and functions for
+database transaction
+*/
+package model
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/reyhanfikridz/ecom-account-service/internal/config"
+)
+
+// allowed current statuses of a user to be set to each status,
+// deleted user can't be reactivated or suspended
+var userStatusTransitions = map[string][]string{
+	UserStatusActive: {UserStatusSuspended},
+	UserStatusSuspended: {UserStatusActive, UserStatusPendingVerification,
+		UserStatusSuspended},
+	UserStatusDeleted: {UserStatusActive, UserStatusPendingVerification,
+		UserStatusSuspended, UserStatusDeleted},
+}
+
+// func for get user status that effective by suspension expiry,
+// suspended user with expired suspension is active
+func EffectiveStatus(u User) string {
+	if u.Status == UserStatusSuspended && u.SuspendedUntil != nil &&
+		!time.Now().UTC().Before(*u.SuspendedUntil) {
+		return UserStatusActive
+	}
+
+	return u.Status
+}
+
+// func for check user with the status can use its account or not,
+// only suspended and deleted user can't
+func IsUserStatusUsable(status string) bool {
+	return status == UserStatusActive || status == UserStatusPendingVerification
+}
+
+// func for check status of a user can be set to a status from its current status,
+// return error if the status not valid
+func CanSetUserStatus(currentStatus string, status string) (bool, error) {
+	fromStatuses, ok := userStatusTransitions[status]
+	if !ok {
+		return false, fmt.Errorf("user status %q not valid", status)
+	}
+
+	for _, fromStatus := range fromStatuses {
+		if fromStatus == currentStatus {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// func for set status of a user, suspension reason and expiry are cleared,
+// all sessions of the user are revoked if the user can't use its account anymore
+//
+// Deleted time set when user status set to deleted, the email still registered
+// until deleted user retention passed. Only suspended user can be set to active.
+// Return status 400 if user not exist, or status 409 if the current status
+// of the user can't be changed to the status
+func SetUserStatus(DB *sql.DB, userID int, status string) (int, error) {
+	return updateUserStatus(DB, userID, status, nil, nil)
+}
+
+// func for suspend a user with its reason, suspension is permanent
+// if until is nil, all sessions of the user are revoked
+//
+// Return status 400 if user not exist, or status 409 if user already deleted
+func SuspendUser(DB *sql.DB, userID int, reason string, until *time.Time) (int, error) {
+	return updateUserStatus(DB, userID, UserStatusSuspended, &reason, until)
+}
+
+// func for update status of a user with its suspension reason and expiry
+func updateUserStatus(DB *sql.DB, userID int, status string,
+	suspendedReason *string, suspendedUntil *time.Time) (int, error) {
+	fromStatuses, ok := userStatusTransitions[status]
+	if !ok {
+		return 500, fmt.Errorf("user status %q not valid", status)
+	}
+
+	now := time.Now().UTC()
+	var deletedAt *time.Time
+	if status == UserStatusDeleted {
+		deletedAt = &now
+	}
+
+	//////////////////// begin transaction /////////////////////
+	tx, err := DB.Begin()
+	if err != nil {
+		return 500, err
+	}
+	defer tx.Rollback() // rollback transaction if fail (return before commit)
+
+	// update user status only if its current status allowed,
+	// keep the first deleted time if already deleted
+	args := []any{status, suspendedReason, suspendedUntil, deletedAt, now, userID}
+	placeholders := []string{}
+	for _, fromStatus := range fromStatuses {
+		args = append(args, fromStatus)
+		placeholders = append(placeholders, "$"+strconv.Itoa(len(args)))
+	}
+	res, err := tx.Exec(`
+		UPDATE account_user
+			SET status = $1, suspended_reason = $2, suspended_until = $3,
+				deleted_at = CASE WHEN $4::TIMESTAMP IS NULL THEN NULL
+					ELSE COALESCE(deleted_at, $4) END,
+				version = version + 1, updated_at = $5
+			WHERE id = $6 AND status IN (`+strings.Join(placeholders, ", ")+`)
+		`, args...)
+	if err != nil {
+		return 500, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return 500, err
+	}
+
+	// check why user not updated, not exist or its status not allowed
+	if affected == 0 {
+		var count int
+		err = tx.QueryRow(`SELECT COUNT(*) FROM account_user WHERE id = $1`,
+			userID).Scan(&count)
+		if err != nil {
+			return 500, err
+		}
+		if count == 0 {
+			return 400, nil
+		}
+
+		return 409, nil
+	}
+
+	// revoke all user sessions
+	if !IsUserStatusUsable(status) {
+		_, err = tx.Exec(`
+			DELETE FROM account_usersession
+				WHERE account_user_id = $1
+			`, userID)
+		if err != nil {
+			return 500, err
+		}
+	}
+
+	// commit transaction
+	err = tx.Commit()
+	if err != nil {
+		return 500, err
+	}
+	////////////////////////////////////////////////////////////
+
+	return 200, nil
+}
+
+// func for release email of deleted user after deleted user retention passed,
+// so the email can be registered again
+//
+// The email of deleted user replaced by placeholder email based on user ID
+func releaseDeletedUserEmail(DB *sql.DB, c config.Config, email string) error {
+	now := time.Now().UTC()
+	_, err := DB.Exec(`
+		UPDATE account_user
+			SET email = 'deleted-' || id || '@deleted.invalid',
+				version = version + 1, updated_at = $1
+			WHERE email = $2 AND status = $3 AND deleted_at <= $4
+		`, now, email, UserStatusDeleted, now.Add(-c.DeletedUserRetention))
+
+	return err
+}
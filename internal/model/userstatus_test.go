@@ -0,0 +1,272 @@
+/*
+Package model containing structs and functions for
+database transaction
+*/
+package model
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+// TestEffectiveStatus test EffectiveStatus with suspension expiry
+func TestEffectiveStatus(t *testing.T) {
+	past := time.Now().UTC().Add(-time.Hour)
+	future := time.Now().UTC().Add(time.Hour)
+
+	// create testing table
+	testTable := []struct {
+		User           User
+		ExpectedStatus string
+	}{
+		{
+			User:           User{Status: UserStatusActive},
+			ExpectedStatus: UserStatusActive,
+		},
+		{
+			User:           User{Status: UserStatusPendingVerification},
+			ExpectedStatus: UserStatusPendingVerification,
+		},
+		{
+			User:           User{Status: UserStatusSuspended},
+			ExpectedStatus: UserStatusSuspended,
+		},
+		{
+			User:           User{Status: UserStatusSuspended, SuspendedUntil: &future},
+			ExpectedStatus: UserStatusSuspended,
+		},
+		{
+			User:           User{Status: UserStatusSuspended, SuspendedUntil: &past},
+			ExpectedStatus: UserStatusActive,
+		},
+		{
+			User:           User{Status: UserStatusDeleted},
+			ExpectedStatus: UserStatusDeleted,
+		},
+	}
+
+	// loop test in test table
+	for _, test := range testTable {
+		status := EffectiveStatus(test.User)
+		if status != test.ExpectedStatus {
+			t.Errorf("Expected status '" + test.ExpectedStatus + "', but got '" + status + "'")
+		}
+	}
+}
+
+// TestSuspendUserAndSoftDeleteUser integration test SuspendUser
+// and SetUserStatus deleted with AuthenticateUser and CreateUser
+func TestSuspendUserAndSoftDeleteUser(t *testing.T) {
+	// get connection to testing DB
+	DB, err := GetTestDBConnection()
+	if err != nil {
+		t.Fatalf("Connection to testing DB failed => " + err.Error())
+	}
+
+	// delete prev data first
+	_, err = DB.Exec(`DELETE FROM account_user WHERE email = $1`, "testuserstatus@gmail.com")
+	if err != nil {
+		t.Errorf("There's an error when deleting previous user testing data => " +
+			err.Error())
+	}
+
+	// create verified user data
+	user, err := CreateUser(DB, testConfig, User{
+		Email:       "testuserstatus@gmail.com",
+		Password:    "testuserstatus",
+		FullName:    "testuserstatus",
+		Address:     "address",
+		PhoneNumber: "08111111111",
+		Role:        "buyer",
+	})
+	if err != nil {
+		t.Errorf("There's an error when creating user => " + err.Error())
+	}
+	if user.Status != UserStatusPendingVerification {
+		t.Errorf("Expected new user status '" + UserStatusPendingVerification +
+			"', but got '" + user.Status + "'")
+	}
+
+	status, err := VerifyUserEmail(DB, user.ID, user.Email)
+	if status != 200 || err != nil {
+		t.Errorf("Expected verify user email status 200, but got %d", status)
+	}
+
+	//////////////////// SUSPEND USER ////////////////////
+	// create testing table
+	past := time.Now().UTC().Add(-time.Hour)
+	future := time.Now().UTC().Add(time.Hour)
+	suspendTestTable := []struct {
+		Until                      *time.Time
+		IsExpired                  bool
+		ExpectedAuthenticateStatus int
+		ExpectedUserStatus         string
+	}{
+		{
+			Until:                      nil,
+			ExpectedAuthenticateStatus: 403,
+			ExpectedUserStatus:         UserStatusSuspended,
+		},
+		{
+			Until:                      &future,
+			ExpectedAuthenticateStatus: 403,
+			ExpectedUserStatus:         UserStatusSuspended,
+		},
+		{
+			Until:                      &past,
+			IsExpired:                  true,
+			ExpectedAuthenticateStatus: 200,
+			ExpectedUserStatus:         UserStatusActive,
+		},
+	}
+
+	for _, test := range suspendTestTable {
+		status, err := SuspendUser(DB, user.ID, "fraud", test.Until)
+		if status != 200 || err != nil {
+			t.Errorf("Expected suspend user status 200, but got %d", status)
+		}
+
+		_, _, status, _, err = AuthenticateUser(DB, testConfig, User{
+			Email:    "testuserstatus@gmail.com",
+			Password: "testuserstatus",
+		}, "test-agent", "127.0.0.1")
+		if err != nil {
+			t.Errorf("There's an error when authenticating user => " + err.Error())
+		}
+		if status != test.ExpectedAuthenticateStatus {
+			t.Errorf("Expected authenticate user status %d, but got %d",
+				test.ExpectedAuthenticateStatus, status)
+		}
+
+		// expired suspension lifted after login
+		suspendedUser, err := GetUser(DB, "", user.ID)
+		if err != nil {
+			t.Errorf("There's an error when getting user => " + err.Error())
+		}
+		if suspendedUser.Status != test.ExpectedUserStatus {
+			t.Errorf("Expected user status '" + test.ExpectedUserStatus +
+				"', but got '" + suspendedUser.Status + "'")
+		}
+		if !test.IsExpired && (suspendedUser.SuspendedReason == nil ||
+			*suspendedUser.SuspendedReason != "fraud") {
+			t.Errorf("Expected user suspended reason 'fraud', but got empty")
+		}
+	}
+
+	//////////////////// SOFT DELETE USER ////////////////////
+	status, err = SetUserStatus(DB, user.ID, UserStatusDeleted)
+	if status != 200 || err != nil {
+		t.Errorf("Expected set user status deleted 200, but got %d", status)
+	}
+
+	// deleted user can't be reactivated or suspended
+	status, err = SetUserStatus(DB, user.ID, UserStatusActive)
+	if status != 409 || err != nil {
+		t.Errorf("Expected reactivate deleted user status 409, but got %d", status)
+	}
+
+	status, err = SuspendUser(DB, user.ID, "fraud", nil)
+	if status != 409 || err != nil {
+		t.Errorf("Expected suspend deleted user status 409, but got %d", status)
+	}
+
+	// deleted user treated as not exist
+	_, _, status, _, err = AuthenticateUser(DB, testConfig, User{
+		Email:    "testuserstatus@gmail.com",
+		Password: "testuserstatus",
+	}, "test-agent", "127.0.0.1")
+	if status != 400 || err != nil {
+		t.Errorf("Expected authenticate deleted user status 400, but got %d", status)
+	}
+
+	// email not released within deleted user retention
+	newUser := User{
+		Email:       "testuserstatus@gmail.com",
+		Password:    "testuserstatus",
+		FullName:    "testuserstatus",
+		Address:     "address",
+		PhoneNumber: "08111111111",
+		Role:        "buyer",
+	}
+	_, err = CreateUser(DB, testConfig, newUser)
+	if err == nil {
+		t.Errorf("Expected create user with email of deleted user failed, but got success")
+	}
+
+	// email released after deleted user retention passed
+	_, err = DB.Exec(`UPDATE account_user SET deleted_at = $1 WHERE id = $2`,
+		time.Now().UTC().Add(-testConfig.DeletedUserRetention-time.Hour), user.ID)
+	if err != nil {
+		t.Errorf("There's an error when updating deleted time => " + err.Error())
+	}
+
+	_, err = CreateUser(DB, testConfig, newUser)
+	if err != nil {
+		t.Errorf("Expected create user with released email success, but got error => " +
+			err.Error())
+	}
+
+	deletedUser, err := GetUser(DB, "", user.ID)
+	if err != nil {
+		t.Errorf("There's an error when getting user => " + err.Error())
+	}
+	expectedEmail := "deleted-" + strconv.Itoa(user.ID) + "@deleted.invalid"
+	if deletedUser.Email != expectedEmail {
+		t.Errorf("Expected deleted user email '" + expectedEmail +
+			"', but got '" + deletedUser.Email + "'")
+	}
+}
+
+// TestCanSetUserStatus test CanSetUserStatus by user status transitions
+func TestCanSetUserStatus(t *testing.T) {
+	// create testing table
+	testTable := []struct {
+		CurrentStatus  string
+		Status         string
+		ExpectedResult bool
+		IsErrExpected  bool
+	}{
+		{
+			CurrentStatus:  UserStatusActive,
+			Status:         UserStatusSuspended,
+			ExpectedResult: true,
+		},
+		{
+			CurrentStatus:  UserStatusSuspended,
+			Status:         UserStatusActive,
+			ExpectedResult: true,
+		},
+		{
+			CurrentStatus:  UserStatusActive,
+			Status:         UserStatusActive,
+			ExpectedResult: false,
+		},
+		{
+			CurrentStatus:  UserStatusDeleted,
+			Status:         UserStatusActive,
+			ExpectedResult: false,
+		},
+		{
+			CurrentStatus:  UserStatusActive,
+			Status:         UserStatusDeleted,
+			ExpectedResult: true,
+		},
+		{
+			CurrentStatus:  UserStatusActive,
+			Status:         UserStatusPendingVerification,
+			ExpectedResult: false,
+			IsErrExpected:  true,
+		},
+	}
+
+	// loop test in test table
+	for _, test := range testTable {
+		result, err := CanSetUserStatus(test.CurrentStatus, test.Status)
+		if result != test.ExpectedResult || (err != nil) != test.IsErrExpected {
+			t.Errorf("Expected result %t and error %t from %q to %q, but got %t error %v",
+				test.ExpectedResult, test.IsErrExpected, test.CurrentStatus, test.Status,
+				result, err)
+		}
+	}
+}
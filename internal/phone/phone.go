@@ -0,0 +1,123 @@
+/*
+Package phone parse and normalize phone number to E.164 format
+*/
+package phone
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrInvalidNumber phone number can't be parsed or not valid for its region
+var ErrInvalidNumber = errors.New("phone number not valid")
+
+// ErrUnknownRegion national phone number given with region not supported
+var ErrUnknownRegion = errors.New("phone number region not supported")
+
+// regionRule numbering rule of a region
+type regionRule struct {
+	CallingCode string
+
+	// prefix dialed before national number inside the region,
+	// not part of E.164 number
+	TrunkPrefix string
+
+	// length of national significant number (without trunk prefix)
+	MinLength int
+	MaxLength int
+}
+
+// numbering rules of supported regions (ISO 3166-1 alpha-2),
+// international number of other region only checked by E.164 length
+var regionRules = map[string]regionRule{
+	"AU": {CallingCode: "61", TrunkPrefix: "0", MinLength: 9, MaxLength: 9},
+	"CA": {CallingCode: "1", TrunkPrefix: "1", MinLength: 10, MaxLength: 10},
+	"DE": {CallingCode: "49", TrunkPrefix: "0", MinLength: 6, MaxLength: 13},
+	"FR": {CallingCode: "33", TrunkPrefix: "0", MinLength: 9, MaxLength: 9},
+	"GB": {CallingCode: "44", TrunkPrefix: "0", MinLength: 9, MaxLength: 10},
+	"ID": {CallingCode: "62", TrunkPrefix: "0", MinLength: 8, MaxLength: 12},
+	"IN": {CallingCode: "91", TrunkPrefix: "0", MinLength: 10, MaxLength: 10},
+	"JP": {CallingCode: "81", TrunkPrefix: "0", MinLength: 9, MaxLength: 10},
+	"MY": {CallingCode: "60", TrunkPrefix: "0", MinLength: 8, MaxLength: 10},
+	"NL": {CallingCode: "31", TrunkPrefix: "0", MinLength: 9, MaxLength: 9},
+	"SG": {CallingCode: "65", TrunkPrefix: "", MinLength: 8, MaxLength: 8},
+	"US": {CallingCode: "1", TrunkPrefix: "1", MinLength: 10, MaxLength: 10},
+}
+
+// Normalize parse phone number and normalize it to E.164 format
+// (e.g. "0811-1111-1111" in region "ID" become "+6281111111111")
+//
+// Number starting with "+" or "00" parsed as international number,
+// otherwise parsed as national number of the region.
+// Space, dash, dot, and parentheses are ignored.
+func Normalize(number string, region string) (string, error) {
+	// remove formatting characters
+	digits := strings.Map(func(r rune) rune {
+		if strings.ContainsRune(" -.()", r) {
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(number))
+
+	isInternational := false
+	if strings.HasPrefix(digits, "+") {
+		digits = digits[1:]
+		isInternational = true
+	} else if strings.HasPrefix(digits, "00") {
+		digits = digits[2:]
+		isInternational = true
+	}
+
+	if digits == "" || strings.Trim(digits, "0123456789") != "" {
+		return "", ErrInvalidNumber
+	}
+
+	// get rule of the number region
+	var rule regionRule
+	var isRuleFound bool
+	if isInternational {
+		rule, isRuleFound = getCallingCodeRule(digits)
+		if !isRuleFound {
+			// E.164 number maximum 15 digits and calling code never start with 0
+			if len(digits) < 8 || len(digits) > 15 || digits[0] == '0' {
+				return "", ErrInvalidNumber
+			}
+			return "+" + digits, nil
+		}
+		digits = digits[len(rule.CallingCode):]
+	} else {
+		rule, isRuleFound = regionRules[strings.ToUpper(region)]
+		if !isRuleFound {
+			return "", ErrUnknownRegion
+		}
+	}
+
+	// national number may contain trunk prefix,
+	// even when written as international number (e.g. "+62 0811..."),
+	// national significant number never start with trunk prefix
+	if rule.TrunkPrefix != "" && strings.HasPrefix(digits, rule.TrunkPrefix) {
+		digits = digits[len(rule.TrunkPrefix):]
+	}
+
+	if len(digits) < rule.MinLength || len(digits) > rule.MaxLength || digits[0] == '0' {
+		return "", ErrInvalidNumber
+	}
+
+	return "+" + rule.CallingCode + digits, nil
+}
+
+// getCallingCodeRule get rule of region with the longest calling code
+// matching the international number
+func getCallingCodeRule(digits string) (regionRule, bool) {
+	var rule regionRule
+	isRuleFound := false
+	for _, r := range regionRules {
+		if strings.HasPrefix(digits, r.CallingCode) &&
+			len(r.CallingCode) > len(rule.CallingCode) {
+			rule = r
+			isRuleFound = true
+		}
+	}
+
+	return rule, isRuleFound
+}
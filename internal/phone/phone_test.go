@@ -0,0 +1,102 @@
+/*
+Package phone parse and normalize phone number to E.164 format
+*/
+package phone
+
+import (
+	"testing"
+)
+
+// TestNormalize test Normalize
+func TestNormalize(t *testing.T) {
+	// initialize testing table
+	testTable := []struct {
+		Number         string
+		Region         string
+		ExpectedNumber string
+		ExpectedErr    error
+	}{
+		{
+			Number:         "0811-1111-1111",
+			Region:         "ID",
+			ExpectedNumber: "+6281111111111",
+		},
+		{
+			Number:         "+62 811 1111 1111",
+			Region:         "US",
+			ExpectedNumber: "+6281111111111",
+		},
+		{
+			Number:         "+62 (0)811-1111-1111",
+			Region:         "",
+			ExpectedNumber: "+6281111111111",
+		},
+		{
+			Number:         "0062 811 1111 1111",
+			Region:         "ID",
+			ExpectedNumber: "+6281111111111",
+		},
+		{
+			Number:         "(415) 555-2671",
+			Region:         "us",
+			ExpectedNumber: "+14155552671",
+		},
+		{
+			Number:         "1-415-555-2671",
+			Region:         "US",
+			ExpectedNumber: "+14155552671",
+		},
+		{
+			Number:         "020 7946 0958",
+			Region:         "GB",
+			ExpectedNumber: "+442079460958",
+		},
+		{
+			Number:         "+66 81 234 5678", // region without rule
+			Region:         "ID",
+			ExpectedNumber: "+66812345678",
+		},
+		{
+			Number:      "",
+			Region:      "ID",
+			ExpectedErr: ErrInvalidNumber,
+		},
+		{
+			Number:      "0811-abc",
+			Region:      "ID",
+			ExpectedErr: ErrInvalidNumber,
+		},
+		{
+			Number:      "0811",
+			Region:      "ID",
+			ExpectedErr: ErrInvalidNumber,
+		},
+		{
+			Number:      "+1 415 555 26711",
+			Region:      "US",
+			ExpectedErr: ErrInvalidNumber,
+		},
+		{
+			Number:      "+66 81 234 5678 1234 56",
+			Region:      "ID",
+			ExpectedErr: ErrInvalidNumber,
+		},
+		{
+			Number:      "081234567890",
+			Region:      "TH",
+			ExpectedErr: ErrUnknownRegion,
+		},
+	}
+
+	// loop test in test table
+	for _, test := range testTable {
+		number, err := Normalize(test.Number, test.Region)
+		if err != test.ExpectedErr {
+			t.Errorf("Expected error %v, but got %v (%s)", test.ExpectedErr, err, test.Number)
+		}
+
+		if number != test.ExpectedNumber {
+			t.Errorf("Expected number '%s', but got '%s'", test.ExpectedNumber, number)
+		}
+	}
+}
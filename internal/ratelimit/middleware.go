@@ -0,0 +1,118 @@
+/*
+Package ratelimit containing token bucket rate limiter
+with pluggable storage and its http middleware
+*/
+package ratelimit
+
+import (
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// Rule limit of a route, requests counted per key
+// (e.g. per IP address, per email, or per token subject)
+type Rule struct {
+	// name of the key, used as part of bucket key
+	Name string
+
+	// get key of a request, rule skipped if the key empty
+	Key func(r *http.Request) string
+
+	Limit Limit
+}
+
+// Limiter router level rate limiting middleware,
+// rules selected by the name of matched route
+type Limiter struct {
+	Store Store
+
+	// rules of each route name, DefaultRules used
+	// if the route name doesn't have its own rules
+	Rules        map[string][]Rule
+	DefaultRules []Rule
+
+	// handler for request over the limit, RateLimit-* and
+	// Retry-After headers already set before it called
+	LimitedHandler http.HandlerFunc
+}
+
+// Middleware rate limiting middleware for mux router
+func (l *Limiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// get rules of matched route
+		routeName := ""
+		if route := mux.CurrentRoute(r); route != nil {
+			routeName = route.GetName()
+		}
+
+		rules, ok := l.Rules[routeName]
+		if !ok {
+			rules = l.DefaultRules
+		}
+
+		// take token of all rules, the most limited result is used
+		var limitedResult *Result
+		now := time.Now()
+		for _, rule := range rules {
+			key := rule.Key(r)
+			if key == "" {
+				continue
+			}
+
+			result, err := l.Store.Take(routeName+":"+rule.Name+":"+key, rule.Limit, now)
+			if err != nil { // if store not available, don't block the request
+				log.Println("Error When Taking Rate Limit Token -> ", err)
+				continue
+			}
+
+			if limitedResult == nil || isMoreLimited(result, *limitedResult) {
+				limitedResult = &result
+			}
+		}
+
+		if limitedResult == nil { // if there's no rule applied
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// set rate limit headers
+		w.Header().Set("RateLimit-Limit", strconv.Itoa(limitedResult.Limit))
+		w.Header().Set("RateLimit-Remaining", strconv.Itoa(limitedResult.Remaining))
+		w.Header().Set("RateLimit-Reset", durationToSeconds(limitedResult.ResetAfter))
+
+		if limitedResult.Allowed {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("Retry-After", durationToSeconds(limitedResult.RetryAfter))
+		if l.LimitedHandler != nil {
+			l.LimitedHandler(w, r)
+		} else {
+			http.Error(w, http.StatusText(http.StatusTooManyRequests),
+				http.StatusTooManyRequests)
+		}
+	})
+}
+
+// isMoreLimited check result more limited than another result
+func isMoreLimited(result Result, another Result) bool {
+	if result.Allowed != another.Allowed {
+		return !result.Allowed
+	}
+	if !result.Allowed {
+		return result.RetryAfter > another.RetryAfter
+	}
+
+	return result.Remaining < another.Remaining
+}
+
+// durationToSeconds convert duration into seconds string (rounded up)
+func durationToSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
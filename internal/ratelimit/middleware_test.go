@@ -0,0 +1,109 @@
+/*
+Package ratelimit containing token bucket rate limiter
+with pluggable storage and its http middleware
+*/
+package ratelimit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// TestLimiterMiddleware test Limiter Middleware
+func TestLimiterMiddleware(t *testing.T) {
+	// initialize router with limited and default route
+	perHeader := Rule{
+		Name:  "client",
+		Key:   func(r *http.Request) string { return r.Header.Get("X-Client") },
+		Limit: Limit{Requests: 1, Period: time.Hour},
+	}
+
+	router := mux.NewRouter()
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(200)
+	}
+	router.HandleFunc("/limited/", handler).Name("limited")
+	router.HandleFunc("/default/", handler).Name("default")
+
+	limiter := &Limiter{
+		Store: NewMemoryStore(),
+		Rules: map[string][]Rule{
+			"limited": {perHeader},
+		},
+		DefaultRules: []Rule{{
+			Name:  "client",
+			Key:   perHeader.Key,
+			Limit: Limit{Requests: 2, Period: time.Hour},
+		}},
+	}
+	router.Use(limiter.Middleware)
+
+	// initialize testing table
+	testTable := []struct {
+		URL               string
+		Client            string
+		ExpectedStatus    int
+		ExpectedRemaining string
+	}{
+		{
+			URL:               "/limited/",
+			Client:            "a",
+			ExpectedStatus:    200,
+			ExpectedRemaining: "0",
+		},
+		{
+			URL:               "/limited/",
+			Client:            "a",
+			ExpectedStatus:    429,
+			ExpectedRemaining: "0",
+		},
+		{
+			URL:               "/limited/",
+			Client:            "b",
+			ExpectedStatus:    200,
+			ExpectedRemaining: "0",
+		},
+		{
+			URL:               "/limited/",
+			Client:            "",
+			ExpectedStatus:    200,
+			ExpectedRemaining: "",
+		},
+		{
+			URL:               "/default/",
+			Client:            "a",
+			ExpectedStatus:    200,
+			ExpectedRemaining: "1",
+		},
+	}
+
+	// loop test in test table
+	for _, test := range testTable {
+		req, err := http.NewRequest("GET", test.URL, nil)
+		if err != nil {
+			t.Errorf("There's an error when creating request => " + err.Error())
+		}
+		req.Header.Set("X-Client", test.Client)
+
+		response := httptest.NewRecorder()
+		router.ServeHTTP(response, req)
+
+		if response.Code != test.ExpectedStatus {
+			t.Errorf("Expected status %d got %d", test.ExpectedStatus, response.Code)
+		}
+
+		if response.Header().Get("RateLimit-Remaining") != test.ExpectedRemaining {
+			t.Errorf("Expected remaining '%s' got '%s'", test.ExpectedRemaining,
+				response.Header().Get("RateLimit-Remaining"))
+		}
+
+		if response.Code == 429 && response.Header().Get("Retry-After") != "3600" {
+			t.Errorf("Expected retry after '3600' got '%s'",
+				response.Header().Get("Retry-After"))
+		}
+	}
+}
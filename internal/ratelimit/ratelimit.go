@@ -0,0 +1,124 @@
+/*
+Package ratelimit containing token bucket rate limiter
+with pluggable storage and its http middleware
+*/
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// Limit maximum number of requests in a period,
+// the bucket refilled continuously (Requests / Period per second)
+// and can hold up to Requests tokens (burst)
+type Limit struct {
+	Requests int
+	Period   time.Duration
+}
+
+// Result result of taking a token from a bucket
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	RetryAfter time.Duration // time until next token available (if not allowed)
+	ResetAfter time.Duration // time until bucket full again
+}
+
+// Store storage of token buckets, shared backend (e.g. redis)
+// can implement it so all service replicas use the same buckets
+type Store interface {
+	// Take take one token from bucket of the key
+	Take(key string, limit Limit, now time.Time) (Result, error)
+}
+
+// Take calculate result of taking one token from a bucket
+// with tokens at updatedAt, return the result and remaining tokens
+//
+// Used by Store implementation, so all stores have the same algorithm
+func Take(tokens float64, updatedAt time.Time, limit Limit, now time.Time) (
+	Result, float64) {
+	burst := float64(limit.Requests)
+	rate := burst / limit.Period.Seconds() // tokens per second
+
+	// refill bucket since last update
+	if now.After(updatedAt) {
+		tokens = math.Min(burst, tokens+now.Sub(updatedAt).Seconds()*rate)
+	}
+
+	result := Result{
+		Limit: limit.Requests,
+	}
+	if tokens >= 1 {
+		tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = secondsToDuration((1 - tokens) / rate)
+	}
+	result.Remaining = int(math.Floor(tokens))
+	result.ResetAfter = secondsToDuration((burst - tokens) / rate)
+
+	return result, tokens
+}
+
+// secondsToDuration convert float seconds into duration
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// bucket token bucket kept in MemoryStore
+type bucket struct {
+	tokens    float64
+	updatedAt time.Time
+	fullAt    time.Time
+}
+
+// MemoryStore in-process Store, only limit requests
+// to one service replica
+type MemoryStore struct {
+	mu            sync.Mutex
+	buckets       map[string]*bucket
+	lastCleanupAt time.Time
+}
+
+// NewMemoryStore create new in-process Store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets: map[string]*bucket{},
+	}
+}
+
+// Take take one token from bucket of the key
+func (s *MemoryStore) Take(key string, limit Limit, now time.Time) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// remove full buckets once a minute, so buckets not grow forever
+	if now.Sub(s.lastCleanupAt) > time.Minute {
+		for bucketKey, b := range s.buckets {
+			if !now.Before(b.fullAt) {
+				delete(s.buckets, bucketKey)
+			}
+		}
+		s.lastCleanupAt = now
+	}
+
+	// new bucket start full
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{
+			tokens:    float64(limit.Requests),
+			updatedAt: now,
+		}
+		s.buckets[key] = b
+	}
+
+	result, tokens := Take(b.tokens, b.updatedAt, limit, now)
+	b.tokens = tokens
+	b.updatedAt = now
+	b.fullAt = now.Add(result.ResetAfter)
+
+	return result, nil
+}
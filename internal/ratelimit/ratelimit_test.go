@@ -0,0 +1,91 @@
+/*
+Package ratelimit containing token bucket rate limiter
+with pluggable storage and its http middleware
+*/
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+// TestMemoryStoreTake test MemoryStore Take
+func TestMemoryStoreTake(t *testing.T) {
+	store := NewMemoryStore()
+	limit := Limit{Requests: 2, Period: 10 * time.Second} // 1 token per 5 seconds
+	now := time.Date(2022, 10, 1, 0, 0, 0, 0, time.UTC)
+
+	// initialize testing table
+	testTable := []struct {
+		Key                string
+		Time               time.Time
+		ExpectedAllowed    bool
+		ExpectedRemaining  int
+		ExpectedRetryAfter time.Duration
+	}{
+		{
+			Key:               "a",
+			Time:              now,
+			ExpectedAllowed:   true,
+			ExpectedRemaining: 1,
+		},
+		{
+			Key:               "a",
+			Time:              now,
+			ExpectedAllowed:   true,
+			ExpectedRemaining: 0,
+		},
+		{
+			Key:                "a",
+			Time:               now.Add(time.Second),
+			ExpectedAllowed:    false,
+			ExpectedRemaining:  0,
+			ExpectedRetryAfter: 4 * time.Second,
+		},
+		{
+			Key:               "b",
+			Time:              now.Add(time.Second),
+			ExpectedAllowed:   true,
+			ExpectedRemaining: 1,
+		},
+		{
+			Key:               "a",
+			Time:              now.Add(5 * time.Second),
+			ExpectedAllowed:   true,
+			ExpectedRemaining: 0,
+		},
+		{
+			Key:               "a",
+			Time:              now.Add(time.Hour),
+			ExpectedAllowed:   true,
+			ExpectedRemaining: 1,
+		},
+	}
+
+	// loop test in test table
+	for _, test := range testTable {
+		result, err := store.Take(test.Key, limit, test.Time)
+		if err != nil {
+			t.Errorf("Expected error nil, but got not nil => " + err.Error())
+		}
+
+		if result.Allowed != test.ExpectedAllowed {
+			t.Errorf("Expected allowed %t, but got %t", test.ExpectedAllowed, result.Allowed)
+		}
+
+		if result.Remaining != test.ExpectedRemaining {
+			t.Errorf("Expected remaining %d, but got %d",
+				test.ExpectedRemaining, result.Remaining)
+		}
+
+		if result.RetryAfter.Round(time.Millisecond) != test.ExpectedRetryAfter {
+			t.Errorf("Expected retry after %s, but got %s",
+				test.ExpectedRetryAfter, result.RetryAfter)
+		}
+	}
+
+	// full buckets removed by cleanup
+	if len(store.buckets) != 1 {
+		t.Errorf("Expected 1 bucket left after cleanup, but got %d", len(store.buckets))
+	}
+}
@@ -0,0 +1,47 @@
+/*
+Package sms collection of SMS sender
+*/
+package sms
+
+import "sync"
+
+// Message SMS message sent by memory sender
+type Message struct {
+	To      string
+	Message string
+}
+
+// MemorySender SMS sender that keep all messages in memory
+// instead of sending it, used for testing
+type MemorySender struct {
+	mu       sync.Mutex
+	Messages []Message
+}
+
+// Send save SMS message in memory
+func (s *MemorySender) Send(to string, message string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.Messages = append(s.Messages, Message{
+		To:      to,
+		Message: message,
+	})
+
+	return nil
+}
+
+// LastMessage get last SMS message sent to a phone number,
+// return false if there's no message sent to the phone number
+func (s *MemorySender) LastMessage(to string) (Message, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := len(s.Messages) - 1; i >= 0; i-- {
+		if s.Messages[i].To == to {
+			return s.Messages[i], true
+		}
+	}
+
+	return Message{}, false
+}
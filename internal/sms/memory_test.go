@@ -0,0 +1,37 @@
+/*
+Package sms collection of SMS sender
+*/
+package sms
+
+import (
+	"testing"
+)
+
+// TestMemorySenderSendAndLastMessage integration test
+// MemorySender Send and LastMessage
+func TestMemorySenderSendAndLastMessage(t *testing.T) {
+	s := &MemorySender{}
+
+	// send messages
+	for _, message := range []string{"first", "second"} {
+		err := s.Send("+6281111111111", message)
+		if err != nil {
+			t.Errorf("Expected error nil, but got not nil => " + err.Error())
+		}
+	}
+
+	// check result
+	message, ok := s.LastMessage("+6281111111111")
+	if !ok {
+		t.Errorf("Expected message exist, but not exist")
+	}
+
+	if message.Message != "second" {
+		t.Errorf("Expected last message 'second', but got '%s'", message.Message)
+	}
+
+	_, ok = s.LastMessage("+6282222222222")
+	if ok {
+		t.Errorf("Expected message not exist, but exist")
+	}
+}
@@ -0,0 +1,23 @@
+/*
+Package sms collection of SMS sender
+*/
+package sms
+
+import (
+	"log"
+)
+
+// Sender SMS sender
+type Sender interface {
+	Send(to string, message string) error
+}
+
+// LogSender SMS sender that only write the message to log,
+// only for development because the message (e.g. verification code) logged
+type LogSender struct{}
+
+// Send write SMS message to log
+func (s LogSender) Send(to string, message string) error {
+	log.Println("SMS to " + to + " => " + message)
+	return nil
+}
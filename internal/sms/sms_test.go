@@ -0,0 +1,29 @@
+/*
+Package sms collection of SMS sender
+*/
+package sms
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"strings"
+	"testing"
+)
+
+// TestLogSenderSend test LogSender Send
+func TestLogSenderSend(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	err := LogSender{}.Send("+6281111111111", "test message")
+	if err != nil {
+		t.Errorf("Expected error nil, but got not nil => " + err.Error())
+	}
+
+	if !strings.Contains(buf.String(), "+6281111111111") ||
+		!strings.Contains(buf.String(), "test message") {
+		t.Errorf("Expected SMS message logged, but got '%s'", buf.String())
+	}
+}
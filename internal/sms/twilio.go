@@ -0,0 +1,63 @@
+/*
+Package sms collection of SMS sender
+*/
+package sms
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// TwilioSender SMS sender through Twilio messaging API
+type TwilioSender struct {
+	AccountSID string
+	AuthToken  string
+	From       string
+
+	// API base URL, default to "https://api.twilio.com" if empty
+	BaseURL string
+	Client  *http.Client
+}
+
+// Send send SMS message through Twilio, return error
+// if Twilio not respond with success status
+func (s TwilioSender) Send(to string, message string) error {
+	baseURL := s.BaseURL
+	if baseURL == "" {
+		baseURL = "https://api.twilio.com"
+	}
+
+	client := s.Client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	form := url.Values{}
+	form.Set("To", to)
+	form.Set("From", s.From)
+	form.Set("Body", message)
+
+	req, err := http.NewRequest("POST", strings.TrimRight(baseURL, "/")+
+		"/2010-04-01/Accounts/"+url.PathEscape(s.AccountSID)+"/Messages.json",
+		strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(s.AccountSID, s.AuthToken)
+
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Errorf("twilio respond with status %d", res.StatusCode)
+	}
+
+	return nil
+}
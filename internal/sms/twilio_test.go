@@ -0,0 +1,78 @@
+/*
+Package sms collection of SMS sender
+*/
+package sms
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestTwilioSenderSend test TwilioSender Send
+// with Twilio that respond success and failed
+func TestTwilioSenderSend(t *testing.T) {
+	// create Twilio server
+	received := []Message{}
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/2010-04-01/Accounts/AC123/Messages.json" {
+				t.Errorf("Expected path of account AC123 messages, but got '%s'", r.URL.Path)
+			}
+
+			username, password, ok := r.BasicAuth()
+			if !ok || username != "AC123" || password != "token" {
+				w.WriteHeader(401)
+				return
+			}
+
+			if r.FormValue("From") != "+15005550006" {
+				t.Errorf("Expected from '+15005550006', but got '%s'", r.FormValue("From"))
+			}
+			received = append(received, Message{
+				To:      r.FormValue("To"),
+				Message: r.FormValue("Body"),
+			})
+			w.WriteHeader(201)
+		}))
+	defer server.Close()
+
+	// create testing table
+	testTable := []struct {
+		AuthToken     string
+		ExpectedError bool
+	}{
+		{
+			AuthToken:     "token",
+			ExpectedError: false,
+		},
+		{
+			AuthToken:     "wrongtoken",
+			ExpectedError: true,
+		},
+	}
+
+	// loop test in test table
+	for _, test := range testTable {
+		s := TwilioSender{
+			AccountSID: "AC123",
+			AuthToken:  test.AuthToken,
+			From:       "+15005550006",
+			BaseURL:    server.URL,
+		}
+		err := s.Send("+6281111111111", "test message")
+		if test.ExpectedError && err == nil {
+			t.Errorf("Expected error not nil, but got nil")
+		} else if !test.ExpectedError && err != nil {
+			t.Errorf("Expected error nil, but got not nil => " + err.Error())
+		}
+	}
+
+	// check result
+	if len(received) != 1 {
+		t.Fatalf("Expected 1 message received, but got %d", len(received))
+	}
+	if received[0].To != "+6281111111111" || received[0].Message != "test message" {
+		t.Errorf("Expected received message same as sent message, but not same")
+	}
+}
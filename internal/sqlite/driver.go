@@ -0,0 +1,218 @@
+/*
+Package sqlite SQLite database for single node and development deployment,
+queries written for Postgres translated so model package used as is
+*/
+package sqlite
+
+import (
+	"context"
+	"database/sql/driver"
+	"strings"
+	"time"
+)
+
+// time formats of SQLite timestamp saved as text,
+// by the driver and by CURRENT_TIMESTAMP
+var timeFormats = []string{
+	"2006-01-02 15:04:05.999999999-07:00",
+	"2006-01-02 15:04:05",
+}
+
+// pgDriver driver that translate every query before
+// passed to the base SQLite driver
+type pgDriver struct {
+	base driver.Driver
+}
+
+// Open open connection of the base driver
+func (d *pgDriver) Open(name string) (driver.Conn, error) {
+	c, err := d.base.Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &conn{Conn: c}, nil
+}
+
+// conn connection of the base driver with translated queries
+type conn struct {
+	driver.Conn
+}
+
+// Prepare prepare translated query
+func (c *conn) Prepare(query string) (driver.Stmt, error) {
+	s, err := c.Conn.Prepare(Rewrite(query))
+	if err != nil {
+		return nil, err
+	}
+
+	return &stmt{Stmt: s}, nil
+}
+
+// PrepareContext prepare translated query with context
+func (c *conn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	preparer, ok := c.Conn.(driver.ConnPrepareContext)
+	if !ok {
+		return c.Prepare(query)
+	}
+
+	s, err := preparer.PrepareContext(ctx, Rewrite(query))
+	if err != nil {
+		return nil, err
+	}
+
+	return &stmt{Stmt: s}, nil
+}
+
+// BeginTx begin transaction with context
+func (c *conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	beginner, ok := c.Conn.(driver.ConnBeginTx)
+	if !ok {
+		return c.Conn.Begin()
+	}
+
+	return beginner.BeginTx(ctx, opts)
+}
+
+// ExecContext execute translated query,
+// skipped (prepared instead) if the base driver can't execute directly
+func (c *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (
+	driver.Result, error) {
+	execer, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+
+	return execer.ExecContext(ctx, Rewrite(query), args)
+}
+
+// QueryContext query translated query,
+// skipped (prepared instead) if the base driver can't query directly
+func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (
+	driver.Rows, error) {
+	queryer, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+
+	r, err := queryer.QueryContext(ctx, Rewrite(query), args)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rows{Rows: r}, nil
+}
+
+// CheckNamedValue check argument by the base driver if supported
+func (c *conn) CheckNamedValue(nv *driver.NamedValue) error {
+	checker, ok := c.Conn.(driver.NamedValueChecker)
+	if !ok {
+		return driver.ErrSkip
+	}
+
+	return checker.CheckNamedValue(nv)
+}
+
+// ResetSession reset session of the base connection if supported
+func (c *conn) ResetSession(ctx context.Context) error {
+	resetter, ok := c.Conn.(driver.SessionResetter)
+	if !ok {
+		return nil
+	}
+
+	return resetter.ResetSession(ctx)
+}
+
+// stmt statement of the base driver returning rows with parsed time
+type stmt struct {
+	driver.Stmt
+}
+
+// Query query statement
+func (s *stmt) Query(args []driver.Value) (driver.Rows, error) {
+	r, err := s.Stmt.Query(args)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rows{Rows: r}, nil
+}
+
+// QueryContext query statement with context
+func (s *stmt) QueryContext(ctx context.Context, args []driver.NamedValue) (
+	driver.Rows, error) {
+	queryer, ok := s.Stmt.(driver.StmtQueryContext)
+	if !ok {
+		values := make([]driver.Value, len(args))
+		for i, arg := range args {
+			values[i] = arg.Value
+		}
+		return s.Query(values)
+	}
+
+	r, err := queryer.QueryContext(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rows{Rows: r}, nil
+}
+
+// ExecContext execute statement with context
+func (s *stmt) ExecContext(ctx context.Context, args []driver.NamedValue) (
+	driver.Result, error) {
+	execer, ok := s.Stmt.(driver.StmtExecContext)
+	if !ok {
+		values := make([]driver.Value, len(args))
+		for i, arg := range args {
+			values[i] = arg.Value
+		}
+		return s.Exec(values)
+	}
+
+	return execer.ExecContext(ctx, args)
+}
+
+// rows rows of the base driver, timestamp of column without declared type
+// (e.g. from RETURNING) parsed as time like column declared as TIMESTAMP
+//
+// Only column named as timestamp column ("*_at" or "*_until") parsed,
+// so text that look like timestamp (e.g. full name) kept as text
+type rows struct {
+	driver.Rows
+}
+
+// Next get next row, parse timestamp text of timestamp column
+// without declared type
+func (r *rows) Next(dest []driver.Value) error {
+	err := r.Rows.Next(dest)
+	if err != nil {
+		return err
+	}
+
+	columns := r.Rows.Columns()
+	typer, ok := r.Rows.(driver.RowsColumnTypeDatabaseTypeName)
+	for i, value := range dest {
+		text, isText := value.(string)
+		if !isText || !isTimeColumn(columns[i]) ||
+			(ok && typer.ColumnTypeDatabaseTypeName(i) != "") {
+			continue
+		}
+
+		for _, format := range timeFormats {
+			t, err := time.Parse(format, text)
+			if err == nil {
+				dest[i] = t
+				break
+			}
+		}
+	}
+
+	return nil
+}
+
+// isTimeColumn check column named as timestamp column or not
+func isTimeColumn(name string) bool {
+	name = strings.ToLower(name)
+	return strings.HasSuffix(name, "_at") || strings.HasSuffix(name, "_until")
+}
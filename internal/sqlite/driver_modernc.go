@@ -0,0 +1,20 @@
+/*
+Package sqlite SQLite database for single node and development deployment,
+queries written for Postgres translated so model package used as is
+*/
+package sqlite
+
+import (
+	moderncsqlite "modernc.org/sqlite"
+)
+
+// SQLite driver always built, it's pure Go so DB_DRIVER=sqlite
+// works on every binary without cgo
+//
+// Timestamps saved in "sqlite" format (UTC text) so they can be compared,
+// and transaction lock the database when begin as replacement of row lock
+func init() {
+	register(&moderncsqlite.Driver{}, "_pragma=foreign_keys(1)"+
+		"&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)"+
+		"&_time_format=sqlite&_txlock=immediate")
+}
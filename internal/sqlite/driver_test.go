@@ -0,0 +1,184 @@
+/*
+Package sqlite SQLite database for single node and development deployment,
+queries written for Postgres translated so model package used as is
+*/
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"testing"
+	"time"
+)
+
+// fakeDriver base driver recording queries, every query return one row
+type fakeDriver struct {
+	queries []string
+	columns []string
+	types   []string
+	row     []driver.Value
+}
+
+func (d *fakeDriver) Open(name string) (driver.Conn, error) {
+	return &fakeConn{d: d}, nil
+}
+
+type fakeConn struct {
+	d *fakeDriver
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	c.d.queries = append(c.d.queries, query)
+	return &fakeStmt{d: c.d}, nil
+}
+
+func (c *fakeConn) Close() error {
+	return nil
+}
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	return c, nil
+}
+
+func (c *fakeConn) Commit() error {
+	return nil
+}
+
+func (c *fakeConn) Rollback() error {
+	return nil
+}
+
+func (c *fakeConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (
+	driver.Rows, error) {
+	c.d.queries = append(c.d.queries, query)
+	return &fakeRows{d: c.d}, nil
+}
+
+type fakeStmt struct {
+	d *fakeDriver
+}
+
+func (s *fakeStmt) Close() error {
+	return nil
+}
+
+func (s *fakeStmt) NumInput() int {
+	return -1
+}
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return driver.RowsAffected(1), nil
+}
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return &fakeRows{d: s.d}, nil
+}
+
+type fakeRows struct {
+	d      *fakeDriver
+	isRead bool
+}
+
+func (r *fakeRows) Columns() []string {
+	return r.d.columns
+}
+
+func (r *fakeRows) Close() error {
+	return nil
+}
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.isRead {
+		return io.EOF
+	}
+	r.isRead = true
+	copy(dest, r.d.row)
+	return nil
+}
+
+func (r *fakeRows) ColumnTypeDatabaseTypeName(index int) string {
+	return r.d.types[index]
+}
+
+// TestDriver test translating driver of the base driver
+func TestDriver(t *testing.T) {
+	d := &fakeDriver{
+		columns: []string{"id", "created_at", "full_name", "updated_at"},
+		types:   []string{"INTEGER", "", "", "TIMESTAMP"},
+		row: []driver.Value{
+			int64(1), "2022-01-02 03:04:05.5+00:00", "2022-01-02 03:04:05",
+			"2022-01-02 03:04:05",
+		},
+	}
+	DB := sql.OpenDB(&connector{d: &pgDriver{base: d}})
+	defer DB.Close()
+
+	// query directly
+	var ID int
+	var createdAt time.Time
+	var fullName, updatedAt string
+	err := DB.QueryRow(`SELECT $1::VARCHAR`, "test").
+		Scan(&ID, &createdAt, &fullName, &updatedAt)
+	if err != nil {
+		t.Fatalf("Expected error nil, but got not nil => " + err.Error())
+	}
+
+	expectedCreatedAt := time.Date(2022, 1, 2, 3, 4, 5, 500000000, time.UTC)
+	if !createdAt.Equal(expectedCreatedAt) {
+		t.Errorf("Expected created_at %s, but got %s", expectedCreatedAt, createdAt)
+	}
+	// text that look like timestamp of not timestamp column kept as text
+	if fullName != "2022-01-02 03:04:05" {
+		t.Errorf("Expected full_name %q, but got %q", "2022-01-02 03:04:05", fullName)
+	}
+	// column with declared type left to the base driver
+	if updatedAt != "2022-01-02 03:04:05" {
+		t.Errorf("Expected updated_at %q, but got %q", "2022-01-02 03:04:05", updatedAt)
+	}
+
+	// query in transaction by prepared statement
+	tx, err := DB.Begin()
+	if err != nil {
+		t.Fatalf("Expected error nil, but got not nil => " + err.Error())
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`SELECT id FROM account_user WHERE id = $1 FOR UPDATE`)
+	if err != nil {
+		t.Fatalf("Expected error nil, but got not nil => " + err.Error())
+	}
+	defer stmt.Close()
+
+	_, err = stmt.Exec(1)
+	if err != nil {
+		t.Errorf("Expected error nil, but got not nil => " + err.Error())
+	}
+
+	expectedQueries := []string{
+		`SELECT ?1`,
+		`SELECT id FROM account_user WHERE id = ?1`,
+	}
+	if len(d.queries) != len(expectedQueries) {
+		t.Fatalf("Expected queries %q, but got %q", expectedQueries, d.queries)
+	}
+	for i, query := range d.queries {
+		if query != expectedQueries[i] {
+			t.Errorf("Expected query %q, but got %q", expectedQueries[i], query)
+		}
+	}
+}
+
+// connector connector of a driver instance, so test driver not registered
+type connector struct {
+	d driver.Driver
+}
+
+func (c *connector) Connect(ctx context.Context) (driver.Conn, error) {
+	return c.d.Open("")
+}
+
+func (c *connector) Driver() driver.Driver {
+	return c.d
+}
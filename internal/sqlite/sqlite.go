@@ -0,0 +1,52 @@
+/*
+Package sqlite SQLite database for single node and development deployment,
+queries written for Postgres translated so model package used as is
+*/
+package sqlite
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"regexp"
+)
+
+// DriverName name of the translating SQLite driver registered in database/sql
+const DriverName = "sqlite-postgres"
+
+// DSN parameters of the base SQLite driver
+var baseDSNParams string
+
+// Postgres syntax used by model package that SQLite doesn't support
+var (
+	// "$1" placeholder, SQLite "$1" is a named parameter,
+	// so the same number can't be used in any order
+	placeholderRegexp = regexp.MustCompile(`\$([0-9]+)`)
+
+	// type cast (e.g. "$1::VARCHAR"), SQLite columns are dynamically typed
+	castRegexp = regexp.MustCompile(`::[A-Za-z]+(\([0-9, ]*\))?`)
+
+	// row lock, SQLite transaction lock the whole database when it begins
+	rowLockRegexp = regexp.MustCompile(`(?i)\s+FOR\s+UPDATE(\s+SKIP\s+LOCKED)?`)
+)
+
+// register register translating driver of the base SQLite driver
+func register(base driver.Driver, DSNParams string) {
+	baseDSNParams = DSNParams
+	sql.Register(DriverName, &pgDriver{base: base})
+}
+
+// Open open SQLite database file, created if not exist
+func Open(path string) (*sql.DB, error) {
+	return sql.Open(DriverName, "file:"+path+"?"+baseDSNParams)
+}
+
+// Rewrite translate query written for Postgres to SQLite
+//
+// Only Postgres syntax used by model package translated,
+// RETURNING and ON CONFLICT already supported by SQLite
+func Rewrite(query string) string {
+	query = placeholderRegexp.ReplaceAllString(query, "?$1")
+	query = castRegexp.ReplaceAllString(query, "")
+	query = rowLockRegexp.ReplaceAllString(query, "")
+	return query
+}
@@ -0,0 +1,84 @@
+/*
+Package sqlite SQLite database for single node and development deployment,
+queries written for Postgres translated so model package used as is
+*/
+package sqlite
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// TestRewrite test translate Postgres query to SQLite
+func TestRewrite(t *testing.T) {
+	testCases := []struct {
+		Query         string
+		ExpectedQuery string
+	}{
+		{
+			Query:         `SELECT id FROM account_user WHERE email = $1`,
+			ExpectedQuery: `SELECT id FROM account_user WHERE email = ?1`,
+		},
+		{
+			Query:         `UPDATE account_user SET full_name = $2 WHERE id = $10`,
+			ExpectedQuery: `UPDATE account_user SET full_name = ?2 WHERE id = ?10`,
+		},
+		{
+			Query:         `SELECT CASE WHEN $2::VARCHAR IS NULL THEN $4::TIMESTAMP END`,
+			ExpectedQuery: `SELECT CASE WHEN ?2 IS NULL THEN ?4 END`,
+		},
+		{
+			Query:         `SELECT $1::VARCHAR(50)`,
+			ExpectedQuery: `SELECT ?1`,
+		},
+		{
+			Query:         "SELECT id FROM account_user WHERE id = $1\n\t\tFOR UPDATE\n\t",
+			ExpectedQuery: "SELECT id FROM account_user WHERE id = ?1\n\t",
+		},
+		{
+			Query:         `SELECT id FROM account_dataexport LIMIT 1 FOR UPDATE SKIP LOCKED)`,
+			ExpectedQuery: `SELECT id FROM account_dataexport LIMIT 1)`,
+		},
+		{
+			Query:         `SELECT 'deleted-' || id FROM account_user`,
+			ExpectedQuery: `SELECT 'deleted-' || id FROM account_user`,
+		},
+	}
+
+	for _, testCase := range testCases {
+		query := Rewrite(testCase.Query)
+		if query != testCase.ExpectedQuery {
+			t.Errorf("Expected query %q, but got %q", testCase.ExpectedQuery, query)
+		}
+	}
+}
+
+// TestOpen test open SQLite database and run query written for Postgres
+func TestOpen(t *testing.T) {
+	DB, err := Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("There's an error when opening database => " + err.Error())
+	}
+	defer DB.Close()
+
+	_, err = DB.Exec(`CREATE TABLE account_user (id INTEGER PRIMARY KEY, email TEXT)`)
+	if err != nil {
+		t.Fatalf("There's an error when creating table => " + err.Error())
+	}
+
+	_, err = DB.Exec(`INSERT INTO account_user (id, email) VALUES ($2, $1::VARCHAR)`,
+		"test@gmail.com", 1)
+	if err != nil {
+		t.Errorf("There's an error when inserting user => " + err.Error())
+	}
+
+	var email string
+	err = DB.QueryRow(`SELECT email FROM account_user WHERE id = $1 FOR UPDATE`, 1).
+		Scan(&email)
+	if err != nil {
+		t.Errorf("There's an error when getting user => " + err.Error())
+	}
+	if email != "test@gmail.com" {
+		t.Errorf("Expected email %q, but got %q", "test@gmail.com", email)
+	}
+}
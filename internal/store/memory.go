@@ -0,0 +1,1991 @@
+/*
+Package store containing storage interfaces of the models,
+with SQL database and in-memory implementation
+*/
+package store
+
+import (
+	"crypto/subtle"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/reyhanfikridz/ecom-account-service/internal/config"
+	"github.com/reyhanfikridz/ecom-account-service/internal/dataexport"
+	"github.com/reyhanfikridz/ecom-account-service/internal/event"
+	"github.com/reyhanfikridz/ecom-account-service/internal/model"
+	"github.com/reyhanfikridz/ecom-account-service/internal/phone"
+	"github.com/reyhanfikridz/ecom-account-service/internal/utils"
+)
+
+// errUserNotExist data created for user not exist,
+// the same as foreign key violation in database
+var errUserNotExist = errors.New("user of the data not exist")
+
+// default roles of memory store, the same as roles seeded by migration
+var defaultRoles = []model.Role{
+	{
+		Name:              "buyer",
+		IsSelfRegistrable: true,
+		Permissions:       []string{"order:create", "order:read", "product:read"},
+	},
+	{
+		Name:              "seller",
+		IsSelfRegistrable: true,
+		Permissions:       []string{"order:read", "product:read", "product:write"},
+	},
+	{
+		Name: "admin",
+		Permissions: []string{
+			model.PermissionPromoteJWTKey, model.PermissionClearLoginLockout,
+			"order:read", "product:read", "product:write",
+			model.PermissionReadUser, model.PermissionWriteUser,
+		},
+	},
+}
+
+// memoryRefreshToken refresh token kept by memory store by its hash
+type memoryRefreshToken struct {
+	userSessionID int
+	isUsed        bool
+	expiredAt     time.Time
+}
+
+// memoryTOTP totp secret of a user with its last used time step
+type memoryTOTP struct {
+	secret   string
+	lastStep int64
+}
+
+// memoryPasswordReset password reset token kept by memory store by its hash
+type memoryPasswordReset struct {
+	ID        int
+	userID    int
+	expiredAt time.Time
+	isUsed    bool
+}
+
+// memoryEmailChange email change with its token hashes
+type memoryEmailChange struct {
+	ID              int
+	userID          int
+	oldEmail        string
+	newEmail        string
+	tokenHash       string
+	expiredAt       time.Time
+	confirmedAt     *time.Time
+	revertTokenHash string
+	revertExpiredAt *time.Time
+	isReverted      bool
+}
+
+// memoryPhoneVerification phone verification code of a user by its hash
+type memoryPhoneVerification struct {
+	phoneNumber string
+	codeHash    string
+	attempts    int
+	createdAt   time.Time
+	expiredAt   time.Time
+}
+
+// memoryDataExport data export with its archive
+type memoryDataExport struct {
+	dataExport model.DataExport
+	archive    []byte
+}
+
+// MemoryStore store that keep all data in memory, used for testing
+// and running the handlers using store without database
+//
+// Roles are the default roles.
+type MemoryStore struct {
+	Config config.Config
+
+	mu                      sync.Mutex
+	users                   map[int]model.User
+	sessions                map[int]model.UserSession
+	refreshTokens           map[string]memoryRefreshToken
+	roles                   map[string]model.Role
+	addresses               map[int]model.Address
+	emailVerificationSentAt map[int]time.Time
+	totps                   map[int]memoryTOTP
+	recoveryCodes           map[int]map[string]bool // code hash to used or not
+	passwordResets          map[string]memoryPasswordReset
+	passwordHistory         map[int][]string // hashed passwords newest first
+	emailChanges            map[int]memoryEmailChange
+	phoneVerifications      map[int]memoryPhoneVerification
+	loginFailures           map[string]model.LoginFailure
+	loginHistory            map[int][]model.LoginHistory // oldest first
+	dataExports             map[int]memoryDataExport
+	events                  []model.Event
+	lastUserID              int
+	lastSessionID           int
+	lastAddressID           int
+	lastPasswordResetID     int
+	lastEmailChangeID       int
+	lastLoginFailureID      int
+	lastLoginHistoryID      int
+	lastDataExportID        int
+}
+
+// NewMemoryStore create empty memory store of a config with the default roles
+func NewMemoryStore(c config.Config) *MemoryStore {
+	s := &MemoryStore{
+		Config:                  c,
+		users:                   map[int]model.User{},
+		sessions:                map[int]model.UserSession{},
+		refreshTokens:           map[string]memoryRefreshToken{},
+		roles:                   map[string]model.Role{},
+		addresses:               map[int]model.Address{},
+		emailVerificationSentAt: map[int]time.Time{},
+		totps:                   map[int]memoryTOTP{},
+		recoveryCodes:           map[int]map[string]bool{},
+		passwordResets:          map[string]memoryPasswordReset{},
+		passwordHistory:         map[int][]string{},
+		emailChanges:            map[int]memoryEmailChange{},
+		phoneVerifications:      map[int]memoryPhoneVerification{},
+		loginFailures:           map[string]model.LoginFailure{},
+		loginHistory:            map[int][]model.LoginHistory{},
+		dataExports:             map[int]memoryDataExport{},
+	}
+	for i, role := range defaultRoles {
+		role.ID = i + 1
+		role.Permissions = append([]string{}, role.Permissions...)
+		sort.Strings(role.Permissions)
+		s.roles[role.Name] = role
+	}
+
+	return s
+}
+
+// CreateUser create exactly one new user
+func (s *MemoryStore) CreateUser(u model.User) (model.User, error) {
+	hashedPassword, err := utils.HashPassword(u.Password)
+	if err != nil {
+		return u, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// release the email if used by deleted user after retention passed
+	s.releaseDeletedUserEmail(u.Email)
+	if s.isEmailRegistered(u.Email, 0) {
+		return u, ErrDuplicateEmail
+	}
+
+	s.lastUserID++
+	u.ID = s.lastUserID
+	u.Status = model.UserStatusPendingVerification
+	u.CreatedAt = time.Now().UTC()
+	u.Version = 1
+	u.UpdatedAt = u.CreatedAt
+
+	// address only kept by the address book
+	user := u
+	user.Password = hashedPassword
+	user.Address = ""
+	s.users[user.ID] = user
+
+	// address given when register become the first address book entry
+	if strings.TrimSpace(u.Address) != "" {
+		s.lastAddressID++
+		s.addresses[s.lastAddressID] = model.Address{
+			ID:                s.lastAddressID,
+			UserID:            u.ID,
+			RecipientName:     u.FullName,
+			Line1:             u.Address,
+			PhoneNumber:       u.PhoneNumber,
+			IsDefaultShipping: true,
+			IsDefaultBilling:  true,
+			CreatedAt:         u.CreatedAt,
+			UpdatedAt:         u.CreatedAt,
+		}
+	}
+
+	return u, nil
+}
+
+// GetUser get user by email, or by ID if ID not 0
+func (s *MemoryStore) GetUser(email string, ID int) (model.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.getUser(email, ID)
+}
+
+// getUser get user by email or by ID, the caller must hold the lock
+func (s *MemoryStore) getUser(email string, ID int) (model.User, error) {
+	if ID != 0 {
+		user, ok := s.users[ID]
+		if !ok {
+			return model.User{}, sql.ErrNoRows
+		}
+		return user, nil
+	}
+
+	for _, user := range s.users {
+		if user.Email == email {
+			return user, nil
+		}
+	}
+
+	return model.User{}, sql.ErrNoRows
+}
+
+// isEmailRegistered check email used by a user other than exceptID or not,
+// the caller must hold the lock
+func (s *MemoryStore) isEmailRegistered(email string, exceptID int) bool {
+	for _, user := range s.users {
+		if user.Email == email && user.ID != exceptID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// releaseDeletedUserEmail release email of deleted user after deleted user
+// retention passed, so the email can be registered again,
+// the caller must hold the lock
+func (s *MemoryStore) releaseDeletedUserEmail(email string) {
+	now := time.Now().UTC()
+	for ID, user := range s.users {
+		if user.Email == email && user.Status == model.UserStatusDeleted &&
+			user.DeletedAt != nil && !user.DeletedAt.After(now.Add(-s.Config.DeletedUserRetention)) {
+			user.Email = "deleted-" + strconv.Itoa(ID) + "@deleted.invalid"
+			user.Version++
+			user.UpdatedAt = now
+			s.users[ID] = user
+		}
+	}
+}
+
+// UpdateUserProfile partial update user profile with optimistic concurrency
+func (s *MemoryStore) UpdateUserProfile(userID int, version int,
+	p model.UserProfileUpdate) (model.User, int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[userID]
+	if !ok {
+		return model.User{}, 400, nil
+	}
+	if user.Version != version {
+		return user, 409, nil
+	}
+
+	// changed phone number need to be verified again
+	if p.FullName != nil {
+		user.FullName = *p.FullName
+	}
+	if p.PhoneNumber != nil && *p.PhoneNumber != user.PhoneNumber {
+		user.PhoneNumber = *p.PhoneNumber
+		user.PhoneVerifiedAt = nil
+	}
+	user.Version++
+	user.UpdatedAt = time.Now().UTC()
+	s.users[userID] = user
+
+	return user, 200, nil
+}
+
+// MarkEmailVerificationSent mark email verification sent to user,
+// only once in resend interval
+func (s *MemoryStore) MarkEmailVerificationSent(userID int) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[userID]; !ok {
+		return false, nil
+	}
+
+	now := time.Now().UTC()
+	sentAt, ok := s.emailVerificationSentAt[userID]
+	if ok && !sentAt.Before(now.Add(-s.Config.EmailVerificationResendInterval)) {
+		return false, nil
+	}
+	s.emailVerificationSentAt[userID] = now
+
+	return true, nil
+}
+
+// VerifyUserEmail verify user email if still the same as the email
+// when verification link sent, user pending verification become active
+//
+// Return status 400 if user not exist or email already changed
+func (s *MemoryStore) VerifyUserEmail(userID int, email string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[userID]
+	if !ok || user.Email != email {
+		return 400, nil
+	}
+
+	// keep the first verified time if already verified
+	if user.EmailVerifiedAt == nil {
+		now := time.Now().UTC()
+		user.EmailVerifiedAt = &now
+	}
+	if user.Status == model.UserStatusPendingVerification {
+		user.Status = model.UserStatusActive
+	}
+	s.users[userID] = user
+
+	return 200, nil
+}
+
+// GetUsers get a page of users by filter with cursor of the next page
+//
+// Return status 400 if sort key or cursor not valid
+func (s *MemoryStore) GetUsers(f model.UserListFilter) ([]model.User, string, int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	users := []model.User{}
+	for _, user := range s.users {
+		users = append(users, user)
+	}
+
+	return model.FilterUsers(users, f)
+}
+
+// SetUserRole set role of a user, the role must be one of the roles
+//
+// Return status 400 if user not exist, or status 422 if role not exist
+func (s *MemoryStore) SetUserRole(userID int, role string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.roles[role]; !ok {
+		return 422, nil
+	}
+
+	user, ok := s.users[userID]
+	if !ok {
+		return 400, nil
+	}
+	user.Role = role
+	user.Version++
+	user.UpdatedAt = time.Now().UTC()
+	s.users[userID] = user
+
+	return 200, nil
+}
+
+// SetUserStatus set status of a user, suspension reason and expiry are cleared
+//
+// Return status 400 if user not exist, or status 409 if the current status
+// of the user can't be changed to the status
+func (s *MemoryStore) SetUserStatus(userID int, status string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.updateUserStatus(userID, status, nil, nil)
+}
+
+// SuspendUser suspend a user with its reason, permanent if until is nil
+//
+// Return status 400 if user not exist, or status 409 if user already deleted
+func (s *MemoryStore) SuspendUser(userID int, reason string, until *time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.updateUserStatus(userID, model.UserStatusSuspended, &reason, until)
+}
+
+// updateUserStatus update status of a user with its suspension reason and expiry,
+// all sessions of the user are revoked if the user can't use its account anymore,
+// the caller must hold the lock
+func (s *MemoryStore) updateUserStatus(userID int, status string,
+	suspendedReason *string, suspendedUntil *time.Time) (int, error) {
+	user, ok := s.users[userID]
+	canSet, err := model.CanSetUserStatus(user.Status, status)
+	if err != nil {
+		return 500, err
+	}
+	if !ok {
+		return 400, nil
+	}
+	if !canSet {
+		return 409, nil
+	}
+
+	// keep the first deleted time if already deleted
+	now := time.Now().UTC()
+	if status != model.UserStatusDeleted {
+		user.DeletedAt = nil
+	} else if user.DeletedAt == nil {
+		user.DeletedAt = &now
+	}
+	user.Status = status
+	user.SuspendedReason = suspendedReason
+	user.SuspendedUntil = suspendedUntil
+	user.Version++
+	user.UpdatedAt = now
+	s.users[userID] = user
+
+	if !model.IsUserStatusUsable(status) {
+		s.deleteUserSessions(userID, 0)
+	}
+
+	return 200, nil
+}
+
+// CreateUserSession create user session of an existing user
+func (s *MemoryStore) CreateUserSession(us model.UserSession) (model.UserSession, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.createUserSession(us)
+}
+
+// createUserSession create user session of an existing user,
+// the caller must hold the lock
+func (s *MemoryStore) createUserSession(us model.UserSession) (model.UserSession, error) {
+	// user agent cut the same as the column length
+	if len(us.UserAgent) > 255 {
+		us.UserAgent = us.UserAgent[:255]
+	}
+	us.CreatedAt = time.Now().UTC()
+	us.LastSeenAt = us.CreatedAt
+
+	if _, ok := s.users[us.User.ID]; !ok {
+		return us, errUserNotExist
+	}
+
+	s.lastSessionID++
+	us.ID = s.lastSessionID
+	s.sessions[us.ID] = us
+
+	return us, nil
+}
+
+// GetUserSession get user session with its user by token and user ID
+func (s *MemoryStore) GetUserSession(tokenString string, userID int) (
+	model.UserSession, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, userSession := range s.sessions {
+		if userSession.Token == tokenString && userSession.User.ID == userID {
+			user, err := s.getUser("", userID)
+			if err != nil {
+				return model.UserSession{}, err
+			}
+
+			userSession.User = user
+			return userSession, nil
+		}
+	}
+
+	return model.UserSession{}, sql.ErrNoRows
+}
+
+// GetUserSessions get all sessions of a user newest activity first,
+// without token and user data
+func (s *MemoryStore) GetUserSessions(userID int) ([]model.UserSession, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.getUserSessions(userID), nil
+}
+
+// getUserSessions get all sessions of a user newest activity first,
+// without token and user data, the caller must hold the lock
+func (s *MemoryStore) getUserSessions(userID int) []model.UserSession {
+	userSessions := []model.UserSession{}
+	for _, userSession := range s.sessions {
+		if userSession.User.ID == userID {
+			userSessions = append(userSessions, model.UserSession{
+				ID:         userSession.ID,
+				User:       model.User{ID: userID},
+				UserAgent:  userSession.UserAgent,
+				IPAddress:  userSession.IPAddress,
+				CreatedAt:  userSession.CreatedAt,
+				LastSeenAt: userSession.LastSeenAt,
+			})
+		}
+	}
+
+	sort.Slice(userSessions, func(i, j int) bool {
+		if !userSessions[i].LastSeenAt.Equal(userSessions[j].LastSeenAt) {
+			return userSessions[i].LastSeenAt.After(userSessions[j].LastSeenAt)
+		}
+		return userSessions[i].ID > userSessions[j].ID
+	})
+
+	return userSessions
+}
+
+// UpdateUserSessionLastSeen update user session last seen time to now
+func (s *MemoryStore) UpdateUserSessionLastSeen(ID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	userSession, ok := s.sessions[ID]
+	if ok {
+		userSession.LastSeenAt = time.Now().UTC()
+		s.sessions[ID] = userSession
+	}
+
+	return nil
+}
+
+// DeleteUserSession delete user session by token
+func (s *MemoryStore) DeleteUserSession(tokenString string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for ID, userSession := range s.sessions {
+		if userSession.Token == tokenString {
+			delete(s.sessions, ID)
+		}
+	}
+
+	return nil
+}
+
+// DeleteUserSessionByID delete user session owned by the user,
+// return false if nothing deleted
+func (s *MemoryStore) DeleteUserSessionByID(ID int, userID int) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	userSession, ok := s.sessions[ID]
+	if !ok || userSession.User.ID != userID {
+		return false, nil
+	}
+	delete(s.sessions, ID)
+
+	return true, nil
+}
+
+// DeleteUserSessions delete all sessions of a user
+func (s *MemoryStore) DeleteUserSessions(userID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.deleteUserSessions(userID, 0)
+	return nil
+}
+
+// deleteUserSessions delete all sessions of a user except session with exceptID,
+// the caller must hold the lock
+func (s *MemoryStore) deleteUserSessions(userID int, exceptID int) {
+	for ID, userSession := range s.sessions {
+		if userSession.User.ID == userID && ID != exceptID {
+			delete(s.sessions, ID)
+		}
+	}
+}
+
+// AuthenticateUser authenticate user by email and password,
+// return access token and refresh token of the new user session
+//
+// Deleted user treated as not exist. Return status 403 if user suspended,
+// or email not verified and login blocked for unverified email.
+// Return status 202 and mfa challenge token as access token (without session)
+// if user enabled two-factor authentication.
+// Return status 423 if account or IP address locked after too many failed login,
+// or status 429 if still delayed after the last failed login
+func (s *MemoryStore) AuthenticateUser(u model.User, userAgent string, IPAddress string) (
+	string, string, int, model.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// check account or IP address locked or not
+	status, _ := s.checkLoginLockout(u.Email, IPAddress)
+	if status != 200 {
+		return "", "", status, model.User{}, nil
+	}
+
+	// get existed user data, deleted user treated as not exist
+	existedUser, err := s.getUser(u.Email, 0)
+	if err == nil && existedUser.Status == model.UserStatusDeleted {
+		err = sql.ErrNoRows
+	}
+	if err == nil {
+		// check password right or wrong
+		err = utils.ComparePassword(existedUser.Password, u.Password)
+	}
+	if err != nil {
+		s.recordLoginFailure(u.Email, IPAddress)
+
+		// record failed login of existed user
+		if existedUser.ID != 0 && existedUser.Status != model.UserStatusDeleted {
+			s.recordLoginHistory(existedUser.ID, userAgent, IPAddress, false)
+		}
+
+		return "", "", 400, existedUser, nil
+	}
+
+	// check user not suspended, lift the suspension if already expired
+	if model.EffectiveStatus(existedUser) == model.UserStatusSuspended {
+		return "", "", 403, existedUser, nil
+	} else if existedUser.Status == model.UserStatusSuspended {
+		status, err = s.updateUserStatus(existedUser.ID, model.UserStatusActive, nil, nil)
+		if err != nil {
+			return "", "", 500, existedUser, err
+		}
+		if status != 200 { // if user status can't be changed
+			return "", "", 403, existedUser, nil
+		}
+		existedUser = s.users[existedUser.ID]
+	}
+
+	// check email verified, if login blocked for unverified email
+	if existedUser.EmailVerifiedAt == nil &&
+		s.Config.EmailVerificationPolicy == config.EmailVerificationPolicyBlockLogin {
+		return "", "", 403, existedUser, nil
+	}
+
+	// return mfa challenge token if two-factor authentication enabled
+	if existedUser.TOTPEnabledAt != nil {
+		mfaToken, err := utils.GenerateActionJWT(s.Config.JWTKeys, "mfa", map[string]string{
+			"sub": strconv.Itoa(existedUser.ID),
+		}, s.Config.MFATokenDuration)
+		if err != nil {
+			return "", "", 500, existedUser, err
+		}
+
+		return mfaToken, "", 202, existedUser, nil
+	}
+
+	// create user session with its tokens
+	tokenString, refreshTokenString, err := s.createUserSessionTokens(
+		existedUser, userAgent, IPAddress)
+	if err != nil {
+		return "", "", 500, existedUser, err
+	}
+
+	return tokenString, refreshTokenString, 200, existedUser, nil
+}
+
+// createUserSessionTokens create user session of authenticated user,
+// return access token and refresh token of the session,
+// the caller must hold the lock
+//
+// Failed login attempts of the account cleared, but not of the IP address.
+// Scheduled deletion of the account cancelled by the login,
+// and the login recorded in login history.
+func (s *MemoryStore) createUserSessionTokens(u model.User, userAgent string,
+	IPAddress string) (string, string, error) {
+	s.clearLoginFailures(u.Email, "")
+
+	// cancel scheduled deletion of the account
+	user, ok := s.users[u.ID]
+	if ok && user.DeletionScheduledAt != nil {
+		user.DeletionScheduledAt = nil
+		user.Version++
+		user.UpdatedAt = time.Now().UTC()
+		s.users[u.ID] = user
+	}
+
+	s.recordLoginHistory(u.ID, userAgent, IPAddress, true)
+
+	tokenString, err := utils.GenerateJWT(s.Config.JWTKeys, u.ID,
+		model.EffectiveRole(s.Config, u), s.Config.AccessTokenDuration)
+	if err != nil {
+		return "", "", err
+	}
+
+	userSession, err := s.createUserSession(model.UserSession{
+		Token:     tokenString,
+		User:      u,
+		UserAgent: userAgent,
+		IPAddress: IPAddress,
+	})
+	if err != nil {
+		return "", "", err
+	}
+
+	refreshTokenString, err := s.createRefreshToken(userSession.ID)
+	if err != nil {
+		return "", "", err
+	}
+
+	return tokenString, refreshTokenString, nil
+}
+
+// RefreshUserSession rotate refresh token of user session,
+// return new access token and new refresh token
+//
+// If refresh token already used before (token reuse), or the user can't
+// use its account anymore (suspended, deleted, or scheduled for deletion),
+// the user session revoked and return status 400.
+// Expired refresh token not marked as used.
+func (s *MemoryStore) RefreshUserSession(refreshTokenString string) (
+	string, string, int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// get refresh token and its user session
+	tokenHash := utils.HashToken(refreshTokenString)
+	rt, ok := s.refreshTokens[tokenHash]
+	if !ok {
+		return "", "", 400, nil
+	}
+	userSession, ok := s.sessions[rt.userSessionID]
+	if !ok { // if token family already revoked
+		return "", "", 400, nil
+	}
+
+	// check user still can use its account
+	user, err := s.getUser("", userSession.User.ID)
+	isUserUsable := err == nil &&
+		model.IsUserStatusUsable(model.EffectiveStatus(user)) &&
+		user.DeletionScheduledAt == nil
+
+	// revoke token family if refresh token reused or user can't use its account
+	if rt.isUsed || !isUserUsable {
+		delete(s.sessions, userSession.ID)
+		return "", "", 400, nil
+	}
+
+	// check refresh token expired or not
+	if time.Now().UTC().After(rt.expiredAt) {
+		return "", "", 400, nil
+	}
+	rt.isUsed = true
+	s.refreshTokens[tokenHash] = rt
+
+	// generate new access token and save it into user session
+	tokenString, err := utils.GenerateJWT(s.Config.JWTKeys, user.ID,
+		model.EffectiveRole(s.Config, user), s.Config.AccessTokenDuration)
+	if err != nil {
+		return "", "", 500, err
+	}
+	userSession.Token = tokenString
+	s.sessions[userSession.ID] = userSession
+
+	// create new refresh token in the same token family
+	newRefreshTokenString, err := s.createRefreshToken(userSession.ID)
+	if err != nil {
+		return "", "", 500, err
+	}
+
+	return tokenString, newRefreshTokenString, 200, nil
+}
+
+// createRefreshToken create refresh token of user session,
+// the caller must hold the lock
+func (s *MemoryStore) createRefreshToken(userSessionID int) (string, error) {
+	tokenString, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+
+	s.refreshTokens[utils.HashToken(tokenString)] = memoryRefreshToken{
+		userSessionID: userSessionID,
+		expiredAt:     time.Now().UTC().Add(s.Config.RefreshTokenDuration),
+	}
+
+	return tokenString, nil
+}
+
+// CheckLoginLockout check login of an account email and source IP allowed or not,
+// also return time until login allowed again
+//
+// Return status 423 if locked (failure count reach the threshold),
+// status 429 if still delayed after the last failure, or status 200 if allowed
+func (s *MemoryStore) CheckLoginLockout(email string, IPAddress string) (
+	int, time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	status, lockedUntil := s.checkLoginLockout(email, IPAddress)
+	return status, lockedUntil, nil
+}
+
+// checkLoginLockout check login of an account email and source IP
+// allowed or not, the caller must hold the lock
+func (s *MemoryStore) checkLoginLockout(email string, IPAddress string) (int, time.Time) {
+	loginFailures := []model.LoginFailure{}
+	for _, key := range model.LoginFailureKeys(email, IPAddress) {
+		if lf, ok := s.loginFailures[key]; ok {
+			loginFailures = append(loginFailures, lf)
+		}
+	}
+
+	return model.LoginLockout(s.Config, loginFailures, time.Now().UTC())
+}
+
+// recordLoginFailure record failed login of an account email and source IP,
+// failure count restarted if the last failure older than lockout duration,
+// the caller must hold the lock
+func (s *MemoryStore) recordLoginFailure(email string, IPAddress string) {
+	now := time.Now().UTC()
+	for _, key := range model.LoginFailureKeys(email, IPAddress) {
+		lf, ok := s.loginFailures[key]
+		if !ok {
+			s.lastLoginFailureID++
+			lf = model.LoginFailure{ID: s.lastLoginFailureID, Key: key}
+		}
+
+		if lf.LastFailedAt.Before(now.Add(-s.Config.LoginLockoutDuration)) {
+			lf.FailureCount = 1
+		} else {
+			lf.FailureCount++
+		}
+		lf.LastFailedAt = now
+
+		lockedUntil := model.LoginFailureLockedUntil(s.Config, key, lf.FailureCount, now)
+		lf.LockedUntil = &lockedUntil
+		s.loginFailures[key] = lf
+	}
+}
+
+// ClearLoginFailures clear failed login attempts of an account email
+// and/or source IP, return false if there's no login failure cleared
+func (s *MemoryStore) ClearLoginFailures(email string, IPAddress string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.clearLoginFailures(email, IPAddress), nil
+}
+
+// clearLoginFailures clear failed login attempts of an account email
+// and/or source IP, the caller must hold the lock
+func (s *MemoryStore) clearLoginFailures(email string, IPAddress string) bool {
+	isCleared := false
+	for _, key := range model.LoginFailureKeys(email, IPAddress) {
+		if _, ok := s.loginFailures[key]; ok {
+			delete(s.loginFailures, key)
+			isCleared = true
+		}
+	}
+
+	return isCleared
+}
+
+// recordLoginHistory record a login attempt of a user,
+// the caller must hold the lock
+func (s *MemoryStore) recordLoginHistory(userID int, userAgent string, IPAddress string,
+	isSucceeded bool) {
+	// user agent cut the same as the column length
+	if len(userAgent) > 255 {
+		userAgent = userAgent[:255]
+	}
+
+	s.lastLoginHistoryID++
+	s.loginHistory[userID] = append(s.loginHistory[userID], model.LoginHistory{
+		ID:          s.lastLoginHistoryID,
+		UserAgent:   userAgent,
+		IPAddress:   IPAddress,
+		IsSucceeded: isSucceeded,
+		CreatedAt:   time.Now().UTC(),
+	})
+}
+
+// getLoginHistory get login history of a user newest first,
+// the caller must hold the lock
+func (s *MemoryStore) getLoginHistory(userID int) []model.LoginHistory {
+	loginHistory := []model.LoginHistory{}
+	for i := len(s.loginHistory[userID]) - 1; i >= 0; i-- {
+		loginHistory = append(loginHistory, s.loginHistory[userID][i])
+	}
+
+	return loginHistory
+}
+
+// EnrollTOTP enroll new totp secret of a user, enroll again
+// before confirmed replace the secret
+//
+// Return status 400 if two-factor authentication already enabled
+func (s *MemoryStore) EnrollTOTP(userID int) (string, int, error) {
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		return "", 500, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[userID]
+	if !ok || user.TOTPEnabledAt != nil {
+		return "", 400, nil
+	}
+	s.totps[userID] = memoryTOTP{secret: secret}
+
+	return secret, 200, nil
+}
+
+// ConfirmTOTP confirm enrolled totp secret with the first totp code,
+// enable two-factor authentication and return new recovery codes
+//
+// Return status 400 if secret not enrolled, already enabled, or code not valid
+func (s *MemoryStore) ConfirmTOTP(userID int, code string) ([]string, int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[userID]
+	totp, isEnrolled := s.totps[userID]
+	if !ok || !isEnrolled || user.TOTPEnabledAt != nil {
+		return nil, 400, nil
+	}
+
+	// validate first totp code
+	now := time.Now().UTC()
+	step := utils.ValidateTOTPCode(totp.secret, code, now, 0)
+	if step == 0 {
+		return nil, 400, nil
+	}
+
+	// replace recovery codes
+	recoveryCodes := []string{}
+	recoveryCodeHashes := map[string]bool{}
+	for i := 0; i < model.RecoveryCodeCount; i++ {
+		recoveryCode, err := utils.GenerateRecoveryCode()
+		if err != nil {
+			return nil, 500, err
+		}
+
+		recoveryCodes = append(recoveryCodes, recoveryCode)
+		recoveryCodeHashes[utils.HashToken(recoveryCode)] = false
+	}
+	s.recoveryCodes[userID] = recoveryCodeHashes
+
+	user.TOTPEnabledAt = &now
+	s.users[userID] = user
+	s.totps[userID] = memoryTOTP{secret: totp.secret, lastStep: step}
+
+	return recoveryCodes, 200, nil
+}
+
+// AuthenticateUserMFA complete login of user with two-factor authentication
+// by mfa challenge token and totp code or recovery code,
+// return access token and refresh token
+//
+// Return status 400 if mfa challenge token or code not valid,
+// failed code counted as failed login like in AuthenticateUser.
+// Return status 403 if user suspended
+func (s *MemoryStore) AuthenticateUserMFA(mfaToken string, code string, userAgent string,
+	IPAddress string) (string, string, int, model.User, error) {
+	// validate mfa challenge token
+	claimsMap := utils.ValidateActionJWT(s.Config.JWTKeys, mfaToken, "mfa")
+	if claimsMap == nil {
+		return "", "", 400, model.User{}, nil
+	}
+
+	userID, err := strconv.Atoi(claimsMap["sub"])
+	if err != nil {
+		return "", "", 400, model.User{}, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existedUser, err := s.getUser("", userID)
+	if err != nil || existedUser.TOTPEnabledAt == nil {
+		return "", "", 400, existedUser, nil
+	}
+
+	// check user not deleted or suspended after mfa challenge token created
+	if existedUser.Status == model.UserStatusDeleted {
+		return "", "", 400, existedUser, nil
+	} else if model.EffectiveStatus(existedUser) == model.UserStatusSuspended {
+		return "", "", 403, existedUser, nil
+	}
+
+	// check account or IP address locked or not
+	status, _ := s.checkLoginLockout(existedUser.Email, IPAddress)
+	if status != 200 {
+		return "", "", status, existedUser, nil
+	}
+
+	// check totp code or recovery code
+	if !s.verifyMFACode(existedUser.ID, code) {
+		s.recordLoginFailure(existedUser.Email, IPAddress)
+		s.recordLoginHistory(existedUser.ID, userAgent, IPAddress, false)
+
+		return "", "", 400, existedUser, nil
+	}
+
+	// create user session with its tokens
+	tokenString, refreshTokenString, err := s.createUserSessionTokens(
+		existedUser, userAgent, IPAddress)
+	if err != nil {
+		return "", "", 500, existedUser, err
+	}
+
+	return tokenString, refreshTokenString, 200, existedUser, nil
+}
+
+// verifyMFACode verify totp code or single use recovery code of a user,
+// each totp code also can only be used once, the caller must hold the lock
+func (s *MemoryStore) verifyMFACode(userID int, code string) bool {
+	// check totp code, mark its time step as used
+	totp := s.totps[userID]
+	step := utils.ValidateTOTPCode(totp.secret, code, time.Now().UTC(), totp.lastStep)
+	if step != 0 {
+		if step <= totp.lastStep {
+			return false
+		}
+
+		totp.lastStep = step
+		s.totps[userID] = totp
+		return true
+	}
+
+	// check recovery code, mark it as used
+	codeHash := utils.HashToken(utils.NormalizeRecoveryCode(code))
+	isUsed, ok := s.recoveryCodes[userID][codeHash]
+	if !ok || isUsed {
+		return false
+	}
+	s.recoveryCodes[userID][codeHash] = true
+
+	return true
+}
+
+// CreatePasswordResetToken create password reset token of a user,
+// all previous password reset token of the user is deleted
+func (s *MemoryStore) CreatePasswordResetToken(prt model.PasswordResetToken) (
+	model.PasswordResetToken, error) {
+	tokenString, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return prt, err
+	}
+	prt.Token = tokenString
+	prt.ExpiredAt = time.Now().UTC().Add(s.Config.PasswordResetTokenDuration)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[prt.User.ID]; !ok {
+		return prt, errUserNotExist
+	}
+
+	// delete previous password reset token
+	for tokenHash, passwordReset := range s.passwordResets {
+		if passwordReset.userID == prt.User.ID {
+			delete(s.passwordResets, tokenHash)
+		}
+	}
+
+	s.lastPasswordResetID++
+	prt.ID = s.lastPasswordResetID
+	s.passwordResets[utils.HashToken(prt.Token)] = memoryPasswordReset{
+		ID:        prt.ID,
+		userID:    prt.User.ID,
+		expiredAt: prt.ExpiredAt,
+	}
+
+	return prt, nil
+}
+
+// ResetPassword reset user password by password reset token, the new password
+// can't be one of the last passwords of the user, all sessions of the user
+// are revoked after password changed
+//
+// Return status 400 if token not valid, already used, or expired,
+// or status 422 if new password already used recently (token not used)
+func (s *MemoryStore) ResetPassword(tokenString string, newPassword string) (int, error) {
+	hashedPassword, err := utils.HashPassword(newPassword)
+	if err != nil {
+		return 500, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// get password reset token, check already used or expired
+	tokenHash := utils.HashToken(tokenString)
+	passwordReset, ok := s.passwordResets[tokenHash]
+	if !ok || passwordReset.isUsed || time.Now().UTC().After(passwordReset.expiredAt) {
+		return 400, nil
+	}
+
+	// check new password not used recently
+	user, ok := s.users[passwordReset.userID]
+	if !ok {
+		return 400, nil
+	}
+	if s.isPasswordUsedRecently(user, newPassword) {
+		return 422, nil
+	}
+
+	passwordReset.isUsed = true
+	s.passwordResets[tokenHash] = passwordReset
+
+	s.setUserPassword(user, hashedPassword)
+	s.deleteUserSessions(user.ID, 0)
+
+	return 200, nil
+}
+
+// ChangePassword change password of a user, the current password must be right
+// and the new password can't be one of the last passwords of the user,
+// all other sessions of the user are revoked after password changed
+//
+// Return status 400 if current password wrong,
+// or status 422 if new password already used recently
+func (s *MemoryStore) ChangePassword(userID int, currentPassword string, newPassword string,
+	currentSessionID int) (int, error) {
+	hashedPassword, err := utils.HashPassword(newPassword)
+	if err != nil {
+		return 500, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[userID]
+	if !ok || utils.ComparePassword(user.Password, currentPassword) != nil {
+		return 400, nil
+	}
+	if s.isPasswordUsedRecently(user, newPassword) {
+		return 422, nil
+	}
+
+	s.setUserPassword(user, hashedPassword)
+	s.deleteUserSessions(userID, currentSessionID)
+
+	return 200, nil
+}
+
+// isPasswordUsedRecently check password is the current password or
+// one of the previous passwords kept in password history,
+// the caller must hold the lock
+func (s *MemoryStore) isPasswordUsedRecently(u model.User, password string) bool {
+	if utils.ComparePassword(u.Password, password) == nil {
+		return true
+	}
+
+	// the current password counted as one of the last passwords
+	for i, hashedPassword := range s.passwordHistory[u.ID] {
+		if i >= s.Config.PasswordHistoryCount-1 {
+			break
+		}
+		if utils.ComparePassword(hashedPassword, password) == nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+// setUserPassword set new hashed password of a user, the old password
+// kept in password history, the caller must hold the lock
+func (s *MemoryStore) setUserPassword(u model.User, hashedPassword string) {
+	// only the last passwords kept
+	passwordHistory := append([]string{u.Password}, s.passwordHistory[u.ID]...)
+	for len(passwordHistory) > 0 && len(passwordHistory) > s.Config.PasswordHistoryCount {
+		passwordHistory = passwordHistory[:len(passwordHistory)-1]
+	}
+	s.passwordHistory[u.ID] = passwordHistory
+
+	u.Password = hashedPassword
+	u.Version++
+	u.UpdatedAt = time.Now().UTC()
+	s.users[u.ID] = u
+}
+
+// CreateEmailChange create email change of a user, the current password
+// must be right, all previous unconfirmed email change of the user is deleted
+//
+// Return status 400 if user not exist or current password wrong,
+// or status 409 if new email already registered
+func (s *MemoryStore) CreateEmailChange(userID int, currentPassword string, newEmail string) (
+	model.EmailChange, int, error) {
+	ec := model.EmailChange{NewEmail: newEmail}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[userID]
+	if !ok {
+		return ec, 400, nil
+	}
+	ec.User = user
+	ec.OldEmail = user.Email
+
+	if utils.ComparePassword(user.Password, currentPassword) != nil {
+		return ec, 400, nil
+	}
+
+	// the email can be used if used by deleted user after retention passed
+	s.releaseDeletedUserEmail(newEmail)
+	if s.isEmailRegistered(newEmail, 0) {
+		return ec, 409, nil
+	}
+
+	tokenString, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return ec, 500, err
+	}
+	ec.Token = tokenString
+	ec.ExpiredAt = time.Now().UTC().Add(s.Config.EmailChangeTokenDuration)
+
+	// delete previous unconfirmed email change
+	for ID, emailChange := range s.emailChanges {
+		if emailChange.userID == userID && emailChange.confirmedAt == nil {
+			delete(s.emailChanges, ID)
+		}
+	}
+
+	s.lastEmailChangeID++
+	ec.ID = s.lastEmailChangeID
+	s.emailChanges[ec.ID] = memoryEmailChange{
+		ID:        ec.ID,
+		userID:    userID,
+		oldEmail:  ec.OldEmail,
+		newEmail:  ec.NewEmail,
+		tokenHash: utils.HashToken(ec.Token),
+		expiredAt: ec.ExpiredAt,
+	}
+
+	return ec, 200, nil
+}
+
+// ConfirmEmailChange confirm email change by token sent to the new email,
+// change user email and return email change with its revert token
+//
+// Return status 400 if token not valid, already used, expired, or
+// user email already changed, or status 409 if new email already registered
+func (s *MemoryStore) ConfirmEmailChange(tokenString string) (model.EmailChange, int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// get email change, check already used or expired
+	emailChange, ok := s.getEmailChange(utils.HashToken(tokenString), "")
+	if !ok {
+		return model.EmailChange{}, 400, nil
+	}
+	ec := emailChange.emailChange()
+
+	now := time.Now().UTC()
+	if emailChange.confirmedAt != nil || now.After(emailChange.expiredAt) {
+		return ec, 400, nil
+	}
+	if s.isEmailRegistered(emailChange.newEmail, 0) {
+		return ec, 409, nil
+	}
+
+	// change user email, the new email verified by the confirmation
+	user, ok := s.users[emailChange.userID]
+	if !ok || user.Email != emailChange.oldEmail {
+		return ec, 400, nil
+	}
+	revertTokenString, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return ec, 500, err
+	}
+
+	user.Email = emailChange.newEmail
+	user.EmailVerifiedAt = &now
+	if user.Status == model.UserStatusPendingVerification {
+		user.Status = model.UserStatusActive
+	}
+	user.Version++
+	user.UpdatedAt = now
+	s.users[user.ID] = user
+
+	// mark email change as confirmed with its revert token
+	revertExpiredAt := now.Add(s.Config.EmailChangeRevertDuration)
+	emailChange.confirmedAt = &now
+	emailChange.revertTokenHash = utils.HashToken(revertTokenString)
+	emailChange.revertExpiredAt = &revertExpiredAt
+	s.emailChanges[emailChange.ID] = emailChange
+
+	ec = emailChange.emailChange()
+	ec.RevertToken = revertTokenString
+
+	return ec, 200, nil
+}
+
+// RevertEmailChange revert confirmed email change by token sent to the old email,
+// user email changed back to the old email and all sessions of the user
+// are revoked
+//
+// Return status 400 if token not valid, already used, expired, or user
+// not exist, or status 409 if old email already registered by another user
+func (s *MemoryStore) RevertEmailChange(tokenString string) (model.EmailChange, int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// get confirmed email change, check already used or expired
+	emailChange, ok := s.getEmailChange("", utils.HashToken(tokenString))
+	if !ok {
+		return model.EmailChange{}, 400, nil
+	}
+	ec := emailChange.emailChange()
+
+	now := time.Now().UTC()
+	if emailChange.isReverted || emailChange.revertExpiredAt == nil ||
+		now.After(*emailChange.revertExpiredAt) {
+		return ec, 400, nil
+	}
+	if s.isEmailRegistered(emailChange.oldEmail, emailChange.userID) {
+		return ec, 409, nil
+	}
+
+	// change user email back to the old email, verified by the revert
+	user, ok := s.users[emailChange.userID]
+	if !ok {
+		return ec, 400, nil
+	}
+	user.Email = emailChange.oldEmail
+	user.EmailVerifiedAt = &now
+	user.Version++
+	user.UpdatedAt = now
+	s.users[user.ID] = user
+
+	// mark email change as reverted, delete all other email changes of the user
+	emailChange.isReverted = true
+	s.emailChanges[emailChange.ID] = emailChange
+	for ID, otherEmailChange := range s.emailChanges {
+		if otherEmailChange.userID == user.ID && ID != emailChange.ID {
+			delete(s.emailChanges, ID)
+		}
+	}
+
+	s.deleteUserSessions(user.ID, 0)
+
+	return ec, 200, nil
+}
+
+// getEmailChange get email change by its token hash or revert token hash,
+// the caller must hold the lock
+func (s *MemoryStore) getEmailChange(tokenHash string, revertTokenHash string) (
+	memoryEmailChange, bool) {
+	for _, emailChange := range s.emailChanges {
+		if (tokenHash != "" && emailChange.tokenHash == tokenHash) ||
+			(revertTokenHash != "" && emailChange.revertTokenHash == revertTokenHash) {
+			return emailChange, true
+		}
+	}
+
+	return memoryEmailChange{}, false
+}
+
+// emailChange get email change model without its tokens
+func (ec memoryEmailChange) emailChange() model.EmailChange {
+	return model.EmailChange{
+		ID:              ec.ID,
+		User:            model.User{ID: ec.userID},
+		OldEmail:        ec.oldEmail,
+		NewEmail:        ec.newEmail,
+		ExpiredAt:       ec.expiredAt,
+		ConfirmedAt:     ec.confirmedAt,
+		RevertExpiredAt: ec.revertExpiredAt,
+	}
+}
+
+// CreatePhoneVerification create phone verification code of user phone number,
+// replace previous code of the user. Phone number saved before normalization
+// normalized to E.164 first, return the normalized phone number with the code
+//
+// Return status 400 if user not exist, status 422 if phone number not valid,
+// status 409 if phone number already verified,
+// or status 429 if code already sent within resend interval
+func (s *MemoryStore) CreatePhoneVerification(userID int) (string, string, int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[userID]
+	if !ok || user.Status == model.UserStatusDeleted {
+		return "", "", 400, nil
+	}
+
+	normalizedPhoneNumber, err := phone.Normalize(user.PhoneNumber, s.Config.PhoneDefaultRegion)
+	if err != nil {
+		return "", "", 422, nil
+	}
+	if user.PhoneVerifiedAt != nil && normalizedPhoneNumber == user.PhoneNumber {
+		return "", "", 409, nil
+	}
+
+	// check resend interval
+	now := time.Now().UTC()
+	pv, ok := s.phoneVerifications[userID]
+	if ok && pv.createdAt.After(now.Add(-s.Config.PhoneVerificationResendInterval)) {
+		return "", "", 429, nil
+	}
+
+	code, err := utils.GenerateNumericCode(model.PhoneVerificationCodeDigits)
+	if err != nil {
+		return "", "", 500, err
+	}
+
+	// normalize phone number saved before normalization
+	if normalizedPhoneNumber != user.PhoneNumber {
+		user.PhoneNumber = normalizedPhoneNumber
+		user.PhoneVerifiedAt = nil
+		user.Version++
+		user.UpdatedAt = now
+		s.users[userID] = user
+	}
+
+	// replace previous code
+	s.phoneVerifications[userID] = memoryPhoneVerification{
+		phoneNumber: normalizedPhoneNumber,
+		codeHash:    utils.HashToken(code),
+		createdAt:   now,
+		expiredAt:   now.Add(s.Config.PhoneVerificationCodeDuration),
+	}
+
+	return code, normalizedPhoneNumber, 200, nil
+}
+
+// VerifyPhone verify user phone number by code sent to the phone number,
+// the phone number must be still the same as the phone number
+// when the code sent
+//
+// Return status 400 if code not valid, expired, or phone number already changed,
+// or status 429 if wrong code entered too many times (code deleted)
+func (s *MemoryStore) VerifyPhone(userID int, code string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now().UTC()
+	pv, ok := s.phoneVerifications[userID]
+	if !ok || !pv.expiredAt.After(now) {
+		return 400, nil
+	}
+
+	// check code, the code deleted after too many wrong attempts
+	if subtle.ConstantTimeCompare([]byte(utils.HashToken(code)), []byte(pv.codeHash)) != 1 {
+		pv.attempts++
+		if pv.attempts >= s.Config.PhoneVerificationMaxAttempts {
+			delete(s.phoneVerifications, userID)
+			return 429, nil
+		}
+
+		s.phoneVerifications[userID] = pv
+		return 400, nil
+	}
+	delete(s.phoneVerifications, userID)
+
+	// keep the first verified time if already verified
+	user, ok := s.users[userID]
+	if !ok || user.PhoneNumber != pv.phoneNumber {
+		return 400, nil
+	}
+	if user.PhoneVerifiedAt == nil {
+		user.PhoneVerifiedAt = &now
+		s.users[userID] = user
+	}
+
+	return 200, nil
+}
+
+// IsRoleSelfRegistrable check a role can be chosen by user when register or not
+func (s *MemoryStore) IsRoleSelfRegistrable(name string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.roles[name].IsSelfRegistrable, nil
+}
+
+// GetRolePermissions get permissions of a role sorted,
+// role that not exist has no permission
+func (s *MemoryStore) GetRolePermissions(name string) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]string{}, s.roles[name].Permissions...), nil
+}
+
+// HasPermission check a role has a permission or not
+func (s *MemoryStore) HasPermission(name string, permission string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, rolePermission := range s.roles[name].Permissions {
+		if rolePermission == permission {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// GetAddresses get all addresses of a user, default addresses first
+// then newest first
+func (s *MemoryStore) GetAddresses(userID int) ([]model.Address, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.getAddresses(userID), nil
+}
+
+// getAddresses get all addresses of a user, default addresses first
+// then newest first, the caller must hold the lock
+func (s *MemoryStore) getAddresses(userID int) []model.Address {
+	addresses := []model.Address{}
+	for _, address := range s.addresses {
+		if address.UserID == userID {
+			addresses = append(addresses, address)
+		}
+	}
+
+	sort.Slice(addresses, func(i, j int) bool {
+		if addresses[i].IsDefaultShipping != addresses[j].IsDefaultShipping {
+			return addresses[i].IsDefaultShipping
+		}
+		if addresses[i].IsDefaultBilling != addresses[j].IsDefaultBilling {
+			return addresses[i].IsDefaultBilling
+		}
+		return addresses[i].ID > addresses[j].ID
+	})
+
+	return addresses
+}
+
+// GetAddress get an address of a user by ID
+func (s *MemoryStore) GetAddress(ID int, userID int) (model.Address, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	address, ok := s.addresses[ID]
+	if !ok || address.UserID != userID {
+		return model.Address{}, sql.ErrNoRows
+	}
+
+	return address, nil
+}
+
+// CreateAddress create address of a user, the first address of the user
+// become default shipping and billing address
+//
+// Return status 400 if the user already has maximum number of addresses
+func (s *MemoryStore) CreateAddress(a model.Address) (model.Address, int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[a.UserID]; !ok {
+		return a, 500, errUserNotExist
+	}
+
+	count := len(s.getAddresses(a.UserID))
+	if count >= model.MaxAddressesPerUser {
+		return a, 400, nil
+	}
+	if count == 0 {
+		a.IsDefaultShipping = true
+		a.IsDefaultBilling = true
+	}
+
+	// only one default address of each type
+	s.unsetDefaultAddresses(a.UserID, 0, a.IsDefaultShipping, a.IsDefaultBilling)
+
+	s.lastAddressID++
+	a.ID = s.lastAddressID
+	a.CreatedAt = time.Now().UTC()
+	a.UpdatedAt = a.CreatedAt
+	s.addresses[a.ID] = a
+
+	return a, 200, nil
+}
+
+// UpdateAddress update address of a user, all fields replaced
+//
+// Return sql.ErrNoRows if the address not exist
+func (s *MemoryStore) UpdateAddress(a model.Address) (model.Address, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	address, ok := s.addresses[a.ID]
+	if !ok || address.UserID != a.UserID {
+		return a, sql.ErrNoRows
+	}
+
+	// only one default address of each type
+	s.unsetDefaultAddresses(a.UserID, a.ID, a.IsDefaultShipping, a.IsDefaultBilling)
+
+	a.CreatedAt = address.CreatedAt
+	a.UpdatedAt = time.Now().UTC()
+	s.addresses[a.ID] = a
+
+	return a, nil
+}
+
+// DeleteAddress delete address of a user, the newest remaining address
+// become default address if the deleted address was default
+//
+// Return sql.ErrNoRows if the address not exist
+func (s *MemoryStore) DeleteAddress(ID int, userID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	address, ok := s.addresses[ID]
+	if !ok || address.UserID != userID {
+		return sql.ErrNoRows
+	}
+	delete(s.addresses, ID)
+
+	// replace deleted default address
+	newestID := 0
+	for _, remainingAddress := range s.addresses {
+		if remainingAddress.UserID == userID && remainingAddress.ID > newestID {
+			newestID = remainingAddress.ID
+		}
+	}
+	if newestID != 0 {
+		newestAddress := s.addresses[newestID]
+		newestAddress.IsDefaultShipping = newestAddress.IsDefaultShipping ||
+			address.IsDefaultShipping
+		newestAddress.IsDefaultBilling = newestAddress.IsDefaultBilling ||
+			address.IsDefaultBilling
+		s.addresses[newestID] = newestAddress
+	}
+
+	return nil
+}
+
+// unsetDefaultAddresses unset default shipping and/or billing address of a user,
+// except address with exceptID, the caller must hold the lock
+func (s *MemoryStore) unsetDefaultAddresses(userID int, exceptID int,
+	isShipping bool, isBilling bool) {
+	for ID, address := range s.addresses {
+		if address.UserID != userID || ID == exceptID {
+			continue
+		}
+
+		address.IsDefaultShipping = address.IsDefaultShipping && !isShipping
+		address.IsDefaultBilling = address.IsDefaultBilling && !isBilling
+		s.addresses[ID] = address
+	}
+}
+
+// ScheduleUserDeletion schedule deletion of a user account after grace period,
+// the password must be right and all sessions of the user are revoked
+//
+// Wrong password recorded as failed login of the account and source IP.
+// Return status 400 if user not exist or password wrong,
+// status 423 if account or IP address locked after too many failed login,
+// or status 429 if still delayed after the last failed login
+func (s *MemoryStore) ScheduleUserDeletion(userID int, password string, IPAddress string) (
+	model.User, int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existedUser, err := s.getUser("", userID)
+	if err != nil {
+		return existedUser, 400, nil
+	}
+
+	// check account or IP address locked or not
+	status, _ := s.checkLoginLockout(existedUser.Email, IPAddress)
+	if status != 200 {
+		return existedUser, status, nil
+	}
+
+	// check password right or wrong
+	if utils.ComparePassword(existedUser.Password, password) != nil {
+		s.recordLoginFailure(existedUser.Email, IPAddress)
+		return existedUser, 400, nil
+	}
+
+	now := time.Now().UTC()
+	deletionScheduledAt := now.Add(s.Config.AccountDeletionGracePeriod)
+	existedUser.DeletionScheduledAt = &deletionScheduledAt
+	existedUser.Version++
+	existedUser.UpdatedAt = now
+	s.users[userID] = existedUser
+
+	s.deleteUserSessions(userID, 0)
+
+	return existedUser, 200, nil
+}
+
+// EraseScheduledUsers erase all user accounts that already passed its deletion
+// time, return number of erased user accounts
+//
+// Personal data of the user deleted, but the user kept with anonymized
+// fields, so the user ID still can be used as pseudonymous ID.
+// Account deleted event created for every erased user.
+func (s *MemoryStore) EraseScheduledUsers() (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now().UTC()
+	userIDs := []int{}
+	for ID, user := range s.users {
+		if user.DeletionScheduledAt != nil && !user.DeletionScheduledAt.After(now) {
+			userIDs = append(userIDs, ID)
+		}
+	}
+	sort.Ints(userIDs)
+
+	for i, userID := range userIDs {
+		err := s.eraseUser(userID, now)
+		if err != nil {
+			return i, err
+		}
+	}
+
+	return len(userIDs), nil
+}
+
+// eraseUser erase a user account with its personal data,
+// the caller must hold the lock
+func (s *MemoryStore) eraseUser(userID int, now time.Time) error {
+	user := s.users[userID]
+	for _, key := range model.LoginFailureKeys(user.Email, "") {
+		delete(s.loginFailures, key)
+	}
+
+	// anonymize personal fields
+	s.users[userID] = model.User{
+		ID:        userID,
+		Email:     "deleted-" + strconv.Itoa(userID) + "@deleted.invalid",
+		Role:      user.Role,
+		Status:    model.UserStatusDeleted,
+		CreatedAt: user.CreatedAt,
+		DeletedAt: &now,
+		Version:   user.Version + 1,
+		UpdatedAt: now,
+	}
+
+	// delete personal data of the user
+	s.deleteUserSessions(userID, 0)
+	delete(s.emailVerificationSentAt, userID)
+	delete(s.totps, userID)
+	delete(s.recoveryCodes, userID)
+	delete(s.passwordHistory, userID)
+	delete(s.phoneVerifications, userID)
+	delete(s.loginHistory, userID)
+	for tokenHash, passwordReset := range s.passwordResets {
+		if passwordReset.userID == userID {
+			delete(s.passwordResets, tokenHash)
+		}
+	}
+	for ID, emailChange := range s.emailChanges {
+		if emailChange.userID == userID {
+			delete(s.emailChanges, ID)
+		}
+	}
+	for ID, address := range s.addresses {
+		if address.UserID == userID {
+			delete(s.addresses, ID)
+		}
+	}
+	for ID, de := range s.dataExports {
+		if de.dataExport.UserID == userID {
+			delete(s.dataExports, ID)
+		}
+	}
+
+	// create account deleted event
+	return s.createEvent(event.TypeAccountDeleted, map[string]any{
+		"user_id":    userID,
+		"deleted_at": now,
+	})
+}
+
+// CreateDataExport create data export of a user, return data export
+// of the user that still pending or processing if exist (return false),
+// so one user only has one export in progress
+func (s *MemoryStore) CreateDataExport(userID int) (model.DataExport, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// get data export in progress
+	inProgressID := 0
+	for ID, de := range s.dataExports {
+		if de.dataExport.UserID == userID && ID > inProgressID &&
+			(de.dataExport.Status == model.DataExportStatusPending ||
+				de.dataExport.Status == model.DataExportStatusProcessing) {
+			inProgressID = ID
+		}
+	}
+	if inProgressID != 0 {
+		return s.dataExports[inProgressID].dataExport, false, nil
+	}
+
+	if _, ok := s.users[userID]; !ok {
+		return model.DataExport{}, false, errUserNotExist
+	}
+
+	s.lastDataExportID++
+	de := model.DataExport{
+		ID:            s.lastDataExportID,
+		UserID:        userID,
+		FormatVersion: dataexport.FormatVersion,
+		Status:        model.DataExportStatusPending,
+		CreatedAt:     time.Now().UTC(),
+	}
+	s.dataExports[de.ID] = memoryDataExport{dataExport: de}
+
+	return de, true, nil
+}
+
+// GetDataExport get data export of a user by ID
+func (s *MemoryStore) GetDataExport(ID int, userID int) (model.DataExport, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	de, ok := s.dataExports[ID]
+	if !ok || de.dataExport.UserID != userID {
+		return model.DataExport{}, sql.ErrNoRows
+	}
+
+	return de.dataExport, nil
+}
+
+// GetDataExportArchive get archive of a ready data export
+func (s *MemoryStore) GetDataExportArchive(ID int) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	de, ok := s.dataExports[ID]
+	if !ok || de.dataExport.Status != model.DataExportStatusReady {
+		return nil, sql.ErrNoRows
+	}
+
+	return de.archive, nil
+}
+
+// ClaimDataExport claim the oldest pending data export to be processed,
+// data export that processing too long claimed again
+//
+// Return sql.ErrNoRows if there's no data export to process
+func (s *MemoryStore) ClaimDataExport() (model.DataExport, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now().UTC()
+	oldestID := 0
+	for ID, de := range s.dataExports {
+		isClaimable := de.dataExport.Status == model.DataExportStatusPending ||
+			(de.dataExport.Status == model.DataExportStatusProcessing &&
+				de.dataExport.StartedAt != nil &&
+				!de.dataExport.StartedAt.After(now.Add(-model.DataExportProcessingTimeout)))
+		if isClaimable && (oldestID == 0 || ID < oldestID) {
+			oldestID = ID
+		}
+	}
+	if oldestID == 0 {
+		return model.DataExport{}, sql.ErrNoRows
+	}
+
+	de := s.dataExports[oldestID]
+	de.dataExport.Status = model.DataExportStatusProcessing
+	de.dataExport.StartedAt = &now
+	s.dataExports[oldestID] = de
+
+	return de.dataExport, nil
+}
+
+// CompleteDataExport complete data export with its archive,
+// the archive expired after data export duration
+func (s *MemoryStore) CompleteDataExport(ID int, archive []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	de, ok := s.dataExports[ID]
+	if ok {
+		now := time.Now().UTC()
+		expiredAt := now.Add(s.Config.DataExportDuration)
+		de.dataExport.Status = model.DataExportStatusReady
+		de.dataExport.CompletedAt = &now
+		de.dataExport.ExpiredAt = &expiredAt
+		de.archive = archive
+		s.dataExports[ID] = de
+	}
+
+	return nil
+}
+
+// FailDataExport mark data export as failed
+func (s *MemoryStore) FailDataExport(ID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	de, ok := s.dataExports[ID]
+	if ok {
+		now := time.Now().UTC()
+		de.dataExport.Status = model.DataExportStatusFailed
+		de.dataExport.CompletedAt = &now
+		s.dataExports[ID] = de
+	}
+
+	return nil
+}
+
+// DeleteExpiredDataExports delete expired data exports with its archive
+func (s *MemoryStore) DeleteExpiredDataExports() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now().UTC()
+	for ID, de := range s.dataExports {
+		if de.dataExport.ExpiredAt != nil && !de.dataExport.ExpiredAt.After(now) {
+			delete(s.dataExports, ID)
+		}
+	}
+
+	return nil
+}
+
+// GetDataExportFiles get all personal data of a user as data export archive files
+func (s *MemoryStore) GetDataExportFiles(userID int) ([]dataexport.File, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, err := s.getUser("", userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return model.DataExportFiles(user, s.getAddresses(userID), s.getUserSessions(userID),
+		s.getLoginHistory(userID)), nil
+}
+
+// createEvent create event with its data as JSON payload,
+// the caller must hold the lock
+func (s *MemoryStore) createEvent(eventType string, data any) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	s.events = append(s.events, model.Event{
+		ID:        len(s.events) + 1,
+		Type:      eventType,
+		Payload:   string(payload),
+		CreatedAt: time.Now().UTC(),
+	})
+
+	return nil
+}
+
+// GetUnpublishedEvents get events not published yet, the oldest event first
+func (s *MemoryStore) GetUnpublishedEvents(limit int) ([]model.Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	events := []model.Event{}
+	for _, e := range s.events {
+		if len(events) >= limit {
+			break
+		}
+		if e.PublishedAt == nil {
+			events = append(events, e)
+		}
+	}
+
+	return events, nil
+}
+
+// MarkEventPublished mark event as published
+func (s *MemoryStore) MarkEventPublished(ID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if ID >= 1 && ID <= len(s.events) {
+		now := time.Now().UTC()
+		s.events[ID-1].PublishedAt = &now
+	}
+
+	return nil
+}
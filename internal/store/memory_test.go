@@ -0,0 +1,140 @@
+/*
+Package store containing storage interfaces of the models,
+with SQL database and in-memory implementation
+*/
+package store_test
+
+import (
+	"log"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/reyhanfikridz/ecom-account-service/internal/config"
+	"github.com/reyhanfikridz/ecom-account-service/internal/event"
+	"github.com/reyhanfikridz/ecom-account-service/internal/model"
+	"github.com/reyhanfikridz/ecom-account-service/internal/store"
+	"github.com/reyhanfikridz/ecom-account-service/internal/store/storetest"
+)
+
+// config of all testing in the package
+var testConfig config.Config
+
+// TestMain do some test before and after all testing in the package
+func TestMain(m *testing.M) {
+	// load config before can be used (e.g. jwt key)
+	var err error
+	testConfig, _, err = config.Load(nil)
+	if err != nil {
+		log.Fatalf("There's an error when initialize config => %s", err)
+	}
+
+	// no delay after failed login, so testing can login right after
+	// testing wrong password (lockout still applied after the threshold)
+	testConfig.LoginBackoffBase = 0
+
+	os.Exit(m.Run())
+}
+
+// TestMemoryStore test MemoryStore pass store conformance testing
+func TestMemoryStore(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) store.Store {
+		return store.NewMemoryStore(testConfig)
+	})
+}
+
+// TestMemoryStoreExpiredRefreshToken test expired refresh token
+// not marked as used, so using it again not revoke the user session
+func TestMemoryStoreExpiredRefreshToken(t *testing.T) {
+	c := testConfig
+	c.RefreshTokenDuration = -time.Minute
+	s := store.NewMemoryStore(c)
+
+	u, err := s.CreateUser(model.User{
+		Email:    storetest.Emails[0],
+		Password: "testpassword1",
+		Role:     "buyer",
+	})
+	if err != nil {
+		t.Fatalf("There's an error when creating testing user => " + err.Error())
+	}
+
+	token, refreshToken, status, _, err := s.AuthenticateUser(model.User{
+		Email:    storetest.Emails[0],
+		Password: "testpassword1",
+	}, "test-agent", "192.0.2.1")
+	if status != 200 || err != nil {
+		t.Fatalf("Expected status 200 and error nil, but got status %d error %v", status, err)
+	}
+
+	for i := 0; i < 2; i++ {
+		_, _, status, err = s.RefreshUserSession(refreshToken)
+		if status != 400 || err != nil {
+			t.Errorf("Expected status 400 and error nil, but got status %d error %v",
+				status, err)
+		}
+	}
+
+	_, err = s.GetUserSession(token, u.ID)
+	if err != nil {
+		t.Errorf("Expected user session still exist, but got error %v", err)
+	}
+}
+
+// TestMemoryStoreEraseScheduledUsers test EraseScheduledUsers erase
+// user after its deletion time and create account deleted event
+func TestMemoryStoreEraseScheduledUsers(t *testing.T) {
+	c := testConfig
+	c.AccountDeletionGracePeriod = -time.Minute
+	s := store.NewMemoryStore(c)
+
+	for _, email := range storetest.Emails[:2] {
+		_, err := s.CreateUser(model.User{
+			Email:    email,
+			Password: "testpassword1",
+			Role:     "buyer",
+		})
+		if err != nil {
+			t.Fatalf("There's an error when creating testing user => " + err.Error())
+		}
+	}
+
+	u, status, err := s.ScheduleUserDeletion(1, "testpassword1", "192.0.2.1")
+	if status != 200 || err != nil {
+		t.Fatalf("Expected status 200 and error nil, but got status %d error %v", status, err)
+	}
+
+	count, err := s.EraseScheduledUsers()
+	if count != 1 || err != nil {
+		t.Errorf("Expected 1 user erased and error nil, but got %d error %v", count, err)
+	}
+
+	// erased user kept anonymized, the email can be registered again
+	user, err := s.GetUser("", u.ID)
+	if err != nil || user.Status != model.UserStatusDeleted || user.Email == u.Email ||
+		user.DeletedAt == nil {
+		t.Errorf("Expected anonymized deleted user, but got %+v error %v", user, err)
+	}
+	_, err = s.CreateUser(model.User{
+		Email:    u.Email,
+		Password: "testpassword1",
+		Role:     "buyer",
+	})
+	if err != nil {
+		t.Errorf("Expected email can be registered again, but got error %v", err)
+	}
+
+	// account deleted event published once
+	events, err := s.GetUnpublishedEvents(10)
+	if err != nil || len(events) != 1 || events[0].Type != event.TypeAccountDeleted {
+		t.Fatalf("Expected 1 account deleted event, but got %+v error %v", events, err)
+	}
+	err = s.MarkEventPublished(events[0].ID)
+	if err != nil {
+		t.Errorf("Expected error nil, but got error %v", err)
+	}
+	events, err = s.GetUnpublishedEvents(10)
+	if err != nil || len(events) != 0 {
+		t.Errorf("Expected no unpublished event, but got %+v error %v", events, err)
+	}
+}
@@ -0,0 +1,311 @@
+/*
+Package store containing storage interfaces of the models,
+with SQL database and in-memory implementation
+*/
+package store
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/reyhanfikridz/ecom-account-service/internal/config"
+	"github.com/reyhanfikridz/ecom-account-service/internal/dataexport"
+	"github.com/reyhanfikridz/ecom-account-service/internal/model"
+)
+
+// SQLStore store in Postgres or SQLite database,
+// the queries are the model package functions
+type SQLStore struct {
+	DB     *sql.DB
+	Config config.Config
+}
+
+// NewSQLStore create store of a Postgres or SQLite database connection
+// and a config
+func NewSQLStore(DB *sql.DB, c config.Config) *SQLStore {
+	return &SQLStore{DB: DB, Config: c}
+}
+
+// CreateUser create exactly one new user
+func (s *SQLStore) CreateUser(u model.User) (model.User, error) {
+	u, err := model.CreateUser(s.DB, s.Config, u)
+	if err != nil && isDuplicateEmailError(err) { // if email unique constraint violated
+		return u, ErrDuplicateEmail
+	}
+
+	return u, err
+}
+
+// GetUser get user by email, or by ID if ID not 0
+func (s *SQLStore) GetUser(email string, ID int) (model.User, error) {
+	return model.GetUser(s.DB, email, ID)
+}
+
+// UpdateUserProfile partial update user profile with optimistic concurrency
+func (s *SQLStore) UpdateUserProfile(userID int, version int,
+	p model.UserProfileUpdate) (model.User, int, error) {
+	return model.UpdateUserProfile(s.DB, userID, version, p)
+}
+
+// MarkEmailVerificationSent mark email verification sent to user
+func (s *SQLStore) MarkEmailVerificationSent(userID int) (bool, error) {
+	return model.MarkEmailVerificationSent(s.DB, s.Config, userID)
+}
+
+// VerifyUserEmail verify user email if still the same as the email
+// when verification link sent
+func (s *SQLStore) VerifyUserEmail(userID int, email string) (int, error) {
+	return model.VerifyUserEmail(s.DB, userID, email)
+}
+
+// GetUsers get a page of users by filter with cursor of the next page
+func (s *SQLStore) GetUsers(f model.UserListFilter) ([]model.User, string, int, error) {
+	return model.GetUsers(s.DB, f)
+}
+
+// SetUserRole set role of a user
+func (s *SQLStore) SetUserRole(userID int, role string) (int, error) {
+	return model.SetUserRole(s.DB, userID, role)
+}
+
+// SetUserStatus set status of a user
+func (s *SQLStore) SetUserStatus(userID int, status string) (int, error) {
+	return model.SetUserStatus(s.DB, userID, status)
+}
+
+// SuspendUser suspend a user with its reason
+func (s *SQLStore) SuspendUser(userID int, reason string, until *time.Time) (int, error) {
+	return model.SuspendUser(s.DB, userID, reason, until)
+}
+
+// CreateUserSession create user session
+func (s *SQLStore) CreateUserSession(us model.UserSession) (model.UserSession, error) {
+	return model.CreateUserSession(s.DB, us)
+}
+
+// GetUserSession get user session by token and user ID
+func (s *SQLStore) GetUserSession(tokenString string, userID int) (
+	model.UserSession, error) {
+	return model.GetUserSession(s.DB, tokenString, userID)
+}
+
+// GetUserSessions get all sessions of a user
+func (s *SQLStore) GetUserSessions(userID int) ([]model.UserSession, error) {
+	return model.GetUserSessions(s.DB, userID)
+}
+
+// UpdateUserSessionLastSeen update user session last seen time to now
+func (s *SQLStore) UpdateUserSessionLastSeen(ID int) error {
+	return model.UpdateUserSessionLastSeen(s.DB, ID)
+}
+
+// DeleteUserSession delete user session by token
+func (s *SQLStore) DeleteUserSession(tokenString string) error {
+	return model.DeleteUserSession(s.DB, tokenString)
+}
+
+// DeleteUserSessionByID delete user session owned by the user
+func (s *SQLStore) DeleteUserSessionByID(ID int, userID int) (bool, error) {
+	return model.DeleteUserSessionByID(s.DB, ID, userID)
+}
+
+// DeleteUserSessions delete all sessions of a user
+func (s *SQLStore) DeleteUserSessions(userID int) error {
+	return model.DeleteUserSessions(s.DB, userID)
+}
+
+// AuthenticateUser authenticate user by email and password,
+// with login lockout and login history
+func (s *SQLStore) AuthenticateUser(u model.User, userAgent string, IPAddress string) (
+	string, string, int, model.User, error) {
+	return model.AuthenticateUser(s.DB, s.Config, u, userAgent, IPAddress)
+}
+
+// RefreshUserSession rotate refresh token of user session
+func (s *SQLStore) RefreshUserSession(refreshTokenString string) (
+	string, string, int, error) {
+	return model.RefreshUserSession(s.DB, s.Config, refreshTokenString)
+}
+
+// CheckLoginLockout check login of an account email and source IP allowed or not
+func (s *SQLStore) CheckLoginLockout(email string, IPAddress string) (
+	int, time.Time, error) {
+	return model.CheckLoginLockout(s.DB, s.Config, email, IPAddress)
+}
+
+// ClearLoginFailures clear failed login attempts of an account email
+// and/or source IP
+func (s *SQLStore) ClearLoginFailures(email string, IPAddress string) (bool, error) {
+	return model.ClearLoginFailures(s.DB, email, IPAddress)
+}
+
+// EnrollTOTP enroll new totp secret of a user
+func (s *SQLStore) EnrollTOTP(userID int) (string, int, error) {
+	return model.EnrollTOTP(s.DB, userID)
+}
+
+// ConfirmTOTP confirm enrolled totp secret with the first totp code
+func (s *SQLStore) ConfirmTOTP(userID int, code string) ([]string, int, error) {
+	return model.ConfirmTOTP(s.DB, userID, code)
+}
+
+// AuthenticateUserMFA complete login of user with two-factor authentication
+func (s *SQLStore) AuthenticateUserMFA(mfaToken string, code string, userAgent string,
+	IPAddress string) (string, string, int, model.User, error) {
+	return model.AuthenticateUserMFA(s.DB, s.Config, mfaToken, code, userAgent, IPAddress)
+}
+
+// CreatePasswordResetToken create password reset token of a user
+func (s *SQLStore) CreatePasswordResetToken(prt model.PasswordResetToken) (
+	model.PasswordResetToken, error) {
+	return model.CreatePasswordResetToken(s.DB, s.Config, prt)
+}
+
+// ResetPassword reset user password by password reset token
+func (s *SQLStore) ResetPassword(tokenString string, newPassword string) (int, error) {
+	return model.ResetPassword(s.DB, s.Config, tokenString, newPassword)
+}
+
+// ChangePassword change password of a user
+func (s *SQLStore) ChangePassword(userID int, currentPassword string, newPassword string,
+	currentSessionID int) (int, error) {
+	return model.ChangePassword(s.DB, s.Config, userID, currentPassword, newPassword,
+		currentSessionID)
+}
+
+// CreateEmailChange create email change of a user
+func (s *SQLStore) CreateEmailChange(userID int, currentPassword string, newEmail string) (
+	model.EmailChange, int, error) {
+	return model.CreateEmailChange(s.DB, s.Config, userID, currentPassword, newEmail)
+}
+
+// ConfirmEmailChange confirm email change by token sent to the new email
+func (s *SQLStore) ConfirmEmailChange(tokenString string) (model.EmailChange, int, error) {
+	return model.ConfirmEmailChange(s.DB, s.Config, tokenString)
+}
+
+// RevertEmailChange revert confirmed email change by token sent to the old email
+func (s *SQLStore) RevertEmailChange(tokenString string) (model.EmailChange, int, error) {
+	return model.RevertEmailChange(s.DB, tokenString)
+}
+
+// CreatePhoneVerification create phone verification code of user phone number
+func (s *SQLStore) CreatePhoneVerification(userID int) (string, string, int, error) {
+	return model.CreatePhoneVerification(s.DB, s.Config, userID)
+}
+
+// VerifyPhone verify user phone number by code sent to the phone number
+func (s *SQLStore) VerifyPhone(userID int, code string) (int, error) {
+	return model.VerifyPhone(s.DB, s.Config, userID, code)
+}
+
+// IsRoleSelfRegistrable check a role can be chosen by user when register or not
+func (s *SQLStore) IsRoleSelfRegistrable(name string) (bool, error) {
+	return model.IsRoleSelfRegistrable(s.DB, name)
+}
+
+// GetRolePermissions get permissions of a role
+func (s *SQLStore) GetRolePermissions(name string) ([]string, error) {
+	return model.GetRolePermissions(s.DB, name)
+}
+
+// HasPermission check a role has a permission or not
+func (s *SQLStore) HasPermission(name string, permission string) (bool, error) {
+	return model.HasPermission(s.DB, name, permission)
+}
+
+// GetAddresses get all addresses of a user
+func (s *SQLStore) GetAddresses(userID int) ([]model.Address, error) {
+	return model.GetAddresses(s.DB, userID)
+}
+
+// GetAddress get an address of a user by ID
+func (s *SQLStore) GetAddress(ID int, userID int) (model.Address, error) {
+	return model.GetAddress(s.DB, ID, userID)
+}
+
+// CreateAddress create address of a user
+func (s *SQLStore) CreateAddress(a model.Address) (model.Address, int, error) {
+	return model.CreateAddress(s.DB, a)
+}
+
+// UpdateAddress update address of a user
+func (s *SQLStore) UpdateAddress(a model.Address) (model.Address, error) {
+	return model.UpdateAddress(s.DB, a)
+}
+
+// DeleteAddress delete address of a user
+func (s *SQLStore) DeleteAddress(ID int, userID int) error {
+	return model.DeleteAddress(s.DB, ID, userID)
+}
+
+// ScheduleUserDeletion schedule deletion of a user account after grace period
+func (s *SQLStore) ScheduleUserDeletion(userID int, password string, IPAddress string) (
+	model.User, int, error) {
+	return model.ScheduleUserDeletion(s.DB, s.Config, userID, password, IPAddress)
+}
+
+// EraseScheduledUsers erase all user accounts that already passed its deletion time
+func (s *SQLStore) EraseScheduledUsers() (int, error) {
+	return model.EraseScheduledUsers(s.DB)
+}
+
+// CreateDataExport create data export of a user
+func (s *SQLStore) CreateDataExport(userID int) (model.DataExport, bool, error) {
+	return model.CreateDataExport(s.DB, userID)
+}
+
+// GetDataExport get data export of a user by ID
+func (s *SQLStore) GetDataExport(ID int, userID int) (model.DataExport, error) {
+	return model.GetDataExport(s.DB, ID, userID)
+}
+
+// GetDataExportArchive get archive of a ready data export
+func (s *SQLStore) GetDataExportArchive(ID int) ([]byte, error) {
+	return model.GetDataExportArchive(s.DB, ID)
+}
+
+// ClaimDataExport claim the oldest pending data export to be processed
+func (s *SQLStore) ClaimDataExport() (model.DataExport, error) {
+	return model.ClaimDataExport(s.DB)
+}
+
+// CompleteDataExport complete data export with its archive
+func (s *SQLStore) CompleteDataExport(ID int, archive []byte) error {
+	return model.CompleteDataExport(s.DB, s.Config, ID, archive)
+}
+
+// FailDataExport mark data export as failed
+func (s *SQLStore) FailDataExport(ID int) error {
+	return model.FailDataExport(s.DB, ID)
+}
+
+// DeleteExpiredDataExports delete expired data exports with its archive
+func (s *SQLStore) DeleteExpiredDataExports() error {
+	return model.DeleteExpiredDataExports(s.DB)
+}
+
+// GetDataExportFiles get all personal data of a user as data export archive files
+func (s *SQLStore) GetDataExportFiles(userID int) ([]dataexport.File, error) {
+	return model.GetDataExportFiles(s.DB, userID)
+}
+
+// GetUnpublishedEvents get events not published yet, the oldest event first
+func (s *SQLStore) GetUnpublishedEvents(limit int) ([]model.Event, error) {
+	return model.GetUnpublishedEvents(s.DB, limit)
+}
+
+// MarkEventPublished mark event as published
+func (s *SQLStore) MarkEventPublished(ID int) error {
+	return model.MarkEventPublished(s.DB, ID)
+}
+
+// isDuplicateEmailError check if error is email unique constraint violation,
+// Postgres "duplicate key ... email" or SQLite "UNIQUE constraint failed: ...email"
+func isDuplicateEmailError(err error) bool {
+	message := err.Error()
+	return (strings.Contains(message, "duplicate") ||
+		strings.Contains(message, "UNIQUE constraint failed")) &&
+		strings.Contains(message, "email")
+}
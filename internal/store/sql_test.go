@@ -0,0 +1,44 @@
+/*
+Package store containing storage interfaces of the models,
+with SQL database and in-memory implementation
+*/
+package store
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestIsDuplicateEmailError test check email unique constraint violation
+func TestIsDuplicateEmailError(t *testing.T) {
+	testCases := []struct {
+		Err            error
+		ExpectedResult bool
+	}{
+		{
+			Err: errors.New(`pq: duplicate key value violates unique constraint ` +
+				`"account_user_email_key"`),
+			ExpectedResult: true,
+		},
+		{
+			Err:            errors.New(`constraint failed: UNIQUE constraint failed: account_user.email (2067)`),
+			ExpectedResult: true,
+		},
+		{
+			Err:            errors.New(`UNIQUE constraint failed: account_usersession.token`),
+			ExpectedResult: false,
+		},
+		{
+			Err:            errors.New(`connection refused`),
+			ExpectedResult: false,
+		},
+	}
+
+	for _, testCase := range testCases {
+		result := isDuplicateEmailError(testCase.Err)
+		if result != testCase.ExpectedResult {
+			t.Errorf("Expected result %t for %q, but got %t",
+				testCase.ExpectedResult, testCase.Err.Error(), result)
+		}
+	}
+}
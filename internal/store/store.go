@@ -0,0 +1,273 @@
+/*
+Package store containing storage interfaces of the models,
+with SQL database and in-memory implementation
+*/
+package store
+
+import (
+	"errors"
+	"time"
+
+	"github.com/reyhanfikridz/ecom-account-service/internal/dataexport"
+	"github.com/reyhanfikridz/ecom-account-service/internal/model"
+)
+
+// ErrDuplicateEmail email already used by another user
+var ErrDuplicateEmail = errors.New("email already used by another user")
+
+// UserStore storage of users
+//
+// User not exist returned as sql.ErrNoRows, the same as model package
+type UserStore interface {
+	// create exactly one new user with hashed password,
+	// return ErrDuplicateEmail if the email already used
+	CreateUser(u model.User) (model.User, error)
+
+	// get user by email, or by ID if ID not 0
+	GetUser(email string, ID int) (model.User, error)
+
+	// partial update user profile if the version still the same,
+	// return status 409 if version changed or status 400 if user not exist
+	UpdateUserProfile(userID int, version int, p model.UserProfileUpdate) (
+		model.User, int, error)
+
+	// mark email verification sent to user, return false
+	// if already sent within resend interval
+	MarkEmailVerificationSent(userID int) (bool, error)
+
+	// verify user email if still the same as the email when verification
+	// link sent, return status 400 if user not exist or email already changed
+	VerifyUserEmail(userID int, email string) (int, error)
+}
+
+// AdminUserStore management of users by admin
+type AdminUserStore interface {
+	// get a page of users by filter with cursor of the next page,
+	// return status 400 if sort key or cursor not valid
+	GetUsers(f model.UserListFilter) ([]model.User, string, int, error)
+
+	// set role of a user, return status 400 if user not exist
+	// or status 422 if role not exist
+	SetUserRole(userID int, role string) (int, error)
+
+	// set status of a user, the status the same as model.SetUserStatus
+	SetUserStatus(userID int, status string) (int, error)
+
+	// suspend a user with its reason, permanent if until is nil,
+	// the status the same as model.SuspendUser
+	SuspendUser(userID int, reason string, until *time.Time) (int, error)
+}
+
+// AuthStore login of users and refresh of their sessions
+type AuthStore interface {
+	// authenticate user by email and password, return access token
+	// and refresh token of the new user session, the status the same
+	// as model.AuthenticateUser
+	AuthenticateUser(u model.User, userAgent string, IPAddress string) (
+		string, string, int, model.User, error)
+
+	// rotate refresh token, return new access token and new refresh token,
+	// return status 400 if refresh token not valid, expired, or reused
+	RefreshUserSession(refreshTokenString string) (string, string, int, error)
+
+	// check login of an account email and source IP allowed or not,
+	// also return time until login allowed again, the status the same
+	// as model.CheckLoginLockout
+	CheckLoginLockout(email string, IPAddress string) (int, time.Time, error)
+
+	// clear failed login attempts of an account email and/or source IP,
+	// return false if there's no login failure cleared
+	ClearLoginFailures(email string, IPAddress string) (bool, error)
+}
+
+// MFAStore two-factor authentication of users
+type MFAStore interface {
+	// enroll new totp secret of a user, return status 400
+	// if two-factor authentication already enabled
+	EnrollTOTP(userID int) (string, int, error)
+
+	// confirm enrolled totp secret with the first totp code, return
+	// new recovery codes, the status the same as model.ConfirmTOTP
+	ConfirmTOTP(userID int, code string) ([]string, int, error)
+
+	// complete login of user with two-factor authentication,
+	// the status the same as model.AuthenticateUserMFA
+	AuthenticateUserMFA(mfaToken string, code string, userAgent string,
+		IPAddress string) (string, string, int, model.User, error)
+}
+
+// PasswordStore password reset and password change of users,
+// with password history
+type PasswordStore interface {
+	// create password reset token of a user,
+	// previous password reset token of the user deleted
+	CreatePasswordResetToken(prt model.PasswordResetToken) (
+		model.PasswordResetToken, error)
+
+	// reset user password by password reset token,
+	// the status the same as model.ResetPassword
+	ResetPassword(tokenString string, newPassword string) (int, error)
+
+	// change password of a user, other sessions of the user revoked,
+	// the status the same as model.ChangePassword
+	ChangePassword(userID int, currentPassword string, newPassword string,
+		currentSessionID int) (int, error)
+}
+
+// EmailChangeStore change of user emails
+type EmailChangeStore interface {
+	// create email change of a user, the status the same
+	// as model.CreateEmailChange
+	CreateEmailChange(userID int, currentPassword string, newEmail string) (
+		model.EmailChange, int, error)
+
+	// confirm email change by token sent to the new email,
+	// the status the same as model.ConfirmEmailChange
+	ConfirmEmailChange(tokenString string) (model.EmailChange, int, error)
+
+	// revert confirmed email change by token sent to the old email,
+	// the status the same as model.RevertEmailChange
+	RevertEmailChange(tokenString string) (model.EmailChange, int, error)
+}
+
+// PhoneVerificationStore verification of user phone numbers
+type PhoneVerificationStore interface {
+	// create phone verification code of user phone number, return the code
+	// and the normalized phone number, the status the same
+	// as model.CreatePhoneVerification
+	CreatePhoneVerification(userID int) (string, string, int, error)
+
+	// verify user phone number by code sent to the phone number,
+	// the status the same as model.VerifyPhone
+	VerifyPhone(userID int, code string) (int, error)
+}
+
+// AccountDeletionStore scheduled deletion of user accounts
+type AccountDeletionStore interface {
+	// schedule deletion of a user account after grace period,
+	// the status the same as model.ScheduleUserDeletion
+	ScheduleUserDeletion(userID int, password string, IPAddress string) (
+		model.User, int, error)
+
+	// erase all user accounts that already passed its deletion time,
+	// return number of erased user accounts
+	EraseScheduledUsers() (int, error)
+}
+
+// DataExportStore personal data exports of users
+//
+// Data export not exist returned as sql.ErrNoRows, the same as model package
+type DataExportStore interface {
+	// create data export of a user, return data export in progress
+	// of the user if exist (return false)
+	CreateDataExport(userID int) (model.DataExport, bool, error)
+
+	// get data export of a user by ID
+	GetDataExport(ID int, userID int) (model.DataExport, error)
+
+	// get archive of a ready data export
+	GetDataExportArchive(ID int) ([]byte, error)
+
+	// claim the oldest pending data export to be processed
+	ClaimDataExport() (model.DataExport, error)
+
+	// complete data export with its archive
+	CompleteDataExport(ID int, archive []byte) error
+
+	// mark data export as failed
+	FailDataExport(ID int) error
+
+	// delete expired data exports with its archive
+	DeleteExpiredDataExports() error
+
+	// get all personal data of a user as data export archive files
+	GetDataExportFiles(userID int) ([]dataexport.File, error)
+}
+
+// EventStore events saved with the change that caused it (outbox)
+type EventStore interface {
+	// get events not published yet, the oldest event first
+	GetUnpublishedEvents(limit int) ([]model.Event, error)
+
+	// mark event as published
+	MarkEventPublished(ID int) error
+}
+
+// RoleStore storage of roles and their permissions
+type RoleStore interface {
+	// check a role can be chosen by user when register or not
+	IsRoleSelfRegistrable(name string) (bool, error)
+
+	// get permissions of a role, role that not exist has no permission
+	GetRolePermissions(name string) ([]string, error)
+
+	// check a role has a permission or not
+	HasPermission(name string, permission string) (bool, error)
+}
+
+// AddressStore storage of user address books
+//
+// Address not exist returned as sql.ErrNoRows, the same as model package
+type AddressStore interface {
+	// get all addresses of a user, default addresses first then newest first
+	GetAddresses(userID int) ([]model.Address, error)
+
+	// get an address of a user by ID
+	GetAddress(ID int, userID int) (model.Address, error)
+
+	// create address of a user, the first address become default address,
+	// return status 400 if the user already has maximum number of addresses
+	CreateAddress(a model.Address) (model.Address, int, error)
+
+	// update address of a user, all fields replaced
+	UpdateAddress(a model.Address) (model.Address, error)
+
+	// delete address of a user, the newest remaining address
+	// become default address if the deleted address was default
+	DeleteAddress(ID int, userID int) error
+}
+
+// SessionStore storage of user sessions
+//
+// User session not exist returned as sql.ErrNoRows, the same as model package
+type SessionStore interface {
+	// create user session of an existing user
+	CreateUserSession(us model.UserSession) (model.UserSession, error)
+
+	// get user session with its user by token and user ID
+	GetUserSession(tokenString string, userID int) (model.UserSession, error)
+
+	// get all sessions of a user newest activity first,
+	// without token and user data
+	GetUserSessions(userID int) ([]model.UserSession, error)
+
+	// update user session last seen time to now
+	UpdateUserSessionLastSeen(ID int) error
+
+	// delete user session by token
+	DeleteUserSession(tokenString string) error
+
+	// delete user session owned by the user, return false if nothing deleted
+	DeleteUserSessionByID(ID int, userID int) (bool, error)
+
+	// delete all sessions of a user
+	DeleteUserSessions(userID int) error
+}
+
+// Store storage of all models used through store,
+// storage of another table added here as its own interface
+type Store interface {
+	UserStore
+	AdminUserStore
+	SessionStore
+	AuthStore
+	MFAStore
+	PasswordStore
+	EmailChangeStore
+	PhoneVerificationStore
+	RoleStore
+	AddressStore
+	AccountDeletionStore
+	DataExportStore
+	EventStore
+}
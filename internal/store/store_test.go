@@ -0,0 +1,79 @@
+/*
+Package store containing storage interfaces of the models,
+with SQL database and in-memory implementation
+*/
+package store_test
+
+import (
+	"database/sql"
+	"fmt"
+	"testing"
+
+	"github.com/reyhanfikridz/ecom-account-service/internal/config"
+	"github.com/reyhanfikridz/ecom-account-service/internal/migration"
+	"github.com/reyhanfikridz/ecom-account-service/internal/sqlite"
+	"github.com/reyhanfikridz/ecom-account-service/internal/store"
+	"github.com/reyhanfikridz/ecom-account-service/internal/store/storetest"
+)
+
+// TestSQLStore test SQLStore pass store conformance testing
+func TestSQLStore(t *testing.T) {
+	// get connection to testing DB
+	DB, err := getTestDBConnection()
+	if err != nil {
+		t.Fatalf("Connection to testing DB failed => " + err.Error())
+	}
+	defer DB.Close()
+
+	storetest.Run(t, func(t *testing.T) store.Store {
+		// delete testing users and failed logins of previous testing
+		for _, email := range storetest.Emails {
+			_, err := DB.Exec(`DELETE FROM account_user WHERE email = $1`, email)
+			if err != nil {
+				t.Errorf("There's an error when deleting store testing data " + err.Error())
+			}
+		}
+		_, err := DB.Exec(`DELETE FROM account_loginfailure`)
+		if err != nil {
+			t.Errorf("There's an error when deleting store testing data " + err.Error())
+		}
+
+		return store.NewSQLStore(DB, testConfig)
+	})
+}
+
+// getTestDBConnection get connection to testing database
+// with all migrations applied
+func getTestDBConnection() (*sql.DB, error) {
+	var DB *sql.DB
+	var err error
+	if testConfig.DBDriver == config.DBDriverSQLite {
+		// Open db file, created if not exist
+		DB, err = sqlite.Open(testConfig.DBTestPath)
+	} else {
+		// Connect to db
+		connString := fmt.Sprintf("host='%s' port='%s' user='%s' password='%s' dbname='%s' "+
+			"sslmode='%s' sslrootcert='%s' sslcert='%s' sslkey='%s'",
+			testConfig.DBHost, testConfig.DBPort, testConfig.DBUsername, testConfig.DBPassword,
+			testConfig.DBTestName, testConfig.DBSSLMode, testConfig.DBSSLRootCert, testConfig.DBSSLCert,
+			testConfig.DBSSLKey)
+
+		DB, err = sql.Open("postgres", connString)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Create or update tables by migrations
+	migrator, err := migration.New(DB, testConfig.DBDriver, testConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = migrator.Up()
+	if err != nil {
+		return nil, err
+	}
+
+	return DB, nil
+}
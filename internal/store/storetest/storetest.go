@@ -0,0 +1,1146 @@
+/*
+Package storetest conformance testing of store implementation,
+every store implementation need to pass the same testing
+*/
+package storetest
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/reyhanfikridz/ecom-account-service/internal/dataexport"
+	"github.com/reyhanfikridz/ecom-account-service/internal/model"
+	"github.com/reyhanfikridz/ecom-account-service/internal/store"
+	"github.com/reyhanfikridz/ecom-account-service/internal/utils"
+)
+
+// Emails emails of testing users, store given to Run
+// must not have users with these emails
+var Emails = []string{"teststore1@gmail.com", "teststore2@gmail.com", "teststore3@gmail.com"}
+
+// Run run all conformance testing of a store,
+// newStore called by every testing to get store without the testing users
+// and without failed login attempts
+//
+// The store config must have no delay after failed login (login backoff base 0),
+// other config can be the default config.
+func Run(t *testing.T, newStore func(t *testing.T) store.Store) {
+	t.Run("User", func(t *testing.T) {
+		testUser(t, newStore(t))
+	})
+	t.Run("UpdateUserProfile", func(t *testing.T) {
+		testUpdateUserProfile(t, newStore(t))
+	})
+	t.Run("UserSession", func(t *testing.T) {
+		testUserSession(t, newStore(t))
+	})
+	t.Run("UserSessions", func(t *testing.T) {
+		testUserSessions(t, newStore(t))
+	})
+	t.Run("MarkEmailVerificationSent", func(t *testing.T) {
+		testMarkEmailVerificationSent(t, newStore(t))
+	})
+	t.Run("AuthenticateUser", func(t *testing.T) {
+		testAuthenticateUser(t, newStore(t))
+	})
+	t.Run("LoginLockout", func(t *testing.T) {
+		testLoginLockout(t, newStore(t))
+	})
+	t.Run("VerifyUserEmail", func(t *testing.T) {
+		testVerifyUserEmail(t, newStore(t))
+	})
+	t.Run("AdminUser", func(t *testing.T) {
+		testAdminUser(t, newStore(t))
+	})
+	t.Run("MFA", func(t *testing.T) {
+		testMFA(t, newStore(t))
+	})
+	t.Run("Password", func(t *testing.T) {
+		testPassword(t, newStore(t))
+	})
+	t.Run("EmailChange", func(t *testing.T) {
+		testEmailChange(t, newStore(t))
+	})
+	t.Run("PhoneVerification", func(t *testing.T) {
+		testPhoneVerification(t, newStore(t))
+	})
+	t.Run("Address", func(t *testing.T) {
+		testAddress(t, newStore(t))
+	})
+	t.Run("AccountDeletion", func(t *testing.T) {
+		testAccountDeletion(t, newStore(t))
+	})
+	t.Run("DataExport", func(t *testing.T) {
+		testDataExport(t, newStore(t))
+	})
+	t.Run("Role", func(t *testing.T) {
+		testRole(t, newStore(t))
+	})
+}
+
+// createTestingUser create testing user with email
+func createTestingUser(t *testing.T, s store.Store, email string) model.User {
+	u, err := s.CreateUser(model.User{
+		Email:       email,
+		Password:    "testpassword1",
+		FullName:    "test",
+		Address:     "test",
+		PhoneNumber: "+6281111111111",
+		Role:        "buyer",
+	})
+	if err != nil {
+		t.Fatalf("There's an error when creating testing user => " + err.Error())
+	}
+
+	return u
+}
+
+// testUser test CreateUser and GetUser
+func testUser(t *testing.T, s store.Store) {
+	u := createTestingUser(t, s, Emails[0])
+	if u.ID == 0 || u.Status != model.UserStatusPendingVerification || u.Version != 1 {
+		t.Errorf("Expected created user has ID, pending verification status, and version 1,"+
+			" but got %+v", u)
+	}
+
+	// duplicate email
+	_, err := s.CreateUser(model.User{
+		Email:    Emails[0],
+		Password: "testpassword1",
+		Role:     "buyer",
+	})
+	if err != store.ErrDuplicateEmail {
+		t.Errorf("Expected error ErrDuplicateEmail, but got %v", err)
+	}
+
+	// get user by email and by ID
+	for _, getUser := range []func() (model.User, error){
+		func() (model.User, error) { return s.GetUser(Emails[0], 0) },
+		func() (model.User, error) { return s.GetUser("", u.ID) },
+	} {
+		user, err := getUser()
+		if err != nil {
+			t.Fatalf("Expected error nil, but got error " + err.Error())
+		}
+
+		if user.ID != u.ID || user.Email != u.Email || user.FullName != "test" ||
+			user.PhoneNumber != "+6281111111111" || user.Role != "buyer" ||
+			user.Status != model.UserStatusPendingVerification || user.Version != 1 {
+			t.Errorf("Expected user the same as created user, but got %+v", user)
+		}
+
+		// password saved hashed, address only kept by the address book
+		if user.Password == "testpassword1" ||
+			utils.ComparePassword(user.Password, "testpassword1") != nil {
+			t.Errorf("Expected password saved hashed, but got '" + user.Password + "'")
+		}
+		if user.Address != "" {
+			t.Errorf("Expected address empty, but got '" + user.Address + "'")
+		}
+	}
+
+	// address given when register become the first address book entry
+	addresses, err := s.GetAddresses(u.ID)
+	if err != nil {
+		t.Fatalf("Expected error nil, but got error " + err.Error())
+	}
+	if len(addresses) != 1 || addresses[0].UserID != u.ID || addresses[0].Line1 != "test" ||
+		addresses[0].RecipientName != "test" || addresses[0].PhoneNumber != "+6281111111111" ||
+		!addresses[0].IsDefaultShipping || !addresses[0].IsDefaultBilling {
+		t.Errorf("Expected 1 default address from register address, but got %+v", addresses)
+	}
+
+	// user not exist
+	_, err = s.GetUser(Emails[1], 0)
+	if err != sql.ErrNoRows {
+		t.Errorf("Expected error sql.ErrNoRows, but got %v", err)
+	}
+	_, err = s.GetUser("", u.ID+1000000)
+	if err != sql.ErrNoRows {
+		t.Errorf("Expected error sql.ErrNoRows, but got %v", err)
+	}
+}
+
+// testUpdateUserProfile test UpdateUserProfile
+func testUpdateUserProfile(t *testing.T, s store.Store) {
+	u := createTestingUser(t, s, Emails[0])
+	newFullName := "new test"
+	newPhoneNumber := "+6282222222222"
+
+	// update full name only
+	user, status, err := s.UpdateUserProfile(u.ID, 1, model.UserProfileUpdate{
+		FullName: &newFullName,
+	})
+	if status != 200 || err != nil {
+		t.Fatalf("Expected status 200 and error nil, but got status %d error %v", status, err)
+	}
+	if user.FullName != newFullName || user.PhoneNumber != u.PhoneNumber || user.Version != 2 {
+		t.Errorf("Expected full name updated and version 2, but got %+v", user)
+	}
+
+	// update with old version
+	user, status, err = s.UpdateUserProfile(u.ID, 1, model.UserProfileUpdate{
+		PhoneNumber: &newPhoneNumber,
+	})
+	if status != 409 || err != nil {
+		t.Errorf("Expected status 409 and error nil, but got status %d error %v", status, err)
+	}
+	if user.PhoneNumber != u.PhoneNumber || user.Version != 2 {
+		t.Errorf("Expected the current user not updated, but got %+v", user)
+	}
+
+	// update with current version
+	user, status, err = s.UpdateUserProfile(u.ID, 2, model.UserProfileUpdate{
+		PhoneNumber: &newPhoneNumber,
+	})
+	if status != 200 || err != nil {
+		t.Errorf("Expected status 200 and error nil, but got status %d error %v", status, err)
+	}
+	if user.FullName != newFullName || user.PhoneNumber != newPhoneNumber ||
+		user.PhoneVerifiedAt != nil || user.Version != 3 {
+		t.Errorf("Expected phone number updated and version 3, but got %+v", user)
+	}
+
+	// user not exist
+	_, status, err = s.UpdateUserProfile(u.ID+1000000, 1, model.UserProfileUpdate{
+		FullName: &newFullName,
+	})
+	if status != 400 || err != nil {
+		t.Errorf("Expected status 400 and error nil, but got status %d error %v", status, err)
+	}
+}
+
+// testUserSession test CreateUserSession, GetUserSession, and DeleteUserSession
+func testUserSession(t *testing.T, s store.Store) {
+	u := createTestingUser(t, s, Emails[0])
+	anotherUser := createTestingUser(t, s, Emails[1])
+
+	// user agent cut to 255 characters
+	userSession, err := s.CreateUserSession(model.UserSession{
+		Token:     "teststore-token-1",
+		User:      u,
+		UserAgent: strings.Repeat("a", 300),
+		IPAddress: "127.0.0.1",
+	})
+	if err != nil {
+		t.Fatalf("Expected error nil, but got error " + err.Error())
+	}
+	if userSession.ID == 0 || len(userSession.UserAgent) != 255 ||
+		userSession.CreatedAt.IsZero() || !userSession.LastSeenAt.Equal(userSession.CreatedAt) {
+		t.Errorf("Expected created user session has ID, user agent cut,"+
+			" and last seen time, but got %+v", userSession)
+	}
+
+	// session of user not exist
+	_, err = s.CreateUserSession(model.UserSession{
+		Token: "teststore-token-2",
+		User:  model.User{ID: u.ID + 1000000},
+	})
+	if err == nil {
+		t.Errorf("Expected error creating session of user not exist, but got nil")
+	}
+
+	// get user session with its user
+	got, err := s.GetUserSession("teststore-token-1", u.ID)
+	if err != nil {
+		t.Fatalf("Expected error nil, but got error " + err.Error())
+	}
+	if got.ID != userSession.ID || got.Token != "teststore-token-1" ||
+		got.User.ID != u.ID || got.User.Email != u.Email || got.IPAddress != "127.0.0.1" {
+		t.Errorf("Expected user session the same as created user session, but got %+v", got)
+	}
+
+	// token owned by another user
+	_, err = s.GetUserSession("teststore-token-1", anotherUser.ID)
+	if err != sql.ErrNoRows {
+		t.Errorf("Expected error sql.ErrNoRows, but got %v", err)
+	}
+
+	// delete user session by token
+	err = s.DeleteUserSession("teststore-token-1")
+	if err != nil {
+		t.Errorf("Expected error nil, but got error " + err.Error())
+	}
+	_, err = s.GetUserSession("teststore-token-1", u.ID)
+	if err != sql.ErrNoRows {
+		t.Errorf("Expected error sql.ErrNoRows, but got %v", err)
+	}
+}
+
+// testUserSessions test GetUserSessions, UpdateUserSessionLastSeen,
+// DeleteUserSessionByID, and DeleteUserSessions
+func testUserSessions(t *testing.T, s store.Store) {
+	u := createTestingUser(t, s, Emails[0])
+	anotherUser := createTestingUser(t, s, Emails[1])
+
+	userSessionIDs := []int{}
+	for _, token := range []string{"teststore-token-1", "teststore-token-2"} {
+		userSession, err := s.CreateUserSession(model.UserSession{Token: token, User: u})
+		if err != nil {
+			t.Fatalf("Expected error nil, but got error " + err.Error())
+		}
+		userSessionIDs = append(userSessionIDs, userSession.ID)
+	}
+	_, err := s.CreateUserSession(model.UserSession{Token: "teststore-token-3", User: anotherUser})
+	if err != nil {
+		t.Fatalf("Expected error nil, but got error " + err.Error())
+	}
+
+	// newest first, without token
+	userSessions, err := s.GetUserSessions(u.ID)
+	if err != nil {
+		t.Fatalf("Expected error nil, but got error " + err.Error())
+	}
+	if len(userSessions) != 2 || userSessions[0].ID != userSessionIDs[1] ||
+		userSessions[0].Token != "" || userSessions[0].User.ID != u.ID {
+		t.Errorf("Expected 2 user sessions newest first without token, but got %+v",
+			userSessions)
+	}
+
+	// the first session become the newest activity
+	err = s.UpdateUserSessionLastSeen(userSessionIDs[0])
+	if err != nil {
+		t.Fatalf("Expected error nil, but got error " + err.Error())
+	}
+	userSessions, err = s.GetUserSessions(u.ID)
+	if err != nil {
+		t.Fatalf("Expected error nil, but got error " + err.Error())
+	}
+	if len(userSessions) != 2 || userSessions[0].ID != userSessionIDs[0] {
+		t.Errorf("Expected user session %d first, but got %+v", userSessionIDs[0], userSessions)
+	}
+
+	// session owned by another user not deleted
+	isDeleted, err := s.DeleteUserSessionByID(userSessionIDs[0], anotherUser.ID)
+	if isDeleted || err != nil {
+		t.Errorf("Expected session not deleted and error nil, but got %t error %v",
+			isDeleted, err)
+	}
+	isDeleted, err = s.DeleteUserSessionByID(userSessionIDs[0], u.ID)
+	if !isDeleted || err != nil {
+		t.Errorf("Expected session deleted and error nil, but got %t error %v",
+			isDeleted, err)
+	}
+
+	// delete all sessions of the user, sessions of another user kept
+	err = s.DeleteUserSessions(u.ID)
+	if err != nil {
+		t.Fatalf("Expected error nil, but got error " + err.Error())
+	}
+	userSessions, err = s.GetUserSessions(u.ID)
+	if err != nil || len(userSessions) != 0 {
+		t.Errorf("Expected no user sessions and error nil, but got %d error %v",
+			len(userSessions), err)
+	}
+	userSessions, err = s.GetUserSessions(anotherUser.ID)
+	if err != nil || len(userSessions) != 1 {
+		t.Errorf("Expected 1 user session and error nil, but got %d error %v",
+			len(userSessions), err)
+	}
+}
+
+// testMarkEmailVerificationSent test MarkEmailVerificationSent
+func testMarkEmailVerificationSent(t *testing.T, s store.Store) {
+	u := createTestingUser(t, s, Emails[0])
+
+	// only marked once in resend interval
+	for _, expectedIsMarked := range []bool{true, false} {
+		isMarked, err := s.MarkEmailVerificationSent(u.ID)
+		if isMarked != expectedIsMarked || err != nil {
+			t.Errorf("Expected marked %t and error nil, but got %t error %v",
+				expectedIsMarked, isMarked, err)
+		}
+	}
+
+	// user not exist
+	isMarked, err := s.MarkEmailVerificationSent(u.ID + 1000000)
+	if isMarked || err != nil {
+		t.Errorf("Expected not marked and error nil, but got %t error %v", isMarked, err)
+	}
+}
+
+// testAuthenticateUser test AuthenticateUser and RefreshUserSession
+func testAuthenticateUser(t *testing.T, s store.Store) {
+	u := createTestingUser(t, s, Emails[0])
+
+	// wrong password and user not exist
+	for _, loginUser := range []model.User{
+		{Email: Emails[0], Password: "wrongpassword1"},
+		{Email: Emails[1], Password: "testpassword1"},
+	} {
+		_, _, status, _, err := s.AuthenticateUser(loginUser, "test-agent", "192.0.2.1")
+		if status != 400 || err != nil {
+			t.Errorf("Expected status 400 and error nil, but got status %d error %v",
+				status, err)
+		}
+	}
+
+	// login create user session with its tokens
+	token, refreshToken, status, user, err := s.AuthenticateUser(model.User{
+		Email:    Emails[0],
+		Password: "testpassword1",
+	}, "test-agent", "192.0.2.1")
+	if status != 200 || err != nil {
+		t.Fatalf("Expected status 200 and error nil, but got status %d error %v", status, err)
+	}
+	if token == "" || refreshToken == "" || user.ID != u.ID {
+		t.Errorf("Expected tokens of the user, but got user %+v", user)
+	}
+	userSession, err := s.GetUserSession(token, u.ID)
+	if err != nil || userSession.UserAgent != "test-agent" {
+		t.Errorf("Expected user session of the token, but got %+v error %v", userSession, err)
+	}
+
+	// refresh token rotated
+	newToken, newRefreshToken, status, err := s.RefreshUserSession(refreshToken)
+	if status != 200 || err != nil {
+		t.Fatalf("Expected status 200 and error nil, but got status %d error %v", status, err)
+	}
+	if newRefreshToken == "" || newRefreshToken == refreshToken {
+		t.Errorf("Expected new refresh token, but got the same refresh token")
+	}
+	_, err = s.GetUserSession(newToken, u.ID)
+	if err != nil {
+		t.Errorf("Expected user session of the new token, but got error %v", err)
+	}
+
+	// refresh token not valid
+	_, _, status, err = s.RefreshUserSession("notvalid")
+	if status != 400 || err != nil {
+		t.Errorf("Expected status 400 and error nil, but got status %d error %v", status, err)
+	}
+
+	// reused refresh token revoke the user session
+	_, _, status, err = s.RefreshUserSession(refreshToken)
+	if status != 400 || err != nil {
+		t.Errorf("Expected status 400 and error nil, but got status %d error %v", status, err)
+	}
+	_, err = s.GetUserSession(newToken, u.ID)
+	if err != sql.ErrNoRows {
+		t.Errorf("Expected error sql.ErrNoRows, but got %v", err)
+	}
+	_, _, status, err = s.RefreshUserSession(newRefreshToken)
+	if status != 400 || err != nil {
+		t.Errorf("Expected status 400 and error nil, but got status %d error %v", status, err)
+	}
+}
+
+// login login testing user with its password, return status, tokens, and user
+func login(t *testing.T, s store.Store, email string, password string) (
+	string, string, int, model.User) {
+	token, refreshToken, status, user, err := s.AuthenticateUser(model.User{
+		Email:    email,
+		Password: password,
+	}, "test-agent", "192.0.2.1")
+	if err != nil {
+		t.Fatalf("There's an error when authenticating user => " + err.Error())
+	}
+
+	return token, refreshToken, status, user
+}
+
+// testLoginLockout test failed login locking out the account,
+// CheckLoginLockout, and ClearLoginFailures
+func testLoginLockout(t *testing.T, s store.Store) {
+	createTestingUser(t, s, Emails[0])
+
+	// failed login recorded until the account locked
+	for i := 0; i < 5; i++ {
+		_, _, status, _ := login(t, s, Emails[0], "wrongpassword1")
+		if status != 400 {
+			t.Errorf("Expected status 400 of failed login %d, but got %d", i+1, status)
+		}
+	}
+
+	_, _, status, _ := login(t, s, Emails[0], "testpassword1")
+	if status != 423 {
+		t.Errorf("Expected status 423 of locked account, but got %d", status)
+	}
+
+	status, lockedUntil, err := s.CheckLoginLockout(Emails[0], "")
+	if status != 423 || !lockedUntil.After(time.Now().UTC()) || err != nil {
+		t.Errorf("Expected status 423 locked until later and error nil,"+
+			" but got status %d until %s error %v", status, lockedUntil, err)
+	}
+
+	// failed login of the IP address also recorded, but not locked yet
+	status, _, err = s.CheckLoginLockout("", "192.0.2.1")
+	if status != 200 || err != nil {
+		t.Errorf("Expected status 200 and error nil, but got status %d error %v", status, err)
+	}
+
+	// login allowed again after cleared
+	isCleared, err := s.ClearLoginFailures(Emails[0], "")
+	if !isCleared || err != nil {
+		t.Errorf("Expected cleared and error nil, but got %t error %v", isCleared, err)
+	}
+
+	_, _, status, _ = login(t, s, Emails[0], "testpassword1")
+	if status != 200 {
+		t.Errorf("Expected status 200 of login after cleared, but got %d", status)
+	}
+
+	// succeeded login only clear failures of the account, not of the IP address
+	isCleared, err = s.ClearLoginFailures(Emails[0], "")
+	if isCleared || err != nil {
+		t.Errorf("Expected not cleared and error nil, but got %t error %v", isCleared, err)
+	}
+	isCleared, err = s.ClearLoginFailures("", "192.0.2.1")
+	if !isCleared || err != nil {
+		t.Errorf("Expected cleared and error nil, but got %t error %v", isCleared, err)
+	}
+}
+
+// testVerifyUserEmail test VerifyUserEmail
+func testVerifyUserEmail(t *testing.T, s store.Store) {
+	u := createTestingUser(t, s, Emails[0])
+
+	// email already changed and user not exist
+	for _, test := range []struct {
+		UserID int
+		Email  string
+	}{
+		{UserID: u.ID, Email: Emails[1]},
+		{UserID: u.ID + 1000000, Email: Emails[0]},
+	} {
+		status, err := s.VerifyUserEmail(test.UserID, test.Email)
+		if status != 400 || err != nil {
+			t.Errorf("Expected status 400 and error nil, but got status %d error %v",
+				status, err)
+		}
+	}
+
+	// user pending verification become active, verified time kept
+	var emailVerifiedAt *time.Time
+	for i := 0; i < 2; i++ {
+		status, err := s.VerifyUserEmail(u.ID, Emails[0])
+		if status != 200 || err != nil {
+			t.Fatalf("Expected status 200 and error nil, but got status %d error %v",
+				status, err)
+		}
+
+		user, err := s.GetUser("", u.ID)
+		if err != nil || user.EmailVerifiedAt == nil ||
+			user.Status != model.UserStatusActive ||
+			(emailVerifiedAt != nil && !user.EmailVerifiedAt.Equal(*emailVerifiedAt)) {
+			t.Errorf("Expected user active with the first verified time, but got %+v", user)
+		}
+		emailVerifiedAt = user.EmailVerifiedAt
+	}
+}
+
+// testAdminUser test GetUsers, SetUserRole, SuspendUser, and SetUserStatus
+func testAdminUser(t *testing.T, s store.Store) {
+	u := createTestingUser(t, s, Emails[0])
+	anotherUser := createTestingUser(t, s, Emails[1])
+
+	// user list sorted by email one user per page
+	f := model.UserListFilter{Query: "TESTSTORE", Sort: "email", Limit: 1}
+	for _, expectedUserID := range []int{u.ID, anotherUser.ID} {
+		users, nextCursor, status, err := s.GetUsers(f)
+		if status != 200 || err != nil {
+			t.Fatalf("Expected status 200 and error nil, but got status %d error %v",
+				status, err)
+		}
+		if len(users) != 1 || users[0].ID != expectedUserID {
+			t.Errorf("Expected user %d, but got %+v", expectedUserID, users)
+		}
+
+		f.Cursor = nextCursor
+	}
+	if f.Cursor != "" {
+		users, _, status, err := s.GetUsers(f)
+		if status != 200 || err != nil || len(users) != 0 {
+			t.Errorf("Expected no more users, but got %+v status %d error %v",
+				users, status, err)
+		}
+	}
+
+	// sort key and cursor not valid
+	for _, f := range []model.UserListFilter{
+		{Sort: "password"},
+		{Sort: "email", Cursor: "notvalid"},
+	} {
+		_, _, status, err := s.GetUsers(f)
+		if status != 400 || err != nil {
+			t.Errorf("Expected status 400 and error nil, but got status %d error %v",
+				status, err)
+		}
+	}
+
+	// set role
+	for _, test := range []struct {
+		UserID         int
+		Role           string
+		ExpectedStatus int
+	}{
+		{UserID: u.ID, Role: "notexist", ExpectedStatus: 422},
+		{UserID: u.ID + 1000000, Role: "seller", ExpectedStatus: 400},
+		{UserID: u.ID, Role: "seller", ExpectedStatus: 200},
+	} {
+		status, err := s.SetUserRole(test.UserID, test.Role)
+		if status != test.ExpectedStatus || err != nil {
+			t.Errorf("Expected status %d and error nil, but got status %d error %v",
+				test.ExpectedStatus, status, err)
+		}
+	}
+
+	// suspended user sessions revoked and can't login
+	token, _, status, _ := login(t, s, Emails[0], "testpassword1")
+	if status != 200 {
+		t.Fatalf("Expected status 200, but got %d", status)
+	}
+
+	status, err := s.SuspendUser(u.ID, "test", nil)
+	if status != 200 || err != nil {
+		t.Fatalf("Expected status 200 and error nil, but got status %d error %v", status, err)
+	}
+	user, err := s.GetUser("", u.ID)
+	if err != nil || user.Role != "seller" || user.Status != model.UserStatusSuspended ||
+		user.SuspendedReason == nil || *user.SuspendedReason != "test" {
+		t.Errorf("Expected suspended seller, but got %+v", user)
+	}
+	_, err = s.GetUserSession(token, u.ID)
+	if err != sql.ErrNoRows {
+		t.Errorf("Expected error sql.ErrNoRows, but got %v", err)
+	}
+	_, _, status, _ = login(t, s, Emails[0], "testpassword1")
+	if status != 403 {
+		t.Errorf("Expected status 403, but got %d", status)
+	}
+
+	// set status, active user can't be set to active again
+	for _, test := range []struct {
+		UserID         int
+		ExpectedStatus int
+	}{
+		{UserID: u.ID, ExpectedStatus: 200},
+		{UserID: u.ID, ExpectedStatus: 409},
+		{UserID: u.ID + 1000000, ExpectedStatus: 400},
+	} {
+		status, err := s.SetUserStatus(test.UserID, model.UserStatusActive)
+		if status != test.ExpectedStatus || err != nil {
+			t.Errorf("Expected status %d and error nil, but got status %d error %v",
+				test.ExpectedStatus, status, err)
+		}
+	}
+
+	user, err = s.GetUser("", u.ID)
+	if err != nil || user.Status != model.UserStatusActive || user.SuspendedReason != nil {
+		t.Errorf("Expected active user without suspension, but got %+v", user)
+	}
+}
+
+// testMFA test EnrollTOTP, ConfirmTOTP, and AuthenticateUserMFA
+func testMFA(t *testing.T, s store.Store) {
+	u := createTestingUser(t, s, Emails[0])
+
+	secret, status, err := s.EnrollTOTP(u.ID)
+	if status != 200 || err != nil || secret == "" {
+		t.Fatalf("Expected status 200 and error nil, but got status %d error %v", status, err)
+	}
+
+	// wrong code
+	_, status, err = s.ConfirmTOTP(u.ID, "000000x")
+	if status != 400 || err != nil {
+		t.Errorf("Expected status 400 and error nil, but got status %d error %v", status, err)
+	}
+
+	code, err := utils.GenerateTOTPCode(secret, utils.GetTOTPStep(time.Now().UTC()))
+	if err != nil {
+		t.Fatalf("There's an error when generating totp code => " + err.Error())
+	}
+	recoveryCodes, status, err := s.ConfirmTOTP(u.ID, code)
+	if status != 200 || err != nil || len(recoveryCodes) != model.RecoveryCodeCount {
+		t.Fatalf("Expected status 200 with recovery codes and error nil,"+
+			" but got status %d %d codes error %v", status, len(recoveryCodes), err)
+	}
+
+	// already enabled
+	_, status, err = s.EnrollTOTP(u.ID)
+	if status != 400 || err != nil {
+		t.Errorf("Expected status 400 and error nil, but got status %d error %v", status, err)
+	}
+
+	// login return mfa challenge token, then completed by recovery code once
+	mfaToken, _, status, _ := login(t, s, Emails[0], "testpassword1")
+	if status != 202 || mfaToken == "" {
+		t.Fatalf("Expected status 202 with mfa token, but got %d", status)
+	}
+
+	for _, test := range []struct {
+		MFAToken       string
+		Code           string
+		ExpectedStatus int
+	}{
+		{MFAToken: "notvalid", Code: recoveryCodes[0], ExpectedStatus: 400},
+		{MFAToken: mfaToken, Code: "notvalid", ExpectedStatus: 400},
+		{MFAToken: mfaToken, Code: recoveryCodes[0], ExpectedStatus: 200},
+		{MFAToken: mfaToken, Code: recoveryCodes[0], ExpectedStatus: 400},
+	} {
+		token, refreshToken, status, _, err := s.AuthenticateUserMFA(test.MFAToken,
+			test.Code, "test-agent", "192.0.2.1")
+		if status != test.ExpectedStatus || err != nil {
+			t.Errorf("Expected status %d and error nil, but got status %d error %v",
+				test.ExpectedStatus, status, err)
+		}
+		if status == 200 && (token == "" || refreshToken == "") {
+			t.Errorf("Expected tokens of the user, but got empty")
+		}
+	}
+}
+
+// testPassword test CreatePasswordResetToken, ResetPassword,
+// and ChangePassword with password history
+func testPassword(t *testing.T, s store.Store) {
+	u := createTestingUser(t, s, Emails[0])
+
+	currentSession, err := s.CreateUserSession(model.UserSession{Token: "teststore-token-1", User: u})
+	if err != nil {
+		t.Fatalf("Expected error nil, but got error " + err.Error())
+	}
+	_, err = s.CreateUserSession(model.UserSession{Token: "teststore-token-2", User: u})
+	if err != nil {
+		t.Fatalf("Expected error nil, but got error " + err.Error())
+	}
+
+	// change password, the current password and the last passwords can't be used
+	for _, test := range []struct {
+		CurrentPassword string
+		NewPassword     string
+		ExpectedStatus  int
+	}{
+		{CurrentPassword: "wrongpassword1", NewPassword: "newpassword1", ExpectedStatus: 400},
+		{CurrentPassword: "testpassword1", NewPassword: "testpassword1", ExpectedStatus: 422},
+		{CurrentPassword: "testpassword1", NewPassword: "newpassword1", ExpectedStatus: 200},
+		{CurrentPassword: "newpassword1", NewPassword: "testpassword1", ExpectedStatus: 422},
+	} {
+		status, err := s.ChangePassword(u.ID, test.CurrentPassword, test.NewPassword,
+			currentSession.ID)
+		if status != test.ExpectedStatus || err != nil {
+			t.Errorf("Expected status %d and error nil, but got status %d error %v",
+				test.ExpectedStatus, status, err)
+		}
+	}
+
+	// other sessions revoked
+	userSessions, err := s.GetUserSessions(u.ID)
+	if err != nil || len(userSessions) != 1 || userSessions[0].ID != currentSession.ID {
+		t.Errorf("Expected only the current session kept, but got %+v error %v",
+			userSessions, err)
+	}
+
+	// reset password, the token only used once
+	prt, err := s.CreatePasswordResetToken(model.PasswordResetToken{User: u})
+	if err != nil || prt.Token == "" {
+		t.Fatalf("Expected password reset token and error nil, but got error %v", err)
+	}
+
+	for _, test := range []struct {
+		Token          string
+		NewPassword    string
+		ExpectedStatus int
+	}{
+		{Token: "notvalid", NewPassword: "resetpassword1", ExpectedStatus: 400},
+		{Token: prt.Token, NewPassword: "testpassword1", ExpectedStatus: 422},
+		{Token: prt.Token, NewPassword: "resetpassword1", ExpectedStatus: 200},
+		{Token: prt.Token, NewPassword: "resetpassword2", ExpectedStatus: 400},
+	} {
+		status, err := s.ResetPassword(test.Token, test.NewPassword)
+		if status != test.ExpectedStatus || err != nil {
+			t.Errorf("Expected status %d and error nil, but got status %d error %v",
+				test.ExpectedStatus, status, err)
+		}
+	}
+
+	// all sessions revoked, login with the new password
+	userSessions, err = s.GetUserSessions(u.ID)
+	if err != nil || len(userSessions) != 0 {
+		t.Errorf("Expected all sessions revoked, but got %+v error %v", userSessions, err)
+	}
+	_, _, status, _ := login(t, s, Emails[0], "resetpassword1")
+	if status != 200 {
+		t.Errorf("Expected status 200 of login with new password, but got %d", status)
+	}
+}
+
+// testEmailChange test CreateEmailChange, ConfirmEmailChange, and RevertEmailChange
+func testEmailChange(t *testing.T, s store.Store) {
+	u := createTestingUser(t, s, Emails[0])
+	createTestingUser(t, s, Emails[1])
+
+	// create email change
+	for _, test := range []struct {
+		CurrentPassword string
+		NewEmail        string
+		ExpectedStatus  int
+	}{
+		{CurrentPassword: "wrongpassword1", NewEmail: Emails[2], ExpectedStatus: 400},
+		{CurrentPassword: "testpassword1", NewEmail: Emails[1], ExpectedStatus: 409},
+		{CurrentPassword: "testpassword1", NewEmail: Emails[2], ExpectedStatus: 200},
+	} {
+		_, status, err := s.CreateEmailChange(u.ID, test.CurrentPassword, test.NewEmail)
+		if status != test.ExpectedStatus || err != nil {
+			t.Errorf("Expected status %d and error nil, but got status %d error %v",
+				test.ExpectedStatus, status, err)
+		}
+	}
+
+	// the previous unconfirmed email change replaced
+	ec, status, err := s.CreateEmailChange(u.ID, "testpassword1", Emails[2])
+	if status != 200 || err != nil || ec.Token == "" || ec.OldEmail != Emails[0] {
+		t.Fatalf("Expected status 200 with token and error nil, but got %+v status %d error %v",
+			ec, status, err)
+	}
+
+	// confirm email change once
+	confirmedEC, status, err := s.ConfirmEmailChange(ec.Token)
+	if status != 200 || err != nil || confirmedEC.RevertToken == "" ||
+		confirmedEC.ConfirmedAt == nil || confirmedEC.RevertExpiredAt == nil {
+		t.Fatalf("Expected status 200 with revert token and error nil,"+
+			" but got %+v status %d error %v", confirmedEC, status, err)
+	}
+	_, status, err = s.ConfirmEmailChange(ec.Token)
+	if status != 400 || err != nil {
+		t.Errorf("Expected status 400 and error nil, but got status %d error %v", status, err)
+	}
+
+	user, err := s.GetUser("", u.ID)
+	if err != nil || user.Email != Emails[2] || user.EmailVerifiedAt == nil ||
+		user.Status != model.UserStatusActive {
+		t.Errorf("Expected user email changed and verified, but got %+v", user)
+	}
+
+	// revert email change once, all sessions revoked
+	_, err = s.CreateUserSession(model.UserSession{Token: "teststore-token-1", User: u})
+	if err != nil {
+		t.Fatalf("Expected error nil, but got error " + err.Error())
+	}
+
+	for _, expectedStatus := range []int{200, 400} {
+		_, status, err = s.RevertEmailChange(confirmedEC.RevertToken)
+		if status != expectedStatus || err != nil {
+			t.Errorf("Expected status %d and error nil, but got status %d error %v",
+				expectedStatus, status, err)
+		}
+	}
+
+	user, err = s.GetUser("", u.ID)
+	if err != nil || user.Email != Emails[0] {
+		t.Errorf("Expected user email changed back, but got %+v", user)
+	}
+	userSessions, err := s.GetUserSessions(u.ID)
+	if err != nil || len(userSessions) != 0 {
+		t.Errorf("Expected all sessions revoked, but got %+v error %v", userSessions, err)
+	}
+}
+
+// testPhoneVerification test CreatePhoneVerification and VerifyPhone
+func testPhoneVerification(t *testing.T, s store.Store) {
+	u := createTestingUser(t, s, Emails[0])
+
+	code, phoneNumber, status, err := s.CreatePhoneVerification(u.ID)
+	if status != 200 || err != nil || code == "" || phoneNumber != "+6281111111111" {
+		t.Fatalf("Expected status 200 with code and error nil,"+
+			" but got phone number %q status %d error %v", phoneNumber, status, err)
+	}
+
+	// code only sent once in resend interval
+	_, _, status, err = s.CreatePhoneVerification(u.ID)
+	if status != 429 || err != nil {
+		t.Errorf("Expected status 429 and error nil, but got status %d error %v", status, err)
+	}
+
+	// wrong code then right code
+	wrongCode := "000000"
+	if code == wrongCode {
+		wrongCode = "111111"
+	}
+	for _, test := range []struct {
+		Code           string
+		ExpectedStatus int
+	}{
+		{Code: wrongCode, ExpectedStatus: 400},
+		{Code: code, ExpectedStatus: 200},
+		{Code: code, ExpectedStatus: 400},
+	} {
+		status, err := s.VerifyPhone(u.ID, test.Code)
+		if status != test.ExpectedStatus || err != nil {
+			t.Errorf("Expected status %d and error nil, but got status %d error %v",
+				test.ExpectedStatus, status, err)
+		}
+	}
+
+	user, err := s.GetUser("", u.ID)
+	if err != nil || user.PhoneVerifiedAt == nil {
+		t.Errorf("Expected phone number verified, but got %+v", user)
+	}
+
+	// already verified
+	_, _, status, err = s.CreatePhoneVerification(u.ID)
+	if status != 409 || err != nil {
+		t.Errorf("Expected status 409 and error nil, but got status %d error %v", status, err)
+	}
+}
+
+// testAddress test CreateAddress, GetAddress, UpdateAddress, and DeleteAddress
+func testAddress(t *testing.T, s store.Store) {
+	u := createTestingUser(t, s, Emails[0])
+	anotherUser := createTestingUser(t, s, Emails[1])
+
+	addresses, err := s.GetAddresses(u.ID)
+	if err != nil || len(addresses) != 1 {
+		t.Fatalf("Expected 1 address from register address, but got %+v error %v",
+			addresses, err)
+	}
+	registerAddress := addresses[0]
+
+	// new default shipping address unset the previous default shipping address
+	address, status, err := s.CreateAddress(model.Address{
+		UserID:            u.ID,
+		RecipientName:     "test",
+		Line1:             "new address",
+		CountryCode:       "ID",
+		IsDefaultShipping: true,
+	})
+	if status != 200 || err != nil || address.ID == 0 || address.CreatedAt.IsZero() {
+		t.Fatalf("Expected status 200 and error nil, but got %+v status %d error %v",
+			address, status, err)
+	}
+
+	registerAddress, err = s.GetAddress(registerAddress.ID, u.ID)
+	if err != nil || registerAddress.IsDefaultShipping || !registerAddress.IsDefaultBilling {
+		t.Errorf("Expected only default billing address, but got %+v error %v",
+			registerAddress, err)
+	}
+
+	// address of another user
+	_, err = s.GetAddress(address.ID, anotherUser.ID)
+	if err != sql.ErrNoRows {
+		t.Errorf("Expected error sql.ErrNoRows, but got %v", err)
+	}
+	_, err = s.UpdateAddress(model.Address{ID: address.ID, UserID: anotherUser.ID})
+	if err != sql.ErrNoRows {
+		t.Errorf("Expected error sql.ErrNoRows, but got %v", err)
+	}
+	err = s.DeleteAddress(address.ID, anotherUser.ID)
+	if err != sql.ErrNoRows {
+		t.Errorf("Expected error sql.ErrNoRows, but got %v", err)
+	}
+
+	// update all fields, the address become default billing address too
+	address.Line1 = "updated address"
+	address.IsDefaultBilling = true
+	updatedAddress, err := s.UpdateAddress(address)
+	if err != nil || updatedAddress.Line1 != "updated address" ||
+		!updatedAddress.CreatedAt.Equal(address.CreatedAt) {
+		t.Errorf("Expected address updated, but got %+v error %v", updatedAddress, err)
+	}
+
+	addresses, err = s.GetAddresses(u.ID)
+	if err != nil || len(addresses) != 2 || addresses[0].ID != address.ID ||
+		addresses[1].IsDefaultShipping || addresses[1].IsDefaultBilling {
+		t.Errorf("Expected the updated address the only default address, but got %+v error %v",
+			addresses, err)
+	}
+
+	// the remaining address become default address
+	err = s.DeleteAddress(address.ID, u.ID)
+	if err != nil {
+		t.Fatalf("Expected error nil, but got error " + err.Error())
+	}
+	addresses, err = s.GetAddresses(u.ID)
+	if err != nil || len(addresses) != 1 || !addresses[0].IsDefaultShipping ||
+		!addresses[0].IsDefaultBilling {
+		t.Errorf("Expected 1 default address, but got %+v error %v", addresses, err)
+	}
+}
+
+// testAccountDeletion test ScheduleUserDeletion and login cancel the deletion
+func testAccountDeletion(t *testing.T, s store.Store) {
+	u := createTestingUser(t, s, Emails[0])
+
+	token, _, status, _ := login(t, s, Emails[0], "testpassword1")
+	if status != 200 {
+		t.Fatalf("Expected status 200, but got %d", status)
+	}
+
+	for _, test := range []struct {
+		UserID         int
+		Password       string
+		ExpectedStatus int
+	}{
+		{UserID: u.ID + 1000000, Password: "testpassword1", ExpectedStatus: 400},
+		{UserID: u.ID, Password: "wrongpassword1", ExpectedStatus: 400},
+		{UserID: u.ID, Password: "testpassword1", ExpectedStatus: 200},
+	} {
+		user, status, err := s.ScheduleUserDeletion(test.UserID, test.Password, "192.0.2.1")
+		if status != test.ExpectedStatus || err != nil {
+			t.Errorf("Expected status %d and error nil, but got status %d error %v",
+				test.ExpectedStatus, status, err)
+		}
+		if status == 200 && user.DeletionScheduledAt == nil {
+			t.Errorf("Expected user deletion time filled, but got empty")
+		}
+	}
+
+	// all sessions revoked, login cancel the deletion
+	_, err := s.GetUserSession(token, u.ID)
+	if err != sql.ErrNoRows {
+		t.Errorf("Expected error sql.ErrNoRows, but got %v", err)
+	}
+
+	_, refreshToken, status, _ := login(t, s, Emails[0], "testpassword1")
+	if status != 200 {
+		t.Fatalf("Expected status 200, but got %d", status)
+	}
+	user, err := s.GetUser("", u.ID)
+	if err != nil || user.DeletionScheduledAt != nil {
+		t.Errorf("Expected user deletion cancelled, but got %+v error %v", user, err)
+	}
+
+	_, _, status, err = s.RefreshUserSession(refreshToken)
+	if status != 200 || err != nil {
+		t.Errorf("Expected status 200 and error nil, but got status %d error %v", status, err)
+	}
+}
+
+// testDataExport test data export lifecycle without claiming,
+// and GetDataExportFiles with login history
+func testDataExport(t *testing.T, s store.Store) {
+	u := createTestingUser(t, s, Emails[0])
+	anotherUser := createTestingUser(t, s, Emails[1])
+
+	// one data export in progress of a user
+	de, isCreated, err := s.CreateDataExport(u.ID)
+	if !isCreated || err != nil || de.Status != model.DataExportStatusPending {
+		t.Fatalf("Expected pending data export created, but got %+v error %v", de, err)
+	}
+	inProgressDE, isCreated, err := s.CreateDataExport(u.ID)
+	if isCreated || err != nil || inProgressDE.ID != de.ID {
+		t.Errorf("Expected data export %d in progress, but got %+v error %v",
+			de.ID, inProgressDE, err)
+	}
+
+	_, err = s.GetDataExport(de.ID, anotherUser.ID)
+	if err != sql.ErrNoRows {
+		t.Errorf("Expected error sql.ErrNoRows, but got %v", err)
+	}
+	_, err = s.GetDataExportArchive(de.ID)
+	if err != sql.ErrNoRows {
+		t.Errorf("Expected error sql.ErrNoRows, but got %v", err)
+	}
+
+	// archive only downloadable after completed
+	err = s.CompleteDataExport(de.ID, []byte("archive"))
+	if err != nil {
+		t.Fatalf("Expected error nil, but got error " + err.Error())
+	}
+	de, err = s.GetDataExport(de.ID, u.ID)
+	if err != nil || de.Status != model.DataExportStatusReady || de.ExpiredAt == nil {
+		t.Errorf("Expected ready data export, but got %+v error %v", de, err)
+	}
+	archive, err := s.GetDataExportArchive(de.ID)
+	if err != nil || string(archive) != "archive" {
+		t.Errorf("Expected archive of the data export, but got %q error %v", archive, err)
+	}
+
+	// failed data export not in progress anymore
+	de, _, err = s.CreateDataExport(u.ID)
+	if err != nil {
+		t.Fatalf("Expected error nil, but got error " + err.Error())
+	}
+	err = s.FailDataExport(de.ID)
+	if err != nil {
+		t.Fatalf("Expected error nil, but got error " + err.Error())
+	}
+	_, isCreated, err = s.CreateDataExport(u.ID)
+	if !isCreated || err != nil {
+		t.Errorf("Expected data export created, but got %t error %v", isCreated, err)
+	}
+
+	// failed and succeeded login kept in login history
+	login(t, s, Emails[0], "wrongpassword1")
+	login(t, s, Emails[0], "testpassword1")
+
+	files, err := s.GetDataExportFiles(u.ID)
+	if err != nil || len(files) != 4 {
+		t.Fatalf("Expected 4 data export files, but got %d error %v", len(files), err)
+	}
+	for _, file := range files {
+		if file.Name != dataexport.FileLoginHistory {
+			continue
+		}
+
+		loginHistory, ok := file.Content.([]model.LoginHistory)
+		if !ok || len(loginHistory) != 2 || !loginHistory[0].IsSucceeded ||
+			loginHistory[1].IsSucceeded || loginHistory[0].UserAgent != "test-agent" {
+			t.Errorf("Expected succeeded login then failed login, but got %+v",
+				file.Content)
+		}
+	}
+
+	_, err = s.GetDataExportFiles(u.ID + 1000000)
+	if err != sql.ErrNoRows {
+		t.Errorf("Expected error sql.ErrNoRows, but got %v", err)
+	}
+}
+
+// testRole test IsRoleSelfRegistrable, GetRolePermissions, and HasPermission
+// of the default roles
+func testRole(t *testing.T, s store.Store) {
+	// create testing table
+	testTable := []struct {
+		Role                      string
+		ExpectedIsSelfRegistrable bool
+		ExpectedPermissions       []string
+	}{
+		{
+			Role:                      "buyer",
+			ExpectedIsSelfRegistrable: true,
+			ExpectedPermissions:       []string{"order:create", "order:read", "product:read"},
+		},
+		{
+			Role:                      "seller",
+			ExpectedIsSelfRegistrable: true,
+			ExpectedPermissions:       []string{"order:read", "product:read", "product:write"},
+		},
+		{
+			Role: "admin",
+			ExpectedPermissions: []string{"jwt_key:promote", "login_lockout:clear",
+				"order:read", "product:read", "product:write", "user:read", "user:write"},
+		},
+		{
+			Role:                "notexist",
+			ExpectedPermissions: []string{},
+		},
+	}
+
+	// loop test in test table
+	for _, test := range testTable {
+		isSelfRegistrable, err := s.IsRoleSelfRegistrable(test.Role)
+		if isSelfRegistrable != test.ExpectedIsSelfRegistrable || err != nil {
+			t.Errorf("Expected role %q self registrable %t and error nil, but got %t error %v",
+				test.Role, test.ExpectedIsSelfRegistrable, isSelfRegistrable, err)
+		}
+
+		permissions, err := s.GetRolePermissions(test.Role)
+		if err != nil || strings.Join(permissions, ",") !=
+			strings.Join(test.ExpectedPermissions, ",") {
+			t.Errorf("Expected role %q permissions %v and error nil, but got %v error %v",
+				test.Role, test.ExpectedPermissions, permissions, err)
+		}
+
+		for _, permission := range []string{"user:write", "order:read"} {
+			hasPermission, err := s.HasPermission(test.Role, permission)
+			expectedHasPermission := strings.Contains(
+				","+strings.Join(test.ExpectedPermissions, ",")+",", ","+permission+",")
+			if hasPermission != expectedHasPermission || err != nil {
+				t.Errorf("Expected role %q has permission %q %t and error nil, but got %t error %v",
+					test.Role, permission, expectedHasPermission, hasPermission, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,53 @@
+/*
+Package utils containing utilities function
+
+This package cannot have import from another package except for config package
+*/
+package utils
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"math/big"
+
+	"github.com/reyhanfikridz/ecom-account-service/internal/config"
+)
+
+// GetJWKS get JSON Web Key Set (RFC 7517) of jwt verification key of key ring,
+// so another service can verify jwt token without the signing key
+//
+// All keys valid for verification (active and retired keys) are published,
+// but HMAC secret key is never published
+func GetJWKS(keyRing *config.JWTKeyRing) map[string]any {
+	keys := []map[string]string{}
+
+	for _, key := range keyRing.Keys() {
+		switch verificationKey := key.VerificationKey.(type) {
+		case *rsa.PublicKey:
+			keys = append(keys, map[string]string{
+				"kty": "RSA",
+				"kid": key.ID,
+				"use": "sig",
+				"alg": key.Method.Alg(),
+				"n":   base64.RawURLEncoding.EncodeToString(verificationKey.N.Bytes()),
+				"e": base64.RawURLEncoding.EncodeToString(
+					big.NewInt(int64(verificationKey.E)).Bytes()),
+			})
+
+		case ed25519.PublicKey:
+			keys = append(keys, map[string]string{
+				"kty": "OKP",
+				"kid": key.ID,
+				"crv": "Ed25519",
+				"use": "sig",
+				"alg": key.Method.Alg(),
+				"x":   base64.RawURLEncoding.EncodeToString(verificationKey),
+			})
+		}
+	}
+
+	return map[string]any{
+		"keys": keys,
+	}
+}
@@ -0,0 +1,92 @@
+/*
+Package utils containing utilities function
+
+This package cannot have import from another package except for config package
+*/
+package utils
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/reyhanfikridz/ecom-account-service/internal/config"
+)
+
+// TestGetJWKS test GetJWKS
+func TestGetJWKS(t *testing.T) {
+	// generate keys
+	rsaPrivateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Errorf("There's an error when generating RSA key => " + err.Error())
+	}
+
+	edPublicKey, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Errorf("There's an error when generating Ed25519 key => " + err.Error())
+	}
+
+	// initialize testing table
+	testTable := []struct {
+		Method          jwt.SigningMethod
+		VerificationKey any
+		ExpectedKeyType string
+	}{
+		{
+			Method:          jwt.SigningMethodHS256,
+			VerificationKey: []byte("secret"),
+			ExpectedKeyType: "",
+		},
+		{
+			Method:          jwt.SigningMethodRS256,
+			VerificationKey: &rsaPrivateKey.PublicKey,
+			ExpectedKeyType: "RSA",
+		},
+		{
+			Method:          jwt.SigningMethodEdDSA,
+			VerificationKey: edPublicKey,
+			ExpectedKeyType: "OKP",
+		},
+	}
+
+	// loop test in test table
+	for _, test := range testTable {
+		keyRing := config.NewJWTKeyRing(config.JWTKey{
+			ID:              "test",
+			Method:          test.Method,
+			VerificationKey: test.VerificationKey,
+		}, time.Hour)
+
+		keys := GetJWKS(keyRing)["keys"].([]map[string]string)
+		if test.ExpectedKeyType == "" {
+			if len(keys) != 0 {
+				t.Errorf("Expected no published key for method %s, but got %d keys",
+					test.Method.Alg(), len(keys))
+			}
+			continue
+		}
+
+		if len(keys) != 1 {
+			t.Errorf("Expected 1 published key for method %s, but got %d keys",
+				test.Method.Alg(), len(keys))
+			continue
+		}
+
+		if keys[0]["kty"] != test.ExpectedKeyType {
+			t.Errorf("Expected key type %s, but got %s",
+				test.ExpectedKeyType, keys[0]["kty"])
+		}
+
+		if keys[0]["kid"] != "test" {
+			t.Errorf("Expected key kid test, but got %s", keys[0]["kid"])
+		}
+
+		if keys[0]["alg"] != test.Method.Alg() {
+			t.Errorf("Expected key alg %s, but got %s",
+				test.Method.Alg(), keys[0]["alg"])
+		}
+	}
+}
@@ -7,6 +7,7 @@ package utils
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
@@ -14,19 +15,101 @@ import (
 	"github.com/reyhanfikridz/ecom-account-service/internal/config"
 )
 
-// GenerateJWT generate jwt token string
-func GenerateJWT(email string, role string) (string, error) {
-	// initialize new token
-	token := jwt.New(config.JWTSigningMethod)
+// GenerateJWT generate jwt token string signed by active key of key ring,
+// identified by user ID (subject claim) because user email can be changed
+func GenerateJWT(keys *config.JWTKeyRing, userID int, role string,
+	duration time.Duration) (string, error) {
+	return signJWT(keys, jwt.MapClaims{
+		"sub":  strconv.Itoa(userID),
+		"role": role,
+		"exp":  time.Now().Add(duration).Unix(),
+	})
+}
+
+// ValidateJWT validate jwt token string,
+// return claims with subject (user ID) or email for token
+// generated before identified by user ID, and role
+//
+// If token not valid, return nil
+func ValidateJWT(keys *config.JWTKeyRing, tokenString string) map[string]string {
+	claims := parseJWT(keys, tokenString)
+	if claims == nil {
+		return nil
+	}
+
+	// action token can't be used as access token
+	if _, ok := claims["action"]; ok {
+		return nil
+	}
+
+	// check claims data, token must have subject or email
+	claimsMap := map[string]string{}
+	for _, key := range []string{"sub", "email", "role"} {
+		if claims[key] != nil && strings.TrimSpace(fmt.Sprint(claims[key])) != "" {
+			claimsMap[key] = fmt.Sprint(claims[key])
+		}
+	}
+	if claimsMap["role"] == "" || (claimsMap["sub"] == "" && claimsMap["email"] == "") {
+		return nil
+	}
+
+	return claimsMap
+}
+
+// GenerateActionJWT generate short-lived jwt token string
+// for one action (e.g. email verification)
+//
+// Action token can't be used as access token
+func GenerateActionJWT(keys *config.JWTKeyRing, action string,
+	claimsMap map[string]string, duration time.Duration) (string, error) {
+	claims := jwt.MapClaims{}
+	for key, item := range claimsMap {
+		claims[key] = item
+	}
+	claims["action"] = action
+	claims["exp"] = time.Now().Add(duration).Unix()
+
+	return signJWT(keys, claims)
+}
+
+// ValidateActionJWT validate jwt token string of an action
+//
+// If token not valid or not for the action, return nil
+func ValidateActionJWT(keys *config.JWTKeyRing, tokenString string,
+	action string) map[string]string {
+	claims := parseJWT(keys, tokenString)
+	if claims == nil || claims["action"] != action {
+		return nil
+	}
+
+	// only return string claims
+	claimsMap := map[string]string{}
+	for key, item := range claims {
+		if s, ok := item.(string); ok {
+			claimsMap[key] = s
+		}
+	}
+
+	return claimsMap
+}
 
-	// set token claims
-	claims := token.Claims.(jwt.MapClaims)
-	claims["email"] = email
-	claims["role"] = role
-	claims["exp"] = time.Now().Add(time.Minute * 30).Unix()
+// signJWT sign jwt token claims by active key
+func signJWT(keys *config.JWTKeyRing, claims jwt.MapClaims) (string, error) {
+	// generate token ID, so every generated token is unique
+	// even when generated at the same second
+	tokenID, err := GenerateOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+	claims["jti"] = tokenID
+
+	// initialize new token signed by active key
+	key := keys.ActiveKey()
+	token := jwt.NewWithClaims(key.Method, claims)
+	token.Header["kid"] = key.ID
 
 	// get token string
-	tokenString, err := token.SignedString([]byte(config.JWTSecretKey))
+	tokenString, err := token.SignedString(key.SigningKey)
 
 	if err != nil {
 		return "", err
@@ -35,17 +118,26 @@ func GenerateJWT(email string, role string) (string, error) {
 	return tokenString, nil
 }
 
-// ValidateJWT validate jwt token string
+// parseJWT parse and verify jwt token string
 //
 // If token not valid, return nil
-func ValidateJWT(tokenString string) map[string]string {
+func parseJWT(keys *config.JWTKeyRing, tokenString string) jwt.MapClaims {
 	// parse token from token string
 	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		_, ok := token.Method.(*jwt.SigningMethodHMAC)
-		if !ok {
+		// get verification key by key ID,
+		// token without key ID (signed before key rotation) use active key
+		key := keys.ActiveKey()
+		if kid, ok := token.Header["kid"].(string); ok {
+			key, ok = keys.GetKey(kid)
+			if !ok {
+				return nil, fmt.Errorf("token signing key not found or expired")
+			}
+		}
+
+		if token.Method.Alg() != key.Method.Alg() {
 			return nil, fmt.Errorf("token not using the right signing method")
 		}
-		return []byte(config.JWTSecretKey), nil
+		return key.VerificationKey, nil
 	})
 	if err != nil {
 		return nil
@@ -62,16 +154,5 @@ func ValidateJWT(tokenString string) map[string]string {
 		return nil
 	}
 
-	// check claims data
-	claimsMap := map[string]string{
-		"email": fmt.Sprint(claims["email"]),
-		"role":  fmt.Sprint(claims["role"]),
-	}
-	for _, item := range claimsMap {
-		if strings.TrimSpace(item) == "" {
-			return nil
-		}
-	}
-
-	return claimsMap
+	return claims
 }
@@ -6,16 +6,25 @@ This package cannot have import from another package except for config package
 package utils
 
 import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
 	"log"
 	"testing"
+	"time"
 
+	"github.com/golang-jwt/jwt/v4"
 	"github.com/reyhanfikridz/ecom-account-service/internal/config"
 )
 
+// config of all testing in the package
+var testConfig config.Config
+
 // TestMain do some test before and after all testing in the package
 func TestMain(m *testing.M) {
-	// init all config before can be used
-	err := config.InitConfig()
+	// load config before can be used
+	var err error
+	testConfig, _, err = config.Load(nil)
 	if err != nil {
 		log.Fatalf("There's an error when initialize config => %s", err)
 	}
@@ -27,28 +36,237 @@ func TestMain(m *testing.M) {
 // TestGenerateJWTAndValidateJWT integration test
 // GenerateJWT and ValidateJWT
 func TestGenerateJWTAndValidateJWT(t *testing.T) {
-	email := "admin@gmail.com"
+	keys := testConfig.JWTKeys
+	userID := 1
 	role := "admin"
 
 	// generate jwt
-	tokenString, err := GenerateJWT(email, role)
+	tokenString, err := GenerateJWT(keys, userID, role, testConfig.AccessTokenDuration)
 	if err != nil {
 		t.Errorf("There's an error when generate JWT => " + err.Error())
 	}
 
 	// validate jwt
-	tokenClaimsMap := ValidateJWT(tokenString)
+	tokenClaimsMap := ValidateJWT(keys, tokenString)
 
 	// check result
 	if tokenClaimsMap == nil {
 		t.Errorf("Expected JWT token valid, but got invalid")
 	}
 
-	if email != tokenClaimsMap["email"] {
-		t.Errorf("Expected email '" + email + "', but got '" + tokenClaimsMap["email"] + "'")
+	if tokenClaimsMap["sub"] != "1" {
+		t.Errorf("Expected sub '1', but got '" + tokenClaimsMap["sub"] + "'")
 	}
 
 	if role != tokenClaimsMap["role"] {
 		t.Errorf("Expected role '" + role + "', but got '" + tokenClaimsMap["role"] + "'")
 	}
 }
+
+// TestValidateJWTEmailToken test ValidateJWT with token
+// identified by email (generated before identified by user ID)
+func TestValidateJWTEmailToken(t *testing.T) {
+	keys := testConfig.JWTKeys
+
+	// initialize testing table
+	testTable := []struct {
+		Claims        jwt.MapClaims
+		ExpectedValid bool
+	}{
+		{
+			Claims: jwt.MapClaims{
+				"email": "admin@gmail.com",
+				"role":  "admin",
+				"exp":   time.Now().Add(time.Minute).Unix(),
+			},
+			ExpectedValid: true,
+		},
+		{
+			Claims: jwt.MapClaims{
+				"role": "admin",
+				"exp":  time.Now().Add(time.Minute).Unix(),
+			},
+			ExpectedValid: false,
+		},
+		{
+			Claims: jwt.MapClaims{
+				"email": "admin@gmail.com",
+				"exp":   time.Now().Add(time.Minute).Unix(),
+			},
+			ExpectedValid: false,
+		},
+	}
+
+	// loop test in test table
+	for _, test := range testTable {
+		tokenString, err := signJWT(keys, test.Claims)
+		if err != nil {
+			t.Errorf("There's an error when sign JWT => " + err.Error())
+		}
+
+		tokenClaimsMap := ValidateJWT(keys, tokenString)
+		if (tokenClaimsMap != nil) != test.ExpectedValid {
+			t.Errorf("Expected JWT token valid %t, but got %t",
+				test.ExpectedValid, tokenClaimsMap != nil)
+		}
+
+		if tokenClaimsMap != nil && tokenClaimsMap["email"] != "admin@gmail.com" {
+			t.Errorf("Expected email 'admin@gmail.com', but got '" +
+				tokenClaimsMap["email"] + "'")
+		}
+	}
+}
+
+// TestGenerateJWTAndValidateJWTAsymmetric integration test
+// GenerateJWT and ValidateJWT with RSA and Ed25519 key
+func TestGenerateJWTAndValidateJWTAsymmetric(t *testing.T) {
+	// generate keys
+	rsaPrivateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Errorf("There's an error when generating RSA key => " + err.Error())
+	}
+
+	edPublicKey, edPrivateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Errorf("There's an error when generating Ed25519 key => " + err.Error())
+	}
+
+	// initialize testing table
+	testTable := []config.JWTKey{
+		{
+			ID:              "rsa",
+			Method:          jwt.SigningMethodRS256,
+			SigningKey:      rsaPrivateKey,
+			VerificationKey: &rsaPrivateKey.PublicKey,
+		},
+		{
+			ID:              "ed25519",
+			Method:          jwt.SigningMethodEdDSA,
+			SigningKey:      edPrivateKey,
+			VerificationKey: edPublicKey,
+		},
+	}
+
+	// loop test in test table
+	for _, test := range testTable {
+		keys := config.NewJWTKeyRing(test, time.Hour)
+
+		// generate jwt
+		tokenString, err := GenerateJWT(keys, 1, "admin", time.Minute)
+		if err != nil {
+			t.Errorf("There's an error when generate JWT => " + err.Error())
+		}
+
+		// validate jwt
+		tokenClaimsMap := ValidateJWT(keys, tokenString)
+		if tokenClaimsMap == nil {
+			t.Errorf("Expected JWT token valid with method %s, but got invalid",
+				test.Method.Alg())
+		}
+
+		// token signed by another key must be invalid
+		keys = config.NewJWTKeyRing(config.JWTKey{
+			ID:              test.ID,
+			Method:          jwt.SigningMethodHS256,
+			SigningKey:      []byte("secret"),
+			VerificationKey: []byte("secret"),
+		}, time.Hour)
+		if ValidateJWT(keys, tokenString) != nil {
+			t.Errorf("Expected JWT token with method %s invalid, but got valid",
+				test.Method.Alg())
+		}
+	}
+}
+
+// TestValidateJWTAfterKeyRotation integration test
+// GenerateJWT and ValidateJWT after jwt key rotated
+func TestValidateJWTAfterKeyRotation(t *testing.T) {
+	keys := config.NewJWTKeyRing(config.JWTKey{
+		ID:              "old",
+		Method:          jwt.SigningMethodHS256,
+		SigningKey:      []byte("old"),
+		VerificationKey: []byte("old"),
+	}, time.Hour)
+
+	// generate jwt before rotation
+	oldTokenString, err := GenerateJWT(keys, 1, "admin", time.Minute)
+	if err != nil {
+		t.Errorf("There's an error when generate JWT => " + err.Error())
+	}
+
+	// rotate key
+	keys.Promote(config.JWTKey{
+		ID:              "new",
+		Method:          jwt.SigningMethodHS256,
+		SigningKey:      []byte("new"),
+		VerificationKey: []byte("new"),
+	})
+
+	// generate jwt after rotation
+	newTokenString, err := GenerateJWT(keys, 1, "admin", time.Minute)
+	if err != nil {
+		t.Errorf("There's an error when generate JWT => " + err.Error())
+	}
+
+	// check result
+	if ValidateJWT(keys, oldTokenString) == nil {
+		t.Errorf("Expected JWT token signed by retired key valid, but got invalid")
+	}
+
+	if ValidateJWT(keys, newTokenString) == nil {
+		t.Errorf("Expected JWT token signed by active key valid, but got invalid")
+	}
+}
+
+// TestGenerateActionJWTAndValidateActionJWT integration test
+// GenerateActionJWT, ValidateActionJWT and ValidateJWT
+func TestGenerateActionJWTAndValidateActionJWT(t *testing.T) {
+	keys := testConfig.JWTKeys
+
+	// generate action jwt
+	tokenString, err := GenerateActionJWT(keys, "email_verification", map[string]string{
+		"sub":   "1",
+		"email": "admin@gmail.com",
+	}, time.Minute)
+	if err != nil {
+		t.Errorf("There's an error when generate action JWT => " + err.Error())
+	}
+
+	// check result
+	claimsMap := ValidateActionJWT(keys, tokenString, "email_verification")
+	if claimsMap == nil {
+		t.Errorf("Expected action JWT token valid, but got invalid")
+	} else if claimsMap["sub"] != "1" || claimsMap["email"] != "admin@gmail.com" {
+		t.Errorf("Expected action JWT claims sub '1' and email 'admin@gmail.com', "+
+			"but got '%s' and '%s'", claimsMap["sub"], claimsMap["email"])
+	}
+
+	if ValidateActionJWT(keys, tokenString, "mfa") != nil {
+		t.Errorf("Expected action JWT token invalid for another action, but got valid")
+	}
+
+	if ValidateJWT(keys, tokenString) != nil {
+		t.Errorf("Expected action JWT token invalid as access token, but got valid")
+	}
+
+	// access token is not action token
+	accessTokenString, err := GenerateJWT(keys, 1, "admin", time.Minute)
+	if err != nil {
+		t.Errorf("There's an error when generate JWT => " + err.Error())
+	}
+
+	if ValidateActionJWT(keys, accessTokenString, "email_verification") != nil {
+		t.Errorf("Expected access token invalid as action token, but got valid")
+	}
+
+	// expired action token
+	expiredTokenString, err := GenerateActionJWT(keys, "email_verification",
+		map[string]string{}, -time.Minute)
+	if err != nil {
+		t.Errorf("There's an error when generate action JWT => " + err.Error())
+	}
+
+	if ValidateActionJWT(keys, expiredTokenString, "email_verification") != nil {
+		t.Errorf("Expected expired action JWT token invalid, but got valid")
+	}
+}
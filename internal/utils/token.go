@@ -0,0 +1,49 @@
+/*
+Package utils containing utilities function
+
+This package cannot have import from another package except for config package
+*/
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"math/big"
+	"strings"
+)
+
+// GenerateOpaqueToken generate random url-safe token string
+// (e.g. for refresh token)
+func GenerateOpaqueToken() (string, error) {
+	b := make([]byte, 32)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// HashToken hashing opaque token before saved into database
+func HashToken(t string) string {
+	hash := sha256.Sum256([]byte(t))
+	return hex.EncodeToString(hash[:])
+}
+
+// GenerateNumericCode generate random numeric code with the number of digits
+// (e.g. for one-time code sent by SMS)
+func GenerateNumericCode(digits int) (string, error) {
+	var code strings.Builder
+	for i := 0; i < digits; i++ {
+		n, err := rand.Int(rand.Reader, big.NewInt(10))
+		if err != nil {
+			return "", err
+		}
+
+		code.WriteString(n.String())
+	}
+
+	return code.String(), nil
+}
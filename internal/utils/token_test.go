@@ -0,0 +1,57 @@
+/*
+Package utils containing utilities function
+
+This package cannot have import from another package except for config package
+*/
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestGenerateOpaqueTokenAndHashToken integration test
+// GenerateOpaqueToken and HashToken
+func TestGenerateOpaqueTokenAndHashToken(t *testing.T) {
+	// generate tokens
+	token, err := GenerateOpaqueToken()
+	if err != nil {
+		t.Errorf("There's an error when generate opaque token => " + err.Error())
+	}
+
+	anotherToken, err := GenerateOpaqueToken()
+	if err != nil {
+		t.Errorf("There's an error when generate opaque token => " + err.Error())
+	}
+
+	// check result
+	if token == anotherToken {
+		t.Errorf("Expected generated tokens different, but got same token")
+	}
+
+	if HashToken(token) != HashToken(token) {
+		t.Errorf("Expected hash of same token equal, but got different hash")
+	}
+
+	if HashToken(token) == HashToken(anotherToken) {
+		t.Errorf("Expected hash of different token different, but got same hash")
+	}
+
+	if len(HashToken(token)) != 64 {
+		t.Errorf("Expected hash length 64, but got %d", len(HashToken(token)))
+	}
+}
+
+// TestGenerateNumericCode test GenerateNumericCode
+func TestGenerateNumericCode(t *testing.T) {
+	for _, digits := range []int{4, 6, 8} {
+		code, err := GenerateNumericCode(digits)
+		if err != nil {
+			t.Errorf("There's an error when generate numeric code => " + err.Error())
+		}
+
+		if len(code) != digits || strings.Trim(code, "0123456789") != "" {
+			t.Errorf("Expected numeric code with %d digits, but got '%s'", digits, code)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+/*
+Package utils containing utilities function
+
+This package cannot have import from another package except for config package
+*/
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// TOTP parameters (RFC 6238 default), most authenticator apps
+// only support these values
+const (
+	TOTPDigits = 6
+	TOTPPeriod = 30 * time.Second
+
+	// how many time step before and after current time step
+	// still accepted because of clock drift
+	totpSkew = 1
+)
+
+// base32 encoding of totp secret, without padding like most authenticator apps
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateTOTPSecret generate random base32 totp secret (160 bit)
+func GenerateTOTPSecret() (string, error) {
+	b := make([]byte, 20)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+
+	return totpEncoding.EncodeToString(b), nil
+}
+
+// GetTOTPStep get totp time step of a time
+func GetTOTPStep(t time.Time) int64 {
+	return t.Unix() / int64(TOTPPeriod.Seconds())
+}
+
+// GenerateTOTPCode generate totp code of a time step (RFC 6238, HMAC-SHA1)
+func GenerateTOTPCode(secret string, step int64) (string, error) {
+	key, err := totpEncoding.DecodeString(strings.ToUpper(strings.TrimSpace(secret)))
+	if err != nil {
+		return "", err
+	}
+
+	// hmac of time step counter as big endian uint64
+	counter := make([]byte, 8)
+	binary.BigEndian.PutUint64(counter, uint64(step))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(counter)
+	sum := mac.Sum(nil)
+
+	// dynamic truncation (RFC 4226 section 5.3)
+	offset := sum[len(sum)-1] & 0x0f
+	code := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	return fmt.Sprintf("%0*d", TOTPDigits, code%1000000), nil
+}
+
+// ValidateTOTPCode validate totp code at a time,
+// return matched time step or 0 if code not valid
+//
+// Code with time step not after lastStep is not valid,
+// so a code can't be used twice
+func ValidateTOTPCode(secret string, code string, t time.Time, lastStep int64) int64 {
+	code = strings.TrimSpace(code)
+	if len(code) != TOTPDigits {
+		return 0
+	}
+
+	currentStep := GetTOTPStep(t)
+	for step := currentStep - totpSkew; step <= currentStep+totpSkew; step++ {
+		if step <= lastStep {
+			continue
+		}
+
+		expectedCode, err := GenerateTOTPCode(secret, step)
+		if err != nil {
+			return 0
+		}
+
+		if subtle.ConstantTimeCompare([]byte(expectedCode), []byte(code)) == 1 {
+			return step
+		}
+	}
+
+	return 0
+}
+
+// GetTOTPURI get otpauth:// uri of totp secret, used by authenticator app
+// (e.g. shown as QR code)
+func GetTOTPURI(issuer string, accountName string, secret string) string {
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", issuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(TOTPDigits))
+	query.Set("period", fmt.Sprint(int(TOTPPeriod.Seconds())))
+
+	return "otpauth://totp/" + url.PathEscape(issuer+":"+accountName) +
+		"?" + query.Encode()
+}
+
+// GenerateRecoveryCode generate random recovery code
+// (e.g. "abcdefgh-ijklmnop") for login when totp device lost
+func GenerateRecoveryCode() (string, error) {
+	b := make([]byte, 10)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+
+	code := strings.ToLower(totpEncoding.EncodeToString(b))
+	return code[:8] + "-" + code[8:], nil
+}
+
+// NormalizeRecoveryCode normalize recovery code input
+// before hashed, so the code is case and space insensitive
+func NormalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), " ", ""))
+}
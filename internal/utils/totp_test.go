@@ -0,0 +1,146 @@
+/*
+Package utils containing utilities function
+
+This package cannot have import from another package except for config package
+*/
+package utils
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestGenerateTOTPCode test GenerateTOTPCode with RFC 6238 test vectors
+func TestGenerateTOTPCode(t *testing.T) {
+	// base32 of "12345678901234567890"
+	secret := "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+	// create testing table
+	testTable := []struct {
+		Time         time.Time
+		ExpectedCode string
+	}{
+		{
+			Time:         time.Unix(59, 0),
+			ExpectedCode: "287082",
+		},
+		{
+			Time:         time.Unix(1111111109, 0),
+			ExpectedCode: "081804",
+		},
+		{
+			Time:         time.Unix(1234567890, 0),
+			ExpectedCode: "005924",
+		},
+		{
+			Time:         time.Unix(2000000000, 0),
+			ExpectedCode: "279037",
+		},
+	}
+
+	for _, test := range testTable {
+		code, err := GenerateTOTPCode(secret, GetTOTPStep(test.Time))
+		if err != nil {
+			t.Errorf("There's an error when generate totp code => " + err.Error())
+		}
+
+		if code != test.ExpectedCode {
+			t.Errorf("Expected code '%s', but got '%s'", test.ExpectedCode, code)
+		}
+	}
+}
+
+// TestValidateTOTPCode test ValidateTOTPCode
+func TestValidateTOTPCode(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		t.Errorf("There's an error when generate totp secret => " + err.Error())
+	}
+
+	now := time.Now()
+	currentStep := GetTOTPStep(now)
+	code, err := GenerateTOTPCode(secret, currentStep)
+	if err != nil {
+		t.Errorf("There's an error when generate totp code => " + err.Error())
+	}
+	prevCode, err := GenerateTOTPCode(secret, currentStep-1)
+	if err != nil {
+		t.Errorf("There's an error when generate totp code => " + err.Error())
+	}
+	oldCode, err := GenerateTOTPCode(secret, currentStep-5)
+	if err != nil {
+		t.Errorf("There's an error when generate totp code => " + err.Error())
+	}
+
+	// create testing table
+	testTable := []struct {
+		Code         string
+		LastStep     int64
+		ExpectedStep int64
+	}{
+		{
+			Code:         code,
+			LastStep:     0,
+			ExpectedStep: currentStep,
+		},
+		{
+			Code:         prevCode,
+			LastStep:     0,
+			ExpectedStep: currentStep - 1,
+		},
+		{
+			Code:         code,
+			LastStep:     currentStep,
+			ExpectedStep: 0,
+		},
+		{
+			Code:         oldCode,
+			LastStep:     0,
+			ExpectedStep: 0,
+		},
+		{
+			Code:         "12345",
+			LastStep:     0,
+			ExpectedStep: 0,
+		},
+	}
+
+	for _, test := range testTable {
+		step := ValidateTOTPCode(secret, test.Code, now, test.LastStep)
+		if step != test.ExpectedStep {
+			t.Errorf("Expected step %d, but got %d", test.ExpectedStep, step)
+		}
+	}
+}
+
+// TestGetTOTPURI test GetTOTPURI
+func TestGetTOTPURI(t *testing.T) {
+	uri := GetTOTPURI("ecom", "test@gmail.com", "ABCDEF")
+
+	if !strings.HasPrefix(uri, "otpauth://totp/ecom:test@gmail.com?") {
+		t.Errorf("Expected uri with otpauth totp label, but got '%s'", uri)
+	}
+
+	if !strings.Contains(uri, "secret=ABCDEF") || !strings.Contains(uri, "issuer=ecom") {
+		t.Errorf("Expected uri with secret and issuer, but got '%s'", uri)
+	}
+}
+
+// TestGenerateRecoveryCodeAndNormalizeRecoveryCode integration test
+// GenerateRecoveryCode and NormalizeRecoveryCode
+func TestGenerateRecoveryCodeAndNormalizeRecoveryCode(t *testing.T) {
+	code, err := GenerateRecoveryCode()
+	if err != nil {
+		t.Errorf("There's an error when generate recovery code => " + err.Error())
+	}
+
+	if len(code) != 17 || code[8] != '-' {
+		t.Errorf("Expected recovery code format 'xxxxxxxx-xxxxxxxx', but got '%s'", code)
+	}
+
+	if NormalizeRecoveryCode(" "+strings.ToUpper(code)+" ") != code {
+		t.Errorf("Expected normalized recovery code '%s', but got '%s'", code,
+			NormalizeRecoveryCode(" "+strings.ToUpper(code)+" "))
+	}
+}